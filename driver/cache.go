@@ -0,0 +1,207 @@
+package driver
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"sync"
+
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+// ParseCache is a cache of parse results keyed by a hash of the source, the transformation mode, the language,
+// the language options, the range, the metadata and the charset.
+// Least recently used results are evicted first. The cache is safe for concurrent use.
+type ParseCache struct {
+	size int
+
+	mu    sync.Mutex
+	lru   *list.List
+	byKey map[parseKey]*list.Element
+}
+
+type parseKey struct {
+	hash  [sha256.Size]byte
+	mode  Mode
+	lang  string
+	opts  string // see optionsKey
+	rng   Range
+	meta  string // see metadataString
+	cs    string // charset set in the request
+	query string
+	mask  string // see FieldMask.key
+}
+
+type cachedParse struct {
+	key  parseKey
+	lang string          // language detected by the driver
+	opts LanguageOptions // options used by the native driver
+	cs   string          // charset used by the driver
+	ast  nodes.Node
+}
+
+// optionsKey returns a string that uniquely identifies the language options.
+func optionsKey(o LanguageOptions) string {
+	if o.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("%q %t %q", o.Version, o.Strict, o.Dialects)
+}
+
+// NewParseCache creates a cache that holds at most a given number of parse results.
+func NewParseCache(size int) *ParseCache {
+	if size <= 0 {
+		size = 1
+	}
+	return &ParseCache{
+		size:  size,
+		lru:   list.New(),
+		byKey: make(map[parseKey]*list.Element),
+	}
+}
+
+func (c *ParseCache) get(key parseKey) (*cachedParse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.byKey[key]
+	if !ok {
+		return nil, false
+	}
+	c.lru.MoveToFront(e)
+	return e.Value.(*cachedParse), true
+}
+
+func (c *ParseCache) put(p *cachedParse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.byKey[p.key]; ok {
+		// parsed concurrently
+		c.lru.MoveToFront(e)
+		return
+	}
+	c.byKey[p.key] = c.lru.PushFront(p)
+	for c.lru.Len() > c.size {
+		e := c.lru.Back()
+		c.lru.Remove(e)
+		delete(c.byKey, e.Value.(*cachedParse).key)
+	}
+}
+
+// Len returns the number of parse results in the cache.
+func (c *ParseCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// NewCachedDriver returns a driver that remembers successful parse results in the cache and returns them for
+// identical requests without calling the driver. Failed requests are not cached.
+//
+// Each call returns a copy of the cached tree, thus callers are free to modify it. The cache may be shared by
+// multiple drivers, since the language is a part of the key.
+func NewCachedDriver(d DriverModule, c *ParseCache) DriverModule {
+	return WithMiddleware(d, CacheMiddleware(c))
+}
+
+var (
+	_ IncrementalDriver = (*cachedDriver)(nil)
+	_ Pinger            = (*cachedDriver)(nil)
+)
+
+type cachedDriver struct {
+	Driver
+	c *ParseCache
+}
+
+// Parse implements Driver.
+func (d *cachedDriver) Parse(ctx context.Context, src string, opts *ParseOptions) (nodes.Node, error) {
+	if opts == nil {
+		opts = &ParseOptions{}
+	}
+	key := parseKey{
+		hash: sha256.Sum256([]byte(src)), mode: opts.Mode, lang: opts.Language,
+		opts: optionsKey(opts.Options), rng: opts.Range, meta: metadataString(opts.Filename, opts.Metadata),
+		cs: opts.Charset, query: opts.Query, mask: opts.Fields.key(),
+	}
+	if key.mode == 0 {
+		key.mode = ModeDefault
+	}
+	if p, ok := d.c.get(key); ok {
+		if opts.Language == "" {
+			opts.Language = p.lang
+		}
+		opts.Options, opts.Charset = p.opts, p.cs
+		return cloneNode(p.ast), nil
+	}
+	ast, err := d.Driver.Parse(ctx, src, opts)
+	if err != nil {
+		return ast, err
+	}
+	d.c.put(&cachedParse{key: key, lang: opts.Language, opts: opts.Options, cs: opts.Charset, ast: cloneNode(ast)})
+	return ast, nil
+}
+
+// ParseFiles implements Driver. Only the files that are not in the cache are passed to the driver.
+func (d *cachedDriver) ParseFiles(ctx context.Context, mode Mode, files []File) ([]Result, error) {
+	if mode == 0 {
+		mode = ModeDefault
+	}
+	out := make([]Result, len(files))
+	var (
+		keys   []parseKey
+		missed []File
+		idx    []int
+	)
+	for i, f := range files {
+		key := parseKey{
+			hash: sha256.Sum256([]byte(f.Content)), mode: mode, lang: f.Language,
+			opts: optionsKey(f.Options), rng: f.Range, meta: metadataString(f.Filename, f.Metadata),
+			cs: f.Charset, query: f.Query, mask: f.Fields.key(),
+		}
+		if p, ok := d.c.get(key); ok {
+			lang := f.Language
+			if lang == "" {
+				lang = p.lang
+			}
+			out[i] = Result{Source: f.Content, Mode: mode, Language: lang, UAST: cloneNode(p.ast), Options: p.opts, Charset: p.cs}
+			continue
+		}
+		keys = append(keys, key)
+		missed = append(missed, f)
+		idx = append(idx, i)
+	}
+	if len(missed) == 0 {
+		return out, nil
+	}
+	res, err := d.Driver.ParseFiles(ctx, mode, missed)
+	if err != nil {
+		return nil, err
+	} else if len(res) != len(missed) {
+		return nil, ErrDriverFailure.Wrap(fmt.Errorf("expected %d results, got %d", len(missed), len(res)))
+	}
+	for j, r := range res {
+		if r.Err == nil {
+			d.c.put(&cachedParse{key: keys[j], lang: r.Language, opts: r.Options, cs: r.Charset, ast: cloneNode(r.UAST)})
+		}
+		out[idx[j]] = r
+	}
+	return out, nil
+}
+
+// ParseIncremental implements IncrementalDriver. Results are not cached.
+func (d *cachedDriver) ParseIncremental(ctx context.Context, prev Result, edits []TextEdit) (Result, error) {
+	return ParseIncremental(ctx, d.Driver, prev, edits)
+}
+
+// Ping implements Pinger. Drivers that do not implement Pinger are always considered responsive.
+func (d *cachedDriver) Ping(ctx context.Context) error {
+	return pingDriver(ctx, d.Driver)
+}
+
+func cloneNode(n nodes.Node) nodes.Node {
+	if n == nil {
+		return nil
+	}
+	return n.Clone()
+}
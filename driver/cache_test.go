@@ -0,0 +1,109 @@
+package driver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/bblfsh/sdk.v2/driver/manifest"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+// closeNative is the same as echoNative, but fails after it is closed.
+type closeNative struct {
+	echoNative
+	closed *bool
+}
+
+func (n closeNative) Close() error {
+	*n.closed = true
+	return nil
+}
+
+func (n closeNative) Parse(ctx context.Context, src string) (nodes.Node, error) {
+	if *n.closed {
+		return nil, ErrDriverFailure.New()
+	}
+	return n.echoNative.Parse(ctx, src)
+}
+
+func TestDriverParseCache(t *testing.T) {
+	require := require.New(t)
+
+	var closed bool
+	m := &manifest.Manifest{Language: "fixture"}
+	d, err := NewDriverFrom(closeNative{closed: &closed}, m, Transforms{})
+	require.NoError(err)
+
+	c := NewParseCache(1)
+	dr := NewCachedDriver(d, c)
+	err = dr.Start()
+	require.NoError(err)
+
+	ctx := context.Background()
+	ast1, err := dr.Parse(ctx, "foo", nil)
+	require.NoError(err)
+	require.Equal(1, c.Len())
+
+	// the native driver is not called for cached results
+	err = dr.Close()
+	require.NoError(err)
+
+	opts := &ParseOptions{}
+	ast2, err := dr.Parse(ctx, "foo", opts)
+	require.NoError(err)
+	require.Equal(ast1, ast2)
+	require.Equal("fixture", opts.Language)
+
+	_, err = dr.Parse(ctx, "foo", &ParseOptions{Mode: ModeNative})
+	require.True(ErrDriverFailure.Is(err))
+	require.Equal(1, c.Len())
+
+	res, err := dr.ParseFiles(ctx, 0, []File{{Content: "foo"}, {Content: "bar"}})
+	require.NoError(err)
+	require.Len(res, 2)
+	require.NoError(res[0].Err)
+	require.Equal(ast1, res[0].UAST)
+	require.Equal("fixture", res[0].Language)
+	require.True(ErrDriverFailure.Is(res[1].Err))
+}
+
+// optionsNative detects the version of the language, if it is not set.
+type optionsNative struct {
+	echoNative
+}
+
+func (n optionsNative) ParseWithOptions(ctx context.Context, src string, opts LanguageOptions) (nodes.Node, LanguageOptions, error) {
+	if opts.Version == "" {
+		opts.Version = "1"
+	}
+	ast, err := n.Parse(ctx, src)
+	return ast, opts, err
+}
+
+func TestDriverParseFilesCacheOptions(t *testing.T) {
+	require := require.New(t)
+
+	m := &manifest.Manifest{Language: "fixture"}
+	d, err := NewDriverFrom(optionsNative{}, m, Transforms{})
+	require.NoError(err)
+
+	c := NewParseCache(1)
+	dr := NewCachedDriver(d, c)
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		res, err := dr.ParseFiles(ctx, ModeNative, []File{{Content: "foo"}})
+		require.NoError(err)
+		require.Len(res, 1)
+		require.NoError(res[0].Err)
+		require.Equal(LanguageOptions{Version: "1"}, res[0].Options)
+		require.Equal(1, c.Len())
+	}
+
+	// results of ParseFiles are shared with Parse
+	opts := &ParseOptions{Mode: ModeNative}
+	_, err = dr.Parse(ctx, "foo", opts)
+	require.NoError(err)
+	require.Equal(LanguageOptions{Version: "1"}, opts.Options)
+}
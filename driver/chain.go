@@ -0,0 +1,180 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+// Preprocessor rewrites the source before it is parsed by the native driver. It can be used to strip template code
+// from the source, or to extract the code embedded into a file of another language.
+//
+// Positions in the UAST refer to the rewritten source. Thus, preprocessors should keep offsets of the remaining code
+// unchanged when possible, for example by replacing the removed parts with spaces.
+type Preprocessor interface {
+	// Preprocess returns the rewritten source. Errors are the same as in Native.Parse.
+	Preprocess(ctx context.Context, src string) (string, error)
+}
+
+// PreprocessorFunc is a function that implements Preprocessor.
+type PreprocessorFunc func(ctx context.Context, src string) (string, error)
+
+// Preprocess implements Preprocessor.
+func (f PreprocessorFunc) Preprocess(ctx context.Context, src string) (string, error) {
+	return f(ctx, src)
+}
+
+// ExtractFunc returns the source for the next driver in the chain, based on the source and the UAST returned by
+// the previous one.
+type ExtractFunc func(ctx context.Context, src string, ast nodes.Node) (string, error)
+
+// Chain returns a preprocessor that parses the source with a given driver and mode, and extracts the source for
+// the next driver from the resulting tree. For example, an HTML driver can be chained with a JavaScript driver to
+// parse scripts of Vue components.
+//
+// Syntax errors of the driver are returned as is. Partial results are not passed to the extract function.
+func Chain(d Driver, mode Mode, extract ExtractFunc) Preprocessor {
+	return PreprocessorFunc(func(ctx context.Context, src string) (string, error) {
+		ast, err := d.Parse(ctx, src, &ParseOptions{Mode: mode})
+		if err != nil {
+			return "", err
+		}
+		return extract(ctx, src, ast)
+	})
+}
+
+// NewPreprocessedNative returns a native driver that rewrites the source with given preprocessors before passing it
+// to the native driver d. Preprocessors are applied in order. See Transforms.Source.
+//
+// Optional interfaces of the native driver are preserved, except IncrementalNative, since edits cannot be applied
+// to the rewritten source.
+func NewPreprocessedNative(d Native, list ...Preprocessor) Native {
+	if len(list) == 0 {
+		return d
+	}
+	return &preprocessedNative{d: d, list: list}
+}
+
+var (
+	_ BatchNative   = (*preprocessedNative)(nil)
+	_ OptionsNative = (*preprocessedNative)(nil)
+	_ TokensNative  = (*preprocessedNative)(nil)
+	_ CapableNative = (*preprocessedNative)(nil)
+	_ Pinger        = (*preprocessedNative)(nil)
+)
+
+type preprocessedNative struct {
+	d    Native
+	list []Preprocessor
+}
+
+func (d *preprocessedNative) Start() error {
+	return d.d.Start()
+}
+
+func (d *preprocessedNative) Close() error {
+	return d.d.Close()
+}
+
+func (d *preprocessedNative) preprocess(ctx context.Context, src string) (string, error) {
+	for _, p := range d.list {
+		var err error
+		src, err = p.Preprocess(ctx, src)
+		if err != nil {
+			return "", err
+		}
+	}
+	return src, nil
+}
+
+// Parse implements Native.
+func (d *preprocessedNative) Parse(ctx context.Context, src string) (nodes.Node, error) {
+	src, err := d.preprocess(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+	return d.d.Parse(ctx, src)
+}
+
+// ParseWithOptions implements OptionsNative. Options are reset if the native driver does not support them.
+func (d *preprocessedNative) ParseWithOptions(ctx context.Context, src string, opts LanguageOptions) (nodes.Node, LanguageOptions, error) {
+	src, err := d.preprocess(ctx, src)
+	if err != nil {
+		return nil, LanguageOptions{}, err
+	}
+	if o, ok := d.d.(OptionsNative); ok {
+		return o.ParseWithOptions(ctx, src, opts)
+	}
+	ast, err := d.d.Parse(ctx, src)
+	return ast, LanguageOptions{}, err
+}
+
+// ParseBatch implements BatchNative. Files that failed to preprocess are not sent to the native driver.
+func (d *preprocessedNative) ParseBatch(ctx context.Context, srcs []string) ([]NativeResult, error) {
+	out := make([]NativeResult, len(srcs))
+	var (
+		batch []string
+		idx   []int
+	)
+	for i, src := range srcs {
+		src, err := d.preprocess(ctx, src)
+		if err != nil {
+			out[i].Err = err
+			continue
+		}
+		batch = append(batch, src)
+		idx = append(idx, i)
+	}
+	b, ok := d.d.(BatchNative)
+	if !ok || len(batch) == 0 {
+		for j, src := range batch {
+			out[idx[j]].AST, out[idx[j]].Err = d.d.Parse(ctx, src)
+		}
+		return out, nil
+	}
+	res, err := b.ParseBatch(ctx, batch)
+	if err != nil {
+		return nil, err
+	} else if len(res) != len(batch) {
+		return nil, ErrDriverFailure.Wrap(fmt.Errorf("expected %d results, got %d", len(batch), len(res)))
+	}
+	for j, r := range res {
+		out[idx[j]] = r
+	}
+	return out, nil
+}
+
+// ParseTokens implements TokensNative. ErrModeNotSupported is returned if the native driver does not support it.
+func (d *preprocessedNative) ParseTokens(ctx context.Context, src string) (nodes.Array, error) {
+	t, ok := d.d.(TokensNative)
+	if !ok {
+		return nil, ErrModeNotSupported.New()
+	}
+	src, err := d.preprocess(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+	return t.ParseTokens(ctx, src)
+}
+
+// Capabilities implements CapableNative.
+func (d *preprocessedNative) Capabilities() Capabilities {
+	if c, ok := d.d.(CapableNative); ok {
+		return c.Capabilities()
+	}
+	c := Capabilities{Modes: ModeNative}
+	_, c.Options = d.d.(OptionsNative)
+	if _, ok := d.d.(TokensNative); ok {
+		c.Modes |= ModeTokens
+	}
+	return c
+}
+
+// Ping implements Pinger.
+func (d *preprocessedNative) Ping(ctx context.Context) error {
+	if p, ok := d.d.(Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
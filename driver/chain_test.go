@@ -0,0 +1,52 @@
+package driver
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/bblfsh/sdk.v2/driver/manifest"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+func TestDriverPreprocess(t *testing.T) {
+	require := require.New(t)
+
+	// replaces template tags with spaces, keeping the offsets
+	strip := PreprocessorFunc(func(ctx context.Context, src string) (string, error) {
+		return regexp.MustCompile(`{{[^}]*}}`).ReplaceAllStringFunc(src, func(s string) string {
+			return strings.Repeat(" ", len(s))
+		}), nil
+	})
+	m := &manifest.Manifest{Language: "html"}
+	html, err := NewDriverFrom(echoNative{}, m, Transforms{Source: []Preprocessor{strip}})
+	require.NoError(err)
+
+	ctx := context.Background()
+	ast, err := html.Parse(ctx, "a {{b}} c", &ParseOptions{Mode: ModeNative})
+	require.NoError(err)
+	require.Equal(nodes.Object{"src": nodes.String("a       c")}, ast)
+
+	res, err := html.ParseFiles(ctx, ModeNative, []File{{Content: "{{x}}"}, {Content: ""}})
+	require.NoError(err)
+	require.Len(res, 2)
+	require.Equal(nodes.Object{"src": nodes.String("     ")}, res[0].UAST)
+	require.True(ErrSyntax.Is(res[1].Err), "%v", res[1].Err)
+
+	// the second driver parses the output of the first one
+	js, err := NewDriverFrom(echoNative{}, &manifest.Manifest{Language: "js"}, Transforms{
+		Source: []Preprocessor{Chain(html, ModeNative, func(ctx context.Context, src string, ast nodes.Node) (string, error) {
+			s := string(ast.(nodes.Object)["src"].(nodes.String))
+			return strings.TrimSpace(s), nil
+		})},
+	})
+	require.NoError(err)
+	ast, err = js.Parse(ctx, " {{b}} c ", &ParseOptions{Mode: ModeNative})
+	require.NoError(err)
+	require.Equal(nodes.Object{"src": nodes.String("c")}, ast)
+
+	_, err = js.Parse(ctx, "", nil)
+	require.True(ErrSyntax.Is(err), "%v", err)
+}
@@ -0,0 +1,237 @@
+package driver
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/transform"
+	"gopkg.in/src-d/go-errors.v1"
+
+	derrors "gopkg.in/bblfsh/sdk.v2/driver/errors"
+	"gopkg.in/bblfsh/sdk.v2/uast"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+// ErrUnknownCharset is returned if the charset of the source is not supported.
+var ErrUnknownCharset = errors.NewKind("unknown charset: %q")
+
+// Names of charsets returned by DetectCharset.
+const (
+	CharsetUTF8     = "utf-8"
+	CharsetUTF16LE  = "utf-16le"
+	CharsetUTF16BE  = "utf-16be"
+	CharsetShiftJIS = "shift_jis"
+	// CharsetWindows1252 is a superset of Latin-1. It is used for all other 8-bit sources.
+	CharsetWindows1252 = "windows-1252"
+)
+
+// DetectCharset guesses the charset of the source. It recognizes byte order marks, UTF-8, UTF-16 and Shift-JIS,
+// and falls back to Windows-1252 for all other sources.
+func DetectCharset(src string) string {
+	switch {
+	case strings.HasPrefix(src, "\xef\xbb\xbf"):
+		return CharsetUTF8
+	case strings.HasPrefix(src, "\xff\xfe"):
+		return CharsetUTF16LE
+	case strings.HasPrefix(src, "\xfe\xff"):
+		return CharsetUTF16BE
+	}
+	// ASCII text in UTF-16 is a valid UTF-8 as well
+	if cs := detectUTF16(src); cs != "" {
+		return cs
+	} else if utf8.ValidString(src) {
+		return CharsetUTF8
+	} else if isShiftJIS(src) {
+		return CharsetShiftJIS
+	}
+	return CharsetWindows1252
+}
+
+// detectUTF16 checks if the source looks like UTF-16 text without a byte order mark. Most of the code is ASCII, thus
+// every second byte is zero.
+func detectUTF16(src string) string {
+	if len(src) < 2 || len(src)%2 != 0 {
+		return ""
+	}
+	var even, odd int
+	for i := 0; i < len(src); i += 2 {
+		if src[i] == 0 {
+			even++
+		}
+		if src[i+1] == 0 {
+			odd++
+		}
+	}
+	half := len(src) / 2
+	switch {
+	case odd*10 >= half*4 && even*10 < half:
+		return CharsetUTF16LE
+	case even*10 >= half*4 && odd*10 < half:
+		return CharsetUTF16BE
+	}
+	return ""
+}
+
+// isShiftJIS checks if the source is a valid Shift-JIS text with at least one double-byte character.
+func isShiftJIS(src string) bool {
+	double := false
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		switch {
+		case c < 0x80, c >= 0xa1 && c <= 0xdf:
+			// ASCII or half-width katakana
+		case c >= 0x81 && c <= 0x9f, c >= 0xe0 && c <= 0xfc:
+			if i+1 >= len(src) {
+				return false
+			}
+			t := src[i+1]
+			if t < 0x40 || t == 0x7f || t > 0xfc {
+				return false
+			}
+			double = true
+			i++
+		default:
+			return false
+		}
+	}
+	return double
+}
+
+// decodeSource converts the source to UTF-8. If the charset is not set, it is detected. It returns the name of the
+// charset and a map of offsets, which is nil if the source was not changed.
+func decodeSource(src, charset string) (string, string, *offsetMap, error) {
+	if charset == "" {
+		charset = DetectCharset(src)
+	}
+	enc, err := htmlindex.Get(charset)
+	if err != nil {
+		return "", charset, nil, ErrUnknownCharset.New(charset)
+	}
+	if name, err := htmlindex.Name(enc); err == nil {
+		charset = name
+	}
+	if charset == CharsetUTF8 {
+		return src, charset, nil, nil
+	}
+	out, m, err := transcode(src, enc)
+	if err != nil {
+		return "", charset, nil, err
+	}
+	return out, charset, m, nil
+}
+
+// transcode converts the source to UTF-8 one character at a time to record offsets of each character.
+func transcode(src string, enc encoding.Encoding) (string, *offsetMap, error) {
+	var (
+		dec = enc.NewDecoder()
+		in  = []byte(src)
+		buf strings.Builder
+		tmp [utf8.UTFMax]byte
+		m   offsetMap
+	)
+	buf.Grow(len(src))
+	for off := 0; off < len(in); {
+		var (
+			nDst, nSrc int
+			err        error
+		)
+		// grow the buffer until a single character fits into it
+		for n := 1; n <= len(tmp); n++ {
+			nDst, nSrc, err = dec.Transform(tmp[:n], in[off:], true)
+			if err != transform.ErrShortDst || nDst != 0 || nSrc != 0 {
+				break
+			}
+		}
+		if err != nil && err != transform.ErrShortDst {
+			return "", nil, err
+		} else if nDst == 0 && nSrc == 0 {
+			return "", nil, transform.ErrShortDst
+		}
+		m.add(buf.Len()+nDst, off+nSrc)
+		buf.Write(tmp[:nDst])
+		off += nSrc
+	}
+	return buf.String(), &m, nil
+}
+
+// offsetMap converts byte offsets of the transcoded source to offsets of the original source.
+type offsetMap struct {
+	// points where the difference between the offsets changes, sorted by the offset in the transcoded source
+	dst, src []int
+}
+
+// add records that the part of the transcoded source up to a given offset corresponds to the part of the original
+// source up to the other offset.
+func (m *offsetMap) add(dst, src int) {
+	delta := 0
+	if n := len(m.dst); n != 0 {
+		delta = m.src[n-1] - m.dst[n-1]
+	}
+	if src-dst == delta {
+		return
+	}
+	m.dst = append(m.dst, dst)
+	m.src = append(m.src, src)
+}
+
+// offset returns the offset in the original source for a given offset in the transcoded source.
+func (m *offsetMap) offset(off int) int {
+	i := sort.SearchInts(m.dst, off+1) - 1
+	if i < 0 {
+		return off
+	}
+	return off + m.src[i] - m.dst[i]
+}
+
+// position converts the offset and the column of the position. Lines are the same in both sources.
+func (m *offsetMap) position(p uast.Position) uast.Position {
+	if !p.HasOffset() {
+		return p
+	}
+	off := int(p.Offset)
+	p.Offset = uint32(m.offset(off))
+	if p.Col > 0 && int(p.Col) <= off+1 {
+		line := off - int(p.Col-1)
+		p.Col = p.Offset - uint32(m.offset(line)) + 1
+	}
+	return p
+}
+
+// positions converts all positions in the tree to offsets of the original source.
+func (m *offsetMap) positions(n nodes.Node) nodes.Node {
+	if m == nil || n == nil {
+		return n
+	}
+	out, _ := nodes.Apply(n, func(n nodes.Node) (nodes.Node, bool) {
+		obj, ok := n.(nodes.Object)
+		if !ok || uast.TypeOf(obj) != uast.TypePosition {
+			return n, false
+		}
+		p := uast.AsPosition(obj)
+		if p == nil {
+			return n, false
+		}
+		return m.position(*p).ToObject(), true
+	})
+	return out
+}
+
+// errors converts the positions of diagnostics in the error to offsets of the original source.
+func (m *offsetMap) errors(err error) {
+	if m == nil || !derrors.HasDiagnostics(err) {
+		return
+	}
+	for _, d := range Diagnostics(err) {
+		if d.Start != nil {
+			p := m.position(*d.Start)
+			d.Start = &p
+		}
+		if d.End != nil {
+			p := m.position(*d.End)
+			d.End = &p
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package driver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/bblfsh/sdk.v2/driver/manifest"
+	"gopkg.in/bblfsh/sdk.v2/uast"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+func TestDriverParseCharset(t *testing.T) {
+	require := require.New(t)
+
+	m := &manifest.Manifest{Language: "fixture"}
+	d, err := NewDriverFrom(recoverNative{}, m, Transforms{})
+	require.NoError(err)
+
+	// returns the token of the last word, the offset and the column of its start
+	last := func(ast nodes.Node) (string, uint32, uint32) {
+		arr := ast.(nodes.Object)["body"].(nodes.Array)
+		w := arr[len(arr)-1].(nodes.Object)
+		start := uast.PositionsOf(w).Start()
+		return uast.TokenOf(w), start.Offset, start.Col
+	}
+
+	ctx := context.Background()
+	for _, c := range []struct {
+		name, src, charset, token string
+		off                       uint32
+	}{
+		{name: "utf-8", src: "日本 word", charset: CharsetUTF8, token: "word", off: 7},
+		{name: "latin-1", src: "caf\xe9 word", charset: CharsetWindows1252, token: "word", off: 5},
+		{name: "shift-jis", src: "\x93\xfa\x96\x7b word", charset: CharsetShiftJIS, token: "word", off: 5},
+		{name: "utf-16 bom", src: "\xff\xfea\x00 \x00b\x00", charset: CharsetUTF16LE, token: "b", off: 6},
+		{name: "utf-16", src: "a\x00 \x00b\x00", charset: CharsetUTF16LE, token: "b", off: 4},
+	} {
+		require.Equal(c.charset, DetectCharset(c.src), c.name)
+
+		opts := &ParseOptions{Mode: ModeNative}
+		ast, err := d.Parse(ctx, c.src, opts)
+		require.NoError(err, c.name)
+		require.Equal(c.charset, opts.Charset, c.name)
+
+		tok, off, col := last(ast)
+		require.Equal(c.token, tok, c.name)
+		require.Equal(c.off, off, c.name)
+		require.Equal(c.off+1, col, c.name)
+	}
+
+	// the charset is set explicitly, thus it is not detected as UTF-8
+	opts := &ParseOptions{Mode: ModeSemantic, Charset: "iso-8859-1"}
+	_, err = d.Parse(ctx, "é word ?x", opts)
+	require.True(ErrSyntax.Is(err), "%v", err)
+	require.Equal(CharsetWindows1252, opts.Charset)
+	diags := Diagnostics(err)
+	require.Len(diags, 1)
+	require.Equal(uint32(8), diags[0].Start.Offset)
+
+	_, err = d.Parse(ctx, "a", &ParseOptions{Charset: "unknown"})
+	require.True(ErrUnknownCharset.Is(err), "%v", err)
+}
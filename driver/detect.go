@@ -0,0 +1,176 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+
+	"gopkg.in/src-d/go-errors.v1"
+
+	"gopkg.in/bblfsh/sdk.v2/driver/manifest"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+var (
+	// ErrUnknownLanguage is returned by Detector if the language of the file cannot be detected.
+	ErrUnknownLanguage = errors.NewKind("cannot detect the language of the file: %q")
+
+	// ErrUnsupportedLanguage is returned by Detector if none of the drivers support the language set in the request.
+	ErrUnsupportedLanguage = errors.NewKind("unsupported language: %q")
+)
+
+var _ IncrementalDriver = (*Detector)(nil)
+
+// Detector routes requests to one of the registered drivers, based on the language set in the request, or the
+// language detected from the filename and the content. Languages are detected using the file associations declared
+// in driver manifests. See manifest.Matcher.
+//
+// Detector does not start or stop the drivers.
+type Detector struct {
+	byLang map[string]DriverModule
+	m      *manifest.Matcher
+}
+
+// NewDetector creates a detector for a given set of drivers. Each driver must handle a different language.
+func NewDetector(drivers ...DriverModule) (*Detector, error) {
+	d := &Detector{byLang: make(map[string]DriverModule, len(drivers))}
+	list := make([]manifest.Manifest, 0, len(drivers))
+	for _, dr := range drivers {
+		m, err := dr.Manifest()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := d.byLang[m.Language]; ok {
+			return nil, fmt.Errorf("multiple drivers for language %q", m.Language)
+		}
+		d.byLang[m.Language] = dr
+		list = append(list, m)
+	}
+	var err error
+	d.m, err = manifest.NewMatcher(list)
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Detect returns the language of a file with a given name and content. The content is optional.
+func (d *Detector) Detect(filename string, content []byte) (string, bool) {
+	m, ok := d.m.Match(filename, content)
+	if !ok {
+		return "", false
+	}
+	return m.Language, true
+}
+
+// DriverFor returns a driver registered for the language.
+func (d *Detector) DriverFor(lang string) (DriverModule, bool) {
+	dr, ok := d.byLang[lang]
+	return dr, ok
+}
+
+// driverFor returns a driver for the file, detecting the language if it is not set.
+func (d *Detector) driverFor(lang, filename, src string) (DriverModule, string, error) {
+	if lang == "" {
+		var ok bool
+		lang, ok = d.Detect(filename, []byte(src))
+		if !ok {
+			return nil, "", ErrUnknownLanguage.New(filename)
+		}
+	}
+	dr, ok := d.byLang[lang]
+	if !ok {
+		return nil, "", ErrUnsupportedLanguage.New(lang)
+	}
+	return dr, lang, nil
+}
+
+// Parse implements Driver. The language is set in options if it was detected.
+func (d *Detector) Parse(ctx context.Context, src string, opts *ParseOptions) (nodes.Node, error) {
+	if opts == nil {
+		opts = &ParseOptions{}
+	}
+	dr, lang, err := d.driverFor(opts.Language, opts.Filename, src)
+	if err != nil {
+		return nil, err
+	}
+	opts.Language = lang
+	return dr.Parse(ctx, src, opts)
+}
+
+// ParseFiles implements Driver. Files are grouped by language and each group is parsed with a single call.
+func (d *Detector) ParseFiles(ctx context.Context, mode Mode, files []File) ([]Result, error) {
+	out := make([]Result, len(files))
+	type group struct {
+		files []File
+		idx   []int
+	}
+	var (
+		order  []DriverModule
+		groups = make(map[DriverModule]*group)
+	)
+	for i, f := range files {
+		dr, lang, err := d.driverFor(f.Language, f.Filename, f.Content)
+		if err != nil {
+			out[i] = Result{Source: f.Content, Mode: mode, Language: f.Language, Err: err}
+			continue
+		}
+		g := groups[dr]
+		if g == nil {
+			g = &group{}
+			groups[dr] = g
+			order = append(order, dr)
+		}
+		f.Language = lang
+		g.files = append(g.files, f)
+		g.idx = append(g.idx, i)
+	}
+	for _, dr := range order {
+		g := groups[dr]
+		res, err := dr.ParseFiles(ctx, mode, g.files)
+		if err != nil {
+			return nil, err
+		} else if len(res) != len(g.files) {
+			return nil, ErrDriverFailure.Wrap(fmt.Errorf("expected %d results, got %d", len(g.files), len(res)))
+		}
+		for j, r := range res {
+			out[g.idx[j]] = r
+		}
+	}
+	return out, nil
+}
+
+// ParseIncremental implements IncrementalDriver. The driver is selected by the language of the previous result.
+func (d *Detector) ParseIncremental(ctx context.Context, prev Result, edits []TextEdit) (Result, error) {
+	dr, ok := d.byLang[prev.Language]
+	if !ok {
+		return Result{}, ErrUnsupportedLanguage.New(prev.Language)
+	}
+	return ParseIncremental(ctx, dr, prev, edits)
+}
+
+// Capabilities implements Driver. Only the capabilities supported by all drivers are reported. Encodings and
+// language versions are specific to each driver, thus they are not reported. See DriverFor.
+func (d *Detector) Capabilities(ctx context.Context) (Capabilities, error) {
+	var (
+		out   Capabilities
+		first = true
+	)
+	for _, dr := range d.byLang {
+		c, err := dr.Capabilities(ctx)
+		if err != nil {
+			return Capabilities{}, err
+		}
+		if first {
+			out = Capabilities{Modes: c.Modes, Options: c.Options, Incremental: c.Incremental, MaxFileSize: c.MaxFileSize}
+			first = false
+			continue
+		}
+		out.Modes &= c.Modes
+		out.Options = out.Options && c.Options
+		out.Incremental = out.Incremental && c.Incremental
+		if c.MaxFileSize != 0 && (out.MaxFileSize == 0 || c.MaxFileSize < out.MaxFileSize) {
+			out.MaxFileSize = c.MaxFileSize
+		}
+	}
+	return out, nil
+}
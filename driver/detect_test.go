@@ -0,0 +1,60 @@
+package driver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/bblfsh/sdk.v2/driver/manifest"
+)
+
+func TestDetector(t *testing.T) {
+	require := require.New(t)
+
+	newDriver := func(lang string, files *manifest.Files) DriverModule {
+		m := &manifest.Manifest{Language: lang, Status: manifest.Beta, Files: files}
+		d, err := NewDriverFrom(treeNative{}, m, Transforms{})
+		require.NoError(err)
+		return d
+	}
+	det, err := NewDetector(
+		newDriver("foo", &manifest.Files{Extensions: []string{".foo"}}),
+		newDriver("bar", &manifest.Files{Extensions: []string{".bar"}, Shebangs: []string{"bar"}}),
+	)
+	require.NoError(err)
+
+	lang, ok := det.Detect("a/b.bar", nil)
+	require.True(ok)
+	require.Equal("bar", lang)
+
+	ctx := context.Background()
+	opts := &ParseOptions{Mode: ModeNative, Filename: "x.foo"}
+	_, err = det.Parse(ctx, "a b", opts)
+	require.NoError(err)
+	require.Equal("foo", opts.Language)
+
+	_, err = det.Parse(ctx, "a b", &ParseOptions{Filename: "x.baz"})
+	require.True(ErrUnknownLanguage.Is(err), "%v", err)
+	_, err = det.Parse(ctx, "a b", &ParseOptions{Language: "baz"})
+	require.True(ErrUnsupportedLanguage.Is(err), "%v", err)
+
+	res, err := det.ParseFiles(ctx, ModeNative, []File{
+		{Content: "#!/usr/bin/env bar\n", Filename: "run"},
+		{Content: "a", Filename: "x.foo"},
+		{Content: "a", Filename: "README"},
+		{Content: "a", Language: "bar"},
+	})
+	require.NoError(err)
+	require.Len(res, 4)
+	for i, exp := range []string{"bar", "foo", "", "bar"} {
+		require.Equal(exp, res[i].Language, "%d", i)
+	}
+	require.NoError(res[0].Err)
+	require.NoError(res[1].Err)
+	require.True(ErrUnknownLanguage.Is(res[2].Err), "%v", res[2].Err)
+	require.NoError(res[3].Err)
+
+	c, err := det.Capabilities(ctx)
+	require.NoError(err)
+	require.True(c.Supports(ModeSemantic))
+}
@@ -4,6 +4,7 @@ package driver
 import (
 	"context"
 	"fmt"
+	"strconv"
 
 	"gopkg.in/src-d/go-errors.v1"
 
@@ -23,8 +24,16 @@ var (
 	// ErrTransformFailure is returned if one of the UAST transformations fails.
 	ErrTransformFailure = errors.NewKind("transform failed")
 
+	// ErrPartialTransform is returned if some of the nodes failed to transform on stages with a lenient failure
+	// policy, or if the transformation reported warnings. The resulting UAST is returned as well.
+	ErrPartialTransform = errors.NewKind("partial transform")
+
 	// ErrModeNotSupported is returned if a UAST transformation mode is not supported by the driver.
 	ErrModeNotSupported = errors.NewKind("transform mode not supported")
+
+	// ErrResourceExhausted is returned if the tree exceeds the memory budget of the pipeline.
+	// See Pipeline.SetMemoryLimit.
+	ErrResourceExhausted = errors.NewKind("resource exhausted")
 )
 
 // ErrMulti joins multiple errors.
@@ -35,6 +44,14 @@ func JoinErrors(errs []error) error {
 	return derrors.Join(errs)
 }
 
+// Diagnostic is a structured syntax error with an optional position in the source.
+type Diagnostic = derrors.Diagnostic
+
+// Diagnostics returns structured diagnostics for all errors joined in err.
+func Diagnostics(err error) []*Diagnostic {
+	return derrors.Diagnostics(err)
+}
+
 type Mode int
 
 const (
@@ -42,6 +59,8 @@ const (
 	ModePreprocessed
 	ModeAnnotated
 	ModeSemantic
+	// ModeTokens returns an ordered list of tokens with their positions instead of the tree. See TokensNative.
+	ModeTokens
 )
 
 const ModeDefault = ModeSemantic
@@ -55,10 +74,29 @@ func ParseMode(mode string) (Mode, error) {
 		return ModeAnnotated, nil
 	case "semantic":
 		return ModeSemantic, nil
+	case "tokens":
+		return ModeTokens, nil
 	}
 	return 0, fmt.Errorf("unsupported mode: %q", mode)
 }
 
+// String returns the name of the mode, as accepted by ParseMode.
+func (m Mode) String() string {
+	switch m {
+	case ModeNative:
+		return "native"
+	case ModePreprocessed:
+		return "preprocessed"
+	case ModeAnnotated:
+		return "annotated"
+	case ModeSemantic:
+		return "semantic"
+	case ModeTokens:
+		return "tokens"
+	}
+	return strconv.Itoa(int(m))
+}
+
 // Module is an interface for a generic module instance.
 type Module interface {
 	Start() error
@@ -69,6 +107,76 @@ type ParseOptions struct {
 	Mode     Mode
 	Language string
 	Filename string
+	// Options are passed to the native driver. They are updated during the Parse call to the options used by
+	// the native driver; the options are reset if the native driver does not support them.
+	Options LanguageOptions
+	// Range restricts the UAST to nodes overlapping a given part of the source. See FilterRange.
+	Range Range
+	// Metadata is passed to transformers together with the filename. See Metadata.
+	Metadata Metadata
+	// Charset of the source, for example "shift_jis" or "utf-16le". It is detected if not set, see DetectCharset.
+	// Sources in other charsets are converted to UTF-8 before parsing, and positions in the UAST are converted back
+	// to byte offsets of the original source. It is updated during the Parse call to the charset that was used.
+	Charset string
+	// Query is an XPath query for nodes to return, instead of the whole tree. See FilterQuery.
+	Query string
+	// Fields selects fields of UAST nodes to return. See FilterFields.
+	Fields FieldMask
+}
+
+// LanguageOptions instruct the native driver how to parse the source, instead of letting it guess.
+type LanguageOptions struct {
+	// Version of the language, for example "3.7" or "ES2018".
+	Version string `json:"version,omitempty"`
+	// Dialects is a list of language extensions enabled for the source, for example "jsx" or "typescript".
+	Dialects []string `json:"dialects,omitempty"`
+	// Strict enables the strict mode of the language.
+	Strict bool `json:"strict,omitempty"`
+}
+
+// IsZero checks if no options are set.
+func (o LanguageOptions) IsZero() bool {
+	return o.Version == "" && len(o.Dialects) == 0 && !o.Strict
+}
+
+// File is a source file parsed by ParseFiles.
+type File struct {
+	// Content is the source code of the file.
+	Content string
+	// Language of the file. If it is not set, the language is detected by the driver.
+	Language string
+	// Filename can be set optionally to assist language detection.
+	Filename string
+	// Options are passed to the native driver. See ParseOptions.
+	Options LanguageOptions
+	// Range restricts the UAST of the file. See ParseOptions.
+	Range Range
+	// Metadata is passed to transformers. See ParseOptions.
+	Metadata Metadata
+	// Charset of the source. It is detected if not set. See ParseOptions.
+	Charset string
+	// Query selects nodes of the file to return. See ParseOptions.
+	Query string
+	// Fields selects fields of UAST nodes of the file. See ParseOptions.
+	Fields FieldMask
+}
+
+// Result is a result of parsing a single file with ParseFiles.
+type Result struct {
+	// Source is the content of the file. It is required to parse the file incrementally. See ParseIncremental.
+	Source string
+	// Mode of the transformation used for the UAST.
+	Mode Mode
+	// Language of the file, either the one set in File, or detected by the driver.
+	Language string
+	// UAST is the resulting tree. It may be set together with an error, the same way as in Parse.
+	UAST nodes.Node
+	// Err is an error returned for the file. Errors are the same as the ones returned by Parse.
+	Err error
+	// Options used by the native driver to parse the file. See ParseOptions.
+	Options LanguageOptions
+	// Charset of the source, either the one set in File, or detected by the driver.
+	Charset string
 }
 
 // Driver is an interface for a language driver that returns UAST.
@@ -87,6 +195,45 @@ type Driver interface {
 	// Native driver failures are indicated by ErrDriverFailure and UAST transformation are indicated by ErrTransformFailure.
 	// All other errors indicate a protocol or server failure.
 	Parse(ctx context.Context, src string, opts *ParseOptions) (nodes.Node, error)
+
+	// ParseFiles parses multiple files with a given transformation mode, amortizing the cost of a single call.
+	//
+	// Results are returned in the same order as the files. Errors of individual files are returned in the results,
+	// while the returned error indicates that the whole request failed.
+	ParseFiles(ctx context.Context, mode Mode, files []File) ([]Result, error)
+
+	// Capabilities returns optional features supported by the driver.
+	Capabilities(ctx context.Context) (Capabilities, error)
+}
+
+// Capabilities describes optional features supported by a driver.
+type Capabilities struct {
+	// Modes is a set of supported transformation modes.
+	Modes Mode
+	// Encodings is a list of encodings used to send the source to the native driver.
+	Encodings []string
+	// Options is set if the driver accepts language options. See LanguageOptions.
+	Options bool
+	// Incremental is set if the driver reuses previous results when parsing edited files. See IncrementalDriver.
+	Incremental bool
+	// MaxFileSize is the maximal size of a source file in bytes. Zero means that the size is not limited.
+	MaxFileSize int
+	// Versions lists syntax features supported for each language version, as declared in the manifest.
+	Versions manifest.LanguageVersions
+
+	// Compressors is a list of gRPC compressors supported by the server. Only set by protocol clients.
+	Compressors []string
+	// CompressionRequired is set if the server rejects requests that are not compressed. Only set by protocol
+	// clients.
+	CompressionRequired bool
+}
+
+// Supports checks if the driver supports a given transformation mode.
+func (c Capabilities) Supports(m Mode) bool {
+	if m == 0 {
+		m = ModeDefault
+	}
+	return c.Modes&m != 0
 }
 
 // DriverModule is an interface for a driver instance.
@@ -96,6 +243,13 @@ type DriverModule interface {
 	Manifest() (manifest.Manifest, error)
 }
 
+// Pinger is an optional interface for drivers and native drivers that can check if they are responsive
+// without parsing a file.
+type Pinger interface {
+	// Ping returns an error if the driver cannot accept requests.
+	Ping(ctx context.Context) error
+}
+
 // Native is a base interface of a language driver that returns a native AST.
 type Native interface {
 	Module
@@ -103,3 +257,43 @@ type Native interface {
 	// All errors are considered ErrSyntax, unless they are wrapped into ErrDriverFailure.
 	Parse(ctx context.Context, src string) (nodes.Node, error)
 }
+
+// NativeResult is a result of parsing a single file by BatchNative.
+type NativeResult struct {
+	// AST is the native AST of the file.
+	AST nodes.Node
+	// Err is an error returned for the file, the same way as in Native.Parse.
+	Err error
+}
+
+// BatchNative is an optional interface for native drivers that can parse multiple files in a single request.
+type BatchNative interface {
+	Native
+	// ParseBatch parses multiple files and returns results in the same order. The returned error indicates
+	// that the whole batch failed.
+	ParseBatch(ctx context.Context, srcs []string) ([]NativeResult, error)
+}
+
+// OptionsNative is an optional interface for native drivers that accept language options.
+type OptionsNative interface {
+	Native
+	// ParseWithOptions is similar to Parse, but uses the given language options. It returns the options that were
+	// actually used, which may include the ones detected by the native driver.
+	ParseWithOptions(ctx context.Context, src string, opts LanguageOptions) (nodes.Node, LanguageOptions, error)
+}
+
+// TokensNative is an optional interface for native drivers that can return tokens without building the tree.
+type TokensNative interface {
+	Native
+	// ParseTokens returns tokens of the source, ordered by their position. Each token is an object with uast.KeyToken
+	// and uast.KeyPos fields. Errors are the same as in Parse. ErrModeNotSupported is returned if the native
+	// driver cannot return tokens for this source; in this case tokens are extracted from the annotated UAST.
+	ParseTokens(ctx context.Context, src string) (nodes.Array, error)
+}
+
+// CapableNative is an optional interface for native drivers that report their capabilities. Only the capabilities
+// of the native driver itself are reported, thus Modes should only include ModeNative and ModeTokens.
+type CapableNative interface {
+	Native
+	Capabilities() Capabilities
+}
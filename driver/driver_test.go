@@ -0,0 +1,76 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"gopkg.in/bblfsh/sdk.v2/uast"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+// treeNative returns two tokens with positions, in the reverse order.
+type treeNative struct{}
+
+func (treeNative) Start() error { return nil }
+func (treeNative) Close() error { return nil }
+
+func (treeNative) Parse(ctx context.Context, src string) (nodes.Node, error) {
+	tok := func(s string, off uint32) nodes.Object {
+		return nodes.Object{
+			uast.KeyToken: nodes.String(s),
+			uast.KeyPos:   uast.Positions{uast.KeyStart: {Offset: off, Line: 1, Col: off + 1}}.ToObject(),
+		}
+	}
+	return nodes.Object{
+		"body": nodes.Array{tok("b", 2), tok("a", 0)},
+	}, nil
+}
+
+// echoNative returns the source it received.
+type echoNative struct{}
+
+func (echoNative) Start() error { return nil }
+func (echoNative) Close() error { return nil }
+
+func (echoNative) Parse(ctx context.Context, src string) (nodes.Node, error) {
+	if src == "" {
+		return nil, errors.New("empty source")
+	}
+	return nodes.Object{"src": nodes.String(src)}, nil
+}
+
+// recoverNative returns words of the source as nodes. Words that start with "?" are reported as syntax errors.
+type recoverNative struct{}
+
+func (recoverNative) Start() error { return nil }
+func (recoverNative) Close() error { return nil }
+
+func (recoverNative) Parse(ctx context.Context, src string) (nodes.Node, error) {
+	var (
+		arr  nodes.Array
+		errs []error
+		off  int
+	)
+	for _, w := range strings.Fields(src) {
+		off += strings.Index(src[off:], w)
+		start := uast.Position{Offset: uint32(off), Line: 1, Col: uint32(off + 1)}
+		end := uast.Position{Offset: uint32(off + len(w)), Line: 1, Col: uint32(off + len(w) + 1)}
+		typ := "Word"
+		if strings.HasPrefix(w, "?") {
+			typ = "ERROR"
+			errs = append(errs, &Diagnostic{Message: "unexpected " + w, Start: &start})
+		}
+		arr = append(arr, nodes.Object{
+			uast.KeyType:  nodes.String(typ),
+			uast.KeyToken: nodes.String(w),
+			uast.KeyPos:   uast.Positions{uast.KeyStart: start, uast.KeyEnd: end}.ToObject(),
+		})
+		off += len(w)
+	}
+	ast := nodes.Object{uast.KeyType: nodes.String("File"), "body": arr}
+	if len(errs) != 0 {
+		return ast, JoinErrors(errs)
+	}
+	return ast, nil
+}
@@ -0,0 +1,110 @@
+package errors
+
+import (
+	"fmt"
+
+	"gopkg.in/src-d/go-errors.v1"
+
+	"gopkg.in/bblfsh/sdk.v2/uast"
+)
+
+// Severity of a diagnostic.
+type Severity int
+
+const (
+	SeverityError Severity = iota
+	SeverityWarning
+	SeverityInfo
+)
+
+var severityNames = []string{
+	SeverityError:   "error",
+	SeverityWarning: "warning",
+	SeverityInfo:    "info",
+}
+
+func (s Severity) String() string {
+	if s < 0 || int(s) >= len(severityNames) {
+		return fmt.Sprintf("Severity(%d)", int(s))
+	}
+	return severityNames[s]
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (s Severity) MarshalText() ([]byte, error) {
+	if s < 0 || int(s) >= len(severityNames) {
+		return nil, fmt.Errorf("unknown severity: %d", int(s))
+	}
+	return []byte(severityNames[s]), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (s *Severity) UnmarshalText(data []byte) error {
+	for i, name := range severityNames {
+		if name == string(data) {
+			*s = Severity(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown severity: %q", string(data))
+}
+
+// Diagnostic is a structured error reported by the parser, with an optional position of the problem in the source.
+// Native drivers may return diagnostics instead of plain errors.
+type Diagnostic struct {
+	// Message is a human-readable description of the problem.
+	Message string `json:"message"`
+	// Severity of the problem.
+	Severity Severity `json:"severity,omitempty"`
+	// Code is a parser-specific error code.
+	Code string `json:"code,omitempty"`
+	// Start and End are positions of the problem in the source. End is exclusive. Both are optional.
+	Start *uast.Position `json:"start,omitempty"`
+	End   *uast.Position `json:"end,omitempty"`
+}
+
+// Error implements error. It returns the message of the diagnostic.
+func (d *Diagnostic) Error() string {
+	return d.Message
+}
+
+// Diagnostics returns diagnostics for all errors joined in err. Errors joined with ErrMulti are unwrapped, as well as
+// diagnostics wrapped into error kinds. Other errors are returned as diagnostics with SeverityError.
+func Diagnostics(err error) []*Diagnostic {
+	switch e := err.(type) {
+	case nil:
+		return nil
+	case *Diagnostic:
+		return []*Diagnostic{e}
+	case *ErrMulti:
+		var out []*Diagnostic
+		for _, e := range e.Errors {
+			out = append(out, Diagnostics(e)...)
+		}
+		return out
+	case *errors.Error:
+		if cause := e.Cause(); HasDiagnostics(cause) {
+			return Diagnostics(cause)
+		}
+	}
+	return []*Diagnostic{{Message: err.Error()}}
+}
+
+// HasDiagnostics checks if any of the errors joined in err are diagnostics. See Diagnostics.
+func HasDiagnostics(err error) bool {
+	switch e := err.(type) {
+	case *Diagnostic:
+		return true
+	case *ErrMulti:
+		for _, e := range e.Errors {
+			if HasDiagnostics(e) {
+				return true
+			}
+		}
+	case *errors.Error:
+		if cause := e.Cause(); cause != nil {
+			return HasDiagnostics(cause)
+		}
+	}
+	return false
+}
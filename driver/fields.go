@@ -0,0 +1,104 @@
+package driver
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+// FieldMask selects fields of UAST nodes returned by Parse, for clients that only need a part of each node.
+// For example, excluding uast.KeyPos drops positions, and including only uast.KeyType and uast.KeyToken keeps
+// the shape of the tree with types and tokens of all nodes.
+type FieldMask struct {
+	// Include is a list of fields to keep in each node. Meta fields (the ones starting with "@") and fields with
+	// primitive values are dropped if they are not included; included meta fields are returned as-is. Other fields
+	// hold child nodes and are always kept, to preserve the structure of the tree. All fields are kept if the list
+	// is empty.
+	Include []string `json:"include,omitempty"`
+	// Exclude is a list of fields to drop from each node, including the fields with child nodes.
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+// IsZero checks if the mask is not set.
+func (m FieldMask) IsZero() bool {
+	return len(m.Include) == 0 && len(m.Exclude) == 0
+}
+
+// key returns a string that uniquely identifies the mask.
+func (m FieldMask) key() string {
+	if m.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("%q %q", m.Include, m.Exclude)
+}
+
+// FilterFields returns a copy of the tree with only the fields selected by the mask.
+func FilterFields(n nodes.Node, m FieldMask) nodes.Node {
+	if m.IsZero() {
+		return n
+	}
+	f := fieldFilter{exclude: make(map[string]struct{}, len(m.Exclude))}
+	for _, k := range m.Exclude {
+		f.exclude[k] = struct{}{}
+	}
+	if len(m.Include) != 0 {
+		f.include = make(map[string]struct{}, len(m.Include))
+		for _, k := range m.Include {
+			f.include[k] = struct{}{}
+		}
+	}
+	return f.filter(n)
+}
+
+type fieldFilter struct {
+	include map[string]struct{} // nil if all fields are included
+	exclude map[string]struct{}
+}
+
+// keep reports if the field should be kept, and if its value should be filtered as well.
+func (f fieldFilter) keep(k string, v nodes.Node) (keep, filter bool) {
+	if _, ok := f.exclude[k]; ok {
+		return false, false
+	}
+	meta := strings.HasPrefix(k, "@")
+	if f.include == nil {
+		return true, !meta
+	}
+	if _, ok := f.include[k]; ok {
+		// included meta fields such as positions are returned as-is
+		return true, !meta
+	}
+	if meta {
+		return false, false
+	}
+	switch v.(type) {
+	case nodes.Object, nodes.Array:
+		return true, true
+	}
+	return false, false
+}
+
+func (f fieldFilter) filter(n nodes.Node) nodes.Node {
+	switch n := n.(type) {
+	case nodes.Object:
+		out := make(nodes.Object, len(n))
+		for k, v := range n {
+			keep, filter := f.keep(k, v)
+			if !keep {
+				continue
+			} else if filter {
+				v = f.filter(v)
+			}
+			out[k] = v
+		}
+		return out
+	case nodes.Array:
+		out := make(nodes.Array, 0, len(n))
+		for _, v := range n {
+			out = append(out, f.filter(v))
+		}
+		return out
+	}
+	return n
+}
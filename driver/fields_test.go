@@ -0,0 +1,48 @@
+package driver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/bblfsh/sdk.v2/driver/manifest"
+	"gopkg.in/bblfsh/sdk.v2/uast"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+func TestDriverParseFields(t *testing.T) {
+	require := require.New(t)
+
+	m := &manifest.Manifest{Language: "fixture"}
+	d, err := NewDriverFrom(treeNative{}, m, Transforms{})
+	require.NoError(err)
+
+	ctx := context.Background()
+	ast, err := d.Parse(ctx, "a b", &ParseOptions{
+		Mode: ModeNative, Fields: FieldMask{Exclude: []string{uast.KeyPos}},
+	})
+	require.NoError(err)
+	require.Equal(nodes.Object{"body": nodes.Array{
+		nodes.Object{uast.KeyToken: nodes.String("b")},
+		nodes.Object{uast.KeyToken: nodes.String("a")},
+	}}, ast)
+
+	res, err := d.ParseFiles(ctx, ModeNative, []File{
+		{Content: "a b", Fields: FieldMask{Include: []string{uast.KeyToken}}},
+		{Content: "a b", Fields: FieldMask{Exclude: []string{"body"}}},
+	})
+	require.NoError(err)
+	require.Len(res, 2)
+	require.NoError(res[0].Err)
+	require.Equal(ast, res[0].UAST)
+	require.NoError(res[1].Err)
+	require.Equal(nodes.Object{}, res[1].UAST)
+
+	// included meta fields are kept as-is
+	ast, err = d.Parse(ctx, "a b", &ParseOptions{
+		Mode: ModeNative, Fields: FieldMask{Include: []string{uast.KeyPos}},
+	})
+	require.NoError(err)
+	pos := uast.PositionsOf(ast.(nodes.Object)["body"].(nodes.Array)[1].(nodes.Object))
+	require.Equal(uint32(1), pos.Start().Line)
+}
@@ -0,0 +1,60 @@
+package fixtures
+
+import (
+	"bytes"
+	"fmt"
+
+	"gopkg.in/bblfsh/sdk.v2/driver"
+	"gopkg.in/bblfsh/sdk.v2/uast/transformer"
+)
+
+// stageCoverage is a rules coverage for a single transformer in a specific stage of the pipeline.
+type stageCoverage struct {
+	stage string
+	index int
+	cov   *transformer.Coverage
+}
+
+// wrapStages makes a copy of transforms with each transformer of all stages replaced by the result of wrap.
+func wrapStages(tr driver.Transforms, wrap func(stage string, index int, t transformer.Transformer) transformer.Transformer) driver.Transforms {
+	stage := func(name string, list []transformer.Transformer) []transformer.Transformer {
+		out := make([]transformer.Transformer, 0, len(list))
+		for i, t := range list {
+			out = append(out, wrap(name, i, t))
+		}
+		return out
+	}
+	tr.Preprocess = stage("preprocess", tr.Preprocess)
+	tr.Normalize = stage("semantic", tr.Normalize)
+	tr.Annotations = stage("annotated", tr.Annotations)
+	return tr
+}
+
+// withCoverage makes a copy of transforms that tracks coverage of all mapping rules.
+func withCoverage(tr driver.Transforms) (driver.Transforms, []stageCoverage) {
+	var covs []stageCoverage
+	tr = wrapStages(tr, func(stage string, i int, t transformer.Transformer) transformer.Transformer {
+		t, cov := transformer.WithCoverage(t)
+		if cov != nil {
+			covs = append(covs, stageCoverage{stage: stage, index: i, cov: cov})
+		}
+		return t
+	})
+	return tr, covs
+}
+
+// coverageReport lists unused rules and never matched node types for each transformer.
+func coverageReport(covs []stageCoverage) string {
+	buf := bytes.NewBuffer(nil)
+	for _, c := range covs {
+		unused, types := c.cov.Unused(), c.cov.NeverMatched()
+		fmt.Fprintf(buf, "%s[%d]: %d rules, %d unused\n", c.stage, c.index, len(c.cov.Hits()), len(unused))
+		if len(unused) != 0 {
+			fmt.Fprintf(buf, "\tunused rules: %v\n", unused)
+		}
+		if len(types) != 0 {
+			fmt.Fprintf(buf, "\tnever matched types: %v\n", types)
+		}
+	}
+	return buf.String()
+}
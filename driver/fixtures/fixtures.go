@@ -2,6 +2,7 @@ package fixtures
 
 import (
 	"context"
+	"encoding/json"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -14,6 +15,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"gopkg.in/bblfsh/sdk.v2/driver"
+	derrors "gopkg.in/bblfsh/sdk.v2/driver/errors"
 	"gopkg.in/bblfsh/sdk.v2/protocol/v1"
 	"gopkg.in/bblfsh/sdk.v2/uast"
 	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
@@ -56,6 +58,8 @@ type Suite struct {
 	UpdateUAST        bool // update UASTs in fixtures to ones produced by driver
 	WriteViewerJSON   bool // write JSON compatible with uast-viewer
 	WritePreprocessed bool // write a preprocessed UAST for fixtures
+	WriteCoverage     bool // write a report listing mapping rules that were never applied to fixtures
+	WriteReverse      bool // write a report listing mapping rules that lose information when reversed
 
 	NewDriver  func() driver.Native
 	Transforms driver.Transforms
@@ -68,6 +72,14 @@ type Suite struct {
 	// VerifyTokens checks that token and positional info matches.
 	// Executed after the preprocessing stage (in annotated mode).
 	VerifyTokens []positioner.VerifyToken
+	// VerifySpans is similar to VerifyTokens, but reports all mismatched nodes with their paths.
+	// Executed after the preprocessing stage (in annotated mode).
+	VerifySpans []positioner.VerifySpans
+	// VerifyPositions checks that positional info is valid.
+	// Executed after the preprocessing stage (in annotated mode).
+	VerifyPositions []positioner.VerifyPositions
+	// Validate checks the mapping rules of all transformation stages. See Transforms.Validate.
+	Validate bool
 }
 
 func (s *Suite) fixturesPath(name string) string {
@@ -102,6 +114,9 @@ func (s *Suite) RunTests(t *testing.T) {
 		s.runTestsDocker(t)
 		return
 	}
+	if s.Validate {
+		t.Run("validate", s.testValidate)
+	}
 	t.Run("native", s.testFixturesNative)
 	t.Run("uast", func(t *testing.T) {
 		s.testFixturesUAST(t, driver.ModeAnnotated, uastExt)
@@ -127,6 +142,9 @@ const (
 	preExt    = ".pre.uast"
 	uastExt   = ".uast"
 	highExt   = ".sem.uast"
+	covExt    = ".coverage"
+	revExt    = ".reverse"
+	errExt    = ".errors"
 )
 
 func marshalNative(o nodes.Node) ([]byte, error) {
@@ -137,6 +155,14 @@ func marshalUAST(o nodes.Node) ([]byte, error) {
 	return uastyml.Marshal(o)
 }
 
+func marshalDiagnostics(list []*driver.Diagnostic) ([]byte, error) {
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
 func isTest(name, ext string) (string, bool) {
 	if !strings.HasSuffix(name, ext) {
 		return "", false
@@ -177,6 +203,7 @@ func (s *Suite) testFixturesNative(t *testing.T) {
 			cancel()
 			if strings.Contains(fname, syntaxErrTestName) {
 				require.True(t, err != nil && !driver.ErrDriverFailure.Is(err), "unexpected error: %v", err)
+				s.testDiagnostics(t, fname, err)
 				return
 			}
 			if err != nil {
@@ -215,6 +242,47 @@ func (s *Suite) testFixturesNative(t *testing.T) {
 	}
 }
 
+// testDiagnostics compares structured syntax errors returned by the driver with the ones stored in fixtures.
+// Drivers that return plain error messages are not checked.
+func (s *Suite) testDiagnostics(t *testing.T, fname string, err error) {
+	if !derrors.HasDiagnostics(err) {
+		return
+	}
+	js, err := marshalDiagnostics(driver.Diagnostics(err))
+	require.NoError(t, err)
+
+	exp := s.readFixturesFile(t, fname+errExt)
+	got := string(js)
+	if exp == "" {
+		s.writeFixturesFile(t, fname+errExt, got)
+		t.Skip("no errors file found - generating")
+	}
+	if !assert.ObjectsAreEqual(exp, got) {
+		ext := errExt + gotSuffix
+		if s.UpdateNative {
+			ext = errExt
+		}
+		s.writeFixturesFile(t, fname+ext, got)
+		if !s.UpdateNative {
+			require.Fail(t, "unexpected errors returned by the driver",
+				"run diff command to debug:\ndiff -d ./%s ./%s",
+				strings.TrimLeft(s.fixturesPath(fname+ext), "./"),
+				strings.TrimLeft(s.fixturesPath(fname+errExt), "./"),
+			)
+		} else {
+			t.Skip("force update of errors fixtures")
+		}
+	} else {
+		s.deleteFixturesFile(fname + errExt + gotSuffix)
+	}
+}
+
+func (s *Suite) testValidate(t *testing.T) {
+	if err := s.Transforms.Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
 func (s *Suite) testFixturesUAST(t *testing.T, mode driver.Mode, suf string, blacklist ...string) {
 	ctx := context.Background()
 
@@ -229,6 +297,22 @@ func (s *Suite) testFixturesUAST(t *testing.T, mode driver.Mode, suf string, bla
 
 	var parseErrors uint32
 
+	tr := s.Transforms
+	if s.WriteCoverage {
+		var covs []stageCoverage
+		tr, covs = withCoverage(tr)
+		defer func() {
+			s.writeFixturesFile(t, "fixtures"+suf+covExt, coverageReport(covs))
+		}()
+	}
+	if s.WriteReverse {
+		var revs []stageReverse
+		tr, revs = withReverseCheck(tr)
+		defer func() {
+			s.writeFixturesFile(t, "fixtures"+suf+revExt, reverseReport(revs))
+		}()
+	}
+
 	suffix := s.Ext
 	for _, ent := range list {
 		fname := ent.Name()
@@ -258,7 +342,6 @@ func (s *Suite) testFixturesUAST(t *testing.T, mode driver.Mode, suf string, bla
 			}
 			require.NoError(t, err)
 
-			tr := s.Transforms
 			if s.WritePreprocessed {
 				ua, err := tr.Do(ctx, driver.ModePreprocessed, code, ast)
 				require.NoError(t, err)
@@ -323,6 +406,20 @@ func (s *Suite) testFixturesUAST(t *testing.T, mode driver.Mode, suf string, bla
 					}
 				}
 			}
+			if len(s.VerifySpans) != 0 && mode == driver.ModeAnnotated {
+				for _, v := range s.VerifySpans {
+					if err := v.Verify(code, ua); err != nil {
+						t.Error(err)
+					}
+				}
+			}
+			if len(s.VerifyPositions) != 0 && mode == driver.ModeAnnotated {
+				for _, v := range s.VerifyPositions {
+					if err := v.Verify(code, ua); err != nil {
+						t.Error(err)
+					}
+				}
+			}
 
 			un, err := marshalUAST(ua)
 			require.NoError(t, err)
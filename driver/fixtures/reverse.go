@@ -0,0 +1,42 @@
+package fixtures
+
+import (
+	"bytes"
+	"fmt"
+
+	"gopkg.in/bblfsh/sdk.v2/driver"
+	"gopkg.in/bblfsh/sdk.v2/uast/transformer"
+)
+
+// stageReverse is a reversibility check for a single transformer in a specific stage of the pipeline.
+type stageReverse struct {
+	stage string
+	index int
+	rev   *transformer.ReverseCheck
+}
+
+// withReverseCheck makes a copy of transforms that checks if all applied mapping rules are reversible.
+func withReverseCheck(tr driver.Transforms) (driver.Transforms, []stageReverse) {
+	var revs []stageReverse
+	tr = wrapStages(tr, func(stage string, i int, t transformer.Transformer) transformer.Transformer {
+		t, rev := transformer.WithReverseCheck(t)
+		if rev != nil {
+			revs = append(revs, stageReverse{stage: stage, index: i, rev: rev})
+		}
+		return t
+	})
+	return tr, revs
+}
+
+// reverseReport lists the first divergence of each rule that cannot be reversed, for each transformer.
+func reverseReport(revs []stageReverse) string {
+	buf := bytes.NewBuffer(nil)
+	for _, r := range revs {
+		divs := r.rev.Divergences()
+		fmt.Fprintf(buf, "%s[%d]: %d rules, %d not reversible\n", r.stage, r.index, len(r.rev.Checked()), len(divs))
+		for _, d := range divs {
+			fmt.Fprintf(buf, "\t%v\n", d)
+		}
+	}
+	return buf.String()
+}
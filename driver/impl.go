@@ -3,6 +3,7 @@ package driver
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/opentracing/opentracing-go"
 
@@ -12,12 +13,35 @@ import (
 
 // NewDriver returns a new Driver instance based on the given ObjectToNode and list of transformers.
 func NewDriverFrom(d Native, m *manifest.Manifest, t Transforms) (DriverModule, error) {
+	dr, err := NewDriverWithPipeline(NewPreprocessedNative(d, t.Source...), m, t.Pipeline())
+	if err != nil {
+		return nil, err
+	}
+	return WithMiddleware(dr, t.Middleware...), nil
+}
+
+// NewDriverWithPipeline returns a new Driver instance that uses a custom transformation pipeline.
+// See Transforms.Pipeline for details. Transforms.Source is not a part of the pipeline, see NewPreprocessedNative.
+//
+// Stage timeouts from the manifest are set on the pipeline, and the timeout of StageNative applies to each call
+// of the native driver.
+func NewDriverWithPipeline(d Native, m *manifest.Manifest, p *Pipeline) (DriverModule, error) {
 	if d == nil {
 		return nil, fmt.Errorf("no driver implementation")
 	} else if m == nil {
 		return nil, fmt.Errorf("no manifest")
+	} else if p == nil {
+		return nil, fmt.Errorf("no pipeline")
+	}
+	for stage, v := range m.Runtime.Timeouts {
+		t, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout of stage %q: %v", stage, err)
+		} else if err = p.SetTimeout(stage, t); err != nil {
+			return nil, err
+		}
 	}
-	return &driverImpl{d: d, m: m, t: t}, nil
+	return &driverImpl{d: d, m: m, p: p}, nil
 }
 
 // Driver implements a bblfsh driver, a driver is on charge of transforming a
@@ -31,7 +55,7 @@ type driverImpl struct {
 	d Native
 
 	m *manifest.Manifest
-	t Transforms
+	p *Pipeline
 }
 
 func (d *driverImpl) Start() error {
@@ -45,34 +69,231 @@ func (d *driverImpl) Close() error {
 // Parse process a protocol.ParseRequest, calling to the native driver. It a
 // parser request is done to the internal native driver and the the returned
 // native AST is transform to UAST.
-func (d *driverImpl) Parse(rctx context.Context, src string, opts *ParseOptions) (nodes.Node, error) {
+func (d *driverImpl) Parse(rctx context.Context, src string, opts *ParseOptions) (_ nodes.Node, gerr error) {
 	sp, ctx := opentracing.StartSpanFromContext(rctx, "bblfsh.driver.Parse")
 	defer sp.Finish()
 
 	if opts == nil {
 		opts = &ParseOptions{}
 	}
-	ast, err := d.d.Parse(ctx, src)
+	defer func() {
+		// language is detected during the call
+		traceRequest(sp, opts.Mode, opts.Language, opts.Filename)
+		TraceError(sp, gerr)
+	}()
+	ctx = withMetadata(ctx, opts.Filename, opts.Metadata)
+	code, cs, m, err := decodeSource(src, opts.Charset)
 	if err != nil {
-		if !ErrDriverFailure.Is(err) {
-			// all other errors are considered syntax errors
-			err = ErrSyntax.Wrap(err)
-		} else {
-			ast = nil
+		return nil, err
+	}
+	opts.Charset = cs
+	if opts.Mode == ModeTokens {
+		toks, lopts, err := d.parseTokens(ctx, code, opts.Options)
+		opts.Options = lopts
+		if (err == nil || ErrPartialTransform.Is(err)) && opts.Language == "" {
+			opts.Language = d.m.Language
 		}
-		return ast, err
+		m.errors(err)
+		return filterResult(m.positions(toks), err, opts.Range, opts.Query, opts.Fields)
 	}
-	if opts.Language == "" {
+	ast, lopts, err := d.parseNative(ctx, code, opts.Options)
+	opts.Options = lopts
+	if err == nil && opts.Language == "" {
 		opts.Language = d.m.Language
 	}
+	ast, err = d.transform(ctx, opts.Mode, code, ast, err, nil)
+	m.errors(err)
+	return filterResult(m.positions(ast), err, opts.Range, opts.Query, opts.Fields)
+}
 
-	ast, err = d.t.Do(ctx, opts.Mode, src, ast)
+// parseNative runs the native driver with given language options. Options are reset if the native driver does not
+// implement OptionsNative.
+func (d *driverImpl) parseNative(ctx context.Context, src string, opts LanguageOptions) (nodes.Node, LanguageOptions, error) {
+	var (
+		ast   nodes.Node
+		lopts LanguageOptions
+	)
+	err := d.native(ctx, func(ctx context.Context) (err error) {
+		if o, ok := d.d.(OptionsNative); ok {
+			ast, lopts, err = o.ParseWithOptions(ctx, src, opts)
+		} else {
+			ast, err = d.d.Parse(ctx, src)
+		}
+		return err
+	})
+	if ErrStageTimeout.Is(err) {
+		return nil, LanguageOptions{}, err
+	}
+	return ast, lopts, err
+}
+
+// ParseFiles implements Driver. Files are sent to the native driver in a single batch, if it implements BatchNative
+// and none of the files have language options.
+func (d *driverImpl) ParseFiles(rctx context.Context, mode Mode, files []File) (_ []Result, gerr error) {
+	sp, ctx := opentracing.StartSpanFromContext(rctx, "bblfsh.driver.ParseFiles")
+	defer sp.Finish()
+	traceRequest(sp, mode, d.m.Language, "")
+	sp.SetTag(TagFiles, len(files))
+	defer func() {
+		TraceError(sp, gerr)
+	}()
+
+	srcs := make([]decodedSource, 0, len(files))
+	batch := len(files) != 0 && !hasOptions(files)
+	for _, f := range files {
+		var s decodedSource
+		s.code, s.charset, s.m, s.err = decodeSource(f.Content, f.Charset)
+		if s.err != nil {
+			batch = false
+		}
+		srcs = append(srcs, s)
+	}
+	if mode == ModeTokens {
+		out := make([]Result, 0, len(files))
+		for i, f := range files {
+			s := srcs[i]
+			r := Result{Source: f.Content, Mode: mode, Language: f.Language, Charset: s.charset, Err: s.err}
+			if s.err == nil {
+				r.UAST, r.Options, r.Err = d.parseTokens(withMetadata(ctx, f.Filename, f.Metadata), s.code, f.Options)
+				s.m.errors(r.Err)
+				r.UAST, r.Err = filterResult(s.m.positions(r.UAST), r.Err, f.Range, f.Query, f.Fields)
+			}
+			if (r.Err == nil || ErrPartialTransform.Is(r.Err)) && r.Language == "" {
+				r.Language = d.m.Language
+			}
+			out = append(out, r)
+		}
+		return out, nil
+	}
+	var (
+		asts  []NativeResult
+		lopts = make([]LanguageOptions, len(files))
+	)
+	if b, ok := d.d.(BatchNative); ok && batch {
+		codes := make([]string, 0, len(files))
+		for _, s := range srcs {
+			codes = append(codes, s.code)
+		}
+		err := d.native(ctx, func(ctx context.Context) (err error) {
+			asts, err = b.ParseBatch(ctx, codes)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		} else if len(asts) != len(files) {
+			return nil, ErrDriverFailure.Wrap(fmt.Errorf("expected %d results, got %d", len(files), len(asts)))
+		}
+	} else {
+		for i, f := range files {
+			if srcs[i].err != nil {
+				asts = append(asts, NativeResult{})
+				continue
+			}
+			ast, o, err := d.parseNative(ctx, srcs[i].code, f.Options)
+			asts = append(asts, NativeResult{AST: ast, Err: err})
+			lopts[i] = o
+		}
+	}
+	out := make([]Result, 0, len(files))
+	for i, f := range files {
+		s := srcs[i]
+		r := Result{Source: f.Content, Mode: mode, Language: f.Language, Options: lopts[i], Charset: s.charset}
+		if s.err != nil {
+			r.Err = s.err
+			out = append(out, r)
+			continue
+		}
+		if asts[i].Err == nil && r.Language == "" {
+			r.Language = d.m.Language
+		}
+		r.UAST, r.Err = d.transform(withMetadata(ctx, f.Filename, f.Metadata), mode, s.code, asts[i].AST, asts[i].Err, nil)
+		s.m.errors(r.Err)
+		r.UAST, r.Err = filterResult(s.m.positions(r.UAST), r.Err, f.Range, f.Query, f.Fields)
+		out = append(out, r)
+	}
+	return out, nil
+}
+
+// decodedSource is a source of the file converted to UTF-8. See decodeSource.
+type decodedSource struct {
+	code    string
+	charset string
+	m       *offsetMap
+	err     error
+}
+
+// hasOptions checks if any of the files have language options.
+func hasOptions(files []File) bool {
+	for _, f := range files {
+		if !f.Options.IsZero() {
+			return true
+		}
+	}
+	return false
+}
+
+// transform converts the native AST to UAST. The error of the native driver is converted to the errors of Parse.
+// If prev is set, results of the previous transformation are reused. See Pipeline.DoIncremental.
+//
+// If the native parser returned a partial tree and the pipeline supports error recovery, the tree is transformed
+// as well and returned together with the syntax error. See Transforms.ErrorNodes.
+func (d *driverImpl) transform(ctx context.Context, mode Mode, src string, ast nodes.Node, err error, prev *Previous) (nodes.Node, error) {
 	if err != nil {
+		if ErrDriverFailure.Is(err) {
+			return nil, err
+		}
+		// all other errors are considered syntax errors
+		serr := ErrSyntax.Wrap(err)
+		if ast == nil || !d.p.recovers() {
+			return ast, serr
+		}
+		// partial trees are never reused for incremental parsing
+		out, terr := d.p.Do(withSyntaxErrors(ctx, err), mode, src, ast)
+		if ErrResourceExhausted.Is(terr) {
+			return nil, terr
+		} else if terr != nil && !ErrPartialTransform.Is(terr) {
+			// return the native tree, as it was done without recovery
+			return ast, serr
+		}
+		return out, serr
+	}
+	if prev != nil {
+		ast, err = d.p.DoIncremental(ctx, mode, src, ast, *prev)
+	} else {
+		ast, err = d.p.Do(ctx, mode, src, ast)
+	}
+	if err != nil && !ErrPartialTransform.Is(err) && !ErrResourceExhausted.Is(err) {
 		err = ErrTransformFailure.Wrap(err)
 	}
 	return ast, err
 }
 
+// allModes is a set of modes supported by the transformation pipeline.
+const allModes = ModeNative | ModePreprocessed | ModeAnnotated | ModeSemantic | ModeTokens
+
+// Capabilities implements Driver. Capabilities of the native driver are used, if it implements CapableNative.
+// Language versions are taken from the manifest.
+func (d *driverImpl) Capabilities(ctx context.Context) (Capabilities, error) {
+	var c Capabilities
+	if cn, ok := d.d.(CapableNative); ok {
+		c = cn.Capabilities()
+	} else {
+		_, c.Options = d.d.(OptionsNative)
+	}
+	c.Modes = allModes
+	c.Incremental = true
+	c.Versions = d.m.LanguageVersions
+	return c, nil
+}
+
+// Ping implements Pinger. Native drivers that do not implement Pinger are always considered responsive.
+func (d *driverImpl) Ping(ctx context.Context) error {
+	if p, ok := d.d.(Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
 // Manifest returns a driver manifest.
 func (d *driverImpl) Manifest() (manifest.Manifest, error) {
 	return *d.m, nil // TODO: clone
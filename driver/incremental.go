@@ -0,0 +1,302 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/opentracing/opentracing-go"
+	"gopkg.in/src-d/go-errors.v1"
+
+	"gopkg.in/bblfsh/sdk.v2/uast"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+	"gopkg.in/bblfsh/sdk.v2/uast/transformer"
+	"gopkg.in/bblfsh/sdk.v2/uast/transformer/positioner"
+)
+
+var (
+	// errNotIncremental is returned by pipeline stages that cannot skip reused subtrees.
+	errNotIncremental = errors.NewKind("%s cannot be applied incrementally")
+
+	// ErrInvalidEdit is returned by ParseIncremental if the edits cannot be applied to the previous source.
+	ErrInvalidEdit = errors.NewKind("invalid edit: %s")
+)
+
+// TextEdit describes a change of the source file: bytes in the [Start, End) range of the previous source are
+// replaced by Text.
+type TextEdit struct {
+	Start int
+	End   int
+	Text  string
+}
+
+// IncrementalDriver is an optional interface for drivers that can parse a file again after it was edited, reusing
+// the previous result.
+type IncrementalDriver interface {
+	Driver
+	// ParseIncremental applies edits to the source of the previous result and parses it again. Edits must be sorted
+	// by the offset and must not overlap. Errors of the file are returned in the result, the same way as in
+	// ParseFiles. ErrInvalidEdit is returned if the edits cannot be applied.
+	//
+	// The previous result must contain the whole UAST, not restricted to a range.
+	ParseIncremental(ctx context.Context, prev Result, edits []TextEdit) (Result, error)
+}
+
+// IncrementalNative is an optional interface for native drivers backed by incremental parsers.
+type IncrementalNative interface {
+	Native
+	// ParseEdits is similar to Parse, but receives the previous source and a list of edits instead of the new source.
+	// The native driver may reuse its own state for the previous source, if it has one.
+	ParseEdits(ctx context.Context, prev string, edits []TextEdit) (nodes.Node, error)
+}
+
+// ParseIncremental parses the file again after edits. If the driver implements IncrementalDriver, the previous result
+// is reused, otherwise the whole file is parsed again.
+func ParseIncremental(ctx context.Context, d Driver, prev Result, edits []TextEdit) (Result, error) {
+	if id, ok := d.(IncrementalDriver); ok {
+		return id.ParseIncremental(ctx, prev, edits)
+	}
+	src, err := ApplyEdits(prev.Source, edits)
+	if err != nil {
+		return Result{}, err
+	}
+	opts := &ParseOptions{Mode: prev.Mode, Language: prev.Language, Options: prev.Options, Charset: prev.Charset}
+	r := Result{Source: src, Mode: prev.Mode}
+	r.UAST, r.Err = d.Parse(ctx, src, opts)
+	r.Language, r.Options, r.Charset = opts.Language, opts.Options, opts.Charset
+	return r, nil
+}
+
+// ApplyEdits applies edits to the source. Edits must be sorted by the offset and must not overlap.
+func ApplyEdits(src string, edits []TextEdit) (string, error) {
+	var (
+		buf  strings.Builder
+		last int
+	)
+	for _, e := range edits {
+		if e.Start < last || e.End < e.Start || e.End > len(src) {
+			return "", ErrInvalidEdit.New(fmt.Sprintf("[%d, %d) after offset %d, source size %d", e.Start, e.End, last, len(src)))
+		}
+		buf.WriteString(src[last:e.Start])
+		buf.WriteString(e.Text)
+		last = e.End
+	}
+	buf.WriteString(src[last:])
+	return buf.String(), nil
+}
+
+// ParseIncremental implements IncrementalDriver. The native driver receives the edits, if it implements
+// IncrementalNative. If the previous result has no errors, the transformation reuses its unchanged subtrees.
+// See Pipeline.DoIncremental. Sources that are not in UTF-8 are always parsed from scratch.
+func (d *driverImpl) ParseIncremental(rctx context.Context, prev Result, edits []TextEdit) (Result, error) {
+	sp, ctx := opentracing.StartSpanFromContext(rctx, "bblfsh.driver.ParseIncremental")
+	defer sp.Finish()
+
+	src, err := ApplyEdits(prev.Source, edits)
+	if err != nil {
+		return Result{}, err
+	}
+	code, cs, m, err := decodeSource(src, prev.Charset)
+	if err != nil {
+		return Result{Source: src, Mode: prev.Mode, Language: prev.Language, Charset: cs, Err: err}, nil
+	}
+	if prev.Mode == ModeTokens {
+		r := Result{Source: src, Mode: prev.Mode, Language: prev.Language, Charset: cs}
+		r.UAST, r.Options, r.Err = d.parseTokens(ctx, code, prev.Options)
+		m.errors(r.Err)
+		r.UAST = m.positions(r.UAST)
+		return r, nil
+	}
+	var (
+		ast   nodes.Node
+		lopts LanguageOptions
+	)
+	// offsets of edits do not match the transcoded source, thus nothing is reused
+	reuse := m == nil
+	if in, ok := d.d.(IncrementalNative); ok && reuse && prev.Options.IsZero() {
+		var out nodes.Node
+		err = d.native(ctx, func(ctx context.Context) (err error) {
+			out, err = in.ParseEdits(ctx, prev.Source, edits)
+			return err
+		})
+		if !ErrStageTimeout.Is(err) {
+			ast = out
+		}
+	} else {
+		ast, lopts, err = d.parseNative(ctx, code, prev.Options)
+	}
+	var p *Previous
+	if reuse && prev.Err == nil && prev.UAST != nil {
+		p = &Previous{Mode: prev.Mode, UAST: prev.UAST, Edits: make([]positioner.Edit, 0, len(edits))}
+		for _, e := range edits {
+			p.Edits = append(p.Edits, positioner.Edit{Start: e.Start, End: e.End, Text: e.Text})
+		}
+	}
+	r := Result{Source: src, Mode: prev.Mode, Language: prev.Language, Options: lopts, Charset: cs}
+	if err == nil && r.Language == "" {
+		r.Language = d.m.Language
+	}
+	r.UAST, r.Err = d.transform(ctx, prev.Mode, code, ast, err, p)
+	m.errors(r.Err)
+	r.UAST = m.positions(r.UAST)
+	return r, nil
+}
+
+// Previous is a result of a previous transformation of the same file. See Pipeline.DoIncremental.
+type Previous struct {
+	// Mode of the previous transformation.
+	Mode Mode
+	// UAST is the result of the previous transformation.
+	UAST nodes.Node
+	// Edits is a list of changes made to the source since the previous transformation.
+	// Edits must be sorted by the offset and must not overlap.
+	Edits []positioner.Edit
+}
+
+// DoIncremental is similar to Do, but reuses the results of the previous transformation for subtrees that are not
+// affected by the edits.
+//
+// The tree is split into subtrees the same way as for parallel processing (see Transforms.Workers). Stages before
+// the first incremental one (see Stage.Incremental) are always executed for the whole tree, and the positions of each
+// subtree are compared with edited ranges. Subtrees located completely before or after all edits are replaced with
+// the subtrees of the previous UAST with the same positions, and all the following stages skip them. Positions of
+// reused subtrees are shifted accordingly.
+//
+// It is assumed that the transformation of each subtree depends only on the subtree itself. If any of the following
+// stages cannot skip subtrees, or the modes of transformations differ, the whole tree is transformed again.
+func (p *Pipeline) DoIncremental(ctx context.Context, mode Mode, code string, nd nodes.Node, prev Previous) (nodes.Node, error) {
+	if mode == 0 {
+		mode = ModeDefault
+	}
+	if prev.Mode == 0 {
+		prev.Mode = ModeDefault
+	}
+	if prev.UAST == nil || prev.Mode != mode {
+		return p.Do(ctx, mode, code, nd)
+	}
+	out, err := p.do(ctx, mode, code, nd, &prev)
+	if errNotIncremental.Is(err) {
+		return p.Do(ctx, mode, code, nd)
+	}
+	return out, err
+}
+
+// span is a range of offsets of a subtree.
+type span struct {
+	start, end int
+}
+
+func spanOf(n nodes.Node) (span, bool) {
+	ps := uast.PositionsOf(n)
+	start, end := ps.Start(), ps.End()
+	if start == nil || end == nil || !start.HasOffset() || !end.HasOffset() {
+		return span{}, false
+	}
+	return span{start: int(start.Offset), end: int(end.Offset)}, true
+}
+
+// reuse replaces subtrees of the preprocessed tree that are not affected by edits with subtrees of the previous UAST.
+func (st *FileState) reuse(nd nodes.Node, prev *Previous) nodes.Node {
+	st.reused = make(map[nodes.Comparable]struct{})
+	// by default, nothing changed and all subtrees are reused
+	first, lastEnd, delta := len(st.Code)+1, -1, 0
+	if len(prev.Edits) != 0 {
+		first = prev.Edits[0].Start
+		for _, e := range prev.Edits {
+			// end of the edit in the new source
+			newEnd := e.Start + len(e.Text)
+			lastEnd = newEnd + delta
+			delta += newEnd - e.End
+		}
+	}
+
+	old := make(map[span]nodes.Node)
+	eachSubtree(prev.UAST, parallelDepth, func(n nodes.Node) nodes.Node {
+		if s, ok := spanOf(n); ok {
+			old[s] = n
+		}
+		return n
+	})
+	fix := positioner.FromOffset().OnCode(st.Code)
+	return eachSubtree(nd, parallelDepth, func(n nodes.Node) nodes.Node {
+		s, ok := spanOf(n)
+		if !ok {
+			return n
+		}
+		var out nodes.Node
+		switch {
+		case s.end < first:
+			out, ok = old[s]
+		case s.start > lastEnd:
+			out, ok = old[span{start: s.start - delta, end: s.end - delta}]
+			if ok && delta != 0 {
+				var err error
+				out, err = shiftOffsets(out, delta, fix)
+				ok = err == nil
+			}
+		default:
+			return n
+		}
+		if !ok {
+			return n
+		}
+		st.reused[nodes.UniqueKey(out)] = struct{}{}
+		return out
+	})
+}
+
+// shiftOffsets returns a copy of the subtree with offsets shifted by delta. Lines and columns are recalculated by fix.
+func shiftOffsets(n nodes.Node, delta int, fix transformer.Transformer) (nodes.Node, error) {
+	shift := transformer.TransformObjFunc(func(o nodes.Object) (nodes.Object, bool, error) {
+		pos := uast.AsPosition(o)
+		if pos == nil {
+			return o, false, nil
+		}
+		pos.Offset = uint32(int(pos.Offset) + delta)
+		return pos.ToObject(), true, nil
+	})
+	n, err := shift.Do(n)
+	if err != nil {
+		return nil, err
+	}
+	return fix.Do(n)
+}
+
+// eachSubtree calls fnc for each subtree at a given depth and replaces the subtree with the result.
+// The depth is counted the same way as in transformer.Parallel.
+func eachSubtree(n nodes.Node, depth int, fnc func(n nodes.Node) nodes.Node) nodes.Node {
+	if depth <= 0 {
+		return fnc(n)
+	}
+	switch n := n.(type) {
+	case nodes.Object:
+		var nn nodes.Object
+		for k, v := range n {
+			nv := eachSubtree(v, depth-1, fnc)
+			if !nodes.Same(nv, v) {
+				if nn == nil {
+					nn = n.CloneObject()
+				}
+				nn[k] = nv
+			}
+		}
+		if nn != nil {
+			return nn
+		}
+	case nodes.Array:
+		var nn nodes.Array
+		for i, v := range n {
+			nv := eachSubtree(v, depth-1, fnc)
+			if !nodes.Same(nv, v) {
+				if nn == nil {
+					nn = n.CloneList()
+				}
+				nn[i] = nv
+			}
+		}
+		if nn != nil {
+			return nn
+		}
+	}
+	return n
+}
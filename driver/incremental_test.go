@@ -0,0 +1,115 @@
+package driver
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/bblfsh/sdk.v2/driver/manifest"
+	"gopkg.in/bblfsh/sdk.v2/uast"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+	"gopkg.in/bblfsh/sdk.v2/uast/transformer/positioner"
+)
+
+func TestDriverParseIncremental(t *testing.T) {
+	require := require.New(t)
+
+	m := &manifest.Manifest{Language: "fixture"}
+	d, err := NewDriverFrom(treeNative{}, m, Transforms{})
+	require.NoError(err)
+
+	ctx := context.Background()
+	res, err := d.ParseFiles(ctx, ModeAnnotated, []File{{Content: "a b"}})
+	require.NoError(err)
+	require.Len(res, 1)
+
+	r, err := ParseIncremental(ctx, d, res[0], []TextEdit{{Start: 1, End: 2, Text: "  "}})
+	require.NoError(err)
+	require.NoError(r.Err)
+	require.Equal("a  b", r.Source)
+	require.Equal("fixture", r.Language)
+	require.Equal([]string{"b", "a"}, uast.Tokens(r.UAST))
+
+	_, err = ParseIncremental(ctx, d, res[0], []TextEdit{{Start: 2, End: 4}})
+	require.True(ErrInvalidEdit.Is(err), "%v", err)
+}
+
+// funcTree returns a file with a function for each word of the source.
+func funcTree(src string) nodes.Node {
+	var (
+		body nodes.Array
+		off  int
+	)
+	for _, w := range strings.Split(src, " ") {
+		end := off + len(w)
+		body = append(body, nodes.Object{
+			uast.KeyType: nodes.String("Func"),
+			"name":       nodes.String(w),
+			uast.KeyPos: uast.Positions{
+				uast.KeyStart: {Offset: uint32(off), Line: 1, Col: uint32(off + 1)},
+				uast.KeyEnd:   {Offset: uint32(end), Line: 1, Col: uint32(end + 1)},
+			}.ToObject(),
+		})
+		off = end + 1
+	}
+	return nodes.Object{"body": body}
+}
+
+func TestPipelineIncremental(t *testing.T) {
+	require := require.New(t)
+
+	// the stage marks functions it transformed and records which of them were reused
+	var reused map[string]bool
+	mark := func(incremental bool) Stage {
+		return Stage{Name: "mark", Mode: ModePreprocessed, Incremental: incremental,
+			Do: func(ctx context.Context, st *FileState, nd nodes.Node) (nodes.Node, error) {
+				reused = make(map[string]bool)
+				file := nd.(nodes.Object).CloneObject()
+				body := file["body"].(nodes.Array).CloneList()
+				for i, f := range body {
+					name := string(f.(nodes.Object)["name"].(nodes.String))
+					reused[name] = st.Reused(f)
+					if !reused[name] {
+						fn := f.(nodes.Object).CloneObject()
+						fn["marked"] = nodes.Bool(true)
+						body[i] = fn
+					}
+				}
+				file["body"] = body
+				return file, nil
+			},
+		}
+	}
+	p := NewPipeline(mark(true))
+
+	ctx := context.Background()
+	src := "foo bar baz"
+	prev, err := p.Do(ctx, ModeSemantic, src, funcTree(src))
+	require.NoError(err)
+	require.Equal(map[string]bool{"foo": false, "bar": false, "baz": false}, reused)
+
+	// only the edited function is transformed again, the following ones are shifted
+	src = "foo quux baz"
+	out, err := p.DoIncremental(ctx, ModeSemantic, src, funcTree(src), Previous{
+		UAST: prev, Edits: []positioner.Edit{{Start: 4, End: 7, Text: "quux"}},
+	})
+	require.NoError(err)
+	require.Equal(map[string]bool{"foo": true, "quux": false, "baz": true}, reused)
+
+	exp, err := p.Do(ctx, ModeSemantic, src, funcTree(src))
+	require.NoError(err)
+	require.Equal(exp, out)
+
+	// stages that cannot skip reused subtrees transform the whole tree
+	p = NewPipeline(mark(true), mark(false))
+	prev, err = p.Do(ctx, ModeSemantic, "foo bar baz", funcTree("foo bar baz"))
+	require.NoError(err)
+
+	out, err = p.DoIncremental(ctx, ModeSemantic, src, funcTree(src), Previous{
+		UAST: prev, Edits: []positioner.Edit{{Start: 4, End: 7, Text: "quux"}},
+	})
+	require.NoError(err)
+	require.Equal(map[string]bool{"foo": false, "quux": false, "baz": false}, reused)
+	require.Equal(exp, out)
+}
@@ -119,6 +119,59 @@ type Documentation struct {
 	Caveats     string `toml:"caveats,omitempty" json:",omitempty"`
 }
 
+// Files describes which files are handled by the driver. See Matcher.
+type Files struct {
+	// Extensions is a list of file name suffixes, including the leading dot. Matched case-insensitively.
+	Extensions []string `toml:"extensions,omitempty" json:",omitempty"`
+	// Filenames is a list of exact file names, without directories.
+	Filenames []string `toml:"filenames,omitempty" json:",omitempty"`
+	// Shebangs is a list of regular expressions matched against the interpreter name in the first line of a file.
+	// The interpreter is the base name of the first command, or the first argument of env.
+	Shebangs []string `toml:"shebangs,omitempty" json:",omitempty"`
+	// Heuristics are used to resolve extensions that are handled by multiple drivers.
+	Heuristics []Heuristic `toml:"heuristics,omitempty" json:",omitempty"`
+}
+
+// Heuristic is a regular expression that should match the content of a file handled by the driver.
+type Heuristic struct {
+	// Extensions the heuristic applies to. If empty, it applies to all extensions of the driver.
+	Extensions []string `toml:"extensions,omitempty" json:",omitempty"`
+	// Pattern is a regular expression in the RE2 syntax. Multi-line mode is enabled.
+	Pattern string `toml:"pattern"`
+}
+
+// LanguageVersion lists syntax features of a specific language version and if the driver supports them.
+type LanguageVersion struct {
+	Version  string          `toml:"version"`
+	Features []SyntaxFeature `toml:"features,omitempty" json:",omitempty"`
+}
+
+// SyntaxFeature is a language construct, for example "match statement" in Python 3.10.
+type SyntaxFeature struct {
+	Name      string `toml:"name"`
+	Supported bool   `toml:"supported"`
+}
+
+// LanguageVersions is a feature matrix of language versions.
+type LanguageVersions []LanguageVersion
+
+// Supports checks if the syntax feature of the language version is supported by the driver. The second value is
+// false if the version or the feature is not listed.
+func (arr LanguageVersions) Supports(version, feature string) (bool, bool) {
+	for _, v := range arr {
+		if v.Version != version {
+			continue
+		}
+		for _, f := range v.Features {
+			if f.Name == feature {
+				return f.Supported, true
+			}
+		}
+		return false, false
+	}
+	return false, false
+}
+
 type Manifest struct {
 	Name            string            `toml:"name"` // human-readable name
 	Language        string            `toml:"language"`
@@ -132,10 +185,22 @@ type Manifest struct {
 		OS             OS       `toml:"os" json:",omitempty"`
 		NativeVersion  Versions `toml:"native_version" json:",omitempty"`
 		NativeEncoding string   `toml:"native_encoding" json:",omitempty"`
+		NativeFormat   string   `toml:"native_format,omitempty" json:",omitempty"`
+		WarmUp         []string `toml:"warm_up,omitempty" json:",omitempty"`
 		GoVersion      string   `toml:"go_version" json:",omitempty"`
+		// Timeouts maps stage names to durations, for example "native" = "10s".
+		Timeouts map[string]string `toml:"timeouts,omitempty" json:",omitempty"`
+		// PoolSize is the number of instances of the native driver to run concurrently. Zero or one runs a single
+		// instance.
+		PoolSize int `toml:"pool_size,omitzero" json:",omitempty"`
+		// Handshake is set if the native driver supports the protocol handshake. Otherwise, the legacy protocol
+		// is assumed. See native.Driver.SetHandshake.
+		Handshake bool `toml:"handshake,omitempty" json:",omitempty"`
 	} `toml:"runtime"`
-	Features    []Feature    `toml:"features" json:",omitempty"`
-	Maintainers []Maintainer `toml:"-" json:",omitempty"`
+	Features         []Feature        `toml:"features" json:",omitempty"`
+	Files            *Files           `toml:"files,omitempty" json:",omitempty"`
+	LanguageVersions LanguageVersions `toml:"language_versions,omitempty" json:",omitempty"`
+	Maintainers      []Maintainer     `toml:"-" json:",omitempty"`
 }
 
 // Supports checks if driver supports specified feature.
@@ -58,6 +58,37 @@ func TestDecode(t *testing.T) {
 	assert.Equal(t, Alpine, m.Runtime.OS)
 }
 
+func TestLanguageVersions(t *testing.T) {
+	m := &Manifest{}
+	err := m.Decode(strings.NewReader(`
+language = "python"
+
+[[language_versions]]
+  version = "3.10"
+  features = [{ name = "match", supported = true }, { name = "walrus", supported = false }]
+
+[[language_versions]]
+  version = "3.7"
+`))
+	require.NoError(t, err)
+	require.Len(t, m.LanguageVersions, 2)
+
+	for _, c := range []struct {
+		vers, feature    string
+		supported, known bool
+	}{
+		{vers: "3.10", feature: "match", supported: true, known: true},
+		{vers: "3.10", feature: "walrus", known: true},
+		{vers: "3.10", feature: "async"},
+		{vers: "3.7", feature: "match"},
+		{vers: "2", feature: "match"},
+	} {
+		ok, known := m.LanguageVersions.Supports(c.vers, c.feature)
+		require.Equal(t, c.supported, ok, "%s %s", c.vers, c.feature)
+		require.Equal(t, c.known, known, "%s %s", c.vers, c.feature)
+	}
+}
+
 func TestCurrentSDKVersion(t *testing.T) {
 	require.Equal(t, 2, CurrentSDKMajor())
 }
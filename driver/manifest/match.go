@@ -0,0 +1,168 @@
+package manifest
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// headerSize is the maximal size of the file header that is checked by heuristics.
+const headerSize = 64 * 1024
+
+// match is a strength of the match. Higher is better.
+type match int
+
+const (
+	matchNone        match = iota
+	matchUnconfirmed       // extension matches, but heuristics do not
+	matchExtension
+	matchHeuristic
+	matchShebang
+	matchFilename
+)
+
+// Matcher selects a driver for a file based on the file associations declared in the manifests. See Files.
+//
+// File names have the highest priority, followed by shebangs and extensions. If the same extension is handled by
+// multiple drivers, heuristics are used to select one of them: drivers with heuristics for the extension are
+// preferred if one of the heuristics matches the content, and are used as the last resort otherwise. The remaining
+// ties are resolved by the development status and the order of manifests.
+type Matcher struct {
+	drivers []matchDriver
+}
+
+type matchDriver struct {
+	m          *Manifest
+	shebangs   []*regexp.Regexp
+	heuristics []heuristic
+}
+
+type heuristic struct {
+	exts []string
+	re   *regexp.Regexp
+}
+
+// NewMatcher creates a matcher for the given manifests. Manifests without file associations are ignored.
+// It returns an error if any of the patterns is invalid.
+func NewMatcher(list []Manifest) (*Matcher, error) {
+	m := &Matcher{}
+	for i := range list {
+		man := &list[i]
+		if man.Files == nil {
+			continue
+		}
+		d := matchDriver{m: man}
+		for _, s := range man.Files.Shebangs {
+			re, err := regexp.Compile(`^(?:` + s + `)$`)
+			if err != nil {
+				return nil, fmt.Errorf("%s: invalid shebang pattern: %v", man.Language, err)
+			}
+			d.shebangs = append(d.shebangs, re)
+		}
+		for _, h := range man.Files.Heuristics {
+			re, err := regexp.Compile(`(?m)` + h.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("%s: invalid heuristic pattern: %v", man.Language, err)
+			}
+			d.heuristics = append(d.heuristics, heuristic{exts: h.Extensions, re: re})
+		}
+		m.drivers = append(m.drivers, d)
+	}
+	return m, nil
+}
+
+// Match returns the manifest of a driver that should be used for a file with a given path and content. The content
+// may be nil, in which case only the path is used. It returns false if no drivers handle the file.
+func (m *Matcher) Match(fpath string, content []byte) (*Manifest, bool) {
+	name := path.Base(strings.Replace(fpath, `\`, "/", -1))
+	interp := shebang(content)
+	if len(content) > headerSize {
+		content = content[:headerSize]
+	}
+	var (
+		best  *Manifest
+		bestM = matchNone
+	)
+	for _, d := range m.drivers {
+		cur := d.match(name, interp, content)
+		if cur == matchNone || cur < bestM {
+			continue
+		} else if cur == bestM && best.Status.Rank() >= d.m.Status.Rank() {
+			continue
+		}
+		best, bestM = d.m, cur
+	}
+	return best, best != nil
+}
+
+func (d *matchDriver) match(name, interp string, content []byte) match {
+	for _, f := range d.m.Files.Filenames {
+		if name == f {
+			return matchFilename
+		}
+	}
+	if interp != "" {
+		for _, re := range d.shebangs {
+			if re.MatchString(interp) {
+				return matchShebang
+			}
+		}
+	}
+	ext := matchExtensions(name, d.m.Files.Extensions)
+	if ext == "" {
+		return matchNone
+	}
+	res := matchExtension
+	for _, h := range d.heuristics {
+		if len(h.exts) != 0 && matchExtensions(name, h.exts) == "" {
+			continue
+		}
+		if content != nil && h.re.Match(content) {
+			return matchHeuristic
+		}
+		res = matchUnconfirmed
+	}
+	return res
+}
+
+// matchExtensions returns the longest extension from the list that matches the file name.
+func matchExtensions(name string, exts []string) string {
+	name = strings.ToLower(name)
+	best := ""
+	for _, e := range exts {
+		if len(e) > len(best) && strings.HasSuffix(name, strings.ToLower(e)) {
+			best = e
+		}
+	}
+	return best
+}
+
+// shebang returns the interpreter name from the first line of the content, or an empty string
+// if there is no shebang.
+func shebang(content []byte) string {
+	if !bytes.HasPrefix(content, []byte("#!")) {
+		return ""
+	}
+	line := content[2:]
+	if i := bytes.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+	args := strings.Fields(string(line))
+	if len(args) == 0 {
+		return ""
+	}
+	interp := path.Base(args[0])
+	if interp != "env" {
+		return interp
+	}
+	for _, a := range args[1:] {
+		// skip env flags and variables
+		if strings.HasPrefix(a, "-") || strings.Contains(a, "=") {
+			continue
+		}
+		return path.Base(a)
+	}
+	return ""
+}
@@ -0,0 +1,85 @@
+package manifest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var matchFixture = `
+language = "cpp"
+status = "beta"
+
+[files]
+  extensions = [".cpp", ".hpp", ".h"]
+  heuristics = [{ extensions = [".h"], pattern = '^\s*(class|namespace|template)\b' }]
+`[1:]
+
+func TestMatcher(t *testing.T) {
+	var cpp Manifest
+	err := cpp.Decode(strings.NewReader(matchFixture))
+	require.NoError(t, err)
+	require.Equal(t, &Files{
+		Extensions: []string{".cpp", ".hpp", ".h"},
+		Heuristics: []Heuristic{{Extensions: []string{".h"}, Pattern: `^\s*(class|namespace|template)\b`}},
+	}, cpp.Files)
+
+	list := []Manifest{
+		{Language: "c", Status: Alpha, Files: &Files{Extensions: []string{".c", ".h"}}},
+		cpp,
+		{Language: "python", Status: Stable, Files: &Files{
+			Extensions: []string{".py"},
+			Filenames:  []string{"SConstruct"},
+			Shebangs:   []string{`python[23]?(\.\d+)?`},
+		}},
+		{Language: "bash", Status: Beta, Files: &Files{
+			Extensions: []string{".sh"},
+			Shebangs:   []string{"bash", "sh"},
+		}},
+		{Language: "typescript", Status: Beta, Files: &Files{Extensions: []string{".ts", ".d.ts"}}},
+		{Language: "go", Status: Beta},
+	}
+	m, err := NewMatcher(list)
+	require.NoError(t, err)
+
+	for _, c := range []struct {
+		path    string
+		content string
+		exp     string
+	}{
+		{path: "main.c", exp: "c"},
+		{path: "src/lib.CPP", exp: "cpp"},
+		{path: "lib.h", exp: "c"},
+		{path: "lib.hpp", content: "int foo();\n", exp: "cpp"},
+		{path: "lib.h", content: "int foo();\n", exp: "c"},
+		{path: "lib.h", content: "#pragma once\nnamespace foo {}\n", exp: "cpp"},
+		{path: "SConstruct", content: "#!/bin/sh\n", exp: "python"},
+		{path: "run", content: "#!/usr/bin/env python3\nprint()\n", exp: "python"},
+		{path: "run", content: "#!/usr/bin/env -S VAR=1 python3.7 -u\n", exp: "python"},
+		{path: "run.py", content: "#!/bin/bash\n", exp: "bash"},
+		{path: `dir\types.d.ts`, exp: "typescript"},
+		{path: "main.go", exp: ""},
+		{path: "run", content: "#!/usr/bin/env\n", exp: ""},
+		{path: "README", exp: ""},
+	} {
+		t.Run(c.path, func(t *testing.T) {
+			var content []byte
+			if c.content != "" {
+				content = []byte(c.content)
+			}
+			got, ok := m.Match(c.path, content)
+			require.Equal(t, c.exp != "", ok)
+			if ok {
+				require.Equal(t, c.exp, got.Language)
+			}
+		})
+	}
+}
+
+func TestMatcherInvalid(t *testing.T) {
+	_, err := NewMatcher([]Manifest{
+		{Language: "foo", Files: &Files{Shebangs: []string{"foo("}}},
+	})
+	require.Error(t, err)
+}
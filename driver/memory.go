@@ -0,0 +1,62 @@
+package driver
+
+import (
+	"fmt"
+
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+// Approximate sizes of nodes in memory, in bytes.
+const (
+	sizeValue  = 16 // interface value
+	sizeObject = 48 // map header
+	sizeArray  = 24 // slice header
+	sizeField  = 16 // map key header
+)
+
+// SetMemoryLimit sets an approximate budget for the tree in bytes. The size of the tree is checked before the first
+// stage and after each stage of the pipeline; if it exceeds the budget, the pipeline stops with ErrResourceExhausted.
+// This prevents a single huge file from exhausting the memory of the process. Zero disables the limit.
+//
+// The limit applies to the tree only, the size of the native driver response should be limited separately.
+func (p *Pipeline) SetMemoryLimit(n int) {
+	p.limit = n
+}
+
+// checkMemory returns ErrResourceExhausted if the tree exceeds the memory budget of the pipeline.
+func (p *Pipeline) checkMemory(n nodes.Node) error {
+	if p.limit <= 0 {
+		return nil
+	}
+	if sz := treeSize(n, p.limit); sz > p.limit {
+		return ErrResourceExhausted.Wrap(fmt.Errorf("the tree exceeds the memory limit of %d bytes", p.limit))
+	}
+	return nil
+}
+
+// treeSize returns an approximate size of the tree in memory. It stops as soon as the size exceeds the limit,
+// thus the returned value is only exact when it's below the limit.
+func treeSize(n nodes.Node, limit int) int {
+	sz := sizeValue
+	switch n := n.(type) {
+	case nodes.Object:
+		sz += sizeObject
+		for k, v := range n {
+			if sz > limit {
+				return sz
+			}
+			sz += sizeField + len(k) + treeSize(v, limit-sz)
+		}
+	case nodes.Array:
+		sz += sizeArray
+		for _, v := range n {
+			if sz > limit {
+				return sz
+			}
+			sz += treeSize(v, limit-sz)
+		}
+	case nodes.String:
+		sz += len(n)
+	}
+	return sz
+}
@@ -0,0 +1,29 @@
+package driver
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/bblfsh/sdk.v2/driver/manifest"
+)
+
+func TestDriverMemoryLimit(t *testing.T) {
+	require := require.New(t)
+
+	m := &manifest.Manifest{Language: "fixture"}
+	d, err := NewDriverFrom(recoverNative{}, m, Transforms{MemoryLimit: 5000})
+	require.NoError(err)
+
+	ctx := context.Background()
+	_, err = d.Parse(ctx, "a b", &ParseOptions{Mode: ModeSemantic})
+	require.NoError(err)
+
+	src := strings.Repeat("word ", 100)
+	for _, mode := range []Mode{ModeNative, ModeSemantic} {
+		ast, err := d.Parse(ctx, src, &ParseOptions{Mode: mode})
+		require.True(ErrResourceExhausted.Is(err), "%v", err)
+		require.Nil(ast)
+	}
+}
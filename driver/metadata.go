@@ -0,0 +1,85 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+// MetaFilename is a metadata key for the name of the file. It is set from ParseOptions.Filename and File.Filename.
+const MetaFilename = "filename"
+
+// Metadata is a set of key-value pairs attached to a parse request, for example the filename, the project root
+// or compiler flags.
+//
+// The pipeline sets each value as a transformer.Context variable named by MetadataVar, thus transformers can check it
+// with transformer.ContextIs. Custom stages can access it via FileState.Metadata.
+type Metadata map[string]string
+
+// MetadataVar returns the name of the transformer.Context variable that holds a given metadata value.
+func MetadataVar(key string) string {
+	return "metadata." + key
+}
+
+type metadataKey struct{}
+
+// WithMetadata returns a context that carries the metadata for the pipeline. It replaces the metadata set previously.
+func WithMetadata(ctx context.Context, md Metadata) context.Context {
+	return context.WithValue(ctx, metadataKey{}, md)
+}
+
+// MetadataFrom returns the metadata attached to the context with WithMetadata.
+func MetadataFrom(ctx context.Context) Metadata {
+	md, _ := ctx.Value(metadataKey{}).(Metadata)
+	return md
+}
+
+// withMetadata attaches the metadata and the filename to the context. The context is not changed if both are empty.
+func withMetadata(ctx context.Context, filename string, md Metadata) context.Context {
+	md = fileMetadata(filename, md)
+	if md == nil {
+		return ctx
+	}
+	return WithMetadata(ctx, md)
+}
+
+// fileMetadata returns a copy of the metadata with MetaFilename set to the filename, unless it's already set.
+func fileMetadata(filename string, md Metadata) Metadata {
+	if _, ok := md[MetaFilename]; ok || filename == "" {
+		return md
+	}
+	out := make(Metadata, len(md)+1)
+	for k, v := range md {
+		out[k] = v
+	}
+	out[MetaFilename] = filename
+	return out
+}
+
+// toContext sets metadata values as variables of the transformation context.
+func (md Metadata) toContext(st *FileState) {
+	for k, v := range md {
+		st.Context.Set(MetadataVar(k), nodes.String(v))
+	}
+}
+
+// metadataString returns a string that uniquely identifies the metadata of the file.
+func metadataString(filename string, md Metadata) string {
+	md = fileMetadata(filename, md)
+	if len(md) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(md))
+	for k := range md {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var buf strings.Builder
+	for _, k := range keys {
+		fmt.Fprintf(&buf, "%q=%q ", k, md[k])
+	}
+	return buf.String()
+}
@@ -0,0 +1,481 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+
+	"gopkg.in/src-d/go-errors.v1"
+
+	"gopkg.in/bblfsh/sdk.v2/driver/manifest"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+var (
+	// ErrPanic is returned by RecoverMiddleware if the driver panics. It is wrapped into ErrDriverFailure.
+	ErrPanic = errors.NewKind("driver panic: %v")
+
+	// ErrOverloaded is returned by LimitMiddleware if the queue of requests is full. The request may be retried later.
+	ErrOverloaded = errors.NewKind("driver overloaded: %d requests in queue")
+)
+
+// Middleware wraps the driver to add a cross-cutting behavior, for example logging or caching.
+//
+// Drivers returned by the middleware should implement IncrementalDriver, ReaderDriver and Pinger if the wrapped
+// driver does, otherwise edited files will be parsed from scratch and the source will be read into memory before
+// parsing.
+type Middleware func(d Driver) Driver
+
+// WithMiddleware wraps the driver with middlewares. The first middleware in the list receives requests first.
+// Start, Close and Manifest are always called on the driver d. See Transforms.Middleware.
+func WithMiddleware(d DriverModule, list ...Middleware) DriverModule {
+	if len(list) == 0 {
+		return d
+	}
+	var dr Driver = d
+	for i := len(list) - 1; i >= 0; i-- {
+		dr = list[i](dr)
+	}
+	return &middlewareModule{Driver: dr, mod: d}
+}
+
+var (
+	_ IncrementalDriver = (*middlewareModule)(nil)
+	_ ReaderDriver      = (*middlewareModule)(nil)
+	_ Pinger            = (*middlewareModule)(nil)
+	_ TimeoutDriver     = (*middlewareModule)(nil)
+)
+
+type middlewareModule struct {
+	Driver
+	mod DriverModule
+}
+
+func (d *middlewareModule) Start() error {
+	return d.mod.Start()
+}
+
+func (d *middlewareModule) Close() error {
+	return d.mod.Close()
+}
+
+// Manifest implements DriverModule.
+func (d *middlewareModule) Manifest() (manifest.Manifest, error) {
+	return d.mod.Manifest()
+}
+
+// ParseIncremental implements IncrementalDriver.
+func (d *middlewareModule) ParseIncremental(ctx context.Context, prev Result, edits []TextEdit) (Result, error) {
+	return ParseIncremental(ctx, d.Driver, prev, edits)
+}
+
+// ParseReader implements ReaderDriver.
+func (d *middlewareModule) ParseReader(ctx context.Context, r io.Reader, size int, opts *ParseOptions) (nodes.Node, error) {
+	return ParseReader(ctx, d.Driver, r, size, opts)
+}
+
+// Ping implements Pinger.
+func (d *middlewareModule) Ping(ctx context.Context) error {
+	return pingDriver(ctx, d.Driver)
+}
+
+// SetTimeout implements TimeoutDriver. Timeouts are set on the wrapped module.
+func (d *middlewareModule) SetTimeout(stage string, t time.Duration) error {
+	if td, ok := d.mod.(TimeoutDriver); ok {
+		return td.SetTimeout(stage, t)
+	}
+	return fmt.Errorf("driver does not support stage timeouts")
+}
+
+// pingDriver calls Ping if the driver implements Pinger. Other drivers are always considered responsive.
+func pingDriver(ctx context.Context, d Driver) error {
+	if p, ok := d.(Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+// Logger is a logger used by LogMiddleware. It is implemented by server.Logger.
+type Logger interface {
+	Debugf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+}
+
+// LogMiddleware logs each request with its duration. Requests that failed with errors other than syntax errors
+// and partial transforms are logged as errors, all other requests are logged as debug messages.
+func LogMiddleware(l Logger) Middleware {
+	return func(d Driver) Driver {
+		return &logDriver{Driver: d, l: l}
+	}
+}
+
+var (
+	_ IncrementalDriver = (*logDriver)(nil)
+	_ ReaderDriver      = (*logDriver)(nil)
+	_ Pinger            = (*logDriver)(nil)
+)
+
+type logDriver struct {
+	Driver
+	l Logger
+}
+
+func (d *logDriver) log(start time.Time, format string, args []interface{}, err error) {
+	format += " in %v"
+	args = append(args, time.Since(start))
+	if err == nil {
+		d.l.Debugf(format, args...)
+		return
+	}
+	format += ": %v"
+	args = append(args, err)
+	if ErrSyntax.Is(err) || ErrPartialTransform.Is(err) {
+		d.l.Debugf(format, args...)
+		return
+	}
+	d.l.Errorf(format, args...)
+}
+
+// Parse implements Driver.
+func (d *logDriver) Parse(ctx context.Context, src string, opts *ParseOptions) (nodes.Node, error) {
+	start := time.Now()
+	ast, err := d.Driver.Parse(ctx, src, opts)
+	var lang, fname string
+	if opts != nil {
+		lang, fname = opts.Language, opts.Filename
+	}
+	d.log(start, "parsed %q (%s, %d bytes)", []interface{}{fname, lang, len(src)}, err)
+	return ast, err
+}
+
+// ParseReader implements ReaderDriver. The size of the source is logged only if it is known in advance.
+func (d *logDriver) ParseReader(ctx context.Context, r io.Reader, size int, opts *ParseOptions) (nodes.Node, error) {
+	start := time.Now()
+	ast, err := ParseReader(ctx, d.Driver, r, size, opts)
+	var lang, fname string
+	if opts != nil {
+		lang, fname = opts.Language, opts.Filename
+	}
+	if size > 0 {
+		d.log(start, "parsed %q (%s, %d bytes)", []interface{}{fname, lang, size}, err)
+	} else {
+		d.log(start, "parsed %q (%s, stream)", []interface{}{fname, lang}, err)
+	}
+	return ast, err
+}
+
+// ParseFiles implements Driver. Errors of individual files are not logged.
+func (d *logDriver) ParseFiles(ctx context.Context, mode Mode, files []File) ([]Result, error) {
+	start := time.Now()
+	res, err := d.Driver.ParseFiles(ctx, mode, files)
+	d.log(start, "parsed %d files", []interface{}{len(files)}, err)
+	return res, err
+}
+
+// ParseIncremental implements IncrementalDriver.
+func (d *logDriver) ParseIncremental(ctx context.Context, prev Result, edits []TextEdit) (Result, error) {
+	start := time.Now()
+	r, err := ParseIncremental(ctx, d.Driver, prev, edits)
+	lerr := err
+	if lerr == nil {
+		lerr = r.Err
+	}
+	d.log(start, "parsed %d edits (%s)", []interface{}{len(edits), prev.Language}, lerr)
+	return r, err
+}
+
+// Ping implements Pinger.
+func (d *logDriver) Ping(ctx context.Context) error {
+	return pingDriver(ctx, d.Driver)
+}
+
+// Metrics collects counters of the driver requests. It is safe for concurrent use. See MetricsMiddleware.
+type Metrics struct {
+	requests uint64
+	files    uint64
+	syntax   uint64
+	failures uint64
+	nanos    int64
+}
+
+// MetricsSnapshot is a copy of the counters of Metrics.
+type MetricsSnapshot struct {
+	// Requests is the number of driver calls.
+	Requests uint64
+	// Files is the number of parsed files, including the ones parsed by ParseFiles.
+	Files uint64
+	// SyntaxErrors is the number of files that failed with ErrSyntax.
+	SyntaxErrors uint64
+	// Failures is the number of files and requests that failed with other errors.
+	Failures uint64
+	// Duration is the total time spent in driver calls.
+	Duration time.Duration
+}
+
+// Snapshot returns current values of the counters.
+func (m *Metrics) Snapshot() MetricsSnapshot {
+	return MetricsSnapshot{
+		Requests:     atomic.LoadUint64(&m.requests),
+		Files:        atomic.LoadUint64(&m.files),
+		SyntaxErrors: atomic.LoadUint64(&m.syntax),
+		Failures:     atomic.LoadUint64(&m.failures),
+		Duration:     time.Duration(atomic.LoadInt64(&m.nanos)),
+	}
+}
+
+func (m *Metrics) request(start time.Time, err error) {
+	atomic.AddUint64(&m.requests, 1)
+	atomic.AddInt64(&m.nanos, int64(time.Since(start)))
+	if err != nil {
+		atomic.AddUint64(&m.failures, 1)
+	}
+}
+
+func (m *Metrics) file(err error) {
+	atomic.AddUint64(&m.files, 1)
+	switch {
+	case err == nil, ErrPartialTransform.Is(err):
+	case ErrSyntax.Is(err):
+		atomic.AddUint64(&m.syntax, 1)
+	default:
+		atomic.AddUint64(&m.failures, 1)
+	}
+}
+
+// MetricsMiddleware records the number of requests, files, errors and the time spent in the driver to m.
+func MetricsMiddleware(m *Metrics) Middleware {
+	return func(d Driver) Driver {
+		return &metricsDriver{Driver: d, m: m}
+	}
+}
+
+var (
+	_ IncrementalDriver = (*metricsDriver)(nil)
+	_ ReaderDriver      = (*metricsDriver)(nil)
+	_ Pinger            = (*metricsDriver)(nil)
+)
+
+type metricsDriver struct {
+	Driver
+	m *Metrics
+}
+
+// Parse implements Driver.
+func (d *metricsDriver) Parse(ctx context.Context, src string, opts *ParseOptions) (nodes.Node, error) {
+	start := time.Now()
+	ast, err := d.Driver.Parse(ctx, src, opts)
+	d.m.request(start, nil)
+	d.m.file(err)
+	return ast, err
+}
+
+// ParseReader implements ReaderDriver.
+func (d *metricsDriver) ParseReader(ctx context.Context, r io.Reader, size int, opts *ParseOptions) (nodes.Node, error) {
+	start := time.Now()
+	ast, err := ParseReader(ctx, d.Driver, r, size, opts)
+	d.m.request(start, nil)
+	d.m.file(err)
+	return ast, err
+}
+
+// ParseFiles implements Driver.
+func (d *metricsDriver) ParseFiles(ctx context.Context, mode Mode, files []File) ([]Result, error) {
+	start := time.Now()
+	res, err := d.Driver.ParseFiles(ctx, mode, files)
+	d.m.request(start, err)
+	for _, r := range res {
+		d.m.file(r.Err)
+	}
+	return res, err
+}
+
+// ParseIncremental implements IncrementalDriver.
+func (d *metricsDriver) ParseIncremental(ctx context.Context, prev Result, edits []TextEdit) (Result, error) {
+	start := time.Now()
+	r, err := ParseIncremental(ctx, d.Driver, prev, edits)
+	d.m.request(start, err)
+	if err == nil {
+		d.m.file(r.Err)
+	}
+	return r, err
+}
+
+// Ping implements Pinger.
+func (d *metricsDriver) Ping(ctx context.Context) error {
+	return pingDriver(ctx, d.Driver)
+}
+
+// CacheMiddleware caches parse results. See NewCachedDriver.
+//
+// Since the cache is keyed by the source, drivers returned by the middleware do not implement ReaderDriver, and
+// the source is always read into memory before parsing.
+func CacheMiddleware(c *ParseCache) Middleware {
+	return func(d Driver) Driver {
+		return &cachedDriver{Driver: d, c: c}
+	}
+}
+
+// RecoverMiddleware converts panics of the driver into ErrDriverFailure errors, instead of crashing the process.
+func RecoverMiddleware() Middleware {
+	return func(d Driver) Driver {
+		return &recoverDriver{Driver: d}
+	}
+}
+
+var (
+	_ IncrementalDriver = (*recoverDriver)(nil)
+	_ ReaderDriver      = (*recoverDriver)(nil)
+	_ Pinger            = (*recoverDriver)(nil)
+)
+
+type recoverDriver struct {
+	Driver
+}
+
+// recoverPanic sets the error if the call panicked. It must be deferred.
+func recoverPanic(err *error) {
+	if r := recover(); r != nil {
+		*err = ErrDriverFailure.Wrap(ErrPanic.New(r))
+	}
+}
+
+// Parse implements Driver.
+func (d *recoverDriver) Parse(ctx context.Context, src string, opts *ParseOptions) (_ nodes.Node, err error) {
+	defer recoverPanic(&err)
+	return d.Driver.Parse(ctx, src, opts)
+}
+
+// ParseReader implements ReaderDriver.
+func (d *recoverDriver) ParseReader(ctx context.Context, r io.Reader, size int, opts *ParseOptions) (_ nodes.Node, err error) {
+	defer recoverPanic(&err)
+	return ParseReader(ctx, d.Driver, r, size, opts)
+}
+
+// ParseFiles implements Driver.
+func (d *recoverDriver) ParseFiles(ctx context.Context, mode Mode, files []File) (_ []Result, err error) {
+	defer recoverPanic(&err)
+	return d.Driver.ParseFiles(ctx, mode, files)
+}
+
+// Capabilities implements Driver.
+func (d *recoverDriver) Capabilities(ctx context.Context) (_ Capabilities, err error) {
+	defer recoverPanic(&err)
+	return d.Driver.Capabilities(ctx)
+}
+
+// ParseIncremental implements IncrementalDriver.
+func (d *recoverDriver) ParseIncremental(ctx context.Context, prev Result, edits []TextEdit) (_ Result, err error) {
+	defer recoverPanic(&err)
+	return ParseIncremental(ctx, d.Driver, prev, edits)
+}
+
+// Ping implements Pinger.
+func (d *recoverDriver) Ping(ctx context.Context) (err error) {
+	defer recoverPanic(&err)
+	return pingDriver(ctx, d.Driver)
+}
+
+// LimitMiddleware restricts the number of requests processed by the driver concurrently. Requests above the limit
+// wait in a queue of a given size until one of the running requests completes, or until the context is cancelled.
+// If the queue is full, the request fails immediately with ErrOverloaded. Zero queue size disables queueing.
+//
+// Capabilities and Ping are not restricted.
+func LimitMiddleware(max, queue int) Middleware {
+	if max <= 0 {
+		max = 1
+	}
+	if queue < 0 {
+		queue = 0
+	}
+	return func(d Driver) Driver {
+		return &limitDriver{Driver: d, active: make(chan struct{}, max), queue: int64(queue)}
+	}
+}
+
+var (
+	_ IncrementalDriver = (*limitDriver)(nil)
+	_ ReaderDriver      = (*limitDriver)(nil)
+	_ Pinger            = (*limitDriver)(nil)
+)
+
+type limitDriver struct {
+	Driver
+	active  chan struct{} // semaphore for running requests
+	queue   int64
+	waiting int64 // number of queued requests; accessed atomically
+}
+
+// acquire waits until the request can be processed. The caller must call release if it returns no error.
+func (d *limitDriver) acquire(ctx context.Context) error {
+	select {
+	case d.active <- struct{}{}:
+		return nil
+	default:
+	}
+	for {
+		n := atomic.LoadInt64(&d.waiting)
+		if n >= d.queue {
+			return ErrOverloaded.New(n)
+		} else if err := ctx.Err(); err != nil {
+			// cancelled requests never take a place in the queue
+			return err
+		}
+		if atomic.CompareAndSwapInt64(&d.waiting, n, n+1) {
+			break
+		}
+	}
+	defer atomic.AddInt64(&d.waiting, -1)
+	select {
+	case d.active <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (d *limitDriver) release() {
+	<-d.active
+}
+
+// Parse implements Driver.
+func (d *limitDriver) Parse(ctx context.Context, src string, opts *ParseOptions) (nodes.Node, error) {
+	if err := d.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer d.release()
+	return d.Driver.Parse(ctx, src, opts)
+}
+
+// ParseReader implements ReaderDriver.
+func (d *limitDriver) ParseReader(ctx context.Context, r io.Reader, size int, opts *ParseOptions) (nodes.Node, error) {
+	if err := d.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer d.release()
+	return ParseReader(ctx, d.Driver, r, size, opts)
+}
+
+// ParseFiles implements Driver. All files are counted as a single request.
+func (d *limitDriver) ParseFiles(ctx context.Context, mode Mode, files []File) ([]Result, error) {
+	if err := d.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer d.release()
+	return d.Driver.ParseFiles(ctx, mode, files)
+}
+
+// ParseIncremental implements IncrementalDriver.
+func (d *limitDriver) ParseIncremental(ctx context.Context, prev Result, edits []TextEdit) (Result, error) {
+	if err := d.acquire(ctx); err != nil {
+		return Result{}, err
+	}
+	defer d.release()
+	return ParseIncremental(ctx, d.Driver, prev, edits)
+}
+
+// Ping implements Pinger.
+func (d *limitDriver) Ping(ctx context.Context) error {
+	return pingDriver(ctx, d.Driver)
+}
@@ -0,0 +1,94 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/bblfsh/sdk.v2/driver/manifest"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+// testLogger records all log messages.
+type testLogger struct {
+	lines []string
+}
+
+func (l *testLogger) Debugf(format string, args ...interface{}) {
+	l.lines = append(l.lines, "debug: "+fmt.Sprintf(format, args...))
+}
+
+func (l *testLogger) Errorf(format string, args ...interface{}) {
+	l.lines = append(l.lines, "error: "+fmt.Sprintf(format, args...))
+}
+
+// panicDriver panics if the source is "panic".
+type panicDriver struct {
+	Driver
+}
+
+func (d panicDriver) Parse(ctx context.Context, src string, opts *ParseOptions) (nodes.Node, error) {
+	if src == "panic" {
+		panic("boom")
+	}
+	return d.Driver.Parse(ctx, src, opts)
+}
+
+func TestDriverMiddleware(t *testing.T) {
+	require := require.New(t)
+
+	var (
+		log     testLogger
+		metrics Metrics
+	)
+	m := &manifest.Manifest{Language: "fixture"}
+	d, err := NewDriverFrom(echoNative{}, m, Transforms{
+		Middleware: []Middleware{
+			RecoverMiddleware(),
+			LogMiddleware(&log),
+			MetricsMiddleware(&metrics),
+			CacheMiddleware(NewParseCache(10)),
+			func(d Driver) Driver { return panicDriver{d} },
+		},
+	})
+	require.NoError(err)
+
+	mf, err := d.Manifest()
+	require.NoError(err)
+	require.Equal("fixture", mf.Language)
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		ast, err := d.Parse(ctx, "a", &ParseOptions{Mode: ModeNative, Filename: "a.f"})
+		require.NoError(err)
+		require.Equal(nodes.Object{"src": nodes.String("a")}, ast)
+	}
+	_, err = d.Parse(ctx, "", nil)
+	require.True(ErrSyntax.Is(err), "%v", err)
+
+	_, err = d.Parse(ctx, "panic", nil)
+	require.True(ErrDriverFailure.Is(err), "%v", err)
+	require.Contains(err.Error(), "boom")
+
+	res, err := d.ParseFiles(ctx, ModeNative, []File{{Content: "a"}, {Content: "b"}})
+	require.NoError(err)
+	require.Len(res, 2)
+
+	s := metrics.Snapshot()
+	// the panic is not counted, since it is recovered by the outer middleware
+	require.Equal(uint64(4), s.Requests)
+	require.Equal(uint64(5), s.Files)
+	require.Equal(uint64(1), s.SyntaxErrors)
+	require.Equal(uint64(0), s.Failures)
+
+	require.Len(log.lines, 4)
+	require.True(strings.HasPrefix(log.lines[0], `debug: parsed "a.f" (fixture, 1 bytes) in `), log.lines[0])
+	require.True(strings.HasPrefix(log.lines[2], "debug: parsed \"\" (, 0 bytes) in "), log.lines[2])
+	require.True(strings.HasPrefix(log.lines[3], "debug: parsed 2 files in "), log.lines[3])
+
+	// incremental parsing is preserved by all middlewares
+	_, ok := d.(IncrementalDriver)
+	require.True(ok)
+}
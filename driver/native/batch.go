@@ -0,0 +1,108 @@
+package native
+
+import (
+	"context"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+
+	"gopkg.in/bblfsh/sdk.v2/driver"
+)
+
+var _ driver.BatchNative = (*Driver)(nil)
+
+// BatchResult is a result of parsing a single file of the batch. See Driver.ParseBatch.
+type BatchResult = driver.NativeResult
+
+// ParseBatch parses multiple files in a single round trip to the native driver. Results are returned in the same
+// order as the sources. Errors of individual files are returned in the results, while the returned error indicates
+// a failure of the whole batch.
+//
+// If the native driver does not declare CapBatch in the handshake, the files are parsed one by one.
+func (d *Driver) ParseBatch(rctx context.Context, srcs []string) (_ []BatchResult, err error) {
+	sp, ctx := opentracing.StartSpanFromContext(rctx, "bblfsh.native.ParseBatch")
+	defer sp.Finish()
+
+	if len(srcs) == 0 {
+		return nil, nil
+	}
+	if err := d.reqs.begin(); err != nil {
+		return nil, driver.ErrDriverFailure.Wrap(err)
+	}
+	defer d.reqs.end()
+	if p := d.Protocol(); !p.Has(CapBatch) {
+		out := make([]BatchResult, 0, len(srcs))
+		for _, src := range srcs {
+			ast, err := d.parse(ctx, src, parseRequest{}, nil)
+			out = append(out, BatchResult{AST: ast, Err: err})
+		}
+		return out, nil
+	}
+
+	start := time.Now()
+	batch := make([]string, 0, len(srcs))
+	for _, src := range srcs {
+		str, err := d.ec.Encode(src)
+		if err != nil {
+			return nil, driver.ErrDriverFailure.Wrap(err)
+		}
+		batch = append(batch, str)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.metrics != nil {
+		done := d.observe(ctx, start)
+		defer func() {
+			done(err)
+		}()
+	}
+	if err = d.ensureRunning(); err != nil {
+		return nil, err
+	}
+
+	var out []BatchResult
+	req := newRequest(ctx, parseRequest{Batch: batch, Encoding: d.ec})
+	if d.mux != nil {
+		err = d.muxExchange(ctx, req, func(next func() (*parseResponse, error)) error {
+			out, _, err = readBatch(len(batch), next)
+			return err
+		}, true)
+	} else {
+		err = d.exchange(ctx, req, func() error {
+			var i int
+			out, i, err = readBatch(len(batch), func() (*parseResponse, error) {
+				return d.readResponse(ctx)
+			})
+			if err != nil && d.state == stateTimeout {
+				// skip responses for the rest of the batch as well
+				d.pending = len(batch) - i
+			}
+			return err
+		})
+	}
+	if err != nil {
+		return nil, driver.ErrDriverFailure.Wrap(err)
+	}
+	d.crashes = 0
+	return out, nil
+}
+
+// readBatch reads responses for n files of the batch with next. If it fails, it returns the index of the file
+// that was being read.
+func readBatch(n int, next func() (*parseResponse, error)) ([]BatchResult, int, error) {
+	out := make([]BatchResult, 0, n)
+	for i := 0; i < n; i++ {
+		r, err := collectStream(next, nil)
+		if ErrResponseTooLarge.Is(err) {
+			out = append(out, BatchResult{Err: driver.ErrDriverFailure.Wrap(err)})
+			continue
+		} else if err != nil {
+			return nil, i, err
+		}
+		ast, err := r.result()
+		out = append(out, BatchResult{AST: ast, Err: err})
+	}
+	return out, n, nil
+}
@@ -0,0 +1,77 @@
+package native
+
+import (
+	"context"
+	"time"
+)
+
+// CancelPolicy defines what happens to the native process when a request is cancelled or its deadline is exceeded.
+type CancelPolicy int
+
+const (
+	// CancelWait keeps the native process running. Its late response is discarded before the next request.
+	CancelWait = CancelPolicy(iota)
+	// CancelKill kills the native process and starts a new one on the next request. It prevents runaway parses
+	// from delaying the following requests. It is ignored for native drivers that process requests concurrently,
+	// since other requests would fail as well.
+	CancelKill
+)
+
+// SetCancelPolicy sets the policy for cancelled requests. The default policy is CancelWait. If restarts are
+// enabled, it does not apply to the timeout of driver.StageNative: the process is stopped and restarted according
+// to the restart policy.
+func (d *Driver) SetCancelPolicy(p CancelPolicy) {
+	d.mu.Lock()
+	d.cancel = p
+	d.mu.Unlock()
+}
+
+// watchCancel interrupts reads and writes to the native process when the context is cancelled.
+// The returned function stops watching and clears IO deadlines set on cancellation.
+func (d *Driver) watchCancel(ctx context.Context) func() {
+	done := ctx.Done()
+	if done == nil {
+		return func() {}
+	}
+	stop, fired := make(chan struct{}), make(chan bool, 1)
+	in, out := d.stdin, d.stdout
+	go func() {
+		select {
+		case <-done:
+			// any time in the past unblocks pending operations
+			past := time.Unix(1, 0)
+			_ = out.SetReadDeadline(past)
+			_ = in.SetWriteDeadline(past)
+			fired <- true
+		case <-stop:
+			fired <- false
+		}
+	}()
+	return func() {
+		close(stop)
+		if <-fired {
+			_ = in.SetWriteDeadline(time.Time{})
+			_ = out.SetReadDeadline(time.Time{})
+		}
+	}
+}
+
+// expired checks if the context is cancelled or its deadline has passed. IO deadlines may fire slightly before
+// the context is marked as done.
+func expired(ctx context.Context) bool {
+	if ctx.Err() != nil {
+		return true
+	}
+	deadline, ok := ctx.Deadline()
+	return ok && !time.Now().Before(deadline)
+}
+
+// cancelled is called when the request failed because the context was cancelled. It must be called with
+// the mutex held.
+func (d *Driver) cancelled() {
+	if d.cancel != CancelKill || d.state == stateBroken {
+		return
+	}
+	_ = d.stop()
+	d.state = stateKilled
+}
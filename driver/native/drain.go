@@ -0,0 +1,73 @@
+package native
+
+import (
+	"sync"
+	"time"
+
+	serrors "gopkg.in/src-d/go-errors.v1"
+)
+
+// ErrClosing is returned for requests sent while Close waits for in-flight requests to finish.
+var ErrClosing = serrors.NewKind("native driver is closing")
+
+// requests tracks requests in progress, allowing Close to wait for them to finish.
+type requests struct {
+	mu      sync.Mutex
+	n       int
+	closing bool
+	idle    chan struct{} // closed when the last request finishes; nil if nobody waits
+}
+
+// begin registers a new request. It fails if the driver is closing.
+func (r *requests) begin() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closing {
+		return ErrClosing.New()
+	}
+	r.n++
+	return nil
+}
+
+// end is called when the request finishes.
+func (r *requests) end() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.n--
+	if r.n == 0 && r.idle != nil {
+		close(r.idle)
+		r.idle = nil
+	}
+}
+
+// drain rejects new requests and waits until all requests in progress finish or the timeout expires.
+// It returns false in the latter case.
+func (r *requests) drain(timeout time.Duration) bool {
+	r.mu.Lock()
+	r.closing = true
+	if r.n == 0 {
+		r.mu.Unlock()
+		return true
+	}
+	if r.idle == nil {
+		r.idle = make(chan struct{})
+	}
+	idle := r.idle
+	r.mu.Unlock()
+
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+	select {
+	case <-idle:
+		return true
+	case <-t.C:
+		return false
+	}
+}
+
+// reopen allows new requests after the driver was closed.
+func (r *requests) reopen() {
+	r.mu.Lock()
+	r.closing = false
+	r.mu.Unlock()
+}
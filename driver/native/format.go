@@ -0,0 +1,612 @@
+package native
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/bblfsh/sdk.v2/driver"
+	derrors "gopkg.in/bblfsh/sdk.v2/driver/errors"
+	"gopkg.in/bblfsh/sdk.v2/driver/native/frames"
+	"gopkg.in/bblfsh/sdk.v2/driver/native/jsonlines"
+	"gopkg.in/bblfsh/sdk.v2/driver/native/msgpack"
+	"gopkg.in/bblfsh/sdk.v2/uast"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+	serrors "gopkg.in/src-d/go-errors.v1"
+)
+
+// FormatEnv is the environment variable used to pass the wire format to the native driver process.
+// Native drivers must use the format specified in this variable, or JSONLines if it is not set.
+const FormatEnv = "BBLFSH_NATIVE_FORMAT"
+
+var (
+	ErrUnsupportedFormat = serrors.NewKind("unsupported native protocol format: %q")
+)
+
+// Format is a wire format of the native driver protocol.
+type Format string
+
+const (
+	// JSONLines encodes each message as a JSON object on a separate line. This is the default format.
+	JSONLines = Format("jsonlines")
+	// MsgPack encodes each message as a MessagePack map with the same fields as in JSON.
+	MsgPack = Format("msgpack")
+	// FramedJSON encodes each message as JSON, prefixed with its size. See frames package.
+	FramedJSON = Format("framed-json")
+	// GRPC is used by native drivers that implement the gRPC contract. It cannot be used with Driver,
+	// see NewGRPCDriverAt.
+	GRPC = Format("grpc")
+)
+
+// FormatFromEnv returns the format requested by the Go driver. See FormatEnv.
+func FormatFromEnv() (Format, error) {
+	f := Format(strings.ToLower(os.Getenv(FormatEnv)))
+	if f == "" {
+		return JSONLines, nil
+	}
+	return f, f.validate()
+}
+
+func (f Format) validate() error {
+	switch f {
+	case JSONLines, MsgPack, FramedJSON, GRPC:
+		return nil
+	}
+	return ErrUnsupportedFormat.New(string(f))
+}
+
+// msgEncoder writes messages of the native protocol.
+type msgEncoder interface {
+	Encode(v interface{}) error
+}
+
+// msgDecoder reads messages of the native protocol.
+type msgDecoder interface {
+	Decode(v interface{}) error
+	// Skip reads the next message without interpreting it and returns its text representation.
+	Skip() (string, error)
+}
+
+func (f Format) newEncoder(w io.Writer) msgEncoder {
+	switch f {
+	case MsgPack:
+		return &msgpackEncoder{enc: msgpack.NewEncoder(w)}
+	case FramedJSON:
+		return &framedEncoder{w: frames.NewWriter(w)}
+	}
+	return jsonlines.NewEncoder(w)
+}
+
+// newDecoder creates a decoder for the format. Messages larger than max bytes are rejected; zero means no limit.
+// The decoder can be used after a message is rejected, see tooLarge.
+func (f Format) newDecoder(r io.Reader, max int) msgDecoder {
+	dec := f.newFrameDecoder(r, max)
+	if max > 0 {
+		// the limit also applies to the decompressed AST, see decodeAST
+		dec = &limitDecoder{msgDecoder: dec, max: max}
+	}
+	return dec
+}
+
+func (f Format) newFrameDecoder(r io.Reader, max int) msgDecoder {
+	switch f {
+	case MsgPack:
+		if max > 0 {
+			return &msgpackDecoder{dec: msgpack.NewLimitedDecoder(r, max)}
+		}
+		return &msgpackDecoder{dec: msgpack.NewDecoder(r)}
+	case FramedJSON:
+		return &framedDecoder{r: frames.NewReader(r, max)}
+	}
+	if max > 0 {
+		return &jsonDecoder{Decoder: jsonlines.NewLimitedDecoder(r, max)}
+	}
+	return &jsonDecoder{Decoder: jsonlines.NewDecoder(r)}
+}
+
+// tooLarge checks if the error was returned by a decoder because the message exceeds the size limit.
+func tooLarge(err error) bool {
+	return jsonlines.ErrLineTooLong.Is(err) ||
+		frames.ErrFrameTooLarge.Is(err) ||
+		msgpack.ErrValueTooLarge.Is(err) ||
+		ErrResponseTooLarge.Is(err)
+}
+
+// limitDecoder passes the size limit to the responses it decodes.
+type limitDecoder struct {
+	msgDecoder
+	max int
+}
+
+func (d *limitDecoder) Decode(v interface{}) error {
+	if r, ok := v.(*parseResponse); ok {
+		r.max = d.max
+	}
+	return d.msgDecoder.Decode(v)
+}
+
+type jsonDecoder struct {
+	jsonlines.Decoder
+}
+
+func (d *jsonDecoder) Skip() (string, error) {
+	var raw json.RawMessage
+	if err := d.Decode(&raw); err != nil {
+		return "", err
+	}
+	return string(raw), nil
+}
+
+type framedEncoder struct {
+	w frames.Writer
+}
+
+func (e *framedEncoder) Encode(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return e.w.WriteFrame(data)
+}
+
+type framedDecoder struct {
+	r frames.Reader
+}
+
+func (d *framedDecoder) Decode(v interface{}) error {
+	data, err := d.r.ReadFrame()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func (d *framedDecoder) Skip() (string, error) {
+	data, err := d.r.ReadFrame()
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// msgpackMessage is implemented by protocol messages that can be sent in the MessagePack format.
+type msgpackMessage interface {
+	toNode() nodes.Object
+	fromNode(obj nodes.Object) error
+}
+
+type msgpackEncoder struct {
+	enc msgpack.Encoder
+}
+
+func (e *msgpackEncoder) Encode(v interface{}) error {
+	m, ok := v.(msgpackMessage)
+	if !ok {
+		return fmt.Errorf("unsupported message type: %T", v)
+	}
+	return e.enc.Encode(m.toNode())
+}
+
+type msgpackDecoder struct {
+	dec msgpack.Decoder
+}
+
+func (d *msgpackDecoder) Decode(v interface{}) error {
+	m, ok := v.(msgpackMessage)
+	if !ok {
+		return fmt.Errorf("unsupported message type: %T", v)
+	}
+	n, err := d.dec.Decode()
+	if err != nil {
+		return err
+	}
+	obj, ok := n.(nodes.Object)
+	if !ok {
+		return fmt.Errorf("expected an object, got: %T", n)
+	}
+	return m.fromNode(obj)
+}
+
+func (d *msgpackDecoder) Skip() (string, error) {
+	n, err := d.dec.Decode()
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(n)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// field returns a string field of the message.
+func field(obj nodes.Object, key string) (string, error) {
+	switch v := obj[key].(type) {
+	case nil:
+		return "", nil
+	case nodes.String:
+		return string(v), nil
+	default:
+		return "", fmt.Errorf("expected a string in %q, got: %T", key, v)
+	}
+}
+
+func (r *parseRequest) toNode() nodes.Object {
+	obj := nodes.Object{
+		"content":  nodes.String(r.Content),
+		"Encoding": nodes.String(r.Encoding),
+	}
+	if r.Ping {
+		obj["ping"] = nodes.Bool(true)
+	}
+	if r.Tokens {
+		obj["tokens"] = nodes.Bool(true)
+	}
+	if r.Hello != nil {
+		obj["hello"] = r.Hello.toNode()
+	}
+	if r.Deadline != nil {
+		obj["deadline"] = nodes.String(r.Deadline.Format(time.RFC3339Nano))
+	}
+	if len(r.Batch) != 0 {
+		obj["batch"] = stringsToNode(r.Batch)
+	}
+	if r.ID != 0 {
+		obj["id"] = nodes.Uint(r.ID)
+	}
+	if r.Options != nil {
+		obj["options"] = optionsToNode(r.Options)
+	}
+	return obj
+}
+
+func (r *parseRequest) fromNode(obj nodes.Object) error {
+	content, err := field(obj, "content")
+	if err != nil {
+		return err
+	}
+	enc, err := field(obj, "Encoding")
+	if err != nil {
+		return err
+	}
+	ping, ok := obj["ping"].(nodes.Bool)
+	if !ok && obj["ping"] != nil {
+		return fmt.Errorf("expected a bool in %q, got: %T", "ping", obj["ping"])
+	}
+	tokens, ok := obj["tokens"].(nodes.Bool)
+	if !ok && obj["tokens"] != nil {
+		return fmt.Errorf("expected a bool in %q, got: %T", "tokens", obj["tokens"])
+	}
+	hello, err := protocolField(obj, "hello")
+	if err != nil {
+		return err
+	}
+	var deadline *time.Time
+	if str, err := field(obj, "deadline"); err != nil {
+		return err
+	} else if str != "" {
+		t, err := time.Parse(time.RFC3339Nano, str)
+		if err != nil {
+			return err
+		}
+		deadline = &t
+	}
+	batch, err := stringsField(obj, "batch")
+	if err != nil {
+		return err
+	}
+	id, err := idField(obj)
+	if err != nil {
+		return err
+	}
+	opts, err := optionsField(obj, "options")
+	if err != nil {
+		return err
+	}
+	*r = parseRequest{
+		Content: content, Encoding: Encoding(strings.ToLower(enc)),
+		Ping: bool(ping), Hello: hello, Deadline: deadline, Batch: batch, ID: id, Options: opts,
+		Tokens: bool(tokens),
+	}
+	return nil
+}
+
+func (r *parseResponse) toNode() nodes.Object {
+	obj := nodes.Object{
+		"status": nodes.String(r.Status),
+		"ast":    r.AST,
+	}
+	if r.Encoding != "" {
+		obj["encoding"] = nodes.String(r.Encoding)
+	}
+	if r.Chunks != "" {
+		obj["chunks"] = nodes.String(r.Chunks)
+	}
+	if r.Errors != nil {
+		obj["errors"] = stringsToNode(r.Errors)
+	}
+	if r.Diagnostics != nil {
+		obj["diagnostics"] = diagnosticsToNode(r.Diagnostics)
+	}
+	if r.Hello != nil {
+		obj["hello"] = r.Hello.toNode()
+	}
+	if r.ID != 0 {
+		obj["id"] = nodes.Uint(r.ID)
+	}
+	if r.Options != nil {
+		obj["options"] = optionsToNode(r.Options)
+	}
+	return obj
+}
+
+func (r *parseResponse) fromNode(obj nodes.Object) error {
+	max := r.max
+	st, err := field(obj, "status")
+	if err != nil {
+		return err
+	}
+	errs, err := stringsField(obj, "errors")
+	if err != nil {
+		return err
+	}
+	diags, err := diagnosticsField(obj, "diagnostics")
+	if err != nil {
+		return err
+	}
+	hello, err := protocolField(obj, "hello")
+	if err != nil {
+		return err
+	}
+	enc, err := field(obj, "encoding")
+	if err != nil {
+		return err
+	}
+	chunks, err := field(obj, "chunks")
+	if err != nil {
+		return err
+	}
+	id, err := idField(obj)
+	if err != nil {
+		return err
+	}
+	opts, err := optionsField(obj, "options")
+	if err != nil {
+		return err
+	}
+	*r = parseResponse{
+		Status:      status(strings.ToLower(st)),
+		Errors:      errs,
+		Diagnostics: diags,
+		AST:         obj["ast"],
+		Encoding:    Encoding(strings.ToLower(enc)),
+		Chunks:      chunks,
+		Hello:       hello,
+		ID:          id,
+		Options:     opts,
+		max:         max,
+	}
+	return r.decodeAST()
+}
+
+func (p *ProtocolInfo) toNode() nodes.Object {
+	encs := make([]string, 0, len(p.Encodings))
+	for _, e := range p.Encodings {
+		encs = append(encs, string(e))
+	}
+	return nodes.Object{
+		"version":      nodes.Int(p.Version),
+		"encodings":    stringsToNode(encs),
+		"capabilities": stringsToNode(p.Capabilities),
+	}
+}
+
+// protocolField returns the protocol information stored in a given field of the message.
+func protocolField(obj nodes.Object, key string) (*ProtocolInfo, error) {
+	var p ProtocolInfo
+	switch v := obj[key].(type) {
+	case nil:
+		return nil, nil
+	case nodes.Object:
+		ver, ok := v["version"].(nodes.Int)
+		if !ok && v["version"] != nil {
+			return nil, fmt.Errorf("expected an int in %q, got: %T", "version", v["version"])
+		}
+		p.Version = int(ver)
+		encs, err := stringsField(v, "encodings")
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range encs {
+			p.Encodings = append(p.Encodings, Encoding(strings.ToLower(e)))
+		}
+		p.Capabilities, err = stringsField(v, "capabilities")
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("expected an object in %q, got: %T", key, v)
+	}
+	return &p, nil
+}
+
+func optionsToNode(o *driver.LanguageOptions) nodes.Object {
+	obj := nodes.Object{}
+	if o.Version != "" {
+		obj["version"] = nodes.String(o.Version)
+	}
+	if len(o.Dialects) != 0 {
+		obj["dialects"] = stringsToNode(o.Dialects)
+	}
+	if o.Strict {
+		obj["strict"] = nodes.Bool(true)
+	}
+	return obj
+}
+
+// optionsField returns the language options stored in a given field of the message.
+func optionsField(obj nodes.Object, key string) (*driver.LanguageOptions, error) {
+	switch v := obj[key].(type) {
+	case nil:
+		return nil, nil
+	case nodes.Object:
+		ver, err := field(v, "version")
+		if err != nil {
+			return nil, err
+		}
+		dialects, err := stringsField(v, "dialects")
+		if err != nil {
+			return nil, err
+		}
+		strict, ok := v["strict"].(nodes.Bool)
+		if !ok && v["strict"] != nil {
+			return nil, fmt.Errorf("expected a bool in %q, got: %T", "strict", v["strict"])
+		}
+		return &driver.LanguageOptions{Version: ver, Dialects: dialects, Strict: bool(strict)}, nil
+	default:
+		return nil, fmt.Errorf("expected an object in %q, got: %T", key, v)
+	}
+}
+
+// idField returns the request ID stored in the message. Small unsigned values are decoded as nodes.Int.
+func idField(obj nodes.Object) (uint64, error) {
+	return uintField(obj, "id")
+}
+
+// uintField returns an unsigned integer stored in a given field of the message.
+func uintField(obj nodes.Object, key string) (uint64, error) {
+	switch v := obj[key].(type) {
+	case nil:
+		return 0, nil
+	case nodes.Uint:
+		return uint64(v), nil
+	case nodes.Int:
+		if v >= 0 {
+			return uint64(v), nil
+		}
+	}
+	return 0, fmt.Errorf("expected an unsigned int in %q, got: %v", key, obj[key])
+}
+
+// stringsField returns a list of strings stored in a given field of the message.
+func stringsField(obj nodes.Object, key string) ([]string, error) {
+	switch arr := obj[key].(type) {
+	case nil:
+		return nil, nil
+	case nodes.Array:
+		out := make([]string, 0, len(arr))
+		for _, e := range arr {
+			s, ok := e.(nodes.String)
+			if !ok {
+				return nil, fmt.Errorf("expected a string in %s list, got: %T", key, e)
+			}
+			out = append(out, string(s))
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("expected an array in %q, got: %T", key, arr)
+	}
+}
+
+func stringsToNode(arr []string) nodes.Array {
+	out := make(nodes.Array, 0, len(arr))
+	for _, s := range arr {
+		out = append(out, nodes.String(s))
+	}
+	return out
+}
+
+func diagnosticsToNode(arr []*derrors.Diagnostic) nodes.Array {
+	out := make(nodes.Array, 0, len(arr))
+	for _, d := range arr {
+		obj := nodes.Object{"message": nodes.String(d.Message)}
+		if d.Severity != derrors.SeverityError {
+			obj["severity"] = nodes.String(d.Severity.String())
+		}
+		if d.Code != "" {
+			obj["code"] = nodes.String(d.Code)
+		}
+		if d.Start != nil {
+			obj["start"] = positionToNode(*d.Start)
+		}
+		if d.End != nil {
+			obj["end"] = positionToNode(*d.End)
+		}
+		out = append(out, obj)
+	}
+	return out
+}
+
+// diagnosticsField returns a list of diagnostics stored in a given field of the message.
+func diagnosticsField(obj nodes.Object, key string) ([]*derrors.Diagnostic, error) {
+	switch arr := obj[key].(type) {
+	case nil:
+		return nil, nil
+	case nodes.Array:
+		out := make([]*derrors.Diagnostic, 0, len(arr))
+		for _, e := range arr {
+			v, ok := e.(nodes.Object)
+			if !ok {
+				return nil, fmt.Errorf("expected an object in %s list, got: %T", key, e)
+			}
+			v = foldKeys(v)
+			d := &derrors.Diagnostic{}
+			var err error
+			if d.Message, err = field(v, "message"); err != nil {
+				return nil, err
+			}
+			if d.Code, err = field(v, "code"); err != nil {
+				return nil, err
+			}
+			sev, err := field(v, "severity")
+			if err != nil {
+				return nil, err
+			} else if sev != "" {
+				if err = d.Severity.UnmarshalText([]byte(strings.ToLower(sev))); err != nil {
+					return nil, err
+				}
+			}
+			if d.Start, err = positionField(v, "start"); err != nil {
+				return nil, err
+			}
+			if d.End, err = positionField(v, "end"); err != nil {
+				return nil, err
+			}
+			out = append(out, d)
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("expected an array in %q, got: %T", key, arr)
+	}
+}
+
+func positionToNode(p uast.Position) nodes.Object {
+	return nodes.Object{
+		"offset": nodes.Uint(p.Offset),
+		"line":   nodes.Uint(p.Line),
+		"col":    nodes.Uint(p.Col),
+	}
+}
+
+// positionField returns a position stored in a given field of the message.
+func positionField(obj nodes.Object, key string) (*uast.Position, error) {
+	switch v := obj[key].(type) {
+	case nil:
+		return nil, nil
+	case nodes.Object:
+		v = foldKeys(v)
+		var vals [3]uint64
+		for i, k := range []string{"offset", "line", "col"} {
+			var err error
+			if vals[i], err = uintField(v, k); err != nil {
+				return nil, err
+			}
+		}
+		return &uast.Position{Offset: uint32(vals[0]), Line: uint32(vals[1]), Col: uint32(vals[2])}, nil
+	default:
+		return nil, fmt.Errorf("expected an object in %q, got: %T", key, v)
+	}
+}
@@ -0,0 +1,6 @@
+// Package frames implements length-prefixed framing of messages. Each frame is preceded by a header with
+// the size of the payload, encoded as a 32 bit big-endian integer.
+//
+// Unlike JSON lines, payloads may contain any bytes, and the reader knows the size of the message
+// before reading it, thus it can allocate the buffer upfront and reject messages that are too large.
+package frames
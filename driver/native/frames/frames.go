@@ -0,0 +1,105 @@
+package frames
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"math"
+
+	"gopkg.in/src-d/go-errors.v1"
+)
+
+const (
+	// HeaderSize is the size of the frame header in bytes.
+	HeaderSize = 4
+
+	// DefaultBufferSize is the default buffer size for reading. It will
+	// be used whenever the given reader is not buffered.
+	DefaultBufferSize = 64 * 1024
+)
+
+// ErrFrameTooLarge is returned when the size of the frame exceeds the limit.
+var ErrFrameTooLarge = errors.NewKind("frame size %d exceeds the limit of %d bytes")
+
+// Reader reads length-prefixed frames.
+type Reader interface {
+	// ReadFrame reads the next frame and returns its payload. The slice is only valid until the next call.
+	//
+	// If the frame exceeds the size limit, its payload is discarded and ErrFrameTooLarge is returned.
+	// The reader can be used after this error.
+	ReadFrame() ([]byte, error)
+}
+
+type reader struct {
+	r   io.Reader
+	max int
+	hdr [HeaderSize]byte
+	buf []byte
+}
+
+// NewReader creates a new frame reader with the given reader. Frames larger than max bytes are rejected.
+// Zero max means there is no limit. If the given reader is not buffered, it will be wrapped with a *bufio.Reader.
+func NewReader(r io.Reader, max int) Reader {
+	if _, ok := r.(io.ByteReader); !ok {
+		r = bufio.NewReaderSize(r, DefaultBufferSize)
+	}
+	return &reader{r: r, max: max}
+}
+
+// ReadFrame implements Reader. It returns io.EOF only if the stream ends before the first byte of the header.
+func (r *reader) ReadFrame() ([]byte, error) {
+	if _, err := io.ReadFull(r.r, r.hdr[:]); err != nil {
+		return nil, err
+	}
+	size := int64(binary.BigEndian.Uint32(r.hdr[:]))
+	if r.max > 0 && size > int64(r.max) {
+		if _, err := io.CopyN(ioutil.Discard, r.r, size); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		return nil, ErrFrameTooLarge.New(size, r.max)
+	}
+	if int64(cap(r.buf)) < size {
+		r.buf = make([]byte, size)
+	}
+	b := r.buf[:size]
+	if _, err := io.ReadFull(r.r, b); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	return b, nil
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// Writer writes length-prefixed frames.
+type Writer interface {
+	// WriteFrame writes the payload as a single frame.
+	WriteFrame(p []byte) error
+}
+
+type writer struct {
+	w   io.Writer
+	buf []byte
+}
+
+// NewWriter creates a new frame writer using the given writer.
+func NewWriter(w io.Writer) Writer {
+	return &writer{w: w}
+}
+
+// WriteFrame implements Writer. The header and the payload are written with a single call to the underlying writer.
+func (w *writer) WriteFrame(p []byte) error {
+	if uint64(len(p)) > math.MaxUint32 {
+		return ErrFrameTooLarge.New(len(p), uint64(math.MaxUint32))
+	}
+	var hdr [HeaderSize]byte
+	binary.BigEndian.PutUint32(hdr[:], uint32(len(p)))
+	w.buf = append(append(w.buf[:0], hdr[:]...), p...)
+	_, err := w.w.Write(w.buf)
+	return err
+}
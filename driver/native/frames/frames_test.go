@@ -0,0 +1,67 @@
+package frames
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFrames(t *testing.T) {
+	require := require.New(t)
+
+	buf := bytes.NewBuffer(nil)
+	w := NewWriter(buf)
+
+	msgs := []string{"{\"a\":\n1}", "", strings.Repeat("x", 100)}
+	for _, m := range msgs {
+		err := w.WriteFrame([]byte(m))
+		require.NoError(err)
+	}
+	require.Equal([]byte{0, 0, 0, 8}, buf.Bytes()[:HeaderSize])
+
+	r := NewReader(buf, 0)
+	for _, m := range msgs {
+		p, err := r.ReadFrame()
+		require.NoError(err)
+		require.Equal(m, string(p))
+	}
+	_, err := r.ReadFrame()
+	require.Equal(io.EOF, err)
+}
+
+func TestFramesLimit(t *testing.T) {
+	require := require.New(t)
+
+	buf := bytes.NewBuffer(nil)
+	w := NewWriter(buf)
+	for _, m := range []string{"small", "too large", "ok"} {
+		err := w.WriteFrame([]byte(m))
+		require.NoError(err)
+	}
+
+	r := NewReader(buf, 5)
+	p, err := r.ReadFrame()
+	require.NoError(err)
+	require.Equal("small", string(p))
+
+	_, err = r.ReadFrame()
+	require.True(ErrFrameTooLarge.Is(err), "%v", err)
+
+	// the stream is still usable
+	p, err = r.ReadFrame()
+	require.NoError(err)
+	require.Equal("ok", string(p))
+}
+
+func TestFramesTruncated(t *testing.T) {
+	r := NewReader(bytes.NewReader([]byte{0, 0, 0, 4, 'a', 'b'}), 0)
+	_, err := r.ReadFrame()
+	require.Equal(t, io.ErrUnexpectedEOF, err)
+
+	r = NewReader(bytes.NewReader([]byte{0, 0}), 0)
+	_, err = r.ReadFrame()
+	require.Equal(t, io.ErrUnexpectedEOF, err)
+}
@@ -0,0 +1,191 @@
+package native
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	xcontext "golang.org/x/net/context"
+	"google.golang.org/grpc"
+
+	"gopkg.in/bblfsh/sdk.v2/driver"
+	"gopkg.in/bblfsh/sdk.v2/driver/native/nativeproto"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+// NewGRPCDriverAt creates a driver for a native binary that implements the NativeDriver gRPC service from
+// nativeproto package instead of the stdio protocol. The native process is started with FormatEnv set to GRPC
+// and must serve the service on the Unix socket passed in SocketEnv. It must exit when its stdin is closed.
+//
+// Unlike Driver, requests are sent concurrently, and the deadlines and the cancellation of the context are
+// propagated to the native process.
+func NewGRPCDriverAt(bin, socket string) driver.Native {
+	if bin == "" {
+		bin = Binary
+	}
+	return &GRPCDriver{bin: bin, socket: socket}
+}
+
+// GRPCDriver is a wrapper of the native command that implements the gRPC contract. See NewGRPCDriverAt.
+type GRPCDriver struct {
+	bin    string
+	socket string
+
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	exited chan error
+	conn   *grpc.ClientConn
+	cli    nativeproto.NativeDriverClient
+}
+
+// Start executes the given native driver and connects to its gRPC service.
+func (d *GRPCDriver) Start() error {
+	if err := os.Remove(d.socket); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	d.cmd = exec.Command(d.bin)
+	d.cmd.Env = append(os.Environ(),
+		FormatEnv+"="+string(GRPC),
+		SocketEnv+"="+d.socket,
+	)
+	d.cmd.Stdout = os.Stderr
+	d.cmd.Stderr = os.Stderr
+	stdin, err := d.cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	if err = d.cmd.Start(); err != nil {
+		return err
+	}
+	d.stdin = stdin
+	d.exited = make(chan error, 1)
+	go func() {
+		d.exited <- d.cmd.Wait()
+	}()
+	// wait for the native driver to start listening
+	c, err := dialSocket(d.socket, d.exited, time.Now().Add(dialTimeout))
+	if err != nil {
+		d.cmd.Process.Kill()
+		return err
+	}
+	c.Close()
+	d.conn, err = grpc.Dial(d.socket, grpc.WithInsecure(),
+		grpc.WithDialer(func(addr string, timeout time.Duration) (net.Conn, error) {
+			return net.DialTimeout("unix", addr, timeout)
+		}),
+	)
+	if err != nil {
+		d.cmd.Process.Kill()
+		return err
+	}
+	d.cli = nativeproto.NewNativeDriverClient(d.conn)
+	return nil
+}
+
+// Parse sends a request to the native driver and returns its response.
+func (d *GRPCDriver) Parse(rctx context.Context, src string) (nodes.Node, error) {
+	sp, ctx := opentracing.StartSpanFromContext(rctx, "bblfsh.native.Parse")
+	defer sp.Finish()
+
+	if d.cli == nil {
+		return nil, driver.ErrDriverFailure.Wrap(ErrNotRunning.New())
+	}
+	resp, err := d.cli.ParseNative(ctx, &nativeproto.ParseNativeRequest{Content: []byte(src)})
+	if err != nil {
+		return nil, driver.ErrDriverFailure.Wrap(err)
+	}
+	r := &parseResponse{Errors: resp.Errors}
+	switch resp.Status {
+	case nativeproto.Status_Ok:
+		r.Status = statusOK
+	case nativeproto.Status_Error:
+		r.Status = statusError
+	case nativeproto.Status_Fatal:
+		r.Status = statusFatal
+	default:
+		return nil, fmt.Errorf("unsupported status: %v", resp.Status)
+	}
+	if len(resp.Ast) != 0 {
+		var ast interface{}
+		if err = json.Unmarshal(resp.Ast, &ast); err != nil {
+			return nil, driver.ErrDriverFailure.Wrap(err)
+		}
+		r.AST, err = nodes.ToNode(ast, nil)
+		if err != nil {
+			return nil, driver.ErrDriverFailure.Wrap(err)
+		}
+	}
+	return r.result()
+}
+
+// Close stops the execution of the native driver.
+func (d *GRPCDriver) Close() error {
+	if d.cli == nil {
+		return nil
+	}
+	d.cli = nil
+	last := d.conn.Close()
+	if err := d.stdin.Close(); err != nil && last == nil {
+		last = err
+	}
+	timeout := time.NewTimer(closeTimeout)
+	select {
+	case err := <-d.exited:
+		timeout.Stop()
+		if err != nil {
+			last = err
+		}
+	case <-timeout.C:
+		d.cmd.Process.Kill()
+	}
+	_ = os.Remove(d.socket)
+	return last
+}
+
+// serveGRPC serves the NativeDriver service on a Unix socket until the stdin is closed.
+func serveGRPC(d driver.Native, path string) error {
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+	srv := grpc.NewServer()
+	nativeproto.RegisterNativeDriverServer(srv, &grpcServer{s: &nativeServer{d: d}})
+	go func() {
+		_, _ = io.Copy(ioutil.Discard, os.Stdin)
+		srv.GracefulStop()
+	}()
+	return srv.Serve(l)
+}
+
+type grpcServer struct {
+	s *nativeServer
+}
+
+// ParseNative implements nativeproto.NativeDriverServer.
+func (s *grpcServer) ParseNative(ctx xcontext.Context, req *nativeproto.ParseNativeRequest) (*nativeproto.ParseNativeResponse, error) {
+	r := s.s.parse(ctx, &parseRequest{Content: string(req.Content), Encoding: UTF8}, nil)
+	resp := &nativeproto.ParseNativeResponse{Errors: r.Errors}
+	switch r.Status {
+	case statusOK:
+		resp.Status = nativeproto.Status_Ok
+	case statusError:
+		resp.Status = nativeproto.Status_Error
+	default:
+		resp.Status = nativeproto.Status_Fatal
+	}
+	if r.AST != nil {
+		data, err := json.Marshal(r.AST)
+		if err != nil {
+			return nil, err
+		}
+		resp.Ast = data
+	}
+	return resp, nil
+}
@@ -0,0 +1,169 @@
+package native
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	serrors "gopkg.in/src-d/go-errors.v1"
+
+	"gopkg.in/bblfsh/sdk.v2/driver"
+)
+
+// ProtocolVersion is the latest version of the native protocol supported by the SDK.
+const ProtocolVersion = 1
+
+const handshakeTimeout = time.Second * 30
+
+// Capabilities of the native driver declared in the handshake.
+const (
+	// CapPing is declared by native drivers that reply to pings without parsing. See Driver.Ping.
+	CapPing = "ping"
+	// CapStream is declared by native drivers that may stream the AST. See StreamingNative.
+	CapStream = "stream"
+	// CapBatch is declared by native drivers that accept multiple files in a single request. See Driver.ParseBatch.
+	CapBatch = "batch"
+	// CapConcurrent is declared by native drivers that process requests concurrently. Such drivers may reply
+	// out of order, and must set the ID of the request in each response. See ConcurrentNative.
+	CapConcurrent = "concurrent"
+	// CapOptions is declared by native drivers that accept language options. See driver.OptionsNative.
+	CapOptions = "options"
+	// CapTokens is declared by native drivers that can return tokens instead of the AST. See driver.TokensNative.
+	CapTokens = "tokens"
+)
+
+var (
+	// ErrIncompatible is returned by Start if the native driver is not compatible with the Go driver.
+	ErrIncompatible = serrors.NewKind("incompatible native driver: %s")
+)
+
+// ProtocolInfo describes the protocol spoken by the native driver. It is sent by the native driver in reply
+// to the handshake message.
+//
+// Native drivers that do not support the handshake are assumed to use the protocol version 0 with UTF8 and Base64
+// encodings and without any capabilities.
+type ProtocolInfo struct {
+	// Version is the version of the protocol used by the native driver. It must not be greater than the version
+	// sent by the Go driver.
+	Version int `json:"version"`
+	// Encodings is a list of encodings supported by the native driver.
+	Encodings []Encoding `json:"encodings,omitempty"`
+	// Capabilities is a list of optional features supported by the native driver.
+	Capabilities []string `json:"capabilities,omitempty"`
+}
+
+// legacyProtocol is assumed for native drivers that do not support the handshake.
+var legacyProtocol = ProtocolInfo{Encodings: []Encoding{UTF8, Base64}}
+
+// Has checks if the native driver declared a given capability.
+func (p ProtocolInfo) Has(capability string) bool {
+	for _, c := range p.Capabilities {
+		if c == capability {
+			return true
+		}
+	}
+	return false
+}
+
+// Supports checks if the native driver supports a given encoding.
+func (p ProtocolInfo) Supports(enc Encoding) bool {
+	for _, e := range p.Encodings {
+		if e == enc {
+			return true
+		}
+	}
+	return false
+}
+
+// SetHandshake enables the handshake with the native driver. If enabled, Start sends the protocol version to the native
+// driver and fails with ErrIncompatible if the native driver cannot use the protocol or the encoding of the Go driver.
+// It must be called before Start.
+func (d *Driver) SetHandshake(enabled bool) {
+	d.hello = enabled
+}
+
+// Protocol returns the protocol information declared by the native driver in the handshake. It returns the legacy
+// protocol if the handshake is not enabled or the native driver does not support it.
+func (d *Driver) Protocol() ProtocolInfo {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.proto == nil {
+		return legacyProtocol
+	}
+	return *d.proto
+}
+
+var _ driver.CapableNative = (*Driver)(nil)
+
+// Capabilities implements driver.CapableNative. Capabilities are based on the protocol negotiated in the handshake,
+// thus only the legacy protocol is reported before the driver is started.
+func (d *Driver) Capabilities() driver.Capabilities {
+	p := d.Protocol()
+	c := driver.Capabilities{Modes: driver.ModeNative, Options: p.Has(CapOptions)}
+	if p.Has(CapTokens) {
+		c.Modes |= driver.ModeTokens
+	}
+	for _, e := range p.Encodings {
+		c.Encodings = append(c.Encodings, string(e))
+	}
+	return c
+}
+
+// handshake negotiates the protocol with the started native driver. The driver is stopped if it is not compatible.
+func (d *Driver) handshake(ctx context.Context) error {
+	d.proto = nil
+	if !d.hello {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, handshakeTimeout)
+	defer cancel()
+	r, err := d.roundTrip(ctx, newRequest(ctx, parseRequest{
+		Encoding: d.ec, Hello: &ProtocolInfo{Version: ProtocolVersion},
+	}), nil)
+	if err != nil {
+		_ = d.stop()
+		return err
+	}
+	p := legacyProtocol
+	if r.Hello != nil {
+		p = *r.Hello
+	}
+	if err = p.compatible(d.ec); err != nil {
+		_ = d.stop()
+		return err
+	}
+	d.proto = &p
+	return nil
+}
+
+// compatible checks if the Go driver can communicate with the native driver using a given encoding.
+func (p ProtocolInfo) compatible(enc Encoding) error {
+	if p.Version > ProtocolVersion {
+		return ErrIncompatible.New(fmt.Sprintf("unsupported protocol version %d (max %d)", p.Version, ProtocolVersion))
+	} else if !p.Supports(enc) {
+		return ErrIncompatible.New(fmt.Sprintf("encoding %q is not supported by the native driver", enc))
+	}
+	return nil
+}
+
+// nativeProtocol returns the protocol information of the native driver served by Main.
+func nativeProtocol(s *nativeServer) ProtocolInfo {
+	p := ProtocolInfo{
+		Version:      ProtocolVersion,
+		Encodings:    []Encoding{UTF8, Base64, Gzip},
+		Capabilities: []string{CapPing, CapBatch},
+	}
+	if _, ok := s.d.(StreamingNative); ok {
+		p.Capabilities = append(p.Capabilities, CapStream)
+	}
+	if _, ok := s.d.(ConcurrentNative); ok {
+		p.Capabilities = append(p.Capabilities, CapConcurrent)
+	}
+	if _, ok := s.d.(driver.OptionsNative); ok {
+		p.Capabilities = append(p.Capabilities, CapOptions)
+	}
+	if _, ok := s.d.(driver.TokensNative); ok {
+		p.Capabilities = append(p.Capabilities, CapTokens)
+	}
+	return p
+}
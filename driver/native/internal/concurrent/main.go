@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"gopkg.in/bblfsh/sdk.v2/driver/native"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+type mockDriver struct{}
+
+func (mockDriver) Start() error {
+	return nil
+}
+
+func (mockDriver) MaxConcurrency() int {
+	return 4
+}
+
+// Parse waits for the duration given in the source, if any. The deadline is ignored on purpose.
+func (mockDriver) Parse(ctx context.Context, src string) (nodes.Node, error) {
+	if dt, err := time.ParseDuration(src); err == nil {
+		time.Sleep(dt)
+	}
+	return nodes.Object{
+		"root": nodes.Object{
+			"key": nodes.String(src),
+		},
+	}, nil
+}
+
+func (mockDriver) Close() error {
+	return nil
+}
+
+func main() {
+	native.Main(mockDriver{})
+}
@@ -2,8 +2,12 @@ package main
 
 import (
 	"context"
+	"strings"
 
+	"gopkg.in/bblfsh/sdk.v2/driver"
+	derrors "gopkg.in/bblfsh/sdk.v2/driver/errors"
 	"gopkg.in/bblfsh/sdk.v2/driver/native"
+	"gopkg.in/bblfsh/sdk.v2/uast"
 	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
 )
 
@@ -13,12 +17,50 @@ func (mockDriver) Start() error {
 	return nil
 }
 
+// Parse returns the source as a key of the root node. If the source contains "!", it also returns a diagnostic
+// pointing to it.
 func (mockDriver) Parse(ctx context.Context, src string) (nodes.Node, error) {
-	return nodes.Object{
+	ast := nodes.Object{
 		"root": nodes.Object{
 			"key": nodes.String(src),
 		},
-	}, nil
+	}
+	if i := strings.IndexByte(src, '!'); i >= 0 {
+		return ast, &derrors.Diagnostic{
+			Message: "unexpected '!'", Severity: derrors.SeverityWarning, Code: "E1",
+			Start: &uast.Position{Offset: uint32(i), Line: 1, Col: uint32(i + 1)},
+		}
+	}
+	return ast, nil
+}
+
+// ParseWithOptions returns the same tree as Parse and detects the language version, if it is not set.
+func (d mockDriver) ParseWithOptions(ctx context.Context, src string, opts driver.LanguageOptions) (nodes.Node, driver.LanguageOptions, error) {
+	if opts.Version == "" {
+		opts.Version = "1"
+	}
+	ast, err := d.Parse(ctx, src)
+	return ast, opts, err
+}
+
+// ParseTokens returns words of the source as tokens.
+func (mockDriver) ParseTokens(ctx context.Context, src string) (nodes.Array, error) {
+	var (
+		toks nodes.Array
+		off  int
+	)
+	for _, w := range strings.Fields(src) {
+		off += strings.Index(src[off:], w)
+		toks = append(toks, nodes.Object{
+			uast.KeyToken: nodes.String(w),
+			uast.KeyPos: uast.Positions{
+				uast.KeyStart: {Offset: uint32(off), Line: 1, Col: uint32(off + 1)},
+				uast.KeyEnd:   {Offset: uint32(off + len(w)), Line: 1, Col: uint32(off + len(w) + 1)},
+			}.ToObject(),
+		})
+		off += len(w)
+	}
+	return toks, nil
 }
 
 func (mockDriver) Close() error {
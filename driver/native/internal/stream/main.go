@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	"gopkg.in/bblfsh/sdk.v2/driver/native"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+type mockDriver struct{}
+
+func (mockDriver) Start() error {
+	return nil
+}
+
+func (d mockDriver) Parse(ctx context.Context, src string) (nodes.Node, error) {
+	var lines nodes.Array
+	root, field, err := d.ParseStream(ctx, src, func(n nodes.Node) error {
+		lines = append(lines, n)
+		return nil
+	})
+	root.(nodes.Object)[field] = lines
+	return root, err
+}
+
+// ParseStream emits each line of the source as a separate node.
+func (mockDriver) ParseStream(ctx context.Context, src string, emit func(n nodes.Node) error) (nodes.Node, string, error) {
+	for _, line := range strings.Split(src, "\n") {
+		if err := emit(nodes.Object{"line": nodes.String(line)}); err != nil {
+			return nil, "", err
+		}
+	}
+	return nodes.Object{"type": nodes.String("file")}, "lines", nil
+}
+
+func (mockDriver) Close() error {
+	return nil
+}
+
+func main() {
+	native.Main(mockDriver{})
+}
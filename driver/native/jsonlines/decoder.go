@@ -4,6 +4,8 @@ import (
 	"bufio"
 	"encoding/json"
 	"io"
+
+	"gopkg.in/src-d/go-errors.v1"
 )
 
 const (
@@ -12,6 +14,9 @@ const (
 	DefaultBufferSize = 1024 * 1024 * 4
 )
 
+// ErrLineTooLong is returned by a limited decoder when the line exceeds the size limit.
+var ErrLineTooLong = errors.NewKind("line exceeds the limit of %d bytes")
+
 type lineReader interface {
 	ReadBytes(delim byte) ([]byte, error)
 }
@@ -23,7 +28,14 @@ type Decoder interface {
 }
 
 type decoder struct {
-	r lineReader
+	r   lineReader
+	buf []byte
+}
+
+type limitedDecoder struct {
+	r   *bufio.Reader
+	max int
+	buf []byte
 }
 
 // NewDecoder creates a new decoder with the given reader. If the given reader
@@ -43,7 +55,7 @@ func NewDecoder(r io.Reader) Decoder {
 // It does not check JSON for well-formedness before decoding, so in case of
 // error, the structure might be half-filled.
 func (d *decoder) Decode(v interface{}) error {
-	line, err := d.r.ReadBytes('\n')
+	line, err := d.readLine()
 	if err != nil {
 		return err
 	}
@@ -55,3 +67,75 @@ func (d *decoder) Decode(v interface{}) error {
 		return json.Unmarshal(line, v)
 	}
 }
+
+// readLine reads the next line. If the reader is a *bufio.Reader, the buffer for the line is reused.
+func (d *decoder) readLine() ([]byte, error) {
+	br, ok := d.r.(*bufio.Reader)
+	if !ok {
+		return d.r.ReadBytes('\n')
+	}
+	d.buf = d.buf[:0]
+	for {
+		frag, err := br.ReadSlice('\n')
+		if err == bufio.ErrBufferFull {
+			d.buf = append(d.buf, frag...)
+			continue
+		} else if err != nil {
+			return nil, err
+		} else if len(d.buf) == 0 {
+			// the whole line is in the buffer of the reader
+			return frag, nil
+		}
+		d.buf = append(d.buf, frag...)
+		return d.buf, nil
+	}
+}
+
+// NewLimitedDecoder is similar to NewDecoder, but rejects lines longer than max bytes.
+// Lines that exceed the limit are skipped and ErrLineTooLong is returned. The decoder can be used after
+// this error.
+func NewLimitedDecoder(r io.Reader, max int) Decoder {
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReaderSize(r, DefaultBufferSize)
+	}
+	return &limitedDecoder{r: br, max: max}
+}
+
+// Decode decodes the next line in the reader.
+func (d *limitedDecoder) Decode(v interface{}) error {
+	line, err := d.readLine()
+	if err != nil {
+		return err
+	}
+	switch o := v.(type) {
+	case json.Unmarshaler:
+		return o.UnmarshalJSON(line)
+	default:
+		return json.Unmarshal(line, v)
+	}
+}
+
+// readLine reads the next line. It never buffers more than max bytes of the line.
+func (d *limitedDecoder) readLine() ([]byte, error) {
+	d.buf = d.buf[:0]
+	tooLong := false
+	for {
+		frag, err := d.r.ReadSlice('\n')
+		if !tooLong && len(d.buf)+len(frag) > d.max {
+			tooLong = true
+			d.buf = d.buf[:0]
+		}
+		if !tooLong {
+			d.buf = append(d.buf, frag...)
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		} else if err != nil {
+			return nil, err
+		} else if tooLong {
+			return nil, ErrLineTooLong.New(d.max)
+		}
+		return d.buf, nil
+	}
+}
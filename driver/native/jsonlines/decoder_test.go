@@ -60,3 +60,49 @@ func TestDecoderWithBufferedReader(t *testing.T) {
 	err = d.Decode(&out)
 	require.Equal(io.EOF, err)
 }
+
+func TestDecoderLongLines(t *testing.T) {
+	require := require.New(t)
+
+	long := strings.Repeat("x", 100)
+	input := `{"example":"` + long + `"}` + "\n" + `{"example":"a"}` + "\n"
+	// lines are longer than the buffer of the reader
+	d := NewDecoder(bufio.NewReaderSize(strings.NewReader(input), 16))
+	out := map[string]string{}
+
+	err := d.Decode(&out)
+	require.NoError(err)
+	require.Equal(long, out["example"])
+
+	err = d.Decode(&out)
+	require.NoError(err)
+	require.Equal("a", out["example"])
+
+	err = d.Decode(&out)
+	require.Equal(io.EOF, err)
+}
+
+func TestLimitedDecoder(t *testing.T) {
+	require := require.New(t)
+
+	input := `{"example":1}
+	{"example":"too long"}
+	{"example":3}
+	`
+	d := NewLimitedDecoder(bufio.NewReaderSize(strings.NewReader(input), 16), 16)
+	out := map[string]interface{}{}
+
+	err := d.Decode(&out)
+	require.NoError(err)
+	require.Equal(float64(1), out["example"])
+
+	err = d.Decode(&out)
+	require.True(ErrLineTooLong.Is(err), "%v", err)
+
+	err = d.Decode(&out)
+	require.NoError(err)
+	require.Equal(float64(3), out["example"])
+
+	err = d.Decode(&out)
+	require.Equal(io.EOF, err)
+}
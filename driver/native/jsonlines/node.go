@@ -0,0 +1,390 @@
+package jsonlines
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+// maxKeys limits the number of distinct object keys remembered by a parser.
+const maxKeys = 4096
+
+var parsers = sync.Pool{
+	New: func() interface{} {
+		return &nodeParser{keys: make(map[string]string)}
+	},
+}
+
+// ParseNode parses a single JSON value into a node.
+//
+// The result is the same as decoding the value into interface{} and converting it with nodes.ToNode, but the nodes are
+// built directly, without intermediate values. Integers are parsed exactly instead of being converted from float64.
+func ParseNode(data []byte) (nodes.Node, error) {
+	p := parsers.Get().(*nodeParser)
+	defer parsers.Put(p)
+	p.data, p.pos = data, 0
+	n, err := p.value()
+	if err == nil {
+		p.skipSpace()
+		if p.pos < len(p.data) {
+			err = p.errorf("unexpected data after the value")
+		}
+	}
+	p.data = nil
+	return n, err
+}
+
+// nodeParser is a reusable JSON parser that builds nodes.
+type nodeParser struct {
+	data []byte
+	pos  int
+	buf  []byte            // scratch buffer for unescaping strings
+	keys map[string]string // interned object keys, since the same keys repeat in the whole tree
+}
+
+func (p *nodeParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("json: "+format+" at offset %d", append(args, p.pos)...)
+}
+
+func (p *nodeParser) skipSpace() {
+	for p.pos < len(p.data) {
+		switch p.data[p.pos] {
+		case ' ', '\t', '\n', '\r':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+func (p *nodeParser) value() (nodes.Node, error) {
+	p.skipSpace()
+	if p.pos >= len(p.data) {
+		return nil, p.errorf("unexpected end of input")
+	}
+	switch c := p.data[p.pos]; c {
+	case '{':
+		return p.object()
+	case '[':
+		return p.array()
+	case '"':
+		s, err := p.str()
+		if err != nil {
+			return nil, err
+		}
+		return nodes.String(s), nil
+	case 't':
+		return nodes.Bool(true), p.literal("true")
+	case 'f':
+		return nodes.Bool(false), p.literal("false")
+	case 'n':
+		return nil, p.literal("null")
+	default:
+		if c == '-' || (c >= '0' && c <= '9') {
+			return p.number()
+		}
+		return nil, p.errorf("unexpected character %q", c)
+	}
+}
+
+func (p *nodeParser) literal(s string) error {
+	if len(p.data)-p.pos < len(s) || string(p.data[p.pos:p.pos+len(s)]) != s {
+		return p.errorf("invalid literal")
+	}
+	p.pos += len(s)
+	return nil
+}
+
+func (p *nodeParser) object() (nodes.Node, error) {
+	p.pos++ // '{'
+	obj := make(nodes.Object)
+	p.skipSpace()
+	if p.pos < len(p.data) && p.data[p.pos] == '}' {
+		p.pos++
+		return obj, nil
+	}
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.data) || p.data[p.pos] != '"' {
+			return nil, p.errorf("expected an object key")
+		}
+		k, err := p.key()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if p.pos >= len(p.data) || p.data[p.pos] != ':' {
+			return nil, p.errorf("expected a colon")
+		}
+		p.pos++
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		obj[k] = v
+		p.skipSpace()
+		if p.pos >= len(p.data) {
+			return nil, p.errorf("unexpected end of input")
+		}
+		switch p.data[p.pos] {
+		case ',':
+			p.pos++
+		case '}':
+			p.pos++
+			return obj, nil
+		default:
+			return nil, p.errorf("expected a comma or a closing brace")
+		}
+	}
+}
+
+func (p *nodeParser) array() (nodes.Node, error) {
+	p.pos++ // '['
+	arr := nodes.Array{}
+	p.skipSpace()
+	if p.pos < len(p.data) && p.data[p.pos] == ']' {
+		p.pos++
+		return arr, nil
+	}
+	for {
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		arr = append(arr, v)
+		p.skipSpace()
+		if p.pos >= len(p.data) {
+			return nil, p.errorf("unexpected end of input")
+		}
+		switch p.data[p.pos] {
+		case ',':
+			p.pos++
+		case ']':
+			p.pos++
+			return arr, nil
+		default:
+			return nil, p.errorf("expected a comma or a closing bracket")
+		}
+	}
+}
+
+// key parses an object key and interns it.
+func (p *nodeParser) key() (string, error) {
+	b, err := p.strBytes()
+	if err != nil {
+		return "", err
+	}
+	if k, ok := p.keys[string(b)]; ok {
+		return k, nil
+	}
+	k := string(b)
+	if len(p.keys) < maxKeys {
+		p.keys[k] = k
+	}
+	return k, nil
+}
+
+func (p *nodeParser) str() (string, error) {
+	b, err := p.strBytes()
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// strBytes parses a string and returns its unescaped bytes. The result is valid until the next call.
+//
+// Invalid UTF-8 sequences and unpaired surrogates are replaced with utf8.RuneError, the same way as in encoding/json.
+func (p *nodeParser) strBytes() ([]byte, error) {
+	p.pos++ // '"'
+	start := p.pos
+	// fast path: no escapes and only ASCII characters
+	for p.pos < len(p.data) {
+		c := p.data[p.pos]
+		if c == '"' {
+			p.pos++
+			return p.data[start : p.pos-1], nil
+		} else if c == '\\' || c >= utf8.RuneSelf || c < ' ' {
+			break
+		}
+		p.pos++
+	}
+	p.buf = append(p.buf[:0], p.data[start:p.pos]...)
+	for p.pos < len(p.data) {
+		c := p.data[p.pos]
+		switch {
+		case c == '"':
+			p.pos++
+			return p.buf, nil
+		case c < ' ':
+			return nil, p.errorf("invalid character %q in string", c)
+		case c == '\\':
+			if err := p.escape(); err != nil {
+				return nil, err
+			}
+		case c < utf8.RuneSelf:
+			p.buf = append(p.buf, c)
+			p.pos++
+		default:
+			r, size := utf8.DecodeRune(p.data[p.pos:])
+			p.pos += size
+			p.buf = appendRune(p.buf, r)
+		}
+	}
+	return nil, p.errorf("unexpected end of input")
+}
+
+// escape unescapes a single escape sequence of a string.
+func (p *nodeParser) escape() error {
+	p.pos++ // '\'
+	if p.pos >= len(p.data) {
+		return p.errorf("unexpected end of input")
+	}
+	c := p.data[p.pos]
+	p.pos++
+	switch c {
+	case '"', '\\', '/':
+		p.buf = append(p.buf, c)
+	case 'b':
+		p.buf = append(p.buf, '\b')
+	case 'f':
+		p.buf = append(p.buf, '\f')
+	case 'n':
+		p.buf = append(p.buf, '\n')
+	case 'r':
+		p.buf = append(p.buf, '\r')
+	case 't':
+		p.buf = append(p.buf, '\t')
+	case 'u':
+		r, err := p.hex4()
+		if err != nil {
+			return err
+		}
+		if utf16.IsSurrogate(r) {
+			r2 := utf8.RuneError
+			if p.pos+1 < len(p.data) && p.data[p.pos] == '\\' && p.data[p.pos+1] == 'u' {
+				save := p.pos
+				p.pos += 2
+				s, err := p.hex4()
+				if err != nil {
+					return err
+				}
+				if r2 = utf16.DecodeRune(r, s); r2 == utf8.RuneError {
+					// not a valid pair; the second escape is decoded separately
+					p.pos = save
+				}
+			}
+			r = r2
+		}
+		p.buf = appendRune(p.buf, r)
+	default:
+		return p.errorf("invalid escape %q", c)
+	}
+	return nil
+}
+
+func (p *nodeParser) hex4() (rune, error) {
+	if len(p.data)-p.pos < 4 {
+		return 0, p.errorf("invalid unicode escape")
+	}
+	var r rune
+	for _, c := range p.data[p.pos : p.pos+4] {
+		switch {
+		case c >= '0' && c <= '9':
+			c -= '0'
+		case c >= 'a' && c <= 'f':
+			c -= 'a' - 10
+		case c >= 'A' && c <= 'F':
+			c -= 'A' - 10
+		default:
+			return 0, p.errorf("invalid unicode escape")
+		}
+		r = r*16 + rune(c)
+	}
+	p.pos += 4
+	return r, nil
+}
+
+func appendRune(buf []byte, r rune) []byte {
+	var tmp [utf8.UTFMax]byte
+	n := utf8.EncodeRune(tmp[:], r)
+	return append(buf, tmp[:n]...)
+}
+
+// number parses a number. Integers are returned as nodes.Int, if they fit into int64. Other numbers without
+// a fractional part are returned as nodes.Int as well, the same way as nodes.ToNode converts float64 values.
+func (p *nodeParser) number() (nodes.Node, error) {
+	start := p.pos
+	if p.data[p.pos] == '-' {
+		p.pos++
+	}
+	if p.digits() == 0 || (p.pos-start > 1 && p.data[start] == '0') || (p.pos-start > 2 && p.data[start] == '-' && p.data[start+1] == '0') {
+		return nil, p.errorf("invalid number")
+	}
+	isInt := true
+	if p.pos < len(p.data) && p.data[p.pos] == '.' {
+		isInt = false
+		p.pos++
+		if p.digits() == 0 {
+			return nil, p.errorf("invalid number")
+		}
+	}
+	if p.pos < len(p.data) && (p.data[p.pos] == 'e' || p.data[p.pos] == 'E') {
+		isInt = false
+		p.pos++
+		if p.pos < len(p.data) && (p.data[p.pos] == '+' || p.data[p.pos] == '-') {
+			p.pos++
+		}
+		if p.digits() == 0 {
+			return nil, p.errorf("invalid number")
+		}
+	}
+	s := p.data[start:p.pos]
+	if isInt {
+		if v, ok := parseInt(s); ok {
+			return nodes.Int(v), nil
+		} else if v, err := strconv.ParseInt(string(s), 10, 64); err == nil {
+			return nodes.Int(v), nil
+		}
+	}
+	f, err := strconv.ParseFloat(string(s), 64)
+	if err != nil {
+		return nil, p.errorf("invalid number %q", s)
+	}
+	if float64(int64(f)) != f {
+		return nodes.Float(f), nil
+	}
+	return nodes.Int(f), nil
+}
+
+// digits skips decimal digits and returns their count.
+func (p *nodeParser) digits() int {
+	start := p.pos
+	for p.pos < len(p.data) && p.data[p.pos] >= '0' && p.data[p.pos] <= '9' {
+		p.pos++
+	}
+	return p.pos - start
+}
+
+// parseInt parses a short decimal integer without allocations. It returns false if the number is too long.
+func parseInt(s []byte) (int64, bool) {
+	neg := s[0] == '-'
+	if neg {
+		s = s[1:]
+	}
+	if len(s) > 18 {
+		return 0, false
+	}
+	var v int64
+	for _, c := range s {
+		v = v*10 + int64(c-'0')
+	}
+	if neg {
+		v = -v
+	}
+	return v, true
+}
@@ -0,0 +1,131 @@
+package jsonlines
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+var parseNodeCases = []string{
+	`null`,
+	`true`,
+	`false`,
+	`0`,
+	`-12`,
+	`1.5`,
+	`-0.25e-3`,
+	`2E3`,
+	`1.0`,
+	`""`,
+	`"abc"`,
+	`"a\"b\\c\/d\b\f\n\r\t"`,
+	`"é世"`,
+	`"😀"`,
+	`"\ud800x"`,
+	`"\ud800A"`,
+	`"привет"`,
+	"\"\xff\"",
+	`[]`,
+	`{}`,
+	` [ 1 , "a" , null , [ ] , { } ] `,
+	`{"@type":"File","@pos":{"start":{"offset":0,"line":1}},"body":[{"@type":"Ident","Name":"a"},{"@type":"Ident","Name":"b"}]}`,
+	`{"a":1,"a":2}`,
+}
+
+func TestParseNode(t *testing.T) {
+	for _, c := range parseNodeCases {
+		c := c
+		t.Run(c, func(t *testing.T) {
+			var v interface{}
+			err := json.Unmarshal([]byte(c), &v)
+			require.NoError(t, err)
+			exp, err := nodes.ToNode(v, nil)
+			require.NoError(t, err)
+
+			got, err := ParseNode([]byte(c))
+			require.NoError(t, err)
+			require.Equal(t, exp, got)
+		})
+	}
+}
+
+func TestParseNodeInt(t *testing.T) {
+	// integers are parsed exactly, even if they cannot be represented as float64
+	n, err := ParseNode([]byte(`9007199254740993`))
+	require.NoError(t, err)
+	require.Equal(t, nodes.Int(9007199254740993), n)
+}
+
+func TestParseNodeInvalid(t *testing.T) {
+	for _, c := range []string{
+		``,
+		`{`,
+		`[1,]`,
+		`{"a"}`,
+		`{"a":1,}`,
+		`{a:1}`,
+		`tru`,
+		`nul`,
+		`01`,
+		`-`,
+		`1.`,
+		`1e`,
+		`.5`,
+		`"abc`,
+		"\"a\nb\"",
+		`"\x"`,
+		`"\u12"`,
+		`1 2`,
+	} {
+		c := c
+		t.Run(c, func(t *testing.T) {
+			var v interface{}
+			err := json.Unmarshal([]byte(c), &v)
+			require.Error(t, err)
+
+			_, err = ParseNode([]byte(c))
+			require.Error(t, err)
+		})
+	}
+}
+
+func benchmarkTree() []byte {
+	var buf strings.Builder
+	buf.WriteString(`{"@type":"File","body":[`)
+	for i := 0; i < 1000; i++ {
+		if i != 0 {
+			buf.WriteString(",")
+		}
+		buf.WriteString(`{"@type":"Ident","Name":"name","@pos":{"@type":"uast:Positions","start":{"@type":"uast:Position","offset":100,"line":10,"col":5}}}`)
+	}
+	buf.WriteString(`]}`)
+	return []byte(buf.String())
+}
+
+func BenchmarkParseNode(b *testing.B) {
+	data := benchmarkTree()
+	b.Run("ParseNode", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := ParseNode(data); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("Unmarshal", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var v interface{}
+			if err := json.Unmarshal(data, &v); err != nil {
+				b.Fatal(err)
+			}
+			if _, err := nodes.ToNode(v, nil); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
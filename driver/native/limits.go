@@ -0,0 +1,121 @@
+package native
+
+import (
+	"sync"
+	"time"
+
+	serrors "gopkg.in/src-d/go-errors.v1"
+)
+
+const defaultCheckInterval = time.Second
+
+var (
+	// ErrMemoryLimit is returned when the native process was killed for exceeding the resident memory limit.
+	ErrMemoryLimit = serrors.NewKind("native driver exceeded the memory limit: %d > %d bytes")
+	// ErrLimitsUnsupported is returned by Start if resource limits are not supported on the current platform.
+	ErrLimitsUnsupported = serrors.NewKind("resource limits are not supported on this platform")
+)
+
+// Limits configures resource limits of the native process. Limits are only supported on Linux.
+//
+// Limits are applied to the native process as a whole. In case a limit is exceeded, the process is killed
+// and the driver becomes broken, thus limits should be used together with the RestartPolicy.
+//
+// Memory and CPU limits are set right after the process is started, not before it executes the binary. Thus, the
+// initialization of the native driver may briefly run without the limits, and the memory it allocated before the
+// limits are set is not checked against Memory. The limits are always set before the first request is sent.
+type Limits struct {
+	// Memory limits the virtual memory of the process in bytes (RLIMIT_AS).
+	Memory uint64
+	// CPU limits the total CPU time of the process (RLIMIT_CPU). Since it is not reset between requests,
+	// it bounds the lifetime of the native process rather than the time of a single request.
+	CPU time.Duration
+	// MaxRSS is the maximal size of the resident memory of the process in bytes. The process is checked
+	// periodically and killed if it exceeds the limit.
+	MaxRSS uint64
+	// CheckInterval is an interval for MaxRSS checks. One second is used if it is not set.
+	CheckInterval time.Duration
+}
+
+func (l Limits) rlimits() bool {
+	return l.Memory != 0 || l.CPU != 0
+}
+
+// SetLimits sets resource limits for the native process. It must be called before Start.
+func (d *Driver) SetLimits(l Limits) {
+	d.limits = l
+}
+
+// watchdog periodically checks the resident memory of the process and kills it if it exceeds the limit.
+type watchdog struct {
+	stop chan struct{}
+
+	mu  sync.Mutex
+	err error
+}
+
+// applyLimits applies resource limits to the started native process and starts the memory watchdog.
+func (d *Driver) applyLimits() error {
+	l := d.limits
+	d.watch = nil
+	if l.rlimits() {
+		if err := setRlimits(d.cmd.Process.Pid, l); err != nil {
+			return err
+		}
+	}
+	if l.MaxRSS == 0 {
+		return nil
+	}
+	proc := d.cmd.Process
+	if _, err := processRSS(proc.Pid); err != nil {
+		return err
+	}
+	interval := l.CheckInterval
+	if interval <= 0 {
+		interval = defaultCheckInterval
+	}
+	w := &watchdog{stop: make(chan struct{})}
+	d.watch = w
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-w.stop:
+				return
+			case <-ticker.C:
+			}
+			rss, err := processRSS(proc.Pid)
+			if err != nil {
+				// the process exited
+				return
+			} else if rss > l.MaxRSS {
+				w.mu.Lock()
+				w.err = ErrMemoryLimit.New(rss, l.MaxRSS)
+				w.mu.Unlock()
+				_ = proc.Kill()
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// limitErr returns an error if the process was killed for exceeding resource limits.
+func (d *Driver) limitErr() error {
+	w := d.watch
+	if w == nil {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+// stopWatchdog stops the memory watchdog, if it is running.
+func (d *Driver) stopWatchdog() {
+	if d.watch != nil {
+		close(d.watch.stop)
+		d.watch = nil
+	}
+}
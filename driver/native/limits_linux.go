@@ -0,0 +1,59 @@
+package native
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+type rlimit64 struct {
+	Cur, Max uint64
+}
+
+func prlimit(pid int, resource int, lim rlimit64) error {
+	_, _, e := syscall.RawSyscall6(syscall.SYS_PRLIMIT64, uintptr(pid), uintptr(resource),
+		uintptr(unsafe.Pointer(&lim)), 0, 0, 0)
+	if e != 0 {
+		return os.NewSyscallError("prlimit", e)
+	}
+	return nil
+}
+
+// setRlimits sets resource limits of the running process. Go cannot set limits between fork and exec, and setting
+// them on the current process would apply them to the Go driver as well, thus limits are set after the start.
+func setRlimits(pid int, l Limits) error {
+	if l.Memory != 0 {
+		if err := prlimit(pid, syscall.RLIMIT_AS, rlimit64{Cur: l.Memory, Max: l.Memory}); err != nil {
+			return err
+		}
+	}
+	if l.CPU != 0 {
+		sec := uint64((l.CPU + 999999999) / 1000000000)
+		// the process receives SIGXCPU at the soft limit and is killed at the hard limit
+		if err := prlimit(pid, syscall.RLIMIT_CPU, rlimit64{Cur: sec, Max: sec + 1}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// processRSS returns the resident memory size of the process in bytes.
+func processRSS(pid int) (uint64, error) {
+	data, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/statm", pid))
+	if err != nil {
+		return 0, err
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) < 2 {
+		return 0, fmt.Errorf("unexpected statm format: %q", data)
+	}
+	pages, err := strconv.ParseUint(fields[1], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return pages * uint64(os.Getpagesize()), nil
+}
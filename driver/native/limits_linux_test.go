@@ -0,0 +1,73 @@
+package native
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	derrors "gopkg.in/bblfsh/sdk.v2/driver/errors"
+)
+
+// sleepDriver creates a script that runs for a long time without replying to requests.
+func sleepDriver(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "native-")
+	require.NoError(t, err)
+	bin := filepath.Join(dir, "native")
+	err = ioutil.WriteFile(bin, []byte("#!/bin/sh\nexec sleep 10\n"), 0755)
+	require.NoError(t, err)
+	return bin, func() {
+		os.RemoveAll(dir)
+	}
+}
+
+func TestNativeDriverLimits(t *testing.T) {
+	require := require.New(t)
+
+	bin, clean := sleepDriver(t)
+	defer clean()
+
+	d := NewDriverAt(bin, "").(*Driver)
+	d.SetLimits(Limits{Memory: 1 << 30, CPU: 5 * time.Second})
+	err := d.Start()
+	require.NoError(err)
+	defer d.cmd.Process.Kill()
+
+	data, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/limits", d.cmd.Process.Pid))
+	require.NoError(err)
+	var found int
+	for _, line := range strings.Split(string(data), "\n") {
+		f := strings.Fields(line)
+		switch {
+		case strings.HasPrefix(line, "Max address space"):
+			require.Equal([]string{"1073741824", "1073741824"}, f[3:5])
+			found++
+		case strings.HasPrefix(line, "Max cpu time"):
+			require.Equal([]string{"5", "6"}, f[3:5])
+			found++
+		}
+	}
+	require.Equal(2, found)
+}
+
+func TestNativeDriverLimits_MaxRSS(t *testing.T) {
+	require := require.New(t)
+
+	bin, clean := sleepDriver(t)
+	defer clean()
+
+	d := NewDriverAt(bin, "").(*Driver)
+	d.SetLimits(Limits{MaxRSS: 1, CheckInterval: 10 * time.Millisecond})
+	err := d.Start()
+	require.NoError(err)
+
+	_, err = d.Parse(context.Background(), "foo")
+	require.True(derrors.ErrDriverFailure.Is(err))
+	require.True(ErrMemoryLimit.Is(err), "%v", err)
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+// +build !linux
+
+package native
+
+func setRlimits(pid int, l Limits) error {
+	return ErrLimitsUnsupported.New()
+}
+
+func processRSS(pid int) (uint64, error) {
+	return 0, ErrLimitsUnsupported.New()
+}
@@ -5,9 +5,11 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"sync"
 
 	"gopkg.in/bblfsh/sdk.v2/driver"
-	"gopkg.in/bblfsh/sdk.v2/driver/native/jsonlines"
+	derrors "gopkg.in/bblfsh/sdk.v2/driver/errors"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
 )
 
 // Main is a main function for running a native Go driver as an Exec-based module that uses internal json protocol.
@@ -16,14 +18,32 @@ func Main(d driver.Native) {
 		panic(err)
 	}
 	defer d.Close()
-	srv := &nativeServer{d: d}
-	c := struct {
+	f, err := FormatFromEnv()
+	if err != nil {
+		panic(err)
+	}
+	if f == GRPC {
+		if err = serveGRPC(d, os.Getenv(SocketEnv)); err != nil {
+			panic(err)
+		}
+		return
+	}
+	srv := &nativeServer{d: d, format: f}
+	var c io.ReadWriter = struct {
 		io.Reader
 		io.Writer
 	}{
 		os.Stdin,
 		os.Stdout,
 	}
+	if path := os.Getenv(SocketEnv); path != "" {
+		conn, err := listenSocket(path)
+		if err != nil {
+			panic(err)
+		}
+		defer conn.Close()
+		c = conn
+	}
 	if err := srv.Serve(c); err != nil {
 		panic(err)
 	}
@@ -41,10 +61,24 @@ func errToStrings(err error) []string {
 }
 
 type nativeServer struct {
-	d driver.Native
+	d      driver.Native
+	format Format
 }
 
-func (s *nativeServer) parse(ctx context.Context, req *parseRequest) *parseResponse {
+// parse runs the native driver for the request. If the driver implements StreamingNative, parts of the AST are
+// passed to emit; if emit is nil, they are assembled into the tree of the returned response.
+func (s *nativeServer) parse(ctx context.Context, req *parseRequest, emit func(r *parseResponse) error) *parseResponse {
+	if req.Deadline != nil {
+		var cancel func()
+		ctx, cancel = context.WithDeadline(ctx, *req.Deadline)
+		defer cancel()
+	}
+	if req.Ping {
+		return &parseResponse{Status: statusOK}
+	} else if req.Hello != nil {
+		p := nativeProtocol(s)
+		return &parseResponse{Status: statusOK, Hello: &p}
+	}
 	src, err := req.Encoding.Decode(req.Content)
 	if err != nil {
 		return &parseResponse{
@@ -52,26 +86,108 @@ func (s *nativeServer) parse(ctx context.Context, req *parseRequest) *parseRespo
 			Errors: errToStrings(err),
 		}
 	}
-	ast, err := s.d.Parse(ctx, src)
+	var (
+		ast    nodes.Node
+		chunks string
+	)
+	var opts *driver.LanguageOptions
+	if td, ok := s.d.(driver.TokensNative); ok && req.Tokens {
+		var toks nodes.Array
+		toks, err = td.ParseTokens(ctx, src)
+		if toks != nil {
+			ast = toks
+		}
+	} else if od, ok := s.d.(driver.OptionsNative); ok && req.Options != nil {
+		var o driver.LanguageOptions
+		ast, o, err = od.ParseWithOptions(ctx, src, *req.Options)
+		opts = &o
+	} else if sd, ok := s.d.(StreamingNative); ok {
+		ast, chunks, err = s.parseStream(ctx, sd, req, src, emit)
+	} else {
+		ast, err = s.d.Parse(ctx, src)
+	}
 	if driver.ErrDriverFailure.Is(err) {
 		return &parseResponse{
 			Status: statusFatal,
 			Errors: errToStrings(err),
 		}
 	}
+	resp := &parseResponse{Status: statusOK, AST: ast}
 	if err != nil {
-		return &parseResponse{
+		resp = &parseResponse{
 			Status: statusError,
 			AST:    ast, Errors: errToStrings(err),
 		}
+		if derrors.HasDiagnostics(err) {
+			resp.Diagnostics = derrors.Diagnostics(err)
+		}
+	}
+	resp.Chunks = chunks
+	resp.Options = opts
+	if req.Encoding == Gzip {
+		// compress the response as well
+		if err = resp.encodeAST(Gzip); err != nil {
+			return &parseResponse{
+				Status: statusFatal,
+				Errors: errToStrings(err),
+			}
+		}
 	}
-	return &parseResponse{Status: statusOK, AST: ast}
+	return resp
+}
+
+// parseStream runs the streaming driver and sends each part of the AST as a separate response. If emit is nil,
+// the parts are assembled and the whole tree is returned.
+func (s *nativeServer) parseStream(ctx context.Context, d StreamingNative, req *parseRequest, src string, emit func(r *parseResponse) error) (nodes.Node, string, error) {
+	if emit == nil {
+		var parts nodes.Array
+		root, field, err := d.ParseStream(ctx, src, func(n nodes.Node) error {
+			parts = append(parts, n)
+			return nil
+		})
+		if root == nil && parts == nil {
+			return nil, "", err
+		}
+		r := &parseResponse{AST: root, Chunks: field}
+		if aerr := r.assemble(parts); aerr != nil {
+			return nil, "", driver.ErrDriverFailure.Wrap(aerr)
+		}
+		return r.AST, "", err
+	}
+	return d.ParseStream(ctx, src, func(n nodes.Node) error {
+		r := &parseResponse{Status: statusChunk, AST: n}
+		if req.Encoding == Gzip {
+			if err := r.encodeAST(Gzip); err != nil {
+				return err
+			}
+		}
+		return emit(r)
+	})
 }
 
 func (s *nativeServer) Serve(c io.ReadWriter) error {
 	ctx := context.Background()
-	enc := jsonlines.NewEncoder(c)
-	dec := jsonlines.NewDecoder(c)
+	enc := s.format.newEncoder(c)
+	dec := s.format.newDecoder(c, 0)
+
+	var (
+		mu  sync.Mutex // protects enc
+		wg  sync.WaitGroup
+		sem chan struct{} // limits the number of concurrent requests; nil if requests are processed one by one
+	)
+	if cd, ok := s.d.(ConcurrentNative); ok {
+		n := cd.MaxConcurrency()
+		if n < 1 {
+			n = 1
+		}
+		sem = make(chan struct{}, n)
+	}
+	encode := func(r *parseResponse) error {
+		mu.Lock()
+		defer mu.Unlock()
+		return enc.Encode(r)
+	}
+	defer wg.Wait()
 	for {
 		var req parseRequest
 		err := dec.Decode(&req)
@@ -82,14 +198,44 @@ func (s *nativeServer) Serve(c io.ReadWriter) error {
 				Status: statusFatal,
 				Errors: []string{fmt.Sprintf("failed to decode request: %v", err)},
 			}
-			if err = enc.Encode(resp); err != nil {
+			if err = encode(resp); err != nil {
 				return err
 			}
 			continue
 		}
-		resp := s.parse(ctx, &req)
-		if err = enc.Encode(resp); err != nil {
+		if sem == nil || req.ID == 0 || req.Ping || req.Hello != nil {
+			if err = s.serve(ctx, &req, encode); err != nil {
+				return err
+			}
+			continue
+		}
+		// requests are read without waiting for the semaphore, so pings are answered even if the driver is busy
+		wg.Add(1)
+		go func(req *parseRequest) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			// write errors will be detected by the main loop
+			_ = s.serve(ctx, req, encode)
+		}(&req)
+	}
+}
+
+// serve runs a single request and sends all responses to it with encode.
+func (s *nativeServer) serve(ctx context.Context, req *parseRequest, encode func(r *parseResponse) error) error {
+	emit := func(r *parseResponse) error {
+		r.ID = req.ID
+		return encode(r)
+	}
+	if len(req.Batch) == 0 {
+		return emit(s.parse(ctx, req, emit))
+	}
+	// send a separate response for each file of the batch
+	for _, content := range req.Batch {
+		sub := &parseRequest{Content: content, Encoding: req.Encoding, Deadline: req.Deadline, Options: req.Options, Tokens: req.Tokens}
+		if err := emit(s.parse(ctx, sub, emit)); err != nil {
 			return err
 		}
 	}
+	return nil
 }
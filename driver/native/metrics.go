@@ -0,0 +1,148 @@
+package native
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+	"time"
+
+	"gopkg.in/bblfsh/sdk.v2/driver"
+)
+
+// Kinds of failures reported in RequestStats.
+const (
+	// FailureTimeout means that the deadline of the request was exceeded.
+	FailureTimeout = "timeout"
+	// FailureCancelled means that the request was cancelled.
+	FailureCancelled = "cancelled"
+	// FailureTooLarge means that the response exceeded the size limit. See Driver.SetMaxResponseSize.
+	FailureTooLarge = "too_large"
+	// FailureMemory means that the native process was killed for exceeding the memory limit. See Limits.
+	FailureMemory = "memory"
+	// FailureUnavailable means that the native process was not running and could not be restarted.
+	FailureUnavailable = "unavailable"
+	// FailureCrash means that the native process crashed or replied with a malformed response.
+	FailureCrash = "crash"
+	// FailureFatal means that the native driver replied with a fatal error.
+	FailureFatal = "fatal"
+)
+
+// FailureKind returns the kind of the driver failure. Unlike the kind reported in RequestStats, it only depends on
+// the error, thus it can be used for errors returned by the driver. It returns an empty string if err is not
+// a driver failure.
+func FailureKind(err error) string {
+	switch {
+	case err == nil || !driver.ErrDriverFailure.Is(err):
+		return ""
+	case ErrResponseTooLarge.Is(err) || ErrConcurrentTooLarge.Is(err):
+		return FailureTooLarge
+	case ErrMemoryLimit.Is(err):
+		return FailureMemory
+	case driver.ErrStageTimeout.Is(err):
+		return FailureTimeout
+	case ErrNotRunning.Is(err) || ErrRestarting.Is(err) || ErrClosing.Is(err):
+		return FailureUnavailable
+	case ErrNativeStderr.Is(err):
+		return FailureCrash
+	}
+	return FailureFatal
+}
+
+// RequestStats contains measurements of a single parse request.
+type RequestStats struct {
+	// Wait is the time the request waited for the previous requests to complete.
+	Wait time.Duration
+	// Latency is the time of the request itself, excluding the wait time.
+	Latency time.Duration
+	// BytesOut is the number of bytes sent to the native process.
+	BytesOut int64
+	// BytesIn is the number of bytes received from the native process.
+	//
+	// If the native driver processes requests concurrently, both counters include the traffic of other requests
+	// that were in flight at the same time.
+	BytesIn int64
+	// Failure is a kind of the driver failure. It is empty if the request succeeded or failed with a syntax error.
+	Failure string
+}
+
+// Metrics is an instrumentation interface for the native driver. It allows to export measurements of native
+// driver operations to a monitoring system. Methods are called synchronously and must be safe for concurrent use.
+type Metrics interface {
+	// RequestDone is called after each parse request.
+	RequestDone(st RequestStats)
+	// Restarted is called each time the native process is restarted.
+	Restarted()
+}
+
+// SetMetrics sets an instrumentation interface for the driver. It must be called before Start.
+func (d *Driver) SetMetrics(m Metrics) {
+	d.metrics = m
+}
+
+// observe starts measuring a request. The returned function reports the measurements to Metrics.
+// Both must be called with the mutex held.
+func (d *Driver) observe(ctx context.Context, start time.Time) func(err error) {
+	st := RequestStats{Wait: time.Since(start)}
+	sent, received := atomic.LoadInt64(&d.sent), atomic.LoadInt64(&d.received)
+	return func(err error) {
+		st.Latency = time.Since(start) - st.Wait
+		st.BytesOut = atomic.LoadInt64(&d.sent) - sent
+		st.BytesIn = atomic.LoadInt64(&d.received) - received
+		st.Failure = d.failureKind(ctx, err)
+		d.metrics.RequestDone(st)
+	}
+}
+
+// restarted records a restart of the native process.
+func (d *Driver) restarted() {
+	atomic.AddUint64(&d.restarts, 1)
+	if d.metrics != nil {
+		d.metrics.Restarted()
+	}
+}
+
+// failureKind returns a kind of the failure of a parse request. See RequestStats.Failure.
+func (d *Driver) failureKind(ctx context.Context, err error) string {
+	switch {
+	case err == nil || !driver.ErrDriverFailure.Is(err):
+		return ""
+	case ErrResponseTooLarge.Is(err) || ErrConcurrentTooLarge.Is(err):
+		return FailureTooLarge
+	case ErrMemoryLimit.Is(err):
+		return FailureMemory
+	case expired(ctx):
+		if ctx.Err() == context.Canceled {
+			return FailureCancelled
+		}
+		return FailureTimeout
+	case ErrNotRunning.Is(err) || ErrRestarting.Is(err):
+		return FailureUnavailable
+	case d.state == stateBroken:
+		return FailureCrash
+	}
+	return FailureFatal
+}
+
+// countingWriter counts the number of bytes written to the native process.
+type countingWriter struct {
+	w io.Writer
+	n *int64
+}
+
+func (w countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	atomic.AddInt64(w.n, int64(n))
+	return n, err
+}
+
+// countingReader counts the number of bytes read from the native process.
+type countingReader struct {
+	r io.Reader
+	n *int64
+}
+
+func (r countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	atomic.AddInt64(r.n, int64(n))
+	return n, err
+}
@@ -0,0 +1,286 @@
+package msgpack
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+
+	"gopkg.in/src-d/go-errors.v1"
+
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+const (
+	// DefaultBufferSize is the default buffer size for decoding. It will
+	// be used whenever the given reader is not buffered.
+	DefaultBufferSize = 1024 * 1024 * 4
+
+	// maxPrealloc limits the capacity allocated upfront for strings, arrays and objects, since their lengths
+	// are not validated until the data is read.
+	maxPrealloc = 1 << 16
+)
+
+// ErrValueTooLarge is returned by a limited decoder when the encoded value exceeds the size limit.
+var ErrValueTooLarge = errors.NewKind("msgpack: value exceeds the limit of %d bytes")
+
+type byteReader interface {
+	io.Reader
+	io.ByteReader
+}
+
+// Decoder decodes MessagePack values as nodes.
+type Decoder interface {
+	// Decode reads the next value from the stream.
+	Decode() (nodes.Node, error)
+}
+
+type decoder struct {
+	r   byteReader
+	buf [8]byte
+
+	max int // size limit for a single value; zero means no limit
+	n   int // number of bytes read for the current value
+}
+
+// NewDecoder creates a new decoder with the given reader. If the given reader
+// is not buffered, it will be wrapped with a *bufio.Reader.
+func NewDecoder(r io.Reader) Decoder {
+	br, ok := r.(byteReader)
+	if !ok {
+		br = bufio.NewReaderSize(r, DefaultBufferSize)
+	}
+	return &decoder{r: br}
+}
+
+// NewLimitedDecoder is similar to NewDecoder, but rejects values larger than max bytes.
+// Values that exceed the limit are consumed without being stored and ErrValueTooLarge is returned.
+// The decoder can be used after this error.
+func NewLimitedDecoder(r io.Reader, max int) Decoder {
+	d := NewDecoder(r).(*decoder)
+	d.max = max
+	return d
+}
+
+// Decode implements Decoder.
+//
+// Values are converted the same way as nodes.ToNode converts decoded JSON: integers that fit into int64
+// and floats without a fractional part are returned as nodes.Int. This way native drivers produce the same
+// trees regardless of the wire format.
+//
+// It returns io.EOF only if the stream ends before the first byte of the value.
+func (d *decoder) Decode() (nodes.Node, error) {
+	d.n = 0
+	n, err := d.decode()
+	if err == nil && d.exceeded() {
+		return nil, ErrValueTooLarge.New(d.max)
+	}
+	return n, err
+}
+
+// exceeded checks if the current value is larger than the limit. The rest of the value is skipped in this case.
+func (d *decoder) exceeded() bool {
+	return d.max > 0 && d.n > d.max
+}
+
+// Unmarshal decodes a single MessagePack value.
+func Unmarshal(data []byte) (nodes.Node, error) {
+	r := bytes.NewReader(data)
+	n, err := (&decoder{r: r}).decode()
+	if err != nil {
+		return nil, err
+	}
+	if r.Len() != 0 {
+		return nil, fmt.Errorf("msgpack: %d unexpected bytes after the value", r.Len())
+	}
+	return n, nil
+}
+
+func (d *decoder) decode() (nodes.Node, error) {
+	tag, err := d.r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	d.n++
+	n, err := d.decodeValue(tag)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+func (d *decoder) decodeValue(tag byte) (nodes.Node, error) {
+	switch {
+	case tag <= 0x7f:
+		return nodes.Int(tag), nil
+	case tag >= 0xe0:
+		return nodes.Int(int8(tag)), nil
+	case tag&0xf0 == 0x80:
+		return d.decodeMap(int(tag & 0x0f))
+	case tag&0xf0 == 0x90:
+		return d.decodeArray(int(tag & 0x0f))
+	case tag&0xe0 == 0xa0:
+		return d.decodeString(int(tag & 0x1f))
+	}
+	switch tag {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return nodes.Bool(false), nil
+	case 0xc3:
+		return nodes.Bool(true), nil
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		v, err := d.readUint(1 << (tag - 0xcc))
+		if err != nil {
+			return nil, err
+		}
+		if v > math.MaxInt64 {
+			return nodes.Uint(v), nil
+		}
+		return nodes.Int(v), nil
+	case 0xd0:
+		v, err := d.readUint(1)
+		return nodes.Int(int8(v)), err
+	case 0xd1:
+		v, err := d.readUint(2)
+		return nodes.Int(int16(v)), err
+	case 0xd2:
+		v, err := d.readUint(4)
+		return nodes.Int(int32(v)), err
+	case 0xd3:
+		v, err := d.readUint(8)
+		return nodes.Int(int64(v)), err
+	case 0xca:
+		v, err := d.readUint(4)
+		return floatNode(float64(math.Float32frombits(uint32(v)))), err
+	case 0xcb:
+		v, err := d.readUint(8)
+		return floatNode(math.Float64frombits(v)), err
+	case 0xd9, 0xda, 0xdb, 0xc4, 0xc5, 0xc6:
+		// binary values are treated as strings
+		size := 1
+		if tag >= 0xd9 {
+			size <<= tag - 0xd9
+		} else {
+			size <<= tag - 0xc4
+		}
+		l, err := d.readUint(size)
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeString(int(l))
+	case 0xdc, 0xdd:
+		l, err := d.readUint(2 << (tag - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeArray(int(l))
+	case 0xde, 0xdf:
+		l, err := d.readUint(2 << (tag - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeMap(int(l))
+	}
+	return nil, fmt.Errorf("msgpack: unsupported type: 0x%02x", tag)
+}
+
+func floatNode(v float64) nodes.Node {
+	if float64(int64(v)) == v {
+		return nodes.Int(v)
+	}
+	return nodes.Float(v)
+}
+
+func (d *decoder) readUint(size int) (uint64, error) {
+	b := d.buf[:size]
+	if _, err := io.ReadFull(d.r, b); err != nil {
+		return 0, err
+	}
+	d.n += size
+	switch size {
+	case 1:
+		return uint64(b[0]), nil
+	case 2:
+		return uint64(binary.BigEndian.Uint16(b)), nil
+	case 4:
+		return uint64(binary.BigEndian.Uint32(b)), nil
+	}
+	return binary.BigEndian.Uint64(b), nil
+}
+
+func (d *decoder) readString(l int) (string, error) {
+	d.n += l
+	if l == 0 {
+		return "", nil
+	} else if d.exceeded() {
+		_, err := io.CopyN(ioutil.Discard, d.r, int64(l))
+		return "", err
+	} else if l > maxPrealloc {
+		var buf bytes.Buffer
+		buf.Grow(maxPrealloc)
+		if _, err := io.CopyN(&buf, d.r, int64(l)); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	}
+	b := make([]byte, l)
+	if _, err := io.ReadFull(d.r, b); err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func (d *decoder) decodeString(l int) (nodes.Node, error) {
+	s, err := d.readString(l)
+	if err != nil {
+		return nil, err
+	}
+	return nodes.String(s), nil
+}
+
+func (d *decoder) decodeArray(l int) (nodes.Node, error) {
+	arr := make(nodes.Array, 0, prealloc(l))
+	for i := 0; i < l; i++ {
+		v, err := d.decode()
+		if err != nil {
+			return nil, err
+		}
+		if !d.exceeded() {
+			arr = append(arr, v)
+		}
+	}
+	return arr, nil
+}
+
+func (d *decoder) decodeMap(l int) (nodes.Node, error) {
+	obj := make(nodes.Object, prealloc(l))
+	for i := 0; i < l; i++ {
+		k, err := d.decode()
+		if err != nil {
+			return nil, err
+		}
+		key, ok := k.(nodes.String)
+		if !ok {
+			return nil, fmt.Errorf("msgpack: expected string key, got: %T", k)
+		}
+		v, err := d.decode()
+		if err != nil {
+			return nil, err
+		}
+		if !d.exceeded() {
+			obj[string(key)] = v
+		}
+	}
+	return obj, nil
+}
+
+func prealloc(l int) int {
+	if l > maxPrealloc {
+		return maxPrealloc
+	}
+	return l
+}
@@ -0,0 +1,135 @@
+package msgpack
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+func TestDecoderRoundTrip(t *testing.T) {
+	arr := make(nodes.Array, 20)
+	for i := range arr {
+		arr[i] = nodes.Int(i * 1000)
+	}
+	cases := []nodes.Node{
+		nodes.Object{
+			"str":  nodes.String(strings.Repeat("x", 70000)),
+			"arr":  arr,
+			"min":  nodes.Int(-1 << 63),
+			"max":  nodes.Uint(1<<64 - 1),
+			"tree": nodes.Object{"k": nodes.Array{nodes.Float(-1.25)}},
+		},
+	}
+	for _, c := range encodeCases {
+		if u, ok := c.node.(nodes.Uint); ok {
+			// small unsigned integers are decoded as signed
+			cases = append(cases, nodes.Int(u))
+			continue
+		}
+		cases = append(cases, c.node)
+	}
+	for _, c := range cases {
+		data, err := Marshal(c)
+		require.NoError(t, err)
+		n, err := Unmarshal(data)
+		require.NoError(t, err)
+		require.Equal(t, c, n)
+	}
+}
+
+func TestDecoderConversions(t *testing.T) {
+	cases := []struct {
+		name string
+		data []byte
+		exp  nodes.Node
+	}{
+		{name: "uint64", data: []byte{0xcf, 0, 0, 0, 0, 0, 0, 0, 7}, exp: nodes.Int(7)},
+		{name: "int8", data: []byte{0xd0, 0xff}, exp: nodes.Int(-1)},
+		{name: "integral float", data: []byte{0xca, 0x40, 0x40, 0, 0}, exp: nodes.Int(3)},
+		{name: "float32", data: []byte{0xca, 0x3f, 0xc0, 0, 0}, exp: nodes.Float(1.5)},
+		{name: "bin", data: []byte{0xc4, 2, 'h', 'i'}, exp: nodes.String("hi")},
+		{name: "map16", data: []byte{0xde, 0, 1, 0xa1, 'k', 0xc0}, exp: nodes.Object{"k": nil}},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			n, err := Unmarshal(c.data)
+			require.NoError(t, err)
+			require.Equal(t, c.exp, n)
+		})
+	}
+}
+
+func TestDecoderErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		data []byte
+	}{
+		{name: "ext", data: []byte{0xd4, 1, 2}},
+		{name: "int key", data: []byte{0x81, 0x01, 0x02}},
+		{name: "trailing", data: []byte{0xc0, 0xc0}},
+		{name: "truncated", data: []byte{0x92, 0x01}},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			_, err := Unmarshal(c.data)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestDecoderStream(t *testing.T) {
+	require := require.New(t)
+
+	d := NewDecoder(bytes.NewReader([]byte{0x01, 0xa1, 'x', 0x92}))
+
+	n, err := d.Decode()
+	require.NoError(err)
+	require.Equal(nodes.Int(1), n)
+
+	n, err = d.Decode()
+	require.NoError(err)
+	require.Equal(nodes.String("x"), n)
+
+	_, err = d.Decode()
+	require.Equal(io.ErrUnexpectedEOF, err)
+
+	_, err = d.Decode()
+	require.Equal(io.EOF, err)
+}
+
+func TestLimitedDecoder(t *testing.T) {
+	require := require.New(t)
+
+	var buf bytes.Buffer
+	for _, n := range []nodes.Node{
+		nodes.Object{"k": nodes.String("v")},
+		nodes.Object{"k": nodes.Array{nodes.String(strings.Repeat("x", 100)), nodes.Int(1)}},
+		nodes.Int(3),
+	} {
+		data, err := Marshal(n)
+		require.NoError(err)
+		buf.Write(data)
+	}
+	d := NewLimitedDecoder(&buf, 16)
+
+	n, err := d.Decode()
+	require.NoError(err)
+	require.Equal(nodes.Object{"k": nodes.String("v")}, n)
+
+	_, err = d.Decode()
+	require.True(ErrValueTooLarge.Is(err), "%v", err)
+
+	n, err = d.Decode()
+	require.NoError(err)
+	require.Equal(nodes.Int(3), n)
+
+	_, err = d.Decode()
+	require.Equal(io.EOF, err)
+}
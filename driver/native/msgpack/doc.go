@@ -0,0 +1,6 @@
+// Package msgpack implements a MessagePack encoding of UAST nodes, used as an alternative wire format
+// of the native driver protocol.
+//
+// Only the subset of the format that maps to nodes is supported: nil, booleans, integers, floats, strings,
+// arrays and maps with string keys. Binary values are decoded as strings, extension types are rejected.
+package msgpack
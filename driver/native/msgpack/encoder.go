@@ -0,0 +1,157 @@
+package msgpack
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+// Encoder encodes nodes as MessagePack values.
+type Encoder interface {
+	// Encode writes the next node to the stream.
+	Encode(n nodes.Node) error
+}
+
+type encoder struct {
+	w   io.Writer
+	buf []byte
+}
+
+// NewEncoder creates a new encoder using the given writer.
+func NewEncoder(w io.Writer) Encoder {
+	return &encoder{w: w}
+}
+
+// Encode implements Encoder. Each node is written with a single call to the underlying writer.
+func (e *encoder) Encode(n nodes.Node) error {
+	b, err := Append(e.buf[:0], n)
+	if err != nil {
+		return err
+	}
+	e.buf = b
+	_, err = e.w.Write(b)
+	return err
+}
+
+// Marshal returns the MessagePack encoding of the node.
+func Marshal(n nodes.Node) ([]byte, error) {
+	return Append(nil, n)
+}
+
+// Append appends the MessagePack encoding of the node to the buffer. Object keys are written in sorted order.
+func Append(b []byte, n nodes.Node) ([]byte, error) {
+	switch n := n.(type) {
+	case nil:
+		return append(b, 0xc0), nil
+	case nodes.Bool:
+		if n {
+			return append(b, 0xc3), nil
+		}
+		return append(b, 0xc2), nil
+	case nodes.Int:
+		return appendInt(b, int64(n)), nil
+	case nodes.Uint:
+		return appendUint(b, uint64(n)), nil
+	case nodes.Float:
+		b = append(b, 0xcb)
+		return appendUint64(b, math.Float64bits(float64(n))), nil
+	case nodes.String:
+		return appendString(b, string(n)), nil
+	case nodes.Array:
+		b = appendLen(b, len(n), 0x90, 0xdc, 0xdd)
+		for _, v := range n {
+			var err error
+			b, err = Append(b, v)
+			if err != nil {
+				return b, err
+			}
+		}
+		return b, nil
+	case nodes.Object:
+		b = appendLen(b, len(n), 0x80, 0xde, 0xdf)
+		for _, k := range n.Keys() {
+			b = appendString(b, k)
+			var err error
+			b, err = Append(b, n[k])
+			if err != nil {
+				return b, err
+			}
+		}
+		return b, nil
+	}
+	return b, fmt.Errorf("msgpack: unsupported node type: %T", n)
+}
+
+func appendInt(b []byte, v int64) []byte {
+	switch {
+	case v >= 0:
+		return appendUint(b, uint64(v))
+	case v >= -32:
+		return append(b, byte(v))
+	case v >= math.MinInt8:
+		return append(b, 0xd0, byte(v))
+	case v >= math.MinInt16:
+		return appendUint16(append(b, 0xd1), uint16(v))
+	case v >= math.MinInt32:
+		return appendUint32(append(b, 0xd2), uint32(v))
+	}
+	return appendUint64(append(b, 0xd3), uint64(v))
+}
+
+func appendUint(b []byte, v uint64) []byte {
+	switch {
+	case v <= 0x7f:
+		return append(b, byte(v))
+	case v <= math.MaxUint8:
+		return append(b, 0xcc, byte(v))
+	case v <= math.MaxUint16:
+		return appendUint16(append(b, 0xcd), uint16(v))
+	case v <= math.MaxUint32:
+		return appendUint32(append(b, 0xce), uint32(v))
+	}
+	return appendUint64(append(b, 0xcf), v)
+}
+
+func appendString(b []byte, s string) []byte {
+	if len(s) <= 31 {
+		b = append(b, 0xa0|byte(len(s)))
+	} else if len(s) <= math.MaxUint8 {
+		b = append(b, 0xd9, byte(len(s)))
+	} else {
+		b = appendLen(b, len(s), 0, 0xda, 0xdb)
+	}
+	return append(b, s...)
+}
+
+// appendLen writes a header of a value with a given length. The fixed form is used for lengths below 16
+// if fix is not zero.
+func appendLen(b []byte, n int, fix, tag16, tag32 byte) []byte {
+	switch {
+	case fix != 0 && n <= 15:
+		return append(b, fix|byte(n))
+	case n <= math.MaxUint16:
+		return appendUint16(append(b, tag16), uint16(n))
+	}
+	return appendUint32(append(b, tag32), uint32(n))
+}
+
+func appendUint16(b []byte, v uint16) []byte {
+	var buf [2]byte
+	binary.BigEndian.PutUint16(buf[:], v)
+	return append(b, buf[:]...)
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], v)
+	return append(b, buf[:]...)
+}
+
+func appendUint64(b []byte, v uint64) []byte {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], v)
+	return append(b, buf[:]...)
+}
@@ -0,0 +1,61 @@
+package msgpack
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+var encodeCases = []struct {
+	name string
+	node nodes.Node
+	exp  []byte
+}{
+	{name: "nil", node: nil, exp: []byte{0xc0}},
+	{name: "bool", node: nodes.Bool(true), exp: []byte{0xc3}},
+	{name: "fixint", node: nodes.Int(5), exp: []byte{0x05}},
+	{name: "negative fixint", node: nodes.Int(-3), exp: []byte{0xfd}},
+	{name: "int16", node: nodes.Int(-300), exp: []byte{0xd1, 0xfe, 0xd4}},
+	{name: "uint8", node: nodes.Uint(200), exp: []byte{0xcc, 0xc8}},
+	{name: "uint32", node: nodes.Int(1 << 20), exp: []byte{0xce, 0x00, 0x10, 0x00, 0x00}},
+	{name: "float", node: nodes.Float(0.5), exp: []byte{0xcb, 0x3f, 0xe0, 0, 0, 0, 0, 0, 0}},
+	{name: "fixstr", node: nodes.String("abc"), exp: []byte{0xa3, 'a', 'b', 'c'}},
+	{name: "array", node: nodes.Array{nodes.Int(1), nil}, exp: []byte{0x92, 0x01, 0xc0}},
+	{
+		name: "object",
+		node: nodes.Object{"b": nodes.Int(2), "a": nodes.Bool(false)},
+		exp:  []byte{0x82, 0xa1, 'a', 0xc2, 0xa1, 'b', 0x02},
+	},
+}
+
+func TestEncoder(t *testing.T) {
+	for _, c := range encodeCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			data, err := Marshal(c.node)
+			require.NoError(t, err)
+			require.Equal(t, c.exp, data)
+		})
+	}
+}
+
+func TestEncoderStream(t *testing.T) {
+	require := require.New(t)
+
+	buf := bytes.NewBuffer(nil)
+	e := NewEncoder(buf)
+
+	err := e.Encode(nodes.Object{"example": nodes.Int(1)})
+	require.NoError(err)
+	err = e.Encode(nodes.String(strings.Repeat("x", 40)))
+	require.NoError(err)
+
+	exp := append([]byte{0x81, 0xa7}, "example"...)
+	exp = append(exp, 0x01, 0xd9, 40)
+	exp = append(exp, strings.Repeat("x", 40)...)
+	require.Equal(exp, buf.Bytes())
+}
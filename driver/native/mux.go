@@ -0,0 +1,224 @@
+package native
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	serrors "gopkg.in/src-d/go-errors.v1"
+
+	"gopkg.in/bblfsh/sdk.v2/driver"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+var (
+	// ErrConcurrentTooLarge is returned for concurrent requests if one of the responses exceeds the size limit.
+	// Since the response is discarded, it cannot be attributed to a specific request, thus all of them fail.
+	ErrConcurrentTooLarge = serrors.NewKind("response to one of concurrent requests exceeds the size limit of %d bytes")
+)
+
+// ConcurrentNative is a native driver that can parse multiple sources at the same time. If the driver passed to Main
+// implements this interface, requests are processed concurrently and responses are sent in the order of completion.
+type ConcurrentNative interface {
+	driver.Native
+	// MaxConcurrency returns the maximal number of requests processed at the same time.
+	MaxConcurrency() int
+}
+
+// muxMsg is a response or an error delivered to a muxWaiter.
+type muxMsg struct {
+	r   *parseResponse
+	err error
+}
+
+// mux reads responses of a native driver that declared CapConcurrent and dispatches them to requests by ID.
+type mux struct {
+	dec msgDecoder
+	max int
+
+	mu      sync.Mutex
+	waiters map[uint64]*muxWaiter
+
+	done chan struct{} // closed when the reader stops
+	err  error         // the reason why the reader stopped; set before closing done
+}
+
+// muxWaiter receives responses to a single request.
+type muxWaiter struct {
+	m    *mux
+	id   uint64
+	ch   chan muxMsg
+	gone chan struct{} // closed when the request no longer waits for responses
+}
+
+// startMux starts reading responses of the native process in the background.
+// It must be called with the mutex held.
+func (d *Driver) startMux() {
+	m := &mux{
+		dec: d.dec, max: d.maxResp,
+		waiters: make(map[uint64]*muxWaiter),
+		done:    make(chan struct{}),
+	}
+	d.mux = m
+	go m.run()
+}
+
+func (m *mux) run() {
+	for {
+		var r parseResponse
+		err := m.dec.Decode(&r)
+		if tooLarge(err) {
+			// the response was consumed, so the stream is still in sync
+			m.tooLarge()
+			continue
+		} else if err == nil && r.ID == 0 {
+			err = fmt.Errorf("response without a request ID: %v", r.Errors)
+		}
+		if err != nil {
+			m.err = err
+			close(m.done)
+			return
+		}
+		m.mu.Lock()
+		w := m.waiters[r.ID]
+		m.mu.Unlock()
+		if w != nil {
+			w.send(muxMsg{r: &r})
+		}
+		// otherwise, the request was cancelled and the late response is discarded
+	}
+}
+
+// tooLarge handles a response that exceeds the size limit. It is attributed to a request only if there is a single
+// request in flight. Otherwise, all requests fail with ErrConcurrentTooLarge.
+func (m *mux) tooLarge() {
+	m.mu.Lock()
+	var ws []*muxWaiter
+	for _, w := range m.waiters {
+		ws = append(ws, w)
+	}
+	if len(ws) > 1 {
+		m.waiters = make(map[uint64]*muxWaiter)
+	}
+	m.mu.Unlock()
+	if len(ws) == 1 {
+		ws[0].send(muxMsg{err: ErrResponseTooLarge.New(m.max)})
+		return
+	}
+	for _, w := range ws {
+		w.send(muxMsg{err: ErrConcurrentTooLarge.New(m.max)})
+	}
+}
+
+// failed returns the error that stopped the reader, or nil if it is still running.
+func (m *mux) failed() error {
+	select {
+	case <-m.done:
+		return m.err
+	default:
+		return nil
+	}
+}
+
+func (m *mux) register(id uint64) *muxWaiter {
+	w := &muxWaiter{m: m, id: id, ch: make(chan muxMsg), gone: make(chan struct{})}
+	m.mu.Lock()
+	m.waiters[id] = w
+	m.mu.Unlock()
+	return w
+}
+
+func (w *muxWaiter) send(msg muxMsg) {
+	select {
+	case w.ch <- msg:
+	case <-w.gone:
+	}
+}
+
+// next waits for the next response to the request.
+func (w *muxWaiter) next(ctx context.Context) (*parseResponse, error) {
+	select {
+	case msg := <-w.ch:
+		return msg.r, msg.err
+	case <-w.m.done:
+		return nil, w.m.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// close stops waiting for responses. Late responses to the request are discarded.
+func (w *muxWaiter) close() {
+	w.m.mu.Lock()
+	if w.m.waiters[w.id] == w {
+		delete(w.m.waiters, w.id)
+	}
+	w.m.mu.Unlock()
+	close(w.gone)
+}
+
+// send assigns an ID to the request, writes it to the native process and returns the waiter for its responses.
+// It must be called with the mutex held.
+func (d *Driver) send(ctx context.Context, req *parseRequest) (*muxWaiter, error) {
+	d.lastID++
+	req.ID = d.lastID
+	w := d.mux.register(req.ID)
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = d.stdin.SetWriteDeadline(deadline)
+		defer d.stdin.SetWriteDeadline(time.Time{})
+	}
+	if err := d.writeRequest(ctx, req); err != nil {
+		w.close()
+		return nil, d.broken(err)
+	}
+	return w, nil
+}
+
+// finish stops waiting for responses and checks if the request failed because the native process stopped.
+// It must be called with the mutex held.
+func (d *Driver) finish(w *muxWaiter, err error) error {
+	w.close()
+	if err == nil || d.mux != w.m || !d.isRunning() || d.state != stateOK {
+		// the process was stopped or restarted in the meantime
+		return err
+	}
+	if merr := w.m.failed(); merr != nil {
+		if lerr := d.limitErr(); lerr != nil {
+			merr = lerr
+		}
+		return d.broken(merr)
+	}
+	return err
+}
+
+// muxExchange is similar to exchange, but reads responses dispatched by request ID. The read function receives
+// the function that returns the next response to the request. It must be called with the mutex held.
+// If unlock is set, the mutex is released while reading the responses, allowing other requests to be sent.
+func (d *Driver) muxExchange(ctx context.Context, req *parseRequest, read func(next func() (*parseResponse, error)) error, unlock bool) error {
+	w, err := d.send(ctx, req)
+	if err != nil {
+		return err
+	}
+	if unlock {
+		d.mu.Unlock()
+	}
+	err = read(func() (*parseResponse, error) {
+		return w.next(ctx)
+	})
+	if unlock {
+		d.mu.Lock()
+	}
+	return d.finish(w, err)
+}
+
+// muxTrip is similar to roundTrip, but dispatches the response by request ID. See muxExchange.
+func (d *Driver) muxTrip(ctx context.Context, req *parseRequest, fnc func(n nodes.Node) error, unlock bool) (*parseResponse, error) {
+	var r *parseResponse
+	err := d.muxExchange(ctx, req, func(next func() (*parseResponse, error)) error {
+		var err error
+		r, err = collectStream(next, fnc)
+		return err
+	}, unlock)
+	return r, err
+}
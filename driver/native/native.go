@@ -1,15 +1,20 @@
 package native
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/opentracing/opentracing-go"
@@ -33,8 +38,13 @@ const (
 
 var (
 	ErrNotRunning = serrors.NewKind("native driver is not running")
+	// ErrResponseTooLarge is returned when the response of the native driver exceeds the limit set by
+	// SetMaxResponseSize. The driver remains usable after this error.
+	ErrResponseTooLarge = serrors.NewKind("native driver response exceeds the limit of %d bytes")
 )
 
+var _ driver.ReaderNative = (*Driver)(nil)
+
 func NewDriver(enc Encoding) driver.Native {
 	return NewDriverAt("", enc)
 }
@@ -46,7 +56,7 @@ func NewDriverAt(bin string, enc Encoding) driver.Native {
 	if enc == "" {
 		enc = UTF8
 	}
-	return &Driver{bin: bin, ec: enc}
+	return &Driver{bin: bin, ec: enc, format: JSONLines}
 }
 
 type driverState int
@@ -55,6 +65,7 @@ const (
 	stateOK = driverState(iota)
 	stateTimeout
 	stateBroken
+	stateKilled // killed on cancellation, must be started again
 )
 
 // Driver is a wrapper of the native command. The operations with the
@@ -63,94 +74,301 @@ const (
 type Driver struct {
 	bin     string
 	ec      Encoding
-	running bool
+	format  Format
+	socket  string
+	maxResp int
+	running int32 // set to 1 when the native process is started; atomic, see isRunning
 
 	mu      sync.Mutex
-	enc     jsonlines.Encoder
-	dec     jsonlines.Decoder
-	stdin   *os.File
-	stdout  *os.File
+	stopMu  sync.Mutex // serializes stop, which may be called without mu; guards pingStop
+	enc     msgEncoder
+	dec     msgDecoder
+	stdin   inStream
+	stdout  outStream
 	cmd     *exec.Cmd
+	exited  chan error // result of cmd.Wait; nil if not waiting yet
 	state   driverState
 	lastErr error
+
+	warmup    []string
+	limits    Limits
+	sandbox   Sandbox
+	watch     *watchdog
+	cancel    CancelPolicy
+	restart   RestartPolicy
+	restarts  uint64    // total number of restarts; atomic
+	crashes   int       // consecutive restarts without a successful response
+	restartAt time.Time // restarts are not allowed before this time
+	pingEvery time.Duration
+	pingStop  chan struct{}
+	log       Logger
+	stderr    *stderrLog
+	opts      Options
+	hello     bool          // handshake is enabled
+	proto     *ProtocolInfo // result of the handshake
+	metrics   Metrics
+	pending   int  // number of late responses to skip in stateTimeout; one if not set
+	mux       *mux // reads responses in the background if the native driver declared CapConcurrent
+	lastID    uint64
+	reqs      requests // requests in progress; see Options.DrainTimeout
+	sent      int64    // bytes sent to the native process; atomic
+	received  int64    // bytes received from the native process; atomic
+}
+
+// Binary returns the path to the native driver binary.
+func (d *Driver) Binary() string {
+	return d.bin
+}
+
+// Encoding returns the encoding of the source files sent to the native driver.
+func (d *Driver) Encoding() Encoding {
+	return d.ec
+}
+
+// SetFormat sets the wire format used to communicate with the native driver. The format is passed to the
+// native process in FormatEnv variable. It must be called before Start.
+func (d *Driver) SetFormat(f Format) error {
+	if f == "" {
+		f = JSONLines
+	}
+	if err := f.validate(); err != nil {
+		return err
+	} else if f == GRPC {
+		return ErrUnsupportedFormat.New(string(f))
+	}
+	d.format = f
+	return nil
+}
+
+// SetMaxResponseSize limits the size of a single encoded response of the native driver. Larger responses are
+// discarded and Parse returns ErrResponseTooLarge. Zero means no limit. It must be called before Start.
+func (d *Driver) SetMaxResponseSize(n int) {
+	if n < 0 {
+		n = 0
+	}
+	d.maxResp = n
+}
+
+// healthy checks if the driver is running and can accept requests. Used by Pool.
+func (d *Driver) healthy() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return (d.isRunning() || d.state == stateKilled) && d.state != stateBroken
 }
 
 // Start executes the given native driver and prepares it to parse code.
+// If warm-up snippets are set, Start parses them and fails if the driver cannot process them.
 func (d *Driver) Start() error {
-	d.cmd = exec.Command(d.bin)
-	d.cmd.Stderr = os.Stderr
+	d.state, d.lastErr, d.pending = stateOK, nil, 0
+	d.mux = nil
+	ctx, cancel := d.startContext()
+	defer cancel()
+	if err := d.start(ctx); err != nil {
+		return err
+	}
+	if err := d.handshake(ctx); err != nil {
+		return err
+	}
+	if d.proto != nil && d.proto.Has(CapConcurrent) {
+		d.startMux()
+	}
+	if err := d.warmUp(ctx); err != nil {
+		return err
+	}
+	if err := d.waitReady(ctx); err != nil {
+		return err
+	}
+	d.startPinger()
+	return nil
+}
 
-	var (
-		err           error
-		stdin, stdout *os.File
-	)
+func (d *Driver) start(ctx context.Context) error {
+	d.cmd = exec.Command(d.bin, d.opts.Args...)
+	d.cmd.Dir = d.opts.Dir
+	d.stderr = newStderrLog(d.log)
+	d.cmd.Stderr = d.stderr
+	env := append(os.Environ(), d.opts.Env...)
+	d.cmd.Env = append(env, FormatEnv+"="+string(d.format))
+	d.exited = nil
+	if d.socket != "" {
+		return d.startSocket(ctx)
+	}
 
-	stdin, d.stdin, err = os.Pipe()
+	stdin, win, err := os.Pipe()
 	if err != nil {
 		return err
 	}
 
-	d.stdout, stdout, err = os.Pipe()
+	rout, stdout, err := os.Pipe()
 	if err != nil {
 		stdin.Close()
-		d.stdin.Close()
+		win.Close()
 		return err
 	}
 	d.cmd.Stdin = stdin
 	d.cmd.Stdout = stdout
+	d.stdin, d.stdout = win, rout
 
-	d.enc = jsonlines.NewEncoder(d.stdin)
-	d.dec = jsonlines.NewDecoder(d.stdout)
+	d.enc = d.format.newEncoder(countingWriter{w: d.stdin, n: &d.sent})
+	d.dec = d.format.newDecoder(countingReader{r: d.stdout, n: &d.received}, d.maxResp)
 
-	err = d.cmd.Start()
-	if err == nil {
-		d.running = true
-		return nil
-	}
-	d.stdin.Close()
-	d.stdout.Close()
+	err = d.startProcess()
+	// the native process owns these ends now; closing them allows to detect when it exits
 	stdin.Close()
 	stdout.Close()
+	if err == nil {
+		if err = d.applyLimits(); err == nil {
+			d.setRunning(true)
+			return nil
+		}
+		d.cmd.Process.Kill()
+	}
+	win.Close()
+	rout.Close()
 	return err
 }
 
 // parseRequest is the request used to communicate the driver with the
 // native driver via json.
+//
+// If Ping is set, the request is a health check and the native driver must reply with statusOK without parsing
+// the content. See Driver.Ping.
+//
+// If Hello is set, the request is a handshake and the native driver must reply with its own protocol information
+// in the Hello field of the response. See ProtocolInfo.
+//
+// Deadline is set if the request has a deadline. Native drivers may stop parsing after the deadline,
+// since the response will be discarded anyway. It is encoded in RFC 3339 format.
 type parseRequest struct {
-	Content  string   `json:"content"`
-	Encoding Encoding `json:"Encoding"`
+	Content  string        `json:"content"`
+	Encoding Encoding      `json:"Encoding"`
+	Ping     bool          `json:"ping,omitempty"`
+	Hello    *ProtocolInfo `json:"hello,omitempty"`
+	Deadline *time.Time    `json:"deadline,omitempty"`
+	// ID is set if the native driver declared CapConcurrent. All responses to the request must have the same ID.
+	ID uint64 `json:"id,omitempty"`
+	// Batch is a list of encoded sources to parse. The native driver sends a separate response for each of them.
+	Batch []string `json:"batch,omitempty"`
+	// Options are sent only if the native driver declared CapOptions.
+	Options *driver.LanguageOptions `json:"options,omitempty"`
+	// Tokens requests an array of tokens instead of the AST. Sent only if the native driver declared CapTokens.
+	Tokens bool `json:"tokens,omitempty"`
+}
+
+// newRequest creates a request with the deadline of the context.
+func newRequest(ctx context.Context, req parseRequest) *parseRequest {
+	if deadline, ok := ctx.Deadline(); ok {
+		deadline = deadline.UTC()
+		req.Deadline = &deadline
+	}
+	return &req
 }
 
 var _ json.Unmarshaler = (*parseResponse)(nil)
 
 // parseResponse is the reply to parseRequest by the native parser.
+//
+// If the Encoding is set, the AST is a string with the JSON representation of the tree, encoded with
+// this encoding. See encodeAST.
+//
+// If the AST is streamed, the driver sends a response with statusChunk for each part of the tree, followed by
+// the final response. Chunks is set to the field of the root node that holds the list of parts. See assemble.
 type parseResponse struct {
-	Status status     `json:"status"`
-	Errors []string   `json:"errors"`
-	AST    nodes.Node `json:"ast"`
+	Status status   `json:"status"`
+	Errors []string `json:"errors"`
+	// Diagnostics are structured errors. If set, they are used instead of Errors.
+	Diagnostics []*derrors.Diagnostic `json:"diagnostics,omitempty"`
+	AST         nodes.Node            `json:"ast"`
+	Encoding    Encoding              `json:"encoding,omitempty"`
+	Chunks      string                `json:"chunks,omitempty"`
+	Hello       *ProtocolInfo         `json:"hello,omitempty"`
+	ID          uint64                `json:"id,omitempty"`
+	// Options is set by the native driver to the language options used to parse the source.
+	Options *driver.LanguageOptions `json:"options,omitempty"`
+
+	// max is the limit for the size of the decoded AST. Zero means no limit.
+	max int
 }
 
+// UnmarshalJSON decodes the response directly into nodes, without decoding the AST into interface{} values first.
+// Field names are matched case-insensitively, the same way as in encoding/json.
 func (r *parseResponse) UnmarshalJSON(data []byte) error {
-	var resp struct {
-		Status status      `json:"status"`
-		Errors []string    `json:"errors"`
-		AST    interface{} `json:"ast"`
-	}
-	if err := json.Unmarshal(data, &resp); err != nil {
+	n, err := jsonlines.ParseNode(data)
+	if err != nil {
 		return err
 	}
-	ast, err := nodes.ToNode(resp.AST, nil)
+	obj, ok := n.(nodes.Object)
+	if !ok {
+		return fmt.Errorf("expected an object, got: %T", n)
+	}
+	obj = foldKeys(obj)
+	if hello, ok := obj["hello"].(nodes.Object); ok {
+		obj["hello"] = foldKeys(hello)
+	}
+	if opts, ok := obj["options"].(nodes.Object); ok {
+		obj["options"] = foldKeys(opts)
+	}
+	return r.fromNode(obj)
+}
+
+// foldKeys adds lower-case aliases for keys of the object that are not lower-case. Exact matches take precedence.
+func foldKeys(obj nodes.Object) nodes.Object {
+	var out nodes.Object
+	for k, v := range obj {
+		lk := strings.ToLower(k)
+		if lk == k {
+			continue
+		} else if _, ok := obj[lk]; ok {
+			continue
+		}
+		if out == nil {
+			out = obj.CloneObject()
+		}
+		out[lk] = v
+	}
+	if out == nil {
+		return obj
+	}
+	return out
+}
+
+// encodeAST replaces the AST with its JSON representation encoded with a given encoding.
+// It allows native drivers to compress large trees.
+func (r *parseResponse) encodeAST(enc Encoding) error {
+	if r.AST == nil {
+		return nil
+	}
+	data, err := json.Marshal(r.AST)
 	if err != nil {
 		return err
 	}
-	*r = parseResponse{
-		Status: resp.Status,
-		Errors: resp.Errors,
-		AST:    ast,
+	str, err := enc.Encode(string(data))
+	if err != nil {
+		return err
 	}
+	r.AST, r.Encoding = nodes.String(str), enc
 	return nil
 }
 
+// decodeAST restores the AST encoded by encodeAST.
+func (r *parseResponse) decodeAST() error {
+	if r.Encoding == "" || r.AST == nil {
+		r.Encoding = ""
+		return nil
+	}
+	str, ok := r.AST.(nodes.String)
+	if !ok {
+		return fmt.Errorf("expected an encoded AST, got: %T", r.AST)
+	}
+	data, err := r.Encoding.decode(string(str), r.max)
+	if err != nil {
+		return err
+	}
+	r.AST, err = jsonlines.ParseNode([]byte(data))
+	r.Encoding = ""
+	return err
+}
+
 func (d *Driver) writeRequest(ctx context.Context, req *parseRequest) error {
 	sp, _ := opentracing.StartSpanFromContext(ctx, "bblfsh.native.Parse.encodeReq")
 	defer sp.Finish()
@@ -162,10 +380,14 @@ type timeoutError interface {
 	Timeout() bool
 }
 
-func (d *Driver) broken(err error) {
+// broken stops the native process after a failure. It returns the error with the last lines of stderr attached.
+func (d *Driver) broken(err error) error {
 	d.state = stateBroken
+	d.restartAt = time.Now().Add(d.restart.delay(d.crashes))
+	_ = d.stop()
+	err = d.withStderr(err)
 	d.lastErr = err
-	_ = d.Close()
+	return err
 }
 
 func (d *Driver) skipResponse(ctx context.Context) error {
@@ -175,15 +397,22 @@ func (d *Driver) skipResponse(ctx context.Context) error {
 	sp, _ := opentracing.StartSpanFromContext(ctx, "bblfsh.native.Parse.skipResp")
 	defer sp.Finish()
 
-	// TODO(dennwc): relies on JSON; should probably be a method on an interface
-	var r json.RawMessage
-	err := d.dec.Decode(&r)
-	if e, ok := err.(timeoutError); ok && e.Timeout() {
-		d.state = stateTimeout
-		return err
-	} else if err != nil {
-		d.broken(err)
-		return err
+	if d.pending < 1 {
+		d.pending = 1
+	}
+	for d.pending > 0 {
+		var r parseResponse
+		err := d.dec.Decode(&r)
+		if e, ok := err.(timeoutError); ok && e.Timeout() {
+			d.state = stateTimeout
+			return err
+		} else if err != nil && !tooLarge(err) {
+			return d.broken(err)
+		} else if err == nil && r.Status == statusChunk {
+			// skip the rest of the stream
+			continue
+		}
+		d.pending--
 	}
 	d.state = stateOK
 	return nil
@@ -200,36 +429,179 @@ func (d *Driver) readResponse(ctx context.Context) (*parseResponse, error) {
 		// so next time we will need to discard the first response
 		d.state = stateTimeout
 		return nil, err
+	} else if tooLarge(err) {
+		// the response was consumed, so the stream is still in sync
+		return nil, ErrResponseTooLarge.New(d.maxResp)
 	} else if err != nil {
 		// we can't be sure what happened, so let's not mess with
 		// the client; we will stop the driver now
-		d.broken(err)
-		return nil, err
+		if lerr := d.limitErr(); lerr != nil {
+			err = lerr
+		}
+		return nil, d.broken(err)
 	}
 	return &r, nil
 }
 
 // Parse sends a request to the native driver and returns its response.
+//
+// If the native driver streams the AST, all parts are assembled into a single tree.
 func (d *Driver) Parse(rctx context.Context, src string) (nodes.Node, error) {
 	sp, ctx := opentracing.StartSpanFromContext(rctx, "bblfsh.native.Parse")
 	defer sp.Finish()
 
-	if !d.running {
-		return nil, driver.ErrDriverFailure.Wrap(ErrNotRunning.New())
+	if err := d.reqs.begin(); err != nil {
+		return nil, driver.ErrDriverFailure.Wrap(err)
+	}
+	defer d.reqs.end()
+	return d.parse(ctx, src, parseRequest{}, nil)
+}
+
+// ParseReader implements driver.ReaderNative. The source is encoded while it is read, thus only the encoded copy
+// is kept in memory.
+func (d *Driver) ParseReader(rctx context.Context, r io.Reader, size int) (nodes.Node, error) {
+	sp, ctx := opentracing.StartSpanFromContext(rctx, "bblfsh.native.Parse")
+	defer sp.Finish()
+
+	if err := d.reqs.begin(); err != nil {
+		return nil, driver.ErrDriverFailure.Wrap(err)
+	}
+	defer d.reqs.end()
+	start := time.Now()
+	str, err := d.ec.EncodeReader(r, size)
+	if err != nil {
+		return nil, driver.ErrDriverFailure.Wrap(err)
+	}
+	return d.parseEncoded(ctx, start, str, parseRequest{}, nil)
+}
+
+// ParseWithOptions implements driver.OptionsNative. If the native driver does not declare CapOptions,
+// the options are not sent and empty options are returned.
+func (d *Driver) ParseWithOptions(rctx context.Context, src string, opts driver.LanguageOptions) (nodes.Node, driver.LanguageOptions, error) {
+	sp, ctx := opentracing.StartSpanFromContext(rctx, "bblfsh.native.Parse")
+	defer sp.Finish()
+
+	if err := d.reqs.begin(); err != nil {
+		return nil, driver.LanguageOptions{}, driver.ErrDriverFailure.Wrap(err)
 	}
+	defer d.reqs.end()
+	if p := d.Protocol(); !p.Has(CapOptions) {
+		ast, err := d.parse(ctx, src, parseRequest{}, nil)
+		return ast, driver.LanguageOptions{}, err
+	}
+	ast, err := d.parse(ctx, src, parseRequest{Options: &opts}, nil)
+	return ast, opts, err
+}
+
+// ParseTokens implements driver.TokensNative. If the native driver does not declare CapTokens,
+// driver.ErrModeNotSupported is returned.
+func (d *Driver) ParseTokens(rctx context.Context, src string) (nodes.Array, error) {
+	sp, ctx := opentracing.StartSpanFromContext(rctx, "bblfsh.native.ParseTokens")
+	defer sp.Finish()
 
+	if p := d.Protocol(); !p.Has(CapTokens) {
+		return nil, driver.ErrModeNotSupported.New()
+	}
+	if err := d.reqs.begin(); err != nil {
+		return nil, driver.ErrDriverFailure.Wrap(err)
+	}
+	defer d.reqs.end()
+	ast, err := d.parse(ctx, src, parseRequest{Tokens: true}, nil)
+	toks, ok := ast.(nodes.Array)
+	if !ok && ast != nil {
+		return nil, driver.ErrDriverFailure.Wrap(fmt.Errorf("expected an array of tokens, got: %T", ast))
+	}
+	return toks, err
+}
+
+// parse sends a request to the native driver and returns its response. The source is added to the base request.
+// If the request has options, they are updated to the ones reported by the native driver. If fnc is set, it is
+// called for each part of the streamed AST, instead of assembling the tree.
+func (d *Driver) parse(ctx context.Context, src string, base parseRequest, fnc func(n nodes.Node) error) (nodes.Node, error) {
+	start := time.Now()
 	str, err := d.ec.Encode(src)
 	if err != nil {
 		return nil, driver.ErrDriverFailure.Wrap(err)
 	}
+	return d.parseEncoded(ctx, start, str, base, fnc)
+}
 
+// parseEncoded is the same as parse, but receives the source already encoded with the encoding of the driver.
+func (d *Driver) parseEncoded(ctx context.Context, start time.Time, str string, base parseRequest, fnc func(n nodes.Node) error) (_ nodes.Node, err error) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
-	if d.state != stateOK && d.state != stateTimeout {
-		return nil, driver.ErrDriverFailure.Wrap(d.lastErr)
+	if d.metrics != nil {
+		done := d.observe(ctx, start)
+		defer func() {
+			done(err)
+		}()
+	}
+	if err = d.ensureRunning(); err != nil {
+		return nil, err
 	}
 
+	base.Content, base.Encoding = str, d.ec
+	req := newRequest(ctx, base)
+	var r *parseResponse
+	if d.mux != nil {
+		r, err = d.muxTrip(ctx, req, fnc, true)
+	} else {
+		r, err = d.roundTrip(ctx, req, fnc)
+	}
+	if err != nil {
+		return nil, driver.ErrDriverFailure.Wrap(err)
+	}
+	d.crashes = 0
+	if base.Options != nil && r.Options != nil {
+		*base.Options = *r.Options
+	}
+	return r.result()
+}
+
+// ensureRunning restarts the native process if necessary and checks that it is running. It must be called with the mutex held.
+func (d *Driver) ensureRunning() error {
+	if d.mux != nil && d.isRunning() && d.state == stateOK {
+		if err := d.mux.failed(); err != nil {
+			// the native process crashed while idle
+			_ = d.broken(err)
+		}
+	}
+	switch d.state {
+	case stateBroken:
+		if err := d.recover(); err != nil {
+			return err
+		}
+	case stateKilled:
+		d.restarted()
+		if err := d.Start(); err != nil {
+			return driver.ErrDriverFailure.Wrap(d.broken(err))
+		}
+	}
+	if !d.isRunning() {
+		return driver.ErrDriverFailure.Wrap(ErrNotRunning.New())
+	}
+	return nil
+}
+
+// roundTrip sends an encoded source to the native driver and reads the response. It must be called with the mutex held.
+// See readStream for the description of fnc.
+func (d *Driver) roundTrip(ctx context.Context, req *parseRequest, fnc func(n nodes.Node) error) (*parseResponse, error) {
+	if d.mux != nil {
+		return d.muxTrip(ctx, req, fnc, false)
+	}
+	var r *parseResponse
+	err := d.exchange(ctx, req, func() error {
+		var err error
+		r, err = d.readStream(ctx, fnc)
+		return err
+	})
+	return r, err
+}
+
+// exchange sends the request to the native driver and calls read to read the responses. It must be called with
+// the mutex held.
+func (d *Driver) exchange(ctx context.Context, req *parseRequest, read func() error) error {
 	if deadline, ok := ctx.Deadline(); ok {
 		_ = d.stdout.SetReadDeadline(deadline)
 		_ = d.stdin.SetWriteDeadline(deadline)
@@ -238,42 +610,55 @@ func (d *Driver) Parse(rctx context.Context, src string) (nodes.Node, error) {
 			_ = d.stdout.SetReadDeadline(time.Time{})
 		}()
 	}
+	defer d.watchCancel(ctx)()
 
 	if d.state == stateTimeout {
-		if err = d.skipResponse(ctx); err != nil {
-			return nil, driver.ErrDriverFailure.Wrap(err)
+		if err := d.skipResponse(ctx); err != nil {
+			return err
 		}
 	}
 
-	err = d.writeRequest(ctx, &parseRequest{
-		Content: str, Encoding: d.ec,
-	})
+	err := d.writeRequest(ctx, req)
 	if err != nil {
 		// Cannot write data - this means the stream is broken or driver crashed.
 		// We will try to recover by reading the response, but since it might be
 		// a stack trace or an error message, we will read it as a "raw" value.
 		// This preserves an original text instead of failing with decoding error.
-		var raw json.RawMessage
 		// TODO: this reads a single line only; we can be smarter and read the whole log if driver cannot recover
-		if err := d.dec.Decode(&raw); err != nil {
+		raw, err2 := d.dec.Skip()
+		if err2 != nil {
 			// stream is broken on both sides, cannot get additional info
-			return nil, driver.ErrDriverFailure.Wrap(err)
+			return d.broken(err2)
 		}
-		return nil, driver.ErrDriverFailure.Wrap(fmt.Errorf("error: %v; %s", err, string(raw)))
+		err = fmt.Errorf("error: %v; %s", err, raw)
+		return d.broken(err)
 	}
 
-	r, err := d.readResponse(ctx)
-	if err != nil {
-		return nil, driver.ErrDriverFailure.Wrap(err)
+	if err = read(); err != nil && d.state != stateBroken && d.restart.allowed(d.crashes) && driver.TimedOut(ctx, driver.StageNative) {
+		// the process may still be busy with the request; restart it
+		err = d.broken(err)
+	} else if err != nil && expired(ctx) {
+		d.cancelled()
 	}
+	return err
+}
+
+// result converts the response to the AST and the error returned by Parse.
+func (r *parseResponse) result() (nodes.Node, error) {
 	if r.Status == statusOK {
 		return r.AST, nil
 	}
 	errs := make([]error, 0, len(r.Errors))
-	for _, s := range r.Errors {
-		errs = append(errs, errors.New(s))
+	if len(r.Diagnostics) != 0 {
+		for _, d := range r.Diagnostics {
+			errs = append(errs, d)
+		}
+	} else {
+		for _, s := range r.Errors {
+			errs = append(errs, errors.New(s))
+		}
 	}
-	err = derrors.Join(errs)
+	err := derrors.Join(errs)
 	switch r.Status {
 	case statusError:
 		// parsing error, wrapping will be done on a higher level
@@ -286,20 +671,52 @@ func (d *Driver) Parse(rctx context.Context, src string) (nodes.Node, error) {
 	return r.AST, err
 }
 
-// Stop stops the execution of the native driver.
+// Close stops the execution of the native driver. If Options.DrainTimeout is set, it waits for in-flight requests
+// to finish first.
 func (d *Driver) Close() error {
-	if !d.running {
+	defer d.reqs.reopen()
+	if t := d.opts.DrainTimeout; t > 0 {
+		// requests still in progress after the timeout fail
+		_ = d.reqs.drain(t)
+	}
+	return d.stop()
+}
+
+// isRunning checks if the native process is running. It can be called without the mutex.
+func (d *Driver) isRunning() bool {
+	return atomic.LoadInt32(&d.running) != 0
+}
+
+func (d *Driver) setRunning(v bool) {
+	var n int32
+	if v {
+		n = 1
+	}
+	atomic.StoreInt32(&d.running, n)
+}
+
+// stop stops the execution of the native driver immediately. Requests in progress fail.
+// It can be called concurrently, for example by Close and by a failed Ping; only the first call stops the process.
+func (d *Driver) stop() error {
+	d.stopMu.Lock()
+	defer d.stopMu.Unlock()
+	if !d.isRunning() {
 		return nil
 	}
 	// note: it should not hold the mutex, or readResponse will deadlock
+	d.stopWatchdog()
+	d.stopPinger()
 	var last error
 	if err := d.stdin.Close(); err != nil {
 		last = err
 	}
-	errc := make(chan error, 1)
-	go func() {
-		errc <- d.cmd.Wait()
-	}()
+	errc := d.exited
+	if errc == nil {
+		errc = make(chan error, 1)
+		go func() {
+			errc <- d.cmd.Wait()
+		}()
+	}
 	timeout := time.NewTimer(closeTimeout)
 	select {
 	case err := <-errc:
@@ -311,7 +728,11 @@ func (d *Driver) Close() error {
 		d.cmd.Process.Kill()
 	}
 	err2 := d.stdout.Close()
-	d.running = false
+	d.setRunning(false)
+	if d.socket != "" {
+		// the process may have been killed without removing the socket
+		_ = os.Remove(d.socket)
+	}
 	if last != nil {
 		return last
 	}
@@ -344,18 +765,23 @@ const (
 	statusError = status("error")
 	// statusFatal is replied when the driver hasn't could get the AST.
 	statusFatal = status("fatal")
+	// statusChunk is replied for each part of the AST streamed by the driver. See StreamingNative.
+	statusChunk = status("chunk")
 )
 
 var _ json.Unmarshaler = (*Encoding)(nil)
 
 // Encoding is the Encoding used for the content string. Currently only
-// UTF-8 or Base64 encodings are supported. You should use UTF-8 if you can
-// and Base64 as a fallback.
+// UTF-8, Base64 or Gzip encodings are supported. You should use UTF-8 if you can
+// and Base64 as a fallback. Gzip is useful for large files.
 type Encoding string
 
 const (
 	UTF8   = Encoding("utf8")
 	Base64 = Encoding("base64")
+	// Gzip compresses the content with gzip and encodes it with Base64. If the request uses this encoding,
+	// the native driver may encode the AST in the response the same way.
+	Gzip = Encoding("gzip")
 )
 
 func (e *Encoding) UnmarshalJSON(data []byte) error {
@@ -370,12 +796,42 @@ func (e *Encoding) UnmarshalJSON(data []byte) error {
 
 // Encode converts UTF8 string into specified Encoding.
 func (e Encoding) Encode(s string) (string, error) {
+	if e == UTF8 {
+		return s, nil
+	}
+	// hide WriteTo of the reader, since it converts the whole string to bytes
+	return e.EncodeReader(struct{ io.Reader }{strings.NewReader(s)}, len(s))
+}
+
+// EncodeReader is similar to Encode, but reads UTF8 content from r. The size is a hint for the length of the
+// content in bytes. The content is encoded while it is read, without keeping a copy of it.
+func (e Encoding) EncodeReader(r io.Reader, size int) (string, error) {
+	var buf strings.Builder
 	switch e {
 	case UTF8:
-		return s, nil
+		return driver.ReadSource(r, size)
 	case Base64:
-		s = base64.StdEncoding.EncodeToString([]byte(s))
-		return s, nil
+		if size > 0 {
+			buf.Grow(base64.StdEncoding.EncodedLen(size))
+		}
+		w := base64.NewEncoder(base64.StdEncoding, &buf)
+		if _, err := io.Copy(w, r); err != nil {
+			return "", err
+		} else if err = w.Close(); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	case Gzip:
+		w := base64.NewEncoder(base64.StdEncoding, &buf)
+		zw := gzip.NewWriter(w)
+		if _, err := io.Copy(zw, r); err != nil {
+			return "", err
+		} else if err = zw.Close(); err != nil {
+			return "", err
+		} else if err = w.Close(); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
 	default:
 		return "", fmt.Errorf("invalid Encoding: %v", e)
 	}
@@ -383,6 +839,12 @@ func (e Encoding) Encode(s string) (string, error) {
 
 // Decode converts specified Encoding into UTF8.
 func (e Encoding) Decode(s string) (string, error) {
+	return e.decode(s, 0)
+}
+
+// decode is similar to Decode, but fails with ErrResponseTooLarge if the decompressed content exceeds max bytes.
+// Zero means no limit.
+func (e Encoding) decode(s string, max int) (string, error) {
 	switch e {
 	case UTF8:
 		return s, nil
@@ -392,6 +854,27 @@ func (e Encoding) Decode(s string) (string, error) {
 			return "", err
 		}
 		return string(b), nil
+	case Gzip:
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return "", err
+		}
+		zr, err := gzip.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return "", err
+		}
+		defer zr.Close()
+		var r io.Reader = zr
+		if max > 0 {
+			r = io.LimitReader(zr, int64(max)+1)
+		}
+		b, err = ioutil.ReadAll(r)
+		if err != nil {
+			return "", err
+		} else if max > 0 && len(b) > max {
+			return "", ErrResponseTooLarge.New(max)
+		}
+		return string(b), nil
 	default:
 		return "", fmt.Errorf("invalid Encoding: %v", e)
 	}
@@ -1,8 +1,13 @@
 package native
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -11,7 +16,10 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"gopkg.in/bblfsh/sdk.v2/driver"
 	derrors "gopkg.in/bblfsh/sdk.v2/driver/errors"
+	"gopkg.in/bblfsh/sdk.v2/driver/manifest"
+	"gopkg.in/bblfsh/sdk.v2/uast"
 	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
 )
 
@@ -70,6 +78,766 @@ func TestNativeDriverNativeParse(t *testing.T) {
 	require.NoError(err)
 }
 
+func TestEncodingGzip(t *testing.T) {
+	require := require.New(t)
+
+	src := strings.Repeat("test message\n", 100)
+	out, err := Gzip.Encode(src)
+	require.NoError(err)
+	require.True(len(out) < len(src))
+
+	got, err := Gzip.Decode(out)
+	require.NoError(err)
+	require.Equal(src, got)
+}
+
+func TestEncodingGzipLimit(t *testing.T) {
+	require := require.New(t)
+
+	src := strings.Repeat("a", 1<<20)
+	out, err := Gzip.Encode(src)
+	require.NoError(err)
+	require.True(len(out) < 1<<12)
+
+	got, err := Gzip.decode(out, len(src))
+	require.NoError(err)
+	require.Equal(src, got)
+
+	_, err = Gzip.decode(out, 1<<12)
+	require.True(ErrResponseTooLarge.Is(err), "%v", err)
+
+	// the limit applies to the AST in a decoded response as well
+	var buf bytes.Buffer
+	r := &parseResponse{Status: statusOK, AST: nodes.String(src)}
+	require.NoError(r.encodeAST(Gzip))
+	require.NoError(JSONLines.newEncoder(&buf).Encode(r))
+
+	var got2 parseResponse
+	err = JSONLines.newDecoder(&buf, 1<<12).Decode(&got2)
+	require.True(tooLarge(err), "%v", err)
+}
+
+func TestNativeDriverNativeParse_Gzip(t *testing.T) {
+	for _, f := range []Format{JSONLines, MsgPack} {
+		f := f
+		t.Run(string(f), func(t *testing.T) {
+			require := require.New(t)
+
+			d := NewDriverAt("internal/simple/mock", Gzip).(*Driver)
+			err := d.SetFormat(f)
+			require.NoError(err)
+			err = d.Start()
+			require.NoError(err)
+
+			r, err := d.Parse(context.Background(), "foo\nbar")
+			require.NoError(err)
+			require.Equal(mockResponse("foo\nbar"), r)
+
+			err = d.Close()
+			require.NoError(err)
+		})
+	}
+}
+
+func TestNativeDriverParseReader(t *testing.T) {
+	for _, enc := range []Encoding{UTF8, Base64, Gzip} {
+		enc := enc
+		t.Run(string(enc), func(t *testing.T) {
+			require := require.New(t)
+
+			d := NewDriverAt("internal/simple/mock", enc).(*Driver)
+			err := d.Start()
+			require.NoError(err)
+			defer d.Close()
+
+			src := strings.Repeat("foo\n", 100)
+			// the size hint may be wrong
+			for _, size := range []int{len(src), 0, 10} {
+				r, err := d.ParseReader(context.Background(), strings.NewReader(src), size)
+				require.NoError(err)
+				require.Equal(mockResponse(src), r)
+			}
+
+			exp, err := enc.Encode(src)
+			require.NoError(err)
+			got, err := enc.EncodeReader(strings.NewReader(src), len(src))
+			require.NoError(err)
+			require.Equal(exp, got)
+		})
+	}
+}
+
+func TestNativeDriverNativeParse_Formats(t *testing.T) {
+	for _, f := range []Format{MsgPack, FramedJSON} {
+		f := f
+		t.Run(string(f), func(t *testing.T) {
+			require := require.New(t)
+
+			d := NewDriverAt("internal/simple/mock", "").(*Driver)
+			err := d.SetFormat(f)
+			require.NoError(err)
+			err = d.Start()
+			require.NoError(err)
+
+			r, err := d.Parse(context.Background(), "foo\nbar")
+			require.NoError(err)
+			require.Equal(mockResponse("foo\nbar"), r)
+
+			err = d.Close()
+			require.NoError(err)
+		})
+	}
+}
+
+func TestParseRequestDeadline(t *testing.T) {
+	deadline := time.Date(2019, 2, 20, 10, 30, 15, 123456789, time.UTC)
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	req := newRequest(ctx, parseRequest{Content: "foo", Encoding: UTF8})
+
+	for _, f := range []Format{JSONLines, MsgPack, FramedJSON} {
+		f := f
+		t.Run(string(f), func(t *testing.T) {
+			require := require.New(t)
+
+			var buf bytes.Buffer
+			err := f.newEncoder(&buf).Encode(req)
+			require.NoError(err)
+
+			var got parseRequest
+			err = f.newDecoder(&buf, 0).Decode(&got)
+			require.NoError(err)
+			require.Equal(req.Content, got.Content)
+			require.NotNil(got.Deadline)
+			require.True(deadline.Equal(*got.Deadline), "%v", got.Deadline)
+		})
+	}
+}
+
+func TestNativeDriverNativeParse_MaxResponseSize(t *testing.T) {
+	for _, f := range []Format{JSONLines, MsgPack, FramedJSON} {
+		f := f
+		t.Run(string(f), func(t *testing.T) {
+			require := require.New(t)
+
+			d := NewDriverAt("internal/simple/mock", "").(*Driver)
+			err := d.SetFormat(f)
+			require.NoError(err)
+			d.SetMaxResponseSize(1024)
+			err = d.Start()
+			require.NoError(err)
+
+			_, err = d.Parse(context.Background(), strings.Repeat("x", 2048))
+			require.True(derrors.ErrDriverFailure.Is(err))
+			require.True(ErrResponseTooLarge.Is(err), "%v", err)
+
+			r, err := d.Parse(context.Background(), "foo")
+			require.NoError(err)
+			require.Equal(mockResponse("foo"), r)
+
+			err = d.Close()
+			require.NoError(err)
+		})
+	}
+}
+
+func TestNativeDriverParseStream(t *testing.T) {
+	lines := func(lines ...string) nodes.Array {
+		arr := nodes.Array{}
+		for _, l := range lines {
+			arr = append(arr, nodes.Object{"line": nodes.String(l)})
+		}
+		return arr
+	}
+	for _, f := range []Format{JSONLines, MsgPack} {
+		f := f
+		t.Run(string(f), func(t *testing.T) {
+			require := require.New(t)
+
+			d := NewDriverAt("internal/stream/mock", Gzip).(*Driver)
+			err := d.SetFormat(f)
+			require.NoError(err)
+			err = d.Start()
+			require.NoError(err)
+
+			r, err := d.Parse(context.Background(), "foo\nbar")
+			require.NoError(err)
+			require.Equal(nodes.Object{
+				"type":  nodes.String("file"),
+				"lines": lines("foo", "bar"),
+			}, r)
+
+			var parts nodes.Array
+			r, err = d.ParseStream(context.Background(), "a\nb\nc", func(n nodes.Node) error {
+				parts = append(parts, n)
+				return nil
+			})
+			require.NoError(err)
+			require.Equal(nodes.Object{"type": nodes.String("file")}, r)
+			require.Equal(lines("a", "b", "c"), parts)
+
+			errStop := errors.NewKind("stop")
+			_, err = d.ParseStream(context.Background(), "a\nb\nc", func(n nodes.Node) error {
+				return errStop.New()
+			})
+			require.True(errStop.Is(err), "%v", err)
+
+			// the rest of the stream must be skipped
+			r, err = d.Parse(context.Background(), "x")
+			require.NoError(err)
+			require.Equal(nodes.Object{
+				"type":  nodes.String("file"),
+				"lines": lines("x"),
+			}, r)
+
+			err = d.Close()
+			require.NoError(err)
+		})
+	}
+}
+
+func TestNativeDriverNativeParse_Socket(t *testing.T) {
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "native-")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	d := NewDriverAt("internal/simple/mock", "").(*Driver)
+	d.SetSocket(filepath.Join(dir, "native.sock"))
+	err = d.Start()
+	require.NoError(err)
+
+	for _, src := range []string{"foo", "bar"} {
+		r, err := d.Parse(context.Background(), src)
+		require.NoError(err)
+		require.Equal(mockResponse(src), r)
+	}
+
+	err = d.Close()
+	require.NoError(err)
+	_, err = os.Stat(filepath.Join(dir, "native.sock"))
+	require.True(os.IsNotExist(err), "%v", err)
+}
+
+func TestNativeDriverClose_KilledSocket(t *testing.T) {
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "native-")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	sock := filepath.Join(dir, "native.sock")
+	d := NewDriverAt("internal/simple/mock", "").(*Driver)
+	d.SetSocket(sock)
+	err = d.Start()
+	require.NoError(err)
+
+	// the process exits with an error and leaves the socket behind
+	err = d.cmd.Process.Kill()
+	require.NoError(err)
+	err = ioutil.WriteFile(sock, nil, 0600)
+	require.NoError(err)
+
+	err = d.Close()
+	require.Error(err)
+	_, err = os.Stat(sock)
+	require.True(os.IsNotExist(err), "%v", err)
+}
+
+func TestGRPCDriverParse(t *testing.T) {
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "native-")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	d := NewGRPCDriverAt("internal/simple/mock", filepath.Join(dir, "native.sock"))
+	err = d.Start()
+	require.NoError(err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			src := fmt.Sprintf("foo_%d", i)
+			r, err := d.Parse(context.Background(), src)
+			require.NoError(err)
+			require.Equal(mockResponse(src), r)
+		}(i)
+	}
+	wg.Wait()
+
+	err = d.Close()
+	require.NoError(err)
+}
+
+func TestNativeDriverSetFormat_Unsupported(t *testing.T) {
+	d := NewDriverAt("internal/simple/mock", "").(*Driver)
+	err := d.SetFormat("xml")
+	require.True(t, ErrUnsupportedFormat.Is(err))
+}
+
+func TestNativeDriverWarmUp(t *testing.T) {
+	require := require.New(t)
+
+	d := NewDriverAt("internal/simple/mock", "").(*Driver)
+	d.SetWarmUp("foo", "bar")
+	err := d.Start()
+	require.NoError(err)
+
+	r, err := d.Parse(context.Background(), "baz")
+	require.NoError(err)
+	require.Equal(mockResponse("baz"), r)
+
+	err = d.Close()
+	require.NoError(err)
+}
+
+func TestNativeDriverWarmUp_Broken(t *testing.T) {
+	d := NewDriverAt("echo", "").(*Driver)
+	d.SetWarmUp("foo")
+	err := d.Start()
+	require.True(t, ErrWarmUp.Is(err), "%v", err)
+}
+
+func TestNativeDriverPing(t *testing.T) {
+	require := require.New(t)
+
+	d := NewDriverAt("internal/simple/mock", "").(*Driver)
+	err := d.Ping(context.Background())
+	require.True(derrors.ErrDriverFailure.Is(err))
+
+	err = d.Start()
+	require.NoError(err)
+
+	err = d.Ping(context.Background())
+	require.NoError(err)
+
+	r, err := d.Parse(context.Background(), "foo")
+	require.NoError(err)
+	require.Equal(mockResponse("foo"), r)
+
+	err = d.Close()
+	require.NoError(err)
+}
+
+func TestNativeDriverPing_Hung(t *testing.T) {
+	require := require.New(t)
+
+	d := NewDriverAt("internal/slow/mock", "").(*Driver)
+	err := d.Start()
+	require.NoError(err)
+	defer d.Close()
+
+	// the slow mock does not reply until the driver is started
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	err = d.Ping(ctx)
+	require.True(derrors.ErrDriverFailure.Is(err))
+	require.False(d.healthy())
+}
+
+func TestNativeDriverClose_FailingPing(t *testing.T) {
+	require := require.New(t)
+
+	for i := 0; i < 5; i++ {
+		d := NewDriverAt("internal/slow/mock", "").(*Driver)
+		d.SetPingInterval(time.Hour)
+		err := d.Start()
+		require.NoError(err)
+
+		// Close races with the failed ping that stops the driver; the pinger must be stopped only once
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		done := make(chan error, 1)
+		go func() {
+			done <- d.Ping(ctx)
+		}()
+		time.Sleep(50 * time.Millisecond)
+		_ = d.Close()
+		cancel()
+
+		err = <-done
+		require.True(derrors.ErrDriverFailure.Is(err), "%v", err)
+		require.False(d.isRunning())
+	}
+}
+
+func TestNativeDriverOptions(t *testing.T) {
+	require := require.New(t)
+
+	mock, err := filepath.Abs("internal/simple/mock")
+	require.NoError(err)
+	dir, err := ioutil.TempDir("", "native-")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+	dir, err = filepath.EvalSymlinks(dir)
+	require.NoError(err)
+
+	bin := filepath.Join(dir, "native")
+	err = ioutil.WriteFile(bin, []byte("#!/bin/sh\n"+
+		"echo \"$1 $NATIVE_OPT $(pwd)\" >&2\n"+
+		"exec "+mock+"\n"), 0755)
+	require.NoError(err)
+
+	d := NewDriverWithOptions(Options{
+		Binary:       bin,
+		Args:         []string{"-flag"},
+		Env:          []string{"NATIVE_OPT=value"},
+		Dir:          dir,
+		StartTimeout: time.Minute,
+	}).(*Driver)
+	err = d.Start()
+	require.NoError(err)
+
+	r, err := d.Parse(context.Background(), "foo")
+	require.NoError(err)
+	require.Equal(mockResponse("foo"), r)
+
+	err = d.Close()
+	require.NoError(err)
+	require.Equal("-flag value "+dir, d.stderr.Tail())
+}
+
+func TestNativeDriverOptions_StartTimeout(t *testing.T) {
+	require := require.New(t)
+
+	d := NewDriverWithOptions(Options{
+		Binary:       "internal/slow/mock",
+		StartTimeout: time.Second,
+	})
+	err := d.Start()
+	require.True(ErrStartTimeout.Is(err), "%v", err)
+
+	_, err = d.Parse(context.Background(), "foo")
+	require.True(derrors.ErrDriverFailure.Is(err))
+}
+
+func TestNativeDriverHandshake(t *testing.T) {
+	for _, f := range []Format{JSONLines, MsgPack} {
+		f := f
+		t.Run(string(f), func(t *testing.T) {
+			require := require.New(t)
+
+			d := NewDriverAt("internal/stream/mock", Gzip).(*Driver)
+			err := d.SetFormat(f)
+			require.NoError(err)
+			d.SetHandshake(true)
+			err = d.Start()
+			require.NoError(err)
+			defer d.Close()
+
+			p := d.Protocol()
+			require.Equal(ProtocolVersion, p.Version)
+			require.True(p.Supports(Gzip))
+			require.True(p.Has(CapPing))
+			require.True(p.Has(CapStream))
+
+			_, err = d.Parse(context.Background(), "foo")
+			require.NoError(err)
+		})
+	}
+}
+
+func TestNativeDriverHandshake_Legacy(t *testing.T) {
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "native-")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+	bin := filepath.Join(dir, "native")
+	err = ioutil.WriteFile(bin, []byte("#!/bin/sh\n"+
+		"while read l; do echo '{\"status\":\"ok\",\"ast\":null}'; done\n"), 0755)
+	require.NoError(err)
+
+	d := NewDriverAt(bin, "").(*Driver)
+	d.SetHandshake(true)
+	err = d.Start()
+	require.NoError(err)
+	require.Equal(legacyProtocol, d.Protocol())
+	err = d.Close()
+	require.NoError(err)
+
+	d = NewDriverAt(bin, Gzip).(*Driver)
+	d.SetHandshake(true)
+	err = d.Start()
+	require.True(ErrIncompatible.Is(err), "%v", err)
+}
+
+func TestNativeDriverParseBatch(t *testing.T) {
+	for _, f := range []Format{JSONLines, MsgPack} {
+		f := f
+		t.Run(string(f), func(t *testing.T) {
+			require := require.New(t)
+
+			d := NewDriverAt("internal/simple/mock", "").(*Driver)
+			err := d.SetFormat(f)
+			require.NoError(err)
+			d.SetHandshake(true)
+			d.SetMaxResponseSize(1024)
+			err = d.Start()
+			require.NoError(err)
+			defer d.Close()
+			require.True(d.Protocol().Has(CapBatch))
+
+			big := strings.Repeat("x", 2048)
+			out, err := d.ParseBatch(context.Background(), []string{"foo", big, "bar"})
+			require.NoError(err)
+			require.Len(out, 3)
+
+			require.NoError(out[0].Err)
+			require.Equal(mockResponse("foo"), out[0].AST)
+			require.True(ErrResponseTooLarge.Is(out[1].Err), "%v", out[1].Err)
+			require.NoError(out[2].Err)
+			require.Equal(mockResponse("bar"), out[2].AST)
+
+			// the stream is still in sync
+			ast, err := d.Parse(context.Background(), "baz")
+			require.NoError(err)
+			require.Equal(mockResponse("baz"), ast)
+		})
+	}
+}
+
+func TestNativeDriverParseBatch_Fallback(t *testing.T) {
+	require := require.New(t)
+
+	d := NewDriverAt("internal/simple/mock", "")
+	err := d.Start()
+	require.NoError(err)
+	defer d.Close()
+
+	out, err := d.(*Driver).ParseBatch(context.Background(), []string{"foo", "bar"})
+	require.NoError(err)
+	require.Equal([]BatchResult{
+		{AST: mockResponse("foo")},
+		{AST: mockResponse("bar")},
+	}, out)
+}
+
+func TestNativeDriverParseWithOptions(t *testing.T) {
+	for _, f := range []Format{JSONLines, MsgPack} {
+		f := f
+		t.Run(string(f), func(t *testing.T) {
+			require := require.New(t)
+
+			d := NewDriverAt("internal/simple/mock", "").(*Driver)
+			err := d.SetFormat(f)
+			require.NoError(err)
+			d.SetHandshake(true)
+			err = d.Start()
+			require.NoError(err)
+			defer d.Close()
+			require.True(d.Protocol().Has(CapOptions))
+
+			ast, opts, err := d.ParseWithOptions(context.Background(), "foo", driver.LanguageOptions{
+				Dialects: []string{"jsx"}, Strict: true,
+			})
+			require.NoError(err)
+			require.Equal(mockResponse("foo"), ast)
+			require.Equal(driver.LanguageOptions{Version: "1", Dialects: []string{"jsx"}, Strict: true}, opts)
+		})
+	}
+}
+
+func TestNativeDriverParseWithOptions_Unsupported(t *testing.T) {
+	require := require.New(t)
+
+	d := NewDriverAt("internal/stream/mock", "").(*Driver)
+	d.SetHandshake(true)
+	err := d.Start()
+	require.NoError(err)
+	defer d.Close()
+	require.False(d.Protocol().Has(CapOptions))
+
+	_, opts, err := d.ParseWithOptions(context.Background(), "foo", driver.LanguageOptions{Version: "2"})
+	require.NoError(err)
+	require.True(opts.IsZero())
+}
+
+func TestNativeDriverParseDiagnostics(t *testing.T) {
+	for _, f := range []Format{JSONLines, MsgPack} {
+		f := f
+		t.Run(string(f), func(t *testing.T) {
+			require := require.New(t)
+
+			d := NewDriverAt("internal/simple/mock", "").(*Driver)
+			err := d.SetFormat(f)
+			require.NoError(err)
+			err = d.Start()
+			require.NoError(err)
+			defer d.Close()
+
+			ast, err := d.Parse(context.Background(), "foo!")
+			require.NotNil(ast)
+			require.Equal([]*derrors.Diagnostic{{
+				Message: "unexpected '!'", Severity: derrors.SeverityWarning, Code: "E1",
+				Start: &uast.Position{Offset: 3, Line: 1, Col: 4},
+			}}, derrors.Diagnostics(err))
+		})
+	}
+}
+
+func TestNativeDriverParseTokens(t *testing.T) {
+	for _, f := range []Format{JSONLines, MsgPack} {
+		f := f
+		t.Run(string(f), func(t *testing.T) {
+			require := require.New(t)
+
+			d := NewDriverAt("internal/simple/mock", "").(*Driver)
+			err := d.SetFormat(f)
+			require.NoError(err)
+			d.SetHandshake(true)
+			err = d.Start()
+			require.NoError(err)
+			defer d.Close()
+			require.True(d.Protocol().Has(CapTokens))
+
+			toks, err := d.ParseTokens(context.Background(), "foo  bar")
+			require.NoError(err)
+			require.Len(toks, 2)
+			require.Equal("bar", uast.TokenOf(toks[1]))
+			require.Equal(uint32(5), uast.PositionsOf(toks[1]).Start().Offset)
+		})
+	}
+}
+
+func TestNativeDriverParseTokens_Unsupported(t *testing.T) {
+	require := require.New(t)
+
+	d := NewDriverAt("internal/stream/mock", "").(*Driver)
+	d.SetHandshake(true)
+	err := d.Start()
+	require.NoError(err)
+	defer d.Close()
+
+	_, err = d.ParseTokens(context.Background(), "foo")
+	require.True(driver.ErrModeNotSupported.Is(err), "%v", err)
+}
+
+func TestNativeDriverConcurrent(t *testing.T) {
+	for _, f := range []Format{JSONLines, MsgPack} {
+		f := f
+		t.Run(string(f), func(t *testing.T) {
+			require := require.New(t)
+
+			d := NewDriverAt("internal/concurrent/mock", "").(*Driver)
+			err := d.SetFormat(f)
+			require.NoError(err)
+			d.SetHandshake(true)
+			err = d.Start()
+			require.NoError(err)
+			defer d.Close()
+			require.True(d.Protocol().Has(CapConcurrent))
+
+			ctx := context.Background()
+			order := make(chan string, 2)
+			var wg sync.WaitGroup
+			for _, src := range []string{"500ms", "foo"} {
+				src := src
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					ast, err := d.Parse(ctx, src)
+					require.NoError(err)
+					require.Equal(mockResponse(src), ast)
+					order <- src
+				}()
+				time.Sleep(50 * time.Millisecond)
+			}
+			wg.Wait()
+			require.Equal("foo", <-order)
+			require.Equal("500ms", <-order)
+
+			// late response is discarded
+			tctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+			defer cancel()
+			_, err = d.Parse(tctx, "300ms")
+			require.True(derrors.ErrDriverFailure.Is(err), "%v", err)
+
+			out, err := d.ParseBatch(ctx, []string{"foo", "bar"})
+			require.NoError(err)
+			require.Equal([]BatchResult{
+				{AST: mockResponse("foo")},
+				{AST: mockResponse("bar")},
+			}, out)
+
+			time.Sleep(300 * time.Millisecond)
+			ast, err := d.Parse(ctx, "baz")
+			require.NoError(err)
+			require.Equal(mockResponse("baz"), ast)
+		})
+	}
+}
+
+func TestNativeDriverClose_Drain(t *testing.T) {
+	require := require.New(t)
+
+	d := NewDriverWithOptions(Options{
+		Binary:       "internal/concurrent/mock",
+		DrainTimeout: 5 * time.Second,
+	}).(*Driver)
+	d.SetHandshake(true)
+	err := d.Start()
+	require.NoError(err)
+
+	ctx := context.Background()
+	done := make(chan error, 1)
+	go func() {
+		ast, err := d.Parse(ctx, "500ms")
+		if err == nil && !nodes.Equal(mockResponse("500ms"), ast) {
+			err = fmt.Errorf("unexpected response: %v", ast)
+		}
+		done <- err
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	closed := make(chan error, 1)
+	go func() {
+		closed <- d.Close()
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	// new requests are rejected while draining
+	_, err = d.Parse(ctx, "foo")
+	require.True(ErrClosing.Is(err), "%v", err)
+
+	require.NoError(<-done)
+	require.NoError(<-closed)
+
+	// the driver can be started again
+	err = d.Start()
+	require.NoError(err)
+	defer d.Close()
+	ast, err := d.Parse(ctx, "foo")
+	require.NoError(err)
+	require.Equal(mockResponse("foo"), ast)
+}
+
+func TestNativeDriverParse_FieldCase(t *testing.T) {
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "native-")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+	bin := filepath.Join(dir, "native")
+	err = ioutil.WriteFile(bin, []byte("#!/bin/sh\n"+
+		"while read l; do echo '{\"Status\":\"OK\",\"AST\":{\"key\":[1,2.5,\"a\"]}}'; done\n"), 0755)
+	require.NoError(err)
+
+	d := NewDriverAt(bin, "")
+	err = d.Start()
+	require.NoError(err)
+	defer d.Close()
+
+	ast, err := d.Parse(context.Background(), "foo")
+	require.NoError(err)
+	require.Equal(nodes.Object{
+		"key": nodes.Array{nodes.Int(1), nodes.Float(2.5), nodes.String("a")},
+	}, ast)
+}
+
 func TestNativeDriverNativeParse_Lock(t *testing.T) {
 	require := require.New(t)
 
@@ -134,6 +902,153 @@ func TestNativeDriverNativeParse_Malformed(t *testing.T) {
 	require.True(derrors.ErrDriverFailure.Is(err))
 }
 
+func TestNativeDriverRestart(t *testing.T) {
+	require := require.New(t)
+
+	d := NewDriverAt("echo", "").(*Driver)
+	d.SetRestartPolicy(RestartPolicy{MaxRestarts: 2, Backoff: time.Hour})
+
+	err := d.Start()
+	require.NoError(err)
+
+	// the first crash
+	_, err = d.Parse(context.Background(), "foo")
+	require.True(derrors.ErrDriverFailure.Is(err))
+	require.False(ErrRestarting.Is(err))
+
+	// restarted immediately, but crashes again
+	_, err = d.Parse(context.Background(), "foo")
+	require.True(derrors.ErrDriverFailure.Is(err))
+	require.False(ErrRestarting.Is(err))
+	require.Equal(uint64(1), d.Restarts())
+
+	// the next restart is delayed
+	_, err = d.Parse(context.Background(), "foo")
+	require.True(derrors.ErrDriverFailure.Is(err))
+	require.True(ErrRestarting.Is(err), "%v", err)
+	require.Equal(uint64(1), d.Restarts())
+}
+
+func TestNativeDriverParse_StageTimeout(t *testing.T) {
+	require := require.New(t)
+
+	d := NewDriverAt("internal/slow/mock", "").(*Driver)
+	d.SetRestartPolicy(RestartPolicy{MaxRestarts: 1})
+	err := d.Start()
+	require.NoError(err)
+	defer d.Close()
+
+	p := driver.Transforms{}.Pipeline()
+	err = p.SetTimeout(driver.StageNative, time.Second)
+	require.NoError(err)
+	dm, err := driver.NewDriverWithPipeline(d, &manifest.Manifest{Language: "fixture"}, p)
+	require.NoError(err)
+
+	_, err = dm.Parse(context.Background(), "first", &driver.ParseOptions{Mode: driver.ModeNative})
+	require.True(driver.ErrStageTimeout.Is(err), "%v", err)
+
+	// the busy process is restarted instead of waiting for the late response
+	r, err := d.Parse(context.Background(), "second")
+	require.NoError(err)
+	require.Equal(mockResponse("second"), r)
+	require.Equal(uint64(1), d.Restarts())
+}
+
+type testMetrics struct {
+	mu       sync.Mutex
+	requests []RequestStats
+	restarts int
+}
+
+func (m *testMetrics) RequestDone(st RequestStats) {
+	m.mu.Lock()
+	m.requests = append(m.requests, st)
+	m.mu.Unlock()
+}
+
+func (m *testMetrics) Restarted() {
+	m.mu.Lock()
+	m.restarts++
+	m.mu.Unlock()
+}
+
+func TestNativeDriverMetrics(t *testing.T) {
+	require := require.New(t)
+
+	m := &testMetrics{}
+	d := NewDriverAt("internal/simple/mock", "").(*Driver)
+	d.SetMetrics(m)
+	err := d.Start()
+	require.NoError(err)
+
+	_, err = d.Parse(context.Background(), "foo")
+	require.NoError(err)
+
+	err = d.Close()
+	require.NoError(err)
+
+	require.Len(m.requests, 1)
+	st := m.requests[0]
+	require.Equal("", st.Failure)
+	require.True(st.Latency > 0)
+	require.True(st.BytesOut > int64(len("foo")))
+	require.True(st.BytesIn > int64(len("foo")))
+
+	_, err = d.Parse(context.Background(), "foo")
+	require.True(derrors.ErrDriverFailure.Is(err))
+	require.Len(m.requests, 2)
+	require.Equal(FailureUnavailable, m.requests[1].Failure)
+}
+
+func TestNativeDriverMetrics_Crash(t *testing.T) {
+	require := require.New(t)
+
+	m := &testMetrics{}
+	d := NewDriverAt("echo", "").(*Driver)
+	d.SetMetrics(m)
+	d.SetRestartPolicy(RestartPolicy{MaxRestarts: 2})
+	err := d.Start()
+	require.NoError(err)
+
+	for i := 0; i < 2; i++ {
+		_, err = d.Parse(context.Background(), "foo")
+		require.True(derrors.ErrDriverFailure.Is(err))
+	}
+	require.Equal(1, m.restarts)
+	require.Len(m.requests, 2)
+	for _, st := range m.requests {
+		require.Equal(FailureCrash, st.Failure)
+	}
+}
+
+func TestNativeDriverRestart_Limit(t *testing.T) {
+	require := require.New(t)
+
+	d := NewDriverAt("echo", "").(*Driver)
+	d.SetRestartPolicy(RestartPolicy{MaxRestarts: 1})
+
+	err := d.Start()
+	require.NoError(err)
+
+	for i := 0; i < 3; i++ {
+		_, err = d.Parse(context.Background(), "foo")
+		require.True(derrors.ErrDriverFailure.Is(err))
+		require.False(ErrRestarting.Is(err))
+	}
+	require.Equal(uint64(1), d.Restarts())
+}
+
+func TestRestartPolicyDelay(t *testing.T) {
+	p := RestartPolicy{Backoff: time.Second, MaxBackoff: 5 * time.Second}
+	var out []time.Duration
+	for i := 0; i < 5; i++ {
+		out = append(out, p.delay(i))
+	}
+	require.Equal(t, []time.Duration{
+		0, time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second,
+	}, out)
+}
+
 func TestNativeDriverParse_Timeout(t *testing.T) {
 	require := require.New(t)
 
@@ -165,3 +1080,51 @@ func TestNativeDriverParse_Timeout(t *testing.T) {
 	require.NoError(err)
 	require.Equal(mockResponse("second"), r)
 }
+
+func TestNativeDriverParse_Cancel(t *testing.T) {
+	require := require.New(t)
+
+	d := NewDriverAt("internal/slow/mock", "")
+
+	err := d.Start()
+	require.NoError(err)
+	defer d.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(time.Second)
+		cancel()
+	}()
+
+	_, err = d.Parse(ctx, "first")
+	require.True(derrors.ErrDriverFailure.Is(err))
+	require.Equal(context.Canceled, ctx.Err())
+
+	r, err := d.Parse(context.Background(), "second")
+	require.NoError(err)
+	require.Equal(mockResponse("second"), r)
+}
+
+func TestNativeDriverParse_CancelKill(t *testing.T) {
+	require := require.New(t)
+
+	d := NewDriverAt("internal/slow/mock", "").(*Driver)
+	d.SetCancelPolicy(CancelKill)
+
+	err := d.Start()
+	require.NoError(err)
+	defer d.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	_, err = d.Parse(ctx, "first")
+	require.True(derrors.ErrDriverFailure.Is(err))
+	require.True(d.healthy())
+
+	// the late response of the first request is never seen
+	r, err := d.Parse(context.Background(), "second")
+	require.NoError(err)
+	require.Equal(mockResponse("second"), r)
+	require.Equal(uint64(1), d.Restarts())
+}
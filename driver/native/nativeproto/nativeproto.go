@@ -0,0 +1,7 @@
+// Package nativeproto defines an optional gRPC contract for native drivers, as an alternative to the stdio protocol.
+//
+// Native drivers that implement the NativeDriver service must serve it on the Unix socket passed to them by
+// the Go driver. Stubs for other languages can be generated from native.proto.
+package nativeproto
+
+//go:generate protoc --proto_path=$GOPATH/src:. --gogo_out=plugins=grpc:. ./native.proto
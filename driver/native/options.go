@@ -0,0 +1,70 @@
+package native
+
+import (
+	"context"
+	"time"
+
+	"gopkg.in/bblfsh/sdk.v2/driver"
+	serrors "gopkg.in/src-d/go-errors.v1"
+)
+
+var (
+	// ErrStartTimeout is returned by Start if the native driver is not ready in time. See Options.StartTimeout.
+	ErrStartTimeout = serrors.NewKind("native driver is not ready after %v")
+)
+
+// Options configures the native driver process.
+type Options struct {
+	// Binary is a path to the native driver. Binary variable is used if it is not set.
+	Binary string
+	// Encoding of the source code sent to the native driver. UTF8 is used if it is not set.
+	Encoding Encoding
+	// Args are command line arguments passed to the native driver.
+	Args []string
+	// Env is a list of additional environment variables for the native driver, in the "key=value" form.
+	// The process inherits the environment of the current process as well.
+	Env []string
+	// Dir is a working directory of the native driver. The current directory is used if it is not set.
+	Dir string
+	// StartTimeout limits the time it takes for the native driver to start and reply to the first ping,
+	// including the warm-up. If it is not set, Start does not wait for the native driver to become ready.
+	StartTimeout time.Duration
+	// DrainTimeout is the maximal time Close waits for in-flight requests to finish before stopping the native
+	// driver. New requests fail with ErrClosing in the meantime. If it is not set, Close stops the native driver
+	// immediately and requests in progress fail.
+	DrainTimeout time.Duration
+}
+
+// NewDriverWithOptions creates a native driver with given options.
+func NewDriverWithOptions(opts Options) driver.Native {
+	d := NewDriverAt(opts.Binary, opts.Encoding).(*Driver)
+	opts.Args = append([]string{}, opts.Args...)
+	opts.Env = append([]string{}, opts.Env...)
+	d.opts = opts
+	return d
+}
+
+// startContext returns a context for the startup of the native driver. See Options.StartTimeout.
+func (d *Driver) startContext() (context.Context, func()) {
+	if d.opts.StartTimeout <= 0 {
+		return context.Background(), func() {}
+	}
+	return context.WithTimeout(context.Background(), d.opts.StartTimeout)
+}
+
+// waitReady pings the native driver to make sure it can accept requests. It does nothing if the start timeout
+// is not set. The driver is stopped if it does not reply.
+func (d *Driver) waitReady(ctx context.Context) error {
+	if d.opts.StartTimeout <= 0 {
+		return nil
+	}
+	_, err := d.roundTrip(ctx, newRequest(ctx, parseRequest{Encoding: d.ec, Ping: true}), nil)
+	if err == nil {
+		return nil
+	}
+	_ = d.stop()
+	if expired(ctx) {
+		return ErrStartTimeout.Wrap(err, d.opts.StartTimeout)
+	}
+	return err
+}
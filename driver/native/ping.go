@@ -0,0 +1,85 @@
+package native
+
+import (
+	"context"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+
+	"gopkg.in/bblfsh/sdk.v2/driver"
+)
+
+const pingTimeout = time.Second * 10
+
+// Ping checks if the native driver is responsive by sending a ping message to it. If the context has no deadline,
+// the native driver must reply in 10 seconds.
+//
+// If the native driver does not reply in time, it is considered hung and is stopped. It will be restarted by the
+// next Parse call according to the RestartPolicy. Native drivers that do not support ping messages reply to them
+// as to a parse request with empty content, which is also considered a valid reply.
+func (d *Driver) Ping(rctx context.Context) error {
+	sp, ctx := opentracing.StartSpanFromContext(rctx, "bblfsh.native.Ping")
+	defer sp.Finish()
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel func()
+		ctx, cancel = context.WithTimeout(ctx, pingTimeout)
+		defer cancel()
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	switch {
+	case d.state == stateKilled:
+		// the process was stopped on purpose and will be started by the next request
+		return nil
+	case d.state == stateBroken || !d.isRunning():
+		return driver.ErrDriverFailure.Wrap(ErrNotRunning.New())
+	}
+	_, err := d.roundTrip(ctx, newRequest(ctx, parseRequest{Encoding: d.ec, Ping: true}), nil)
+	if err == nil {
+		return nil
+	}
+	if (d.state == stateTimeout || (d.mux != nil && d.state == stateOK)) && expired(ctx) {
+		err = d.broken(err)
+	}
+	return driver.ErrDriverFailure.Wrap(err)
+}
+
+// SetPingInterval enables periodic pings of the native driver. Hung native drivers are stopped by failed pings,
+// see Ping. Zero interval disables pings. It must be called before Start.
+func (d *Driver) SetPingInterval(interval time.Duration) {
+	d.pingEvery = interval
+}
+
+// startPinger starts a goroutine that pings the native driver periodically.
+func (d *Driver) startPinger() {
+	if d.pingEvery <= 0 {
+		return
+	}
+	stop := make(chan struct{})
+	d.stopMu.Lock()
+	d.pingStop = stop
+	d.stopMu.Unlock()
+	go func() {
+		ticker := time.NewTicker(d.pingEvery)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+			_ = d.Ping(context.Background())
+		}
+	}()
+}
+
+// stopPinger stops periodic pings, if they are enabled. It must be called with stopMu held.
+func (d *Driver) stopPinger() {
+	if d.pingStop != nil {
+		close(d.pingStop)
+		d.pingStop = nil
+	}
+}
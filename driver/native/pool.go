@@ -0,0 +1,252 @@
+package native
+
+import (
+	"context"
+	"io"
+	"runtime"
+	"sync"
+
+	"github.com/opentracing/opentracing-go"
+
+	"gopkg.in/bblfsh/sdk.v2/driver"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+const (
+	// maxFailures is the number of consecutive driver failures after which a pool instance is restarted.
+	maxFailures = 3
+)
+
+var (
+	_ driver.Native       = (*Pool)(nil)
+	_ driver.ReaderNative = (*Pool)(nil)
+)
+
+// Pool runs multiple instances of the native driver and dispatches parse requests to them concurrently.
+//
+// Each instance is tracked separately: if it breaks or fails several requests in a row, it is restarted
+// before receiving new requests.
+type Pool struct {
+	size      int
+	newDriver func() driver.Native
+
+	mu      sync.Mutex
+	running bool
+	all     []*instance
+	idle    chan *instance
+	done    chan struct{}
+}
+
+// InstanceStats is a health information about a single instance of the native driver in the pool.
+type InstanceStats struct {
+	// Requests is the number of requests processed by the instance.
+	Requests uint64
+	// Failures is the number of driver failures of the instance. Parsing errors are not counted.
+	Failures uint64
+	// Restarts is the number of times the instance was restarted.
+	Restarts uint64
+	// Healthy is set if the instance is running and can accept requests.
+	Healthy bool
+}
+
+type instance struct {
+	d     driver.Native
+	fails int // consecutive failures
+
+	mu    sync.Mutex
+	stats InstanceStats
+}
+
+// NewPool creates a pool with a given number of native driver instances, created by newDriver.
+// If size is zero or negative, the number of CPUs is used.
+func NewPool(size int, newDriver func() driver.Native) *Pool {
+	if size <= 0 {
+		size = runtime.NumCPU()
+	}
+	return &Pool{size: size, newDriver: newDriver}
+}
+
+// NewDriverPool creates a pool of native drivers that run the given binary. See NewPool and NewDriverAt.
+//
+// If configure is set, it is called for each new instance before it is started. It accepts the same setters as
+// a single Driver, for example SetFormat, SetLimits, SetWarmUp, SetLogger and SetMetrics.
+func NewDriverPool(bin string, enc Encoding, size int, configure func(d *Driver)) *Pool {
+	return NewPool(size, func() driver.Native {
+		d := NewDriverAt(bin, enc).(*Driver)
+		if configure != nil {
+			configure(d)
+		}
+		return d
+	})
+}
+
+// Start starts all instances of the native driver.
+func (p *Pool) Start() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.running {
+		return nil
+	}
+	all := make([]*instance, 0, p.size)
+	for i := 0; i < p.size; i++ {
+		inst := &instance{d: p.newDriver()}
+		if err := inst.d.Start(); err != nil {
+			for _, inst := range all {
+				inst.d.Close()
+			}
+			return err
+		}
+		inst.stats.Healthy = true
+		all = append(all, inst)
+	}
+	p.all = all
+	p.idle = make(chan *instance, len(all))
+	for _, inst := range all {
+		p.idle <- inst
+	}
+	p.done = make(chan struct{})
+	p.running = true
+	return nil
+}
+
+// Parse sends a request to an idle instance of the native driver. It blocks until an instance is available
+// or the context is cancelled.
+func (p *Pool) Parse(rctx context.Context, src string) (nodes.Node, error) {
+	sp, ctx := opentracing.StartSpanFromContext(rctx, "bblfsh.native.Pool.Parse")
+	defer sp.Finish()
+
+	return p.parse(ctx, func(d driver.Native) (nodes.Node, error) {
+		return d.Parse(ctx, src)
+	})
+}
+
+// ParseReader implements driver.ReaderNative. The source is read into memory, if the instance of the native driver
+// does not implement driver.ReaderNative.
+func (p *Pool) ParseReader(rctx context.Context, r io.Reader, size int) (nodes.Node, error) {
+	sp, ctx := opentracing.StartSpanFromContext(rctx, "bblfsh.native.Pool.Parse")
+	defer sp.Finish()
+
+	return p.parse(ctx, func(d driver.Native) (nodes.Node, error) {
+		if rn, ok := d.(driver.ReaderNative); ok {
+			return rn.ParseReader(ctx, r, size)
+		}
+		src, err := driver.ReadSource(r, size)
+		if err != nil {
+			return nil, driver.ErrDriverFailure.Wrap(err)
+		}
+		return d.Parse(ctx, src)
+	})
+}
+
+// parse calls fnc with an idle instance of the native driver and restarts the instance if it fails.
+func (p *Pool) parse(ctx context.Context, fnc func(d driver.Native) (nodes.Node, error)) (nodes.Node, error) {
+	p.mu.Lock()
+	idle, done, running := p.idle, p.done, p.running
+	p.mu.Unlock()
+	if !running {
+		return nil, driver.ErrDriverFailure.Wrap(ErrNotRunning.New())
+	}
+	var inst *instance
+	select {
+	case inst = <-idle:
+	case <-done:
+		return nil, driver.ErrDriverFailure.Wrap(ErrNotRunning.New())
+	case <-ctx.Done():
+		return nil, driver.ErrDriverFailure.Wrap(ctx.Err())
+	}
+	defer func() {
+		idle <- inst
+	}()
+	if inst.d == nil {
+		// the last restart failed, try again
+		if err := p.restart(inst); err != nil {
+			return nil, driver.ErrDriverFailure.Wrap(err)
+		}
+	}
+	ast, err := fnc(inst.d)
+	failed := driver.ErrDriverFailure.Is(err)
+	inst.mu.Lock()
+	inst.stats.Requests++
+	if failed {
+		inst.stats.Failures++
+	}
+	inst.mu.Unlock()
+	if !failed {
+		inst.fails = 0
+		return ast, err
+	}
+	inst.fails++
+	if inst.fails >= maxFailures || !healthy(inst.d) {
+		_ = p.restart(inst)
+	}
+	return ast, err
+}
+
+// healthy checks if the driver can accept more requests.
+func healthy(d driver.Native) bool {
+	h, ok := d.(interface {
+		healthy() bool
+	})
+	return !ok || h.healthy()
+}
+
+// restart stops the instance and starts a new one in its place.
+func (p *Pool) restart(inst *instance) error {
+	if inst.d != nil {
+		_ = inst.d.Close()
+	}
+	d := p.newDriver()
+	err := d.Start()
+	inst.mu.Lock()
+	defer inst.mu.Unlock()
+	inst.stats.Restarts++
+	inst.fails = 0
+	if err != nil {
+		inst.d = nil
+		inst.stats.Healthy = false
+		return err
+	}
+	inst.d = d
+	inst.stats.Healthy = true
+	return nil
+}
+
+// Stats returns health information about all instances of the pool.
+func (p *Pool) Stats() []InstanceStats {
+	p.mu.Lock()
+	all := p.all
+	p.mu.Unlock()
+	out := make([]InstanceStats, 0, len(all))
+	for _, inst := range all {
+		inst.mu.Lock()
+		out = append(out, inst.stats)
+		inst.mu.Unlock()
+	}
+	return out
+}
+
+// Close stops all instances of the native driver. It waits for in-flight requests to finish.
+func (p *Pool) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.running {
+		return nil
+	}
+	p.running = false
+	close(p.done)
+	var last error
+	for range p.all {
+		inst := <-p.idle
+		if inst.d == nil {
+			continue
+		}
+		if err := inst.d.Close(); err != nil {
+			last = err
+		}
+		inst.mu.Lock()
+		inst.stats.Healthy = false
+		inst.mu.Unlock()
+	}
+	p.all = nil
+	return last
+}
@@ -0,0 +1,134 @@
+package native
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"gopkg.in/bblfsh/sdk.v2/driver"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+type fakeNative struct {
+	active *int32
+	max    *int32
+	fail   func(src string) bool
+}
+
+func (d *fakeNative) Start() error { return nil }
+func (d *fakeNative) Close() error { return nil }
+
+func (d *fakeNative) Parse(ctx context.Context, src string) (nodes.Node, error) {
+	n := atomic.AddInt32(d.active, 1)
+	defer atomic.AddInt32(d.active, -1)
+	for {
+		m := atomic.LoadInt32(d.max)
+		if n <= m || atomic.CompareAndSwapInt32(d.max, m, n) {
+			break
+		}
+	}
+	if d.fail != nil && d.fail(src) {
+		return nil, driver.ErrDriverFailure.Wrap(errors.New("crashed"))
+	}
+	return mockResponse(src), nil
+}
+
+func TestPoolParse(t *testing.T) {
+	require := require.New(t)
+
+	var active, max int32
+	p := NewPool(4, func() driver.Native {
+		return &fakeNative{active: &active, max: &max}
+	})
+	err := p.Start()
+	require.NoError(err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			src := fmt.Sprintf("foo_%d", i)
+			r, err := p.Parse(context.Background(), src)
+			require.NoError(err)
+			require.Equal(mockResponse(src), r)
+		}(i)
+	}
+	wg.Wait()
+	require.True(max <= 4, "%d", max)
+
+	var total uint64
+	for _, st := range p.Stats() {
+		require.True(st.Healthy)
+		total += st.Requests
+	}
+	require.Equal(uint64(100), total)
+
+	err = p.Close()
+	require.NoError(err)
+
+	_, err = p.Parse(context.Background(), "foo")
+	require.True(driver.ErrDriverFailure.Is(err))
+}
+
+func TestPoolRestart(t *testing.T) {
+	require := require.New(t)
+
+	var active, max, created int32
+	p := NewPool(1, func() driver.Native {
+		atomic.AddInt32(&created, 1)
+		return &fakeNative{active: &active, max: &max, fail: func(src string) bool {
+			return src == "crash"
+		}}
+	})
+	err := p.Start()
+	require.NoError(err)
+	defer p.Close()
+
+	for i := 0; i < maxFailures; i++ {
+		_, err = p.Parse(context.Background(), "crash")
+		require.True(driver.ErrDriverFailure.Is(err))
+	}
+	require.Equal(int32(2), created)
+
+	r, err := p.Parse(context.Background(), "ok")
+	require.NoError(err)
+	require.Equal(mockResponse("ok"), r)
+
+	st := p.Stats()
+	require.Equal([]InstanceStats{
+		{Requests: maxFailures + 1, Failures: maxFailures, Restarts: 1, Healthy: true},
+	}, st)
+}
+
+func TestDriverPoolConfigure(t *testing.T) {
+	require := require.New(t)
+
+	var (
+		mu      sync.Mutex
+		created []*Driver
+	)
+	p := NewDriverPool("internal/simple/mock", "", 2, func(d *Driver) {
+		d.SetHandshake(true)
+		mu.Lock()
+		created = append(created, d)
+		mu.Unlock()
+	})
+	err := p.Start()
+	require.NoError(err)
+	defer p.Close()
+
+	r, err := p.Parse(context.Background(), "foo")
+	require.NoError(err)
+	require.Equal(mockResponse("foo"), r)
+
+	require.Len(created, 2)
+	for _, d := range created {
+		require.Equal(ProtocolVersion, d.Protocol().Version)
+	}
+}
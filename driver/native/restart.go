@@ -0,0 +1,82 @@
+package native
+
+import (
+	"sync/atomic"
+	"time"
+
+	"gopkg.in/bblfsh/sdk.v2/driver"
+	serrors "gopkg.in/src-d/go-errors.v1"
+)
+
+var (
+	// ErrRestarting is returned when the native driver crashed and is being restarted. The request
+	// can be retried later. Other driver failures of a broken driver are permanent.
+	ErrRestarting = serrors.NewKind("native driver is restarting")
+)
+
+// RestartPolicy controls automatic restarts of a crashed native driver.
+//
+// The first restart happens on the next request after the crash. If the driver crashes again before
+// it successfully replies to any request, the next restart is delayed by Backoff, and the delay doubles
+// with each consecutive crash, up to MaxBackoff. Requests received during the delay fail with ErrRestarting.
+type RestartPolicy struct {
+	// MaxRestarts is the maximal number of consecutive restarts. Zero disables restarts,
+	// negative value removes the limit.
+	MaxRestarts int
+	// Backoff is the delay before the second consecutive restart.
+	Backoff time.Duration
+	// MaxBackoff limits the delay between restarts. Zero value means no limit.
+	MaxBackoff time.Duration
+}
+
+// delay returns the delay before the restart after n consecutive restarts.
+func (p RestartPolicy) delay(n int) time.Duration {
+	if n == 0 {
+		return 0
+	}
+	d := p.Backoff
+	for i := 1; i < n; i++ {
+		d *= 2
+		if p.MaxBackoff > 0 && d >= p.MaxBackoff {
+			break
+		}
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	return d
+}
+
+func (p RestartPolicy) allowed(n int) bool {
+	return p.MaxRestarts < 0 || n < p.MaxRestarts
+}
+
+// SetRestartPolicy enables automatic restarts of the native process if it crashes or produces malformed responses.
+func (d *Driver) SetRestartPolicy(p RestartPolicy) {
+	d.mu.Lock()
+	d.restart = p
+	d.mu.Unlock()
+}
+
+// Restarts returns the number of times the native process was restarted.
+func (d *Driver) Restarts() uint64 {
+	return atomic.LoadUint64(&d.restarts)
+}
+
+// recover restarts a broken native process according to the restart policy. It must be called with the mutex held.
+func (d *Driver) recover() error {
+	if !d.restart.allowed(d.crashes) {
+		return driver.ErrDriverFailure.Wrap(d.lastErr)
+	}
+	if time.Now().Before(d.restartAt) {
+		return driver.ErrDriverFailure.Wrap(ErrRestarting.Wrap(d.lastErr))
+	}
+	d.crashes++
+	d.restarted()
+	if err := d.Start(); err != nil {
+		d.state, d.lastErr = stateBroken, err
+		d.restartAt = time.Now().Add(d.restart.delay(d.crashes))
+		return driver.ErrDriverFailure.Wrap(ErrRestarting.Wrap(err))
+	}
+	return nil
+}
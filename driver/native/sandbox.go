@@ -0,0 +1,40 @@
+package native
+
+import (
+	serrors "gopkg.in/src-d/go-errors.v1"
+)
+
+// ErrSandboxUnsupported is returned by Start if the sandbox is not supported on the current platform.
+var ErrSandboxUnsupported = serrors.NewKind("sandbox is not supported on this platform")
+
+// Sandbox configures the isolation of the native process, since it processes untrusted source code.
+// The sandbox is only supported on Linux, and Seccomp and NoNetwork only on amd64 and arm64.
+type Sandbox struct {
+	// Namespaces runs the process in new user, mount, PID, IPC and UTS namespaces. The process keeps the user and
+	// group IDs of the current process. Requires the kernel to allow unprivileged user namespaces, unless the
+	// current process is privileged.
+	Namespaces bool
+	// NoNetwork prevents the process from creating network sockets. Unix sockets are still allowed, thus it can be
+	// used together with SetSocket. If Namespaces is set, the process also runs in a new network namespace.
+	NoNetwork bool
+	// Seccomp installs a seccomp filter that denies system calls not needed for parsing, such as ptrace, mount,
+	// or loading kernel modules. Denied system calls fail with EPERM.
+	Seccomp bool
+}
+
+func (s Sandbox) enabled() bool {
+	return s.Namespaces || s.NoNetwork || s.Seccomp
+}
+
+// SetSandbox sets the sandbox for the native process. It must be called before Start.
+func (d *Driver) SetSandbox(s Sandbox) {
+	d.sandbox = s
+}
+
+// startProcess starts the native process in the sandbox, if it is enabled.
+func (d *Driver) startProcess() error {
+	if !d.sandbox.enabled() {
+		return d.cmd.Start()
+	}
+	return startSandboxed(d.cmd, d.sandbox)
+}
@@ -0,0 +1,64 @@
+package native
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	prSetNoNewPrivs   = 38
+	prSetSeccomp      = 22
+	seccompModeFilter = 2
+)
+
+// startSandboxed starts the process in new namespaces and with a seccomp filter, as configured by the sandbox.
+func startSandboxed(cmd *exec.Cmd, s Sandbox) error {
+	if s.Namespaces {
+		flags := uintptr(syscall.CLONE_NEWUSER | syscall.CLONE_NEWNS | syscall.CLONE_NEWPID |
+			syscall.CLONE_NEWIPC | syscall.CLONE_NEWUTS)
+		if s.NoNetwork {
+			flags |= syscall.CLONE_NEWNET
+		}
+		uid, gid := os.Getuid(), os.Getgid()
+		cmd.SysProcAttr = &syscall.SysProcAttr{
+			Cloneflags:  flags,
+			UidMappings: []syscall.SysProcIDMap{{ContainerID: uid, HostID: uid, Size: 1}},
+			GidMappings: []syscall.SysProcIDMap{{ContainerID: gid, HostID: gid, Size: 1}},
+		}
+	}
+	if !s.Seccomp && !s.NoNetwork {
+		return cmd.Start()
+	}
+	prog, err := seccompFilter(s)
+	if err != nil {
+		return err
+	}
+	// Seccomp filters are inherited by child processes, but cannot be removed. Thus, the filter is installed on
+	// a dedicated thread that starts the process. The thread is never unlocked, so it exits with the goroutine.
+	errc := make(chan error, 1)
+	go func() {
+		runtime.LockOSThread()
+		if err := installFilter(prog); err != nil {
+			errc <- err
+			return
+		}
+		errc <- cmd.Start()
+	}()
+	return <-errc
+}
+
+// installFilter installs the seccomp filter for the current thread.
+func installFilter(prog []syscall.SockFilter) error {
+	// required to install filters without CAP_SYS_ADMIN; also prevents the process from gaining privileges
+	if _, _, e := syscall.RawSyscall(syscall.SYS_PRCTL, prSetNoNewPrivs, 1, 0); e != 0 {
+		return os.NewSyscallError("prctl", e)
+	}
+	fprog := syscall.SockFprog{Len: uint16(len(prog)), Filter: &prog[0]}
+	if _, _, e := syscall.RawSyscall(syscall.SYS_PRCTL, prSetSeccomp, seccompModeFilter, uintptr(unsafe.Pointer(&fprog))); e != 0 {
+		return os.NewSyscallError("prctl", e)
+	}
+	return nil
+}
@@ -0,0 +1,8 @@
+package native
+
+const (
+	// auditArch is the AUDIT_ARCH value of the architecture checked by the seccomp filter.
+	auditArch = 0xc000003e
+	// denyX32 denies system calls of the x32 ABI, which share the architecture value.
+	denyX32 = true
+)
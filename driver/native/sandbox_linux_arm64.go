@@ -0,0 +1,7 @@
+package native
+
+const (
+	// auditArch is the AUDIT_ARCH value of the architecture checked by the seccomp filter.
+	auditArch = 0xc00000b7
+	denyX32   = false
+)
@@ -0,0 +1,83 @@
+package native
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+// commandDriver creates a script that runs a shell command for each request and replies with its output.
+func commandDriver(t *testing.T, cmd string) (string, func()) {
+	dir, err := ioutil.TempDir("", "native-")
+	require.NoError(t, err)
+	bin := filepath.Join(dir, "native")
+	script := "#!/bin/bash\nwhile read -r line; do\n" +
+		"\tout=$( (" + cmd + ") 2>&1 | tr -d '\"\\\\\\n')\n" +
+		"\techo \"{\\\"status\\\":\\\"ok\\\",\\\"ast\\\":{\\\"out\\\":\\\"$out\\\"}}\"\n" +
+		"done\n"
+	err = ioutil.WriteFile(bin, []byte(script), 0755)
+	require.NoError(t, err)
+	return bin, func() {
+		os.RemoveAll(dir)
+	}
+}
+
+func sandboxOutput(t *testing.T, cmd string, s Sandbox) string {
+	bin, clean := commandDriver(t, cmd)
+	defer clean()
+
+	d := NewDriverAt(bin, "").(*Driver)
+	d.SetSandbox(s)
+	err := d.Start()
+	if ErrSandboxUnsupported.Is(err) {
+		t.Skip(err)
+	}
+	if s.Namespaces && err != nil {
+		t.Skipf("namespaces are not available: %v", err)
+	}
+	require.NoError(t, err)
+	defer d.Close()
+
+	ast, err := d.Parse(context.Background(), "")
+	require.NoError(t, err)
+	obj, ok := ast.(nodes.Object)
+	require.True(t, ok, "%v", ast)
+	out, _ := obj["out"].(nodes.String)
+	return string(out)
+}
+
+func TestNativeDriverSandbox_NoNetwork(t *testing.T) {
+	const cmd = "exec 3<>/dev/tcp/127.0.0.1/9"
+
+	out := sandboxOutput(t, cmd, Sandbox{})
+	require.NotContains(t, out, "not permitted")
+
+	out = sandboxOutput(t, cmd, Sandbox{NoNetwork: true})
+	require.Contains(t, out, "Operation not permitted")
+}
+
+func TestNativeDriverSandbox_Seccomp(t *testing.T) {
+	if _, err := exec.LookPath("unshare"); err != nil {
+		t.Skip("unshare is not installed")
+	}
+	const cmd = "unshare -U true && echo allowed"
+
+	out := sandboxOutput(t, cmd, Sandbox{})
+	if out != "allowed" {
+		t.Skipf("user namespaces are not available: %s", out)
+	}
+	out = sandboxOutput(t, cmd, Sandbox{Seccomp: true})
+	require.Contains(t, out, "Operation not permitted")
+}
+
+func TestNativeDriverSandbox_Namespaces(t *testing.T) {
+	out := sandboxOutput(t, "echo $$", Sandbox{Namespaces: true, NoNetwork: true})
+	require.Equal(t, "1", out)
+}
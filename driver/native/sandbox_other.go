@@ -0,0 +1,10 @@
+//go:build !linux
+// +build !linux
+
+package native
+
+import "os/exec"
+
+func startSandboxed(cmd *exec.Cmd, s Sandbox) error {
+	return ErrSandboxUnsupported.New()
+}
@@ -0,0 +1,119 @@
+//go:build amd64 || arm64
+// +build amd64 arm64
+
+package native
+
+import "syscall"
+
+const (
+	seccompRetAllow = 0x7fff0000
+	seccompRetErrno = 0x00050000
+
+	// offsets of fields in struct seccomp_data
+	offNR   = 0
+	offArch = 4
+	offArg0 = 16 // low half of the first argument on little-endian architectures
+
+	x32SyscallBit = 0x40000000
+)
+
+// deniedSyscalls are the system calls denied by the seccomp filter.
+var deniedSyscalls = []uintptr{
+	syscall.SYS_PTRACE,
+	syscall.SYS_PERF_EVENT_OPEN,
+	syscall.SYS_MOUNT,
+	syscall.SYS_UMOUNT2,
+	syscall.SYS_PIVOT_ROOT,
+	syscall.SYS_CHROOT,
+	syscall.SYS_UNSHARE,
+	syscall.SYS_SWAPON,
+	syscall.SYS_SWAPOFF,
+	syscall.SYS_REBOOT,
+	syscall.SYS_KEXEC_LOAD,
+	syscall.SYS_INIT_MODULE,
+	syscall.SYS_DELETE_MODULE,
+	syscall.SYS_ACCT,
+	syscall.SYS_QUOTACTL,
+	syscall.SYS_SETTIMEOFDAY,
+	syscall.SYS_CLOCK_SETTIME,
+	syscall.SYS_ADJTIMEX,
+	syscall.SYS_SETHOSTNAME,
+	syscall.SYS_SETDOMAINNAME,
+	syscall.SYS_ADD_KEY,
+	syscall.SYS_REQUEST_KEY,
+	syscall.SYS_KEYCTL,
+}
+
+// deniedFamilies are the socket families denied if the network is disabled.
+var deniedFamilies = []uint32{
+	syscall.AF_INET,
+	syscall.AF_INET6,
+	syscall.AF_PACKET,
+}
+
+// target is a destination of a conditional jump of the filter.
+type target int
+
+const (
+	next target = iota
+	toAllow
+	toDeny
+)
+
+// bpfInsn is an instruction of the filter that may jump to one of its final returns.
+type bpfInsn struct {
+	syscall.SockFilter
+	jt, jf target
+}
+
+func load(off uint32) bpfInsn {
+	return bpfInsn{SockFilter: syscall.SockFilter{Code: syscall.BPF_LD | syscall.BPF_W | syscall.BPF_ABS, K: off}}
+}
+
+func jump(op uint16, k uint32, jt, jf target) bpfInsn {
+	return bpfInsn{SockFilter: syscall.SockFilter{Code: syscall.BPF_JMP | op | syscall.BPF_K, K: k}, jt: jt, jf: jf}
+}
+
+// seccompFilter builds a filter program for the sandbox.
+func seccompFilter(s Sandbox) ([]syscall.SockFilter, error) {
+	insns := []bpfInsn{
+		// system calls of other architectures are denied, since their numbers differ
+		load(offArch),
+		jump(syscall.BPF_JEQ, auditArch, next, toDeny),
+		load(offNR),
+	}
+	if denyX32 {
+		insns = append(insns, jump(syscall.BPF_JGE, x32SyscallBit, toDeny, next))
+	}
+	if s.Seccomp {
+		for _, nr := range deniedSyscalls {
+			insns = append(insns, jump(syscall.BPF_JEQ, uint32(nr), toDeny, next))
+		}
+	}
+	if s.NoNetwork {
+		insns = append(insns, jump(syscall.BPF_JEQ, syscall.SYS_SOCKET, next, toAllow), load(offArg0))
+		for _, f := range deniedFamilies {
+			insns = append(insns, jump(syscall.BPF_JEQ, f, toDeny, next))
+		}
+	}
+	allow, deny := len(insns), len(insns)+1
+	offset := func(i int, t target) uint8 {
+		switch t {
+		case toAllow:
+			return uint8(allow - i - 1)
+		case toDeny:
+			return uint8(deny - i - 1)
+		}
+		return 0
+	}
+	prog := make([]syscall.SockFilter, 0, len(insns)+2)
+	for i, in := range insns {
+		in.Jt, in.Jf = offset(i, in.jt), offset(i, in.jf)
+		prog = append(prog, in.SockFilter)
+	}
+	prog = append(prog,
+		syscall.SockFilter{Code: syscall.BPF_RET | syscall.BPF_K, K: seccompRetAllow},
+		syscall.SockFilter{Code: syscall.BPF_RET | syscall.BPF_K, K: seccompRetErrno | uint32(syscall.EPERM)},
+	)
+	return prog, nil
+}
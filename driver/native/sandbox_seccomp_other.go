@@ -0,0 +1,11 @@
+//go:build linux && !amd64 && !arm64
+// +build linux,!amd64,!arm64
+
+package native
+
+import "syscall"
+
+// seccompFilter is only implemented for architectures with known system call numbers.
+func seccompFilter(s Sandbox) ([]syscall.SockFilter, error) {
+	return nil, ErrSandboxUnsupported.New()
+}
@@ -0,0 +1,115 @@
+package native
+
+import (
+	"context"
+	"io"
+	"net"
+	"os"
+	"time"
+)
+
+// SocketEnv is the environment variable used to pass the path of a Unix socket to the native driver process.
+// If it is set, the native driver must listen on this socket and serve the protocol on the first accepted
+// connection instead of stdin and stdout.
+const SocketEnv = "BBLFSH_NATIVE_SOCKET"
+
+const (
+	dialTimeout  = time.Second * 30
+	dialInterval = time.Millisecond * 50
+)
+
+// inStream is a stream used to send requests to the native driver.
+type inStream interface {
+	io.WriteCloser
+	SetWriteDeadline(t time.Time) error
+}
+
+// outStream is a stream used to read responses of the native driver.
+type outStream interface {
+	io.ReadCloser
+	SetReadDeadline(t time.Time) error
+}
+
+// SetSocket makes the driver communicate with the native process over a Unix socket at a given path, instead of
+// stdin and stdout. The path is passed to the native process in SocketEnv variable. An empty path switches back
+// to stdio. It must be called before Start.
+func (d *Driver) SetSocket(path string) {
+	d.socket = path
+}
+
+// startSocket starts the native process and connects to the socket it listens on.
+func (d *Driver) startSocket(ctx context.Context) error {
+	if err := os.Remove(d.socket); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	d.cmd.Env = append(d.cmd.Env, SocketEnv+"="+d.socket)
+	// stdout is not used by the protocol, so keep the output for diagnostics
+	d.cmd.Stdout = d.stderr
+	if err := d.startProcess(); err != nil {
+		return err
+	}
+	if err := d.applyLimits(); err != nil {
+		d.cmd.Process.Kill()
+		return err
+	}
+	exited := make(chan error, 1)
+	go func() {
+		exited <- d.cmd.Wait()
+	}()
+	deadline := time.Now().Add(dialTimeout)
+	if dl, ok := ctx.Deadline(); ok && dl.Before(deadline) {
+		deadline = dl
+	}
+	conn, err := dialSocket(d.socket, exited, deadline)
+	if err != nil {
+		d.cmd.Process.Kill()
+		return err
+	}
+	d.exited = exited
+	d.stdin = writeHalf{conn}
+	d.stdout = conn
+	d.enc = d.format.newEncoder(countingWriter{w: d.stdin, n: &d.sent})
+	d.dec = d.format.newDecoder(countingReader{r: d.stdout, n: &d.received}, d.maxResp)
+	d.setRunning(true)
+	return nil
+}
+
+// dialSocket connects to a Unix socket, waiting for the native process to start listening on it until the deadline.
+func dialSocket(path string, exited <-chan error, deadline time.Time) (*net.UnixConn, error) {
+	for {
+		conn, err := net.DialUnix("unix", nil, &net.UnixAddr{Name: path, Net: "unix"})
+		if err == nil {
+			return conn, nil
+		} else if time.Now().After(deadline) {
+			return nil, err
+		}
+		select {
+		case werr := <-exited:
+			if werr == nil {
+				werr = err
+			}
+			return nil, werr
+		case <-time.After(dialInterval):
+		}
+	}
+}
+
+// writeHalf is a write side of a socket. Closing it signals EOF to the native process,
+// while responses can still be read from the connection.
+type writeHalf struct {
+	*net.UnixConn
+}
+
+func (c writeHalf) Close() error {
+	return c.CloseWrite()
+}
+
+// listenSocket accepts a single connection on a Unix socket at a given path.
+func listenSocket(path string) (net.Conn, error) {
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	defer l.Close()
+	return l.Accept()
+}
@@ -0,0 +1,186 @@
+package native
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	serrors "gopkg.in/src-d/go-errors.v1"
+)
+
+const (
+	// stderrLines is the number of last lines of stderr that are attached to driver failures.
+	stderrLines = 20
+	// maxStderrLine limits the length of a single line of stderr. Longer lines are truncated.
+	maxStderrLine = 4096
+)
+
+var (
+	// ErrNativeStderr wraps driver failures with the last lines written to stderr by the native process.
+	ErrNativeStderr = serrors.NewKind("native driver stderr:\n%s")
+)
+
+// Logger is a logger used for the output of the native process. It is implemented by server.Logger.
+type Logger interface {
+	Debugf(format string, args ...interface{})
+	Infof(format string, args ...interface{})
+	Warningf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+}
+
+// SetLogger sets a logger for the stderr output of the native process. It must be called before Start.
+//
+// Each line of stderr is logged separately. Lines may contain JSON objects with "level" and "msg" fields,
+// in this case the message is logged with a given level and other fields are appended to it. Other lines
+// are logged as warnings. If the logger is not set, the output is copied to stderr of the current process.
+func (d *Driver) SetLogger(l Logger) {
+	d.log = l
+}
+
+// stderrLog is a writer for stderr of the native process. It logs each line and keeps the last lines of the output.
+type stderrLog struct {
+	log Logger
+	out io.Writer // used if log is not set
+
+	mu    sync.Mutex
+	buf   []byte   // incomplete line
+	lines []string // ring buffer of the last lines
+	last  int      // index of the next line in the ring buffer
+}
+
+func newStderrLog(l Logger) *stderrLog {
+	return &stderrLog{log: l, out: os.Stderr}
+}
+
+func (w *stderrLog) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.log == nil {
+		if _, err := w.out.Write(p); err != nil {
+			return 0, err
+		}
+	}
+	n := len(p)
+	for len(p) != 0 {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			w.append(p)
+			break
+		}
+		w.append(p[:i])
+		w.flush()
+		p = p[i+1:]
+	}
+	return n, nil
+}
+
+// append adds data to the current line, truncating it if necessary.
+func (w *stderrLog) append(p []byte) {
+	if n := maxStderrLine - len(w.buf); n < len(p) {
+		p = p[:n]
+	}
+	w.buf = append(w.buf, p...)
+}
+
+// flush logs the current line and saves it for Tail.
+func (w *stderrLog) flush() {
+	line := strings.TrimRight(string(w.buf), "\r")
+	w.buf = w.buf[:0]
+	if line == "" {
+		return
+	}
+	if len(w.lines) < stderrLines {
+		w.lines = append(w.lines, line)
+	} else {
+		w.lines[w.last] = line
+		w.last = (w.last + 1) % stderrLines
+	}
+	if w.log != nil {
+		logLine(w.log, line)
+	}
+}
+
+// Tail returns the last lines written to stderr, including an incomplete line.
+func (w *stderrLog) Tail() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	lines := append(append([]string{}, w.lines[w.last:]...), w.lines[:w.last]...)
+	if len(w.buf) != 0 {
+		lines = append(lines, string(w.buf))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// logLine logs a single line of stderr. See SetLogger.
+func logLine(l Logger, line string) {
+	var m map[string]interface{}
+	if line[0] != '{' || json.Unmarshal([]byte(line), &m) != nil {
+		l.Warningf("native: %s", line)
+		return
+	}
+	level, _ := m["level"].(string)
+	msg, ok := m["msg"].(string)
+	if !ok {
+		msg, _ = m["message"].(string)
+	}
+	delete(m, "level")
+	delete(m, "msg")
+	delete(m, "message")
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		msg += fmt.Sprintf(" %s=%v", k, m[k])
+	}
+	switch strings.ToLower(level) {
+	case "debug", "trace":
+		l.Debugf("native: %s", msg)
+	case "info":
+		l.Infof("native: %s", msg)
+	case "error", "fatal", "panic":
+		l.Errorf("native: %s", msg)
+	default:
+		l.Warningf("native: %s", msg)
+	}
+}
+
+// withStderr attaches the last lines of stderr of the native process to the error.
+// It should be called after the process exits, so that the output is complete.
+func (d *Driver) withStderr(err error) error {
+	if d.stderr == nil {
+		return err
+	}
+	tail := d.stderr.Tail()
+	if tail == "" {
+		return err
+	}
+	return ErrNativeStderr.Wrap(err, tail)
+}
+
+// Stderr returns the last lines of stderr attached to the driver failure, or an empty string if there are none.
+// See ErrNativeStderr.
+func Stderr(err error) string {
+	for err != nil {
+		e, ok := err.(*serrors.Error)
+		if !ok {
+			return ""
+		}
+		cause := e.Cause()
+		if ErrNativeStderr.Is(e) && !ErrNativeStderr.Is(cause) {
+			msg := strings.TrimPrefix(e.Error(), "native driver stderr:\n")
+			if cause != nil {
+				msg = strings.TrimSuffix(msg, ": "+cause.Error())
+			}
+			return msg
+		}
+		err = cause
+	}
+	return ""
+}
@@ -0,0 +1,97 @@
+package native
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	derrors "gopkg.in/bblfsh/sdk.v2/driver/errors"
+)
+
+type testLogger struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (l *testLogger) logf(level, format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, level+": "+fmt.Sprintf(format, args...))
+}
+
+func (l *testLogger) Debugf(format string, args ...interface{})   { l.logf("debug", format, args...) }
+func (l *testLogger) Infof(format string, args ...interface{})    { l.logf("info", format, args...) }
+func (l *testLogger) Warningf(format string, args ...interface{}) { l.logf("warning", format, args...) }
+func (l *testLogger) Errorf(format string, args ...interface{})   { l.logf("error", format, args...) }
+
+func TestStderrLog(t *testing.T) {
+	require := require.New(t)
+
+	l := &testLogger{}
+	w := newStderrLog(l)
+	_, err := fmt.Fprint(w, "plain line\n{\"level\":\"info\",\"msg\":\"started\",\"pid\":1}\n")
+	require.NoError(err)
+	_, err = fmt.Fprint(w, "{\"level\":\"error\",")
+	require.NoError(err)
+	_, err = fmt.Fprint(w, "\"message\":\"failed\"}\npartial")
+	require.NoError(err)
+
+	require.Equal([]string{
+		"warning: native: plain line",
+		"info: native: started pid=1",
+		"error: native: failed",
+	}, l.lines)
+	require.Equal("plain line\n"+
+		`{"level":"info","msg":"started","pid":1}`+"\n"+
+		`{"level":"error","message":"failed"}`+"\n"+
+		"partial", w.Tail())
+}
+
+func TestStderrLog_Tail(t *testing.T) {
+	w := newStderrLog(&testLogger{})
+	var exp []string
+	for i := 0; i < stderrLines*2; i++ {
+		line := fmt.Sprintf("line %d", i)
+		if i >= stderrLines {
+			exp = append(exp, line)
+		}
+		fmt.Fprintln(w, line)
+	}
+	require.Equal(t, strings.Join(exp, "\n"), w.Tail())
+}
+
+func TestNativeDriverStderr(t *testing.T) {
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "native-")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+	bin := filepath.Join(dir, "native")
+	err = ioutil.WriteFile(bin, []byte("#!/bin/sh\n"+
+		`echo '{"level":"error","msg":"cannot parse"}' >&2`+"\n"+
+		"echo 'panic: crashed' >&2\n"+
+		"exit 1\n"), 0755)
+	require.NoError(err)
+
+	l := &testLogger{}
+	d := NewDriverAt(bin, "").(*Driver)
+	d.SetLogger(l)
+	err = d.Start()
+	require.NoError(err)
+
+	_, err = d.Parse(context.Background(), "foo")
+	require.True(derrors.ErrDriverFailure.Is(err))
+	require.True(ErrNativeStderr.Is(err), "%v", err)
+	require.Contains(err.Error(), "panic: crashed")
+	require.Equal([]string{
+		"error: native: cannot parse",
+		"warning: native: panic: crashed",
+	}, l.lines)
+}
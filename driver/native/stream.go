@@ -0,0 +1,126 @@
+package native
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/opentracing/opentracing-go"
+
+	"gopkg.in/bblfsh/sdk.v2/driver"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+// StreamingNative is a native driver that can send the AST in parts. If the driver passed to Main implements
+// this interface, the AST is streamed to the Go driver instead of being sent in a single message.
+// This reduces the peak memory usage for large files on both sides.
+type StreamingNative interface {
+	driver.Native
+	// ParseStream parses the source and calls emit for each part of the AST, for example for each top-level
+	// declaration. It returns the root of the tree without the emitted parts and the field of the root that will
+	// hold the list of parts when the tree is assembled. The root may be nil, in this case the list of parts is
+	// used as the tree.
+	ParseStream(ctx context.Context, src string, emit func(n nodes.Node) error) (root nodes.Node, field string, err error)
+}
+
+// ParseStream is similar to Parse, but calls fnc for each part of the AST streamed by the native driver instead of
+// assembling the tree. It returns the root of the tree without streamed parts. If the native driver does not stream
+// the AST, fnc is never called and the whole tree is returned.
+//
+// Errors returned by fnc are returned as-is. The rest of the stream is skipped in this case.
+func (d *Driver) ParseStream(rctx context.Context, src string, fnc func(n nodes.Node) error) (nodes.Node, error) {
+	sp, ctx := opentracing.StartSpanFromContext(rctx, "bblfsh.native.ParseStream")
+	defer sp.Finish()
+
+	if err := d.reqs.begin(); err != nil {
+		return nil, driver.ErrDriverFailure.Wrap(err)
+	}
+	defer d.reqs.end()
+
+	var ferr error
+	ast, err := d.parse(ctx, src, parseRequest{}, func(n nodes.Node) error {
+		ferr = fnc(n)
+		return ferr
+	})
+	if ferr != nil {
+		return nil, ferr
+	}
+	return ast, err
+}
+
+// readStream reads the response of the native driver, including all parts of the streamed AST.
+//
+// If fnc is nil, streamed parts are assembled into a single tree. Otherwise, fnc is called for each part and the
+// response contains the root node only. If fnc fails, the rest of the stream is consumed and the error is returned.
+//
+// Parts that exceed the size limit are skipped with the rest of the stream. Note that if the first message
+// of the stream is too large, it is assumed to be the final response.
+func (d *Driver) readStream(ctx context.Context, fnc func(n nodes.Node) error) (*parseResponse, error) {
+	return collectStream(func() (*parseResponse, error) {
+		return d.readResponse(ctx)
+	}, fnc)
+}
+
+// collectStream reads the response and all parts of the streamed AST with next. See readStream.
+func collectStream(next func() (*parseResponse, error), fnc func(n nodes.Node) error) (*parseResponse, error) {
+	var (
+		parts    nodes.Array
+		streamed bool
+		ferr     error // first error of the stream; the rest of the stream is skipped after it
+	)
+	for {
+		r, err := next()
+		if streamed && ErrResponseTooLarge.Is(err) {
+			if ferr == nil {
+				ferr = err
+			}
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if r.Status != statusChunk {
+			if ferr != nil {
+				return nil, ferr
+			} else if fnc != nil {
+				return r, nil
+			} else if streamed {
+				err = r.assemble(parts)
+			}
+			return r, err
+		}
+		streamed = true
+		if ferr != nil {
+			continue
+		}
+		if fnc != nil {
+			ferr = fnc(r.AST)
+		} else {
+			parts = append(parts, r.AST)
+		}
+	}
+}
+
+// assemble puts the streamed parts of the AST into the root node.
+func (r *parseResponse) assemble(parts nodes.Array) error {
+	if parts == nil {
+		parts = nodes.Array{}
+	}
+	if r.Chunks == "" {
+		if r.AST != nil {
+			return fmt.Errorf("native driver streamed the AST without specifying the field for its parts")
+		}
+		r.AST = parts
+		return nil
+	}
+	var root nodes.Object
+	switch ast := r.AST.(type) {
+	case nil:
+		root = nodes.Object{}
+	case nodes.Object:
+		root = ast
+	default:
+		return fmt.Errorf("expected an object as a root of the streamed AST, got: %T", ast)
+	}
+	root[r.Chunks] = parts
+	r.AST, r.Chunks = root, ""
+	return nil
+}
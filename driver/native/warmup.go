@@ -0,0 +1,50 @@
+package native
+
+import (
+	"context"
+	"time"
+
+	serrors "gopkg.in/src-d/go-errors.v1"
+)
+
+const warmUpTimeout = time.Minute
+
+var (
+	// ErrWarmUp is returned by Start if the native driver failed to parse warm-up snippets.
+	ErrWarmUp = serrors.NewKind("native driver warm-up failed")
+)
+
+// SetWarmUp sets source snippets that are parsed each time the native process is started, before it receives
+// any other requests. This allows JIT-based runtimes to warm up, and makes Start fail early if the native
+// driver is broken. Syntax errors in the snippets are ignored.
+func (d *Driver) SetWarmUp(snippets ...string) {
+	d.warmup = append([]string{}, snippets...)
+}
+
+// warmUp parses all warm-up snippets. The driver is stopped if any of them fails.
+func (d *Driver) warmUp(ctx context.Context) error {
+	for _, src := range d.warmup {
+		if err := d.warmUpOne(ctx, src); err != nil {
+			_ = d.stop()
+			return ErrWarmUp.Wrap(err)
+		}
+	}
+	return nil
+}
+
+func (d *Driver) warmUpOne(ctx context.Context, src string) error {
+	str, err := d.ec.Encode(src)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, warmUpTimeout)
+	defer cancel()
+	r, err := d.roundTrip(ctx, newRequest(ctx, parseRequest{Content: str, Encoding: d.ec}), nil)
+	if err != nil {
+		return err
+	} else if r.Status == statusFatal {
+		_, err = r.result()
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package driver
+
+import "time"
+
+// StageObserver is an instrumentation interface for stages of the driver. It allows to export durations of each
+// stage to a monitoring system. It is called synchronously and must be safe for concurrent use.
+type StageObserver interface {
+	// StageDone is called after each stage with its duration. The error is nil if the stage succeeded.
+	StageDone(stage string, d time.Duration, err error)
+}
+
+// ObservedDriver is an optional interface for drivers that report durations of stages.
+type ObservedDriver interface {
+	Driver
+	// SetStageObserver sets an observer for all stages, including StageNative. It must be called before Start.
+	SetStageObserver(o StageObserver)
+}
+
+// SetObserver sets an observer that is called after each stage of the pipeline and after the native driver call.
+// It must be called before the pipeline is used.
+func (p *Pipeline) SetObserver(o StageObserver) {
+	p.observer = o
+}
+
+var _ ObservedDriver = (*driverImpl)(nil)
+
+// SetStageObserver implements ObservedDriver.
+func (d *driverImpl) SetStageObserver(o StageObserver) {
+	d.p.SetObserver(o)
+}
+
+// SetStageObserver implements ObservedDriver. The observer is set on the wrapped module.
+func (d *middlewareModule) SetStageObserver(o StageObserver) {
+	if od, ok := d.mod.(ObservedDriver); ok {
+		od.SetStageObserver(o)
+	}
+}
+
+// observe reports the duration of the stage to the observer, if it is set.
+func (p *Pipeline) observe(stage string, start time.Time, err error) {
+	if p.observer != nil {
+		p.observer.StageDone(stage, time.Since(start), err)
+	}
+}
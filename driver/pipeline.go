@@ -0,0 +1,274 @@
+package driver
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"gopkg.in/src-d/go-errors.v1"
+
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+	"gopkg.in/bblfsh/sdk.v2/uast/transformer"
+)
+
+// ErrUnknownStage is returned when referring to a stage that is not defined in the pipeline.
+var ErrUnknownStage = errors.NewKind("unknown pipeline stage: %q")
+
+// Names of the stages of the pipeline created by Transforms.
+const (
+	StagePreprocess     = "preprocess"
+	StagePreprocessCode = "preprocess-code"
+	StageSemantic       = "semantic"
+	StageAnnotated      = "annotated"
+	StageCode           = "on-code"
+	StageNamespace      = "namespace"
+	StageRecover        = "recover"
+)
+
+// FileState is a per-file state shared by all stages of the pipeline.
+type FileState struct {
+	// Mode is the requested transformation mode.
+	Mode Mode
+	// Code is the source of the file.
+	Code string
+	// Context is a transformation context shared by all transformers. See transformer.WithContext.
+	Context *transformer.Context
+	// Diagnostics collects non-fatal errors. If any errors are recorded, the pipeline returns ErrPartialTransform.
+	Diagnostics *transformer.Diagnostics
+	// Metadata of the request. See WithMetadata.
+	Metadata Metadata
+	// SyntaxErrors are reported by the native parser if it recovered from syntax errors and returned
+	// a partial tree. See Transforms.ErrorNodes.
+	SyntaxErrors []*Diagnostic
+
+	// reused is a set of subtrees taken from the previous transformation; nil if the transformation
+	// is not incremental
+	reused map[nodes.Comparable]struct{}
+}
+
+// Reused checks if the subtree was taken from the previous transformation and must not be transformed again.
+// Incremental stages must leave such subtrees unchanged. See Pipeline.DoIncremental.
+func (st *FileState) Reused(n nodes.Node) bool {
+	if st.reused == nil {
+		return false
+	}
+	_, ok := st.reused[nodes.UniqueKey(n)]
+	return ok
+}
+
+// Stage is a named step of the transformation pipeline.
+type Stage struct {
+	// Name of the stage. Should be unique in the pipeline.
+	Name string
+	// Mode is the lowest transformation mode that enables the stage.
+	Mode Mode
+	// Incremental is set if the stage skips subtrees reused from the previous transformation. See FileState.Reused
+	// and Pipeline.DoIncremental.
+	Incremental bool
+	// Do runs the stage on the tree.
+	Do func(ctx context.Context, st *FileState, nd nodes.Node) (nodes.Node, error)
+}
+
+// BeforeHook is called before each stage of the pipeline. If it returns true, the stage is not executed and
+// the returned node is used as a result of the stage. This can be used for caching.
+type BeforeHook func(ctx context.Context, stage string, st *FileState, nd nodes.Node) (nodes.Node, bool, error)
+
+// AfterHook is called after each stage of the pipeline with the resulting tree and the error. It is also called
+// for stages skipped by a BeforeHook, with the node returned by the hook. The returned error replaces the error
+// of the stage.
+type AfterHook func(ctx context.Context, stage string, st *FileState, nd nodes.Node, err error) error
+
+// Pipeline is an ordered list of transformation stages. It allows to disable stages, insert new ones and
+// observe the execution with hooks.
+//
+// Pipeline is not safe for concurrent modification, but it is safe to call Do concurrently.
+type Pipeline struct {
+	stages   []Stage
+	disabled map[string]struct{}
+	before   []BeforeHook
+	after    []AfterHook
+	limit    int // memory budget for the tree; see SetMemoryLimit
+	timeouts map[string]time.Duration
+	observer StageObserver
+}
+
+// NewPipeline creates a pipeline with given stages.
+func NewPipeline(stages ...Stage) *Pipeline {
+	return &Pipeline{stages: append([]Stage{}, stages...)}
+}
+
+// Stages returns names of all stages of the pipeline, including disabled ones.
+func (p *Pipeline) Stages() []string {
+	names := make([]string, 0, len(p.stages))
+	for _, s := range p.stages {
+		names = append(names, s.Name)
+	}
+	return names
+}
+
+func (p *Pipeline) index(name string) (int, error) {
+	for i, s := range p.stages {
+		if s.Name == name {
+			return i, nil
+		}
+	}
+	return -1, ErrUnknownStage.New(name)
+}
+
+// Enable enables or disables a stage with a given name.
+func (p *Pipeline) Enable(name string, enabled bool) error {
+	if _, err := p.index(name); err != nil {
+		return err
+	}
+	if enabled {
+		delete(p.disabled, name)
+		return nil
+	}
+	if p.disabled == nil {
+		p.disabled = make(map[string]struct{})
+	}
+	p.disabled[name] = struct{}{}
+	return nil
+}
+
+// Enabled checks if the stage is enabled.
+func (p *Pipeline) Enabled(name string) bool {
+	_, ok := p.disabled[name]
+	return !ok
+}
+
+// Append adds stages to the end of the pipeline.
+func (p *Pipeline) Append(stages ...Stage) {
+	p.stages = append(p.stages, stages...)
+}
+
+// InsertBefore inserts stages before the stage with a given name.
+func (p *Pipeline) InsertBefore(name string, stages ...Stage) error {
+	i, err := p.index(name)
+	if err != nil {
+		return err
+	}
+	p.insert(i, stages)
+	return nil
+}
+
+// InsertAfter inserts stages after the stage with a given name.
+func (p *Pipeline) InsertAfter(name string, stages ...Stage) error {
+	i, err := p.index(name)
+	if err != nil {
+		return err
+	}
+	p.insert(i+1, stages)
+	return nil
+}
+
+func (p *Pipeline) insert(i int, stages []Stage) {
+	out := make([]Stage, 0, len(p.stages)+len(stages))
+	out = append(out, p.stages[:i]...)
+	out = append(out, stages...)
+	out = append(out, p.stages[i:]...)
+	p.stages = out
+}
+
+// Before adds a hook that will be called before each stage.
+func (p *Pipeline) Before(h BeforeHook) {
+	p.before = append(p.before, h)
+}
+
+// After adds a hook that will be called after each stage.
+func (p *Pipeline) After(h AfterHook) {
+	p.after = append(p.after, h)
+}
+
+// Do runs all enabled stages of the pipeline that are required for a given mode. Metadata is read from the context,
+// see WithMetadata.
+func (p *Pipeline) Do(ctx context.Context, mode Mode, code string, nd nodes.Node) (nodes.Node, error) {
+	return p.do(ctx, mode, code, nd, nil)
+}
+
+func (p *Pipeline) do(rctx context.Context, mode Mode, code string, nd nodes.Node, prev *Previous) (nodes.Node, error) {
+	sp, ctx := opentracing.StartSpanFromContext(rctx, "uast.Transform")
+	defer sp.Finish()
+	traceRequest(sp, mode, "", "")
+
+	if mode > ModeSemantic {
+		return nil, ErrModeNotSupported.New()
+	}
+	if mode == 0 {
+		mode = ModeDefault
+	}
+	if err := p.checkMemory(nd); err != nil {
+		return nil, err
+	}
+	if mode == ModeNative {
+		return nd, nil
+	}
+
+	st := &FileState{
+		Mode:         mode,
+		Code:         code,
+		Context:      transformer.NewContext(),
+		Diagnostics:  &transformer.Diagnostics{},
+		Metadata:     MetadataFrom(rctx),
+		SyntaxErrors: syntaxErrorsFrom(rctx),
+	}
+	st.Metadata.toContext(st)
+	for _, s := range p.stages {
+		if mode < s.Mode || !p.Enabled(s.Name) {
+			continue
+		}
+		if prev != nil && st.reused == nil && s.Incremental {
+			nd = st.reuse(nd, prev)
+		} else if st.reused != nil && !s.Incremental {
+			return nil, errNotIncremental.New("stage " + strconv.Quote(s.Name))
+		}
+		var err error
+		nd, err = p.runStage(ctx, s, st, nd)
+		if err != nil {
+			return nd, err
+		} else if err = p.checkMemory(nd); err != nil {
+			return nil, err
+		}
+	}
+	if errs := st.Diagnostics.Errors(); len(errs) != 0 {
+		return nd, ErrPartialTransform.Wrap(JoinErrors(errs))
+	}
+	return nd, nil
+}
+
+func (p *Pipeline) runStage(ctx context.Context, s Stage, st *FileState, nd nodes.Node) (nodes.Node, error) {
+	sp, ctx := startStage(ctx, "uast.Transform."+s.Name, s.Name)
+	defer sp.Finish()
+
+	var (
+		out  nodes.Node
+		skip bool
+		err  error
+	)
+	for _, h := range p.before {
+		out, skip, err = h(ctx, s.Name, st, nd)
+		if err != nil {
+			return nd, err
+		} else if skip {
+			break
+		}
+	}
+	if !skip {
+		err = p.withTimeout(ctx, s.Name, func(ctx context.Context) error {
+			var err error
+			out, err = s.Do(ctx, st, nd)
+			return err
+		})
+		if ErrStageTimeout.Is(err) {
+			// the stage may still use the state
+			TraceError(sp, err)
+			return nil, err
+		}
+	}
+	for _, h := range p.after {
+		err = h(ctx, s.Name, st, out, err)
+	}
+	TraceError(sp, err)
+	return out, err
+}
@@ -0,0 +1,148 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/bblfsh/sdk.v2/uast"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+	"gopkg.in/bblfsh/sdk.v2/uast/transformer"
+)
+
+// appendStage creates a stage that appends its name to the array.
+func appendStage(name string, mode Mode) Stage {
+	return Stage{Name: name, Mode: mode, Do: func(ctx context.Context, st *FileState, nd nodes.Node) (nodes.Node, error) {
+		arr, _ := nd.(nodes.Array)
+		return append(arr.CloneList(), nodes.String(name)), nil
+	}}
+}
+
+// strs creates an array of strings.
+func strs(names ...string) nodes.Array {
+	arr := nodes.Array{}
+	for _, s := range names {
+		arr = append(arr, nodes.String(s))
+	}
+	return arr
+}
+
+func TestPipelineStages(t *testing.T) {
+	require := require.New(t)
+
+	p := NewPipeline(appendStage("a", ModePreprocessed), appendStage("c", ModeSemantic))
+	p.Append(appendStage("e", ModeAnnotated))
+	err := p.InsertBefore("c", appendStage("b", ModePreprocessed))
+	require.NoError(err)
+	err = p.InsertAfter("c", appendStage("d", ModeAnnotated))
+	require.NoError(err)
+	require.Equal([]string{"a", "b", "c", "d", "e"}, p.Stages())
+
+	err = p.InsertAfter("x", appendStage("y", ModeSemantic))
+	require.True(ErrUnknownStage.Is(err))
+	err = p.InsertBefore("x", appendStage("y", ModeSemantic))
+	require.True(ErrUnknownStage.Is(err))
+	err = p.Enable("x", false)
+	require.True(ErrUnknownStage.Is(err))
+
+	ctx := context.Background()
+	out, err := p.Do(ctx, ModeSemantic, "", nodes.Array{})
+	require.NoError(err)
+	require.Equal(strs("a", "b", "c", "d", "e"), out)
+
+	// stages of higher modes are not executed
+	out, err = p.Do(ctx, ModeAnnotated, "", nodes.Array{})
+	require.NoError(err)
+	require.Equal(strs("a", "b", "d", "e"), out)
+
+	out, err = p.Do(ctx, ModeNative, "", nodes.Array{})
+	require.NoError(err)
+	require.Equal(nodes.Array{}, out)
+
+	err = p.Enable("b", false)
+	require.NoError(err)
+	require.False(p.Enabled("b"))
+	require.Equal([]string{"a", "b", "c", "d", "e"}, p.Stages())
+
+	out, err = p.Do(ctx, ModeSemantic, "", nodes.Array{})
+	require.NoError(err)
+	require.Equal(strs("a", "c", "d", "e"), out)
+
+	err = p.Enable("b", true)
+	require.NoError(err)
+	require.True(p.Enabled("b"))
+
+	out, err = p.Do(ctx, ModeSemantic, "", nodes.Array{})
+	require.NoError(err)
+	require.Equal(strs("a", "b", "c", "d", "e"), out)
+}
+
+func TestPipelineHooks(t *testing.T) {
+	require := require.New(t)
+
+	p := NewPipeline(appendStage("a", ModePreprocessed), appendStage("b", ModePreprocessed), appendStage("c", ModePreprocessed))
+
+	var before, after []string
+	p.Before(func(ctx context.Context, stage string, st *FileState, nd nodes.Node) (nodes.Node, bool, error) {
+		before = append(before, stage)
+		if stage == "b" {
+			return strs("cached"), true, nil
+		}
+		return nil, false, nil
+	})
+	p.After(func(ctx context.Context, stage string, st *FileState, nd nodes.Node, err error) error {
+		arr := nd.(nodes.Array)
+		after = append(after, stage+":"+string(arr[len(arr)-1].(nodes.String)))
+		return err
+	})
+
+	ctx := context.Background()
+	out, err := p.Do(ctx, ModeSemantic, "", nodes.Array{})
+	require.NoError(err)
+	require.Equal(strs("cached", "c"), out)
+	require.Equal([]string{"a", "b", "c"}, before)
+	// after hooks are called for skipped stages as well
+	require.Equal([]string{"a:a", "b:cached", "c:c"}, after)
+
+	// after hooks may replace the error of the stage
+	errHook := errors.New("hook")
+	p.After(func(ctx context.Context, stage string, st *FileState, nd nodes.Node, err error) error {
+		if stage == "c" {
+			return errHook
+		}
+		return err
+	})
+	_, err = p.Do(ctx, ModeSemantic, "", nodes.Array{})
+	require.Equal(errHook, err)
+
+	// errors of before hooks stop the pipeline
+	p = NewPipeline(appendStage("a", ModePreprocessed), appendStage("b", ModePreprocessed))
+	p.Before(func(ctx context.Context, stage string, st *FileState, nd nodes.Node) (nodes.Node, bool, error) {
+		if stage == "b" {
+			return nil, false, errHook
+		}
+		return nil, false, nil
+	})
+	out, err = p.Do(ctx, ModeSemantic, "", nodes.Array{})
+	require.Equal(errHook, err)
+	require.Equal(strs("a"), out)
+}
+
+func TestTransformsWarnings(t *testing.T) {
+	require := require.New(t)
+
+	tr := Transforms{Normalize: []transformer.Transformer{transformer.Mappings(transformer.Map(
+		transformer.Obj{
+			uast.KeyType: transformer.String("A"),
+			"op": transformer.LookupWarn(transformer.Var("op"), map[nodes.Value]nodes.Value{
+				nodes.String("+"): nodes.String("add"),
+			}),
+		},
+		transformer.Obj{uast.KeyType: transformer.String("B"), "op": transformer.Var("op")},
+	))}}
+
+	out, err := tr.Do(context.Background(), ModeSemantic, "", nodes.Object{uast.KeyType: nodes.String("A"), "op": nodes.String("**")})
+	require.True(ErrPartialTransform.Is(err), "%v", err)
+	require.Equal(nodes.Object{uast.KeyType: nodes.String("B"), "op": nodes.String("**")}, out)
+}
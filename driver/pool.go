@@ -0,0 +1,330 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"gopkg.in/src-d/go-errors.v1"
+
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+// ErrPoolClosed is returned by Pool after it was closed.
+var ErrPoolClosed = errors.NewKind("driver pool is closed")
+
+// NewDriverFunc creates a new instance of a driver. The driver is started by the caller.
+type NewDriverFunc func() (DriverModule, error)
+
+// PoolOptions control the lifecycle of drivers in the Pool. Zero values disable the corresponding feature.
+type PoolOptions struct {
+	// MaxRequests is the number of requests after which the driver is replaced with a new instance.
+	MaxRequests int
+	// IdleTimeout is the time after the last request after which the driver is stopped. It is started again
+	// on the next request.
+	IdleTimeout time.Duration
+	// HealthInterval is the interval between health checks of running drivers. Drivers that fail Ping are replaced
+	// with a new instance on the next request. See Pinger.
+	HealthInterval time.Duration
+}
+
+// PoolStats is a health information about drivers of a single language in the Pool.
+type PoolStats struct {
+	// Running is set if an instance of the driver is running.
+	Running bool
+	// Requests is the total number of requests.
+	Requests uint64
+	// Failures is the number of requests that failed with ErrDriverFailure.
+	Failures uint64
+	// Starts is the number of started instances.
+	Starts uint64
+	// Unhealthy is the number of instances that were stopped after a failed health check.
+	Unhealthy uint64
+}
+
+// Pool manages the lifecycle of drivers for multiple languages: drivers are started on the first request, stopped
+// when idle, replaced after a number of requests and after failed health checks. See PoolOptions.
+//
+// Instances that are replaced are closed after all their requests complete. The pool is safe for concurrent use.
+type Pool struct {
+	opts PoolOptions
+
+	mu     sync.RWMutex
+	byLang map[string]*poolEntry
+	closed bool
+	stop   chan struct{}
+	wg     sync.WaitGroup
+}
+
+type poolEntry struct {
+	newDriver NewDriverFunc
+
+	mu        sync.Mutex
+	cur       *poolInstance // nil if not running
+	stats     PoolStats
+	lastCheck time.Time
+}
+
+type poolInstance struct {
+	d        DriverModule
+	active   int // requests in progress
+	requests int
+	lastUsed time.Time
+	retired  bool // closed when active reaches zero
+}
+
+// NewPool creates an empty pool of drivers. If idle timeout or health checks are enabled, the pool runs Check
+// periodically until it is closed.
+func NewPool(opts PoolOptions) *Pool {
+	p := &Pool{opts: opts, byLang: make(map[string]*poolEntry), stop: make(chan struct{})}
+	tick := opts.HealthInterval
+	if opts.IdleTimeout > 0 && (tick == 0 || opts.IdleTimeout < tick) {
+		tick = opts.IdleTimeout
+	}
+	if tick > 0 {
+		p.wg.Add(1)
+		go p.checkEvery(tick)
+	}
+	return p
+}
+
+// Register adds a driver for a given language. Drivers are created and started on the first request.
+func (p *Pool) Register(lang string, fnc NewDriverFunc) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return ErrPoolClosed.New()
+	} else if _, ok := p.byLang[lang]; ok {
+		return fmt.Errorf("multiple drivers for language %q", lang)
+	}
+	p.byLang[lang] = &poolEntry{newDriver: fnc}
+	return nil
+}
+
+// Languages returns a sorted list of registered languages.
+func (p *Pool) Languages() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	out := make([]string, 0, len(p.byLang))
+	for lang := range p.byLang {
+		out = append(out, lang)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Stats returns health information about drivers of each registered language.
+func (p *Pool) Stats() map[string]PoolStats {
+	p.mu.RLock()
+	byLang := make(map[string]*poolEntry, len(p.byLang))
+	for lang, e := range p.byLang {
+		byLang[lang] = e
+	}
+	p.mu.RUnlock()
+	out := make(map[string]PoolStats, len(byLang))
+	for lang, e := range byLang {
+		e.mu.Lock()
+		st := e.stats
+		st.Running = e.cur != nil
+		e.mu.Unlock()
+		out[lang] = st
+	}
+	return out
+}
+
+// Parse parses the source with the driver for a given language, starting the driver if necessary.
+// ErrUnsupportedLanguage is returned if no drivers are registered for the language.
+func (p *Pool) Parse(ctx context.Context, lang string, src string, opts *ParseOptions) (nodes.Node, error) {
+	if opts == nil {
+		opts = &ParseOptions{}
+	}
+	opts.Language = lang
+	var ast nodes.Node
+	err := p.Do(ctx, lang, func(d Driver) error {
+		var err error
+		ast, err = d.Parse(ctx, src, opts)
+		return err
+	})
+	return ast, err
+}
+
+// Do calls fnc with the driver for a given language, starting the driver if necessary. The driver must not be
+// used after fnc returns. The error of fnc is returned as-is.
+func (p *Pool) Do(ctx context.Context, lang string, fnc func(d Driver) error) error {
+	p.mu.RLock()
+	e, ok := p.byLang[lang]
+	closed := p.closed
+	p.mu.RUnlock()
+	if closed {
+		return ErrPoolClosed.New()
+	} else if !ok {
+		return ErrUnsupportedLanguage.New(lang)
+	}
+	inst, err := p.acquire(e)
+	if err != nil {
+		return err
+	}
+	err = fnc(inst.d)
+	p.release(e, inst, err)
+	return err
+}
+
+// acquire returns a running instance of the driver, starting a new one if necessary.
+func (p *Pool) acquire(e *poolEntry) (*poolInstance, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	p.mu.RLock()
+	closed := p.closed
+	p.mu.RUnlock()
+	if closed {
+		return nil, ErrPoolClosed.New()
+	}
+	if e.cur == nil {
+		d, err := e.newDriver()
+		if err != nil {
+			return nil, ErrDriverFailure.Wrap(err)
+		} else if err = d.Start(); err != nil {
+			_ = d.Close()
+			return nil, ErrDriverFailure.Wrap(err)
+		}
+		e.cur = &poolInstance{d: d}
+		e.stats.Starts++
+	}
+	inst := e.cur
+	inst.active++
+	inst.requests++
+	inst.lastUsed = time.Now()
+	e.stats.Requests++
+	if p.opts.MaxRequests > 0 && inst.requests >= p.opts.MaxRequests {
+		// the next request will start a new instance
+		e.retire(inst)
+	}
+	return inst, nil
+}
+
+// release marks the request as completed and closes the instance if it was retired.
+func (p *Pool) release(e *poolEntry, inst *poolInstance, err error) {
+	e.mu.Lock()
+	inst.active--
+	inst.lastUsed = time.Now()
+	if ErrDriverFailure.Is(err) {
+		e.stats.Failures++
+	}
+	closing := inst.retired && inst.active == 0
+	e.mu.Unlock()
+	if closing {
+		_ = inst.d.Close()
+	}
+}
+
+// retire stops giving the instance to new requests. It returns true if the instance has no requests in progress,
+// in this case the caller must close it. The caller must hold the lock.
+func (e *poolEntry) retire(inst *poolInstance) bool {
+	if e.cur == inst {
+		e.cur = nil
+	}
+	inst.retired = true
+	return inst.active == 0
+}
+
+func (p *Pool) checkEvery(tick time.Duration) {
+	defer p.wg.Done()
+	t := time.NewTicker(tick)
+	defer t.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-t.C:
+			p.Check(context.Background())
+		}
+	}
+}
+
+// Check stops idle drivers and runs health checks of running drivers, according to the pool options. It is called
+// periodically by the pool, but may also be called manually.
+func (p *Pool) Check(ctx context.Context) {
+	p.mu.RLock()
+	list := make([]*poolEntry, 0, len(p.byLang))
+	for _, e := range p.byLang {
+		list = append(list, e)
+	}
+	p.mu.RUnlock()
+	for _, e := range list {
+		p.check(ctx, e)
+	}
+}
+
+func (p *Pool) check(ctx context.Context, e *poolEntry) {
+	now := time.Now()
+	e.mu.Lock()
+	inst := e.cur
+	if inst == nil {
+		e.mu.Unlock()
+		return
+	}
+	if p.opts.IdleTimeout > 0 && inst.active == 0 && now.Sub(inst.lastUsed) >= p.opts.IdleTimeout {
+		e.retire(inst)
+		e.mu.Unlock()
+		_ = inst.d.Close()
+		return
+	}
+	if p.opts.HealthInterval <= 0 || now.Sub(e.lastCheck) < p.opts.HealthInterval {
+		e.mu.Unlock()
+		return
+	}
+	e.lastCheck = now
+	// keep the instance open during the check
+	inst.active++
+	e.mu.Unlock()
+
+	err := pingDriver(ctx, inst.d)
+
+	e.mu.Lock()
+	inst.active--
+	closing := inst.retired && inst.active == 0
+	if err != nil && !inst.retired {
+		e.stats.Unhealthy++
+		closing = e.retire(inst)
+	}
+	e.mu.Unlock()
+	if closing {
+		_ = inst.d.Close()
+	}
+}
+
+// Close stops all drivers. Drivers with requests in progress are closed after the requests complete.
+func (p *Pool) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	close(p.stop)
+	list := make([]*poolEntry, 0, len(p.byLang))
+	for _, e := range p.byLang {
+		list = append(list, e)
+	}
+	p.mu.Unlock()
+	p.wg.Wait()
+
+	var errs []error
+	for _, e := range list {
+		e.mu.Lock()
+		inst := e.cur
+		closing := inst != nil && e.retire(inst)
+		e.mu.Unlock()
+		if closing {
+			if err := inst.d.Close(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return JoinErrors(errs)
+}
@@ -0,0 +1,122 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/bblfsh/sdk.v2/driver/manifest"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+// countModule counts starts and closes of the  Ping fails if broken is set.
+type countModule struct {
+	DriverModule
+	starts, closes *int
+	broken         *bool
+}
+
+func (d countModule) Start() error {
+	*d.starts++
+	return d.DriverModule.Start()
+}
+
+func (d countModule) Close() error {
+	*d.closes++
+	return d.DriverModule.Close()
+}
+
+func (d countModule) Ping(ctx context.Context) error {
+	if *d.broken {
+		return fmt.Errorf("broken")
+	}
+	return nil
+}
+
+func TestDriverPool(t *testing.T) {
+	require := require.New(t)
+
+	var (
+		starts, closes int
+		broken         bool
+	)
+	p := NewPool(PoolOptions{MaxRequests: 3})
+	defer p.Close()
+	err := p.Register("fixture", func() (DriverModule, error) {
+		d, err := NewDriverFrom(echoNative{}, &manifest.Manifest{Language: "fixture"}, Transforms{})
+		if err != nil {
+			return nil, err
+		}
+		return countModule{DriverModule: d, starts: &starts, closes: &closes, broken: &broken}, nil
+	})
+	require.NoError(err)
+	require.Error(p.Register("fixture", nil))
+	require.Equal([]string{"fixture"}, p.Languages())
+	require.False(p.Stats()["fixture"].Running)
+	require.Equal(0, starts)
+
+	ctx := context.Background()
+	opts := &ParseOptions{Mode: ModeNative}
+	ast, err := p.Parse(ctx, "fixture", "a", opts)
+	require.NoError(err)
+	require.Equal(nodes.Object{"src": nodes.String("a")}, ast)
+	require.Equal("fixture", opts.Language)
+	require.Equal(1, starts)
+
+	_, err = p.Parse(ctx, "other", "a", nil)
+	require.True(ErrUnsupportedLanguage.Is(err), "%v", err)
+
+	// the driver is recycled after the third request
+	for i := 0; i < 3; i++ {
+		_, err = p.Parse(ctx, "fixture", "a", &ParseOptions{Mode: ModeNative})
+		require.NoError(err)
+	}
+	require.Equal(2, starts)
+	require.Equal(1, closes)
+	require.Equal(PoolStats{Running: true, Requests: 4, Starts: 2}, p.Stats()["fixture"])
+
+	// unhealthy drivers are stopped by Check
+	p2 := NewPool(PoolOptions{HealthInterval: time.Hour})
+	defer p2.Close()
+	err = p2.Register("fixture", func() (DriverModule, error) {
+		d, err := NewDriverFrom(echoNative{}, &manifest.Manifest{Language: "fixture"}, Transforms{})
+		return countModule{DriverModule: d, starts: &starts, closes: &closes, broken: &broken}, err
+	})
+	require.NoError(err)
+	_, err = p2.Parse(ctx, "fixture", "a", nil)
+	require.NoError(err)
+	require.Equal(3, starts)
+
+	broken = true
+	p2.Check(ctx)
+	require.Equal(PoolStats{Requests: 1, Starts: 1, Unhealthy: 1}, p2.Stats()["fixture"])
+	require.Equal(2, closes)
+
+	broken = false
+	_, err = p2.Parse(ctx, "fixture", "a", nil)
+	require.NoError(err)
+	require.Equal(4, starts)
+
+	require.NoError(p2.Close())
+	require.Equal(3, closes)
+	_, err = p2.Parse(ctx, "fixture", "a", nil)
+	require.True(ErrPoolClosed.Is(err), "%v", err)
+
+	// idle drivers are stopped in the background
+	p3 := NewPool(PoolOptions{IdleTimeout: time.Millisecond})
+	defer p3.Close()
+	err = p3.Register("fixture", func() (DriverModule, error) {
+		return NewDriverFrom(echoNative{}, &manifest.Manifest{Language: "fixture"}, Transforms{})
+	})
+	require.NoError(err)
+	_, err = p3.Parse(ctx, "fixture", "a", nil)
+	require.NoError(err)
+	for p3.Stats()["fixture"].Running {
+		time.Sleep(time.Millisecond)
+	}
+	_, err = p3.Parse(ctx, "fixture", "a", nil)
+	require.NoError(err)
+	require.Equal(uint64(2), p3.Stats()["fixture"].Starts)
+}
@@ -0,0 +1,53 @@
+package driver
+
+import (
+	"gopkg.in/src-d/go-errors.v1"
+
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+	"gopkg.in/bblfsh/sdk.v2/uast/query"
+	"gopkg.in/bblfsh/sdk.v2/uast/query/xpath"
+)
+
+// ErrInvalidQuery is returned if the query set in ParseOptions cannot be compiled.
+var ErrInvalidQuery = errors.NewKind("invalid query: %v")
+
+// FilterQuery runs the XPath query over the tree and returns an array of matching subtrees, in document order.
+// Queries that return a value, for example "count(//uast:Identifier)", return an array with a single value.
+func FilterQuery(n nodes.Node, q string) (nodes.Node, error) {
+	if q == "" {
+		return n, nil
+	}
+	prep, err := xpath.New().Prepare(q)
+	if err != nil {
+		return nil, ErrInvalidQuery.New(err)
+	}
+	if n == nil {
+		return nodes.Array{}, nil
+	}
+	it, err := prep.Execute(n)
+	if err != nil {
+		return nil, ErrInvalidQuery.New(err)
+	}
+	out := nodes.Array{}
+	for _, m := range query.AllNodes(it) {
+		nd, err := nodes.ToNode(m, nil)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, nd)
+	}
+	return out, nil
+}
+
+// filterResult restricts the tree returned for a file to a given range, query result and fields, in this order.
+// A failure of the query replaces the parsing error.
+func filterResult(n nodes.Node, err error, r Range, q string, f FieldMask) (nodes.Node, error) {
+	n = FilterRange(n, r)
+	if q != "" {
+		var qerr error
+		if n, qerr = FilterQuery(n, q); qerr != nil {
+			return nil, qerr
+		}
+	}
+	return FilterFields(n, f), err
+}
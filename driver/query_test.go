@@ -0,0 +1,44 @@
+package driver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/bblfsh/sdk.v2/driver/manifest"
+	"gopkg.in/bblfsh/sdk.v2/uast"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+func TestDriverParseQuery(t *testing.T) {
+	require := require.New(t)
+
+	m := &manifest.Manifest{Language: "fixture"}
+	d, err := NewDriverFrom(treeNative{}, m, Transforms{})
+	require.NoError(err)
+
+	ctx := context.Background()
+	ast, err := d.Parse(ctx, "a b", &ParseOptions{
+		Mode: ModeNative, Query: "//*[text() = 'b']",
+		Fields: FieldMask{Exclude: []string{uast.KeyPos}},
+	})
+	require.NoError(err)
+	require.Equal(nodes.Array{nodes.Object{uast.KeyToken: nodes.String("b")}}, ast)
+
+	ast, err = d.Parse(ctx, "a b", &ParseOptions{Mode: ModeNative, Query: "count(//body/*)"})
+	require.NoError(err)
+	require.Equal(nodes.Array{nodes.Int(2)}, ast)
+
+	_, err = d.Parse(ctx, "a b", &ParseOptions{Query: "//["})
+	require.True(ErrInvalidQuery.Is(err), "%v", err)
+
+	res, err := d.ParseFiles(ctx, ModeNative, []File{
+		{Content: "a b", Query: "//*[text() = 'c']"},
+		{Content: "a b", Query: "//["},
+	})
+	require.NoError(err)
+	require.Len(res, 2)
+	require.NoError(res[0].Err)
+	require.Equal(nodes.Array{}, res[0].UAST)
+	require.True(ErrInvalidQuery.Is(res[1].Err), "%v", res[1].Err)
+}
@@ -0,0 +1,116 @@
+package driver
+
+import (
+	"gopkg.in/bblfsh/sdk.v2/uast"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+// Range is a part of the source used to restrict the UAST returned by Parse. Either a byte range or a line range
+// should be set. If both are set, the byte range is used.
+type Range struct {
+	// Start and End are byte offsets of the range. End is exclusive.
+	Start, End uint32
+	// StartLine and EndLine are 1-based line numbers of the range. Both are inclusive.
+	StartLine, EndLine uint32
+}
+
+// IsZero checks if the range is not set.
+func (r Range) IsZero() bool {
+	return r == Range{}
+}
+
+func (r Range) byOffset() bool {
+	return r.End > r.Start
+}
+
+// overlaps checks if the node with given positions overlaps the range. The second value is false if the node has no
+// positions that can be compared with the range.
+func (r Range) overlaps(ps uast.Positions) (bool, bool) {
+	start := ps.Start()
+	if start == nil {
+		return false, false
+	}
+	end := ps.End()
+	if end == nil {
+		end = start
+	}
+	if r.byOffset() {
+		if !start.HasOffset() {
+			return false, false
+		}
+		s, e := start.Offset, end.Offset
+		if e <= s {
+			return s >= r.Start && s < r.End, true
+		}
+		return s < r.End && e > r.Start, true
+	}
+	if start.Line == 0 {
+		return false, false
+	}
+	s, e := start.Line, end.Line
+	if e < s {
+		e = s
+	}
+	return s <= r.EndLine && e >= r.StartLine, true
+}
+
+// FilterRange returns a copy of the tree that contains only nodes overlapping a given range. It returns nil if none
+// of the nodes overlap the range.
+//
+// Positioned nodes that do not overlap the range are removed from arrays, or set to nil in object fields. Nodes
+// without positions are kept, unless all positioned nodes inside them were removed. Thus, the tree is not changed
+// if it has no positions, as it is usually the case for ModeNative.
+func FilterRange(n nodes.Node, r Range) nodes.Node {
+	if r.IsZero() {
+		return n
+	}
+	out, keep, _ := filterRange(n, r)
+	if !keep {
+		return nil
+	}
+	return out
+}
+
+// filterRange filters the node and reports if it should be kept, and if it contains any positioned nodes.
+func filterRange(n nodes.Node, r Range) (nodes.Node, bool, bool) {
+	switch n := n.(type) {
+	case nodes.Object:
+		ok, known := r.overlaps(uast.PositionsOf(n))
+		if known && !ok {
+			return nil, false, true
+		}
+		out := make(nodes.Object, len(n))
+		var positioned, kept bool
+		for k, v := range n {
+			if k == uast.KeyPos {
+				out[k] = v
+				continue
+			}
+			v2, keep, pos := filterRange(v, r)
+			positioned = positioned || pos
+			if keep {
+				kept = kept || pos
+				out[k] = v2
+			} else {
+				out[k] = nil
+			}
+		}
+		if known {
+			return out, true, true
+		}
+		return out, !positioned || kept, positioned
+	case nodes.Array:
+		out := make(nodes.Array, 0, len(n))
+		var positioned, kept bool
+		for _, v := range n {
+			v2, keep, pos := filterRange(v, r)
+			positioned = positioned || pos
+			if keep {
+				kept = kept || pos
+				out = append(out, v2)
+			}
+		}
+		return out, !positioned || kept, positioned
+	}
+	return n, true, false
+}
@@ -0,0 +1,27 @@
+package driver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/bblfsh/sdk.v2/driver/manifest"
+	"gopkg.in/bblfsh/sdk.v2/uast"
+)
+
+func TestDriverParseRange(t *testing.T) {
+	require := require.New(t)
+
+	m := &manifest.Manifest{Language: "fixture"}
+	d, err := NewDriverFrom(treeNative{}, m, Transforms{})
+	require.NoError(err)
+
+	ctx := context.Background()
+	ast, err := d.Parse(ctx, "a b", &ParseOptions{Mode: ModeAnnotated, Range: Range{Start: 2, End: 3}})
+	require.NoError(err)
+	require.Equal([]string{"b"}, uast.Tokens(ast))
+
+	ast, err = d.Parse(ctx, "a b", &ParseOptions{Mode: ModeAnnotated, Range: Range{StartLine: 2, EndLine: 3}})
+	require.NoError(err)
+	require.Nil(ast)
+}
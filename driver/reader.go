@@ -0,0 +1,89 @@
+package driver
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"github.com/opentracing/opentracing-go"
+
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+// ReaderDriver is an optional interface for drivers that can read the source from a stream.
+type ReaderDriver interface {
+	Driver
+	// ParseReader is similar to Parse, but reads the source from r. The size is a hint for the length of the source
+	// in bytes; it is ignored if it is negative or zero.
+	ParseReader(ctx context.Context, r io.Reader, size int, opts *ParseOptions) (nodes.Node, error)
+}
+
+// ReaderNative is an optional interface for native drivers that can send the source to the native process directly
+// from a stream, without keeping a copy of it.
+type ReaderNative interface {
+	Native
+	// ParseReader is similar to Parse, but reads the source from r. The size is a hint, see ReaderDriver.
+	ParseReader(ctx context.Context, r io.Reader, size int) (nodes.Node, error)
+}
+
+// ParseReader parses the source read from r. If the driver implements ReaderDriver, the reader is passed to it,
+// otherwise the source is read into memory and passed to Parse. The size is a hint, see ReaderDriver.
+func ParseReader(ctx context.Context, d Driver, r io.Reader, size int, opts *ParseOptions) (nodes.Node, error) {
+	if rd, ok := d.(ReaderDriver); ok {
+		return rd.ParseReader(ctx, r, size, opts)
+	}
+	src, err := ReadSource(r, size)
+	if err != nil {
+		return nil, err
+	}
+	return d.Parse(ctx, src, opts)
+}
+
+// ReadSource reads the whole source from r. If the size hint is correct, the source is copied only once.
+func ReadSource(r io.Reader, size int) (string, error) {
+	var buf strings.Builder
+	if size > 0 {
+		// one more byte to detect EOF without growing the buffer
+		buf.Grow(size + 1)
+	}
+	// strings.Builder does not implement io.ReaderFrom, thus the data is copied by chunks
+	_, err := io.Copy(&buf, r)
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+var _ ReaderDriver = (*driverImpl)(nil)
+
+// ParseReader implements ReaderDriver. The source is passed to the native driver as a stream only if the native
+// driver implements ReaderNative, the native AST is requested without language options and the charset is set
+// to UTF-8, since all other requests need the source to transform the tree.
+func (d *driverImpl) ParseReader(rctx context.Context, r io.Reader, size int, opts *ParseOptions) (nodes.Node, error) {
+	rn, ok := d.d.(ReaderNative)
+	if !ok || opts == nil || opts.Mode != ModeNative || opts.Charset != CharsetUTF8 || !opts.Options.IsZero() {
+		src, err := ReadSource(r, size)
+		if err != nil {
+			return nil, err
+		}
+		return d.Parse(rctx, src, opts)
+	}
+	sp, ctx := opentracing.StartSpanFromContext(rctx, "bblfsh.driver.ParseReader")
+	defer sp.Finish()
+
+	ctx = withMetadata(ctx, opts.Filename, opts.Metadata)
+	var ast nodes.Node
+	err := d.native(ctx, func(ctx context.Context) (err error) {
+		ast, err = rn.ParseReader(ctx, r, size)
+		return err
+	})
+	if ErrStageTimeout.Is(err) {
+		return nil, err
+	}
+	opts.Options = LanguageOptions{}
+	if err == nil && opts.Language == "" {
+		opts.Language = d.m.Language
+	}
+	ast, err = d.transform(ctx, opts.Mode, "", ast, err, nil)
+	return filterResult(ast, err, opts.Range, opts.Query, opts.Fields)
+}
@@ -0,0 +1,74 @@
+package driver
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/bblfsh/sdk.v2/driver/manifest"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+// readerNative is the same as echoNative, but records if the source was passed as a stream.
+type readerNative struct {
+	echoNative
+	streamed *bool
+}
+
+func (n readerNative) ParseReader(ctx context.Context, r io.Reader, size int) (nodes.Node, error) {
+	*n.streamed = true
+	src, err := ReadSource(r, size)
+	if err != nil {
+		return nil, err
+	}
+	return n.Parse(ctx, src)
+}
+
+func TestDriverParseReader(t *testing.T) {
+	require := require.New(t)
+
+	var streamed bool
+	m := &manifest.Manifest{Language: "fixture"}
+	d, err := NewDriverFrom(readerNative{streamed: &streamed}, m, Transforms{})
+	require.NoError(err)
+
+	ctx := context.Background()
+	opts := &ParseOptions{Mode: ModeNative, Charset: CharsetUTF8}
+	ast, err := ParseReader(ctx, d, strings.NewReader("a b"), 3, opts)
+	require.NoError(err)
+	require.Equal(nodes.Object{"src": nodes.String("a b")}, ast)
+	require.Equal("fixture", opts.Language)
+	require.True(streamed)
+
+	// the charset must be detected, thus the source is read first
+	streamed = false
+	opts = &ParseOptions{Mode: ModeNative}
+	ast, err = ParseReader(ctx, d, strings.NewReader("a b"), 0, opts)
+	require.NoError(err)
+	require.Equal(nodes.Object{"src": nodes.String("a b")}, ast)
+	require.Equal(CharsetUTF8, opts.Charset)
+	require.False(streamed)
+
+	// built-in middlewares pass the stream to the driver
+	var metrics Metrics
+	md := WithMiddleware(d,
+		RecoverMiddleware(), LogMiddleware(&testLogger{}), MetricsMiddleware(&metrics), LimitMiddleware(1, 0),
+	)
+	opts = &ParseOptions{Mode: ModeNative, Charset: CharsetUTF8}
+	ast, err = ParseReader(ctx, md, strings.NewReader("a"), 1, opts)
+	require.NoError(err)
+	require.Equal(nodes.Object{"src": nodes.String("a")}, ast)
+	require.True(streamed)
+	require.Equal(uint64(1), metrics.Snapshot().Files)
+
+	// except for the cache, which needs the source to compute the key
+	streamed = false
+	md = WithMiddleware(d, CacheMiddleware(NewParseCache(10)))
+	opts = &ParseOptions{Mode: ModeNative, Charset: CharsetUTF8}
+	ast, err = ParseReader(ctx, md, strings.NewReader("a"), 1, opts)
+	require.NoError(err)
+	require.Equal(nodes.Object{"src": nodes.String("a")}, ast)
+	require.False(streamed)
+}
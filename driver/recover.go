@@ -0,0 +1,118 @@
+package driver
+
+import (
+	"context"
+
+	derrors "gopkg.in/bblfsh/sdk.v2/driver/errors"
+	"gopkg.in/bblfsh/sdk.v2/uast"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+// typeError is a UAST type of error nodes.
+var typeError = uast.TypeOf(uast.Error{})
+
+type syntaxErrorsKey struct{}
+
+// withSyntaxErrors attaches syntax errors of a partial parse to the context of the pipeline.
+func withSyntaxErrors(ctx context.Context, err error) context.Context {
+	return context.WithValue(ctx, syntaxErrorsKey{}, derrors.Diagnostics(err))
+}
+
+// syntaxErrorsFrom returns syntax errors attached to the context with withSyntaxErrors.
+func syntaxErrorsFrom(ctx context.Context) []*Diagnostic {
+	errs, _ := ctx.Value(syntaxErrorsKey{}).([]*Diagnostic)
+	return errs
+}
+
+// recovers checks if the pipeline can transform partial trees returned by the native parser.
+func (p *Pipeline) recovers() bool {
+	if _, err := p.index(StageRecover); err != nil {
+		return false
+	}
+	return p.Enabled(StageRecover)
+}
+
+// recoverStage creates a pipeline stage that replaces native nodes of given types with uast:Error nodes.
+func recoverStage(types []string) Stage {
+	set := make(map[string]struct{}, len(types))
+	for _, typ := range types {
+		set[typ] = struct{}{}
+	}
+	return Stage{
+		Name: StageRecover, Mode: ModePreprocessed, Incremental: true,
+		Do: func(ctx context.Context, st *FileState, nd nodes.Node) (nodes.Node, error) {
+			out, _ := recoverNodes(st, set, nd)
+			return out, nil
+		},
+	}
+}
+
+// recoverNodes replaces error nodes in the tree. It returns false if the tree was not changed.
+func recoverNodes(st *FileState, types map[string]struct{}, n nodes.Node) (nodes.Node, bool) {
+	if st.Reused(n) {
+		return n, false
+	}
+	switch n := n.(type) {
+	case nodes.Object:
+		if _, ok := types[uast.TypeOf(n)]; ok {
+			return errorNode(st, n), true
+		}
+		var out nodes.Object
+		for k, v := range n {
+			if v2, changed := recoverNodes(st, types, v); changed {
+				if out == nil {
+					out = n.CloneObject()
+				}
+				out[k] = v2
+			}
+		}
+		if out == nil {
+			return n, false
+		}
+		return out, true
+	case nodes.Array:
+		var out nodes.Array
+		for i, v := range n {
+			if v2, changed := recoverNodes(st, types, v); changed {
+				if out == nil {
+					out = n.CloneList()
+				}
+				out[i] = v2
+			}
+		}
+		if out == nil {
+			return n, false
+		}
+		return out, true
+	}
+	return n, false
+}
+
+// errorNode wraps the native node into uast:Error. The message is taken from the first syntax error that starts
+// inside the node. The end position is inclusive to match errors of zero-length nodes.
+func errorNode(st *FileState, n nodes.Object) nodes.Object {
+	obj := nodes.Object{
+		uast.KeyType: nodes.String(typeError),
+		"Message":    nodes.String(""),
+		"Native":     n,
+	}
+	ps := uast.PositionsOf(n)
+	if pos, ok := n[uast.KeyPos]; ok {
+		obj[uast.KeyPos] = pos
+	}
+	start, end := ps.Start(), ps.End()
+	if start == nil {
+		return obj
+	}
+	for _, e := range st.SyntaxErrors {
+		if e.Start == nil || e.Start.Offset < start.Offset {
+			continue
+		}
+		if end != nil && e.Start.Offset > end.Offset {
+			continue
+		}
+		obj["Message"] = nodes.String(e.Message)
+		break
+	}
+	return obj
+}
@@ -0,0 +1,43 @@
+package driver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/bblfsh/sdk.v2/driver/manifest"
+	"gopkg.in/bblfsh/sdk.v2/uast"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+func TestDriverParseRecover(t *testing.T) {
+	require := require.New(t)
+
+	m := &manifest.Manifest{Language: "fixture"}
+	d, err := NewDriverFrom(recoverNative{}, m, Transforms{
+		Namespace: "fixture", ErrorNodes: []string{"ERROR"},
+	})
+	require.NoError(err)
+
+	ctx := context.Background()
+	ast, err := d.Parse(ctx, "a ?b c", &ParseOptions{Mode: ModeSemantic})
+	require.True(ErrSyntax.Is(err), "%v", err)
+	body := ast.(nodes.Object)["body"].(nodes.Array)
+	require.Len(body, 3)
+	require.Equal("fixture:Word", uast.TypeOf(body[0]))
+	require.Equal("uast:Error", uast.TypeOf(body[1]))
+	require.Equal(nodes.String("unexpected ?b"), body[1].(nodes.Object)["Message"])
+	require.Equal(uint32(2), uast.PositionsOf(body[1]).Start().Offset)
+
+	var e uast.Error
+	err = uast.NodeAs(body[1], &e)
+	require.NoError(err)
+	require.Equal("unexpected ?b", e.Message)
+
+	// partial trees are not transformed without recovery
+	d, err = NewDriverFrom(recoverNative{}, m, Transforms{Namespace: "fixture"})
+	require.NoError(err)
+	ast, err = d.Parse(ctx, "a ?b", &ParseOptions{Mode: ModeSemantic})
+	require.True(ErrSyntax.Is(err), "%v", err)
+	require.Equal("File", uast.TypeOf(ast))
+}
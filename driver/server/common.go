@@ -21,17 +21,70 @@ func Run(t driver.Transforms) {
 }
 
 // RunNative is like Run but allows to provide a custom driver native driver implementation.
+//
+// If the native driver is created with native.NewDriver and the manifest sets the pool size, the driver runs
+// a pool of native drivers with the same binary and encoding, configured from the manifest.
 func RunNative(d driver.Native, t driver.Transforms) {
 	m, err := manifest.Load(ManifestLocation)
 	if err != nil {
 		panic(err)
 	}
+	c := &nativeConfig{m: m}
+	nd, _ := d.(*native.Driver)
+	if nd != nil {
+		if err = c.apply(nd); err != nil {
+			panic(err)
+		}
+		if size := m.Runtime.PoolSize; size > 1 {
+			d = native.NewDriverPool(nd.Binary(), nd.Encoding(), size, func(d *native.Driver) {
+				_ = c.apply(d) // the configuration was already checked above
+			})
+		}
+	}
 	dr, err := driver.NewDriverFrom(d, m, t)
 	if err != nil {
 		panic(err)
 	}
 	s := NewServer(dr)
+	if nd != nil {
+		// log the output of the native driver with the server logger
+		s.onLogger = append(s.onLogger, func(l Logger) {
+			c.logger = l
+			nd.SetLogger(l)
+		})
+		s.onMetrics = append(s.onMetrics, func(m *Metrics) {
+			c.metrics = m
+			nd.SetMetrics(m.Native())
+		})
+	}
 	if err := s.Start(); err != nil {
 		panic(err)
 	}
 }
+
+// nativeConfig configures native drivers from the runtime section of the manifest, and with the logger and
+// the metrics of the server, once they are initialized.
+type nativeConfig struct {
+	m       *manifest.Manifest
+	logger  Logger
+	metrics *Metrics
+}
+
+func (c *nativeConfig) apply(d *native.Driver) error {
+	if f := c.m.Runtime.NativeFormat; f != "" {
+		if err := d.SetFormat(native.Format(f)); err != nil {
+			return err
+		}
+	}
+	d.SetWarmUp(c.m.Runtime.WarmUp...)
+	if c.m.Runtime.Handshake {
+		d.SetHandshake(true)
+	}
+	if c.logger != nil {
+		d.SetLogger(c.logger)
+	}
+	if c.metrics != nil {
+		d.SetMetrics(c.metrics.Native())
+	}
+	return nil
+}
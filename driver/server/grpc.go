@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	protocol1 "gopkg.in/bblfsh/sdk.v1/protocol"
 	"gopkg.in/bblfsh/sdk.v2/driver"
 	"gopkg.in/bblfsh/sdk.v2/driver/manifest"
@@ -24,16 +25,35 @@ func NewGRPCServer(drv driver.DriverModule, opts ...grpc.ServerOption) *grpc.Ser
 
 // NewGRPCServerCustom is the same as NewGRPCServer, but it won't include any options except the ones that were passed.
 func NewGRPCServerCustom(drv driver.DriverModule, opts ...grpc.ServerOption) *grpc.Server {
+	return newGRPCServer(drv, protocol2.ServiceOptions{}, opts...)
+}
+
+// NewGRPCServerWith is the same as NewGRPCServer, but allows to configure the driver service.
+func NewGRPCServerWith(drv driver.DriverModule, sopts protocol2.ServiceOptions, opts ...grpc.ServerOption) *grpc.Server {
+	opts = append(opts, protocol2.ServerOptions()...)
+	return newGRPCServer(drv, sopts, opts...)
+}
+
+func newGRPCServer(drv driver.DriverModule, sopts protocol2.ServiceOptions, opts ...grpc.ServerOption) *grpc.Server {
 	srv := grpc.NewServer(opts...)
+	registerServices(srv, drv, sopts, &healthServer{d: drv})
+	return srv
+}
 
+// registerServices registers the driver services and the health service that reports their status.
+func registerServices(srv *grpc.Server, drv driver.DriverModule, sopts protocol2.ServiceOptions, h *healthServer) {
 	protocol1.DefaultService = service{drv}
 	protocol1.RegisterProtocolServiceServer(
 		srv,
 		protocol1.NewProtocolServiceServer(),
 	)
-	protocol2.RegisterDriver(srv, drv)
+	protocol2.RegisterDriverWith(srv, drv, sopts)
 
-	return srv
+	h.services = make(map[string]struct{})
+	for name := range srv.GetServiceInfo() {
+		h.services[name] = struct{}{}
+	}
+	healthpb.RegisterHealthServer(srv, h)
 }
 
 type service struct {
@@ -0,0 +1,41 @@
+package server
+
+import (
+	"sync/atomic"
+
+	xcontext "golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+
+	"gopkg.in/bblfsh/sdk.v2/driver"
+)
+
+// healthServer implements the standard gRPC health checking protocol. The driver is reported as serving if it was
+// started and it replies to pings, see driver.Pinger. Thus, the native driver is not serving while it is starting,
+// restarting or hung. All services of the gRPC server share the same status; other service names are not found.
+type healthServer struct {
+	d driver.DriverModule
+	// ready is set to non-zero once the driver is started; nil if the driver is started by the caller
+	ready *int32
+	// services is a set of known service names; nil if names are not checked
+	services map[string]struct{}
+}
+
+// Check implements healthpb.HealthServer.
+func (s *healthServer) Check(ctx xcontext.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	if _, ok := s.services[req.Service]; !ok && s.services != nil && req.Service != "" {
+		return nil, status.Errorf(codes.NotFound, "unknown service: %q", req.Service)
+	}
+	resp := &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_NOT_SERVING}
+	if s.ready != nil && atomic.LoadInt32(s.ready) == 0 {
+		return resp, nil
+	}
+	if p, ok := s.d.(driver.Pinger); ok {
+		if err := p.Ping(ctx); err != nil {
+			return resp, nil
+		}
+	}
+	resp.Status = healthpb.HealthCheckResponse_SERVING
+	return resp, nil
+}
@@ -0,0 +1,228 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"google.golang.org/grpc/peer"
+
+	"gopkg.in/bblfsh/sdk.v2/driver"
+	protocol2 "gopkg.in/bblfsh/sdk.v2/protocol"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+// HTTPParseRequest is a JSON body of the POST /parse request of the HTTP gateway.
+type HTTPParseRequest struct {
+	Content  string                 `json:"content"`
+	Language string                 `json:"language,omitempty"`
+	Filename string                 `json:"filename,omitempty"`
+	Mode     string                 `json:"mode,omitempty"` // see driver.ParseMode; semantic by default
+	Charset  string                 `json:"charset,omitempty"`
+	Options  driver.LanguageOptions `json:"options,omitempty"`
+	Metadata driver.Metadata        `json:"metadata,omitempty"`
+	Query    string                 `json:"query,omitempty"`
+	Fields   driver.FieldMask       `json:"fields,omitempty"`
+}
+
+// HTTPParseResponse is a JSON reply to the POST /parse request. Syntax errors and partial transformations are
+// reported in Errors together with the tree, the same way as in the gRPC protocol.
+type HTTPParseResponse struct {
+	Language string                  `json:"language,omitempty"`
+	Charset  string                  `json:"charset,omitempty"`
+	Options  *driver.LanguageOptions `json:"options,omitempty"`
+	UAST     nodes.Node              `json:"uast"`
+	Errors   []*driver.Diagnostic    `json:"errors,omitempty"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (r *HTTPParseResponse) UnmarshalJSON(data []byte) error {
+	type response HTTPParseResponse
+	var resp struct {
+		response
+		UAST interface{} `json:"uast"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return err
+	}
+	ast, err := nodes.ToNode(resp.UAST, nil)
+	if err != nil {
+		return err
+	}
+	*r = HTTPParseResponse(resp.response)
+	r.UAST = ast
+	return nil
+}
+
+// httpError is a JSON reply for failed requests.
+type httpError struct {
+	Error string `json:"error"`
+}
+
+// NewHTTPHandler creates an HTTP gateway for the driver for clients without gRPC support. It serves POST /parse
+// with HTTPParseRequest as a body and returns HTTPParseResponse, and the same operations over a WebSocket
+// on /ws (see WSRequest). Requests larger than maxSize bytes are rejected; zero disables the limit.
+// Browsers may only open the WebSocket from pages served by the same host.
+//
+// Requests are traced with the global tracer, and continue the trace propagated in the HTTP headers, if any.
+func NewHTTPHandler(d driver.Driver, maxSize int64) http.Handler {
+	return newHTTPHandler(d, maxSize, nil, nil)
+}
+
+// newHTTPHandler is the same as NewHTTPHandler, but also limits the rate of requests, if the limiter is set.
+// Requests above the limit fail with 429 Too Many Requests. Each message of a WebSocket counts as a request.
+// Pages from the given origins may open the WebSocket as well, see checkOrigin.
+func newHTTPHandler(d driver.Driver, maxSize int64, limit *protocol2.Limiter, origins []string) http.Handler {
+	p := httpParser{d: d, maxSize: maxSize, limit: limit}
+	mux := http.NewServeMux()
+	mux.Handle("/parse", p)
+	mux.Handle("/ws", newWSHandler(p, origins))
+	return mux
+}
+
+type httpParser struct {
+	d       driver.Driver
+	maxSize int64
+	limit   *protocol2.Limiter // nil if rate limits are disabled
+}
+
+// limited takes the request from the rate limits and returns the delay after which it may succeed, if it is above
+// the limit.
+func (h httpParser) limited(r *http.Request) time.Duration {
+	if h.limit == nil {
+		return 0
+	}
+	return h.limit.Take(clientContext(r), 1)
+}
+
+// clientContext returns the context of the request with the address of the client, which identifies the client
+// for rate limits, unless it was authenticated.
+func clientContext(r *http.Request) context.Context {
+	ctx := r.Context()
+	if addr, err := net.ResolveTCPAddr("tcp", r.RemoteAddr); err == nil {
+		ctx = peer.NewContext(ctx, &peer.Peer{Addr: addr})
+	}
+	return ctx
+}
+
+// retryAfter formats the delay as a value of the Retry-After header, in whole seconds.
+func retryAfter(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+// ServeHTTP implements http.Handler.
+func (h httpParser) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	sp := opentracing.GlobalTracer().StartSpan("bblfsh.http.Parse", ext.RPCServerOption(spanContext(r)))
+	defer sp.Finish()
+	ext.HTTPMethod.Set(sp, r.Method)
+	ext.HTTPUrl.Set(sp, r.URL.Path)
+	ctx := opentracing.ContextWithSpan(r.Context(), sp)
+	w = &statusWriter{ResponseWriter: w, sp: sp}
+
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeJSON(w, http.StatusMethodNotAllowed, httpError{Error: "only POST is allowed"})
+		return
+	}
+	if d := h.limited(r); d > 0 {
+		w.Header().Set("Retry-After", retryAfter(d))
+		writeJSON(w, http.StatusTooManyRequests, httpError{Error: protocol2.ErrRateLimited.New(d).Error()})
+		return
+	}
+	body := r.Body
+	if h.maxSize > 0 {
+		body = http.MaxBytesReader(w, body, h.maxSize)
+	}
+	var req HTTPParseRequest
+	if err := json.NewDecoder(body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, httpError{Error: fmt.Sprintf("invalid request: %v", err)})
+		return
+	}
+	resp, code, err := h.parse(ctx, &req)
+	if err != nil {
+		writeJSON(w, code, httpError{Error: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// parse runs the request and returns the response, or an error with the HTTP status code for it.
+func (h httpParser) parse(ctx context.Context, req *HTTPParseRequest) (*HTTPParseResponse, int, error) {
+	opts := &driver.ParseOptions{
+		Language: req.Language, Filename: req.Filename, Charset: req.Charset,
+		Options: req.Options, Metadata: req.Metadata, Query: req.Query, Fields: req.Fields,
+	}
+	if req.Mode != "" {
+		m, err := driver.ParseMode(req.Mode)
+		if err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		opts.Mode = m
+	}
+	ast, err := h.d.Parse(ctx, req.Content, opts)
+	if err != nil && !driver.ErrSyntax.Is(err) && !driver.ErrPartialTransform.Is(err) {
+		return nil, httpStatus(err), err
+	}
+	resp := &HTTPParseResponse{Language: opts.Language, Charset: opts.Charset, UAST: ast}
+	if !opts.Options.IsZero() {
+		resp.Options = &opts.Options
+	}
+	if err != nil {
+		resp.Errors = driver.Diagnostics(err)
+	}
+	return resp, 0, nil
+}
+
+// httpStatus returns an HTTP status code for the parse failure.
+func httpStatus(err error) int {
+	switch {
+	case driver.ErrUnknownCharset.Is(err), driver.ErrModeNotSupported.Is(err), driver.ErrInvalidQuery.Is(err):
+		return http.StatusBadRequest
+	case driver.ErrOverloaded.Is(err):
+		return http.StatusServiceUnavailable
+	case driver.ErrStageTimeout.Is(err):
+		return http.StatusGatewayTimeout
+	case driver.ErrResourceExhausted.Is(err):
+		return http.StatusRequestEntityTooLarge
+	case driver.ErrTransformFailure.Is(err):
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusInternalServerError
+}
+
+// spanContext extracts the trace context propagated in the headers of the request. It returns nil if there is none.
+func spanContext(r *http.Request) opentracing.SpanContext {
+	sc, err := opentracing.GlobalTracer().Extract(opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(r.Header))
+	if err != nil {
+		return nil
+	}
+	return sc
+}
+
+// statusWriter records the status code of the response on the span.
+type statusWriter struct {
+	http.ResponseWriter
+	sp opentracing.Span
+}
+
+// WriteHeader implements http.ResponseWriter.
+func (w *statusWriter) WriteHeader(code int) {
+	ext.HTTPStatusCode.Set(w.sp, uint16(code))
+	if code >= http.StatusInternalServerError {
+		ext.Error.Set(w.sp, true)
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
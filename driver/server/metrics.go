@@ -0,0 +1,252 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+
+	"gopkg.in/bblfsh/sdk.v2/driver"
+	"gopkg.in/bblfsh/sdk.v2/driver/native"
+	protocol2 "gopkg.in/bblfsh/sdk.v2/protocol"
+)
+
+var (
+	// latencyBuckets are upper bounds of histogram buckets for durations, in seconds.
+	latencyBuckets = []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30}
+	// sizeBuckets are upper bounds of histogram buckets for response sizes, in bytes.
+	sizeBuckets = []float64{1 << 10, 10 << 10, 100 << 10, 1 << 20, 10 << 20, 100 << 20}
+)
+
+// stageEncode is a name of the stage that encodes the tree for the response.
+const stageEncode = "encode"
+
+// Metrics collects request counts, latencies by stage, response sizes and restarts of the native driver, and
+// serves them in the Prometheus text format. It is safe for concurrent use. See NewMetrics.
+type Metrics struct {
+	mu       sync.Mutex
+	requests map[requestKey]uint64
+	latency  *histogram
+	stages   map[string]*histogram
+	sizes    *histogram
+	restarts uint64
+	failures map[string]uint64 // failures of the native driver by kind
+}
+
+type requestKey struct {
+	mode driver.Mode
+	code codes.Code
+}
+
+var (
+	_ protocol2.ServiceMetrics = (*Metrics)(nil)
+	_ driver.StageObserver     = (*Metrics)(nil)
+	_ http.Handler             = (*Metrics)(nil)
+)
+
+// NewMetrics creates an empty set of metrics.
+func NewMetrics() *Metrics {
+	return &Metrics{
+		requests: make(map[requestKey]uint64),
+		latency:  newHistogram(latencyBuckets),
+		stages:   make(map[string]*histogram),
+		sizes:    newHistogram(sizeBuckets),
+		failures: make(map[string]uint64),
+	}
+}
+
+// RequestDone implements protocol.ServiceMetrics.
+func (m *Metrics) RequestDone(mode driver.Mode, code codes.Code, d time.Duration) {
+	if mode == 0 {
+		mode = driver.ModeDefault
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests[requestKey{mode: mode, code: code}]++
+	m.latency.observe(d.Seconds())
+}
+
+// Encoded implements protocol.ServiceMetrics.
+func (m *Metrics) Encoded(d time.Duration, size int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stage(stageEncode).observe(d.Seconds())
+	m.sizes.observe(float64(size))
+}
+
+// StageDone implements driver.StageObserver.
+func (m *Metrics) StageDone(stage string, d time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stage(stage).observe(d.Seconds())
+}
+
+// stage returns a histogram for the stage. It must be called with the lock held.
+func (m *Metrics) stage(name string) *histogram {
+	h := m.stages[name]
+	if h == nil {
+		h = newHistogram(latencyBuckets)
+		m.stages[name] = h
+	}
+	return h
+}
+
+// Native returns an instrumentation interface for the native driver that records restarts and failures.
+// See native.Driver.SetMetrics.
+func (m *Metrics) Native() native.Metrics {
+	return nativeMetrics{m}
+}
+
+type nativeMetrics struct {
+	m *Metrics
+}
+
+// RequestDone implements native.Metrics.
+func (n nativeMetrics) RequestDone(st native.RequestStats) {
+	if st.Failure == "" {
+		return
+	}
+	n.m.mu.Lock()
+	defer n.m.mu.Unlock()
+	n.m.failures[st.Failure]++
+}
+
+// Restarted implements native.Metrics.
+func (n nativeMetrics) Restarted() {
+	n.m.mu.Lock()
+	defer n.m.mu.Unlock()
+	n.m.restarts++
+}
+
+// ServeHTTP implements http.Handler. It writes all metrics in the Prometheus text format.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	m.WriteTo(w)
+}
+
+// WriteTo writes all metrics in the Prometheus text format.
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	pw := &promWriter{w: w}
+
+	pw.header("bblfsh_driver_requests_total", "counter", "Number of parse requests by transformation mode and status code.")
+	keys := make([]requestKey, 0, len(m.requests))
+	for k := range m.requests {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].mode != keys[j].mode {
+			return keys[i].mode < keys[j].mode
+		}
+		return keys[i].code < keys[j].code
+	})
+	for _, k := range keys {
+		pw.value("bblfsh_driver_requests_total", fmt.Sprintf("mode=%q,code=%q", k.mode.String(), k.code.String()), float64(m.requests[k]))
+	}
+
+	pw.header("bblfsh_driver_request_duration_seconds", "histogram", "Duration of parse requests.")
+	m.latency.write(pw, "bblfsh_driver_request_duration_seconds", "")
+
+	pw.header("bblfsh_driver_stage_duration_seconds", "histogram", "Duration of the native driver, transformation stages and encoding.")
+	stages := make([]string, 0, len(m.stages))
+	for s := range m.stages {
+		stages = append(stages, s)
+	}
+	sort.Strings(stages)
+	for _, s := range stages {
+		m.stages[s].write(pw, "bblfsh_driver_stage_duration_seconds", fmt.Sprintf("stage=%q", s))
+	}
+
+	pw.header("bblfsh_driver_response_size_bytes", "histogram", "Size of encoded trees in responses.")
+	m.sizes.write(pw, "bblfsh_driver_response_size_bytes", "")
+
+	pw.header("bblfsh_driver_native_restarts_total", "counter", "Number of restarts of the native driver.")
+	pw.value("bblfsh_driver_native_restarts_total", "", float64(m.restarts))
+
+	pw.header("bblfsh_driver_native_failures_total", "counter", "Number of failed requests of the native driver by kind.")
+	kinds := make([]string, 0, len(m.failures))
+	for k := range m.failures {
+		kinds = append(kinds, k)
+	}
+	sort.Strings(kinds)
+	for _, k := range kinds {
+		pw.value("bblfsh_driver_native_failures_total", fmt.Sprintf("kind=%q", k), float64(m.failures[k]))
+	}
+	return pw.n, pw.err
+}
+
+// histogram counts observations in buckets with given upper bounds.
+type histogram struct {
+	bounds []float64
+	counts []uint64 // the last one is for values above all bounds
+	sum    float64
+}
+
+func newHistogram(bounds []float64) *histogram {
+	return &histogram{bounds: bounds, counts: make([]uint64, len(bounds)+1)}
+}
+
+func (h *histogram) observe(v float64) {
+	i := sort.SearchFloat64s(h.bounds, v)
+	h.counts[i]++
+	h.sum += v
+}
+
+// write writes cumulative buckets, the sum and the count of the histogram with extra labels.
+func (h *histogram) write(pw *promWriter, name, labels string) {
+	if labels != "" {
+		labels += ","
+	}
+	var total uint64
+	for i, c := range h.counts {
+		total += c
+		le := "+Inf"
+		if i < len(h.bounds) {
+			le = strconv.FormatFloat(h.bounds[i], 'g', -1, 64)
+		}
+		pw.value(name+"_bucket", labels+fmt.Sprintf("le=%q", le), float64(total))
+	}
+	labels = trimComma(labels)
+	pw.value(name+"_sum", labels, h.sum)
+	pw.value(name+"_count", labels, float64(total))
+}
+
+func trimComma(s string) string {
+	if n := len(s); n != 0 && s[n-1] == ',' {
+		return s[:n-1]
+	}
+	return s
+}
+
+// promWriter writes lines of the Prometheus text format and keeps the first error.
+type promWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (pw *promWriter) printf(format string, args ...interface{}) {
+	if pw.err != nil {
+		return
+	}
+	n, err := fmt.Fprintf(pw.w, format, args...)
+	pw.n += int64(n)
+	pw.err = err
+}
+
+func (pw *promWriter) header(name, typ, help string) {
+	pw.printf("# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+func (pw *promWriter) value(name, labels string, v float64) {
+	if labels != "" {
+		labels = "{" + labels + "}"
+	}
+	pw.printf("%s%s %s\n", name, labels, strconv.FormatFloat(v, 'g', -1, 64))
+}
@@ -0,0 +1,90 @@
+package server
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+
+	protocol2 "gopkg.in/bblfsh/sdk.v2/protocol"
+)
+
+// loadTLSConfig loads the server certificate and key. If clientCA is set, clients must present a certificate signed
+// by one of the authorities in the file (mutual TLS).
+func loadTLSConfig(cert, key, clientCA string) (*tls.Config, error) {
+	pair, err := tls.LoadX509KeyPair(cert, key)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load TLS certificate: %v", err)
+	}
+	cfg := &tls.Config{Certificates: []tls.Certificate{pair}, MinVersion: tls.VersionTLS12}
+	if clientCA == "" {
+		return cfg, nil
+	}
+	data, err := ioutil.ReadFile(clientCA)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read client CA: %v", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in %s", clientCA)
+	}
+	cfg.ClientCAs = pool
+	cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	return cfg, nil
+}
+
+// loadTokens reads bearer tokens from a file, one per line. Empty lines and lines starting with # are ignored.
+func loadTokens(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var tokens []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		tokens = append(tokens, line)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	} else if len(tokens) == 0 {
+		return nil, fmt.Errorf("no tokens found in %s", path)
+	}
+	return tokens, nil
+}
+
+// wsTokenParam is the query parameter with the bearer token of WebSocket requests. Browsers cannot set
+// the Authorization header when opening a WebSocket.
+const wsTokenParam = "access_token"
+
+// requireToken wraps the HTTP handler to require one of the bearer tokens in the Authorization header. WebSocket
+// requests may pass the token in the wsTokenParam query parameter instead. The token is set in the context of
+// the request, thus it identifies the client for rate limits.
+func requireToken(h http.Handler, tokens []string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if tok := r.URL.Query().Get(wsTokenParam); auth == "" && tok != "" && isWebSocket(r) {
+			auth = "Bearer " + tok
+		}
+		if !protocol2.ValidToken(tokens, auth) {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeJSON(w, http.StatusUnauthorized, httpError{Error: protocol2.ErrUnauthenticated.New().Error()})
+			return
+		}
+		tok := strings.TrimPrefix(auth, "Bearer ")
+		h.ServeHTTP(w, r.WithContext(protocol2.ContextWithToken(r.Context(), tok)))
+	})
+}
+
+// isWebSocket checks if the request opens a WebSocket connection.
+func isWebSocket(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Upgrade"), "websocket")
+}
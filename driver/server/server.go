@@ -1,16 +1,23 @@
 package server
 
 import (
+	"crypto/tls"
 	"flag"
+	"fmt"
 	"io"
 	"net"
+	"net/http"
 	"os"
+	"strings"
+	"sync/atomic"
 
 	jaegercfg "github.com/uber/jaeger-client-go/config"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 
 	cmdutil "gopkg.in/bblfsh/sdk.v2/cmd"
 	"gopkg.in/bblfsh/sdk.v2/driver"
+	protocol2 "gopkg.in/bblfsh/sdk.v2/protocol"
 	"gopkg.in/src-d/go-errors.v1"
 )
 
@@ -25,11 +32,25 @@ var (
 )
 
 var (
-	network        *string
-	address        *string
-	verbose        *string
-	maxMessageSize *int
-	logs           struct {
+	network            *string
+	address            *string
+	verbose            *string
+	maxMessageSize     *int
+	parseCache         *int
+	maxParses          *int
+	maxQueue           *int
+	stageTimeouts      *string
+	requireCompression *bool
+	httpAddress        *string
+	httpOrigins        *string
+	tlsCert            *string
+	tlsKey             *string
+	tlsClientCA        *string
+	authTokens         *string
+	rateLimit          *float64
+	clientRateLimit    *float64
+	metricsAddress     *string
+	logs               struct {
 		level  *string
 		format *string
 		fields *string
@@ -44,6 +65,19 @@ type Server struct {
 
 	d driver.DriverModule
 
+	ready int32 // set once the driver is started; see healthServer
+
+	tls    *tls.Config // nil if TLS is disabled
+	tokens []string    // bearer tokens accepted by the server; empty if authentication is disabled
+
+	metrics *Metrics           // nil if metrics are disabled
+	limiter *protocol2.Limiter // nil if rate limits are disabled
+
+	// onLogger is called with the logger once it is initialized
+	onLogger []func(l Logger)
+	// onMetrics is called with the metrics before the driver starts, if metrics are enabled
+	onMetrics []func(m *Metrics)
+
 	// closers is a list of things to be closed
 	// TODO: proper driver shutdown logic; it's unused right now
 	closers []io.Closer
@@ -56,24 +90,78 @@ func NewServer(d driver.DriverModule) *Server {
 
 // Start executes the binary driver and start to listen in the network and
 // address defined by the args.
+//
+// The server starts listening before the driver is started, and the health service reports it as not serving
+// until the driver is ready.
 func (s *Server) Start() error {
 	if err := s.initialize(); err != nil {
 		return err
 	}
 
+	l, err := net.Listen(*network, *address)
+	if err != nil {
+		return err
+	}
+
+	s.Logger.Infof("server listening in %s (%s)", *address, *network)
+	served := make(chan error, 1)
+	go func() {
+		served <- s.grpc.Serve(l)
+	}()
+
 	s.Logger.Debugf("executing native binary ...")
 	if err := s.d.Start(); err != nil {
+		s.grpc.Stop()
 		return err
 	}
+	atomic.StoreInt32(&s.ready, 1)
+	s.Logger.Debugf("driver is ready")
 
-	l, err := net.Listen(*network, *address)
-	if err != nil {
-		return err
+	if *httpAddress != "" {
+		go s.serveHTTP(*httpAddress)
+	}
+	if s.metrics != nil {
+		go s.serveMetrics(*metricsAddress)
 	}
 
-	s.Logger.Infof("server listening in %s (%s)", *address, *network)
+	return <-served
+}
+
+// serveHTTP runs the HTTP gateway. The gateway is optional, thus failures are only logged.
+func (s *Server) serveHTTP(addr string) {
+	s.Logger.Infof("http gateway listening in %s", addr)
+	var origins []string
+	for _, o := range strings.Split(*httpOrigins, ",") {
+		if o = strings.TrimSpace(o); o != "" {
+			origins = append(origins, o)
+		}
+	}
+	h := newHTTPHandler(s.d, int64(*maxMessageSize)*1024*1024, s.limiter, origins)
+	if len(s.tokens) != 0 {
+		h = requireToken(h, s.tokens)
+	}
+	srv := &http.Server{Addr: addr, Handler: h, TLSConfig: s.tls}
+	var err error
+	if s.tls != nil {
+		// certificates are already loaded into the config
+		err = srv.ListenAndServeTLS("", "")
+	} else {
+		err = srv.ListenAndServe()
+	}
+	if err != nil {
+		s.Logger.Errorf("http gateway failed: %v", err)
+	}
+}
 
-	return s.grpc.Serve(l)
+// serveMetrics serves the metrics in the Prometheus format on /metrics. The endpoint is optional, thus failures
+// are only logged.
+func (s *Server) serveMetrics(addr string) {
+	s.Logger.Infof("metrics listening in %s", addr)
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", s.metrics)
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		s.Logger.Errorf("metrics endpoint failed: %v", err)
+	}
 }
 
 func (s *Server) initialize() error {
@@ -104,7 +192,108 @@ func (s *Server) initialize() error {
 		m.Version,
 		build,
 	)
-	s.grpc = NewGRPCServer(s.d, grpcOpts...)
+	if err := s.initializeTimeouts(*stageTimeouts); err != nil {
+		return err
+	}
+	if *metricsAddress != "" {
+		s.initializeMetrics()
+	}
+	mw := []driver.Middleware{driver.RecoverMiddleware(), driver.LogMiddleware(s.Logger)}
+	if *parseCache > 0 {
+		mw = append(mw, driver.CacheMiddleware(driver.NewParseCache(*parseCache)))
+	}
+	if *maxParses > 0 {
+		// cached results are returned without waiting in the queue
+		mw = append(mw, driver.LimitMiddleware(*maxParses, *maxQueue))
+	}
+	s.d = driver.WithMiddleware(s.d, mw...)
+	sopts, err := s.initializeGRPC()
+	if err != nil {
+		return err
+	}
+	grpcOpts = append(grpcOpts, sopts...)
+	sopt := protocol2.ServiceOptions{RequireCompression: *requireCompression}
+	if s.metrics != nil {
+		sopt.Metrics = s.metrics
+	}
+	s.grpc = grpc.NewServer(grpcOpts...)
+	registerServices(s.grpc, s.d, sopt, &healthServer{d: s.d, ready: &s.ready})
+	return nil
+}
+
+// initializeGRPC loads TLS certificates and authentication tokens, and returns gRPC options for them and for rate
+// limits, including the common options of the protocol.
+func (s *Server) initializeGRPC() ([]grpc.ServerOption, error) {
+	var opts []grpc.ServerOption
+	if *tlsCert != "" || *tlsKey != "" {
+		cfg, err := loadTLSConfig(*tlsCert, *tlsKey, *tlsClientCA)
+		if err != nil {
+			return nil, err
+		}
+		s.tls = cfg
+		opts = append(opts, grpc.Creds(credentials.NewTLS(cfg)))
+	} else if *tlsClientCA != "" {
+		return nil, fmt.Errorf("client CA requires a server certificate")
+	}
+	var (
+		unary  []grpc.UnaryServerInterceptor
+		stream []grpc.StreamServerInterceptor
+	)
+	if *authTokens != "" {
+		tokens, err := loadTokens(*authTokens)
+		if err != nil {
+			return nil, err
+		}
+		if s.tls == nil {
+			s.Logger.Warningf("authentication is enabled without TLS; tokens are sent in plain text")
+		}
+		s.tokens = tokens
+		u, st := protocol2.TokenAuth(tokens...)
+		unary, stream = append(unary, u), append(stream, st)
+	}
+	if *rateLimit > 0 || *clientRateLimit > 0 {
+		// shared with the HTTP gateway; runs after authentication, which sets validated tokens that identify clients
+		s.limiter = protocol2.NewLimiter(protocol2.RateLimits{
+			Global:    protocol2.RateLimit{Rate: *rateLimit},
+			PerClient: protocol2.RateLimit{Rate: *clientRateLimit},
+		})
+		u, st := s.limiter.Interceptors()
+		unary, stream = append(unary, u), append(stream, st)
+	}
+	return append(opts, protocol2.ServerOptionsWith(unary, stream)...), nil
+}
+
+// initializeMetrics creates metrics and sets them as a stage observer of the driver.
+func (s *Server) initializeMetrics() {
+	s.metrics = NewMetrics()
+	if od, ok := s.d.(driver.ObservedDriver); ok {
+		od.SetStageObserver(s.metrics)
+	} else {
+		s.Logger.Warningf("driver does not report stage durations")
+	}
+	for _, fnc := range s.onMetrics {
+		fnc(s.metrics)
+	}
+}
+
+// initializeTimeouts sets stage timeouts from the flag. They override timeouts from the manifest.
+func (s *Server) initializeTimeouts(list string) error {
+	if list == "" {
+		return nil
+	}
+	timeouts, err := driver.ParseTimeouts(list)
+	if err != nil {
+		return err
+	}
+	td, ok := s.d.(driver.TimeoutDriver)
+	if !ok {
+		return fmt.Errorf("driver does not support stage timeouts")
+	}
+	for stage, d := range timeouts {
+		if err := td.SetTimeout(stage, d); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -120,6 +309,20 @@ func (s *Server) initializeFlags() {
 	network = cmd.String("network", defaultNetwork, "network type: tcp, tcp4, tcp6, unix or unixpacket.")
 	address = cmd.String("address", defaultAddress, "address to listen.")
 	maxMessageSize = cmdutil.FlagMaxGRPCMsgSizeMB(cmd)
+	parseCache = cmd.Int("parse-cache", 0, "number of parse results to cache; zero disables the cache.")
+	maxParses = cmd.Int("max-parses", 0, "maximal number of concurrent parse requests; zero disables the limit.")
+	maxQueue = cmd.Int("max-queue", 0, "maximal number of parse requests waiting for the limit; others fail as overloaded.")
+	stageTimeouts = cmd.String("stage-timeouts", "", "timeouts of driver stages, for example: native=10s,semantic=5s.")
+	httpAddress = cmd.String("http-address", "", "address to serve the HTTP/JSON gateway; empty disables the gateway.")
+	httpOrigins = cmd.String("http-origins", "", "comma-separated origins of web pages allowed to use the WebSocket of the HTTP gateway, or * for any; pages of the gateway host are always allowed.")
+	tlsCert = cmd.String("tls-cert", "", "path to the TLS certificate of the server; enables TLS.")
+	tlsKey = cmd.String("tls-key", "", "path to the private key of the TLS certificate.")
+	tlsClientCA = cmd.String("tls-client-ca", "", "path to the CA certificates for client certificates; enables mutual TLS.")
+	authTokens = cmd.String("auth-tokens", "", "path to a file with bearer tokens accepted by the server, one per line; enables authentication.")
+	rateLimit = cmd.Float64("rate-limit", 0, "maximal rate of requests per second of all clients; zero disables the limit.")
+	clientRateLimit = cmd.Float64("client-rate-limit", 0, "maximal rate of requests per second of each client; zero disables the limit.")
+	metricsAddress = cmd.String("metrics-address", "", "address to serve Prometheus metrics on /metrics; empty disables metrics.")
+	requireCompression = cmd.Bool("require-compression", false, "reject requests that are not compressed with gzip or other registered compressors.")
 
 	logs.level = cmd.String("log-level", defaultVerbose, "log level: panic, fatal, error, warning, info, debug.")
 	logs.format = cmd.String("log-format", defaultFormat, "format of the logs: text or json.")
@@ -140,6 +343,9 @@ func (s *Server) initializeLogger() error {
 	if err != nil {
 		return ErrInvalidLogger.Wrap(err)
 	}
+	for _, fnc := range s.onLogger {
+		fnc(s.Logger)
+	}
 
 	return nil
 }
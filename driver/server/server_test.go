@@ -1,14 +1,51 @@
 package server
 
 import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	protocol1 "gopkg.in/bblfsh/sdk.v1/protocol"
 
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 	"gopkg.in/bblfsh/sdk.v2/driver"
+	derrors "gopkg.in/bblfsh/sdk.v2/driver/errors"
 	"gopkg.in/bblfsh/sdk.v2/driver/manifest"
 	"gopkg.in/bblfsh/sdk.v2/driver/native"
+	protocol2 "gopkg.in/bblfsh/sdk.v2/protocol"
+	"gopkg.in/bblfsh/sdk.v2/uast"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
 )
 
 func init() {
@@ -154,3 +191,1677 @@ func TestDriverParserVersion(t *testing.T) {
 	require.Equal(v.Version, "42")
 	require.Equal(v.Build.String(), "2015-10-21 04:29:00 +0000 UTC")
 }
+
+func TestDriverHealth(t *testing.T) {
+	require := require.New(t)
+
+	d, err := newDriver("")
+	require.NoError(err)
+
+	h := healthServer{d: d.d}
+	ctx := context.Background()
+
+	// not started yet
+	r, err := h.Check(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(err)
+	require.Equal(healthpb.HealthCheckResponse_NOT_SERVING, r.Status)
+
+	err = d.d.Start()
+	require.NoError(err)
+
+	r, err = h.Check(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(err)
+	require.Equal(healthpb.HealthCheckResponse_SERVING, r.Status)
+
+	err = d.d.Close()
+	require.NoError(err)
+}
+
+func TestDriverParseFiles(t *testing.T) {
+	require := require.New(t)
+
+	d, err := newDriver("")
+	require.NoError(err)
+	err = d.d.Start()
+	require.NoError(err)
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(err)
+	srv := NewGRPCServer(d.d)
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	require.NoError(err)
+	defer conn.Close()
+	cli := protocol2.AsDriver(conn)
+
+	ctx := context.Background()
+	files := []driver.File{
+		{Content: "foo"},
+		{Content: "bar", Language: "other"},
+	}
+	res, err := cli.ParseFiles(ctx, driver.ModeNative, files)
+	require.NoError(err)
+	require.Len(res, 2)
+	for i, f := range files {
+		exp, err := d.d.Parse(ctx, f.Content, &driver.ParseOptions{Mode: driver.ModeNative})
+		require.NoError(err)
+		require.NoError(res[i].Err)
+		require.Equal(exp, res[i].UAST)
+	}
+	require.Equal("fixture", res[0].Language)
+	require.Equal("other", res[1].Language)
+
+	// failures are reported for each file
+	err = d.d.Close()
+	require.NoError(err)
+	res, err = cli.ParseFiles(ctx, driver.ModeNative, files)
+	require.NoError(err)
+	require.Len(res, 2)
+	for _, r := range res {
+		require.True(driver.ErrDriverFailure.Is(r.Err), "%v", r.Err)
+	}
+}
+
+func TestDriverParseOptions(t *testing.T) {
+	require := require.New(t)
+
+	m, err := manifest.Load(ManifestLocation)
+	require.NoError(err)
+	nd := native.NewDriverAt("../native/internal/simple/mock", native.UTF8).(*native.Driver)
+	nd.SetHandshake(true)
+	d, err := driver.NewDriverFrom(nd, m, driver.Transforms{})
+	require.NoError(err)
+	err = d.Start()
+	require.NoError(err)
+	defer d.Close()
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(err)
+	srv := NewGRPCServer(d)
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	require.NoError(err)
+	defer conn.Close()
+	cli := protocol2.AsDriver(conn)
+
+	ctx := context.Background()
+	opts := &driver.ParseOptions{Mode: driver.ModeNative, Options: driver.LanguageOptions{
+		Dialects: []string{"jsx"}, Strict: true,
+	}}
+	_, err = cli.Parse(ctx, "foo", opts)
+	require.NoError(err)
+	// the mock driver detects the version
+	exp := driver.LanguageOptions{Version: "1", Dialects: []string{"jsx"}, Strict: true}
+	require.Equal(exp, opts.Options)
+
+	res, err := cli.ParseFiles(ctx, driver.ModeNative, []driver.File{
+		{Content: "foo", Options: driver.LanguageOptions{Version: "2"}},
+		{Content: "bar"},
+	})
+	require.NoError(err)
+	require.Len(res, 2)
+	require.NoError(res[0].Err)
+	require.Equal(driver.LanguageOptions{Version: "2"}, res[0].Options)
+	require.NoError(res[1].Err)
+	require.Equal(driver.LanguageOptions{Version: "1"}, res[1].Options)
+}
+
+func TestDriverCapabilities(t *testing.T) {
+	require := require.New(t)
+
+	m, err := manifest.Load(ManifestLocation)
+	require.NoError(err)
+	m.LanguageVersions = manifest.LanguageVersions{
+		{Version: "2", Features: []manifest.SyntaxFeature{{Name: "bar", Supported: true}, {Name: "baz"}}},
+	}
+	nd := native.NewDriverAt("../native/internal/simple/mock", native.UTF8).(*native.Driver)
+	nd.SetHandshake(true)
+	d, err := driver.NewDriverFrom(nd, m, driver.Transforms{})
+	require.NoError(err)
+	err = d.Start()
+	require.NoError(err)
+	defer d.Close()
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(err)
+	srv := NewGRPCServer(d)
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	require.NoError(err)
+	defer conn.Close()
+	cli := protocol2.AsDriver(conn)
+
+	c, err := cli.Capabilities(context.Background())
+	require.NoError(err)
+	require.True(c.Options)
+	require.True(c.Incremental)
+	require.Contains(c.Encodings, string(native.UTF8))
+	for _, mode := range []driver.Mode{0, driver.ModeNative, driver.ModeSemantic, driver.ModeTokens} {
+		require.True(c.Supports(mode), "%v", mode)
+	}
+	require.Equal(m.LanguageVersions, c.Versions)
+}
+
+// treeNative returns the tree with tokens in the reverse order.
+type treeNative struct{}
+
+func (treeNative) Start() error { return nil }
+func (treeNative) Close() error { return nil }
+
+func (treeNative) Parse(ctx context.Context, src string) (nodes.Node, error) {
+	tok := func(s string, off uint32) nodes.Object {
+		return nodes.Object{
+			uast.KeyToken: nodes.String(s),
+			uast.KeyPos:   uast.Positions{uast.KeyStart: {Offset: off, Line: 1, Col: off + 1}}.ToObject(),
+		}
+	}
+	return nodes.Object{
+		"body": nodes.Array{tok("b", 2), tok("a", 0)},
+	}, nil
+}
+
+func TestDriverParseTokens(t *testing.T) {
+	require := require.New(t)
+
+	m, err := manifest.Load(ManifestLocation)
+	require.NoError(err)
+	ctx := context.Background()
+
+	// native drivers that return tokens directly
+	nd := native.NewDriverAt("../native/internal/simple/mock", native.UTF8).(*native.Driver)
+	nd.SetHandshake(true)
+	d, err := driver.NewDriverFrom(nd, m, driver.Transforms{})
+	require.NoError(err)
+	err = d.Start()
+	require.NoError(err)
+	defer d.Close()
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(err)
+	srv := NewGRPCServer(d)
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	require.NoError(err)
+	defer conn.Close()
+	cli := protocol2.AsDriver(conn)
+
+	toks, err := cli.Parse(ctx, "foo bar", &driver.ParseOptions{Mode: driver.ModeTokens})
+	require.NoError(err)
+	require.Equal([]string{"foo", "bar"}, uast.Tokens(toks))
+
+	res, err := cli.ParseFiles(ctx, driver.ModeTokens, []driver.File{{Content: "x y z"}})
+	require.NoError(err)
+	require.Len(res, 1)
+	require.NoError(res[0].Err)
+	require.Equal([]string{"x", "y", "z"}, uast.Tokens(res[0].UAST))
+}
+
+func TestDriverParseRange(t *testing.T) {
+	require := require.New(t)
+
+	m, err := manifest.Load(ManifestLocation)
+	require.NoError(err)
+	ctx := context.Background()
+
+	nd := native.NewDriverAt("../native/internal/simple/mock", native.UTF8).(*native.Driver)
+	nd.SetHandshake(true)
+	d, err := driver.NewDriverFrom(nd, m, driver.Transforms{})
+	require.NoError(err)
+	err = d.Start()
+	require.NoError(err)
+	defer d.Close()
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(err)
+	srv := NewGRPCServer(d)
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	require.NoError(err)
+	defer conn.Close()
+	cli := protocol2.AsDriver(conn)
+
+	toks, err := cli.Parse(ctx, "foo bar baz", &driver.ParseOptions{
+		Mode: driver.ModeTokens, Range: driver.Range{Start: 5, End: 9},
+	})
+	require.NoError(err)
+	require.Equal([]string{"bar", "baz"}, uast.Tokens(toks))
+}
+
+func TestDriverParseFields(t *testing.T) {
+	require := require.New(t)
+
+	m := &manifest.Manifest{Language: "fixture"}
+	d, err := driver.NewDriverFrom(treeNative{}, m, driver.Transforms{})
+	require.NoError(err)
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(err)
+	srv := NewGRPCServer(d)
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	require.NoError(err)
+	defer conn.Close()
+	cli := protocol2.AsDriver(conn)
+
+	ctx := context.Background()
+	ast, err := cli.Parse(ctx, "a b", &driver.ParseOptions{
+		Mode: driver.ModeNative, Fields: driver.FieldMask{Exclude: []string{uast.KeyPos}},
+	})
+	require.NoError(err)
+	require.Equal(nodes.Object{"body": nodes.Array{
+		nodes.Object{uast.KeyToken: nodes.String("b")},
+		nodes.Object{uast.KeyToken: nodes.String("a")},
+	}}, ast)
+
+	res, err := cli.ParseFiles(ctx, driver.ModeNative, []driver.File{
+		{Content: "a b", Fields: driver.FieldMask{Include: []string{uast.KeyToken}}},
+		{Content: "a b", Fields: driver.FieldMask{Exclude: []string{"body"}}},
+	})
+	require.NoError(err)
+	require.Len(res, 2)
+	require.NoError(res[0].Err)
+	require.Equal(ast, res[0].UAST)
+	require.NoError(res[1].Err)
+	require.Equal(nodes.Object{}, res[1].UAST)
+}
+
+func TestDriverParseQuery(t *testing.T) {
+	require := require.New(t)
+
+	m := &manifest.Manifest{Language: "fixture"}
+	d, err := driver.NewDriverFrom(treeNative{}, m, driver.Transforms{})
+	require.NoError(err)
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(err)
+	srv := NewGRPCServer(d)
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	require.NoError(err)
+	defer conn.Close()
+	cli := protocol2.AsDriver(conn)
+
+	ctx := context.Background()
+	ast, err := cli.Parse(ctx, "a b", &driver.ParseOptions{
+		Mode: driver.ModeNative, Query: "//*[text() = 'b']",
+		Fields: driver.FieldMask{Exclude: []string{uast.KeyPos}},
+	})
+	require.NoError(err)
+	require.Equal(nodes.Array{nodes.Object{uast.KeyToken: nodes.String("b")}}, ast)
+
+	_, err = cli.Parse(ctx, "a b", &driver.ParseOptions{Query: "//["})
+	require.True(driver.ErrInvalidQuery.Is(err), "%v", err)
+
+	res, err := cli.ParseFiles(ctx, driver.ModeNative, []driver.File{
+		{Content: "a b", Query: "//*[text() = 'c']"},
+		{Content: "a b", Query: "//["},
+	})
+	require.NoError(err)
+	require.Len(res, 2)
+	require.NoError(res[0].Err)
+	require.Equal(nodes.Array{}, res[0].UAST)
+	require.True(driver.ErrInvalidQuery.Is(res[1].Err), "%v", res[1].Err)
+}
+
+func TestDriverParseIncremental(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.Background()
+
+	// drivers without incremental support parse the whole file again
+	sd, err := newDriver("")
+	require.NoError(err)
+	err = sd.d.Start()
+	require.NoError(err)
+	defer sd.d.Close()
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(err)
+	srv := NewGRPCServer(sd.d)
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	require.NoError(err)
+	defer conn.Close()
+	cli := protocol2.AsDriver(conn)
+
+	res, err := cli.ParseFiles(ctx, driver.ModeNative, []driver.File{{Content: "foo"}})
+	require.NoError(err)
+	r, err := driver.ParseIncremental(ctx, cli, res[0], []driver.TextEdit{{Start: 3, End: 3, Text: "bar"}})
+	require.NoError(err)
+	require.NoError(r.Err)
+	require.Equal(driver.ModeNative, r.Mode)
+	exp, err := sd.d.Parse(ctx, "foobar", &driver.ParseOptions{Mode: driver.ModeNative})
+	require.NoError(err)
+	require.Equal(exp, r.UAST)
+}
+
+// echoNative returns the source it received.
+type echoNative struct{}
+
+func (echoNative) Start() error { return nil }
+func (echoNative) Close() error { return nil }
+
+func (echoNative) Parse(ctx context.Context, src string) (nodes.Node, error) {
+	if src == "" {
+		return nil, errors.New("empty source")
+	}
+	return nodes.Object{"src": nodes.String(src)}, nil
+}
+
+func TestDriverParseMetadata(t *testing.T) {
+	require := require.New(t)
+
+	p := driver.NewPipeline(driver.Stage{
+		Name: "meta", Mode: driver.ModeAnnotated,
+		Do: func(ctx context.Context, st *driver.FileState, nd nodes.Node) (nodes.Node, error) {
+			obj := nd.(nodes.Object).CloneObject()
+			obj["root"] = nodes.String(st.Metadata["root"])
+			if v, ok := st.Context.Get(driver.MetadataVar(driver.MetaFilename)); ok {
+				obj["file"] = v
+			}
+			return obj, nil
+		},
+	})
+	m := &manifest.Manifest{Language: "fixture"}
+	d, err := driver.NewDriverWithPipeline(treeNative{}, m, p)
+	require.NoError(err)
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(err)
+	srv := NewGRPCServer(d)
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	require.NoError(err)
+	defer conn.Close()
+	cli := protocol2.AsDriver(conn)
+
+	ctx := context.Background()
+	ast, err := cli.Parse(ctx, "a", &driver.ParseOptions{
+		Mode: driver.ModeSemantic, Filename: "a_test.go", Metadata: driver.Metadata{"root": "/src"},
+	})
+	require.NoError(err)
+	obj := ast.(nodes.Object)
+	require.Equal(nodes.String("/src"), obj["root"])
+	require.Equal(nodes.String("a_test.go"), obj["file"])
+
+	res, err := cli.ParseFiles(ctx, driver.ModeSemantic, []driver.File{
+		{Content: "a", Metadata: driver.Metadata{"root": "/lib"}},
+	})
+	require.NoError(err)
+	require.Len(res, 1)
+	require.NoError(res[0].Err)
+	obj = res[0].UAST.(nodes.Object)
+	require.Equal(nodes.String("/lib"), obj["root"])
+	require.NotContains(obj, "file")
+}
+
+func TestDriverParseDiagnostics(t *testing.T) {
+	require := require.New(t)
+
+	d, err := newDriver("")
+	require.NoError(err)
+	err = d.d.Start()
+	require.NoError(err)
+	defer d.d.Close()
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(err)
+	srv := NewGRPCServer(d.d)
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	require.NoError(err)
+	defer conn.Close()
+	cli := protocol2.AsDriver(conn)
+
+	ctx := context.Background()
+	ast, err := cli.Parse(ctx, "a!", &driver.ParseOptions{Mode: driver.ModeNative})
+	require.NotNil(ast)
+	require.True(driver.ErrSyntax.Is(err), "%v", err)
+	require.Equal([]*driver.Diagnostic{{
+		Message: "unexpected '!'", Severity: derrors.SeverityWarning, Code: "E1",
+		Start: &uast.Position{Offset: 1, Line: 1, Col: 2},
+	}}, driver.Diagnostics(err))
+}
+
+// recoverNative returns words of the source as nodes. Words that start with "?" are reported as syntax errors.
+type recoverNative struct{}
+
+func (recoverNative) Start() error { return nil }
+func (recoverNative) Close() error { return nil }
+
+func (recoverNative) Parse(ctx context.Context, src string) (nodes.Node, error) {
+	var (
+		arr  nodes.Array
+		errs []error
+		off  int
+	)
+	for _, w := range strings.Fields(src) {
+		off += strings.Index(src[off:], w)
+		start := uast.Position{Offset: uint32(off), Line: 1, Col: uint32(off + 1)}
+		end := uast.Position{Offset: uint32(off + len(w)), Line: 1, Col: uint32(off + len(w) + 1)}
+		typ := "Word"
+		if strings.HasPrefix(w, "?") {
+			typ = "ERROR"
+			errs = append(errs, &driver.Diagnostic{Message: "unexpected " + w, Start: &start})
+		}
+		arr = append(arr, nodes.Object{
+			uast.KeyType:  nodes.String(typ),
+			uast.KeyToken: nodes.String(w),
+			uast.KeyPos:   uast.Positions{uast.KeyStart: start, uast.KeyEnd: end}.ToObject(),
+		})
+		off += len(w)
+	}
+	ast := nodes.Object{uast.KeyType: nodes.String("File"), "body": arr}
+	if len(errs) != 0 {
+		return ast, driver.JoinErrors(errs)
+	}
+	return ast, nil
+}
+
+func TestDriverMemoryLimit(t *testing.T) {
+	require := require.New(t)
+
+	m := &manifest.Manifest{Language: "fixture"}
+	d, err := driver.NewDriverFrom(recoverNative{}, m, driver.Transforms{MemoryLimit: 5000})
+	require.NoError(err)
+
+	ctx := context.Background()
+	src := strings.Repeat("word ", 100)
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(err)
+	srv := NewGRPCServer(d)
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	require.NoError(err)
+	defer conn.Close()
+	cli := protocol2.AsDriver(conn)
+
+	_, err = cli.Parse(ctx, src, nil)
+	require.True(driver.ErrResourceExhausted.Is(err), "%v", err)
+
+	res, err := cli.ParseFiles(ctx, driver.ModeSemantic, []driver.File{{Content: "a"}, {Content: src}})
+	require.NoError(err)
+	require.Len(res, 2)
+	require.NoError(res[0].Err)
+	require.True(driver.ErrResourceExhausted.Is(res[1].Err), "%v", res[1].Err)
+}
+
+func TestDriverParseCharset(t *testing.T) {
+	require := require.New(t)
+
+	m := &manifest.Manifest{Language: "fixture"}
+	d, err := driver.NewDriverFrom(recoverNative{}, m, driver.Transforms{})
+	require.NoError(err)
+
+	// returns the token of the last word, the offset and the column of its start
+	last := func(ast nodes.Node) (string, uint32, uint32) {
+		arr := ast.(nodes.Object)["body"].(nodes.Array)
+		w := arr[len(arr)-1].(nodes.Object)
+		start := uast.PositionsOf(w).Start()
+		return uast.TokenOf(w), start.Offset, start.Col
+	}
+
+	ctx := context.Background()
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(err)
+	srv := NewGRPCServer(d)
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	require.NoError(err)
+	defer conn.Close()
+	cli := protocol2.AsDriver(conn)
+
+	opts := &driver.ParseOptions{Mode: driver.ModeNative}
+	ast, err := cli.Parse(ctx, "\x93\xfa\x96\x7b word", opts)
+	require.NoError(err)
+	require.Equal(driver.CharsetShiftJIS, opts.Charset)
+	_, off, _ := last(ast)
+	require.Equal(uint32(5), off)
+
+	res, err := cli.ParseFiles(ctx, driver.ModeNative, []driver.File{
+		{Content: "caf\xe9 word"}, {Content: "a", Charset: "unknown"},
+	})
+	require.NoError(err)
+	require.Len(res, 2)
+	require.NoError(res[0].Err)
+	require.Equal(driver.CharsetWindows1252, res[0].Charset)
+	require.True(driver.ErrUnknownCharset.Is(res[1].Err), "%v", res[1].Err)
+
+	r, err := driver.ParseIncremental(ctx, cli, res[0], []driver.TextEdit{{Start: 0, End: 0, Text: "\xe9 "}})
+	require.NoError(err)
+	require.NoError(r.Err)
+	require.Equal(driver.CharsetWindows1252, r.Charset)
+	_, off, _ = last(r.UAST)
+	require.Equal(uint32(7), off)
+}
+
+// blockDriver blocks each parse request until it receives a value from the release channel.
+type blockDriver struct {
+	driver.Driver
+	started chan struct{}
+	release chan struct{}
+}
+
+func (d blockDriver) Parse(ctx context.Context, src string, opts *driver.ParseOptions) (nodes.Node, error) {
+	d.started <- struct{}{}
+	<-d.release
+	return d.Driver.Parse(ctx, src, opts)
+}
+
+func TestDriverLimit(t *testing.T) {
+	require := require.New(t)
+
+	bd := blockDriver{started: make(chan struct{}, 2), release: make(chan struct{})}
+	m := &manifest.Manifest{Language: "fixture"}
+	d, err := driver.NewDriverFrom(echoNative{}, m, driver.Transforms{
+		Middleware: []driver.Middleware{
+			driver.LimitMiddleware(1, 1),
+			func(d driver.Driver) driver.Driver { bd.Driver = d; return bd },
+		},
+	})
+	require.NoError(err)
+
+	ctx := context.Background()
+	errc := make(chan error, 2)
+	parse := func() {
+		_, err := d.Parse(ctx, "a", &driver.ParseOptions{Mode: driver.ModeNative})
+		errc <- err
+	}
+	go parse()
+	<-bd.started
+	go parse()
+
+	// wait for the second request to be queued; cancelled requests are not queued
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	for {
+		_, err = d.Parse(cctx, "a", nil)
+		if driver.ErrOverloaded.Is(err) {
+			break
+		}
+		require.Equal(context.Canceled, err)
+	}
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(err)
+	srv := NewGRPCServer(d)
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	require.NoError(err)
+	defer conn.Close()
+	cli := protocol2.AsDriver(conn)
+
+	_, err = cli.Parse(ctx, "a", nil)
+	require.True(driver.ErrOverloaded.Is(err), "%v", err)
+	_, err = cli.ParseFiles(ctx, driver.ModeNative, []driver.File{{Content: "a"}})
+	require.True(driver.ErrOverloaded.Is(err), "%v", err)
+
+	bd.release <- struct{}{}
+	require.NoError(<-errc)
+	<-bd.started
+	bd.release <- struct{}{}
+	require.NoError(<-errc)
+
+	_, err = cli.Capabilities(ctx)
+	require.NoError(err)
+}
+
+// countModule counts starts and closes of the driver. Ping fails if broken is set.
+type countModule struct {
+	driver.DriverModule
+	starts, closes *int
+	broken         *bool
+}
+
+func (d countModule) Start() error {
+	*d.starts++
+	return d.DriverModule.Start()
+}
+
+func (d countModule) Close() error {
+	*d.closes++
+	return d.DriverModule.Close()
+}
+
+func (d countModule) Ping(ctx context.Context) error {
+	if *d.broken {
+		return fmt.Errorf("broken")
+	}
+	return nil
+}
+
+// slowNative blocks on the "slow" source until the request is cancelled.
+type slowNative struct {
+	echoNative
+}
+
+func (n slowNative) Parse(ctx context.Context, src string) (nodes.Node, error) {
+	if src == "slow" {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return n.echoNative.Parse(ctx, src)
+}
+
+func TestDriverStageTimeout(t *testing.T) {
+	require := require.New(t)
+
+	m := &manifest.Manifest{Language: "fixture"}
+	m.Runtime.Timeouts = map[string]string{driver.StageNative: "20ms"}
+	d, err := driver.NewDriverFrom(slowNative{}, m, driver.Transforms{})
+	require.NoError(err)
+
+	ctx := context.Background()
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(err)
+	srv := NewGRPCServer(d)
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	require.NoError(err)
+	defer conn.Close()
+	cli := protocol2.AsDriver(conn)
+
+	_, err = cli.Parse(ctx, "slow", nil)
+	require.True(driver.ErrStageTimeout.Is(err), "%v", err)
+	require.Equal(driver.ErrStageTimeout.New(driver.StageNative, 20*time.Millisecond).Error(), err.Error())
+}
+
+// waitNative blocks on the "wait" source until released.
+type waitNative struct {
+	echoNative
+	started chan struct{}
+	release chan struct{}
+}
+
+func (n waitNative) Parse(ctx context.Context, src string) (nodes.Node, error) {
+	if src == "wait" {
+		n.started <- struct{}{}
+		<-n.release
+	}
+	return n.echoNative.Parse(ctx, src)
+}
+
+func TestDriverParseStream(t *testing.T) {
+	require := require.New(t)
+
+	wn := waitNative{started: make(chan struct{}, 1), release: make(chan struct{})}
+	m := &manifest.Manifest{Language: "fixture"}
+	d, err := driver.NewDriverFrom(wn, m, driver.Transforms{})
+	require.NoError(err)
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(err)
+	srv := NewGRPCServer(d)
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	require.NoError(err)
+	defer conn.Close()
+
+	ctx := context.Background()
+	st, err := protocol2.NewStream(ctx, conn)
+	require.NoError(err)
+
+	// the second request completes before the first one
+	errc := make(chan error, 1)
+	go func() {
+		ast, err := st.Parse(ctx, "wait", &driver.ParseOptions{Mode: driver.ModeNative})
+		if err == nil && !nodes.Equal(nodes.Object{"src": nodes.String("wait")}, ast) {
+			err = fmt.Errorf("unexpected tree: %v", ast)
+		}
+		errc <- err
+	}()
+	<-wn.started
+
+	opts := &driver.ParseOptions{Mode: driver.ModeNative}
+	ast, err := st.Parse(ctx, "a", opts)
+	require.NoError(err)
+	require.Equal(nodes.Object{"src": nodes.String("a")}, ast)
+	require.Equal("fixture", opts.Language)
+	require.Equal(driver.CharsetUTF8, opts.Charset)
+
+	_, err = st.Parse(ctx, "", nil)
+	require.True(driver.ErrSyntax.Is(err), "%v", err)
+
+	_, err = st.Parse(ctx, "a", &driver.ParseOptions{Charset: "unknown"})
+	require.True(driver.ErrUnknownCharset.Is(err), "%v", err)
+
+	close(wn.release)
+	require.NoError(<-errc)
+
+	require.NoError(st.Close())
+	_, err = st.Parse(ctx, "a", nil)
+	require.True(protocol2.ErrStreamClosed.Is(err), "%v", err)
+}
+
+func TestDriverParseChunked(t *testing.T) {
+	require := require.New(t)
+
+	m := &manifest.Manifest{Language: "fixture"}
+	d, err := driver.NewDriverFrom(echoNative{}, m, driver.Transforms{})
+	require.NoError(err)
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(err)
+	srv := NewGRPCServer(d, grpc.MaxSendMsgSize(16<<10))
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	require.NoError(err)
+	defer conn.Close()
+
+	ctx := context.Background()
+	src := strings.Repeat("a", 64<<10)
+	_, err = protocol2.NewDriverClient(conn).Parse(ctx, &protocol2.ParseRequest{Content: src, Mode: protocol2.Mode_Native})
+	require.Equal(codes.ResourceExhausted, status.Code(err), "%v", err)
+
+	// the client requests the tree again in chunks
+	opts := &driver.ParseOptions{Mode: driver.ModeNative}
+	ast, err := protocol2.AsDriver(conn).Parse(ctx, src, opts)
+	require.NoError(err)
+	require.Equal(nodes.Object{"src": nodes.String(src)}, ast)
+	require.Equal("fixture", opts.Language)
+
+	s, err := protocol2.NewDriverClient(conn).ParseChunked(ctx, &protocol2.ParseChunkedRequest{
+		Request: &protocol2.ParseRequest{Content: "abc", Mode: protocol2.Mode_Native}, ChunkSize: 4,
+	})
+	require.NoError(err)
+	resp, err := protocol2.ReadChunks(s)
+	require.NoError(err)
+	ast, err = resp.Nodes()
+	require.NoError(err)
+	require.Equal(nodes.Object{"src": nodes.String("abc")}, ast)
+
+	// tiny chunks are not sent, even if requested
+	s, err = protocol2.NewDriverClient(conn).ParseChunked(ctx, &protocol2.ParseChunkedRequest{
+		Request: &protocol2.ParseRequest{Content: strings.Repeat("a", 10<<10), Mode: protocol2.Mode_Native}, ChunkSize: 1,
+	})
+	require.NoError(err)
+	_, err = s.Recv()
+	require.NoError(err)
+	n := 0
+	for {
+		c, err := s.Recv()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(err)
+		n++
+		require.True(len(c.Data) >= 4<<10 || n == 3, "chunk %d: %d bytes", n, len(c.Data))
+	}
+	require.Equal(3, n)
+
+	// failures are returned as the status of the stream
+	s, err = protocol2.NewDriverClient(conn).ParseChunked(ctx, &protocol2.ParseChunkedRequest{
+		Request: &protocol2.ParseRequest{Content: "abc", Charset: "unknown"},
+	})
+	require.NoError(err)
+	_, err = protocol2.ReadChunks(s)
+	require.Equal(codes.InvalidArgument, status.Code(err), "%v", err)
+}
+
+// chunkStream is a fake client stream of ParseChunked that returns a fixed set of chunks.
+type chunkStream struct {
+	grpc.ClientStream
+	chunks []*protocol2.ParseChunk
+}
+
+func (s *chunkStream) Recv() (*protocol2.ParseChunk, error) {
+	if len(s.chunks) == 0 {
+		return nil, io.EOF
+	}
+	c := s.chunks[0]
+	s.chunks = s.chunks[1:]
+	return c, nil
+}
+
+func TestReadChunksBadSize(t *testing.T) {
+	// the size reported by the server must not be allocated upfront
+	s := &chunkStream{chunks: []*protocol2.ParseChunk{
+		{Response: &protocol2.ParseResponse{}, Size: math.MaxUint64},
+		{Data: []byte("abc")},
+	}}
+	_, err := protocol2.ReadChunks(s)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "got 3")
+
+	// the rest of the stream is not read once the data exceeds the size
+	s = &chunkStream{chunks: []*protocol2.ParseChunk{
+		{Response: &protocol2.ParseResponse{}, Size: 2},
+		{Data: []byte("abc")},
+		{Data: []byte("def")},
+	}}
+	_, err = protocol2.ReadChunks(s)
+	require.Error(t, err)
+	require.Len(t, s.chunks, 1)
+}
+
+func TestDriverCompression(t *testing.T) {
+	require := require.New(t)
+
+	m := &manifest.Manifest{Language: "fixture"}
+	d, err := driver.NewDriverFrom(echoNative{}, m, driver.Transforms{})
+	require.NoError(err)
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(err)
+	srv := NewGRPCServerWith(d, protocol2.ServiceOptions{RequireCompression: true})
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	ctx := context.Background()
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	require.NoError(err)
+	defer conn.Close()
+	cli := protocol2.AsDriver(conn)
+
+	c, err := cli.Capabilities(ctx)
+	require.NoError(err)
+	require.True(c.CompressionRequired)
+	require.Contains(c.Compressors, protocol2.CompressionGzip)
+
+	_, err = cli.Parse(ctx, "a", nil)
+	require.True(protocol2.ErrCompressionRequired.Is(err), "%v", err)
+
+	st, err := protocol2.NewStream(ctx, conn)
+	require.NoError(err)
+	defer st.Close()
+	_, err = st.Parse(ctx, "a", nil)
+	require.True(protocol2.ErrCompressionRequired.Is(err), "%v", err)
+
+	gconn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure(), protocol2.WithCompression(protocol2.CompressionGzip))
+	require.NoError(err)
+	defer gconn.Close()
+
+	src := strings.Repeat("a", 10000)
+	ast, err := protocol2.AsDriver(gconn).Parse(ctx, src, &driver.ParseOptions{Mode: driver.ModeNative})
+	require.NoError(err)
+	require.Equal(nodes.Object{"src": nodes.String(src)}, ast)
+
+	res, err := protocol2.AsDriver(gconn).ParseFiles(ctx, driver.ModeNative, []driver.File{{Content: "a"}})
+	require.NoError(err)
+	require.Len(res, 1)
+	require.NoError(res[0].Err)
+
+	gst, err := protocol2.NewStream(ctx, gconn)
+	require.NoError(err)
+	defer gst.Close()
+	ast, err = gst.Parse(ctx, "b", &driver.ParseOptions{Mode: driver.ModeNative})
+	require.NoError(err)
+	require.Equal(nodes.Object{"src": nodes.String("b")}, ast)
+}
+
+func TestDriverHTTP(t *testing.T) {
+	require := require.New(t)
+
+	m := &manifest.Manifest{Language: "fixture"}
+	d, err := driver.NewDriverFrom(echoNative{}, m, driver.Transforms{})
+	require.NoError(err)
+
+	srv := httptest.NewServer(NewHTTPHandler(d, 1<<10))
+	defer srv.Close()
+
+	post := func(body string) (int, string) {
+		resp, err := http.Post(srv.URL+"/parse", "application/json", strings.NewReader(body))
+		require.NoError(err)
+		defer resp.Body.Close()
+		data, err := ioutil.ReadAll(resp.Body)
+		require.NoError(err)
+		require.Equal("application/json", resp.Header.Get("Content-Type"))
+		return resp.StatusCode, strings.TrimSpace(string(data))
+	}
+
+	code, body := post(`{"content": "a", "mode": "native"}`)
+	require.Equal(http.StatusOK, code, body)
+	require.Equal(`{"language":"fixture","charset":"utf-8","uast":{"src":"a"}}`, body)
+
+	code, body = post(`{"content": "a", "mode": "unknown"}`)
+	require.Equal(http.StatusBadRequest, code, body)
+
+	code, body = post(`{"content": "a", "charset": "unknown"}`)
+	require.Equal(http.StatusBadRequest, code, body)
+
+	code, body = post(`{"content": `)
+	require.Equal(http.StatusBadRequest, code, body)
+
+	code, body = post(`{"content": "` + strings.Repeat("a", 2<<10) + `"}`)
+	require.Equal(http.StatusBadRequest, code, body)
+
+	resp, err := http.Get(srv.URL + "/parse")
+	require.NoError(err)
+	resp.Body.Close()
+	require.Equal(http.StatusMethodNotAllowed, resp.StatusCode)
+	require.Equal(http.MethodPost, resp.Header.Get("Allow"))
+}
+
+func TestDriverWebSocket(t *testing.T) {
+	require := require.New(t)
+
+	m := &manifest.Manifest{Language: "fixture"}
+	d, err := driver.NewDriverFrom(echoNative{}, m, driver.Transforms{})
+	require.NoError(err)
+
+	srv := httptest.NewServer(NewHTTPHandler(d, 1<<10))
+	defer srv.Close()
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws"
+	c, err := websocket.Dial(url, "", srv.URL)
+	require.NoError(err)
+	defer c.Close()
+
+	reqs := []WSRequest{
+		{ID: 1, Request: HTTPParseRequest{Content: "a", Mode: "native"}},
+		{ID: 2, Op: WSOpParse, Request: HTTPParseRequest{Content: "b", Mode: "native"}},
+		{ID: 3, Op: "unknown"},
+		{ID: 4, Request: HTTPParseRequest{Content: "a", Charset: "unknown"}},
+	}
+	for _, req := range reqs {
+		require.NoError(websocket.JSON.Send(c, req))
+	}
+	got := make(map[uint64]WSResponse)
+	for range reqs {
+		var resp WSResponse
+		require.NoError(websocket.JSON.Receive(c, &resp))
+		got[resp.ID] = resp
+	}
+	require.Equal(http.StatusOK, got[1].Code, got[1].Error)
+	require.Equal(nodes.Object{"src": nodes.String("a")}, got[1].Response.UAST)
+	require.Equal(http.StatusOK, got[2].Code, got[2].Error)
+	require.Equal(nodes.Object{"src": nodes.String("b")}, got[2].Response.UAST)
+	require.Equal(http.StatusBadRequest, got[3].Code)
+	require.Nil(got[3].Response)
+	require.Equal(http.StatusBadRequest, got[4].Code)
+
+	// invalid and oversized messages are rejected, but the connection is kept
+	require.NoError(websocket.Message.Send(c, `{"id": `))
+	require.NoError(websocket.JSON.Send(c, WSRequest{ID: 5, Request: HTTPParseRequest{Content: strings.Repeat("a", 2<<10)}}))
+	require.NoError(websocket.JSON.Send(c, WSRequest{ID: 6, Request: HTTPParseRequest{Content: "c", Mode: "native"}}))
+
+	var resp WSResponse
+	require.NoError(websocket.JSON.Receive(c, &resp))
+	require.Equal(http.StatusBadRequest, resp.Code)
+	require.NoError(websocket.JSON.Receive(c, &resp))
+	require.Equal(http.StatusRequestEntityTooLarge, resp.Code)
+	resp = WSResponse{}
+	require.NoError(websocket.JSON.Receive(c, &resp))
+	require.Equal(uint64(6), resp.ID)
+	require.Equal(nodes.Object{"src": nodes.String("c")}, resp.Response.UAST)
+}
+
+func TestDriverWebSocketOrigin(t *testing.T) {
+	require := require.New(t)
+
+	m := &manifest.Manifest{Language: "fixture"}
+	d, err := driver.NewDriverFrom(echoNative{}, m, driver.Transforms{})
+	require.NoError(err)
+
+	srv := httptest.NewServer(newHTTPHandler(d, 0, nil, []string{"https://viewer.example"}))
+	defer srv.Close()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws"
+
+	// pages of other hosts cannot use the gateway
+	_, err = websocket.Dial(url, "", "https://evil.example")
+	require.Error(err)
+
+	for _, origin := range []string{srv.URL, "https://viewer.example"} {
+		c, err := websocket.Dial(url, "", origin)
+		require.NoError(err, origin)
+		require.NoError(websocket.JSON.Send(c, WSRequest{ID: 1, Request: HTTPParseRequest{Content: "a", Mode: "native"}}))
+		var resp WSResponse
+		require.NoError(websocket.JSON.Receive(c, &resp))
+		require.Equal(http.StatusOK, resp.Code, resp.Error)
+		c.Close()
+	}
+}
+
+// writeCert generates a certificate signed by the parent, or a self-signed one if the parent is nil, and writes it
+// to files in the directory. It returns the certificate and the key.
+func writeCert(t testing.TB, dir, name string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{"localhost"},
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if parent == nil {
+		tmpl.IsCA, tmpl.BasicConstraintsValid = true, true
+		parent, parentKey = tmpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	kder, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	err = ioutil.WriteFile(filepath.Join(dir, name+".crt"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	require.NoError(t, err)
+	err = ioutil.WriteFile(filepath.Join(dir, name+".key"), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: kder}), 0600)
+	require.NoError(t, err)
+	return cert, key
+}
+
+func TestDriverAuth(t *testing.T) {
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "driver-tls")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	ca, caKey := writeCert(t, dir, "ca", nil, nil)
+	writeCert(t, dir, "server", ca, caKey)
+	writeCert(t, dir, "client", ca, caKey)
+	path := func(name string) string { return filepath.Join(dir, name) }
+
+	err = ioutil.WriteFile(path("tokens"), []byte("# tokens\nfoo\n\nbar\n"), 0600)
+	require.NoError(err)
+	tokens, err := loadTokens(path("tokens"))
+	require.NoError(err)
+	require.Equal([]string{"foo", "bar"}, tokens)
+
+	scfg, err := loadTLSConfig(path("server.crt"), path("server.key"), path("ca.crt"))
+	require.NoError(err)
+
+	m := &manifest.Manifest{Language: "fixture"}
+	d, err := driver.NewDriverFrom(echoNative{}, m, driver.Transforms{})
+	require.NoError(err)
+	err = d.Start()
+	require.NoError(err)
+	defer d.Close()
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(err)
+	unary, stream := protocol2.TokenAuth(tokens...)
+	opts := append(protocol2.ServerOptionsWith(
+		[]grpc.UnaryServerInterceptor{unary}, []grpc.StreamServerInterceptor{stream},
+	), grpc.Creds(credentials.NewTLS(scfg)))
+	srv := newGRPCServer(d, protocol2.ServiceOptions{}, opts...)
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	pair, err := tls.LoadX509KeyPair(path("client.crt"), path("client.key"))
+	require.NoError(err)
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	ccfg := &tls.Config{RootCAs: roots, Certificates: []tls.Certificate{pair}, ServerName: "localhost"}
+
+	dial := func(opts ...grpc.DialOption) *grpc.ClientConn {
+		conn, err := grpc.Dial(lis.Addr().String(), append(opts, grpc.WithTransportCredentials(credentials.NewTLS(ccfg)))...)
+		require.NoError(err)
+		return conn
+	}
+	ctx := context.Background()
+
+	conn := dial()
+	defer conn.Close()
+	_, err = protocol2.AsDriver(conn).Parse(ctx, "a", nil)
+	require.True(protocol2.ErrUnauthenticated.Is(err), "%v", err)
+
+	st, err := protocol2.NewStream(ctx, conn)
+	require.NoError(err)
+	defer st.Close()
+	_, err = st.Parse(ctx, "a", nil)
+	require.True(protocol2.ErrUnauthenticated.Is(err), "%v", err)
+
+	// health checks are allowed without a token
+	r, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(err)
+	require.Equal(healthpb.HealthCheckResponse_SERVING, r.Status)
+
+	bad := dial(protocol2.WithToken("baz"))
+	defer bad.Close()
+	_, err = protocol2.AsDriver(bad).Parse(ctx, "a", nil)
+	require.True(protocol2.ErrUnauthenticated.Is(err), "%v", err)
+
+	good := dial(protocol2.WithToken("bar"))
+	defer good.Close()
+	ast, err := protocol2.AsDriver(good).Parse(ctx, "a", &driver.ParseOptions{Mode: driver.ModeNative})
+	require.NoError(err)
+	require.Equal(nodes.Object{"src": nodes.String("a")}, ast)
+
+	// clients without a certificate are rejected
+	nocert := &tls.Config{RootCAs: roots, ServerName: "localhost"}
+	conn2, err := grpc.Dial(lis.Addr().String(), grpc.WithTransportCredentials(credentials.NewTLS(nocert)), protocol2.WithToken("bar"))
+	require.NoError(err)
+	defer conn2.Close()
+	tctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	_, err = protocol2.AsDriver(conn2).Parse(tctx, "a", nil)
+	require.Error(err)
+
+	// the same tokens are required by the HTTP gateway
+	hsrv := httptest.NewServer(requireToken(NewHTTPHandler(d, 0), tokens))
+	defer hsrv.Close()
+	req, err := http.NewRequest(http.MethodPost, hsrv.URL+"/parse", strings.NewReader(`{"content": "a"}`))
+	require.NoError(err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(err)
+	resp.Body.Close()
+	require.Equal(http.StatusUnauthorized, resp.StatusCode)
+
+	req, err = http.NewRequest(http.MethodPost, hsrv.URL+"/parse", strings.NewReader(`{"content": "a"}`))
+	require.NoError(err)
+	req.Header.Set("Authorization", "Bearer foo")
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(err)
+	resp.Body.Close()
+	require.Equal(http.StatusOK, resp.StatusCode)
+
+	// browsers pass the token of the WebSocket in the query
+	url := "ws" + strings.TrimPrefix(hsrv.URL, "http") + "/ws"
+	_, err = websocket.Dial(url, "", hsrv.URL)
+	require.Error(err)
+	_, err = websocket.Dial(url+"?"+wsTokenParam+"=baz", "", hsrv.URL)
+	require.Error(err)
+	c, err := websocket.Dial(url+"?"+wsTokenParam+"=foo", "", hsrv.URL)
+	require.NoError(err)
+	c.Close()
+
+	// the query is ignored for other requests
+	req, err = http.NewRequest(http.MethodPost, hsrv.URL+"/parse?"+wsTokenParam+"=foo", strings.NewReader(`{"content": "a"}`))
+	require.NoError(err)
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(err)
+	resp.Body.Close()
+	require.Equal(http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestDriverRateLimit(t *testing.T) {
+	require := require.New(t)
+
+	m := &manifest.Manifest{Language: "fixture"}
+	d, err := driver.NewDriverFrom(echoNative{}, m, driver.Transforms{})
+	require.NoError(err)
+	err = d.Start()
+	require.NoError(err)
+	defer d.Close()
+
+	var closers []func()
+	defer func() {
+		for _, c := range closers {
+			c()
+		}
+	}()
+	serve := func(l protocol2.RateLimits) *grpc.ClientConn {
+		lis, err := net.Listen("tcp", "localhost:0")
+		require.NoError(err)
+		unary, stream := protocol2.RateLimiter(l)
+		srv := newGRPCServer(d, protocol2.ServiceOptions{}, protocol2.ServerOptionsWith(
+			[]grpc.UnaryServerInterceptor{unary}, []grpc.StreamServerInterceptor{stream},
+		)...)
+		go srv.Serve(lis)
+
+		conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+		require.NoError(err)
+		closers = append(closers, func() {
+			conn.Close()
+			srv.Stop()
+		})
+		return conn
+	}
+
+	// rates are low enough to not refill the buckets during the test
+	conn := serve(protocol2.RateLimits{
+		Global:    protocol2.RateLimit{Rate: 0.1, Burst: 3},
+		PerClient: protocol2.RateLimit{Rate: 0.1, Burst: 2},
+		ClientKey: func(ctx context.Context) string {
+			md, _ := metadata.FromIncomingContext(ctx)
+			return strings.Join(md.Get("client"), "")
+		},
+	})
+	cli := protocol2.AsDriver(conn)
+	ctxA := metadata.AppendToOutgoingContext(context.Background(), "client", "a")
+	ctxB := metadata.AppendToOutgoingContext(context.Background(), "client", "b")
+
+	for i := 0; i < 2; i++ {
+		_, err = cli.Parse(ctxA, "a", nil)
+		require.NoError(err)
+	}
+	_, err = cli.Parse(ctxA, "a", nil)
+	require.True(protocol2.ErrRateLimited.Is(err), "%v", err)
+	delay, ok := protocol2.RetryAfter(err)
+	require.True(ok)
+	require.True(delay > 0 && delay <= 10*time.Second, "%v", delay)
+
+	_, err = cli.ParseFiles(ctxA, driver.ModeNative, []driver.File{{Content: "a"}, {Content: "b"}})
+	require.True(protocol2.ErrRateLimited.Is(err), "%v", err)
+
+	// other clients are only limited by the global limit
+	_, err = cli.Parse(ctxB, "a", nil)
+	require.NoError(err)
+	_, err = cli.Parse(ctxB, "a", nil)
+	require.True(protocol2.ErrRateLimited.Is(err), "%v", err)
+
+	r, err := healthpb.NewHealthClient(conn).Check(ctxA, &healthpb.HealthCheckRequest{})
+	require.NoError(err)
+	require.Equal(healthpb.HealthCheckResponse_SERVING, r.Status)
+
+	// messages of streams are delayed instead
+	conn = serve(protocol2.RateLimits{Global: protocol2.RateLimit{Rate: 20, Burst: 1}})
+	st, err := protocol2.NewStream(context.Background(), conn)
+	require.NoError(err)
+	defer st.Close()
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		_, err = st.Parse(context.Background(), "a", &driver.ParseOptions{Mode: driver.ModeNative})
+		require.NoError(err)
+	}
+	require.True(time.Since(start) >= 100*time.Millisecond, "%v", time.Since(start))
+}
+
+func TestDriverRateLimitClients(t *testing.T) {
+	require := require.New(t)
+
+	m := &manifest.Manifest{Language: "fixture"}
+	d, err := driver.NewDriverFrom(echoNative{}, m, driver.Transforms{})
+	require.NoError(err)
+	err = d.Start()
+	require.NoError(err)
+	defer d.Close()
+
+	limits := protocol2.RateLimits{PerClient: protocol2.RateLimit{Rate: 0.1, Burst: 1}}
+	parse := func(auth bool, tokens ...string) []error {
+		var (
+			unary  []grpc.UnaryServerInterceptor
+			stream []grpc.StreamServerInterceptor
+		)
+		if auth {
+			u, st := protocol2.TokenAuth("a", "b")
+			unary, stream = append(unary, u), append(stream, st)
+		}
+		u, st := protocol2.RateLimiter(limits)
+		unary, stream = append(unary, u), append(stream, st)
+
+		lis, err := net.Listen("tcp", "localhost:0")
+		require.NoError(err)
+		srv := newGRPCServer(d, protocol2.ServiceOptions{}, protocol2.ServerOptionsWith(unary, stream)...)
+		go srv.Serve(lis)
+		defer srv.Stop()
+
+		conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+		require.NoError(err)
+		defer conn.Close()
+		cli := protocol2.AsDriver(conn)
+
+		var errs []error
+		for _, tok := range tokens {
+			ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+tok)
+			_, err := cli.Parse(ctx, "a", nil)
+			errs = append(errs, err)
+		}
+		return errs
+	}
+
+	// tokens that are not validated do not identify clients, thus they cannot be used to avoid the limit
+	errs := parse(false, "a", "b")
+	require.NoError(errs[0])
+	require.True(protocol2.ErrRateLimited.Is(errs[1]), "%v", errs[1])
+
+	errs = parse(true, "a", "b", "a")
+	require.NoError(errs[0])
+	require.NoError(errs[1])
+	require.True(protocol2.ErrRateLimited.Is(errs[2]), "%v", errs[2])
+}
+
+func TestDriverHTTPRateLimit(t *testing.T) {
+	require := require.New(t)
+
+	m := &manifest.Manifest{Language: "fixture"}
+	d, err := driver.NewDriverFrom(echoNative{}, m, driver.Transforms{})
+	require.NoError(err)
+
+	l := protocol2.NewLimiter(protocol2.RateLimits{Global: protocol2.RateLimit{Rate: 0.1, Burst: 2}})
+	srv := httptest.NewServer(newHTTPHandler(d, 1<<10, l, nil))
+	defer srv.Close()
+
+	post := func() *http.Response {
+		resp, err := http.Post(srv.URL+"/parse", "application/json", strings.NewReader(`{"content": "a", "mode": "native"}`))
+		require.NoError(err)
+		resp.Body.Close()
+		return resp
+	}
+	resp := post()
+	require.Equal(http.StatusOK, resp.StatusCode)
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws"
+	c, err := websocket.Dial(url, "", srv.URL)
+	require.NoError(err)
+	defer c.Close()
+
+	for i, code := range []int{http.StatusOK, http.StatusTooManyRequests} {
+		err = websocket.JSON.Send(c, WSRequest{ID: uint64(i), Request: HTTPParseRequest{Content: "a", Mode: "native"}})
+		require.NoError(err)
+		var r WSResponse
+		err = websocket.JSON.Receive(c, &r)
+		require.NoError(err)
+		require.Equal(code, r.Code, r.Error)
+	}
+
+	resp = post()
+	require.Equal(http.StatusTooManyRequests, resp.StatusCode)
+	sec, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	require.NoError(err)
+	require.True(sec > 0 && sec <= 10, "%v", sec)
+}
+
+func TestDriverMetrics(t *testing.T) {
+	require := require.New(t)
+
+	m := &manifest.Manifest{Language: "fixture"}
+	d, err := driver.NewDriverFrom(echoNative{}, m, driver.Transforms{})
+	require.NoError(err)
+
+	metrics := NewMetrics()
+	od, ok := d.(driver.ObservedDriver)
+	require.True(ok)
+	od.SetStageObserver(metrics)
+	err = d.Start()
+	require.NoError(err)
+	defer d.Close()
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(err)
+	srv := newGRPCServer(d, protocol2.ServiceOptions{Metrics: metrics}, protocol2.ServerOptions()...)
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	require.NoError(err)
+	defer conn.Close()
+	cli := protocol2.AsDriver(conn)
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		_, err = cli.Parse(ctx, "a", &driver.ParseOptions{Mode: driver.ModeNative})
+		require.NoError(err)
+	}
+	_, err = cli.Parse(ctx, "a", &driver.ParseOptions{Charset: "unknown"})
+	require.True(driver.ErrUnknownCharset.Is(err), "%v", err)
+	_, err = cli.ParseFiles(ctx, driver.ModeNative, []driver.File{{Content: "a"}, {Content: "b"}})
+	require.NoError(err)
+	metrics.Native().Restarted()
+
+	hs := httptest.NewServer(metrics)
+	defer hs.Close()
+	resp, err := http.Get(hs.URL)
+	require.NoError(err)
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	require.NoError(err)
+	out := string(data)
+
+	for _, line := range []string{
+		`bblfsh_driver_requests_total{mode="native",code="OK"} 3`,
+		`bblfsh_driver_requests_total{mode="semantic",code="InvalidArgument"} 1`,
+		`bblfsh_driver_request_duration_seconds_count 4`,
+		`bblfsh_driver_request_duration_seconds_bucket{le="+Inf"} 4`,
+		`bblfsh_driver_stage_duration_seconds_count{stage="native"} 4`,
+		`bblfsh_driver_stage_duration_seconds_count{stage="encode"} 4`,
+		`bblfsh_driver_response_size_bytes_count 4`,
+		`bblfsh_driver_native_restarts_total 1`,
+		`# TYPE bblfsh_driver_stage_duration_seconds histogram`,
+	} {
+		require.Contains(out, line+"\n")
+	}
+}
+
+func TestDriverTracing(t *testing.T) {
+	require := require.New(t)
+
+	tracer := mocktracer.New()
+	opentracing.SetGlobalTracer(tracer)
+	defer opentracing.SetGlobalTracer(opentracing.NoopTracer{})
+
+	m := &manifest.Manifest{Language: "fixture"}
+	p := driver.Transforms{}.Pipeline()
+	p.Append(driver.Stage{
+		Name: "check", Mode: driver.ModeAnnotated,
+		Do: func(ctx context.Context, st *driver.FileState, nd nodes.Node) (nodes.Node, error) {
+			return nd, nil
+		},
+	})
+	d, err := driver.NewDriverWithPipeline(echoNative{}, m, p)
+	require.NoError(err)
+	err = d.Start()
+	require.NoError(err)
+	defer d.Close()
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(err)
+	srv := newGRPCServer(d, protocol2.ServiceOptions{}, protocol2.ServerOptions()...)
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	conn, err := grpc.Dial(lis.Addr().String(), append(protocol2.DialOptions(), grpc.WithInsecure())...)
+	require.NoError(err)
+	defer conn.Close()
+	cli := protocol2.AsDriver(conn)
+
+	// spans finished in the trace of the given span, by operation name
+	trace := func(parent opentracing.Span) map[string]*mocktracer.MockSpan {
+		id := parent.Context().(mocktracer.MockSpanContext).TraceID
+		spans := make(map[string]*mocktracer.MockSpan)
+		for _, sp := range tracer.FinishedSpans() {
+			if sp.SpanContext.TraceID == id {
+				spans[sp.OperationName] = sp
+			}
+		}
+		return spans
+	}
+
+	parent := tracer.StartSpan("client")
+	ctx := opentracing.ContextWithSpan(context.Background(), parent)
+	_, err = cli.Parse(ctx, "a", &driver.ParseOptions{Mode: driver.ModeAnnotated})
+	require.NoError(err)
+	parent.Finish()
+
+	spans := trace(parent)
+	for _, name := range []string{
+		"bblfsh.server.Parse", "bblfsh.driver.Parse", "uast.Native", "uast.Transform", "uast.Transform.check", "uast.Encode",
+	} {
+		require.Contains(spans, name)
+	}
+	sp := spans["bblfsh.driver.Parse"]
+	require.Equal("fixture", sp.Tag(driver.TagLanguage))
+	require.Equal("annotated", sp.Tag(driver.TagMode))
+	require.Nil(sp.Tag("error"))
+	require.Equal(driver.StageNative, spans["uast.Native"].Tag(driver.TagStage))
+	require.Equal("check", spans["uast.Transform.check"].Tag(driver.TagStage))
+	require.Equal("annotated", spans["bblfsh.server.Parse"].Tag(driver.TagMode))
+	require.NotNil(spans["uast.Encode"].Tag(driver.TagSize))
+
+	parent = tracer.StartSpan("client")
+	ctx = opentracing.ContextWithSpan(context.Background(), parent)
+	_, err = cli.Parse(ctx, "a", &driver.ParseOptions{Charset: "unknown"})
+	require.True(driver.ErrUnknownCharset.Is(err), "%v", err)
+	parent.Finish()
+	require.Equal(true, trace(parent)["bblfsh.driver.Parse"].Tag("error"))
+
+	// the HTTP gateway continues the trace from the headers
+	hs := httptest.NewServer(NewHTTPHandler(d, 0))
+	parent = tracer.StartSpan("client")
+	req, err := http.NewRequest(http.MethodPost, hs.URL+"/parse", strings.NewReader(`{"content": "a", "mode": "native"}`))
+	require.NoError(err)
+	err = tracer.Inject(parent.Context(), opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(req.Header))
+	require.NoError(err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(err)
+	resp.Body.Close()
+	require.Equal(http.StatusOK, resp.StatusCode)
+	hs.Close() // waits for the handler
+	parent.Finish()
+
+	spans = trace(parent)
+	require.Contains(spans, "bblfsh.driver.Parse")
+	sp = spans["bblfsh.http.Parse"]
+	require.NotNil(sp)
+	require.Equal(parent.Context().(mocktracer.MockSpanContext).SpanID, sp.ParentID)
+	require.Equal(uint16(http.StatusOK), sp.Tag("http.status_code"))
+}
+
+func TestDriverHealthReady(t *testing.T) {
+	require := require.New(t)
+
+	m := &manifest.Manifest{Language: "fixture"}
+	dm, err := driver.NewDriverFrom(echoNative{}, m, driver.Transforms{})
+	require.NoError(err)
+	var starts, closes int
+	broken := false
+	d := countModule{DriverModule: dm, starts: &starts, closes: &closes, broken: &broken}
+
+	var ready int32
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(err)
+	srv := grpc.NewServer()
+	registerServices(srv, d, protocol2.ServiceOptions{}, &healthServer{d: d, ready: &ready})
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	require.NoError(err)
+	defer conn.Close()
+	cli := healthpb.NewHealthClient(conn)
+
+	check := func(service string) healthpb.HealthCheckResponse_ServingStatus {
+		r, err := cli.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+		require.NoError(err)
+		return r.Status
+	}
+
+	// the driver is starting
+	require.Equal(healthpb.HealthCheckResponse_NOT_SERVING, check(""))
+
+	err = d.Start()
+	require.NoError(err)
+	defer d.Close()
+	atomic.StoreInt32(&ready, 1)
+	require.Equal(healthpb.HealthCheckResponse_SERVING, check(""))
+	require.Equal(healthpb.HealthCheckResponse_SERVING, check("gopkg.in.bblfsh.sdk.v2.protocol.Driver"))
+
+	// the native driver does not reply to pings
+	broken = true
+	require.Equal(healthpb.HealthCheckResponse_NOT_SERVING, check(""))
+	broken = false
+	require.Equal(healthpb.HealthCheckResponse_SERVING, check(""))
+
+	_, err = cli.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "unknown"})
+	require.Equal(codes.NotFound, status.Code(err), "%v", err)
+}
+
+type crashNative struct {
+	echoNative
+}
+
+func (n crashNative) Parse(ctx context.Context, src string) (nodes.Node, error) {
+	if src == "crash" {
+		return nil, driver.ErrDriverFailure.Wrap(native.ErrNativeStderr.Wrap(io.ErrUnexpectedEOF, "panic: boom"))
+	}
+	return n.echoNative.Parse(ctx, src)
+}
+
+func TestDriverErrorDetails(t *testing.T) {
+	require := require.New(t)
+
+	m := &manifest.Manifest{Language: "fixture"}
+	p := driver.Transforms{}.Pipeline()
+	p.Append(driver.Stage{
+		Name: "check", Mode: driver.ModeAnnotated,
+		Do: func(ctx context.Context, st *driver.FileState, nd nodes.Node) (nodes.Node, error) {
+			if nd.(nodes.Object)["src"] == nodes.String("bad") {
+				return nil, &derrors.Diagnostic{Message: "unexpected node", Start: &uast.Position{Offset: 1, Line: 1, Col: 2}}
+			}
+			return nd, nil
+		},
+	})
+	d, err := driver.NewDriverWithPipeline(crashNative{}, m, p)
+	require.NoError(err)
+	err = d.Start()
+	require.NoError(err)
+	defer d.Close()
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(err)
+	srv := newGRPCServer(d, protocol2.ServiceOptions{}, protocol2.ServerOptions()...)
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	require.NoError(err)
+	defer conn.Close()
+	cli := protocol2.AsDriver(conn)
+	ctx := context.Background()
+
+	_, err = cli.Parse(ctx, "crash", nil)
+	require.True(driver.ErrDriverFailure.Is(err), "%v", err)
+	f := protocol2.FailureDetails(err)
+	require.NotNil(f)
+	require.Equal(native.FailureCrash, f.Kind)
+	require.Equal(driver.StageNative, f.Stage)
+	require.Equal("panic: boom", f.Stderr)
+
+	_, err = cli.Parse(ctx, "bad", &driver.ParseOptions{Mode: driver.ModeAnnotated})
+	require.True(driver.ErrTransformFailure.Is(err), "%v", err)
+	diags := driver.Diagnostics(err)
+	require.Len(diags, 1)
+	require.Equal("unexpected node", diags[0].Message)
+	require.Equal(&uast.Position{Offset: 1, Line: 1, Col: 2}, diags[0].Start)
+
+	res, err := cli.ParseFiles(ctx, driver.ModeNative, []driver.File{{Content: "a"}, {Content: "crash"}})
+	require.NoError(err)
+	require.Len(res, 2)
+	require.NoError(res[0].Err)
+	f = protocol2.FailureDetails(res[1].Err)
+	require.NotNil(f)
+	require.Equal(native.FailureCrash, f.Kind)
+
+	// standard details for invalid requests
+	_, err = protocol2.NewDriverClient(conn).Parse(ctx, &protocol2.ParseRequest{Content: "a", Charset: "unknown"})
+	st := status.Convert(err)
+	require.Equal(codes.InvalidArgument, st.Code())
+	var field string
+	for _, d := range st.Details() {
+		if br, ok := d.(*errdetails.BadRequest); ok {
+			field = br.FieldViolations[0].Field
+		}
+	}
+	require.Equal("charset", field)
+}
@@ -0,0 +1,141 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"golang.org/x/net/websocket"
+
+	protocol2 "gopkg.in/bblfsh/sdk.v2/protocol"
+)
+
+// WSOpParse is the operation of WSRequest that parses a file.
+const WSOpParse = "parse"
+
+// maxWSParses is the number of requests of a single WebSocket connection that are parsed concurrently.
+const maxWSParses = 16
+
+// WSRequest is a JSON message sent by the client over the WebSocket connection of the HTTP gateway.
+type WSRequest struct {
+	// ID is set by the client and is copied to the response. Responses may arrive in a different order.
+	ID uint64 `json:"id"`
+	// Op is an operation to perform. Only WSOpParse is supported; it is the default if Op is not set.
+	Op      string           `json:"op,omitempty"`
+	Request HTTPParseRequest `json:"request"`
+}
+
+// WSResponse is a JSON message sent by the server in reply to WSRequest. Code is an HTTP status code of
+// the request, the same as for POST /parse.
+type WSResponse struct {
+	ID       uint64             `json:"id"`
+	Code     int                `json:"code"`
+	Response *HTTPParseResponse `json:"response,omitempty"`
+	Error    string             `json:"error,omitempty"`
+}
+
+// newWSHandler creates a WebSocket handler for the gateway. Browsers may only connect from pages of the gateway
+// itself or from the allowed origins, see checkOrigin.
+func newWSHandler(p httpParser, origins []string) http.Handler {
+	return websocket.Server{
+		Handshake: checkOrigin(origins),
+		Handler: func(c *websocket.Conn) {
+			if p.maxSize > 0 {
+				c.MaxPayloadBytes = int(p.maxSize)
+			}
+			p.serveWS(c)
+		},
+	}
+}
+
+// checkOrigin returns a WebSocket handshake that rejects connections from pages of other hosts, unless their
+// origin is in the allowed list. An origin "*" allows any page. Clients that do not send the Origin header are
+// not browsers, thus they are always accepted.
+func checkOrigin(allowed []string) func(*websocket.Config, *http.Request) error {
+	return func(c *websocket.Config, r *http.Request) error {
+		origin, err := websocket.Origin(c, r)
+		if err != nil || origin == nil {
+			return err
+		}
+		c.Origin = origin
+		if strings.EqualFold(origin.Host, r.Host) {
+			return nil
+		}
+		o := origin.Scheme + "://" + origin.Host
+		for _, a := range allowed {
+			if a == "*" || strings.EqualFold(a, o) {
+				return nil
+			}
+		}
+		return fmt.Errorf("origin is not allowed: %s", o)
+	}
+}
+
+// serveWS reads requests from the connection until it is closed. Requests are parsed concurrently and responses
+// are sent as soon as they are ready. Each request is traced separately, as a part of the trace propagated
+// in the headers of the connection request.
+func (h httpParser) serveWS(c *websocket.Conn) {
+	ctx := c.Request().Context()
+	sc := spanContext(c.Request())
+	var (
+		mu  sync.Mutex // protects Send
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, maxWSParses)
+	)
+	defer wg.Wait()
+	send := func(resp *WSResponse) {
+		mu.Lock()
+		defer mu.Unlock()
+		// if the connection is broken, Receive fails as well
+		_ = websocket.JSON.Send(c, resp)
+	}
+	for {
+		var req WSRequest
+		err := websocket.JSON.Receive(c, &req)
+		switch err.(type) {
+		case nil:
+		case *json.SyntaxError, *json.UnmarshalTypeError:
+			send(&WSResponse{ID: req.ID, Code: http.StatusBadRequest, Error: fmt.Sprintf("invalid request: %v", err)})
+			continue
+		default:
+			if err == websocket.ErrFrameTooLarge {
+				// the rest of the frame is discarded, thus the connection can still be used
+				send(&WSResponse{Code: http.StatusRequestEntityTooLarge, Error: err.Error()})
+				continue
+			}
+			return
+		}
+		if req.Op != "" && req.Op != WSOpParse {
+			send(&WSResponse{ID: req.ID, Code: http.StatusBadRequest, Error: fmt.Sprintf("unknown operation: %q", req.Op)})
+			continue
+		}
+		if d := h.limited(c.Request()); d > 0 {
+			send(&WSResponse{ID: req.ID, Code: http.StatusTooManyRequests, Error: protocol2.ErrRateLimited.New(d).Error()})
+			continue
+		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			sp := opentracing.GlobalTracer().StartSpan("bblfsh.ws.Parse", ext.RPCServerOption(sc))
+			defer func() {
+				sp.Finish()
+				<-sem
+				wg.Done()
+			}()
+			resp, code, err := h.parse(opentracing.ContextWithSpan(ctx, sp), &req.Request)
+			if err == nil {
+				code = http.StatusOK
+			}
+			ext.HTTPStatusCode.Set(sp, uint16(code))
+			if err != nil {
+				send(&WSResponse{ID: req.ID, Code: code, Error: err.Error()})
+				return
+			}
+			send(&WSResponse{ID: req.ID, Code: code, Response: resp})
+		}()
+	}
+}
@@ -0,0 +1,147 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"gopkg.in/src-d/go-errors.v1"
+)
+
+// ErrStageTimeout is returned if a stage of the driver did not finish in time. See Pipeline.SetTimeout.
+var ErrStageTimeout = errors.NewKind("stage %q timed out after %v")
+
+// StageNative is a name of the native parsing stage. It is not a part of the pipeline, but accepts a timeout
+// the same way as other stages.
+const StageNative = "native"
+
+// TimeoutDriver is an optional interface for drivers that limit the time of each stage.
+type TimeoutDriver interface {
+	Driver
+	// SetTimeout sets a timeout for a stage with a given name. See Pipeline.SetTimeout.
+	SetTimeout(stage string, d time.Duration) error
+}
+
+// SetTimeout sets a timeout for a stage with a given name. If the stage does not finish in time, the pipeline stops
+// with ErrStageTimeout, which reports the stage name. Zero disables the timeout.
+//
+// The stage gets a context with the deadline, but it is not waited for after the timeout. StageNative sets
+// the timeout of the native driver, see NewDriverWithPipeline.
+func (p *Pipeline) SetTimeout(stage string, d time.Duration) error {
+	if stage != StageNative {
+		if _, err := p.index(stage); err != nil {
+			return err
+		}
+	}
+	if d <= 0 {
+		delete(p.timeouts, stage)
+		return nil
+	}
+	if p.timeouts == nil {
+		p.timeouts = make(map[string]time.Duration)
+	}
+	p.timeouts[stage] = d
+	return nil
+}
+
+// Timeout returns the timeout of a given stage, or zero if it is not set.
+func (p *Pipeline) Timeout(stage string) time.Duration {
+	return p.timeouts[stage]
+}
+
+// withTimeout runs fnc with the timeout of the stage. If the timeout expires, ErrStageTimeout is returned without
+// waiting for fnc, thus the caller must not access values set by fnc in this case. The duration of the stage is
+// reported to the observer, if it is set.
+func (p *Pipeline) withTimeout(ctx context.Context, stage string, fnc func(ctx context.Context) error) (err error) {
+	if p.observer != nil {
+		defer func(start time.Time) {
+			p.observe(stage, start, err)
+		}(time.Now())
+	}
+	d := p.timeouts[stage]
+	if d <= 0 {
+		return fnc(ctx)
+	}
+	// the deadline of the context is never before the one of the stage
+	sd := stageDeadline{stage: stage, at: time.Now().Add(d)}
+	tctx, cancel := context.WithTimeout(ctx, d)
+	defer cancel()
+	tctx = context.WithValue(tctx, stageTimeoutKey{}, sd)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- fnc(tctx)
+	}()
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case err := <-done:
+		if err != nil && ctx.Err() == nil && tctx.Err() == context.DeadlineExceeded {
+			// the stage was interrupted by its own deadline
+			return ErrStageTimeout.New(stage, d)
+		}
+		return err
+	case <-t.C:
+		return ErrStageTimeout.New(stage, d)
+	}
+}
+
+type stageTimeoutKey struct{}
+
+type stageDeadline struct {
+	stage string
+	at    time.Time
+}
+
+// TimedOut checks if the timeout of a given stage has expired for the context. Native drivers use it to tell
+// the timeout of StageNative, after which the native process should be restarted, from the cancellation
+// of the request.
+func TimedOut(ctx context.Context, stage string) bool {
+	sd, ok := ctx.Value(stageTimeoutKey{}).(stageDeadline)
+	return ok && sd.stage == stage && !time.Now().Before(sd.at)
+}
+
+// ParseTimeouts parses a comma-separated list of stage timeouts in the "stage=duration" form, for example
+// "native=10s,semantic=5s".
+func ParseTimeouts(s string) (map[string]time.Duration, error) {
+	out := make(map[string]time.Duration)
+	for _, kv := range strings.Split(s, ",") {
+		kv = strings.TrimSpace(kv)
+		if kv == "" {
+			continue
+		}
+		i := strings.Index(kv, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("invalid stage timeout: %q", kv)
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(kv[i+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout of stage %q: %v", kv[:i], err)
+		}
+		out[strings.TrimSpace(kv[:i])] = d
+	}
+	return out, nil
+}
+
+var _ TimeoutDriver = (*driverImpl)(nil)
+
+// SetTimeout implements TimeoutDriver.
+func (d *driverImpl) SetTimeout(stage string, t time.Duration) error {
+	return d.p.SetTimeout(stage, t)
+}
+
+// native calls the native driver with the timeout of StageNative. The timeout is reported as ErrDriverFailure.
+// The native driver may check TimedOut to restart the process that is still busy with the request.
+// Values set by fnc must not be used if ErrStageTimeout is returned.
+func (d *driverImpl) native(rctx context.Context, fnc func(ctx context.Context) error) error {
+	sp, ctx := startStage(rctx, "uast.Native", StageNative)
+	defer sp.Finish()
+
+	err := d.p.withTimeout(ctx, StageNative, fnc)
+	if ErrStageTimeout.Is(err) && !ErrDriverFailure.Is(err) {
+		err = ErrDriverFailure.Wrap(err)
+	}
+	TraceError(sp, err)
+	return err
+}
@@ -0,0 +1,80 @@
+package driver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/bblfsh/sdk.v2/driver/manifest"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+// slowNative blocks on the "slow" source until the request is cancelled.
+type slowNative struct {
+	echoNative
+}
+
+func (n slowNative) Parse(ctx context.Context, src string) (nodes.Node, error) {
+	if src == "slow" {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return n.echoNative.Parse(ctx, src)
+}
+
+func TestDriverStageTimeout(t *testing.T) {
+	require := require.New(t)
+
+	m := &manifest.Manifest{Language: "fixture"}
+	m.Runtime.Timeouts = map[string]string{StageNative: "20ms"}
+
+	// the stage ignores the context and is not waited for
+	release := make(chan struct{})
+	defer close(release)
+	p := Transforms{}.Pipeline()
+	p.Append(Stage{
+		Name: "slow", Mode: ModeAnnotated,
+		Do: func(ctx context.Context, st *FileState, nd nodes.Node) (nodes.Node, error) {
+			if nd.(nodes.Object)["src"] == nodes.String("slow-stage") {
+				<-release
+			}
+			return nd, nil
+		},
+	})
+	dm, err := NewDriverWithPipeline(slowNative{}, m, p)
+	require.NoError(err)
+	require.Equal(20*time.Millisecond, p.Timeout(StageNative))
+
+	d := WithMiddleware(dm, RecoverMiddleware())
+	td, ok := d.(TimeoutDriver)
+	require.True(ok)
+	require.True(ErrUnknownStage.Is(td.SetTimeout("unknown", time.Second)))
+	require.NoError(td.SetTimeout("slow", 20*time.Millisecond))
+
+	ctx := context.Background()
+	_, err = d.Parse(ctx, "fast", &ParseOptions{Mode: ModeAnnotated})
+	require.NoError(err)
+
+	ast, err := d.Parse(ctx, "slow", &ParseOptions{Mode: ModeAnnotated})
+	require.True(ErrDriverFailure.Is(err), "%v", err)
+	require.True(ErrStageTimeout.Is(err), "%v", err)
+	require.Contains(err.Error(), `stage "native" timed out`)
+	require.Nil(ast)
+
+	ast, err = d.Parse(ctx, "slow-stage", &ParseOptions{Mode: ModeAnnotated})
+	require.True(ErrTransformFailure.Is(err), "%v", err)
+	require.True(ErrStageTimeout.Is(err), "%v", err)
+	require.Contains(err.Error(), `stage "slow" timed out`)
+	require.Nil(ast)
+
+	// the stage does not run in native mode
+	_, err = d.Parse(ctx, "slow-stage", &ParseOptions{Mode: ModeNative})
+	require.NoError(err)
+
+	timeouts, err := ParseTimeouts("native=10s, semantic=500ms")
+	require.NoError(err)
+	require.Equal(map[string]time.Duration{StageNative: 10 * time.Second, StageSemantic: 500 * time.Millisecond}, timeouts)
+	_, err = ParseTimeouts("native")
+	require.Error(err)
+}
@@ -0,0 +1,82 @@
+package driver
+
+import (
+	"context"
+	"sort"
+
+	"gopkg.in/bblfsh/sdk.v2/uast"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+// parseTokens returns tokens of the source for ModeTokens. If the native driver does not implement TokensNative,
+// or language options are set, the source is parsed and transformed to ModeAnnotated, and tokens are collected
+// from the tree.
+func (d *driverImpl) parseTokens(ctx context.Context, src string, opts LanguageOptions) (nodes.Node, LanguageOptions, error) {
+	if t, ok := d.d.(TokensNative); ok && opts.IsZero() {
+		var toks nodes.Array
+		err := d.native(ctx, func(ctx context.Context) (err error) {
+			toks, err = t.ParseTokens(ctx, src)
+			return err
+		})
+		if ErrStageTimeout.Is(err) {
+			return nil, LanguageOptions{}, err
+		} else if !ErrModeNotSupported.Is(err) {
+			if err != nil && !ErrDriverFailure.Is(err) {
+				err = ErrSyntax.Wrap(err)
+			}
+			if toks == nil {
+				return nil, LanguageOptions{}, err
+			}
+			return toks, LanguageOptions{}, err
+		}
+	}
+	ast, lopts, err := d.parseNative(ctx, src, opts)
+	ast, err = d.transform(ctx, ModeAnnotated, src, ast, err, nil)
+	if err != nil && !ErrPartialTransform.Is(err) {
+		return nil, lopts, err
+	}
+	return TokenStream(ast), lopts, err
+}
+
+// TokenStream collects all tokens of the tree and returns them ordered by the start offset. Each token is an object
+// with uast.KeyToken and uast.KeyPos fields, the same as the ones returned in ModeTokens. Tokens without positions
+// are skipped.
+func TokenStream(n nodes.Node) nodes.Array {
+	type token struct {
+		off uint32
+		obj nodes.Object
+	}
+	var toks []token
+	nodes.WalkPreOrder(n, func(n nodes.Node) bool {
+		obj, ok := n.(nodes.Object)
+		if !ok {
+			return true
+		}
+		tok := uast.TokenOf(obj)
+		if tok == "" {
+			return true
+		}
+		ps := uast.PositionsOf(obj)
+		start := ps.Start()
+		if start == nil {
+			return true
+		}
+		pos := uast.Positions{uast.KeyStart: *start}
+		if end := ps.End(); end != nil {
+			pos[uast.KeyEnd] = *end
+		}
+		toks = append(toks, token{off: start.Offset, obj: nodes.Object{
+			uast.KeyToken: nodes.String(tok),
+			uast.KeyPos:   pos.ToObject(),
+		}})
+		return true
+	})
+	sort.SliceStable(toks, func(i, j int) bool {
+		return toks[i].off < toks[j].off
+	})
+	out := make(nodes.Array, 0, len(toks))
+	for _, t := range toks {
+		out = append(out, t.obj)
+	}
+	return out
+}
@@ -0,0 +1,26 @@
+package driver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/bblfsh/sdk.v2/driver/manifest"
+	"gopkg.in/bblfsh/sdk.v2/uast"
+)
+
+func TestDriverParseTokens(t *testing.T) {
+	require := require.New(t)
+
+	m := &manifest.Manifest{Language: "fixture"}
+
+	// native drivers without TokensNative support
+	d, err := NewDriverFrom(treeNative{}, m, Transforms{})
+	require.NoError(err)
+	ctx := context.Background()
+	opts := &ParseOptions{Mode: ModeTokens}
+	toks, err := d.Parse(ctx, "a b", opts)
+	require.NoError(err)
+	require.Equal([]string{"a", "b"}, uast.Tokens(toks))
+	require.Equal("fixture", opts.Language)
+}
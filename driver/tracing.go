@@ -0,0 +1,53 @@
+package driver
+
+import (
+	"context"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/log"
+)
+
+// Tags set on the tracing spans of the driver, which allow to find slow requests of a specific language,
+// transformation mode or stage.
+const (
+	TagLanguage = "bblfsh.language"
+	TagMode     = "bblfsh.mode"
+	TagStage    = "bblfsh.stage"
+	TagFilename = "bblfsh.filename"
+	TagFiles    = "bblfsh.files" // number of files in ParseFiles
+	TagSize     = "bblfsh.size"  // size of the encoded tree
+)
+
+// TraceError records the error on the span. Only failures mark the span as failed; syntax errors and partial
+// transformations are logged, since the tree is still returned.
+func TraceError(sp opentracing.Span, err error) {
+	if err == nil {
+		return
+	}
+	if !ErrSyntax.Is(err) && !ErrPartialTransform.Is(err) {
+		ext.Error.Set(sp, true)
+	}
+	sp.LogFields(log.String("event", "error"), log.String("message", err.Error()))
+}
+
+// traceRequest sets tags of the parse request on the span.
+func traceRequest(sp opentracing.Span, mode Mode, lang, filename string) {
+	if mode == 0 {
+		mode = ModeDefault
+	}
+	sp.SetTag(TagMode, mode.String())
+	if lang != "" {
+		sp.SetTag(TagLanguage, lang)
+	}
+	if filename != "" {
+		sp.SetTag(TagFilename, filename)
+	}
+}
+
+// startStage starts a span for a stage of the driver.
+func startStage(ctx context.Context, op, stage string) (opentracing.Span, context.Context) {
+	sp, ctx := opentracing.StartSpanFromContext(ctx, op)
+	sp.SetTag(TagStage, stage)
+	return sp, ctx
+}
@@ -2,15 +2,19 @@ package driver
 
 import (
 	"context"
-
-	"github.com/opentracing/opentracing-go"
+	"fmt"
+	"time"
 
 	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
 	"gopkg.in/bblfsh/sdk.v2/uast/transformer"
+	"gopkg.in/bblfsh/sdk.v2/uast/transformer/positioner"
 )
 
 // Transforms describes a set of AST transformations the driver requires.
 //
+// All transformers of the pipeline share a single transformer.Context for each file.
+// See transformer.WithContext for details.
+//
 // The pipeline can be illustrated as:
 //         ( AST )--------------> ( ModeNative )
 //            V
@@ -28,6 +32,13 @@ import (
 //            V                V
 //     ( ModeAnnotated ) ( ModeSemantic )
 type Transforms struct {
+	// Source rewrites the source before it is passed to the native driver, for example, to strip template code.
+	// Preprocessors are applied in order. See Preprocessor and Chain.
+	Source []Preprocessor
+
+	// Middleware wraps the driver, for example, to log or cache requests. See WithMiddleware.
+	Middleware []Middleware
+
 	// Namespace for native AST nodes of this language. Only enabled in Semantic mode.
 	//
 	// Namespace will be set at the end of the pipeline, thus all transforms can
@@ -67,98 +78,304 @@ type Transforms struct {
 	//
 	// Deprecated: see PreprocessCode
 	Code []transformer.CodeTransformer
+
+	// Workers sets the number of goroutines used to run Normalize and Annotations stages.
+	// The tree will be split into subtrees at the level of top-level declarations of the file. Transformers that
+	// use the per-file context still run sequentially, see transformer.Parallel for details.
+	Workers int
+
+	// Policies sets how transformation errors are handled on each stage. By default, the pipeline stops on
+	// the first error.
+	Policies Policies
+
+	// Positions describes positional information reported by the native parser. If set, positions are converted
+	// to byte offsets, lines and columns before running PreprocessCode transformers, thus drivers don't need to
+	// add a positioner to the stage.
+	Positions PositionConfig
+
+	// ErrorNodes is a list of native node types used by the parser for regions it failed to parse, when it recovers
+	// from syntax errors. If set, such nodes are converted to uast:Error after the PreprocessCode stage, and partial
+	// trees are transformed the same way as valid ones, instead of returning the native AST.
+	ErrorNodes []string
+
+	// MemoryLimit is an approximate budget for the tree in bytes. See Pipeline.SetMemoryLimit.
+	MemoryLimit int
+
+	// Timeouts of the stages by name, including StageNative. See Pipeline.SetTimeout.
+	Timeouts map[string]time.Duration
 }
 
-// Do applies AST transformation pipeline for specified AST subtree.
-//
-// Mode can be specified to stop the pipeline at a specific abstraction level.
-func (t Transforms) Do(rctx context.Context, mode Mode, code string, nd nodes.Node) (nodes.Node, error) {
-	sp, ctx := opentracing.StartSpanFromContext(rctx, "uast.Transform")
-	defer sp.Finish()
+// PositionSource selects fields of native positions used to compute all other positional fields.
+type PositionSource int
+
+const (
+	// PositionsNone disables the conversion of positions.
+	PositionsNone PositionSource = iota
+	// PositionsFromOffset computes lines and columns from offsets.
+	PositionsFromOffset
+	// PositionsFromLineCol computes offsets from lines and columns.
+	PositionsFromLineCol
+)
+
+// PositionConfig describes positional information reported by the native parser.
+type PositionConfig struct {
+	// From selects fields of native positions used to compute all other fields.
+	From PositionSource
+	// Units of offsets and columns reported by the native parser. Both offsets and columns in the UAST
+	// are always in bytes.
+	Units positioner.Units
+}
 
-	if mode > ModeSemantic {
-		return nil, ErrModeNotSupported.New()
+// positioner returns a positioner for the config, or nil if the conversion is disabled.
+func (c PositionConfig) positioner() transformer.CodeTransformer {
+	switch c.From {
+	case PositionsFromOffset:
+		return positioner.FromOffsetUnits(c.Units)
+	case PositionsFromLineCol:
+		return positioner.FromLineColUnits(c.Units)
 	}
-	if mode == 0 {
-		mode = ModeDefault
+	return nil
+}
+
+// preprocessCode returns transformers of the PreprocessCode stage with a positioner, if it's configured.
+func (t Transforms) preprocessCode() []transformer.CodeTransformer {
+	p := t.Positions.positioner()
+	if p == nil {
+		return t.PreprocessCode
 	}
-	if mode == ModeNative {
-		return nd, nil
+	return append([]transformer.CodeTransformer{p}, t.PreprocessCode...)
+}
+
+// FailurePolicy defines how a stage of the pipeline handles transformation errors.
+type FailurePolicy int
+
+const (
+	// FailFast stops the pipeline on the first error.
+	FailFast FailurePolicy = iota
+	// SkipNode leaves nodes that failed to transform unchanged and continues the pipeline.
+	// See transformer.Tolerant for details.
+	SkipNode
+	// BestEffort continues the pipeline with a tree returned by a failed transformer, or with the tree it received.
+	// See transformer.BestEffort for details.
+	BestEffort
+)
+
+// Policies sets failure policies for each stage of the pipeline.
+//
+// For all policies except FailFast, the errors are returned as ErrPartialTransform together with the resulting tree,
+// the same way as transformation warnings.
+type Policies struct {
+	Preprocess     FailurePolicy
+	PreprocessCode FailurePolicy
+	Normalize      FailurePolicy
+	Annotations    FailurePolicy
+	Code           FailurePolicy
+}
+
+// AllStages returns policies that use the same failure policy for all stages.
+func AllStages(p FailurePolicy) Policies {
+	return Policies{
+		Preprocess:     p,
+		PreprocessCode: p,
+		Normalize:      p,
+		Annotations:    p,
+		Code:           p,
 	}
+}
 
-	return t.do(ctx, mode, code, nd)
+// parallelDepth is the depth of the tree at which Transforms splits the tree for parallel processing.
+// It assumes that the root node is a file that contains an array of top-level declarations.
+const parallelDepth = 2
+
+// parallel wraps all transformers for concurrent execution, if enabled.
+func (t Transforms) parallel(list []transformer.Transformer) []transformer.Transformer {
+	if t.Workers <= 1 {
+		return list
+	}
+	out := make([]transformer.Transformer, 0, len(list))
+	for _, tr := range list {
+		out = append(out, transformer.Parallel(tr, t.Workers, parallelDepth))
+	}
+	return out
 }
 
-func (t Transforms) do(ctx context.Context, mode Mode, code string, nd nodes.Node) (nodes.Node, error) {
+// wrapOne binds the transformer to a per-file context and applies a failure policy to it. Warnings are recorded to
+// the file diagnostics, as well as errors for all policies except FailFast.
+//
+// If the file is transformed incrementally, the transformer will skip subtrees reused from the previous
+// transformation. An error is returned if the transformer cannot skip subtrees.
+func wrapOne(st *FileState, p FailurePolicy, tr transformer.Transformer) (transformer.Transformer, error) {
+	tr = transformer.WithContext(tr, st.Context)
+	tr = transformer.WithWarnings(tr, st.Diagnostics)
+	skip := func(tr transformer.Transformer) (transformer.Transformer, error) {
+		if st.reused == nil {
+			return tr, nil
+		}
+		tr, ok := transformer.SkipSubtrees(tr, parallelDepth, st.Reused)
+		if !ok {
+			return nil, errNotIncremental.New(fmt.Sprintf("transformer %T", tr))
+		}
+		return tr, nil
+	}
 	var err error
-	runAll := func(name string, list []transformer.Transformer) error {
-		sp, _ := opentracing.StartSpanFromContext(ctx, "uast.Transform."+name)
-		defer sp.Finish()
+	switch p {
+	case SkipNode:
+		tr, err = skip(transformer.Tolerant(tr, st.Diagnostics))
+	case BestEffort:
+		tr, err = skip(tr)
+		if err == nil {
+			tr = transformer.BestEffort(tr, st.Diagnostics)
+		}
+	default:
+		tr, err = skip(tr)
+	}
+	return tr, err
+}
 
-		for _, t := range list {
-			nd, err = t.Do(nd)
-			if err != nil {
-				return err
-			}
+// wrap calls wrapOne for each transformer in the list.
+func wrap(st *FileState, p FailurePolicy, list []transformer.Transformer) ([]transformer.Transformer, error) {
+	out := make([]transformer.Transformer, 0, len(list))
+	for _, tr := range list {
+		tr, err := wrapOne(st, p, tr)
+		if err != nil {
+			return nil, err
 		}
-		return nil
+		out = append(out, tr)
 	}
-	runAllCode := func(name string, list []transformer.CodeTransformer) error {
-		sp, _ := opentracing.StartSpanFromContext(ctx, "uast.Transform."+name)
-		defer sp.Finish()
+	return out, nil
+}
 
-		for _, ct := range list {
-			t := ct.OnCode(code)
-			nd, err = t.Do(nd)
-			if err != nil {
-				return err
+// Validate checks mapping rules of all stages for errors that can be detected without running the transformation.
+// See transformer.Validate for details.
+func (t Transforms) Validate() error {
+	var errs []error
+	check := func(stage string, list []transformer.Transformer) {
+		for i, tr := range list {
+			if err := transformer.Validate(tr); err != nil {
+				errs = append(errs, fmt.Errorf("%s[%d]: %v", stage, i, err))
 			}
 		}
-		return nil
 	}
+	check("preprocess", t.Preprocess)
+	check("semantic", t.Normalize)
+	check("annotated", t.Annotations)
+	if len(errs) != 0 {
+		return JoinErrors(errs)
+	}
+	return nil
+}
+
+// Pipeline returns a transformation pipeline that consists of all stages of Transforms.
+// The caller may change the pipeline, for example, insert custom stages or disable existing ones.
+// See Stage* constants for stage names.
+func (t Transforms) Pipeline() *Pipeline {
+	p := NewPipeline(
+		transformStage(StagePreprocess, ModePreprocessed, t.Policies.Preprocess, t.Preprocess, nil),
+		// the second pre-processing stage can access the source code (to fix tokens, for example)
+		codeStage(StagePreprocessCode, ModePreprocessed, t.Policies.PreprocessCode, t.preprocessCode()),
+
+		// First run Semantic mode (UAST canonicalization).
+		// It's considered a more high-level representation, but it needs
+		// a clean AST to run, so we execute it before Annotated mode.
+		incremental(transformStage(StageSemantic, ModeSemantic, t.Policies.Normalize, t.Normalize, t.parallel)),
+
+		// Next run Annotated mode. It won't see nodes converted by Semantic nodes,
+		// because it expects a clean native AST.
+		// This is intentional — Semantic nodes are already defined with specific
+		// roles in mind, thus they shouldn't be annotated further on this stage.
+		incremental(transformStage(StageAnnotated, ModeAnnotated, t.Policies.Annotations, t.Annotations, t.parallel)),
 
-	// Preprocess AST and optionally use the second pre-processing stage
-	// that can access the source code (to fix tokens, for example).
-	if err := runAll("preprocess", t.Preprocess); err != nil {
-		return nd, err
+		// Run a code-assisted post-processing. Deprecated.
+		// There is no real reason to run it after all other stages except Preprocess.
+		incremental(codeStage(StageCode, ModePreprocessed, t.Policies.Code, t.Code)),
+
+		// All native nodes should have a namespace in Semantic mode.
+		// Set if it was specified in the transform configuration.
+		Stage{
+			Name: StageNamespace, Mode: ModeSemantic, Incremental: true,
+			Do: func(ctx context.Context, st *FileState, nd nodes.Node) (nodes.Node, error) {
+				if t.Namespace == "" {
+					return nd, nil
+				}
+				tr, err := wrapOne(st, FailFast, transformer.DefaultNamespace(t.Namespace))
+				if err != nil {
+					return nd, err
+				}
+				return runAll(nd, []transformer.Transformer{tr})
+			},
+		},
+	)
+	p.SetMemoryLimit(t.MemoryLimit)
+	if len(t.ErrorNodes) != 0 {
+		// errors must be detected before any stage that may change native types
+		p.InsertAfter(StagePreprocessCode, recoverStage(t.ErrorNodes))
 	}
-	if err := runAllCode("preprocess-code", t.PreprocessCode); err != nil {
-		return nd, err
+	for stage, d := range t.Timeouts {
+		p.SetTimeout(stage, d)
 	}
+	return p
+}
 
-	// First run Semantic mode (UAST canonicalization).
-	// It's considered a more high-level representation, but it needs
-	// a clean AST to run, so we execute it before Annotated mode.
-	if mode >= ModeSemantic {
-		if err := runAll("semantic", t.Normalize); err != nil {
+// runAll runs all transformers in order.
+func runAll(nd nodes.Node, list []transformer.Transformer) (nodes.Node, error) {
+	for _, tr := range list {
+		var err error
+		nd, err = tr.Do(nd)
+		if err != nil {
 			return nd, err
 		}
 	}
+	return nd, nil
+}
 
-	// Next run Annotated mode. It won't see nodes converted by Semantic nodes,
-	// because it expects a clean native AST.
-	// This is intentional — Semantic nodes are already defined with specific
-	// roles in mind, thus they shouldn't be annotated further on this stage.
-	if mode >= ModeAnnotated {
-		if err := runAll("annotated", t.Annotations); err != nil {
-			return nd, err
-		}
+// transformStage creates a pipeline stage that runs a list of transformers with a given failure policy.
+// An optional wrapper function is applied to the list after binding it to the file state. The wrapper is not used
+// for incremental transformations.
+func transformStage(name string, mode Mode, p FailurePolicy, list []transformer.Transformer, wrapper func([]transformer.Transformer) []transformer.Transformer) Stage {
+	return Stage{
+		Name: name, Mode: mode,
+		Do: func(ctx context.Context, st *FileState, nd nodes.Node) (nodes.Node, error) {
+			trs, err := wrap(st, p, list)
+			if err != nil {
+				return nd, err
+			}
+			if wrapper != nil && st.reused == nil {
+				trs = wrapper(trs)
+			}
+			return runAll(nd, trs)
+		},
 	}
+}
 
-	// Run a code-assisted post-processing. Deprecated.
-	// There is no real reason to run it after all other stages except Preprocess.
-	if err := runAllCode("on-code", t.Code); err != nil {
-		return nd, err
-	}
+// incremental marks the stage as incremental. Stages created by transformStage and codeStage skip reused subtrees,
+// but only stages that run after the last pre-processing stage are marked, thus the pre-processing is always done
+// for the whole tree.
+func incremental(s Stage) Stage {
+	s.Incremental = true
+	return s
+}
 
-	// All native nodes should have a namespace in Semantic mode.
-	// Set if it was specified in the transform configuration.
-	if mode >= ModeSemantic && t.Namespace != "" {
-		tr := transformer.DefaultNamespace(t.Namespace)
-		if err := runAll("namespace", []transformer.Transformer{tr}); err != nil {
-			return nd, err
-		}
+// codeStage creates a pipeline stage that runs a list of code-assisted transformers with a given failure policy.
+func codeStage(name string, mode Mode, p FailurePolicy, list []transformer.CodeTransformer) Stage {
+	return Stage{
+		Name: name, Mode: mode,
+		Do: func(ctx context.Context, st *FileState, nd nodes.Node) (nodes.Node, error) {
+			trs := make([]transformer.Transformer, 0, len(list))
+			for _, ct := range list {
+				tr, err := wrapOne(st, p, ct.OnCode(st.Code))
+				if err != nil {
+					return nd, err
+				}
+				trs = append(trs, tr)
+			}
+			return runAll(nd, trs)
+		},
 	}
+}
 
-	return nd, nil
+// Do applies AST transformation pipeline for specified AST subtree.
+//
+// Mode can be specified to stop the pipeline at a specific abstraction level.
+func (t Transforms) Do(ctx context.Context, mode Mode, code string, nd nodes.Node) (nodes.Node, error) {
+	return t.Pipeline().Do(ctx, mode, code, nd)
 }
@@ -0,0 +1,148 @@
+package protocol
+
+import (
+	"context"
+	"crypto/subtle"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	serrors "gopkg.in/src-d/go-errors.v1"
+)
+
+// ErrUnauthenticated is returned by the server if the request has no valid token. See TokenAuth.
+var ErrUnauthenticated = serrors.NewKind("request is not authenticated")
+
+const (
+	authHeader   = "authorization"
+	bearerPrefix = "Bearer "
+	healthPrefix = "/grpc.health.v1.Health/"
+)
+
+// ValidToken checks if the value of the authorization header contains one of the bearer tokens.
+func ValidToken(tokens []string, header string) bool {
+	if !strings.HasPrefix(header, bearerPrefix) {
+		return false
+	}
+	tok := []byte(strings.TrimPrefix(header, bearerPrefix))
+	ok := false
+	for _, t := range tokens {
+		// check all tokens to not leak which one matched
+		if subtle.ConstantTimeCompare(tok, []byte(t)) == 1 {
+			ok = true
+		}
+	}
+	return ok
+}
+
+type tokenKey struct{}
+
+// ContextWithToken returns a context with a bearer token of the request that was validated by the server, as done
+// by TokenAuth. Clients are identified by this token for rate limits. See ValidToken.
+func ContextWithToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, tokenKey{}, token)
+}
+
+// authToken returns the token set by ContextWithToken.
+func authToken(ctx context.Context) (string, bool) {
+	tok, ok := ctx.Value(tokenKey{}).(string)
+	return tok, ok
+}
+
+// TokenAuth returns server interceptors that require one of the bearer tokens in the authorization metadata of each
+// request. Health checks are allowed without a token. The validated token is set in the context of the call, see
+// ContextWithToken. See ServerOptionsWith and WithToken.
+func TokenAuth(tokens ...string) (grpc.UnaryServerInterceptor, grpc.StreamServerInterceptor) {
+	check := func(ctx context.Context, method string) (context.Context, error) {
+		if strings.HasPrefix(method, healthPrefix) {
+			return ctx, nil
+		}
+		md, _ := metadata.FromIncomingContext(ctx)
+		for _, h := range md.Get(authHeader) {
+			if ValidToken(tokens, h) {
+				return ContextWithToken(ctx, strings.TrimPrefix(h, bearerPrefix)), nil
+			}
+		}
+		return nil, status.Error(codes.Unauthenticated, ErrUnauthenticated.New().Error())
+	}
+	unary := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := check(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+	stream := func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := check(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authStream{ServerStream: ss, ctx: ctx})
+	}
+	return unary, stream
+}
+
+// authStream replaces the context of the stream with the one that has the validated token.
+type authStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context implements grpc.ServerStream.
+func (s *authStream) Context() context.Context {
+	return s.ctx
+}
+
+// WithToken returns a dial option that sends a bearer token with each request. The token is only sent over
+// secure connections, thus it must be used together with grpc.WithTransportCredentials.
+func WithToken(token string) grpc.DialOption {
+	return grpc.WithPerRPCCredentials(tokenCreds(token))
+}
+
+type tokenCreds string
+
+// GetRequestMetadata implements credentials.PerRPCCredentials.
+func (t tokenCreds) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{authHeader: bearerPrefix + string(t)}, nil
+}
+
+// RequireTransportSecurity implements credentials.PerRPCCredentials.
+func (tokenCreds) RequireTransportSecurity() bool {
+	return true
+}
+
+// chainUnary combines interceptors into one. They are called in the given order.
+func chainUnary(ints []grpc.UnaryServerInterceptor) grpc.UnaryServerInterceptor {
+	if len(ints) == 1 {
+		return ints[0]
+	}
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		h := handler
+		for i := len(ints) - 1; i >= 0; i-- {
+			in, next := ints[i], h
+			h = func(ctx context.Context, req interface{}) (interface{}, error) {
+				return in(ctx, req, info, next)
+			}
+		}
+		return h(ctx, req)
+	}
+}
+
+// chainStream combines interceptors into one. They are called in the given order.
+func chainStream(ints []grpc.StreamServerInterceptor) grpc.StreamServerInterceptor {
+	if len(ints) == 1 {
+		return ints[0]
+	}
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		h := handler
+		for i := len(ints) - 1; i >= 0; i-- {
+			in, next := ints[i], h
+			h = func(srv interface{}, ss grpc.ServerStream) error {
+				return in(srv, ss, info, next)
+			}
+		}
+		return h(srv, ss)
+	}
+}
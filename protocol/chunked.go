@@ -0,0 +1,123 @@
+package protocol
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultChunkSize is the size of the UAST data in each chunk of ParseChunked, if the client does not set it.
+// It is well below the default gRPC message size limit of 4MB.
+const DefaultChunkSize = 1 << 20
+
+// minChunkSize is the smallest size of chunks sent by ParseChunked. Smaller sizes requested by the client are
+// increased to avoid sending a message per few bytes of the tree.
+const minChunkSize = 4 << 10
+
+// maxChunkedPrealloc limits the size of the buffer allocated upfront by ReadChunks. The size reported by the server
+// is not trusted, so the buffer is only preallocated up to the default gRPC receive limit and grows as data arrives.
+const maxChunkedPrealloc = 4 << 20
+
+// ParseChunked implements DriverServer. The tree is encoded the same way as for Parse and sent in chunks of
+// the requested size.
+func (s *driverServer) ParseChunked(req *ParseChunkedRequest, srv Driver_ParseChunkedServer) error {
+	if req.Request == nil {
+		req.Request = &ParseRequest{}
+	}
+	resp, err := s.Parse(srv.Context(), req.Request)
+	if err != nil {
+		return err
+	}
+	size := int(req.ChunkSize)
+	if size <= 0 {
+		size = DefaultChunkSize
+	} else if size < minChunkSize {
+		size = minChunkSize
+	}
+	data := resp.Uast
+	resp.Uast = nil
+	if err = srv.Send(&ParseChunk{Response: resp, Size: uint64(len(data))}); err != nil {
+		return err
+	}
+	for len(data) != 0 {
+		n := size
+		if n > len(data) {
+			n = len(data)
+		}
+		if err = srv.Send(&ParseChunk{Data: data[:n]}); err != nil {
+			return err
+		}
+		data = data[n:]
+	}
+	return nil
+}
+
+// ReadChunks reads all chunks of the ParseChunked reply and reassembles the response. Errors of the stream are
+// returned as-is.
+func ReadChunks(s Driver_ParseChunkedClient) (*ParseResponse, error) {
+	first, err := s.Recv()
+	if err != nil {
+		return nil, err
+	} else if first.Response == nil {
+		return nil, fmt.Errorf("no response in the first chunk")
+	}
+	resp := first.Response
+	prealloc := first.Size
+	if prealloc > maxChunkedPrealloc {
+		prealloc = maxChunkedPrealloc
+	}
+	buf := make([]byte, 0, prealloc)
+	for c := first; ; {
+		// the size is checked before appending to not buffer more data than the server announced
+		if uint64(len(buf))+uint64(len(c.Data)) > first.Size {
+			return nil, fmt.Errorf("expected %d bytes of UAST, got more", first.Size)
+		}
+		buf = append(buf, c.Data...)
+		c, err = s.Recv()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	if uint64(len(buf)) != first.Size {
+		return nil, fmt.Errorf("expected %d bytes of UAST, got %d", first.Size, len(buf))
+	}
+	resp.Uast = buf
+	return resp, nil
+}
+
+// tooLarge checks if the call failed because the message exceeds the gRPC size limit of the client or the server.
+// It returns the limit, or zero if it is not known.
+func tooLarge(err error) (int, bool) {
+	s, ok := status.FromError(err)
+	if !ok || s.Code() != codes.ResourceExhausted || !strings.Contains(s.Message(), "larger than max") {
+		return 0, false
+	}
+	msg := s.Message()
+	var size, max int
+	if i := strings.LastIndex(msg, "("); i >= 0 {
+		if _, err := fmt.Sscanf(msg[i:], "(%d vs. %d)", &size, &max); err != nil {
+			max = 0
+		}
+	}
+	return max, true
+}
+
+// parseChunked requests the response in chunks. The size of chunks is chosen to fit into the limit, leaving space
+// for the rest of the message.
+func (c *client) parseChunked(ctx context.Context, req *ParseRequest, limit int) (*ParseResponse, error) {
+	size := DefaultChunkSize
+	if limit > 0 && limit/2 < size {
+		size = limit / 2
+	}
+	s, err := c.c.ParseChunked(ctx, &ParseChunkedRequest{Request: req, ChunkSize: uint32(size)})
+	if err != nil {
+		return nil, err
+	}
+	return ReadChunks(s)
+}
@@ -0,0 +1,83 @@
+package protocol
+
+import (
+	"context"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/status"
+	serrors "gopkg.in/src-d/go-errors.v1"
+)
+
+// ErrCompressionRequired is returned by the server if it requires compression and the request is not compressed.
+// See ServiceOptions.
+var ErrCompressionRequired = serrors.NewKind("request compression is required")
+
+// CompressionGzip is the name of the gzip compressor. It is always registered, thus all clients and servers
+// support it.
+const CompressionGzip = gzip.Name
+
+var compressors = struct {
+	sync.RWMutex
+	names []string
+}{names: []string{CompressionGzip}}
+
+// RegisterCompressor registers a gRPC compressor, for example zstd, and advertises it in the Capabilities
+// response. It must be called during initialization on both the server and the client side.
+func RegisterCompressor(c encoding.Compressor) {
+	encoding.RegisterCompressor(c)
+	compressors.Lock()
+	defer compressors.Unlock()
+	for _, name := range compressors.names {
+		if name == c.Name() {
+			return
+		}
+	}
+	compressors.names = append(compressors.names, c.Name())
+}
+
+// Compressors returns names of compressors supported by this package. See RegisterCompressor.
+func Compressors() []string {
+	compressors.RLock()
+	defer compressors.RUnlock()
+	return append([]string{}, compressors.names...)
+}
+
+// WithCompression returns a dial option that compresses all requests with a given compressor. The server replies
+// using the same compressor.
+func WithCompression(name string) grpc.DialOption {
+	return grpc.WithDefaultCallOptions(grpc.UseCompressor(name))
+}
+
+// ServiceOptions configures the driver service. See RegisterDriverWith.
+type ServiceOptions struct {
+	// RequireCompression rejects requests that are not compressed, except for Capabilities, which allows clients
+	// to discover the requirement.
+	RequireCompression bool
+	// Metrics receives request counts, latencies and response sizes of the service, if set.
+	Metrics ServiceMetrics
+}
+
+// recvCompress returns the name of the compressor used for the request.
+func recvCompress(ctx context.Context) string {
+	s, ok := grpc.ServerTransportStreamFromContext(ctx).(interface{ RecvCompress() string })
+	if !ok {
+		return ""
+	}
+	return s.RecvCompress()
+}
+
+// checkCompression returns ErrCompressionRequired as a gRPC status error if the service requires compression and
+// the request is not compressed.
+func (s *driverServer) checkCompression(ctx context.Context) error {
+	if !s.opts.RequireCompression {
+		return nil
+	}
+	if c := recvCompress(ctx); c != "" && c != encoding.Identity {
+		return nil
+	}
+	return status.Error(codes.FailedPrecondition, ErrCompressionRequired.New().Error())
+}
@@ -0,0 +1,168 @@
+package protocol
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/any"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	serrors "gopkg.in/src-d/go-errors.v1"
+
+	"gopkg.in/bblfsh/sdk.v2/driver"
+	derrors "gopkg.in/bblfsh/sdk.v2/driver/errors"
+	"gopkg.in/bblfsh/sdk.v2/driver/native"
+)
+
+// FailureTransform is a kind of DriverFailure for failed transformations of the tree. Other kinds are the same as
+// for native driver failures, see native.FailureKind.
+const FailureTransform = "transform"
+
+// typeURLPrefix is a prefix of type URLs of details in gRPC statuses.
+const typeURLPrefix = "type.googleapis.com/"
+
+// withDetails attaches typed details of the failure to the status: DriverFailure if the driver itself failed,
+// ParseErrors with the diagnostics of the error, and standard BadRequest and QuotaFailure details for invalid
+// requests and exhausted resources.
+func withDetails(st *status.Status, err error) *status.Status {
+	p := st.Proto()
+	if f := failureDetails(err); f != nil {
+		p.Details = append(p.Details, marshalDetail(f))
+	}
+	if derrors.HasDiagnostics(err) {
+		p.Details = append(p.Details, marshalDetail(&ParseErrors{Errors: toParseErrors(err)}))
+	}
+	var std proto.Message
+	switch {
+	case driver.ErrUnknownCharset.Is(err):
+		std = badRequest("charset", err)
+	case driver.ErrInvalidQuery.Is(err):
+		std = badRequest("query", err)
+	case driver.ErrModeNotSupported.Is(err):
+		std = badRequest("mode", err)
+	case driver.ErrResourceExhausted.Is(err):
+		std = &errdetails.QuotaFailure{Violations: []*errdetails.QuotaFailure_Violation{
+			{Subject: "memory", Description: err.Error()},
+		}}
+	}
+	if std != nil {
+		if a, err := ptypes.MarshalAny(std); err == nil {
+			p.Details = append(p.Details, a)
+		}
+	}
+	return status.FromProto(p)
+}
+
+func badRequest(field string, err error) *errdetails.BadRequest {
+	return &errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{
+		{Field: field, Description: err.Error()},
+	}}
+}
+
+// failureDetails describes the failure of the driver. It returns nil for other errors.
+func failureDetails(err error) *DriverFailure {
+	switch e, _ := err.(*serrors.Error); {
+	case e == nil:
+		return nil
+	case driver.ErrStageTimeout.Is(err):
+		f := &DriverFailure{Kind: native.FailureTimeout}
+		_, _ = fmt.Sscanf(stageTimeout(e).Error(), "stage %q timed out", &f.Stage)
+		return f
+	case driver.ErrDriverFailure.Is(err):
+		return &DriverFailure{Kind: native.FailureKind(err), Stage: driver.StageNative, Stderr: native.Stderr(err)}
+	case driver.ErrTransformFailure.Is(err):
+		return &DriverFailure{Kind: FailureTransform}
+	}
+	return nil
+}
+
+// marshalDetail encodes the detail message. Messages of this package are registered with gogo/protobuf, thus
+// ptypes.MarshalAny cannot resolve their names.
+func marshalDetail(m interface {
+	proto.Message
+	Marshal() ([]byte, error)
+}) *any.Any {
+	data, _ := m.Marshal() // only fails for invalid messages
+	return &any.Any{TypeUrl: typeURLPrefix + proto.MessageName(m), Value: data}
+}
+
+// statusDetails decodes the details of the status attached by withDetails.
+func statusDetails(s *status.Status) (*DriverFailure, []*ParseError) {
+	var (
+		f    *DriverFailure
+		errs []*ParseError
+	)
+	for _, a := range s.Proto().Details {
+		switch strings.TrimPrefix(a.TypeUrl, typeURLPrefix) {
+		case proto.MessageName((*DriverFailure)(nil)):
+			var m DriverFailure
+			if m.Unmarshal(a.Value) == nil {
+				f = &m
+			}
+		case proto.MessageName((*ParseErrors)(nil)):
+			var m ParseErrors
+			if m.Unmarshal(a.Value) == nil {
+				errs = append(errs, m.Errors...)
+			}
+		}
+	}
+	return f, errs
+}
+
+// resultStatus returns a status for a failed result of ParseFiles or ParseStream, with the details from the result.
+func resultStatus(code uint32, msg string, f *DriverFailure, errs []*ParseError) error {
+	p := status.New(codes.Code(code), msg).Proto()
+	if f != nil {
+		p.Details = append(p.Details, marshalDetail(f))
+	}
+	if len(errs) != 0 {
+		p.Details = append(p.Details, marshalDetail(&ParseErrors{Errors: errs}))
+	}
+	return status.FromProto(p).Err()
+}
+
+// statusCause returns the cause of an error converted from the status. Diagnostics are returned as-is, thus
+// they can be retrieved with driver.Diagnostics, and the driver failure is kept for FailureDetails.
+func statusCause(s *status.Status) error {
+	f, perrs := statusDetails(s)
+	if len(perrs) != 0 {
+		errs := make([]error, 0, len(perrs))
+		for _, e := range perrs {
+			errs = append(errs, e.Diagnostic())
+		}
+		return derrors.Join(errs)
+	} else if f != nil {
+		return &failureError{msg: s.Message(), f: f}
+	}
+	return errors.New(s.Message())
+}
+
+// failureError keeps the details of the driver failure returned by the server.
+type failureError struct {
+	msg string
+	f   *DriverFailure
+}
+
+func (e *failureError) Error() string {
+	return e.msg
+}
+
+// FailureDetails returns the details of the driver failure returned by the server, or nil if the error is not
+// a driver failure or the server did not send the details.
+func FailureDetails(err error) *DriverFailure {
+	for err != nil {
+		switch e := err.(type) {
+		case *failureError:
+			return e.f
+		case *serrors.Error:
+			err = e.Cause()
+		default:
+			return nil
+		}
+	}
+	return nil
+}
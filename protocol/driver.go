@@ -3,7 +3,10 @@ package protocol
 import (
 	"bytes"
 	"context"
-	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/grpc-ecosystem/grpc-opentracing/go/otgrpc"
 	"github.com/opentracing/opentracing-go"
@@ -14,6 +17,9 @@ import (
 	serrors "gopkg.in/src-d/go-errors.v1"
 
 	"gopkg.in/bblfsh/sdk.v2/driver"
+	derrors "gopkg.in/bblfsh/sdk.v2/driver/errors"
+	"gopkg.in/bblfsh/sdk.v2/driver/manifest"
+	"gopkg.in/bblfsh/sdk.v2/uast"
 	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
 	"gopkg.in/bblfsh/sdk.v2/uast/nodes/nodesproto"
 )
@@ -24,14 +30,26 @@ import (
 //
 // It automatically enables OpenTrace if a global tracer is set.
 func ServerOptions() []grpc.ServerOption {
+	return ServerOptionsWith(nil, nil)
+}
+
+// ServerOptionsWith is the same as ServerOptions, but also includes given interceptors, for example the ones returned
+// by TokenAuth. gRPC allows only one interceptor of each kind, thus all of them are combined and run in the given
+// order, after the tracing interceptors.
+func ServerOptionsWith(unary []grpc.UnaryServerInterceptor, stream []grpc.StreamServerInterceptor) []grpc.ServerOption {
 	tracer := opentracing.GlobalTracer()
-	if _, ok := tracer.(opentracing.NoopTracer); ok {
-		return nil
+	if _, ok := tracer.(opentracing.NoopTracer); !ok {
+		unary = append([]grpc.UnaryServerInterceptor{otgrpc.OpenTracingServerInterceptor(tracer)}, unary...)
+		stream = append([]grpc.StreamServerInterceptor{otgrpc.OpenTracingStreamServerInterceptor(tracer)}, stream...)
+	}
+	var opts []grpc.ServerOption
+	if len(unary) != 0 {
+		opts = append(opts, grpc.UnaryInterceptor(chainUnary(unary)))
 	}
-	return []grpc.ServerOption{
-		grpc.UnaryInterceptor(otgrpc.OpenTracingServerInterceptor(tracer)),
-		grpc.StreamInterceptor(otgrpc.OpenTracingStreamServerInterceptor(tracer)),
+	if len(stream) != 0 {
+		opts = append(opts, grpc.StreamInterceptor(chainStream(stream)))
 	}
+	return opts
 }
 
 // DialOptions returns a set of common options that should be used when dialing bblfsh server.
@@ -49,7 +67,12 @@ func DialOptions() []grpc.DialOption {
 }
 
 func RegisterDriver(srv *grpc.Server, d driver.Driver) {
-	RegisterDriverServer(srv, &driverServer{d: d})
+	RegisterDriverWith(srv, d, ServiceOptions{})
+}
+
+// RegisterDriverWith is the same as RegisterDriver, but allows to configure the service.
+func RegisterDriverWith(srv *grpc.Server, d driver.Driver, opts ServiceOptions) {
+	RegisterDriverServer(srv, &driverServer{d: d, opts: opts})
 }
 
 func AsDriver(cc *grpc.ClientConn) driver.Driver {
@@ -57,60 +80,220 @@ func AsDriver(cc *grpc.ClientConn) driver.Driver {
 }
 
 func toParseErrors(err error) []*ParseError {
-	if e, ok := err.(*driver.ErrMulti); ok {
-		errs := make([]*ParseError, 0, len(e.Errors))
-		for _, e := range e.Errors {
-			errs = append(errs, &ParseError{Text: e.Error()})
-		}
-		return errs
+	diags := derrors.Diagnostics(err)
+	errs := make([]*ParseError, 0, len(diags))
+	for _, d := range diags {
+		errs = append(errs, &ParseError{
+			Text:     d.Message,
+			Severity: Severity(d.Severity),
+			Code:     d.Code,
+			Start:    newPosition(d.Start),
+			End:      newPosition(d.End),
+		})
 	}
-	return []*ParseError{
-		{Text: err.Error()},
+	return errs
+}
+
+// Diagnostic converts the error to a structured diagnostic.
+func (e *ParseError) Diagnostic() *driver.Diagnostic {
+	return &driver.Diagnostic{
+		Message:  e.Text,
+		Severity: derrors.Severity(e.Severity),
+		Code:     e.Code,
+		Start:    e.Start.toPosition(),
+		End:      e.End.toPosition(),
 	}
 }
 
+func newPosition(p *uast.Position) *Position {
+	if p == nil {
+		return nil
+	}
+	return &Position{Offset: p.Offset, Line: p.Line, Col: p.Col}
+}
+
+func (p *Position) toPosition() *uast.Position {
+	if p == nil {
+		return nil
+	}
+	return &uast.Position{Offset: p.Offset, Line: p.Line, Col: p.Col}
+}
+
 type driverServer struct {
-	d driver.Driver
+	d    driver.Driver
+	opts ServiceOptions
 }
 
 // Parse implements DriverServer.
-func (s *driverServer) Parse(rctx xcontext.Context, req *ParseRequest) (*ParseResponse, error) {
+func (s *driverServer) Parse(rctx xcontext.Context, req *ParseRequest) (_ *ParseResponse, gerr error) {
 	sp, ctx := opentracing.StartSpanFromContext(rctx, "bblfsh.server.Parse")
 	defer sp.Finish()
+	defer func(start time.Time) {
+		s.requestDone(req.Mode, start, gerr)
+	}(time.Now())
+	sp.SetTag(driver.TagMode, modeName(req.Mode))
+
+	if err := s.checkCompression(ctx); err != nil {
+		return nil, err
+	}
 
 	opts := &driver.ParseOptions{
 		Mode:     driver.Mode(req.Mode),
 		Language: req.Language,
 		Filename: req.Filename,
+		Options:  req.Options.toDriver(),
+		Range:    req.Range.toDriver(),
+		Metadata: req.Metadata,
+		Charset:  req.Charset,
+		Query:    req.Query,
+		Fields:   req.Fields.toDriver(),
 	}
-	var resp ParseResponse
 	n, err := s.d.Parse(ctx, req.Content, opts)
-	resp.Language = opts.Language // can be set during the call
+	if opts.Language != "" {
+		sp.SetTag(driver.TagLanguage, opts.Language)
+	}
+	// language, options and charset can be set during the call
+	return s.newResponse(ctx, opts.Language, opts.Options, opts.Charset, n, err)
+}
+
+// ParseFiles implements DriverServer.
+func (s *driverServer) ParseFiles(rctx xcontext.Context, req *ParseFilesRequest) (_ *ParseFilesResponse, gerr error) {
+	sp, ctx := opentracing.StartSpanFromContext(rctx, "bblfsh.server.ParseFiles")
+	defer sp.Finish()
+	defer func(start time.Time) {
+		s.requestDone(req.Mode, start, gerr)
+	}(time.Now())
+	sp.SetTag(driver.TagMode, modeName(req.Mode))
+	sp.SetTag(driver.TagFiles, len(req.Files))
+
+	if err := s.checkCompression(ctx); err != nil {
+		return nil, err
+	}
+
+	files := make([]driver.File, 0, len(req.Files))
+	for _, f := range req.Files {
+		files = append(files, driver.File{
+			Content: f.Content, Language: f.Language, Filename: f.Filename,
+			Options: f.Options.toDriver(), Range: f.Range.toDriver(), Metadata: f.Metadata, Charset: f.Charset,
+			Query: f.Query, Fields: f.Fields.toDriver(),
+		})
+	}
+	results, err := s.d.ParseFiles(ctx, driver.Mode(req.Mode), files)
+	if err != nil {
+		if serr := failureStatus(err); serr != nil {
+			return nil, serr
+		}
+		return nil, err // unknown error
+	}
+	resp := &ParseFilesResponse{Results: make([]*ParseFileResult, 0, len(results))}
+	for _, r := range results {
+		pr, err := s.newResponse(ctx, r.Language, r.Options, r.Charset, r.UAST, r.Err)
+		if err != nil {
+			st := status.Convert(err)
+			f, errs := statusDetails(st)
+			resp.Results = append(resp.Results, &ParseFileResult{
+				Code: uint32(st.Code()), Message: st.Message(), Failure: f, Errors: errs,
+			})
+			continue
+		}
+		resp.Results = append(resp.Results, &ParseFileResult{Response: pr})
+	}
+	return resp, nil
+}
+
+// Capabilities implements DriverServer. It is allowed without compression, see ServiceOptions.
+func (s *driverServer) Capabilities(rctx xcontext.Context, req *CapabilitiesRequest) (*CapabilitiesResponse, error) {
+	c, err := s.d.Capabilities(rctx)
+	if err != nil {
+		if serr := failureStatus(err); serr != nil {
+			return nil, serr
+		}
+		return nil, err // unknown error
+	}
+	resp := &CapabilitiesResponse{
+		Encodings:   c.Encodings,
+		Options:     c.Options,
+		Incremental: c.Incremental,
+		MaxFileSize: uint64(c.MaxFileSize),
+		Versions:    newLanguageVersions(c.Versions),
+
+		Compressors:         Compressors(),
+		CompressionRequired: s.opts.RequireCompression,
+	}
+	for m := driver.Mode(1); m != 0 && m <= c.Modes; m <<= 1 {
+		if c.Modes&m != 0 {
+			resp.Modes = append(resp.Modes, Mode(m))
+		}
+	}
+	return resp, nil
+}
+
+// modeName returns the name of the mode for tracing tags, or the name of the default mode if it is not set.
+func modeName(m Mode) string {
+	if m == 0 {
+		return driver.ModeDefault.String()
+	}
+	return driver.Mode(m).String()
+}
+
+// failureStatus converts driver, transformation, mode, charset, query, resource and overload errors to gRPC status
+// errors with typed details, see withDetails. It returns nil for other errors.
+func failureStatus(err error) error {
+	e, ok := err.(*serrors.Error)
+	if !ok {
+		return nil
+	}
+	cause := e.Cause()
+	var st *status.Status
+	switch {
+	case driver.ErrUnknownCharset.Is(err), driver.ErrInvalidQuery.Is(err):
+		st = status.New(codes.InvalidArgument, err.Error())
+	case driver.ErrOverloaded.Is(err):
+		st = status.New(codes.Unavailable, err.Error())
+	case driver.ErrStageTimeout.Is(err):
+		st = status.New(codes.DeadlineExceeded, stageTimeout(e).Error())
+	case driver.ErrDriverFailure.Is(err):
+		st = status.New(codes.Internal, cause.Error())
+	case driver.ErrTransformFailure.Is(err):
+		st = status.New(codes.FailedPrecondition, cause.Error())
+	case driver.ErrModeNotSupported.Is(err):
+		st = status.New(codes.InvalidArgument, cause.Error())
+	case driver.ErrResourceExhausted.Is(err):
+		st = status.New(codes.ResourceExhausted, cause.Error())
+	default:
+		return nil
+	}
+	return withDetails(st, err).Err()
+}
+
+// newResponse encodes the result of Driver.Parse. Failures are returned as gRPC status errors.
+func (s *driverServer) newResponse(ctx context.Context, lang string, opts driver.LanguageOptions, cs string, n nodes.Node, err error) (*ParseResponse, error) {
+	resp := ParseResponse{Language: lang, Options: newLanguageOptions(opts), Charset: cs}
 	if e, ok := err.(*serrors.Error); ok {
-		cause := e.Cause()
-		if driver.ErrDriverFailure.Is(err) {
-			return nil, status.Error(codes.Internal, cause.Error())
-		} else if driver.ErrTransformFailure.Is(err) {
-			return nil, status.Error(codes.FailedPrecondition, cause.Error())
-		} else if driver.ErrModeNotSupported.Is(err) {
-			return nil, status.Error(codes.InvalidArgument, cause.Error())
-		}
-		if !driver.ErrSyntax.Is(err) {
+		if serr := failureStatus(err); serr != nil {
+			return nil, serr
+		}
+		if !driver.ErrSyntax.Is(err) && !driver.ErrPartialTransform.Is(err) {
 			return nil, err // unknown error
 		}
-		// partial parse or syntax error; we will send an OK status code, but will fill Errors field
-		resp.Errors = toParseErrors(cause)
+		// partial parse, partial transform or syntax error; we will send an OK status code, but will fill Errors field
+		resp.Errors = toParseErrors(e.Cause())
 	}
 
 	dsp, _ := opentracing.StartSpanFromContext(ctx, "uast.Encode")
 	defer dsp.Finish()
 
+	start := time.Now()
 	buf := bytes.NewBuffer(nil)
 	err = nodesproto.WriteTo(buf, n)
 	if err != nil {
 		return nil, err // unknown error = server failure
 	}
 	resp.Uast = buf.Bytes()
+	dsp.SetTag(driver.TagSize, len(resp.Uast))
+	if s.opts.Metrics != nil {
+		s.opts.Metrics.Encoded(time.Since(start), len(resp.Uast))
+	}
 	return &resp, nil
 }
 
@@ -118,37 +301,51 @@ type client struct {
 	c DriverClient
 }
 
-// Parse implements DriverClient.
+// Parse implements DriverClient. If the response exceeds the gRPC message size limit, it is requested again
+// in chunks, see ParseChunked.
 func (c *client) Parse(rctx context.Context, src string, opts *driver.ParseOptions) (nodes.Node, error) {
 	sp, ctx := opentracing.StartSpanFromContext(rctx, "bblfsh.client.Parse")
 	defer sp.Finish()
 
+	req := newParseRequest(src, opts)
+	resp, err := c.c.Parse(ctx, req)
+	if limit, ok := tooLarge(err); ok {
+		// the tree does not fit into a single message
+		if cresp, cerr := c.parseChunked(ctx, req, limit); status.Code(cerr) != codes.Unimplemented {
+			resp, err = cresp, cerr
+		}
+	}
+	if err != nil {
+		return nil, fromStatus(err) // server or network error
+	}
+	return parseResult(ctx, resp, opts)
+}
+
+// newParseRequest converts the source and parse options to the protocol message.
+func newParseRequest(src string, opts *driver.ParseOptions) *ParseRequest {
 	req := &ParseRequest{Content: src}
 	if opts != nil {
 		req.Mode = Mode(opts.Mode)
 		req.Language = opts.Language
 		req.Filename = opts.Filename
+		req.Options = newLanguageOptions(opts.Options)
+		req.Range = newRange(opts.Range)
+		req.Metadata = opts.Metadata
+		req.Charset = opts.Charset
+		req.Query = opts.Query
+		req.Fields = newFieldMask(opts.Fields)
 	}
-	resp, err := c.c.Parse(ctx, req)
-	if s, ok := status.FromError(err); ok {
-		var kind *serrors.Kind
-		switch s.Code() {
-		case codes.Internal:
-			kind = driver.ErrDriverFailure
-		case codes.FailedPrecondition:
-			kind = driver.ErrTransformFailure
-		case codes.InvalidArgument:
-			kind = driver.ErrModeNotSupported
-		}
-		if kind != nil {
-			return nil, kind.Wrap(errors.New(s.Message()))
+	return req
+}
+
+// parseResult decodes the tree from the response and sets the options returned by the server.
+func parseResult(ctx context.Context, resp *ParseResponse, opts *driver.ParseOptions) (nodes.Node, error) {
+	if opts != nil {
+		if opts.Language == "" {
+			opts.Language = resp.Language
 		}
-	}
-	if err != nil {
-		return nil, err // server or network error
-	}
-	if opts != nil && opts.Language == "" {
-		opts.Language = resp.Language
+		opts.Options = resp.Options.toDriver()
+		opts.Charset = resp.Charset
 	}
 
 	dsp, _ := opentracing.StartSpanFromContext(ctx, "uast.Decode")
@@ -158,6 +355,253 @@ func (c *client) Parse(rctx context.Context, src string, opts *driver.ParseOptio
 	return resp.Nodes()
 }
 
+// ParseFiles implements Driver. If the server does not support ParseFiles, files are parsed one by one.
+func (c *client) ParseFiles(rctx context.Context, mode driver.Mode, files []driver.File) ([]driver.Result, error) {
+	sp, ctx := opentracing.StartSpanFromContext(rctx, "bblfsh.client.ParseFiles")
+	defer sp.Finish()
+
+	req := &ParseFilesRequest{Mode: Mode(mode), Files: make([]*ParseRequest, 0, len(files))}
+	for _, f := range files {
+		req.Files = append(req.Files, &ParseRequest{
+			Content: f.Content, Language: f.Language, Filename: f.Filename,
+			Options: newLanguageOptions(f.Options), Range: newRange(f.Range), Metadata: f.Metadata,
+			Charset: f.Charset, Query: f.Query, Fields: newFieldMask(f.Fields),
+		})
+	}
+	resp, err := c.c.ParseFiles(ctx, req)
+	if status.Code(err) == codes.Unimplemented {
+		return c.parseEach(ctx, mode, files)
+	} else if err != nil {
+		return nil, fromStatus(err)
+	} else if len(resp.Results) != len(files) {
+		return nil, fmt.Errorf("expected %d results, got %d", len(files), len(resp.Results))
+	}
+
+	dsp, _ := opentracing.StartSpanFromContext(ctx, "uast.Decode")
+	defer dsp.Finish()
+
+	out := make([]driver.Result, 0, len(files))
+	for i, r := range resp.Results {
+		res := driver.Result{Source: files[i].Content, Mode: mode, Language: files[i].Language}
+		if r.Code != 0 {
+			res.Err = fromStatus(resultStatus(r.Code, r.Message, r.Failure, r.Errors))
+		} else if r.Response != nil {
+			if res.Language == "" {
+				res.Language = r.Response.Language
+			}
+			res.Options = r.Response.Options.toDriver()
+			res.Charset = r.Response.Charset
+			res.UAST, res.Err = r.Response.Nodes()
+		}
+		out = append(out, res)
+	}
+	return out, nil
+}
+
+// legacyModes is a set of modes supported by servers that do not implement Capabilities.
+const legacyModes = driver.ModeNative | driver.ModePreprocessed | driver.ModeAnnotated | driver.ModeSemantic
+
+// Capabilities implements Driver. If the server does not support Capabilities, only the modes are reported.
+func (c *client) Capabilities(ctx context.Context) (driver.Capabilities, error) {
+	resp, err := c.c.Capabilities(ctx, &CapabilitiesRequest{})
+	if status.Code(err) == codes.Unimplemented {
+		return driver.Capabilities{Modes: legacyModes}, nil
+	} else if err != nil {
+		return driver.Capabilities{}, fromStatus(err)
+	}
+	out := driver.Capabilities{
+		Encodings:   resp.Encodings,
+		Options:     resp.Options,
+		Incremental: resp.Incremental,
+		MaxFileSize: int(resp.MaxFileSize),
+		Versions:    toLanguageVersions(resp.Versions),
+
+		Compressors:         resp.Compressors,
+		CompressionRequired: resp.CompressionRequired,
+	}
+	for _, m := range resp.Modes {
+		out.Modes |= driver.Mode(m)
+	}
+	return out, nil
+}
+
+// parseEach parses files one by one. Server and network errors fail the whole request.
+func (c *client) parseEach(ctx context.Context, mode driver.Mode, files []driver.File) ([]driver.Result, error) {
+	out := make([]driver.Result, 0, len(files))
+	for _, f := range files {
+		opts := &driver.ParseOptions{
+			Mode: mode, Language: f.Language, Filename: f.Filename,
+			Options: f.Options, Range: f.Range, Metadata: f.Metadata, Charset: f.Charset,
+			Query: f.Query, Fields: f.Fields,
+		}
+		n, err := c.Parse(ctx, f.Content, opts)
+		if _, ok := err.(*serrors.Error); err != nil && !ok {
+			return nil, err
+		}
+		out = append(out, driver.Result{
+			Source: f.Content, Mode: mode, Language: opts.Language,
+			UAST: n, Err: err, Options: opts.Options, Charset: opts.Charset,
+		})
+	}
+	return out, nil
+}
+
+// newLanguageOptions converts driver options to the protocol message. It returns nil if no options are set.
+func newLanguageOptions(o driver.LanguageOptions) *LanguageOptions {
+	if o.IsZero() {
+		return nil
+	}
+	return &LanguageOptions{Version: o.Version, Dialects: o.Dialects, Strict: o.Strict}
+}
+
+// toDriver converts the protocol message to driver options. It is safe to call on a nil message.
+func (o *LanguageOptions) toDriver() driver.LanguageOptions {
+	if o == nil {
+		return driver.LanguageOptions{}
+	}
+	return driver.LanguageOptions{Version: o.Version, Dialects: o.Dialects, Strict: o.Strict}
+}
+
+// newRange converts the driver range to the protocol message. It returns nil if the range is not set.
+func newRange(r driver.Range) *Range {
+	if r.IsZero() {
+		return nil
+	}
+	return &Range{Start: r.Start, End: r.End, StartLine: r.StartLine, EndLine: r.EndLine}
+}
+
+// toDriver converts the protocol message to the driver range. It is safe to call on a nil message.
+func (r *Range) toDriver() driver.Range {
+	if r == nil {
+		return driver.Range{}
+	}
+	return driver.Range{Start: r.Start, End: r.End, StartLine: r.StartLine, EndLine: r.EndLine}
+}
+
+// newFieldMask converts the driver field mask to the protocol message. It returns nil if the mask is not set.
+func newFieldMask(m driver.FieldMask) *FieldMask {
+	if m.IsZero() {
+		return nil
+	}
+	return &FieldMask{Include: m.Include, Exclude: m.Exclude}
+}
+
+// toDriver converts the protocol message to the driver field mask. It is safe to call on a nil message.
+func (m *FieldMask) toDriver() driver.FieldMask {
+	if m == nil {
+		return driver.FieldMask{}
+	}
+	return driver.FieldMask{Include: m.Include, Exclude: m.Exclude}
+}
+
+// newLanguageVersions converts the manifest feature matrix to protocol messages.
+func newLanguageVersions(arr manifest.LanguageVersions) []*LanguageVersion {
+	if len(arr) == 0 {
+		return nil
+	}
+	out := make([]*LanguageVersion, 0, len(arr))
+	for _, v := range arr {
+		pv := &LanguageVersion{Version: v.Version}
+		for _, f := range v.Features {
+			pv.Features = append(pv.Features, &SyntaxFeature{Name: f.Name, Supported: f.Supported})
+		}
+		out = append(out, pv)
+	}
+	return out
+}
+
+// toLanguageVersions converts protocol messages to the manifest feature matrix.
+func toLanguageVersions(arr []*LanguageVersion) manifest.LanguageVersions {
+	if len(arr) == 0 {
+		return nil
+	}
+	out := make(manifest.LanguageVersions, 0, len(arr))
+	for _, pv := range arr {
+		v := manifest.LanguageVersion{Version: pv.Version}
+		for _, f := range pv.Features {
+			v.Features = append(v.Features, manifest.SyntaxFeature{Name: f.Name, Supported: f.Supported})
+		}
+		out = append(out, v)
+	}
+	return out
+}
+
+// fromStatus converts gRPC status errors returned for driver failures back to driver errors.
+func fromStatus(err error) error {
+	s, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	var kind *serrors.Kind
+	switch s.Code() {
+	case codes.Internal:
+		kind = driver.ErrDriverFailure
+	case codes.FailedPrecondition:
+		if s.Message() == ErrCompressionRequired.New().Error() {
+			return ErrCompressionRequired.New()
+		}
+		kind = driver.ErrTransformFailure
+	case codes.InvalidArgument:
+		if cs, ok := unknownCharset(s.Message()); ok {
+			return driver.ErrUnknownCharset.New(cs)
+		} else if msg := s.Message(); strings.HasPrefix(msg, "invalid query: ") {
+			return driver.ErrInvalidQuery.New(strings.TrimPrefix(msg, "invalid query: "))
+		}
+		kind = driver.ErrModeNotSupported
+	case codes.ResourceExhausted:
+		if d, ok := retryDelay(s); ok {
+			return ErrRateLimited.New(d)
+		}
+		kind = driver.ErrResourceExhausted
+	case codes.Unauthenticated:
+		if s.Message() == ErrUnauthenticated.New().Error() {
+			return ErrUnauthenticated.New()
+		}
+	case codes.DeadlineExceeded:
+		// also returned if the deadline of the request expires
+		var (
+			stage string
+			dur   string
+		)
+		if _, err := fmt.Sscanf(s.Message(), "stage %q timed out after %s", &stage, &dur); err == nil {
+			if d, err := time.ParseDuration(dur); err == nil {
+				return driver.ErrStageTimeout.New(stage, d)
+			}
+		}
+	case codes.Unavailable:
+		// also returned if the server is not reachable
+		var n int
+		if _, err := fmt.Sscanf(s.Message(), "driver overloaded: %d requests in queue", &n); err == nil {
+			return driver.ErrOverloaded.New(n)
+		}
+	}
+	if kind != nil {
+		return kind.Wrap(statusCause(s))
+	}
+	return err
+}
+
+// stageTimeout returns ErrStageTimeout wrapped into the error.
+func stageTimeout(e *serrors.Error) *serrors.Error {
+	for {
+		c, ok := e.Cause().(*serrors.Error)
+		if !ok || !driver.ErrStageTimeout.Is(c) {
+			return e
+		}
+		e = c
+	}
+}
+
+// unknownCharset checks if the status message was returned for ErrUnknownCharset and returns the charset name.
+func unknownCharset(msg string) (string, bool) {
+	const prefix = "unknown charset: "
+	if !strings.HasPrefix(msg, prefix) {
+		return "", false
+	}
+	cs, err := strconv.Unquote(strings.TrimPrefix(msg, prefix))
+	return cs, err == nil
+}
+
 func (m *ParseResponse) Nodes() (nodes.Node, error) {
 	ast, err := nodesproto.ReadTree(bytes.NewReader(m.Uast))
 	if err != nil {
@@ -166,7 +610,7 @@ func (m *ParseResponse) Nodes() (nodes.Node, error) {
 	if len(m.Errors) != 0 {
 		var errs []error
 		for _, e := range m.Errors {
-			errs = append(errs, errors.New(e.Text))
+			errs = append(errs, e.Diagnostic())
 		}
 		// syntax error or partial parse - return both UAST and an error
 		err = driver.ErrSyntax.Wrap(driver.JoinErrors(errs))
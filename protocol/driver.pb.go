@@ -11,6 +11,23 @@
 		ParseRequest
 		ParseResponse
 		ParseError
+		ParseFilesRequest
+		ParseFilesResponse
+		ParseFileResult
+		LanguageOptions
+		Range
+		CapabilitiesRequest
+		CapabilitiesResponse
+		LanguageVersion
+		SyntaxFeature
+		Position
+		ParseStreamRequest
+		ParseStreamResponse
+		ParseChunkedRequest
+		ParseChunk
+		FieldMask
+		ParseErrors
+		DriverFailure
 */
 package protocol
 
@@ -50,14 +67,17 @@ const (
 	Mode_Annotated Mode = 4
 	// Semantic UAST normalizes native AST nodes to a unified structure where possible.
 	Mode_Semantic Mode = 8
+	// Tokens returns only an ordered list of tokens with their positions instead of the tree.
+	Mode_Tokens Mode = 16
 )
 
 var Mode_name = map[int32]string{
-	0: "DEFAULT_MODE",
-	1: "NATIVE",
-	2: "PREPROCESSED",
-	4: "ANNOTATED",
-	8: "SEMANTIC",
+	0:  "DEFAULT_MODE",
+	1:  "NATIVE",
+	2:  "PREPROCESSED",
+	4:  "ANNOTATED",
+	8:  "SEMANTIC",
+	16: "TOKENS",
 }
 var Mode_value = map[string]int32{
 	"DEFAULT_MODE": 0,
@@ -65,6 +85,7 @@ var Mode_value = map[string]int32{
 	"PREPROCESSED": 2,
 	"ANNOTATED":    4,
 	"SEMANTIC":     8,
+	"TOKENS":       16,
 }
 
 func (x Mode) String() string {
@@ -72,6 +93,34 @@ func (x Mode) String() string {
 }
 func (Mode) EnumDescriptor() ([]byte, []int) { return fileDescriptorDriver, []int{0} }
 
+// Severity of a parse error.
+type Severity int32
+
+const (
+	// Error is a syntax error.
+	Severity_Error Severity = 0
+	// Warning is a problem that does not prevent the source from being parsed.
+	Severity_Warning Severity = 1
+	// Info is an informational message of the parser.
+	Severity_Info Severity = 2
+)
+
+var Severity_name = map[int32]string{
+	0: "ERROR",
+	1: "WARNING",
+	2: "INFO",
+}
+var Severity_value = map[string]int32{
+	"ERROR":   0,
+	"WARNING": 1,
+	"INFO":    2,
+}
+
+func (x Severity) String() string {
+	return proto.EnumName(Severity_name, int32(x))
+}
+func (Severity) EnumDescriptor() ([]byte, []int) { return fileDescriptorDriver, []int{1} }
+
 // ParseRequest is a request to parse a file and get its UAST.
 type ParseRequest struct {
 	// Content stores the content of a source file. Required.
@@ -82,6 +131,19 @@ type ParseRequest struct {
 	Filename string `protobuf:"bytes,3,opt,name=filename,proto3" json:"filename,omitempty"`
 	// Mode sets a transformation pipeline used for UAST.
 	Mode Mode `protobuf:"varint,4,opt,name=mode,proto3,enum=gopkg.in.bblfsh.sdk.v2.protocol.Mode" json:"mode,omitempty"`
+	// Options are passed to the native driver to parse the file with a specific language version or dialect.
+	Options *LanguageOptions `protobuf:"bytes,5,opt,name=options" json:"options,omitempty"`
+	// Range restricts the UAST to nodes overlapping a given part of the source.
+	Range *Range `protobuf:"bytes,6,opt,name=range" json:"range,omitempty"`
+	// Metadata is passed to transformers, for example the project root or compiler flags.
+	Metadata map[string]string `protobuf:"bytes,7,rep,name=metadata" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// Charset of the content, for example "shift_jis". It is detected if not set.
+	Charset string `protobuf:"bytes,8,opt,name=charset,proto3" json:"charset,omitempty"`
+	// Fields selects fields of UAST nodes to return. All fields are returned if it is not set.
+	Fields *FieldMask `protobuf:"bytes,9,opt,name=fields" json:"fields,omitempty"`
+	// Query is an XPath query for nodes to return, instead of the whole tree. Matching subtrees are returned
+	// as an array.
+	Query string `protobuf:"bytes,10,opt,name=query,proto3" json:"query,omitempty"`
 }
 
 func (m *ParseRequest) Reset()                    { *m = ParseRequest{} }
@@ -98,6 +160,10 @@ type ParseResponse struct {
 	// Errors is a list of parsing errors.
 	// Only set if parser was able to return a response. Otherwise gRPC error codes are used.
 	Errors []*ParseError `protobuf:"bytes,3,rep,name=errors" json:"errors,omitempty"`
+	// Options that were used by the native driver. Not set if the native driver does not support options.
+	Options *LanguageOptions `protobuf:"bytes,4,opt,name=options" json:"options,omitempty"`
+	// Charset of the source, either the one set in the request, or detected by the driver.
+	Charset string `protobuf:"bytes,5,opt,name=charset,proto3" json:"charset,omitempty"`
 }
 
 func (m *ParseResponse) Reset()                    { *m = ParseResponse{} }
@@ -108,6 +174,14 @@ func (*ParseResponse) Descriptor() ([]byte, []int) { return fileDescriptorDriver
 type ParseError struct {
 	// Text is an error message.
 	Text string `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
+	// Severity of the error.
+	Severity Severity `protobuf:"varint,2,opt,name=severity,proto3,enum=gopkg.in.bblfsh.sdk.v2.protocol.Severity" json:"severity,omitempty"`
+	// Code is a parser-specific error code.
+	Code string `protobuf:"bytes,3,opt,name=code,proto3" json:"code,omitempty"`
+	// Start is an optional position of the error in the source.
+	Start *Position `protobuf:"bytes,4,opt,name=start" json:"start,omitempty"`
+	// End is an optional exclusive end position of the error in the source.
+	End *Position `protobuf:"bytes,5,opt,name=end" json:"end,omitempty"`
 }
 
 func (m *ParseError) Reset()                    { *m = ParseError{} }
@@ -115,11 +189,283 @@ func (m *ParseError) String() string            { return proto.CompactTextString
 func (*ParseError) ProtoMessage()               {}
 func (*ParseError) Descriptor() ([]byte, []int) { return fileDescriptorDriver, []int{2} }
 
+// ParseFilesRequest is a request to parse multiple files at once.
+type ParseFilesRequest struct {
+	// Files to parse. Modes of individual requests are ignored.
+	Files []*ParseRequest `protobuf:"bytes,1,rep,name=files" json:"files,omitempty"`
+	// Mode sets a transformation pipeline used for UAST of all files.
+	Mode Mode `protobuf:"varint,2,opt,name=mode,proto3,enum=gopkg.in.bblfsh.sdk.v2.protocol.Mode" json:"mode,omitempty"`
+}
+
+func (m *ParseFilesRequest) Reset()                    { *m = ParseFilesRequest{} }
+func (m *ParseFilesRequest) String() string            { return proto.CompactTextString(m) }
+func (*ParseFilesRequest) ProtoMessage()               {}
+func (*ParseFilesRequest) Descriptor() ([]byte, []int) { return fileDescriptorDriver, []int{3} }
+
+// ParseFilesResponse is the reply to ParseFilesRequest.
+type ParseFilesResponse struct {
+	// Results for each file, in the same order as in the request.
+	Results []*ParseFileResult `protobuf:"bytes,1,rep,name=results" json:"results,omitempty"`
+}
+
+func (m *ParseFilesResponse) Reset()                    { *m = ParseFilesResponse{} }
+func (m *ParseFilesResponse) String() string            { return proto.CompactTextString(m) }
+func (*ParseFilesResponse) ProtoMessage()               {}
+func (*ParseFilesResponse) Descriptor() ([]byte, []int) { return fileDescriptorDriver, []int{4} }
+
+// ParseFileResult is a result of parsing a single file of ParseFilesRequest.
+type ParseFileResult struct {
+	// Response is the same as the reply to ParseRequest for this file. Not set if the file failed.
+	Response *ParseResponse `protobuf:"bytes,1,opt,name=response" json:"response,omitempty"`
+	// Code is a gRPC status code that Parse would return for this file. Zero if the file was parsed.
+	Code uint32 `protobuf:"varint,2,opt,name=code,proto3" json:"code,omitempty"`
+	// Message describes the failure. Only set together with Code.
+	Message string `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	// Failure describes the failure of the driver. Only set together with Code.
+	Failure *DriverFailure `protobuf:"bytes,4,opt,name=failure" json:"failure,omitempty"`
+	// Errors is a list of syntax errors and diagnostics of the failed transformation. Only set together with Code.
+	Errors []*ParseError `protobuf:"bytes,5,rep,name=errors" json:"errors,omitempty"`
+}
+
+func (m *ParseFileResult) Reset()                    { *m = ParseFileResult{} }
+func (m *ParseFileResult) String() string            { return proto.CompactTextString(m) }
+func (*ParseFileResult) ProtoMessage()               {}
+func (*ParseFileResult) Descriptor() ([]byte, []int) { return fileDescriptorDriver, []int{5} }
+
+// LanguageOptions instruct the native driver how to parse the source.
+type LanguageOptions struct {
+	// Version of the language.
+	Version string `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
+	// Dialects is a list of language extensions enabled for the source.
+	Dialects []string `protobuf:"bytes,2,rep,name=dialects" json:"dialects,omitempty"`
+	// Strict enables the strict mode of the language.
+	Strict bool `protobuf:"varint,3,opt,name=strict,proto3" json:"strict,omitempty"`
+}
+
+func (m *LanguageOptions) Reset()                    { *m = LanguageOptions{} }
+func (m *LanguageOptions) String() string            { return proto.CompactTextString(m) }
+func (*LanguageOptions) ProtoMessage()               {}
+func (*LanguageOptions) Descriptor() ([]byte, []int) { return fileDescriptorDriver, []int{6} }
+
+// Range is a part of the source. Either a byte range or a line range should be set.
+type Range struct {
+	// Start is a byte offset of the range.
+	Start uint32 `protobuf:"varint,1,opt,name=start,proto3" json:"start,omitempty"`
+	// End is an exclusive byte offset of the range.
+	End uint32 `protobuf:"varint,2,opt,name=end,proto3" json:"end,omitempty"`
+	// StartLine is a 1-based line number of the range.
+	StartLine uint32 `protobuf:"varint,3,opt,name=start_line,json=startLine,proto3" json:"start_line,omitempty"`
+	// EndLine is an inclusive 1-based line number of the range.
+	EndLine uint32 `protobuf:"varint,4,opt,name=end_line,json=endLine,proto3" json:"end_line,omitempty"`
+}
+
+func (m *Range) Reset()                    { *m = Range{} }
+func (m *Range) String() string            { return proto.CompactTextString(m) }
+func (*Range) ProtoMessage()               {}
+func (*Range) Descriptor() ([]byte, []int) { return fileDescriptorDriver, []int{7} }
+
+// CapabilitiesRequest is a request to list features supported by the driver.
+type CapabilitiesRequest struct {
+}
+
+func (m *CapabilitiesRequest) Reset()                    { *m = CapabilitiesRequest{} }
+func (m *CapabilitiesRequest) String() string            { return proto.CompactTextString(m) }
+func (*CapabilitiesRequest) ProtoMessage()               {}
+func (*CapabilitiesRequest) Descriptor() ([]byte, []int) { return fileDescriptorDriver, []int{8} }
+
+// CapabilitiesResponse lists features supported by the driver.
+type CapabilitiesResponse struct {
+	// Modes is a list of supported transformation modes.
+	Modes []Mode `protobuf:"varint,1,rep,packed,name=modes,enum=gopkg.in.bblfsh.sdk.v2.protocol.Mode" json:"modes,omitempty"`
+	// Encodings is a list of supported source encodings.
+	Encodings []string `protobuf:"bytes,2,rep,name=encodings" json:"encodings,omitempty"`
+	// Options is set if the driver supports language options.
+	Options bool `protobuf:"varint,3,opt,name=options,proto3" json:"options,omitempty"`
+	// Incremental is set if the driver can reparse the source incrementally.
+	Incremental bool `protobuf:"varint,4,opt,name=incremental,proto3" json:"incremental,omitempty"`
+	// MaxFileSize is the maximal size of the source file in bytes. Zero means no limit.
+	MaxFileSize uint64 `protobuf:"varint,5,opt,name=max_file_size,json=maxFileSize,proto3" json:"max_file_size,omitempty"`
+	// Versions lists syntax features supported for each language version.
+	Versions []*LanguageVersion `protobuf:"bytes,6,rep,name=versions" json:"versions,omitempty"`
+	// Compressors is a list of gRPC compressors supported by the server for requests and responses.
+	Compressors []string `protobuf:"bytes,7,rep,name=compressors" json:"compressors,omitempty"`
+	// CompressionRequired is set if the server rejects requests that are not compressed.
+	CompressionRequired bool `protobuf:"varint,8,opt,name=compression_required,json=compressionRequired,proto3" json:"compression_required,omitempty"`
+}
+
+func (m *CapabilitiesResponse) Reset()                    { *m = CapabilitiesResponse{} }
+func (m *CapabilitiesResponse) String() string            { return proto.CompactTextString(m) }
+func (*CapabilitiesResponse) ProtoMessage()               {}
+func (*CapabilitiesResponse) Descriptor() ([]byte, []int) { return fileDescriptorDriver, []int{9} }
+
+// LanguageVersion lists syntax features of a language version and if the driver supports them.
+type LanguageVersion struct {
+	// Version of the language.
+	Version string `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
+	// Features is a list of syntax features of this version.
+	Features []*SyntaxFeature `protobuf:"bytes,2,rep,name=features" json:"features,omitempty"`
+}
+
+func (m *LanguageVersion) Reset()                    { *m = LanguageVersion{} }
+func (m *LanguageVersion) String() string            { return proto.CompactTextString(m) }
+func (*LanguageVersion) ProtoMessage()               {}
+func (*LanguageVersion) Descriptor() ([]byte, []int) { return fileDescriptorDriver, []int{10} }
+
+// SyntaxFeature is a language construct that may not be supported by the driver.
+type SyntaxFeature struct {
+	// Name of the feature.
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// Supported is set if the driver can parse this feature.
+	Supported bool `protobuf:"varint,2,opt,name=supported,proto3" json:"supported,omitempty"`
+}
+
+func (m *SyntaxFeature) Reset()                    { *m = SyntaxFeature{} }
+func (m *SyntaxFeature) String() string            { return proto.CompactTextString(m) }
+func (*SyntaxFeature) ProtoMessage()               {}
+func (*SyntaxFeature) Descriptor() ([]byte, []int) { return fileDescriptorDriver, []int{11} }
+
+// Position is a position in the source.
+type Position struct {
+	// Offset is a 0-based byte offset.
+	Offset uint32 `protobuf:"varint,1,opt,name=offset,proto3" json:"offset,omitempty"`
+	// Line is a 1-based line number.
+	Line uint32 `protobuf:"varint,2,opt,name=line,proto3" json:"line,omitempty"`
+	// Col is a 1-based column number.
+	Col uint32 `protobuf:"varint,3,opt,name=col,proto3" json:"col,omitempty"`
+}
+
+func (m *Position) Reset()                    { *m = Position{} }
+func (m *Position) String() string            { return proto.CompactTextString(m) }
+func (*Position) ProtoMessage()               {}
+func (*Position) Descriptor() ([]byte, []int) { return fileDescriptorDriver, []int{12} }
+
+// ParseStreamRequest is a single parse request sent on the ParseStream stream.
+type ParseStreamRequest struct {
+	// ID is set by the client to match responses to requests. It must be unique among requests in progress.
+	ID uint64 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Request is the same as a request to Parse.
+	Request *ParseRequest `protobuf:"bytes,2,opt,name=request" json:"request,omitempty"`
+}
+
+func (m *ParseStreamRequest) Reset()                    { *m = ParseStreamRequest{} }
+func (m *ParseStreamRequest) String() string            { return proto.CompactTextString(m) }
+func (*ParseStreamRequest) ProtoMessage()               {}
+func (*ParseStreamRequest) Descriptor() ([]byte, []int) { return fileDescriptorDriver, []int{13} }
+
+// ParseStreamResponse is the reply to ParseStreamRequest with the same ID. Responses may be sent in a different
+// order than requests.
+type ParseStreamResponse struct {
+	// ID of the request.
+	ID uint64 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Response is the same as the reply to Parse. Not set if the request failed.
+	Response *ParseResponse `protobuf:"bytes,2,opt,name=response" json:"response,omitempty"`
+	// Code is a gRPC status code that Parse would return for this request. Zero if the file was parsed.
+	Code uint32 `protobuf:"varint,3,opt,name=code,proto3" json:"code,omitempty"`
+	// Message describes the failure. Only set together with Code.
+	Message string `protobuf:"bytes,4,opt,name=message,proto3" json:"message,omitempty"`
+	// Failure describes the failure of the driver. Only set together with Code.
+	Failure *DriverFailure `protobuf:"bytes,5,opt,name=failure" json:"failure,omitempty"`
+	// Errors is a list of syntax errors and diagnostics of the failed transformation. Only set together with Code.
+	Errors []*ParseError `protobuf:"bytes,6,rep,name=errors" json:"errors,omitempty"`
+}
+
+func (m *ParseStreamResponse) Reset()                    { *m = ParseStreamResponse{} }
+func (m *ParseStreamResponse) String() string            { return proto.CompactTextString(m) }
+func (*ParseStreamResponse) ProtoMessage()               {}
+func (*ParseStreamResponse) Descriptor() ([]byte, []int) { return fileDescriptorDriver, []int{14} }
+
+// ParseChunkedRequest is a request to parse a file and receive its UAST in multiple chunks.
+type ParseChunkedRequest struct {
+	// Request is the same as a request to Parse.
+	Request *ParseRequest `protobuf:"bytes,1,opt,name=request" json:"request,omitempty"`
+	// ChunkSize is the maximal size of the UAST data in each chunk. The server chooses the size if it is not set.
+	ChunkSize uint32 `protobuf:"varint,2,opt,name=chunk_size,json=chunkSize,proto3" json:"chunk_size,omitempty"`
+}
+
+func (m *ParseChunkedRequest) Reset()                    { *m = ParseChunkedRequest{} }
+func (m *ParseChunkedRequest) String() string            { return proto.CompactTextString(m) }
+func (*ParseChunkedRequest) ProtoMessage()               {}
+func (*ParseChunkedRequest) Descriptor() ([]byte, []int) { return fileDescriptorDriver, []int{15} }
+
+// ParseChunk is a part of the reply to ParseChunkedRequest. The first chunk contains the response without the UAST,
+// the following chunks contain consecutive parts of the encoded UAST.
+type ParseChunk struct {
+	// Response is the same as the reply to Parse, except for the UAST. Only set in the first chunk.
+	Response *ParseResponse `protobuf:"bytes,1,opt,name=response" json:"response,omitempty"`
+	// Size is the total size of the encoded UAST. Only set in the first chunk.
+	Size uint64 `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`
+	// Data is the next part of the encoded UAST.
+	Data []byte `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+}
+
+func (m *ParseChunk) Reset()                    { *m = ParseChunk{} }
+func (m *ParseChunk) String() string            { return proto.CompactTextString(m) }
+func (*ParseChunk) ProtoMessage()               {}
+func (*ParseChunk) Descriptor() ([]byte, []int) { return fileDescriptorDriver, []int{16} }
+
+// FieldMask selects fields of UAST nodes returned to the client.
+type FieldMask struct {
+	// Include is a list of fields to keep in each node. All fields are kept if it is empty.
+	Include []string `protobuf:"bytes,1,rep,name=include" json:"include,omitempty"`
+	// Exclude is a list of fields to drop from each node.
+	Exclude []string `protobuf:"bytes,2,rep,name=exclude" json:"exclude,omitempty"`
+}
+
+func (m *FieldMask) Reset()                    { *m = FieldMask{} }
+func (m *FieldMask) String() string            { return proto.CompactTextString(m) }
+func (*FieldMask) ProtoMessage()               {}
+func (*FieldMask) Descriptor() ([]byte, []int) { return fileDescriptorDriver, []int{17} }
+
+// ParseErrors is a detail of the gRPC status of a failed request. It lists syntax errors and diagnostics of
+// the transformation.
+type ParseErrors struct {
+	Errors []*ParseError `protobuf:"bytes,1,rep,name=errors" json:"errors,omitempty"`
+}
+
+func (m *ParseErrors) Reset()                    { *m = ParseErrors{} }
+func (m *ParseErrors) String() string            { return proto.CompactTextString(m) }
+func (*ParseErrors) ProtoMessage()               {}
+func (*ParseErrors) Descriptor() ([]byte, []int) { return fileDescriptorDriver, []int{18} }
+
+// DriverFailure is a detail of the gRPC status returned if the driver itself failed, as opposed to errors
+// in the source.
+type DriverFailure struct {
+	// Kind of the failure, for example "crash" or "timeout".
+	Kind string `protobuf:"bytes,1,opt,name=kind,proto3" json:"kind,omitempty"`
+	// Stage that failed, for example "native" or a name of the transformation stage. Empty if it is not known.
+	Stage string `protobuf:"bytes,2,opt,name=stage,proto3" json:"stage,omitempty"`
+	// Stderr is the last output of the native driver, if it crashed.
+	Stderr string `protobuf:"bytes,3,opt,name=stderr,proto3" json:"stderr,omitempty"`
+}
+
+func (m *DriverFailure) Reset()                    { *m = DriverFailure{} }
+func (m *DriverFailure) String() string            { return proto.CompactTextString(m) }
+func (*DriverFailure) ProtoMessage()               {}
+func (*DriverFailure) Descriptor() ([]byte, []int) { return fileDescriptorDriver, []int{19} }
+
 func init() {
 	proto.RegisterType((*ParseRequest)(nil), "gopkg.in.bblfsh.sdk.v2.protocol.ParseRequest")
 	proto.RegisterType((*ParseResponse)(nil), "gopkg.in.bblfsh.sdk.v2.protocol.ParseResponse")
 	proto.RegisterType((*ParseError)(nil), "gopkg.in.bblfsh.sdk.v2.protocol.ParseError")
+	proto.RegisterType((*ParseFilesRequest)(nil), "gopkg.in.bblfsh.sdk.v2.protocol.ParseFilesRequest")
+	proto.RegisterType((*ParseFilesResponse)(nil), "gopkg.in.bblfsh.sdk.v2.protocol.ParseFilesResponse")
+	proto.RegisterType((*ParseFileResult)(nil), "gopkg.in.bblfsh.sdk.v2.protocol.ParseFileResult")
+	proto.RegisterType((*LanguageOptions)(nil), "gopkg.in.bblfsh.sdk.v2.protocol.LanguageOptions")
+	proto.RegisterType((*Range)(nil), "gopkg.in.bblfsh.sdk.v2.protocol.Range")
+	proto.RegisterType((*CapabilitiesRequest)(nil), "gopkg.in.bblfsh.sdk.v2.protocol.CapabilitiesRequest")
+	proto.RegisterType((*CapabilitiesResponse)(nil), "gopkg.in.bblfsh.sdk.v2.protocol.CapabilitiesResponse")
+	proto.RegisterType((*LanguageVersion)(nil), "gopkg.in.bblfsh.sdk.v2.protocol.LanguageVersion")
+	proto.RegisterType((*SyntaxFeature)(nil), "gopkg.in.bblfsh.sdk.v2.protocol.SyntaxFeature")
+	proto.RegisterType((*Position)(nil), "gopkg.in.bblfsh.sdk.v2.protocol.Position")
+	proto.RegisterType((*ParseStreamRequest)(nil), "gopkg.in.bblfsh.sdk.v2.protocol.ParseStreamRequest")
+	proto.RegisterType((*ParseStreamResponse)(nil), "gopkg.in.bblfsh.sdk.v2.protocol.ParseStreamResponse")
+	proto.RegisterType((*ParseChunkedRequest)(nil), "gopkg.in.bblfsh.sdk.v2.protocol.ParseChunkedRequest")
+	proto.RegisterType((*ParseChunk)(nil), "gopkg.in.bblfsh.sdk.v2.protocol.ParseChunk")
+	proto.RegisterType((*FieldMask)(nil), "gopkg.in.bblfsh.sdk.v2.protocol.FieldMask")
+	proto.RegisterType((*ParseErrors)(nil), "gopkg.in.bblfsh.sdk.v2.protocol.ParseErrors")
+	proto.RegisterType((*DriverFailure)(nil), "gopkg.in.bblfsh.sdk.v2.protocol.DriverFailure")
 	proto.RegisterEnum("gopkg.in.bblfsh.sdk.v2.protocol.Mode", Mode_name, Mode_value)
+	proto.RegisterEnum("gopkg.in.bblfsh.sdk.v2.protocol.Severity", Severity_name, Severity_value)
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -135,6 +481,14 @@ const _ = grpc.SupportPackageIsVersion4
 type DriverClient interface {
 	// Parse returns an UAST for a given source file.
 	Parse(ctx context.Context, in *ParseRequest, opts ...grpc.CallOption) (*ParseResponse, error)
+	// ParseFiles returns UASTs for multiple source files.
+	ParseFiles(ctx context.Context, in *ParseFilesRequest, opts ...grpc.CallOption) (*ParseFilesResponse, error)
+	// Capabilities returns features supported by the driver.
+	Capabilities(ctx context.Context, in *CapabilitiesRequest, opts ...grpc.CallOption) (*CapabilitiesResponse, error)
+	// ParseStream parses multiple source files sent on a single stream.
+	ParseStream(ctx context.Context, opts ...grpc.CallOption) (Driver_ParseStreamClient, error)
+	// ParseChunked returns an UAST for a given source file, split into multiple chunks.
+	ParseChunked(ctx context.Context, in *ParseChunkedRequest, opts ...grpc.CallOption) (Driver_ParseChunkedClient, error)
 }
 
 type driverClient struct {
@@ -154,11 +508,100 @@ func (c *driverClient) Parse(ctx context.Context, in *ParseRequest, opts ...grpc
 	return out, nil
 }
 
+func (c *driverClient) ParseFiles(ctx context.Context, in *ParseFilesRequest, opts ...grpc.CallOption) (*ParseFilesResponse, error) {
+	out := new(ParseFilesResponse)
+	err := grpc.Invoke(ctx, "/gopkg.in.bblfsh.sdk.v2.protocol.Driver/ParseFiles", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *driverClient) Capabilities(ctx context.Context, in *CapabilitiesRequest, opts ...grpc.CallOption) (*CapabilitiesResponse, error) {
+	out := new(CapabilitiesResponse)
+	err := grpc.Invoke(ctx, "/gopkg.in.bblfsh.sdk.v2.protocol.Driver/Capabilities", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *driverClient) ParseStream(ctx context.Context, opts ...grpc.CallOption) (Driver_ParseStreamClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Driver_serviceDesc.Streams[0], c.cc, "/gopkg.in.bblfsh.sdk.v2.protocol.Driver/ParseStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &driverParseStreamClient{stream}
+	return x, nil
+}
+
+type Driver_ParseStreamClient interface {
+	Send(*ParseStreamRequest) error
+	Recv() (*ParseStreamResponse, error)
+	grpc.ClientStream
+}
+
+type driverParseStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *driverParseStreamClient) Send(m *ParseStreamRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *driverParseStreamClient) Recv() (*ParseStreamResponse, error) {
+	m := new(ParseStreamResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *driverClient) ParseChunked(ctx context.Context, in *ParseChunkedRequest, opts ...grpc.CallOption) (Driver_ParseChunkedClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Driver_serviceDesc.Streams[1], c.cc, "/gopkg.in.bblfsh.sdk.v2.protocol.Driver/ParseChunked", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &driverParseChunkedClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Driver_ParseChunkedClient interface {
+	Recv() (*ParseChunk, error)
+	grpc.ClientStream
+}
+
+type driverParseChunkedClient struct {
+	grpc.ClientStream
+}
+
+func (x *driverParseChunkedClient) Recv() (*ParseChunk, error) {
+	m := new(ParseChunk)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // Server API for Driver service
 
 type DriverServer interface {
 	// Parse returns an UAST for a given source file.
 	Parse(context.Context, *ParseRequest) (*ParseResponse, error)
+	// ParseFiles returns UASTs for multiple source files.
+	ParseFiles(context.Context, *ParseFilesRequest) (*ParseFilesResponse, error)
+	// Capabilities returns features supported by the driver.
+	Capabilities(context.Context, *CapabilitiesRequest) (*CapabilitiesResponse, error)
+	// ParseStream parses multiple source files sent on a single stream.
+	ParseStream(Driver_ParseStreamServer) error
+	// ParseChunked returns an UAST for a given source file, split into multiple chunks.
+	ParseChunked(*ParseChunkedRequest, Driver_ParseChunkedServer) error
 }
 
 func RegisterDriverServer(s *grpc.Server, srv DriverServer) {
@@ -183,6 +626,89 @@ func _Driver_Parse_Handler(srv interface{}, ctx context.Context, dec func(interf
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Driver_ParseFiles_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ParseFilesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DriverServer).ParseFiles(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gopkg.in.bblfsh.sdk.v2.protocol.Driver/ParseFiles",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DriverServer).ParseFiles(ctx, req.(*ParseFilesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Driver_Capabilities_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CapabilitiesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DriverServer).Capabilities(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gopkg.in.bblfsh.sdk.v2.protocol.Driver/Capabilities",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DriverServer).Capabilities(ctx, req.(*CapabilitiesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Driver_ParseStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(DriverServer).ParseStream(&driverParseStreamServer{stream})
+}
+
+type Driver_ParseStreamServer interface {
+	Send(*ParseStreamResponse) error
+	Recv() (*ParseStreamRequest, error)
+	grpc.ServerStream
+}
+
+type driverParseStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *driverParseStreamServer) Send(m *ParseStreamResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *driverParseStreamServer) Recv() (*ParseStreamRequest, error) {
+	m := new(ParseStreamRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Driver_ParseChunked_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ParseChunkedRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DriverServer).ParseChunked(m, &driverParseChunkedServer{stream})
+}
+
+type Driver_ParseChunkedServer interface {
+	Send(*ParseChunk) error
+	grpc.ServerStream
+}
+
+type driverParseChunkedServer struct {
+	grpc.ServerStream
+}
+
+func (x *driverParseChunkedServer) Send(m *ParseChunk) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 var _Driver_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "gopkg.in.bblfsh.sdk.v2.protocol.Driver",
 	HandlerType: (*DriverServer)(nil),
@@ -191,8 +717,28 @@ var _Driver_serviceDesc = grpc.ServiceDesc{
 			MethodName: "Parse",
 			Handler:    _Driver_Parse_Handler,
 		},
+		{
+			MethodName: "ParseFiles",
+			Handler:    _Driver_ParseFiles_Handler,
+		},
+		{
+			MethodName: "Capabilities",
+			Handler:    _Driver_Capabilities_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ParseStream",
+			Handler:       _Driver_ParseStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "ParseChunked",
+			Handler:       _Driver_ParseChunked_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "driver.proto",
 }
 
@@ -234,6 +780,65 @@ func (m *ParseRequest) MarshalTo(dAtA []byte) (int, error) {
 		i++
 		i = encodeVarintDriver(dAtA, i, uint64(m.Mode))
 	}
+	if m.Options != nil {
+		dAtA[i] = 0x2a
+		i++
+		i = encodeVarintDriver(dAtA, i, uint64(m.Options.ProtoSize()))
+		n1, err := m.Options.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n1
+	}
+	if m.Range != nil {
+		dAtA[i] = 0x32
+		i++
+		i = encodeVarintDriver(dAtA, i, uint64(m.Range.ProtoSize()))
+		n2, err := m.Range.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n2
+	}
+	if len(m.Metadata) > 0 {
+		for k, _ := range m.Metadata {
+			dAtA[i] = 0x3a
+			i++
+			v := m.Metadata[k]
+			mapSize := 1 + len(k) + sovDriver(uint64(len(k))) + 1 + len(v) + sovDriver(uint64(len(v)))
+			i = encodeVarintDriver(dAtA, i, uint64(mapSize))
+			dAtA[i] = 0xa
+			i++
+			i = encodeVarintDriver(dAtA, i, uint64(len(k)))
+			i += copy(dAtA[i:], k)
+			dAtA[i] = 0x12
+			i++
+			i = encodeVarintDriver(dAtA, i, uint64(len(v)))
+			i += copy(dAtA[i:], v)
+		}
+	}
+	if len(m.Charset) > 0 {
+		dAtA[i] = 0x42
+		i++
+		i = encodeVarintDriver(dAtA, i, uint64(len(m.Charset)))
+		i += copy(dAtA[i:], m.Charset)
+	}
+	if m.Fields != nil {
+		dAtA[i] = 0x4a
+		i++
+		i = encodeVarintDriver(dAtA, i, uint64(m.Fields.ProtoSize()))
+		n3, err := m.Fields.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n3
+	}
+	if len(m.Query) > 0 {
+		dAtA[i] = 0x52
+		i++
+		i = encodeVarintDriver(dAtA, i, uint64(len(m.Query)))
+		i += copy(dAtA[i:], m.Query)
+	}
 	return i, nil
 }
 
@@ -276,6 +881,22 @@ func (m *ParseResponse) MarshalTo(dAtA []byte) (int, error) {
 			i += n
 		}
 	}
+	if m.Options != nil {
+		dAtA[i] = 0x22
+		i++
+		i = encodeVarintDriver(dAtA, i, uint64(m.Options.ProtoSize()))
+		n3, err := m.Options.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n3
+	}
+	if len(m.Charset) > 0 {
+		dAtA[i] = 0x2a
+		i++
+		i = encodeVarintDriver(dAtA, i, uint64(len(m.Charset)))
+		i += copy(dAtA[i:], m.Charset)
+	}
 	return i, nil
 }
 
@@ -300,101 +921,3648 @@ func (m *ParseError) MarshalTo(dAtA []byte) (int, error) {
 		i = encodeVarintDriver(dAtA, i, uint64(len(m.Text)))
 		i += copy(dAtA[i:], m.Text)
 	}
+	if m.Severity != 0 {
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintDriver(dAtA, i, uint64(m.Severity))
+	}
+	if len(m.Code) > 0 {
+		dAtA[i] = 0x1a
+		i++
+		i = encodeVarintDriver(dAtA, i, uint64(len(m.Code)))
+		i += copy(dAtA[i:], m.Code)
+	}
+	if m.Start != nil {
+		dAtA[i] = 0x22
+		i++
+		i = encodeVarintDriver(dAtA, i, uint64(m.Start.ProtoSize()))
+		n4, err := m.Start.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n4
+	}
+	if m.End != nil {
+		dAtA[i] = 0x2a
+		i++
+		i = encodeVarintDriver(dAtA, i, uint64(m.End.ProtoSize()))
+		n5, err := m.End.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n5
+	}
 	return i, nil
 }
 
-func encodeFixed64Driver(dAtA []byte, offset int, v uint64) int {
-	dAtA[offset] = uint8(v)
-	dAtA[offset+1] = uint8(v >> 8)
-	dAtA[offset+2] = uint8(v >> 16)
-	dAtA[offset+3] = uint8(v >> 24)
-	dAtA[offset+4] = uint8(v >> 32)
-	dAtA[offset+5] = uint8(v >> 40)
-	dAtA[offset+6] = uint8(v >> 48)
-	dAtA[offset+7] = uint8(v >> 56)
-	return offset + 8
-}
-func encodeFixed32Driver(dAtA []byte, offset int, v uint32) int {
-	dAtA[offset] = uint8(v)
-	dAtA[offset+1] = uint8(v >> 8)
-	dAtA[offset+2] = uint8(v >> 16)
-	dAtA[offset+3] = uint8(v >> 24)
-	return offset + 4
-}
-func encodeVarintDriver(dAtA []byte, offset int, v uint64) int {
-	for v >= 1<<7 {
-		dAtA[offset] = uint8(v&0x7f | 0x80)
-		v >>= 7
-		offset++
+func (m *ParseFilesRequest) Marshal() (dAtA []byte, err error) {
+	size := m.ProtoSize()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
 	}
-	dAtA[offset] = uint8(v)
-	return offset + 1
+	return dAtA[:n], nil
 }
-func (m *ParseRequest) ProtoSize() (n int) {
+
+func (m *ParseFilesRequest) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
-	l = len(m.Content)
-	if l > 0 {
-		n += 1 + l + sovDriver(uint64(l))
-	}
-	l = len(m.Language)
-	if l > 0 {
-		n += 1 + l + sovDriver(uint64(l))
-	}
-	l = len(m.Filename)
-	if l > 0 {
-		n += 1 + l + sovDriver(uint64(l))
+	if len(m.Files) > 0 {
+		for _, msg := range m.Files {
+			dAtA[i] = 0xa
+			i++
+			i = encodeVarintDriver(dAtA, i, uint64(msg.ProtoSize()))
+			n, err := msg.MarshalTo(dAtA[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
+		}
 	}
 	if m.Mode != 0 {
-		n += 1 + sovDriver(uint64(m.Mode))
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintDriver(dAtA, i, uint64(m.Mode))
 	}
-	return n
+	return i, nil
 }
 
-func (m *ParseResponse) ProtoSize() (n int) {
-	var l int
-	_ = l
-	l = len(m.Uast)
-	if l > 0 {
-		n += 1 + l + sovDriver(uint64(l))
+func (m *ParseFilesResponse) Marshal() (dAtA []byte, err error) {
+	size := m.ProtoSize()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
 	}
-	l = len(m.Language)
-	if l > 0 {
-		n += 1 + l + sovDriver(uint64(l))
+	return dAtA[:n], nil
+}
+
+func (m *ParseFilesResponse) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.Results) > 0 {
+		for _, msg := range m.Results {
+			dAtA[i] = 0xa
+			i++
+			i = encodeVarintDriver(dAtA, i, uint64(msg.ProtoSize()))
+			n, err := msg.MarshalTo(dAtA[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
+		}
+	}
+	return i, nil
+}
+
+func (m *ParseFileResult) Marshal() (dAtA []byte, err error) {
+	size := m.ProtoSize()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ParseFileResult) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if m.Response != nil {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintDriver(dAtA, i, uint64(m.Response.ProtoSize()))
+		n7, err := m.Response.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n7
+	}
+	if m.Code != 0 {
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintDriver(dAtA, i, uint64(m.Code))
+	}
+	if len(m.Message) > 0 {
+		dAtA[i] = 0x1a
+		i++
+		i = encodeVarintDriver(dAtA, i, uint64(len(m.Message)))
+		i += copy(dAtA[i:], m.Message)
+	}
+	if m.Failure != nil {
+		dAtA[i] = 0x22
+		i++
+		i = encodeVarintDriver(dAtA, i, uint64(m.Failure.ProtoSize()))
+		n8, err := m.Failure.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n8
 	}
 	if len(m.Errors) > 0 {
-		for _, e := range m.Errors {
-			l = e.ProtoSize()
-			n += 1 + l + sovDriver(uint64(l))
+		for _, msg := range m.Errors {
+			dAtA[i] = 0x2a
+			i++
+			i = encodeVarintDriver(dAtA, i, uint64(msg.ProtoSize()))
+			n, err := msg.MarshalTo(dAtA[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
 		}
 	}
-	return n
+	return i, nil
 }
 
-func (m *ParseError) ProtoSize() (n int) {
+func (m *LanguageOptions) Marshal() (dAtA []byte, err error) {
+	size := m.ProtoSize()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *LanguageOptions) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
 	var l int
 	_ = l
-	l = len(m.Text)
-	if l > 0 {
-		n += 1 + l + sovDriver(uint64(l))
+	if len(m.Version) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintDriver(dAtA, i, uint64(len(m.Version)))
+		i += copy(dAtA[i:], m.Version)
 	}
-	return n
+	if len(m.Dialects) > 0 {
+		for _, s := range m.Dialects {
+			dAtA[i] = 0x12
+			i++
+			l = len(s)
+			for l >= 1<<7 {
+				dAtA[i] = uint8(uint64(l)&0x7f | 0x80)
+				l >>= 7
+				i++
+			}
+			dAtA[i] = uint8(l)
+			i++
+			i += copy(dAtA[i:], s)
+		}
+	}
+	if m.Strict {
+		dAtA[i] = 0x18
+		i++
+		if m.Strict {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
+	return i, nil
 }
 
-func sovDriver(x uint64) (n int) {
-	for {
-		n++
-		x >>= 7
-		if x == 0 {
-			break
+func (m *Range) Marshal() (dAtA []byte, err error) {
+	size := m.ProtoSize()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Range) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if m.Start != 0 {
+		dAtA[i] = 0x8
+		i++
+		i = encodeVarintDriver(dAtA, i, uint64(m.Start))
+	}
+	if m.End != 0 {
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintDriver(dAtA, i, uint64(m.End))
+	}
+	if m.StartLine != 0 {
+		dAtA[i] = 0x18
+		i++
+		i = encodeVarintDriver(dAtA, i, uint64(m.StartLine))
+	}
+	if m.EndLine != 0 {
+		dAtA[i] = 0x20
+		i++
+		i = encodeVarintDriver(dAtA, i, uint64(m.EndLine))
+	}
+	return i, nil
+}
+
+func (m *CapabilitiesRequest) Marshal() (dAtA []byte, err error) {
+	size := m.ProtoSize()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *CapabilitiesRequest) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	return i, nil
+}
+
+func (m *CapabilitiesResponse) Marshal() (dAtA []byte, err error) {
+	size := m.ProtoSize()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *CapabilitiesResponse) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.Modes) > 0 {
+		dAtA8 := make([]byte, len(m.Modes)*10)
+		var j7 int
+		for _, num := range m.Modes {
+			for num >= 1<<7 {
+				dAtA8[j7] = uint8(uint64(num)&0x7f | 0x80)
+				num >>= 7
+				j7++
+			}
+			dAtA8[j7] = uint8(num)
+			j7++
+		}
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintDriver(dAtA, i, uint64(j7))
+		i += copy(dAtA[i:], dAtA8[:j7])
+	}
+	if len(m.Encodings) > 0 {
+		for _, s := range m.Encodings {
+			dAtA[i] = 0x12
+			i++
+			l = len(s)
+			for l >= 1<<7 {
+				dAtA[i] = uint8(uint64(l)&0x7f | 0x80)
+				l >>= 7
+				i++
+			}
+			dAtA[i] = uint8(l)
+			i++
+			i += copy(dAtA[i:], s)
+		}
+	}
+	if m.Options {
+		dAtA[i] = 0x18
+		i++
+		if m.Options {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
+	if m.Incremental {
+		dAtA[i] = 0x20
+		i++
+		if m.Incremental {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
+	if m.MaxFileSize != 0 {
+		dAtA[i] = 0x28
+		i++
+		i = encodeVarintDriver(dAtA, i, uint64(m.MaxFileSize))
+	}
+	if len(m.Versions) > 0 {
+		for _, msg := range m.Versions {
+			dAtA[i] = 0x32
+			i++
+			i = encodeVarintDriver(dAtA, i, uint64(msg.ProtoSize()))
+			n, err := msg.MarshalTo(dAtA[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
+		}
+	}
+	if len(m.Compressors) > 0 {
+		for _, s := range m.Compressors {
+			dAtA[i] = 0x3a
+			i++
+			l = len(s)
+			for l >= 1<<7 {
+				dAtA[i] = uint8(uint64(l)&0x7f | 0x80)
+				l >>= 7
+				i++
+			}
+			dAtA[i] = uint8(l)
+			i++
+			i += copy(dAtA[i:], s)
+		}
+	}
+	if m.CompressionRequired {
+		dAtA[i] = 0x40
+		i++
+		if m.CompressionRequired {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
+	return i, nil
+}
+
+func (m *LanguageVersion) Marshal() (dAtA []byte, err error) {
+	size := m.ProtoSize()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *LanguageVersion) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.Version) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintDriver(dAtA, i, uint64(len(m.Version)))
+		i += copy(dAtA[i:], m.Version)
+	}
+	if len(m.Features) > 0 {
+		for _, msg := range m.Features {
+			dAtA[i] = 0x12
+			i++
+			i = encodeVarintDriver(dAtA, i, uint64(msg.ProtoSize()))
+			n, err := msg.MarshalTo(dAtA[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
+		}
+	}
+	return i, nil
+}
+
+func (m *SyntaxFeature) Marshal() (dAtA []byte, err error) {
+	size := m.ProtoSize()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *SyntaxFeature) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.Name) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintDriver(dAtA, i, uint64(len(m.Name)))
+		i += copy(dAtA[i:], m.Name)
+	}
+	if m.Supported {
+		dAtA[i] = 0x10
+		i++
+		if m.Supported {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
 		}
+		i++
+	}
+	return i, nil
+}
+
+func (m *Position) Marshal() (dAtA []byte, err error) {
+	size := m.ProtoSize()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Position) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if m.Offset != 0 {
+		dAtA[i] = 0x8
+		i++
+		i = encodeVarintDriver(dAtA, i, uint64(m.Offset))
+	}
+	if m.Line != 0 {
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintDriver(dAtA, i, uint64(m.Line))
+	}
+	if m.Col != 0 {
+		dAtA[i] = 0x18
+		i++
+		i = encodeVarintDriver(dAtA, i, uint64(m.Col))
+	}
+	return i, nil
+}
+
+func (m *ParseStreamRequest) Marshal() (dAtA []byte, err error) {
+	size := m.ProtoSize()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ParseStreamRequest) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if m.ID != 0 {
+		dAtA[i] = 0x8
+		i++
+		i = encodeVarintDriver(dAtA, i, uint64(m.ID))
+	}
+	if m.Request != nil {
+		dAtA[i] = 0x12
+		i++
+		i = encodeVarintDriver(dAtA, i, uint64(m.Request.ProtoSize()))
+		n9, err := m.Request.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n9
+	}
+	return i, nil
+}
+
+func (m *ParseStreamResponse) Marshal() (dAtA []byte, err error) {
+	size := m.ProtoSize()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ParseStreamResponse) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if m.ID != 0 {
+		dAtA[i] = 0x8
+		i++
+		i = encodeVarintDriver(dAtA, i, uint64(m.ID))
+	}
+	if m.Response != nil {
+		dAtA[i] = 0x12
+		i++
+		i = encodeVarintDriver(dAtA, i, uint64(m.Response.ProtoSize()))
+		n12, err := m.Response.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n12
+	}
+	if m.Code != 0 {
+		dAtA[i] = 0x18
+		i++
+		i = encodeVarintDriver(dAtA, i, uint64(m.Code))
+	}
+	if len(m.Message) > 0 {
+		dAtA[i] = 0x22
+		i++
+		i = encodeVarintDriver(dAtA, i, uint64(len(m.Message)))
+		i += copy(dAtA[i:], m.Message)
+	}
+	if m.Failure != nil {
+		dAtA[i] = 0x2a
+		i++
+		i = encodeVarintDriver(dAtA, i, uint64(m.Failure.ProtoSize()))
+		n13, err := m.Failure.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n13
+	}
+	if len(m.Errors) > 0 {
+		for _, msg := range m.Errors {
+			dAtA[i] = 0x32
+			i++
+			i = encodeVarintDriver(dAtA, i, uint64(msg.ProtoSize()))
+			n, err := msg.MarshalTo(dAtA[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
+		}
+	}
+	return i, nil
+}
+
+func (m *ParseChunkedRequest) Marshal() (dAtA []byte, err error) {
+	size := m.ProtoSize()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ParseChunkedRequest) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if m.Request != nil {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintDriver(dAtA, i, uint64(m.Request.ProtoSize()))
+		n11, err := m.Request.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n11
+	}
+	if m.ChunkSize != 0 {
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintDriver(dAtA, i, uint64(m.ChunkSize))
+	}
+	return i, nil
+}
+
+func (m *ParseChunk) Marshal() (dAtA []byte, err error) {
+	size := m.ProtoSize()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ParseChunk) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if m.Response != nil {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintDriver(dAtA, i, uint64(m.Response.ProtoSize()))
+		n12, err := m.Response.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n12
+	}
+	if m.Size != 0 {
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintDriver(dAtA, i, uint64(m.Size))
+	}
+	if len(m.Data) > 0 {
+		dAtA[i] = 0x1a
+		i++
+		i = encodeVarintDriver(dAtA, i, uint64(len(m.Data)))
+		i += copy(dAtA[i:], m.Data)
+	}
+	return i, nil
+}
+
+func (m *FieldMask) Marshal() (dAtA []byte, err error) {
+	size := m.ProtoSize()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *FieldMask) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.Include) > 0 {
+		for _, s := range m.Include {
+			dAtA[i] = 0xa
+			i++
+			l = len(s)
+			for l >= 1<<7 {
+				dAtA[i] = uint8(uint64(l)&0x7f | 0x80)
+				l >>= 7
+				i++
+			}
+			dAtA[i] = uint8(l)
+			i++
+			i += copy(dAtA[i:], s)
+		}
+	}
+	if len(m.Exclude) > 0 {
+		for _, s := range m.Exclude {
+			dAtA[i] = 0x12
+			i++
+			l = len(s)
+			for l >= 1<<7 {
+				dAtA[i] = uint8(uint64(l)&0x7f | 0x80)
+				l >>= 7
+				i++
+			}
+			dAtA[i] = uint8(l)
+			i++
+			i += copy(dAtA[i:], s)
+		}
+	}
+	return i, nil
+}
+
+func (m *ParseErrors) Marshal() (dAtA []byte, err error) {
+	size := m.ProtoSize()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ParseErrors) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.Errors) > 0 {
+		for _, msg := range m.Errors {
+			dAtA[i] = 0xa
+			i++
+			i = encodeVarintDriver(dAtA, i, uint64(msg.ProtoSize()))
+			n, err := msg.MarshalTo(dAtA[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
+		}
+	}
+	return i, nil
+}
+
+func (m *DriverFailure) Marshal() (dAtA []byte, err error) {
+	size := m.ProtoSize()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *DriverFailure) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.Kind) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintDriver(dAtA, i, uint64(len(m.Kind)))
+		i += copy(dAtA[i:], m.Kind)
+	}
+	if len(m.Stage) > 0 {
+		dAtA[i] = 0x12
+		i++
+		i = encodeVarintDriver(dAtA, i, uint64(len(m.Stage)))
+		i += copy(dAtA[i:], m.Stage)
+	}
+	if len(m.Stderr) > 0 {
+		dAtA[i] = 0x1a
+		i++
+		i = encodeVarintDriver(dAtA, i, uint64(len(m.Stderr)))
+		i += copy(dAtA[i:], m.Stderr)
+	}
+	return i, nil
+}
+
+func encodeFixed64Driver(dAtA []byte, offset int, v uint64) int {
+	dAtA[offset] = uint8(v)
+	dAtA[offset+1] = uint8(v >> 8)
+	dAtA[offset+2] = uint8(v >> 16)
+	dAtA[offset+3] = uint8(v >> 24)
+	dAtA[offset+4] = uint8(v >> 32)
+	dAtA[offset+5] = uint8(v >> 40)
+	dAtA[offset+6] = uint8(v >> 48)
+	dAtA[offset+7] = uint8(v >> 56)
+	return offset + 8
+}
+func encodeFixed32Driver(dAtA []byte, offset int, v uint32) int {
+	dAtA[offset] = uint8(v)
+	dAtA[offset+1] = uint8(v >> 8)
+	dAtA[offset+2] = uint8(v >> 16)
+	dAtA[offset+3] = uint8(v >> 24)
+	return offset + 4
+}
+func encodeVarintDriver(dAtA []byte, offset int, v uint64) int {
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
+	return offset + 1
+}
+func (m *ParseRequest) ProtoSize() (n int) {
+	var l int
+	_ = l
+	l = len(m.Content)
+	if l > 0 {
+		n += 1 + l + sovDriver(uint64(l))
+	}
+	l = len(m.Language)
+	if l > 0 {
+		n += 1 + l + sovDriver(uint64(l))
+	}
+	l = len(m.Filename)
+	if l > 0 {
+		n += 1 + l + sovDriver(uint64(l))
+	}
+	if m.Mode != 0 {
+		n += 1 + sovDriver(uint64(m.Mode))
+	}
+	if m.Options != nil {
+		l = m.Options.ProtoSize()
+		n += 1 + l + sovDriver(uint64(l))
+	}
+	if m.Range != nil {
+		l = m.Range.ProtoSize()
+		n += 1 + l + sovDriver(uint64(l))
+	}
+	if len(m.Metadata) > 0 {
+		for k, v := range m.Metadata {
+			_ = k
+			_ = v
+			mapEntrySize := 1 + len(k) + sovDriver(uint64(len(k))) + 1 + len(v) + sovDriver(uint64(len(v)))
+			n += mapEntrySize + 1 + sovDriver(uint64(mapEntrySize))
+		}
+	}
+	l = len(m.Charset)
+	if l > 0 {
+		n += 1 + l + sovDriver(uint64(l))
+	}
+	if m.Fields != nil {
+		l = m.Fields.ProtoSize()
+		n += 1 + l + sovDriver(uint64(l))
+	}
+	l = len(m.Query)
+	if l > 0 {
+		n += 1 + l + sovDriver(uint64(l))
+	}
+	return n
+}
+
+func (m *ParseResponse) ProtoSize() (n int) {
+	var l int
+	_ = l
+	l = len(m.Uast)
+	if l > 0 {
+		n += 1 + l + sovDriver(uint64(l))
+	}
+	l = len(m.Language)
+	if l > 0 {
+		n += 1 + l + sovDriver(uint64(l))
+	}
+	if len(m.Errors) > 0 {
+		for _, e := range m.Errors {
+			l = e.ProtoSize()
+			n += 1 + l + sovDriver(uint64(l))
+		}
+	}
+	if m.Options != nil {
+		l = m.Options.ProtoSize()
+		n += 1 + l + sovDriver(uint64(l))
+	}
+	l = len(m.Charset)
+	if l > 0 {
+		n += 1 + l + sovDriver(uint64(l))
+	}
+	return n
+}
+
+func (m *ParseError) ProtoSize() (n int) {
+	var l int
+	_ = l
+	l = len(m.Text)
+	if l > 0 {
+		n += 1 + l + sovDriver(uint64(l))
+	}
+	if m.Severity != 0 {
+		n += 1 + sovDriver(uint64(m.Severity))
+	}
+	l = len(m.Code)
+	if l > 0 {
+		n += 1 + l + sovDriver(uint64(l))
+	}
+	if m.Start != nil {
+		l = m.Start.ProtoSize()
+		n += 1 + l + sovDriver(uint64(l))
+	}
+	if m.End != nil {
+		l = m.End.ProtoSize()
+		n += 1 + l + sovDriver(uint64(l))
+	}
+	return n
+}
+
+func (m *ParseFilesRequest) ProtoSize() (n int) {
+	var l int
+	_ = l
+	if len(m.Files) > 0 {
+		for _, e := range m.Files {
+			l = e.ProtoSize()
+			n += 1 + l + sovDriver(uint64(l))
+		}
+	}
+	if m.Mode != 0 {
+		n += 1 + sovDriver(uint64(m.Mode))
+	}
+	return n
+}
+
+func (m *ParseFilesResponse) ProtoSize() (n int) {
+	var l int
+	_ = l
+	if len(m.Results) > 0 {
+		for _, e := range m.Results {
+			l = e.ProtoSize()
+			n += 1 + l + sovDriver(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *ParseFileResult) ProtoSize() (n int) {
+	var l int
+	_ = l
+	if m.Response != nil {
+		l = m.Response.ProtoSize()
+		n += 1 + l + sovDriver(uint64(l))
+	}
+	if m.Code != 0 {
+		n += 1 + sovDriver(uint64(m.Code))
+	}
+	l = len(m.Message)
+	if l > 0 {
+		n += 1 + l + sovDriver(uint64(l))
+	}
+	if m.Failure != nil {
+		l = m.Failure.ProtoSize()
+		n += 1 + l + sovDriver(uint64(l))
+	}
+	if len(m.Errors) > 0 {
+		for _, e := range m.Errors {
+			l = e.ProtoSize()
+			n += 1 + l + sovDriver(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *LanguageOptions) ProtoSize() (n int) {
+	var l int
+	_ = l
+	l = len(m.Version)
+	if l > 0 {
+		n += 1 + l + sovDriver(uint64(l))
+	}
+	if len(m.Dialects) > 0 {
+		for _, s := range m.Dialects {
+			l = len(s)
+			n += 1 + l + sovDriver(uint64(l))
+		}
+	}
+	if m.Strict {
+		n += 2
+	}
+	return n
+}
+
+func (m *Range) ProtoSize() (n int) {
+	var l int
+	_ = l
+	if m.Start != 0 {
+		n += 1 + sovDriver(uint64(m.Start))
+	}
+	if m.End != 0 {
+		n += 1 + sovDriver(uint64(m.End))
+	}
+	if m.StartLine != 0 {
+		n += 1 + sovDriver(uint64(m.StartLine))
+	}
+	if m.EndLine != 0 {
+		n += 1 + sovDriver(uint64(m.EndLine))
+	}
+	return n
+}
+
+func (m *CapabilitiesRequest) ProtoSize() (n int) {
+	var l int
+	_ = l
+	return n
+}
+
+func (m *CapabilitiesResponse) ProtoSize() (n int) {
+	var l int
+	_ = l
+	if len(m.Modes) > 0 {
+		l = 0
+		for _, e := range m.Modes {
+			l += sovDriver(uint64(e))
+		}
+		n += 1 + sovDriver(uint64(l)) + l
+	}
+	if len(m.Encodings) > 0 {
+		for _, s := range m.Encodings {
+			l = len(s)
+			n += 1 + l + sovDriver(uint64(l))
+		}
+	}
+	if m.Options {
+		n += 2
+	}
+	if m.Incremental {
+		n += 2
+	}
+	if m.MaxFileSize != 0 {
+		n += 1 + sovDriver(uint64(m.MaxFileSize))
+	}
+	if len(m.Versions) > 0 {
+		for _, e := range m.Versions {
+			l = e.ProtoSize()
+			n += 1 + l + sovDriver(uint64(l))
+		}
+	}
+	if len(m.Compressors) > 0 {
+		for _, s := range m.Compressors {
+			l = len(s)
+			n += 1 + l + sovDriver(uint64(l))
+		}
+	}
+	if m.CompressionRequired {
+		n += 2
+	}
+	return n
+}
+
+func (m *LanguageVersion) ProtoSize() (n int) {
+	var l int
+	_ = l
+	l = len(m.Version)
+	if l > 0 {
+		n += 1 + l + sovDriver(uint64(l))
+	}
+	if len(m.Features) > 0 {
+		for _, e := range m.Features {
+			l = e.ProtoSize()
+			n += 1 + l + sovDriver(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *SyntaxFeature) ProtoSize() (n int) {
+	var l int
+	_ = l
+	l = len(m.Name)
+	if l > 0 {
+		n += 1 + l + sovDriver(uint64(l))
+	}
+	if m.Supported {
+		n += 2
+	}
+	return n
+}
+
+func (m *Position) ProtoSize() (n int) {
+	var l int
+	_ = l
+	if m.Offset != 0 {
+		n += 1 + sovDriver(uint64(m.Offset))
+	}
+	if m.Line != 0 {
+		n += 1 + sovDriver(uint64(m.Line))
+	}
+	if m.Col != 0 {
+		n += 1 + sovDriver(uint64(m.Col))
+	}
+	return n
+}
+
+func (m *ParseStreamRequest) ProtoSize() (n int) {
+	var l int
+	_ = l
+	if m.ID != 0 {
+		n += 1 + sovDriver(uint64(m.ID))
+	}
+	if m.Request != nil {
+		l = m.Request.ProtoSize()
+		n += 1 + l + sovDriver(uint64(l))
+	}
+	return n
+}
+
+func (m *ParseStreamResponse) ProtoSize() (n int) {
+	var l int
+	_ = l
+	if m.ID != 0 {
+		n += 1 + sovDriver(uint64(m.ID))
+	}
+	if m.Response != nil {
+		l = m.Response.ProtoSize()
+		n += 1 + l + sovDriver(uint64(l))
+	}
+	if m.Code != 0 {
+		n += 1 + sovDriver(uint64(m.Code))
+	}
+	l = len(m.Message)
+	if l > 0 {
+		n += 1 + l + sovDriver(uint64(l))
+	}
+	if m.Failure != nil {
+		l = m.Failure.ProtoSize()
+		n += 1 + l + sovDriver(uint64(l))
+	}
+	if len(m.Errors) > 0 {
+		for _, e := range m.Errors {
+			l = e.ProtoSize()
+			n += 1 + l + sovDriver(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *ParseChunkedRequest) ProtoSize() (n int) {
+	var l int
+	_ = l
+	if m.Request != nil {
+		l = m.Request.ProtoSize()
+		n += 1 + l + sovDriver(uint64(l))
+	}
+	if m.ChunkSize != 0 {
+		n += 1 + sovDriver(uint64(m.ChunkSize))
+	}
+	return n
+}
+
+func (m *ParseChunk) ProtoSize() (n int) {
+	var l int
+	_ = l
+	if m.Response != nil {
+		l = m.Response.ProtoSize()
+		n += 1 + l + sovDriver(uint64(l))
+	}
+	if m.Size != 0 {
+		n += 1 + sovDriver(uint64(m.Size))
+	}
+	l = len(m.Data)
+	if l > 0 {
+		n += 1 + l + sovDriver(uint64(l))
+	}
+	return n
+}
+
+func (m *FieldMask) ProtoSize() (n int) {
+	var l int
+	_ = l
+	if len(m.Include) > 0 {
+		for _, s := range m.Include {
+			l = len(s)
+			n += 1 + l + sovDriver(uint64(l))
+		}
+	}
+	if len(m.Exclude) > 0 {
+		for _, s := range m.Exclude {
+			l = len(s)
+			n += 1 + l + sovDriver(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *ParseErrors) ProtoSize() (n int) {
+	var l int
+	_ = l
+	if len(m.Errors) > 0 {
+		for _, e := range m.Errors {
+			l = e.ProtoSize()
+			n += 1 + l + sovDriver(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *DriverFailure) ProtoSize() (n int) {
+	var l int
+	_ = l
+	l = len(m.Kind)
+	if l > 0 {
+		n += 1 + l + sovDriver(uint64(l))
+	}
+	l = len(m.Stage)
+	if l > 0 {
+		n += 1 + l + sovDriver(uint64(l))
+	}
+	l = len(m.Stderr)
+	if l > 0 {
+		n += 1 + l + sovDriver(uint64(l))
+	}
+	return n
+}
+
+func sovDriver(x uint64) (n int) {
+	for {
+		n++
+		x >>= 7
+		if x == 0 {
+			break
+		}
+	}
+	return n
+}
+func sozDriver(x uint64) (n int) {
+	return sovDriver(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+}
+func (m *ParseRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowDriver
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ParseRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ParseRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Content", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthDriver
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Content = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Language", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthDriver
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Language = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Filename", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthDriver
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Filename = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Mode", wireType)
+			}
+			m.Mode = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Mode |= (Mode(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Options", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthDriver
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Options == nil {
+				m.Options = &LanguageOptions{}
+			}
+			if err := m.Options.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Range", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthDriver
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Range == nil {
+				m.Range = &Range{}
+			}
+			if err := m.Range.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 7:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Metadata", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthDriver
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Metadata == nil {
+				m.Metadata = make(map[string]string)
+			}
+			var mapkey string
+			var mapvalue string
+			for iNdEx < postIndex {
+				entryPreIndex := iNdEx
+				var wire uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowDriver
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					wire |= (uint64(b) & 0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				fieldNum := int32(wire >> 3)
+				if fieldNum == 1 {
+					var stringLenmapkey uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowDriver
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapkey |= (uint64(b) & 0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapkey := int(stringLenmapkey)
+					if intStringLenmapkey < 0 {
+						return ErrInvalidLengthDriver
+					}
+					postStringIndexmapkey := iNdEx + intStringLenmapkey
+					if postStringIndexmapkey > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapkey = string(dAtA[iNdEx:postStringIndexmapkey])
+					iNdEx = postStringIndexmapkey
+				} else if fieldNum == 2 {
+					var stringLenmapvalue uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowDriver
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapvalue |= (uint64(b) & 0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapvalue := int(stringLenmapvalue)
+					if intStringLenmapvalue < 0 {
+						return ErrInvalidLengthDriver
+					}
+					postStringIndexmapvalue := iNdEx + intStringLenmapvalue
+					if postStringIndexmapvalue > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapvalue = string(dAtA[iNdEx:postStringIndexmapvalue])
+					iNdEx = postStringIndexmapvalue
+				} else {
+					iNdEx = entryPreIndex
+					skippy, err := skipDriver(dAtA[iNdEx:])
+					if err != nil {
+						return err
+					}
+					if skippy < 0 {
+						return ErrInvalidLengthDriver
+					}
+					if (iNdEx + skippy) > postIndex {
+						return io.ErrUnexpectedEOF
+					}
+					iNdEx += skippy
+				}
+			}
+			m.Metadata[mapkey] = mapvalue
+			iNdEx = postIndex
+		case 8:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Charset", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthDriver
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Charset = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 9:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Fields", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthDriver
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Fields == nil {
+				m.Fields = &FieldMask{}
+			}
+			if err := m.Fields.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 10:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Query", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthDriver
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Query = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipDriver(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthDriver
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ParseResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowDriver
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ParseResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ParseResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Uast", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthDriver
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Uast = append(m.Uast[:0], dAtA[iNdEx:postIndex]...)
+			if m.Uast == nil {
+				m.Uast = []byte{}
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Language", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthDriver
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Language = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Errors", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthDriver
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Errors = append(m.Errors, &ParseError{})
+			if err := m.Errors[len(m.Errors)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Options", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthDriver
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Options == nil {
+				m.Options = &LanguageOptions{}
+			}
+			if err := m.Options.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Charset", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthDriver
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Charset = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipDriver(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthDriver
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ParseError) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowDriver
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ParseError: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ParseError: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Text", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthDriver
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Text = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Severity", wireType)
+			}
+			m.Severity = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Severity |= (Severity(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Code", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthDriver
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Code = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Start", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthDriver
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Start == nil {
+				m.Start = &Position{}
+			}
+			if err := m.Start.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field End", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthDriver
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.End == nil {
+				m.End = &Position{}
+			}
+			if err := m.End.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipDriver(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthDriver
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ParseFilesRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowDriver
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ParseFilesRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ParseFilesRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Files", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthDriver
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Files = append(m.Files, &ParseRequest{})
+			if err := m.Files[len(m.Files)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Mode", wireType)
+			}
+			m.Mode = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Mode |= (Mode(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipDriver(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthDriver
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ParseFilesResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowDriver
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ParseFilesResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ParseFilesResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Results", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthDriver
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Results = append(m.Results, &ParseFileResult{})
+			if err := m.Results[len(m.Results)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipDriver(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthDriver
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ParseFileResult) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowDriver
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ParseFileResult: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ParseFileResult: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Response", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthDriver
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Response == nil {
+				m.Response = &ParseResponse{}
+			}
+			if err := m.Response.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Code", wireType)
+			}
+			m.Code = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Code |= (uint32(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Message", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthDriver
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Message = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Failure", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthDriver
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Failure == nil {
+				m.Failure = &DriverFailure{}
+			}
+			if err := m.Failure.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Errors", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthDriver
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Errors = append(m.Errors, &ParseError{})
+			if err := m.Errors[len(m.Errors)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipDriver(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthDriver
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+func (m *LanguageOptions) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowDriver
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: LanguageOptions: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: LanguageOptions: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Version", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthDriver
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Version = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Dialects", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthDriver
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Dialects = append(m.Dialects, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Strict", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Strict = bool(v != 0)
+		default:
+			iNdEx = preIndex
+			skippy, err := skipDriver(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthDriver
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+func (m *Range) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowDriver
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Range: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Range: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Start", wireType)
+			}
+			m.Start = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Start |= (uint32(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field End", wireType)
+			}
+			m.End = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.End |= (uint32(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field StartLine", wireType)
+			}
+			m.StartLine = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.StartLine |= (uint32(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field EndLine", wireType)
+			}
+			m.EndLine = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.EndLine |= (uint32(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipDriver(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthDriver
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+func (m *CapabilitiesRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowDriver
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: CapabilitiesRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: CapabilitiesRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		default:
+			iNdEx = preIndex
+			skippy, err := skipDriver(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthDriver
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+func (m *CapabilitiesResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowDriver
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: CapabilitiesResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: CapabilitiesResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType == 0 {
+				var v Mode
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowDriver
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					v |= (Mode(b) & 0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				m.Modes = append(m.Modes, v)
+			} else if wireType == 2 {
+				var packedLen int
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowDriver
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					packedLen |= (int(b) & 0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				if packedLen < 0 {
+					return ErrInvalidLengthDriver
+				}
+				postIndex := iNdEx + packedLen
+				if postIndex > l {
+					return io.ErrUnexpectedEOF
+				}
+				for iNdEx < postIndex {
+					var v Mode
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowDriver
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						v |= (Mode(b) & 0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					m.Modes = append(m.Modes, v)
+				}
+			} else {
+				return fmt.Errorf("proto: wrong wireType = %d for field Modes", wireType)
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Encodings", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthDriver
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Encodings = append(m.Encodings, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Options", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Options = bool(v != 0)
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Incremental", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Incremental = bool(v != 0)
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxFileSize", wireType)
+			}
+			m.MaxFileSize = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MaxFileSize |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Versions", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthDriver
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Versions = append(m.Versions, &LanguageVersion{})
+			if err := m.Versions[len(m.Versions)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 7:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Compressors", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthDriver
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Compressors = append(m.Compressors, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		case 8:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field CompressionRequired", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.CompressionRequired = bool(v != 0)
+		default:
+			iNdEx = preIndex
+			skippy, err := skipDriver(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthDriver
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+func (m *LanguageVersion) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowDriver
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: LanguageVersion: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: LanguageVersion: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Version", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthDriver
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Version = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Features", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthDriver
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Features = append(m.Features, &SyntaxFeature{})
+			if err := m.Features[len(m.Features)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipDriver(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthDriver
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+func (m *SyntaxFeature) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowDriver
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: SyntaxFeature: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: SyntaxFeature: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Name", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthDriver
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Name = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Supported", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Supported = bool(v != 0)
+		default:
+			iNdEx = preIndex
+			skippy, err := skipDriver(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthDriver
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+func (m *Position) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowDriver
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Position: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Position: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Offset", wireType)
+			}
+			m.Offset = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Offset |= (uint32(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Line", wireType)
+			}
+			m.Line = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Line |= (uint32(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Col", wireType)
+			}
+			m.Col = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Col |= (uint32(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipDriver(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthDriver
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+func (m *ParseStreamRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowDriver
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ParseStreamRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ParseStreamRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ID", wireType)
+			}
+			m.ID = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ID |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Request", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthDriver
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Request == nil {
+				m.Request = &ParseRequest{}
+			}
+			if err := m.Request.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipDriver(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthDriver
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+func (m *ParseStreamResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowDriver
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ParseStreamResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ParseStreamResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ID", wireType)
+			}
+			m.ID = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ID |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Response", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthDriver
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Response == nil {
+				m.Response = &ParseResponse{}
+			}
+			if err := m.Response.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Code", wireType)
+			}
+			m.Code = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Code |= (uint32(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Message", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthDriver
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Message = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Failure", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthDriver
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Failure == nil {
+				m.Failure = &DriverFailure{}
+			}
+			if err := m.Failure.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Errors", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthDriver
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Errors = append(m.Errors, &ParseError{})
+			if err := m.Errors[len(m.Errors)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipDriver(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthDriver
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
 	}
-	return n
-}
-func sozDriver(x uint64) (n int) {
-	return sovDriver(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+	return nil
 }
-func (m *ParseRequest) Unmarshal(dAtA []byte) error {
+
+func (m *ParseChunkedRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -417,17 +4585,17 @@ func (m *ParseRequest) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: ParseRequest: wiretype end group for non-group")
+			return fmt.Errorf("proto: ParseChunkedRequest: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: ParseRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: ParseChunkedRequest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Content", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Request", wireType)
 			}
-			var stringLen uint64
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowDriver
@@ -437,26 +4605,30 @@ func (m *ParseRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= (uint64(b) & 0x7F) << shift
+				msglen |= (int(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
+			if msglen < 0 {
 				return ErrInvalidLengthDriver
 			}
-			postIndex := iNdEx + intStringLen
+			postIndex := iNdEx + msglen
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Content = string(dAtA[iNdEx:postIndex])
+			if m.Request == nil {
+				m.Request = &ParseRequest{}
+			}
+			if err := m.Request.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
 			iNdEx = postIndex
 		case 2:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Language", wireType)
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ChunkSize", wireType)
 			}
-			var stringLen uint64
+			m.ChunkSize = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowDriver
@@ -466,26 +4638,67 @@ func (m *ParseRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= (uint64(b) & 0x7F) << shift
+				m.ChunkSize |= (uint32(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
+		default:
+			iNdEx = preIndex
+			skippy, err := skipDriver(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
 				return ErrInvalidLengthDriver
 			}
-			postIndex := iNdEx + intStringLen
-			if postIndex > l {
+			if (iNdEx + skippy) > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Language = string(dAtA[iNdEx:postIndex])
-			iNdEx = postIndex
-		case 3:
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+func (m *ParseChunk) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowDriver
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ParseChunk: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ParseChunk: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Filename", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Response", wireType)
 			}
-			var stringLen uint64
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowDriver
@@ -495,26 +4708,30 @@ func (m *ParseRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= (uint64(b) & 0x7F) << shift
+				msglen |= (int(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
+			if msglen < 0 {
 				return ErrInvalidLengthDriver
 			}
-			postIndex := iNdEx + intStringLen
+			postIndex := iNdEx + msglen
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Filename = string(dAtA[iNdEx:postIndex])
+			if m.Response == nil {
+				m.Response = &ParseResponse{}
+			}
+			if err := m.Response.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
 			iNdEx = postIndex
-		case 4:
+		case 2:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Mode", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Size", wireType)
 			}
-			m.Mode = 0
+			m.Size = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowDriver
@@ -524,11 +4741,42 @@ func (m *ParseRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Mode |= (Mode(b) & 0x7F) << shift
+				m.Size |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Data", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= (int(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			if byteLen < 0 {
+				return ErrInvalidLengthDriver
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Data = append(m.Data[:0], dAtA[iNdEx:postIndex]...)
+			if m.Data == nil {
+				m.Data = []byte{}
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipDriver(dAtA[iNdEx:])
@@ -550,7 +4798,8 @@ func (m *ParseRequest) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *ParseResponse) Unmarshal(dAtA []byte) error {
+
+func (m *FieldMask) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -573,17 +4822,17 @@ func (m *ParseResponse) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: ParseResponse: wiretype end group for non-group")
+			return fmt.Errorf("proto: FieldMask: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: ParseResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: FieldMask: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Uast", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Include", wireType)
 			}
-			var byteLen int
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowDriver
@@ -593,26 +4842,24 @@ func (m *ParseResponse) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				byteLen |= (int(b) & 0x7F) << shift
+				stringLen |= (uint64(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if byteLen < 0 {
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
 				return ErrInvalidLengthDriver
 			}
-			postIndex := iNdEx + byteLen
+			postIndex := iNdEx + intStringLen
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Uast = append(m.Uast[:0], dAtA[iNdEx:postIndex]...)
-			if m.Uast == nil {
-				m.Uast = []byte{}
-			}
+			m.Include = append(m.Include, string(dAtA[iNdEx:postIndex]))
 			iNdEx = postIndex
 		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Language", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Exclude", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -637,9 +4884,60 @@ func (m *ParseResponse) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Language = string(dAtA[iNdEx:postIndex])
+			m.Exclude = append(m.Exclude, string(dAtA[iNdEx:postIndex]))
 			iNdEx = postIndex
-		case 3:
+		default:
+			iNdEx = preIndex
+			skippy, err := skipDriver(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthDriver
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+func (m *ParseErrors) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowDriver
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ParseErrors: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ParseErrors: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
 			if wireType != 2 {
 				return fmt.Errorf("proto: wrong wireType = %d for field Errors", wireType)
 			}
@@ -691,7 +4989,8 @@ func (m *ParseResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *ParseError) Unmarshal(dAtA []byte) error {
+
+func (m *DriverFailure) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -714,15 +5013,15 @@ func (m *ParseError) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: ParseError: wiretype end group for non-group")
+			return fmt.Errorf("proto: DriverFailure: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: ParseError: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: DriverFailure: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Text", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Kind", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -747,7 +5046,65 @@ func (m *ParseError) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Text = string(dAtA[iNdEx:postIndex])
+			m.Kind = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Stage", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthDriver
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Stage = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Stderr", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDriver
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthDriver
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Stderr = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
@@ -878,34 +5235,100 @@ var (
 func init() { proto.RegisterFile("driver.proto", fileDescriptorDriver) }
 
 var fileDescriptorDriver = []byte{
-	// 457 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x52, 0xcd, 0x8e, 0x93, 0x50,
-	0x14, 0xee, 0x9d, 0x56, 0x6c, 0xef, 0x30, 0x4a, 0xee, 0xc2, 0x10, 0x62, 0x10, 0x9b, 0x98, 0x34,
-	0x9a, 0x61, 0x92, 0xba, 0x72, 0x89, 0x05, 0x93, 0x49, 0x2c, 0x6d, 0x28, 0xba, 0x70, 0x63, 0xf8,
-	0x39, 0x30, 0x64, 0x28, 0x17, 0xb9, 0x97, 0xc6, 0x07, 0x70, 0xc5, 0x2b, 0x18, 0xa2, 0x8f, 0x33,
-	0x4b, 0x1f, 0x41, 0xeb, 0x8b, 0x18, 0x2e, 0xad, 0xee, 0x9c, 0xd9, 0x7d, 0xdf, 0xfd, 0xce, 0x97,
-	0xf3, 0x9d, 0x73, 0x0f, 0x96, 0xe3, 0x2a, 0xdb, 0x41, 0x65, 0x96, 0x15, 0xe5, 0x94, 0x3c, 0x49,
-	0x69, 0x79, 0x9d, 0x9a, 0x59, 0x61, 0x86, 0x61, 0x9e, 0xb0, 0x2b, 0x93, 0xc5, 0xd7, 0xe6, 0x6e,
-	0xde, 0xab, 0x11, 0xcd, 0xb5, 0xf3, 0x34, 0xe3, 0x57, 0x75, 0x68, 0x46, 0x74, 0x7b, 0x91, 0xd2,
-	0x94, 0x5e, 0x08, 0x25, 0xac, 0x13, 0xc1, 0x04, 0x11, 0xa8, 0x77, 0x4c, 0xbf, 0x22, 0x2c, 0xaf,
-	0x83, 0x8a, 0x81, 0x07, 0x9f, 0x6a, 0x60, 0x9c, 0xa8, 0xf8, 0x7e, 0x44, 0x0b, 0x0e, 0x05, 0x57,
-	0x91, 0x81, 0x66, 0x13, 0xef, 0x48, 0x89, 0x86, 0xc7, 0x79, 0x50, 0xa4, 0x75, 0x90, 0x82, 0x7a,
-	0x22, 0xa4, 0xbf, 0xbc, 0xd3, 0x92, 0x2c, 0x87, 0x22, 0xd8, 0x82, 0x3a, 0xec, 0xb5, 0x23, 0x27,
-	0xaf, 0xf0, 0x68, 0x4b, 0x63, 0x50, 0x47, 0x06, 0x9a, 0x3d, 0x98, 0x3f, 0x33, 0x6f, 0x99, 0xc0,
-	0x5c, 0xd2, 0x18, 0x3c, 0x61, 0x99, 0x7e, 0x41, 0xf8, 0xec, 0x90, 0x8e, 0x95, 0xb4, 0x60, 0x40,
-	0x08, 0x1e, 0xd5, 0x01, 0xeb, 0xb3, 0xc9, 0x9e, 0xc0, 0xff, 0x0d, 0xb6, 0xc0, 0x12, 0x54, 0x15,
-	0xad, 0x98, 0x3a, 0x34, 0x86, 0xb3, 0xd3, 0xf9, 0x8b, 0x5b, 0xdb, 0x8b, 0x7e, 0x4e, 0xe7, 0xf1,
-	0x0e, 0xd6, 0xa9, 0x81, 0xf1, 0xbf, 0xd7, 0x2e, 0x02, 0x87, 0xcf, 0xc7, 0xf5, 0x08, 0xfc, 0xfc,
-	0x1b, 0xc2, 0xa3, 0x2e, 0x37, 0x79, 0x8a, 0x65, 0xdb, 0x79, 0x63, 0xbd, 0x7b, 0xeb, 0x7f, 0x5c,
-	0xae, 0x6c, 0x47, 0x19, 0x68, 0x0f, 0x9b, 0xd6, 0x38, 0xb5, 0x21, 0x09, 0xea, 0x9c, 0x8b, 0x92,
-	0x47, 0x58, 0x72, 0x2d, 0xff, 0xf2, 0xbd, 0xa3, 0x20, 0x0d, 0x37, 0xad, 0x21, 0xb9, 0x01, 0xcf,
-	0x76, 0x40, 0xa6, 0x58, 0x5e, 0x7b, 0xce, 0xda, 0x5b, 0x2d, 0x9c, 0xcd, 0xc6, 0xb1, 0x95, 0x13,
-	0x4d, 0x69, 0x5a, 0x43, 0x5e, 0x57, 0x50, 0x56, 0x34, 0x02, 0xc6, 0x20, 0x26, 0x8f, 0xf1, 0xc4,
-	0x72, 0xdd, 0x95, 0x6f, 0xf9, 0x8e, 0xad, 0x8c, 0xb4, 0xb3, 0xa6, 0x35, 0x26, 0x56, 0x51, 0x50,
-	0x1e, 0x70, 0x88, 0xbb, 0x45, 0x6c, 0x9c, 0xa5, 0xe5, 0xfa, 0x97, 0x0b, 0x65, 0xac, 0xc9, 0x4d,
-	0x6b, 0x8c, 0x37, 0xb0, 0x0d, 0x0a, 0x9e, 0x45, 0xf3, 0x12, 0x4b, 0xb6, 0x38, 0x24, 0x92, 0xe0,
-	0x7b, 0x62, 0x1a, 0x72, 0x7e, 0xb7, 0x5d, 0x1c, 0x2e, 0x43, 0x33, 0xef, 0x5a, 0xde, 0x7f, 0xd5,
-	0x6b, 0xfd, 0xe6, 0x97, 0x3e, 0xb8, 0xd9, 0xeb, 0xe8, 0xc7, 0x5e, 0x47, 0x3f, 0xf7, 0xfa, 0xe0,
-	0xfb, 0x6f, 0x1d, 0x7d, 0x18, 0x1f, 0xab, 0x43, 0x49, 0xa0, 0x97, 0x7f, 0x02, 0x00, 0x00, 0xff,
-	0xff, 0x64, 0x59, 0x48, 0x92, 0xe1, 0x02, 0x00, 0x00,
+	// 1510 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x56, 0x4f, 0x6f, 0x1b, 0x45,
+	0x1b, 0xcf, 0xda, 0x6b, 0x7b, 0xfd, 0xd8, 0x6e, 0xfc, 0x4e, 0xfb, 0x56, 0xfb, 0xae, 0xde, 0x37,
+	0xf5, 0xbb, 0x12, 0x28, 0xb4, 0xaa, 0x1b, 0xdc, 0x22, 0x01, 0x41, 0xaa, 0xdc, 0xc4, 0x69, 0x13,
+	0x12, 0x27, 0x8c, 0x43, 0x2b, 0x71, 0x09, 0x1b, 0xef, 0xd8, 0x19, 0x65, 0x3d, 0xeb, 0xee, 0xec,
+	0x86, 0xa4, 0x80, 0xc4, 0xd9, 0x57, 0x6e, 0x48, 0x96, 0xf8, 0x06, 0xdc, 0xe0, 0x2b, 0xf4, 0xc8,
+	0x8d, 0x1b, 0x82, 0x70, 0xe3, 0xcc, 0x85, 0x1b, 0x9a, 0x99, 0x5d, 0xc7, 0xae, 0xda, 0xda, 0x6e,
+	0x7b, 0x9b, 0x67, 0x9e, 0xf9, 0x3d, 0xcf, 0x3c, 0xff, 0x7e, 0x33, 0x50, 0x74, 0x03, 0x7a, 0x42,
+	0x82, 0x6a, 0x3f, 0xf0, 0x43, 0x1f, 0x5d, 0xeb, 0xfa, 0xfd, 0xe3, 0x6e, 0x95, 0xb2, 0xea, 0xe1,
+	0xa1, 0xd7, 0xe1, 0x47, 0x55, 0xee, 0x1e, 0x57, 0x4f, 0x6a, 0x4a, 0xdb, 0xf6, 0x3d, 0xeb, 0x66,
+	0x97, 0x86, 0x47, 0xd1, 0x61, 0xb5, 0xed, 0xf7, 0x6e, 0x75, 0xfd, 0xae, 0x7f, 0x4b, 0x6a, 0x0e,
+	0xa3, 0x8e, 0x94, 0xa4, 0x20, 0x57, 0x0a, 0x61, 0xff, 0xa0, 0x43, 0x71, 0xcf, 0x09, 0x38, 0xc1,
+	0xe4, 0x71, 0x44, 0x78, 0x88, 0x4c, 0xc8, 0xb5, 0x7d, 0x16, 0x12, 0x16, 0x9a, 0x5a, 0x45, 0x5b,
+	0xce, 0xe3, 0x44, 0x44, 0x16, 0x18, 0x9e, 0xc3, 0xba, 0x91, 0xd3, 0x25, 0x66, 0x4a, 0xaa, 0x46,
+	0xb2, 0xd0, 0x75, 0xa8, 0x47, 0x98, 0xd3, 0x23, 0x66, 0x5a, 0xe9, 0x12, 0x19, 0x7d, 0x00, 0x7a,
+	0xcf, 0x77, 0x89, 0xa9, 0x57, 0xb4, 0xe5, 0x4b, 0xb5, 0xb7, 0xaa, 0x53, 0x22, 0xa8, 0xee, 0xf8,
+	0x2e, 0xc1, 0x12, 0x82, 0xb6, 0x20, 0xe7, 0xf7, 0x43, 0xea, 0x33, 0x6e, 0x66, 0x2a, 0xda, 0x72,
+	0xa1, 0xb6, 0x32, 0x15, 0xbd, 0x1d, 0x5f, 0x69, 0x57, 0xe1, 0x70, 0x62, 0x00, 0x7d, 0x04, 0x99,
+	0xc0, 0x61, 0x5d, 0x62, 0x66, 0xa5, 0xa5, 0xb7, 0xa7, 0x5a, 0xc2, 0xe2, 0x34, 0x56, 0x20, 0xf4,
+	0x08, 0x8c, 0x1e, 0x09, 0x1d, 0xd7, 0x09, 0x1d, 0x33, 0x57, 0x49, 0x2f, 0x17, 0x6a, 0xab, 0x53,
+	0x0d, 0x8c, 0xe7, 0xb5, 0xba, 0x13, 0xa3, 0x1b, 0x2c, 0x0c, 0xce, 0xf0, 0xc8, 0x98, 0xcc, 0xf7,
+	0x91, 0x38, 0x18, 0x9a, 0x46, 0x9c, 0x6f, 0x25, 0xa2, 0x7b, 0x90, 0xed, 0x50, 0xe2, 0xb9, 0xdc,
+	0xcc, 0xcb, 0x1b, 0x5f, 0x9f, 0xea, 0x70, 0x43, 0x1c, 0xdf, 0x71, 0xf8, 0x31, 0x8e, 0x91, 0xe8,
+	0x0a, 0x64, 0x1e, 0x47, 0x24, 0x38, 0x33, 0x41, 0xda, 0x56, 0x82, 0xb5, 0x0a, 0xa5, 0x89, 0xeb,
+	0xa0, 0x32, 0xa4, 0x8f, 0xc9, 0x59, 0x5c, 0x70, 0xb1, 0x14, 0xc0, 0x13, 0xc7, 0x8b, 0x92, 0x4a,
+	0x2b, 0xe1, 0xc3, 0xd4, 0xfb, 0x9a, 0xfd, 0xa7, 0x06, 0xa5, 0x38, 0x32, 0xde, 0xf7, 0x19, 0x27,
+	0x08, 0x81, 0x1e, 0x39, 0x5c, 0xf5, 0x4b, 0x11, 0xcb, 0xf5, 0x4b, 0x9b, 0x65, 0x0d, 0xb2, 0x24,
+	0x08, 0xfc, 0x80, 0x9b, 0x69, 0x99, 0xc9, 0x1b, 0xb3, 0x65, 0xb2, 0x21, 0x30, 0x38, 0x86, 0x8e,
+	0xb7, 0x86, 0xfe, 0xba, 0xad, 0x31, 0x56, 0x83, 0xcc, 0x44, 0x0d, 0xec, 0xbf, 0x35, 0x80, 0x0b,
+	0xe7, 0x22, 0xd2, 0x90, 0x9c, 0x26, 0x93, 0x21, 0xd7, 0xa8, 0x01, 0x06, 0x27, 0x27, 0x24, 0xa0,
+	0xe1, 0x99, 0x8c, 0xf4, 0x52, 0xed, 0x9d, 0xa9, 0x37, 0x69, 0xc5, 0x00, 0x3c, 0x82, 0x0a, 0xd3,
+	0x6d, 0xdf, 0x4d, 0xa6, 0x47, 0xae, 0xd1, 0x5d, 0xc8, 0xf0, 0xd0, 0x09, 0xc2, 0x38, 0xc2, 0xe9,
+	0x76, 0xf7, 0x7c, 0x4e, 0x45, 0x48, 0x58, 0xe1, 0xd0, 0x2a, 0xa4, 0x09, 0x73, 0xcd, 0xcc, 0xbc,
+	0x70, 0x81, 0xb2, 0xbf, 0xd5, 0xe0, 0x5f, 0x32, 0xf6, 0x0d, 0xea, 0x11, 0x9e, 0xf0, 0xc3, 0x1a,
+	0x64, 0xc4, 0x64, 0x73, 0x53, 0x93, 0xb5, 0xbb, 0x39, 0xd7, 0x14, 0x60, 0x85, 0x1d, 0x51, 0x42,
+	0x6a, 0x6e, 0x4a, 0xb0, 0x3f, 0x07, 0x34, 0x7e, 0xa9, 0xb8, 0x05, 0xb7, 0x20, 0x17, 0x10, 0x1e,
+	0x79, 0x61, 0x72, 0xaf, 0x95, 0xd9, 0xee, 0x25, 0xac, 0x60, 0x09, 0xc4, 0x89, 0x01, 0xfb, 0xbb,
+	0x14, 0x2c, 0x3e, 0xa3, 0x44, 0x5b, 0x60, 0x04, 0xb1, 0x2f, 0x59, 0xfc, 0x42, 0xad, 0x3a, 0x6b,
+	0xe0, 0x0a, 0x85, 0x47, 0xf8, 0x51, 0xa5, 0x45, 0xf0, 0xa5, 0xb8, 0xd2, 0x26, 0xe4, 0x7a, 0x84,
+	0x73, 0xa7, 0x9b, 0x34, 0x40, 0x22, 0xa2, 0x07, 0x90, 0xeb, 0x38, 0xd4, 0x8b, 0x02, 0x62, 0xea,
+	0x33, 0x3a, 0x5e, 0x97, 0x0f, 0xc6, 0x86, 0x42, 0xe1, 0x04, 0x3e, 0x36, 0x76, 0x99, 0x57, 0x1e,
+	0x3b, 0xfb, 0x00, 0x16, 0x9f, 0x19, 0x23, 0x71, 0xf7, 0x13, 0x12, 0x70, 0xea, 0xb3, 0xe4, 0xc5,
+	0x88, 0x45, 0x41, 0x02, 0x2e, 0x75, 0x3c, 0xd2, 0x0e, 0xb9, 0x99, 0xaa, 0xa4, 0x05, 0x09, 0x24,
+	0x32, 0xba, 0x0a, 0x59, 0x1e, 0x06, 0xb4, 0x1d, 0xca, 0x80, 0x0d, 0x1c, 0x4b, 0x36, 0x85, 0x8c,
+	0x24, 0x5e, 0xc1, 0x40, 0xaa, 0xf9, 0x35, 0x99, 0x27, 0x25, 0xa0, 0xb2, 0xea, 0x68, 0x95, 0x3b,
+	0xb1, 0x44, 0xff, 0x03, 0x90, 0xaa, 0x03, 0x8f, 0x32, 0x95, 0xbd, 0x12, 0xce, 0xcb, 0x9d, 0x6d,
+	0xca, 0x08, 0xfa, 0x0f, 0x18, 0x84, 0xb9, 0x4a, 0xa9, 0x4b, 0x65, 0x8e, 0x30, 0x57, 0xa8, 0xec,
+	0x7f, 0xc3, 0xe5, 0x35, 0xa7, 0xef, 0x1c, 0x52, 0x8f, 0x86, 0x74, 0xd4, 0xe1, 0xf6, 0x5f, 0x29,
+	0xb8, 0x32, 0xb9, 0x1f, 0x17, 0x6e, 0x15, 0x32, 0xa2, 0x05, 0x55, 0x8b, 0xcd, 0xdc, 0xb6, 0x0a,
+	0x83, 0xfe, 0x0b, 0x79, 0xc2, 0xda, 0xbe, 0x4b, 0x59, 0x37, 0x49, 0xc6, 0xc5, 0x86, 0xc8, 0x61,
+	0xc2, 0x66, 0x2a, 0x1d, 0x89, 0x88, 0x2a, 0x50, 0xa0, 0xac, 0x1d, 0x90, 0x1e, 0x61, 0xa1, 0xe3,
+	0xc9, 0x10, 0x0c, 0x3c, 0xbe, 0x85, 0x6c, 0x28, 0xf5, 0x9c, 0xd3, 0x03, 0x31, 0x59, 0x07, 0x9c,
+	0x3e, 0x21, 0x72, 0xdc, 0x75, 0x5c, 0xe8, 0x39, 0xa7, 0xa2, 0x83, 0x5b, 0xf4, 0x09, 0x41, 0xdb,
+	0x60, 0xc4, 0x45, 0xe1, 0x66, 0x76, 0xc6, 0x01, 0x49, 0xea, 0xfc, 0x50, 0x01, 0xf1, 0xc8, 0x82,
+	0xb8, 0x53, 0xdb, 0xef, 0xf5, 0x03, 0xc2, 0xb9, 0x68, 0xa7, 0x9c, 0x8c, 0x66, 0x7c, 0x0b, 0xbd,
+	0x0b, 0x57, 0x12, 0x91, 0xfa, 0xec, 0x20, 0x20, 0x8f, 0x23, 0x1a, 0x10, 0x57, 0x3e, 0x71, 0x06,
+	0xbe, 0x3c, 0xa6, 0xc3, 0xb1, 0xca, 0xfe, 0x02, 0x16, 0x9f, 0xf1, 0xf8, 0x92, 0xce, 0xda, 0x02,
+	0xa3, 0x43, 0x9c, 0x30, 0x0a, 0x88, 0x4a, 0xe6, 0x2c, 0x63, 0xd1, 0x3a, 0x63, 0xa1, 0x73, 0xba,
+	0xa1, 0x60, 0x78, 0x84, 0xb7, 0xeb, 0x50, 0x9a, 0x50, 0x89, 0x01, 0x95, 0x1f, 0x99, 0x98, 0xe5,
+	0xc5, 0x5a, 0x94, 0x8f, 0x47, 0xfd, 0xbe, 0x1f, 0x84, 0x44, 0x75, 0x9f, 0x81, 0x2f, 0x36, 0xec,
+	0x07, 0x60, 0x24, 0xdc, 0x29, 0x1a, 0xdb, 0xef, 0x74, 0xc4, 0x5b, 0xa2, 0x1a, 0x37, 0x96, 0x84,
+	0x55, 0xd9, 0x84, 0xf1, 0xd8, 0x8b, 0xb5, 0xe8, 0xe6, 0xb6, 0xef, 0xc5, 0x4d, 0x2b, 0x96, 0x76,
+	0x14, 0xd3, 0x5b, 0x2b, 0x0c, 0x88, 0xd3, 0x4b, 0x48, 0xf7, 0x2a, 0xa4, 0xa8, 0x2b, 0xed, 0xe9,
+	0xf7, 0xb2, 0xe7, 0xbf, 0x5e, 0x4b, 0x6d, 0xae, 0xe3, 0x14, 0x75, 0xd1, 0x7d, 0x41, 0x7b, 0xf2,
+	0x88, 0x34, 0x3b, 0x37, 0x1d, 0x27, 0x68, 0xfb, 0xc7, 0x14, 0x5c, 0x9e, 0xf0, 0x1b, 0xb7, 0xfc,
+	0x8b, 0x1c, 0x8f, 0xf3, 0x61, 0xea, 0x0d, 0xf1, 0x61, 0xfa, 0xf9, 0x7c, 0xa8, 0xbf, 0x90, 0x0f,
+	0x33, 0x6f, 0x8a, 0x0f, 0xb3, 0xaf, 0xce, 0x87, 0x5f, 0xc7, 0x79, 0x5b, 0x3b, 0x8a, 0xd8, 0x31,
+	0x71, 0x93, 0x82, 0x8d, 0x15, 0x46, 0x7b, 0x9d, 0xc2, 0x08, 0x76, 0x6b, 0x0b, 0xd3, 0x6a, 0xb2,
+	0x55, 0xef, 0xe4, 0xe5, 0x8e, 0x98, 0x6b, 0xfb, 0x9b, 0xe4, 0x7f, 0x22, 0xfd, 0xbf, 0xe9, 0x67,
+	0x6a, 0xe4, 0x53, 0xc7, 0x72, 0x2d, 0xf6, 0xe4, 0x0f, 0x38, 0xad, 0x7e, 0x7a, 0x62, 0x6d, 0xdf,
+	0x85, 0xfc, 0xe8, 0xdf, 0x29, 0xea, 0x46, 0x59, 0xdb, 0x8b, 0x5c, 0x22, 0x49, 0x32, 0x8f, 0x13,
+	0x51, 0x68, 0xc8, 0xa9, 0xd2, 0x28, 0xf6, 0x4b, 0x44, 0x1b, 0x43, 0xe1, 0x22, 0xb1, 0x7c, 0xac,
+	0x2c, 0xda, 0xab, 0x97, 0xe5, 0x13, 0x28, 0x4d, 0x54, 0x5d, 0xdc, 0xfc, 0x98, 0x32, 0x37, 0x99,
+	0x69, 0xb1, 0x8e, 0x5f, 0x98, 0xd1, 0x07, 0x55, 0x09, 0xea, 0x61, 0x72, 0x49, 0x10, 0xc4, 0x2f,
+	0x71, 0x2c, 0x5d, 0xff, 0x49, 0x03, 0x5d, 0x10, 0x3a, 0xfa, 0x3f, 0x14, 0xd7, 0x1b, 0x1b, 0xf5,
+	0x4f, 0xb7, 0xf7, 0x0f, 0x76, 0x76, 0xd7, 0x1b, 0xe5, 0x05, 0x6b, 0x71, 0x30, 0xac, 0x14, 0xd6,
+	0x49, 0xc7, 0x89, 0xbc, 0x50, 0x1e, 0xb9, 0x0a, 0xd9, 0x66, 0x7d, 0x7f, 0xf3, 0x61, 0xa3, 0xac,
+	0x59, 0x30, 0x18, 0x56, 0xb2, 0x4d, 0x27, 0xa4, 0x27, 0x04, 0xd9, 0x50, 0xdc, 0xc3, 0x8d, 0x3d,
+	0xbc, 0xbb, 0xd6, 0x68, 0xb5, 0x1a, 0xeb, 0xe5, 0x94, 0x55, 0x1e, 0x0c, 0x2b, 0xc5, 0xbd, 0x80,
+	0xf4, 0x03, 0xbf, 0x4d, 0x38, 0x27, 0xae, 0x60, 0x9a, 0x7a, 0xb3, 0xb9, 0xbb, 0x5f, 0xdf, 0x6f,
+	0xac, 0x97, 0x75, 0xab, 0x34, 0x18, 0x56, 0xf2, 0x75, 0xc6, 0xfc, 0xd0, 0x09, 0x89, 0x2b, 0x9e,
+	0xd4, 0x56, 0x63, 0xa7, 0xde, 0xdc, 0xdf, 0x5c, 0x2b, 0x1b, 0x56, 0x71, 0x30, 0xac, 0x18, 0x2d,
+	0xd2, 0x73, 0x58, 0x48, 0xdb, 0xc2, 0xeb, 0xfe, 0xee, 0xc7, 0x8d, 0x66, 0xab, 0x5c, 0x56, 0x5e,
+	0xf7, 0xfd, 0x63, 0xc2, 0xf8, 0xf5, 0x26, 0x18, 0xc9, 0x87, 0x53, 0xc4, 0xdc, 0xc0, 0x78, 0x17,
+	0x97, 0x17, 0xac, 0xfc, 0x60, 0x58, 0xc9, 0xa8, 0x7f, 0xad, 0x09, 0xb9, 0x47, 0x75, 0xdc, 0xdc,
+	0x6c, 0xde, 0x2f, 0x6b, 0x56, 0x61, 0x30, 0xac, 0xe4, 0x1e, 0x39, 0x01, 0xa3, 0xac, 0x2b, 0xf2,
+	0xb6, 0xd9, 0xdc, 0xd8, 0x2d, 0xa7, 0x2c, 0x63, 0x30, 0xac, 0xe8, 0x9b, 0xac, 0xe3, 0xd7, 0x7e,
+	0xd1, 0x21, 0xab, 0xb2, 0x8b, 0x3a, 0x90, 0x91, 0xd9, 0x47, 0xf3, 0xf5, 0xb7, 0x35, 0x67, 0x5b,
+	0xa2, 0x28, 0x6e, 0x73, 0xf9, 0xeb, 0x43, 0xb5, 0xd9, 0x3f, 0x77, 0xc9, 0xab, 0x6e, 0xdd, 0x9e,
+	0x0b, 0x13, 0xbb, 0xfd, 0x12, 0x8a, 0xe3, 0x3f, 0x01, 0x74, 0x67, 0xaa, 0x91, 0xe7, 0x7c, 0x28,
+	0xac, 0xf7, 0xe6, 0x44, 0xc5, 0xce, 0xbf, 0x82, 0xc2, 0x18, 0x25, 0xa3, 0x19, 0x03, 0x98, 0x78,
+	0x38, 0xac, 0x3b, 0xf3, 0x81, 0x94, 0xe7, 0x65, 0x6d, 0x45, 0x43, 0x1c, 0x8a, 0x17, 0xc4, 0x42,
+	0x5c, 0x34, 0xa3, 0xa5, 0x49, 0x1e, 0xb4, 0x6e, 0xcc, 0x81, 0x5a, 0xd1, 0xee, 0x2d, 0x3d, 0xfd,
+	0x7d, 0x69, 0xe1, 0xe9, 0xf9, 0x92, 0xf6, 0xf3, 0xf9, 0x92, 0xf6, 0xdb, 0xf9, 0xd2, 0xc2, 0xf7,
+	0x7f, 0x2c, 0x69, 0x9f, 0x19, 0xc9, 0xe1, 0xc3, 0xac, 0x5c, 0xdd, 0xfe, 0x67, 0x00, 0xe3, 0x36,
+	0xc4, 0x0a, 0x14, 0x11, 0x00, 0x00,
 }
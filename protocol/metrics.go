@@ -0,0 +1,29 @@
+package protocol
+
+import (
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"gopkg.in/bblfsh/sdk.v2/driver"
+)
+
+// ServiceMetrics is an instrumentation interface for the driver service. It allows to export request counts,
+// latencies and response sizes to a monitoring system. See ServiceOptions.
+//
+// Methods are called synchronously and must be safe for concurrent use.
+type ServiceMetrics interface {
+	// RequestDone is called after each Parse and ParseFiles request, including the requests of streams, with
+	// the transformation mode, the status code of the reply and the duration of the request.
+	RequestDone(mode driver.Mode, code codes.Code, d time.Duration)
+	// Encoded is called after each tree is encoded, with the duration of encoding and the size of the result.
+	Encoded(d time.Duration, size int)
+}
+
+// requestDone reports the request to the metrics, if they are set.
+func (s *driverServer) requestDone(mode Mode, start time.Time, err error) {
+	if s.opts.Metrics != nil {
+		s.opts.Metrics.RequestDone(driver.Mode(mode), status.Code(err), time.Since(start))
+	}
+}
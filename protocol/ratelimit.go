@@ -0,0 +1,296 @@
+package protocol
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	serrors "gopkg.in/src-d/go-errors.v1"
+)
+
+// ErrRateLimited is returned by the server if the request exceeds the rate limit. See RateLimiter and RetryAfter.
+var ErrRateLimited = serrors.NewKind("rate limit exceeded; retry after %v")
+
+// RetryAfter returns the delay suggested by the server for a request rejected with ErrRateLimited.
+func RetryAfter(err error) (time.Duration, bool) {
+	for err != nil {
+		e, ok := err.(*serrors.Error)
+		if !ok {
+			return 0, false
+		}
+		var s string
+		if _, serr := fmt.Sscanf(e.Error(), "rate limit exceeded; retry after %s", &s); serr == nil {
+			d, perr := time.ParseDuration(s)
+			return d, perr == nil
+		}
+		err = e.Cause()
+	}
+	return 0, false
+}
+
+// RateLimit is a token bucket that allows Rate requests per second on average, with bursts of up to Burst requests.
+// Zero rate disables the limit. If Burst is not set, it allows requests for one second.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// RateLimits configures the rate limiter of the server. See RateLimiter.
+type RateLimits struct {
+	// Global limits the requests of all clients together.
+	Global RateLimit
+	// PerClient limits the requests of each client, as identified by ClientKey.
+	PerClient RateLimit
+	// ClientKey identifies the client that sent the request. By default, clients are identified by the bearer token
+	// if it was validated by TokenAuth, or by the IP address otherwise.
+	ClientKey func(ctx context.Context) string
+}
+
+// RateLimiter returns server interceptors that limit the rate of requests. Calls above the limit fail with
+// the codes.ResourceExhausted status and a RetryInfo detail with the delay after which the call may succeed,
+// which is returned by the client as ErrRateLimited. Messages of streams count as separate requests, but they are
+// delayed until the limit allows them, instead of failing. ParseFiles counts as a request per file.
+//
+// Health checks are not limited. See ServerOptionsWith and NewLimiter.
+func RateLimiter(l RateLimits) (grpc.UnaryServerInterceptor, grpc.StreamServerInterceptor) {
+	return NewLimiter(l).Interceptors()
+}
+
+// Limiter limits the rate of requests. It can be shared by the gRPC server and other endpoints of the driver,
+// thus the limits apply to all of them together. See RateLimiter.
+type Limiter struct {
+	global *bucket // nil if there is no global limit
+	limit  RateLimit
+	key    func(ctx context.Context) string
+
+	mu      sync.Mutex
+	clients map[string]*bucket // nil if there is no per-client limit
+	swept   time.Time
+}
+
+// NewLimiter creates a rate limiter with given limits.
+func NewLimiter(l RateLimits) *Limiter {
+	r := &Limiter{global: newBucket(l.Global), limit: l.PerClient, key: l.ClientKey}
+	if r.key == nil {
+		r.key = clientKey
+	}
+	if l.PerClient.Rate > 0 {
+		r.clients = make(map[string]*bucket)
+	}
+	return r
+}
+
+// Interceptors returns gRPC server interceptors for the limiter. See RateLimiter.
+func (r *Limiter) Interceptors() (grpc.UnaryServerInterceptor, grpc.StreamServerInterceptor) {
+	unary := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if strings.HasPrefix(info.FullMethod, healthPrefix) {
+			return handler(ctx, req)
+		}
+		n := 1
+		if fr, ok := req.(*ParseFilesRequest); ok && len(fr.Files) > 1 {
+			n = len(fr.Files)
+		}
+		if d := r.Take(ctx, n); d > 0 {
+			return nil, rateLimited(d)
+		}
+		return handler(ctx, req)
+	}
+	stream := func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if strings.HasPrefix(info.FullMethod, healthPrefix) {
+			return handler(srv, ss)
+		}
+		return handler(srv, &limitedStream{ServerStream: ss, r: r})
+	}
+	return unary, stream
+}
+
+// Take takes n requests of the client from the limits. It returns zero if the requests are allowed, or the delay
+// after which they may succeed. The client is identified by the context, as for gRPC calls; see ClientKey and
+// peer.NewContext.
+func (r *Limiter) Take(ctx context.Context, n int) time.Duration {
+	return r.take(ctx, time.Now(), n)
+}
+
+// rateLimited returns ErrRateLimited as a gRPC status error with a retry delay.
+func rateLimited(d time.Duration) error {
+	st := status.New(codes.ResourceExhausted, ErrRateLimited.New(d).Error())
+	if sd, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: ptypes.DurationProto(d)}); err == nil {
+		st = sd
+	}
+	return st.Err()
+}
+
+// retryDelay returns the delay from the RetryInfo detail of the status.
+func retryDelay(s *status.Status) (time.Duration, bool) {
+	for _, d := range s.Details() {
+		if ri, ok := d.(*errdetails.RetryInfo); ok && ri.RetryDelay != nil {
+			d, err := ptypes.Duration(ri.RetryDelay)
+			return d, err == nil
+		}
+	}
+	return 0, false
+}
+
+// clientKey identifies the client by the bearer token validated by TokenAuth, or by the IP address. Tokens that
+// were not validated are ignored, since clients could avoid the limit by sending a new token with each request.
+func clientKey(ctx context.Context) string {
+	if tok, ok := authToken(ctx); ok {
+		return bearerPrefix + tok
+	}
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	addr := p.Addr.String()
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// limitedStream delays received messages until the rate limit allows them. This stops reading the stream, thus
+// the client is slowed down by the flow control of the transport.
+type limitedStream struct {
+	grpc.ServerStream
+	r *Limiter
+}
+
+// RecvMsg implements grpc.ServerStream.
+func (s *limitedStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	ctx := s.Context()
+	for {
+		d := s.r.take(ctx, time.Now(), 1)
+		if d <= 0 {
+			return nil
+		}
+		t := time.NewTimer(d)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return status.Error(codes.Canceled, ctx.Err().Error())
+		}
+	}
+}
+
+// idleClients is the interval after which buckets of clients that are no longer limited are removed.
+const idleClients = time.Minute
+
+// take takes n tokens for the request. If the request is above the limit, no tokens are taken and the function
+// returns the time after which the request may succeed.
+func (r *Limiter) take(ctx context.Context, now time.Time, n int) time.Duration {
+	var b *bucket
+	if r.clients != nil {
+		key := r.key(ctx)
+		r.mu.Lock()
+		if now.Sub(r.swept) > idleClients {
+			r.sweep(now)
+		}
+		b = r.clients[key]
+		if b == nil {
+			b = newBucket(r.limit)
+			r.clients[key] = b
+		}
+		r.mu.Unlock()
+		if d := b.take(now, n); d > 0 {
+			return d
+		}
+	}
+	if r.global != nil {
+		if d := r.global.take(now, n); d > 0 {
+			if b != nil {
+				b.give(n)
+			}
+			return d
+		}
+	}
+	return 0
+}
+
+// sweep removes buckets that are full, since they do not limit their clients. It must be called with the lock held.
+func (r *Limiter) sweep(now time.Time) {
+	r.swept = now
+	for k, b := range r.clients {
+		if b.full(now) {
+			delete(r.clients, k)
+		}
+	}
+}
+
+// bucket is a token bucket. See RateLimit.
+type bucket struct {
+	rate  float64
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// newBucket creates a full bucket for the limit. It returns nil if the limit is disabled.
+func newBucket(l RateLimit) *bucket {
+	if l.Rate <= 0 {
+		return nil
+	}
+	burst := float64(l.Burst)
+	if burst <= 0 {
+		burst = math.Ceil(l.Rate)
+	}
+	return &bucket{rate: l.Rate, burst: burst, tokens: burst}
+}
+
+// refill adds tokens for the time since the last call. It must be called with the lock held.
+func (b *bucket) refill(now time.Time) {
+	if b.last.IsZero() {
+		b.last = now
+	} else if now.After(b.last) {
+		b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+		b.last = now
+	}
+}
+
+// take takes n tokens from the bucket, or returns the time after which they will be available. Requests larger
+// than the bucket wait until it is full.
+func (b *bucket) take(now time.Time, n int) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(now)
+	need := math.Min(float64(n), b.burst)
+	if b.tokens >= need {
+		b.tokens -= need
+		return 0
+	}
+	d := time.Duration((need - b.tokens) / b.rate * float64(time.Second))
+	if d <= 0 {
+		d = time.Nanosecond
+	}
+	return d
+}
+
+// give returns n tokens taken by the request.
+func (b *bucket) give(n int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = math.Min(b.burst, b.tokens+math.Min(float64(n), b.burst))
+}
+
+// full checks if the bucket has all the tokens.
+func (b *bucket) full(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(now)
+	return b.tokens >= b.burst
+}
@@ -0,0 +1,199 @@
+package protocol
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/opentracing/opentracing-go"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	serrors "gopkg.in/src-d/go-errors.v1"
+
+	"gopkg.in/bblfsh/sdk.v2/driver"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+// ErrStreamClosed is returned by Stream after the stream was closed.
+var ErrStreamClosed = serrors.NewKind("parse stream is closed")
+
+// maxStreamParses is the number of requests of a single stream that are parsed concurrently. The server stops reading
+// the stream until one of them completes.
+const maxStreamParses = 64
+
+// ParseStream implements DriverServer. Requests are parsed concurrently and each response is sent as soon as it is
+// ready, thus responses may be sent in a different order than requests.
+func (s *driverServer) ParseStream(srv Driver_ParseStreamServer) error {
+	ctx := srv.Context()
+	if err := s.checkCompression(ctx); err != nil {
+		return err
+	}
+	var (
+		mu  sync.Mutex // protects Send
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, maxStreamParses)
+	)
+	// all responses must be sent before the stream is closed
+	defer wg.Wait()
+	for {
+		req, err := srv.Recv()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if req.Request == nil {
+			req.Request = &ParseRequest{}
+		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			resp := &ParseStreamResponse{ID: req.ID}
+			if r, err := s.Parse(ctx, req.Request); err != nil {
+				st := status.Convert(err)
+				resp.Code, resp.Message = uint32(st.Code()), st.Message()
+				resp.Failure, resp.Errors = statusDetails(st)
+			} else {
+				resp.Response = r
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			// if the stream is broken, Recv fails as well
+			_ = srv.Send(resp)
+		}()
+	}
+}
+
+// Stream sends parse requests to the server over a single ParseStream stream, avoiding the overhead of a separate
+// call for each file. It is safe for concurrent use: each request is sent as soon as Parse is called, and requests
+// may complete in any order.
+//
+// If the server does not implement ParseStream, all requests fail with the codes.Unimplemented status.
+type Stream struct {
+	s      Driver_ParseStreamClient
+	cancel func()
+
+	sendMu sync.Mutex
+
+	mu      sync.Mutex
+	last    uint64
+	pending map[uint64]chan *ParseStreamResponse
+	err     error // set when the stream is closed
+}
+
+// NewStream opens a parse stream. The stream is closed when the context is cancelled or Close is called.
+func NewStream(ctx context.Context, cc *grpc.ClientConn) (*Stream, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	s, err := NewDriverClient(cc).ParseStream(ctx)
+	if err != nil {
+		cancel()
+		return nil, fromStatus(err)
+	}
+	st := &Stream{s: s, cancel: cancel, pending: make(map[uint64]chan *ParseStreamResponse)}
+	go st.recv()
+	return st, nil
+}
+
+// recv dispatches responses to pending requests until the stream fails.
+func (s *Stream) recv() {
+	for {
+		resp, err := s.s.Recv()
+		if err == io.EOF {
+			s.close(ErrStreamClosed.New())
+			return
+		} else if err != nil {
+			s.close(fromStatus(err))
+			return
+		}
+		s.mu.Lock()
+		ch, ok := s.pending[resp.ID]
+		delete(s.pending, resp.ID)
+		s.mu.Unlock()
+		if ok {
+			ch <- resp
+		}
+	}
+}
+
+// close fails all pending requests with the error. Only the first error is kept.
+func (s *Stream) close(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err == nil {
+		s.err = err
+	}
+	for id, ch := range s.pending {
+		delete(s.pending, id)
+		close(ch)
+	}
+}
+
+// closed returns the error the stream was closed with.
+func (s *Stream) closed() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Parse sends a parse request on the stream and waits for the response. It accepts the same options as
+// driver.Driver.Parse.
+func (s *Stream) Parse(rctx context.Context, src string, opts *driver.ParseOptions) (nodes.Node, error) {
+	sp, ctx := opentracing.StartSpanFromContext(rctx, "bblfsh.client.ParseStream")
+	defer sp.Finish()
+
+	ch := make(chan *ParseStreamResponse, 1)
+	s.mu.Lock()
+	if err := s.err; err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+	s.last++
+	id := s.last
+	s.pending[id] = ch
+	s.mu.Unlock()
+
+	s.sendMu.Lock()
+	err := s.s.Send(&ParseStreamRequest{ID: id, Request: newParseRequest(src, opts)})
+	s.sendMu.Unlock()
+	if err != nil && err != io.EOF {
+		s.forget(id)
+		return nil, fromStatus(err)
+	}
+	// on io.EOF the stream failed and the request is cancelled by recv
+	select {
+	case resp, ok := <-ch:
+		if !ok {
+			return nil, s.closed()
+		} else if resp.Code != 0 {
+			return nil, fromStatus(resultStatus(resp.Code, resp.Message, resp.Failure, resp.Errors))
+		} else if resp.Response == nil {
+			return nil, fmt.Errorf("no response for request %d", id)
+		}
+		return parseResult(ctx, resp.Response, opts)
+	case <-ctx.Done():
+		s.forget(id)
+		return nil, ctx.Err()
+	}
+}
+
+// forget removes the request from the list of pending requests. The response is ignored if it arrives later.
+func (s *Stream) forget(id uint64) {
+	s.mu.Lock()
+	delete(s.pending, id)
+	s.mu.Unlock()
+}
+
+// Close closes the stream. Requests in progress fail with ErrStreamClosed.
+func (s *Stream) Close() error {
+	s.close(ErrStreamClosed.New())
+	s.sendMu.Lock()
+	err := s.s.CloseSend()
+	s.sendMu.Unlock()
+	s.cancel()
+	return err
+}
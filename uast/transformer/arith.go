@@ -0,0 +1,263 @@
+package transformer
+
+import (
+	"math"
+
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+// numAdd adds two numeric values. The kind of the result is the same as the kind of the first argument, unless one
+// of the arguments is a float; mixed integer and float values are added as floats. ErrUnexpectedValue is returned if
+// the result does not fit into the kind.
+func numAdd(a, b nodes.Value) (nodes.Value, error) {
+	switch a := a.(type) {
+	case nodes.Int:
+		switch b := b.(type) {
+		case nodes.Int:
+			return intAdd(a, b)
+		case nodes.Uint:
+			if b > math.MaxInt64 {
+				return nil, ErrUnexpectedValue.New(b)
+			}
+			return intAdd(a, nodes.Int(b))
+		case nodes.Float:
+			return nodes.Float(a) + b, nil
+		}
+	case nodes.Uint:
+		switch b := b.(type) {
+		case nodes.Int:
+			if b >= 0 {
+				return uintAdd(a, nodes.Uint(b))
+			}
+			// -(b+1) never overflows, even for MinInt64
+			return uintSub(a, nodes.Uint(-(b+1))+1)
+		case nodes.Uint:
+			return uintAdd(a, b)
+		case nodes.Float:
+			return nodes.Float(a) + b, nil
+		}
+	case nodes.Float:
+		switch b := b.(type) {
+		case nodes.Int:
+			return a + nodes.Float(b), nil
+		case nodes.Uint:
+			return a + nodes.Float(b), nil
+		case nodes.Float:
+			return a + b, nil
+		}
+	default:
+		return nil, ErrUnexpectedType.New(nodes.Int(0), a)
+	}
+	return nil, ErrUnexpectedType.New(nodes.Int(0), b)
+}
+
+// intAdd adds two signed integers and checks for overflow.
+func intAdd(a, b nodes.Int) (nodes.Value, error) {
+	if (b > 0 && a > math.MaxInt64-b) || (b < 0 && a < math.MinInt64-b) {
+		return nil, ErrUnexpectedValue.New(b)
+	}
+	return a + b, nil
+}
+
+// intSub subtracts b from a and checks for overflow.
+func intSub(a, b nodes.Int) (nodes.Value, error) {
+	if (b < 0 && a > math.MaxInt64+b) || (b > 0 && a < math.MinInt64+b) {
+		return nil, ErrUnexpectedValue.New(b)
+	}
+	return a - b, nil
+}
+
+// uintAdd adds two unsigned values and checks for overflow.
+func uintAdd(a, b nodes.Uint) (nodes.Value, error) {
+	if b > math.MaxUint64-a {
+		return nil, ErrUnexpectedValue.New(b)
+	}
+	return a + b, nil
+}
+
+// uintSub subtracts b from a and checks for underflow.
+func uintSub(a, b nodes.Uint) (nodes.Value, error) {
+	if b > a {
+		return nil, ErrUnexpectedValue.New(b)
+	}
+	return a - b, nil
+}
+
+// numNeg negates a numeric value. Unsigned values are converted to signed integers.
+func numNeg(v nodes.Value) (nodes.Value, error) {
+	switch v := v.(type) {
+	case nodes.Int:
+		if v == math.MinInt64 {
+			return nil, ErrUnexpectedValue.New(v)
+		}
+		return -v, nil
+	case nodes.Uint:
+		if v > 1<<63 {
+			return nil, ErrUnexpectedValue.New(v)
+		}
+		return -nodes.Int(v), nil
+	case nodes.Float:
+		return -v, nil
+	}
+	return nil, ErrUnexpectedType.New(nodes.Int(0), v)
+}
+
+// numSub subtracts b from a. The kind of the result is determined the same way as in numAdd.
+func numSub(a, b nodes.Value) (nodes.Value, error) {
+	switch a := a.(type) {
+	case nodes.Int:
+		if b, ok := b.(nodes.Int); ok {
+			return intSub(a, b)
+		}
+	case nodes.Uint:
+		switch b := b.(type) {
+		case nodes.Uint:
+			return uintSub(a, b)
+		case nodes.Int:
+			if b >= 0 {
+				return uintSub(a, nodes.Uint(b))
+			}
+			return uintAdd(a, nodes.Uint(-(b+1))+1)
+		}
+	}
+	nb, err := numNeg(b)
+	if err != nil {
+		return nil, err
+	}
+	return numAdd(a, nb)
+}
+
+// Add adds a constant to a numeric value and passes the result to a sub-operation.
+// Reversal subtracts the same constant. It can be used to convert 0-based positions to 1-based
+// or to shift offsets.
+func Add(on Op, delta int) Op {
+	d := nodes.Int(delta)
+	return valueConvKind(on, nodes.KindInt|nodes.KindUint|nodes.KindFloat,
+		func(v nodes.Value) (nodes.Value, error) {
+			return numAdd(v, d)
+		},
+		func(v nodes.Value) (nodes.Value, error) {
+			return numSub(v, d)
+		},
+	)
+}
+
+// Sum creates a node with the value a+b, where a and b are named variables.
+// Reversal checks the value of the node and derives a missing variable from it: if a variable a is
+// already defined, it sets b to node-a and vice versa.
+//
+// This is useful when the native AST provides a start offset and a length, while UAST requires start
+// and end offsets, e.g. Sum("start", "len") will compute the end offset.
+//
+// At least one variable must be defined before Check is executed, thus Fields should be used instead of Obj
+// to control the execution order.
+func Sum(a, b string) Op {
+	return &opArith{a: a, b: b}
+}
+
+// Diff creates a node with the value a-b, where a and b are named variables. See Sum for details.
+func Diff(a, b string) Op {
+	return &opArith{a: a, b: b, sub: true}
+}
+
+type opArith struct {
+	a, b string
+	sub  bool
+}
+
+func (*opArith) Kinds() nodes.Kind {
+	return nodes.KindInt | nodes.KindUint | nodes.KindFloat
+}
+
+func (op *opArith) getValue(st *State, name string) (nodes.Value, bool, error) {
+	n, ok := st.GetVar(name)
+	if !ok {
+		return nil, false, nil
+	}
+	v, ok := n.(nodes.Value)
+	if !ok || v == nil {
+		return nil, false, ErrExpectedValue.New(n)
+	}
+	return v, true, nil
+}
+
+func (op *opArith) Check(st *State, n nodes.Node) (bool, error) {
+	v, ok := n.(nodes.Value)
+	if !ok || v == nil {
+		return filtered("expected a numeric value for %v, got: %T", op, n)
+	}
+	switch v.(type) {
+	case nodes.Int, nodes.Uint, nodes.Float:
+	default:
+		return filtered("expected a numeric value for %v, got: %T", op, n)
+	}
+	a, okA, err := op.getValue(st, op.a)
+	if err != nil {
+		return false, err
+	}
+	b, okB, err := op.getValue(st, op.b)
+	if err != nil {
+		return false, err
+	}
+	switch {
+	case okA && okB:
+		exp, err := op.eval(a, b)
+		if err != nil {
+			return false, err
+		} else if !nodes.Equal(exp, v) {
+			return filtered("%v != %v for %v", exp, v, op)
+		}
+		return true, nil
+	case okA:
+		// a + b = n  =>  b = n - a
+		// a - b = n  =>  b = a - n
+		if op.sub {
+			b, err = numSub(a, v)
+		} else {
+			b, err = numSub(v, a)
+		}
+		if err != nil {
+			return false, err
+		}
+		return true, st.SetVar(op.b, b)
+	case okB:
+		// a + b = n  =>  a = n - b
+		// a - b = n  =>  a = n + b
+		if op.sub {
+			a, err = numAdd(v, b)
+		} else {
+			a, err = numSub(v, b)
+		}
+		if err != nil {
+			return false, err
+		}
+		return true, st.SetVar(op.a, a)
+	}
+	return false, ErrVariableNotDefined.New(op.a)
+}
+
+func (op *opArith) eval(a, b nodes.Value) (nodes.Value, error) {
+	if op.sub {
+		return numSub(a, b)
+	}
+	return numAdd(a, b)
+}
+
+func (op *opArith) Construct(st *State, n nodes.Node) (nodes.Node, error) {
+	if err := noNode(n); err != nil {
+		return nil, err
+	}
+	a, ok, err := op.getValue(st, op.a)
+	if err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, ErrVariableNotDefined.New(op.a)
+	}
+	b, ok, err := op.getValue(st, op.b)
+	if err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, ErrVariableNotDefined.New(op.b)
+	}
+	return op.eval(a, b)
+}
@@ -363,7 +363,7 @@ func (op opEach) Check(st *State, n nodes.Node) (bool, error) {
 		subs = make([]*State, 0, len(arr))
 	}
 	for i, sub := range arr {
-		sst := NewState()
+		sst := st.newSub()
 		ok, err := op.op.Check(sst, sub)
 		if err != nil {
 			return false, errElem.Wrap(err, i, sub)
@@ -400,6 +400,48 @@ func (op opEach) Construct(st *State, n nodes.Node) (nodes.Node, error) {
 	return arr, nil
 }
 
+// Repeat is like Each, but also checks that the array has at least min and at most max elements.
+// If max is zero or negative, the number of elements is not limited. Nil array is considered empty.
+func Repeat(vr string, min, max int, op Op) Op {
+	return opRepeat{min: min, max: max, each: opEach{vr: vr, op: op}}
+}
+
+type opRepeat struct {
+	min, max int
+	each     opEach
+}
+
+func (op opRepeat) Kinds() nodes.Kind {
+	if op.min > 0 {
+		return nodes.KindArray
+	}
+	return op.each.Kinds()
+}
+
+func (op opRepeat) inRange(n int) bool {
+	return n >= op.min && (op.max <= 0 || n <= op.max)
+}
+
+func (op opRepeat) Check(st *State, n nodes.Node) (bool, error) {
+	arr, ok := n.(nodes.Array)
+	if !ok && n != nil {
+		return filtered("%+v is not a list, %+v", n, op)
+	} else if !op.inRange(len(arr)) {
+		return filtered("%+v has wrong len for %+v", n, op)
+	}
+	return op.each.Check(st, n)
+}
+
+func (op opRepeat) Construct(st *State, n nodes.Node) (nodes.Node, error) {
+	subs, ok := st.GetStateVar(op.each.vr)
+	if !ok {
+		return nil, ErrVariableNotDefined.New(op.each.vr)
+	} else if !op.inRange(len(subs)) {
+		return nil, ErrUnexpectedValue.New(nodes.Int(len(subs)))
+	}
+	return op.each.Construct(st, n)
+}
+
 func ArrWith(arr Op, items ...Op) Op {
 	if len(items) == 0 {
 		return arr
@@ -24,6 +24,94 @@ func SavePosLineCol(varLine, varCol string) Op {
 	})
 }
 
+// SpanPos wraps an object operation and assigns positions to the constructed object if it has none. The positions
+// span all positioned children of the object, thus synthetic nodes created during normalization will inherit
+// the positions of the nodes they were created from.
+//
+// Reversal removes positions from the node before passing it to the operation, but only if they match
+// the positions of children.
+func SpanPos(op Op) Op {
+	return &opSpanPos{op: op}
+}
+
+type opSpanPos struct {
+	op Op
+}
+
+func (op *opSpanPos) Kinds() nodes.Kind {
+	return op.op.Kinds()
+}
+
+func (op *opSpanPos) Check(st *State, n nodes.Node) (bool, error) {
+	if obj, ok := n.(nodes.Object); ok {
+		if ps := spanChildren(obj); ps != nil && nodes.Equal(obj[uast.KeyPos], ps.ToObject()) {
+			obj = obj.CloneObject()
+			delete(obj, uast.KeyPos)
+			n = obj
+		}
+	}
+	return op.op.Check(st, n)
+}
+
+func (op *opSpanPos) Construct(st *State, n nodes.Node) (nodes.Node, error) {
+	n, err := op.op.Construct(st, n)
+	if err != nil {
+		return nil, err
+	}
+	obj, ok := n.(nodes.Object)
+	if !ok || len(uast.PositionsOf(obj)) != 0 {
+		return n, nil
+	}
+	if pos := spanChildren(obj); pos != nil {
+		obj = obj.CloneObject()
+		obj[uast.KeyPos] = pos.ToObject()
+		return obj, nil
+	}
+	return n, nil
+}
+
+// spanChildren returns positions that span all positioned children of an object. Children without
+// positions are considered transparent. It returns nil if no children have positions.
+func spanChildren(obj nodes.Object) uast.Positions {
+	var start, end uast.Position
+	var walk func(n nodes.Node)
+	walk = func(n nodes.Node) {
+		switch n := n.(type) {
+		case nodes.Object:
+			ps := uast.PositionsOf(n)
+			if len(ps) == 0 {
+				for _, v := range n {
+					walk(v)
+				}
+				return
+			}
+			if p := ps.Start(); p != nil && p.Less(start) {
+				start = *p
+			}
+			if p := ps.End(); p != nil && p.Valid() && (!end.Valid() || end.Less(*p)) {
+				end = *p
+			}
+		case nodes.Array:
+			for _, v := range n {
+				walk(v)
+			}
+		}
+	}
+	for k, v := range obj {
+		if k != uast.KeyPos {
+			walk(v)
+		}
+	}
+	if !start.Valid() {
+		return nil
+	}
+	ps := uast.Positions{uast.KeyStart: start}
+	if end.Valid() {
+		ps[uast.KeyEnd] = end
+	}
+	return ps
+}
+
 // Roles makes an operation that will check/construct a list of roles.
 func Roles(roles ...role.Role) ArrayOp {
 	arr := make([]Op, 0, len(roles))
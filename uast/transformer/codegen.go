@@ -0,0 +1,331 @@
+package transformer
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+
+	"gopkg.in/src-d/go-errors.v1"
+
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+// ErrNotCompilable is returned by GenerateGo if a mapping contains an operation that cannot be compiled.
+var ErrNotCompilable = errors.NewKind("rule %d: operation cannot be compiled: %T")
+
+// GenerateGo writes a Go source file that implements a list of mappings as a single TransformFunc variable.
+// The variable will have the same effect as Mappings, but will not use generic operations at runtime,
+// and thus has no interpretation overhead.
+//
+// Only a subset of operations can be compiled: Is and its helpers (String, Int, etc), Var, Obj, Fields without
+// optional fields, Part and Arr. ErrNotCompilable is returned for all other operations.
+//
+// Unlike Mappings, the generated function stops at the first error for each node.
+//
+// It's recommended to call the generator from a separate program with go:generate and commit the generated file.
+func GenerateGo(w io.Writer, pkg, name string, maps ...Mapping) error {
+	buf := bytes.NewBuffer(nil)
+	fmt.Fprintf(buf, "// Code generated by transformer.GenerateGo. DO NOT EDIT.\n\n")
+	fmt.Fprintf(buf, "package %s\n\n", pkg)
+	fmt.Fprintf(buf, "import (\n\t\"fmt\"\n\t\"math\"\n\n")
+	fmt.Fprintf(buf, "\t%q\n\t%q\n)\n\n", "gopkg.in/bblfsh/sdk.v2/uast/nodes", "gopkg.in/bblfsh/sdk.v2/uast/transformer")
+	fmt.Fprintf(buf, "var (\n\t_ = fmt.Errorf\n\t_ = math.Inf\n)\n\n")
+
+	fmt.Fprintf(buf, "// %s is a compiled version of %d mapping rules.\n", name, len(maps))
+	fmt.Fprintf(buf, "var %s = transformer.TransformFunc(func(n nodes.Node) (nodes.Node, bool, error) {\n", name)
+	fmt.Fprintf(buf, "\tchanged := false\n")
+	for i := range maps {
+		fmt.Fprintf(buf, "\tif nn, ok, err := %s_rule%d(n); err != nil {\n", name, i)
+		fmt.Fprintf(buf, "\t\treturn n, false, err\n")
+		fmt.Fprintf(buf, "\t} else if ok {\n\t\tn, changed = nn, true\n\t}\n")
+	}
+	fmt.Fprintf(buf, "\treturn n, changed, nil\n})\n")
+
+	for i, m := range maps {
+		g := &codeGen{rule: i, vars: make(map[string]string), used: make(map[string]bool)}
+		if err := g.genRule(fmt.Sprintf("%s_rule%d", name, i), m); err != nil {
+			return err
+		}
+		buf.WriteString("\n")
+		buf.Write(g.buf.Bytes())
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("cannot format generated code: %v", err)
+	}
+	_, err = w.Write(src)
+	return err
+}
+
+type codeGen struct {
+	rule int
+	buf  bytes.Buffer
+	tmp  int
+	vars map[string]string // variable names to Go identifiers
+	used map[string]bool   // variables used in construct step
+}
+
+func (g *codeGen) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+func (g *codeGen) newTemp() string {
+	g.tmp++
+	return "t" + strconv.Itoa(g.tmp)
+}
+
+func (g *codeGen) genRule(name string, m Mapping) error {
+	src, dst := m.Mapping()
+	body := &codeGen{rule: g.rule, vars: g.vars, used: g.used}
+	if err := body.check(src, "n"); err != nil {
+		return err
+	}
+	body.printf("\t// construct\n")
+	expr, err := body.construct(dst)
+	if err != nil {
+		return err
+	}
+	var unused []string
+	for name := range g.vars {
+		if !g.used[name] {
+			unused = append(unused, name)
+		}
+	}
+	if len(unused) != 0 {
+		sort.Strings(unused)
+		return errMapping.Wrap(ErrVariableUnused.New(unused), strconv.Itoa(g.rule))
+	}
+
+	g.printf("func %s(n nodes.Node) (nodes.Node, bool, error) {\n", name)
+	if len(g.vars) != 0 {
+		names := make([]string, 0, len(g.vars))
+		for name := range g.vars {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		g.printf("\tvar (\n")
+		for _, name := range names {
+			g.printf("\t\t%s nodes.Node // %q\n", g.vars[name], name)
+		}
+		g.printf("\t)\n")
+	}
+	g.buf.Write(body.buf.Bytes())
+	g.printf("\treturn %s, true, nil\n}\n", expr)
+	return nil
+}
+
+func (g *codeGen) skip() {
+	g.printf("\t\treturn nil, false, nil\n\t}\n")
+}
+
+func (g *codeGen) check(op Op, expr string) error {
+	switch op := op.(type) {
+	case opIs:
+		g.printf("\tif !nodes.Equal(%s, %s) {\n", expr, nodeLiteral(op.n))
+		g.skip()
+	case opVar:
+		if id, ok := g.vars[op.name]; ok {
+			g.printf("\tif !nodes.Equal(%s, %s) {\n", id, expr)
+			g.printf("\t\treturn nil, false, transformer.ErrVariableRedeclared.New(%q, %s, %s)\n\t}\n", op.name, id, expr)
+			return nil
+		}
+		id := "v" + strconv.Itoa(len(g.vars))
+		g.vars[op.name] = id
+		g.printf("\t%s = %s\n", id, expr)
+	case Obj:
+		return g.checkFields(op.fields(), expr, "")
+	case Fields:
+		return g.checkFields(op, expr, "")
+	case *opPartialObj:
+		var fields Fields
+		switch sub := op.op.(type) {
+		case Obj:
+			fields = sub.fields()
+		case Fields:
+			fields = sub
+		default:
+			return ErrNotCompilable.New(g.rule, op.op)
+		}
+		return g.checkFields(fields, expr, op.vr)
+	case opArr:
+		t := g.newTemp()
+		g.printf("\t%s, ok := %s.(nodes.Array)\n", t, expr)
+		g.printf("\tif !ok || len(%s) != %d {\n", t, len(op))
+		g.skip()
+		for i, sub := range op {
+			if err := g.check(sub, fmt.Sprintf("%s[%d]", t, i)); err != nil {
+				return err
+			}
+		}
+	default:
+		return ErrNotCompilable.New(g.rule, op)
+	}
+	return nil
+}
+
+func (g *codeGen) checkFields(fields Fields, expr string, part string) error {
+	t := g.newTemp()
+	g.printf("\t%s, ok := %s.(nodes.Object)\n", t, expr)
+	g.printf("\tif !ok {\n")
+	g.skip()
+	for _, f := range fields {
+		if f.Optional != "" {
+			return ErrNotCompilable.New(g.rule, f)
+		}
+		fv := g.newTemp()
+		g.printf("\t%s, ok := %s[%q]\n", fv, t, f.Name)
+		g.printf("\tif !ok {\n")
+		g.skip()
+		if err := g.check(f.Op, fv); err != nil {
+			return err
+		}
+	}
+	names := make([]string, 0, len(fields))
+	for _, f := range fields {
+		names = append(names, strconv.Quote(f.Name))
+	}
+	if part == "" {
+		g.printf("\tfor k := range %s {\n", t)
+		if len(names) != 0 {
+			g.printf("\t\tswitch k {\n\t\tcase %s:\n\t\t\tcontinue\n\t\t}\n", joinNames(names))
+		}
+		g.printf("\t\treturn nil, false, transformer.NewErrUnusedField(%s, []string{k})\n\t}\n", t)
+		return nil
+	}
+	other := g.newTemp()
+	g.printf("\t%s := make(nodes.Object, len(%s))\n", other, t)
+	g.printf("\tfor k, v := range %s {\n", t)
+	if len(names) != 0 {
+		g.printf("\t\tswitch k {\n\t\tcase %s:\n\t\t\tcontinue\n\t\t}\n", joinNames(names))
+	}
+	g.printf("\t\t%s[k] = v\n\t}\n", other)
+	return g.check(opVar{name: part}, other)
+}
+
+func (g *codeGen) construct(op Op) (string, error) {
+	switch op := op.(type) {
+	case opIs:
+		return nodeLiteral(op.n), nil
+	case opVar:
+		id, ok := g.vars[op.name]
+		if !ok {
+			return "", errMapping.Wrap(ErrVariableNotDefined.New(op.name), strconv.Itoa(g.rule))
+		}
+		g.used[op.name] = true
+		return id, nil
+	case Obj:
+		return g.constructFields(op.fields(), "")
+	case Fields:
+		return g.constructFields(op, "")
+	case *opPartialObj:
+		switch sub := op.op.(type) {
+		case Obj:
+			return g.constructFields(sub.fields(), op.vr)
+		case Fields:
+			return g.constructFields(sub, op.vr)
+		}
+		return "", ErrNotCompilable.New(g.rule, op.op)
+	case opArr:
+		elems := make([]string, 0, len(op))
+		for _, sub := range op {
+			e, err := g.construct(sub)
+			if err != nil {
+				return "", err
+			}
+			elems = append(elems, e)
+		}
+		t := g.newTemp()
+		g.printf("\t%s := nodes.Array{%s}\n", t, joinNames(elems))
+		return t, nil
+	}
+	return "", ErrNotCompilable.New(g.rule, op)
+}
+
+func (g *codeGen) constructFields(fields Fields, part string) (string, error) {
+	vals := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if f.Optional != "" {
+			return "", ErrNotCompilable.New(g.rule, f)
+		}
+		e, err := g.construct(f.Op)
+		if err != nil {
+			return "", err
+		}
+		vals = append(vals, fmt.Sprintf("%q: %s", f.Name, e))
+	}
+	t := g.newTemp()
+	g.printf("\t%s := nodes.Object{%s}\n", t, joinNames(vals))
+	if part == "" {
+		return t, nil
+	}
+	v, err := g.construct(opVar{name: part})
+	if err != nil {
+		return "", err
+	}
+	other := g.newTemp()
+	g.printf("\t%s, ok := %s.(nodes.Object)\n", other, v)
+	g.printf("\tif !ok {\n\t\treturn nil, false, transformer.ErrExpectedObject.New(%s)\n\t}\n", v)
+	g.printf("\tfor k, v := range %s {\n", other)
+	g.printf("\t\tif _, ok := %s[k]; ok {\n", t)
+	g.printf("\t\t\treturn nil, false, fmt.Errorf(\"trying to overwrite already set field with partial object data: %%q\", k)\n\t\t}\n")
+	g.printf("\t\t%s[k] = v\n\t}\n", t)
+	return t, nil
+}
+
+func joinNames(names []string) string {
+	buf := bytes.NewBuffer(nil)
+	for i, s := range names {
+		if i != 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(s)
+	}
+	return buf.String()
+}
+
+// nodeLiteral returns a Go expression that creates a copy of the node.
+func nodeLiteral(n nodes.Node) string {
+	switch n := n.(type) {
+	case nil:
+		return "nil"
+	case nodes.String:
+		return fmt.Sprintf("nodes.String(%q)", string(n))
+	case nodes.Int:
+		return fmt.Sprintf("nodes.Int(%d)", int64(n))
+	case nodes.Uint:
+		return fmt.Sprintf("nodes.Uint(%d)", uint64(n))
+	case nodes.Float:
+		f := float64(n)
+		switch {
+		case math.IsNaN(f):
+			return "nodes.Float(math.NaN())"
+		case math.IsInf(f, 1):
+			return "nodes.Float(math.Inf(1))"
+		case math.IsInf(f, -1):
+			return "nodes.Float(math.Inf(-1))"
+		case f == 0 && math.Signbit(f):
+			return "nodes.Float(math.Copysign(0, -1))"
+		}
+		return fmt.Sprintf("nodes.Float(%v)", f)
+	case nodes.Bool:
+		return fmt.Sprintf("nodes.Bool(%v)", bool(n))
+	case nodes.Array:
+		elems := make([]string, 0, len(n))
+		for _, e := range n {
+			elems = append(elems, nodeLiteral(e))
+		}
+		return "nodes.Array{" + joinNames(elems) + "}"
+	case nodes.Object:
+		keys := n.Keys()
+		elems := make([]string, 0, len(keys))
+		for _, k := range keys {
+			elems = append(elems, fmt.Sprintf("%q: %s", k, nodeLiteral(n[k])))
+		}
+		return "nodes.Object{" + joinNames(elems) + "}"
+	}
+	panic(fmt.Errorf("unexpected node type: %T", n))
+}
@@ -0,0 +1,66 @@
+package transformer_test
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	u "gopkg.in/bblfsh/sdk.v2/uast"
+	un "gopkg.in/bblfsh/sdk.v2/uast/nodes"
+	. "gopkg.in/bblfsh/sdk.v2/uast/transformer"
+)
+
+//go:generate go test -run TestGenerateGoGolden -update
+
+// codegenMappings are compiled into codegenCompiled, see codegen_golden_test.go.
+var codegenMappings = []Mapping{
+	Map(
+		Part("rest", Obj{u.KeyType: String("A"), "v": Var("x")}),
+		Part("rest", Obj{u.KeyType: String("B"), "arr": Arr(Var("x"), Int(1))}),
+	),
+	Map(
+		Obj{u.KeyType: String("Inf"), "v": Is(un.Float(math.Inf(1)))},
+		Obj{u.KeyType: String("NegInf"), "v": Is(un.Float(math.Inf(-1))), "half": Is(un.Float(0.5))},
+	),
+}
+
+const codegenGolden = "codegen_golden_test.go"
+
+var updateGolden = flag.Bool("update", false, "update golden files")
+
+func TestGenerateGoGolden(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	err := GenerateGo(buf, "transformer_test", "codegenCompiled", codegenMappings...)
+	require.NoError(t, err)
+	if *updateGolden {
+		err = ioutil.WriteFile(codegenGolden, buf.Bytes(), 0644)
+		require.NoError(t, err)
+		return
+	}
+
+	exp, err := ioutil.ReadFile(codegenGolden)
+	require.NoError(t, err)
+	require.Equal(t, string(exp), buf.String(), "generated code differs from %s", codegenGolden)
+}
+
+func TestGenerateGoCompiled(t *testing.T) {
+	fixture := func() un.Node {
+		return un.Array{
+			un.Object{u.KeyType: un.String("A"), "v": un.Int(1), "pos": un.Int(2)},
+			un.Object{u.KeyType: un.String("A"), "w": un.Int(1)},
+			un.Object{u.KeyType: un.String("Inf"), "v": un.Float(math.Inf(1))},
+			un.Object{u.KeyType: un.String("Inf"), "v": un.Float(1)},
+			un.Object{u.KeyType: un.String("C"), "v": un.Object{u.KeyType: un.String("A"), "v": un.String("x")}},
+		}
+	}
+	exp, err := Mappings(codegenMappings...).Do(fixture())
+	require.NoError(t, err)
+
+	out, err := codegenCompiled.Do(fixture())
+	require.NoError(t, err)
+	require.Equal(t, exp, out)
+}
@@ -0,0 +1,259 @@
+package transformer
+
+import (
+	"sort"
+
+	"gopkg.in/bblfsh/sdk.v2/uast"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+var typeComment = nodes.String(uast.TypeOf(uast.Comment{}))
+
+const (
+	// DefaultLeadingComments is a default field name for comments that precede the node.
+	DefaultLeadingComments = "LeadingComments"
+	// DefaultTrailingComments is a default field name for comments that follow the node.
+	DefaultTrailingComments = "TrailingComments"
+)
+
+var _ Transformer = AttachComments{}
+
+// AttachComments is a transformer that moves comment nodes to the leading and trailing fields of adjacent nodes,
+// based on their positions.
+//
+// For each comment, the transformer finds the innermost node that contains it and checks the children of that node.
+// A comment that starts on the same line where the previous sibling ends becomes a trailing comment of that sibling.
+// Otherwise, it becomes a leading comment of the next sibling, or a trailing comment of the previous one if there
+// is no next sibling. If there are no siblings, the comment is attached as a trailing comment of the parent node.
+//
+// Comments without positional information are left in place. Objects without positions are considered transparent:
+// their children are treated as children of the closest positioned ancestor.
+//
+// Target nodes receive new fields, thus the transformer should be executed on the native AST before other mappings.
+type AttachComments struct {
+	// Field is the name of the root field that holds a list of all comments in the file.
+	// If not set, comments are collected from all arrays of the tree.
+	Field string
+	// Leading is the field name for comments that precede the node. Defaults to DefaultLeadingComments.
+	Leading string
+	// Trailing is the field name for comments that follow the node. Defaults to DefaultTrailingComments.
+	Trailing string
+	// IsComment reports if an object is a comment. By default, only uast:Comment nodes are considered comments.
+	IsComment func(n nodes.Object) bool
+}
+
+func (c AttachComments) leading() string {
+	if c.Leading == "" {
+		return DefaultLeadingComments
+	}
+	return c.Leading
+}
+
+func (c AttachComments) trailing() string {
+	if c.Trailing == "" {
+		return DefaultTrailingComments
+	}
+	return c.Trailing
+}
+
+func (c AttachComments) isComment(n nodes.Object) bool {
+	if c.IsComment != nil {
+		return c.IsComment(n)
+	}
+	return n[uast.KeyType] == typeComment
+}
+
+// detachedComment is a detached comment node with its span.
+type detachedComment struct {
+	node       nodes.Object
+	start, end uast.Position
+}
+
+// span returns start and end positions of a node. The end position is set to start if it's missing.
+func span(n nodes.Object) (uast.Position, uast.Position, bool) {
+	ps := uast.PositionsOf(n)
+	start := ps.Start()
+	if start == nil || !start.Valid() {
+		return uast.Position{}, uast.Position{}, false
+	}
+	end := ps.End()
+	if end == nil || !end.Valid() {
+		end = start
+	}
+	return *start, *end, true
+}
+
+// Do implements Transformer.
+func (c AttachComments) Do(root nodes.Node) (nodes.Node, error) {
+	if root == nil {
+		return nil, nil
+	}
+	root = root.Clone()
+	var (
+		list []detachedComment
+		left nodes.Array
+	)
+	if c.Field != "" {
+		obj, ok := root.(nodes.Object)
+		if !ok {
+			return nil, ErrExpectedObject.New(root)
+		}
+		v, ok := obj[c.Field]
+		if !ok || v == nil {
+			return root, nil
+		}
+		arr, ok := v.(nodes.Array)
+		if !ok {
+			return nil, ErrExpectedList.New(v)
+		}
+		for _, e := range arr {
+			co, ok := e.(nodes.Object)
+			if !ok || !c.isComment(co) {
+				left = append(left, e)
+				continue
+			}
+			start, end, ok := span(co)
+			if !ok {
+				left = append(left, e)
+				continue
+			}
+			list = append(list, detachedComment{node: co, start: start, end: end})
+		}
+		delete(obj, c.Field)
+	} else {
+		root = c.collect(root, &list)
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].start.Less(list[j].start)
+	})
+	for _, com := range list {
+		if !c.attach(root, com) {
+			left = append(left, com.node)
+		}
+	}
+	if len(left) != 0 {
+		if c.Field != "" {
+			root.(nodes.Object)[c.Field] = left
+		} else if arr, ok := root.(nodes.Array); ok {
+			root = append(arr, left...)
+		}
+	}
+	return root, nil
+}
+
+// collect removes all positioned comments from arrays of the tree and appends them to the list.
+// The tree is modified in place.
+func (c AttachComments) collect(n nodes.Node, list *[]detachedComment) nodes.Node {
+	switch n := n.(type) {
+	case nodes.Object:
+		for k, v := range n {
+			if k == uast.KeyPos {
+				continue
+			}
+			n[k] = c.collect(v, list)
+		}
+	case nodes.Array:
+		out := n[:0]
+		for _, e := range n {
+			if co, ok := e.(nodes.Object); ok && c.isComment(co) {
+				if start, end, ok := span(co); ok {
+					*list = append(*list, detachedComment{node: co, start: start, end: end})
+					continue
+				}
+			}
+			out = append(out, c.collect(e, list))
+		}
+		return out
+	}
+	return n
+}
+
+// children returns all positioned objects that are direct descendants of the node, sorted by the start position.
+func (c AttachComments) children(n nodes.Node) []nodes.Object {
+	var out []nodes.Object
+	var visit func(n nodes.Node)
+	visit = func(n nodes.Node) {
+		switch n := n.(type) {
+		case nodes.Object:
+			if _, _, ok := span(n); ok {
+				out = append(out, n)
+				return
+			}
+			c.fields(n, visit)
+		case nodes.Array:
+			for _, e := range n {
+				visit(e)
+			}
+		}
+	}
+	switch n := n.(type) {
+	case nodes.Object:
+		c.fields(n, visit)
+	default:
+		visit(n)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		si, _, _ := span(out[i])
+		sj, _, _ := span(out[j])
+		return si.Less(sj)
+	})
+	return out
+}
+
+// fields calls fnc for each field of an object in a stable order, skipping positional information and
+// attached comments.
+func (c AttachComments) fields(n nodes.Object, fnc func(v nodes.Node)) {
+	for _, k := range n.Keys() {
+		switch k {
+		case uast.KeyPos, c.leading(), c.trailing():
+			continue
+		}
+		fnc(n[k])
+	}
+}
+
+// attach finds the innermost node that contains the comment and attaches the comment to one of its children.
+// It returns false if there is no node the comment can be attached to.
+func (c AttachComments) attach(n nodes.Node, com detachedComment) bool {
+	kids := c.children(n)
+	for _, k := range kids {
+		start, end, _ := span(k)
+		if !com.start.Less(start) && com.start.Less(end) {
+			return c.attach(k, com)
+		}
+	}
+	var prev, next nodes.Object
+	for _, k := range kids {
+		start, end, _ := span(k)
+		if !com.start.Less(end) {
+			prev = k
+		} else if next == nil && !start.Less(com.end) {
+			next = k
+		}
+	}
+	if prev != nil {
+		_, end, _ := span(prev)
+		if end.HasLineCol() && com.start.HasLineCol() && end.Line == com.start.Line {
+			next = nil
+		}
+	}
+	switch {
+	case next != nil:
+		appendField(next, c.leading(), com.node)
+	case prev != nil:
+		appendField(prev, c.trailing(), com.node)
+	default:
+		obj, ok := n.(nodes.Object)
+		if !ok {
+			return false
+		}
+		appendField(obj, c.trailing(), com.node)
+	}
+	return true
+}
+
+// appendField appends a node to an array stored in the object field.
+func appendField(obj nodes.Object, field string, n nodes.Node) {
+	arr, _ := obj[field].(nodes.Array)
+	obj[field] = append(arr, n)
+}
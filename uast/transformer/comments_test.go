@@ -0,0 +1,191 @@
+package transformer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"gopkg.in/bblfsh/sdk.v2/uast"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+// posNode creates a native node spanning the given lines with the start and end columns.
+func posNode(typ string, line1, col1, line2, col2 int) nodes.Object {
+	return nodes.Object{
+		uast.KeyType: nodes.String(typ),
+		uast.KeyPos: uast.Positions{
+			uast.KeyStart: {Line: uint32(line1), Col: uint32(col1)},
+			uast.KeyEnd:   {Line: uint32(line2), Col: uint32(col2)},
+		}.ToObject(),
+	}
+}
+
+func with(obj nodes.Object, k string, v nodes.Node) nodes.Object {
+	obj = obj.CloneObject()
+	obj[k] = v
+	return obj
+}
+
+// comNode creates a native comment node on a given line.
+func comNode(line, col int) nodes.Object {
+	return posNode("Comment", line, col, line, col+5)
+}
+
+// file creates a native root node with given fields.
+func file(kv ...interface{}) nodes.Object {
+	obj := nodes.Object{uast.KeyType: nodes.String("File")}
+	for i := 0; i < len(kv); i += 2 {
+		obj[kv[i].(string)] = kv[i+1].(nodes.Node)
+	}
+	return obj
+}
+
+func isNativeComment(n nodes.Object) bool {
+	return n[uast.KeyType] == nodes.String("Comment")
+}
+
+var commentsCases = []struct {
+	name     string
+	inp, exp func() nodes.Node
+	field    string
+}{
+	{
+		// 1: // c
+		// 2: stmt
+		name:  "leading",
+		field: "comments",
+		inp: func() nodes.Node {
+			return file("comments", nodes.Array{comNode(1, 1)}, "body", nodes.Array{posNode("Stmt", 2, 1, 2, 6)})
+		},
+		exp: func() nodes.Node {
+			return file("body", nodes.Array{
+				with(posNode("Stmt", 2, 1, 2, 6), DefaultLeadingComments, nodes.Array{comNode(1, 1)}),
+			})
+		},
+	},
+	{
+		// 1: stmt // c
+		// 2: stmt
+		name:  "trailing",
+		field: "comments",
+		inp: func() nodes.Node {
+			return file("comments", nodes.Array{comNode(1, 8)}, "body", nodes.Array{
+				posNode("Stmt", 1, 1, 1, 6), posNode("Stmt", 2, 1, 2, 6),
+			})
+		},
+		exp: func() nodes.Node {
+			return file("body", nodes.Array{
+				with(posNode("Stmt", 1, 1, 1, 6), DefaultTrailingComments, nodes.Array{comNode(1, 8)}),
+				posNode("Stmt", 2, 1, 2, 6),
+			})
+		},
+	},
+	{
+		// 1: stmt
+		// 2: // c
+		name:  "trailing last",
+		field: "comments",
+		inp: func() nodes.Node {
+			return file("comments", nodes.Array{comNode(2, 1)}, "body", nodes.Array{posNode("Stmt", 1, 1, 1, 6)})
+		},
+		exp: func() nodes.Node {
+			return file("body", nodes.Array{
+				with(posNode("Stmt", 1, 1, 1, 6), DefaultTrailingComments, nodes.Array{comNode(2, 1)}),
+			})
+		},
+	},
+	{
+		// 1: func {
+		// 2:   // c
+		// 3: }
+		name:  "orphan",
+		field: "comments",
+		inp: func() nodes.Node {
+			return file("comments", nodes.Array{comNode(2, 3)}, "body", nodes.Array{posNode("Func", 1, 1, 3, 2)})
+		},
+		exp: func() nodes.Node {
+			return file("body", nodes.Array{
+				with(posNode("Func", 1, 1, 3, 2), DefaultTrailingComments, nodes.Array{comNode(2, 3)}),
+			})
+		},
+	},
+	{
+		// 1: // c
+		name:  "orphan root",
+		field: "comments",
+		inp: func() nodes.Node {
+			return file("comments", nodes.Array{comNode(1, 1)})
+		},
+		exp: func() nodes.Node {
+			return file(DefaultTrailingComments, nodes.Array{comNode(1, 1)})
+		},
+	},
+	{
+		name:  "no positions",
+		field: "comments",
+		inp: func() nodes.Node {
+			return file("comments", nodes.Array{nodes.Object{uast.KeyType: nodes.String("Comment")}},
+				"body", nodes.Array{posNode("Stmt", 1, 1, 1, 6)})
+		},
+	},
+	{
+		// 1: // c1
+		// 2: stmt // c2
+		// 3: func {
+		// 4:   // c3
+		// 5:   stmt
+		// 6:   // c4
+		// 7: }
+		// 8: // c5
+		name: "in place",
+		inp: func() nodes.Node {
+			return file("body", nodes.Array{comNode(1, 1), posNode("Stmt", 2, 1, 2, 6), comNode(2, 8),
+				with(posNode("Func", 3, 1, 7, 2), "body", nodes.Array{comNode(4, 3), posNode("Stmt", 5, 3, 5, 8), comNode(6, 3)}),
+				comNode(8, 1),
+			})
+		},
+		exp: func() nodes.Node {
+			return file("body", nodes.Array{
+				with(with(posNode("Stmt", 2, 1, 2, 6), DefaultLeadingComments, nodes.Array{comNode(1, 1)}), DefaultTrailingComments, nodes.Array{comNode(2, 8)}),
+				with(with(posNode("Func", 3, 1, 7, 2), "body", nodes.Array{
+					with(with(posNode("Stmt", 5, 3, 5, 8), DefaultLeadingComments, nodes.Array{comNode(4, 3)}), DefaultTrailingComments, nodes.Array{comNode(6, 3)}),
+				}), DefaultTrailingComments, nodes.Array{comNode(8, 1)}),
+			})
+		},
+	},
+}
+
+func TestAttachComments(t *testing.T) {
+	for _, c := range commentsCases {
+		if c.exp == nil {
+			c.exp = c.inp
+		}
+		t.Run(c.name, func(t *testing.T) {
+			inp := c.inp()
+			out, err := AttachComments{Field: c.field, IsComment: isNativeComment}.Do(inp)
+			require.NoError(t, err)
+			require.Equal(t, c.exp(), out, "transformation failed")
+			require.Equal(t, c.inp(), inp, "transformation should clone the value")
+		})
+	}
+}
+
+func TestAttachCommentsReverse(t *testing.T) {
+	// attached comments can be consumed by reversible mappings
+	m := Map(
+		Part("_", Obj{uast.KeyType: String("Stmt"), DefaultLeadingComments: Var("c")}),
+		Part("_", Obj{uast.KeyType: String("Stmt"), "Comments": Var("c")}),
+	)
+	for _, c := range commentsCases {
+		if c.exp == nil {
+			c.exp = c.inp
+		}
+		t.Run(c.name, func(t *testing.T) {
+			out, err := Mappings(m).Do(c.exp())
+			require.NoError(t, err)
+			out, err = Mappings(Reverse(m)).Do(out)
+			require.NoError(t, err)
+			require.Equal(t, c.exp(), out)
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package transformer
+
+import (
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+// nodeContext describes the position of the node in the source tree.
+//
+// Context nodes are the original nodes of the tree, as they were before applying the transformation.
+type nodeContext struct {
+	up     *nodeContext // context of the parent node; nil for the root
+	parent nodes.Node   // parent node
+	index  int          // index of the node in the parent array; -1 if the parent is not an array
+}
+
+// sibling returns a node that precedes the current one in the parent array.
+func (c *nodeContext) sibling() (nodes.Node, bool) {
+	if c == nil || c.index <= 0 {
+		return nil, false
+	}
+	arr, ok := c.parent.(nodes.Array)
+	if !ok {
+		return nil, false
+	}
+	return arr[c.index-1], true
+}
+
+// applyContext is similar to nodes.Apply, but tracks the position of each node in the tree and passes it to the
+// callback.
+func applyContext(root nodes.Node, ctx *nodeContext, apply func(ctx *nodeContext, n nodes.Node) (nodes.Node, bool)) (nodes.Node, bool) {
+	if root == nil {
+		return nil, false
+	}
+	var changed bool
+	switch n := root.(type) {
+	case nodes.Object:
+		var nn nodes.Object
+		sub := &nodeContext{up: ctx, parent: n, index: -1}
+		for k, v := range n {
+			if nv, ok := applyContext(v, sub, apply); ok {
+				if nn == nil {
+					nn = n.CloneObject()
+				}
+				nn[k] = nv
+			}
+		}
+		if nn != nil {
+			changed = true
+			root = nn
+		}
+	case nodes.Array:
+		var nn nodes.Array
+		for i, v := range n {
+			sub := &nodeContext{up: ctx, parent: n, index: i}
+			if nv, ok := applyContext(v, sub, apply); ok {
+				if nn == nil {
+					nn = n.CloneList()
+				}
+				nn[i] = nv
+			}
+		}
+		if nn != nil {
+			changed = true
+			root = nn
+		}
+	}
+	nn, changed2 := apply(ctx, root)
+	return nn, changed || changed2
+}
+
+// Parent checks the closest object that contains the current node. Arrays are skipped.
+// The check will fail for the root node.
+//
+// The parent node is taken from the source tree, thus it is not affected by the current transformation.
+// The selector cannot change the state.
+func Parent(s Sel) Sel {
+	return &opParent{sel: s}
+}
+
+type opParent struct {
+	sel Sel
+}
+
+func (*opParent) Kinds() nodes.Kind {
+	return nodes.KindsAny
+}
+
+func (op *opParent) Check(st *State, _ nodes.Node) (bool, error) {
+	for c := st.ctx; c != nil; c = c.up {
+		if _, ok := c.parent.(nodes.Object); ok {
+			return checkContext(st, c.up, op.sel, c.parent)
+		}
+	}
+	return filtered("no parent object for %v", op)
+}
+
+// Ancestor checks if any object that contains the current node matches the selector. Arrays are skipped.
+// See Parent for details.
+func Ancestor(s Sel) Sel {
+	return &opAncestor{sel: s}
+}
+
+type opAncestor struct {
+	sel Sel
+}
+
+func (*opAncestor) Kinds() nodes.Kind {
+	return nodes.KindsAny
+}
+
+func (op *opAncestor) Check(st *State, _ nodes.Node) (bool, error) {
+	for c := st.ctx; c != nil; c = c.up {
+		if _, ok := c.parent.(nodes.Object); !ok {
+			continue
+		}
+		if ok, err := checkContext(st, c.up, op.sel, c.parent); err != nil || ok {
+			return ok, err
+		}
+	}
+	return filtered("no matching ancestor for %v", op)
+}
+
+// PrevSibling checks a node that precedes the current one in the parent array. If the current node is the first one,
+// or it is not an element of an array, the selector will receive a nil node.
+//
+// The sibling node is taken from the source tree, thus it is not affected by the current transformation.
+// The selector cannot change the state.
+func PrevSibling(s Sel) Sel {
+	return &opPrevSibling{sel: s}
+}
+
+type opPrevSibling struct {
+	sel Sel
+}
+
+func (*opPrevSibling) Kinds() nodes.Kind {
+	return nodes.KindsAny
+}
+
+func (op *opPrevSibling) Check(st *State, _ nodes.Node) (bool, error) {
+	prev, ok := st.ctx.sibling()
+	if !ok {
+		return checkContext(st, st.ctx, op.sel, nil)
+	}
+	ctx := *st.ctx
+	ctx.index--
+	return checkContext(st, &ctx, op.sel, prev)
+}
+
+// FirstElem checks if the current node is the first element of an array.
+func FirstElem() Sel {
+	return opFirstElem{}
+}
+
+type opFirstElem struct{}
+
+func (opFirstElem) Kinds() nodes.Kind {
+	return nodes.KindsAny
+}
+
+func (opFirstElem) Check(st *State, _ nodes.Node) (bool, error) {
+	c := st.ctx
+	if c == nil {
+		return false, nil
+	}
+	_, ok := c.parent.(nodes.Array)
+	return ok && c.index == 0, nil
+}
+
+// checkContext runs a selector on a context node with a position set to ctx.
+func checkContext(st *State, ctx *nodeContext, sel Sel, n nodes.Node) (bool, error) {
+	st = st.Clone()
+	st.ctx = ctx
+	return sel.Check(st, n)
+}
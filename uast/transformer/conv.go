@@ -3,6 +3,9 @@ package transformer
 import (
 	"fmt"
 	"strconv"
+	"strings"
+
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
 )
 
 // Quote uses strconv.Quote/Unquote to wrap provided string value.
@@ -17,3 +20,265 @@ func Quote(op Op) Op {
 		return strconv.Quote(s), nil
 	})
 }
+
+// Trim removes a specific prefix and suffix from a string value and passes it to a sub-operation.
+// The check will not match if the string has no such prefix or suffix. Reversal restores both of them.
+func Trim(op Op, prefix, suffix string) Op {
+	return &opTrim{prefix: prefix, suffix: suffix, op: op}
+}
+
+type opTrim struct {
+	prefix, suffix string
+	op             Op
+}
+
+func (*opTrim) Kinds() nodes.Kind {
+	return nodes.KindString
+}
+
+func (op *opTrim) Check(st *State, n nodes.Node) (bool, error) {
+	sv, ok := n.(nodes.String)
+	if !ok {
+		return filtered("%+v is not a string: %v", n, op)
+	}
+	s := string(sv)
+	if len(s) < len(op.prefix)+len(op.suffix) || !strings.HasPrefix(s, op.prefix) || !strings.HasSuffix(s, op.suffix) {
+		return filtered("%q has no prefix %q or suffix %q", s, op.prefix, op.suffix)
+	}
+	return op.op.Check(st, nodes.String(s[len(op.prefix):len(s)-len(op.suffix)]))
+}
+
+func (op *opTrim) Construct(st *State, n nodes.Node) (nodes.Node, error) {
+	n, err := op.op.Construct(st, n)
+	if err != nil {
+		return nil, err
+	}
+	s, ok := n.(nodes.String)
+	if !ok {
+		return nil, ErrUnexpectedType.New(nodes.String(""), n)
+	}
+	return nodes.String(op.prefix) + s + nodes.String(op.suffix), nil
+}
+
+// TrimSpace removes all leading and trailing whitespaces from a string value and passes it to a sub-operation.
+// Whitespaces are stored into named variables left and right and are restored on reversal.
+func TrimSpace(left, right string, op Op) Op {
+	return TrimCutset(" \t\r\n", left, right, op)
+}
+
+// TrimCutset removes all leading and trailing characters contained in the cutset from a string value and passes it
+// to a sub-operation. Removed characters are stored into named variables left and right and are restored on reversal.
+func TrimCutset(cutset string, left, right string, op Op) Op {
+	return &opTrimCutset{cutset: cutset, left: left, right: right, op: op}
+}
+
+type opTrimCutset struct {
+	cutset      string
+	left, right string
+	op          Op
+}
+
+func (*opTrimCutset) Kinds() nodes.Kind {
+	return nodes.KindString
+}
+
+func (op *opTrimCutset) Check(st *State, n nodes.Node) (bool, error) {
+	sv, ok := n.(nodes.String)
+	if !ok {
+		return filtered("%+v is not a string: %v", n, op)
+	}
+	s := string(sv)
+	ns := strings.TrimLeft(s, op.cutset)
+	left := s[:len(s)-len(ns)]
+	s = ns
+	ns = strings.TrimRight(s, op.cutset)
+	right := s[len(ns):]
+	if err := st.SetVars(Vars{
+		op.left:  nodes.String(left),
+		op.right: nodes.String(right),
+	}); err != nil {
+		return false, err
+	}
+	return op.op.Check(st, nodes.String(ns))
+}
+
+func (op *opTrimCutset) Construct(st *State, n nodes.Node) (nodes.Node, error) {
+	var left, right nodes.String
+	if err := st.MustGetVars(VarsPtrs{
+		op.left: &left, op.right: &right,
+	}); err != nil {
+		return nil, err
+	}
+	n, err := op.op.Construct(st, n)
+	if err != nil {
+		return nil, err
+	}
+	s, ok := n.(nodes.String)
+	if !ok {
+		return nil, ErrUnexpectedType.New(nodes.String(""), n)
+	}
+	return left + s + right, nil
+}
+
+// Lower converts a string value to lower case and passes it to a sub-operation.
+// The original value is stored into a named variable and is restored on reversal, unless the sub-operation
+// constructs a different value.
+func Lower(vr string, op Op) Op {
+	return &opCase{vr: vr, op: op, conv: strings.ToLower}
+}
+
+// Upper converts a string value to upper case and passes it to a sub-operation.
+// The original value is stored into a named variable and is restored on reversal. See Lower.
+func Upper(vr string, op Op) Op {
+	return &opCase{vr: vr, op: op, conv: strings.ToUpper}
+}
+
+type opCase struct {
+	vr   string
+	op   Op
+	conv func(string) string
+}
+
+func (*opCase) Kinds() nodes.Kind {
+	return nodes.KindString
+}
+
+func (op *opCase) Check(st *State, n nodes.Node) (bool, error) {
+	s, ok := n.(nodes.String)
+	if !ok {
+		return filtered("%+v is not a string: %v", n, op)
+	}
+	if err := st.SetVar(op.vr, s); err != nil {
+		return false, err
+	}
+	return op.op.Check(st, nodes.String(op.conv(string(s))))
+}
+
+func (op *opCase) Construct(st *State, n nodes.Node) (nodes.Node, error) {
+	var orig nodes.String
+	if err := st.MustGetVars(VarsPtrs{op.vr: &orig}); err != nil {
+		return nil, err
+	}
+	n, err := op.op.Construct(st, n)
+	if err != nil {
+		return nil, err
+	}
+	s, ok := n.(nodes.String)
+	if !ok {
+		return nil, ErrUnexpectedType.New(nodes.String(""), n)
+	}
+	if op.conv(string(orig)) != string(s) {
+		// the value was changed, the original case cannot be restored
+		return s, nil
+	}
+	return orig, nil
+}
+
+// Split splits a string value by a separator and passes an array of strings to a sub-operation.
+// Empty string is converted to an empty array. Reversal joins array elements with the same separator.
+func Split(sep string, op Op) Op {
+	return &opSplit{sep: sep, op: op}
+}
+
+type opSplit struct {
+	sep string
+	op  Op
+}
+
+func (*opSplit) Kinds() nodes.Kind {
+	return nodes.KindString
+}
+
+func (op *opSplit) Check(st *State, n nodes.Node) (bool, error) {
+	s, ok := n.(nodes.String)
+	if !ok {
+		return filtered("%+v is not a string: %v", n, op)
+	}
+	return op.op.Check(st, splitString(string(s), op.sep))
+}
+
+func (op *opSplit) Construct(st *State, n nodes.Node) (nodes.Node, error) {
+	if err := noNode(n); err != nil {
+		return nil, err
+	}
+	n, err := op.op.Construct(st, nil)
+	if err != nil {
+		return nil, err
+	}
+	s, err := joinStrings(n, op.sep)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Join joins an array of strings with a separator and passes the resulting string to a sub-operation.
+// Reversal splits the string by the same separator. See Split.
+func Join(sep string, op Op) Op {
+	return &opJoin{sep: sep, op: op}
+}
+
+type opJoin struct {
+	sep string
+	op  Op
+}
+
+func (*opJoin) Kinds() nodes.Kind {
+	return nodes.KindArray
+}
+
+func (op *opJoin) Check(st *State, n nodes.Node) (bool, error) {
+	if _, ok := n.(nodes.Array); !ok {
+		return filtered("%+v is not an array: %v", n, op)
+	}
+	s, err := joinStrings(n, op.sep)
+	if ErrUnexpectedType.Is(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return op.op.Check(st, s)
+}
+
+func (op *opJoin) Construct(st *State, n nodes.Node) (nodes.Node, error) {
+	if err := noNode(n); err != nil {
+		return nil, err
+	}
+	n, err := op.op.Construct(st, nil)
+	if err != nil {
+		return nil, err
+	}
+	s, ok := n.(nodes.String)
+	if !ok {
+		return nil, ErrUnexpectedType.New(nodes.String(""), n)
+	}
+	return splitString(string(s), op.sep), nil
+}
+
+func splitString(s, sep string) nodes.Array {
+	if s == "" {
+		return nodes.Array{}
+	}
+	parts := strings.Split(s, sep)
+	arr := make(nodes.Array, 0, len(parts))
+	for _, p := range parts {
+		arr = append(arr, nodes.String(p))
+	}
+	return arr
+}
+
+func joinStrings(n nodes.Node, sep string) (nodes.String, error) {
+	arr, ok := n.(nodes.Array)
+	if !ok {
+		return "", ErrExpectedList.New(n)
+	}
+	parts := make([]string, 0, len(arr))
+	for _, e := range arr {
+		s, ok := e.(nodes.String)
+		if !ok {
+			return "", ErrUnexpectedType.New(nodes.String(""), e)
+		}
+		parts = append(parts, string(s))
+	}
+	return nodes.String(strings.Join(parts, sep)), nil
+}
@@ -0,0 +1,89 @@
+package transformer
+
+import (
+	"sort"
+	"sync"
+)
+
+// WithCoverage returns a copy of the transformer that records all rules applied to the tree.
+// It works only for transformers created with Mappings, and returns nil coverage for all other types.
+func WithCoverage(t Transformer) (Transformer, *Coverage) {
+	m, ok := t.(mappings)
+	if !ok {
+		return t, nil
+	}
+	m.cov = &Coverage{
+		hits:      make([]int, len(m.all)),
+		matched:   make(map[string]int),
+		unmatched: make(map[string]int),
+	}
+	return m, m.cov
+}
+
+// Coverage tracks how many times each mapping rule was applied. See WithCoverage.
+//
+// It is safe to use the same coverage from multiple goroutines.
+type Coverage struct {
+	mu        sync.Mutex
+	hits      []int          // by rule index
+	matched   map[string]int // node types matched by at least one rule
+	unmatched map[string]int // node types not matched by any rule
+}
+
+func (c *Coverage) addRule(ind int) {
+	if c == nil || ind < 0 {
+		return
+	}
+	c.mu.Lock()
+	if ind < len(c.hits) {
+		c.hits[ind]++
+	}
+	c.mu.Unlock()
+}
+
+func (c *Coverage) addNode(typ string, applied bool) {
+	if c == nil || typ == "" {
+		return
+	}
+	c.mu.Lock()
+	if applied {
+		c.matched[typ]++
+	} else {
+		c.unmatched[typ]++
+	}
+	c.mu.Unlock()
+}
+
+// Hits returns the number of times each rule was applied, by the index of the rule.
+func (c *Coverage) Hits() []int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]int{}, c.hits...)
+}
+
+// Unused returns indexes of rules that were never applied.
+func (c *Coverage) Unused() []int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var out []int
+	for i, n := range c.hits {
+		if n == 0 {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+// NeverMatched returns a sorted list of node types that were not matched by any rule.
+func (c *Coverage) NeverMatched() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var out []string
+	for typ := range c.unmatched {
+		if _, ok := c.matched[typ]; !ok {
+			out = append(out, typ)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
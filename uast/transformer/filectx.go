@@ -0,0 +1,166 @@
+package transformer
+
+import (
+	"sync"
+
+	"gopkg.in/src-d/go-errors.v1"
+
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+// ErrNoContext is returned by operations that change the transformation context if the transformer was not
+// configured with WithContext.
+var ErrNoContext = errors.NewKind("transformation context is not set")
+
+// Context stores values shared by all rules applied to a single file, such as a detected language dialect or pragmas
+// found in the file header. See WithContext.
+//
+// It is safe to use the same context from multiple goroutines.
+type Context struct {
+	mu   sync.RWMutex
+	vars map[string]nodes.Node
+}
+
+// NewContext creates a new empty transformation context.
+func NewContext() *Context {
+	return &Context{vars: make(map[string]nodes.Node)}
+}
+
+// Get returns the value of a context variable.
+func (c *Context) Get(name string) (nodes.Node, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	v, ok := c.vars[name]
+	return v, ok
+}
+
+// Set changes the value of a context variable.
+func (c *Context) Set(name string, v nodes.Node) {
+	c.mu.Lock()
+	c.vars[name] = v
+	c.mu.Unlock()
+}
+
+func (c *Context) clone() *Context {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	c2 := NewContext()
+	for k, v := range c.vars {
+		c2.vars[k] = v
+	}
+	return c2
+}
+
+// WithContext returns a copy of the transformer that uses a given context for SetContext, SaveContext and ContextIs
+// operations. The same context can be passed to multiple transformers to share values between them.
+// It works only for transformers created with Mappings, and returns all other transformers unchanged.
+//
+// Values set by a rule are only saved if the rule is applied successfully. Note that Mappings transform the tree
+// bottom-up, thus values set for a node are visible when transforming its parents and following siblings.
+// Transformers that depend on the context should not be used with Memoize.
+func WithContext(t Transformer, c *Context) Transformer {
+	m, ok := t.(mappings)
+	if !ok {
+		return t
+	}
+	m.file = c
+	return m
+}
+
+// contextVar is a context variable set by a rule that is not yet applied.
+type contextVar struct {
+	name string
+	val  nodes.Node
+}
+
+func (st *State) setContext(name string, v nodes.Node) error {
+	if st.file == nil {
+		return ErrNoContext.New()
+	}
+	if st.fileSet != nil {
+		*st.fileSet = append(*st.fileSet, contextVar{name: name, val: v})
+	} else {
+		st.file.Set(name, v)
+	}
+	return nil
+}
+
+// SetContext sets a context variable to a constant value. Both Check and Construct always succeed, and Construct
+// returns the node unchanged, thus the operation should be used in Seq after an operation that matches the node.
+// See WithContext for details.
+func SetContext(name string, val nodes.Value) Op {
+	return &opSetContext{name: name, val: val}
+}
+
+type opSetContext struct {
+	name string
+	val  nodes.Value
+}
+
+func (*opSetContext) Kinds() nodes.Kind {
+	return nodes.KindsAny
+}
+
+func (op *opSetContext) Check(st *State, _ nodes.Node) (bool, error) {
+	return true, st.setContext(op.name, op.val)
+}
+
+func (op *opSetContext) Construct(st *State, n nodes.Node) (nodes.Node, error) {
+	return n, st.setContext(op.name, op.val)
+}
+
+// SaveContext stores the current node in a context variable and checks it with op.
+// Reversal stores the node constructed by op. See WithContext for details.
+func SaveContext(name string, op Op) Op {
+	return &opSaveContext{name: name, op: op}
+}
+
+type opSaveContext struct {
+	name string
+	op   Op
+}
+
+func (op *opSaveContext) Kinds() nodes.Kind {
+	return op.op.Kinds()
+}
+
+func (op *opSaveContext) Check(st *State, n nodes.Node) (bool, error) {
+	if ok, err := op.op.Check(st, n); err != nil || !ok {
+		return ok, err
+	}
+	return true, st.setContext(op.name, n)
+}
+
+func (op *opSaveContext) Construct(st *State, n nodes.Node) (nodes.Node, error) {
+	nn, err := op.op.Construct(st, n)
+	if err != nil {
+		return nil, err
+	}
+	return nn, st.setContext(op.name, nn)
+}
+
+// ContextIs checks the value of a context variable with a selector. The check fails if the variable is not set,
+// or if the transformer has no context. The selector cannot change the state. See WithContext for details.
+func ContextIs(name string, sel Sel) Sel {
+	return &opContextIs{name: name, sel: sel}
+}
+
+type opContextIs struct {
+	name string
+	sel  Sel
+}
+
+func (*opContextIs) Kinds() nodes.Kind {
+	return nodes.KindsAny
+}
+
+func (op *opContextIs) Check(st *State, _ nodes.Node) (bool, error) {
+	if st.file == nil {
+		return filtered("no context for %v", op)
+	}
+	v, ok := st.file.Get(op.name)
+	if !ok {
+		return filtered("context variable %q is not set", op.name)
+	}
+	return op.sel.Check(st.Clone(), v)
+}
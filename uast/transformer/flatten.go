@@ -0,0 +1,130 @@
+package transformer
+
+import (
+	"gopkg.in/bblfsh/sdk.v2/uast"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+// Unwrap matches a wrapper object of a given type that has no other fields except the type and a single field
+// with a child node. The child node is passed to a sub-operation. Reversal wraps the node constructed by the
+// sub-operation into an object of the same type.
+//
+// Nested wrappers can be collapsed by nesting Unwrap calls, for example:
+//
+//	Unwrap("ExpressionStatement", "Value", Unwrap("Expression", "Value", Var("x")))
+func Unwrap(typ, field string, op Op) Op {
+	return &opUnwrap{typ: nodes.String(typ), field: field, op: op}
+}
+
+type opUnwrap struct {
+	typ   nodes.String
+	field string
+	op    Op
+}
+
+func (*opUnwrap) Kinds() nodes.Kind {
+	return nodes.KindObject
+}
+
+func (op *opUnwrap) Check(st *State, n nodes.Node) (bool, error) {
+	obj, ok := n.(nodes.Object)
+	if !ok {
+		return filtered("%+v is not an object\n%+v", n, op)
+	}
+	if typ, _ := obj[uast.KeyType].(nodes.String); typ != op.typ {
+		return filtered("expected type %q for %v, got: %q", op.typ, op, typ)
+	}
+	child, ok := obj[op.field]
+	if !ok || len(obj) != 2 {
+		return filtered("%v is not a wrapper for field %q", op.typ, op.field)
+	}
+	return op.op.Check(st, child)
+}
+
+func (op *opUnwrap) Construct(st *State, n nodes.Node) (nodes.Node, error) {
+	if err := noNode(n); err != nil {
+		return nil, err
+	}
+	child, err := op.op.Construct(st, nil)
+	if err != nil {
+		return nil, err
+	}
+	return nodes.Object{
+		uast.KeyType: op.typ,
+		op.field:     child,
+	}, nil
+}
+
+// Merge moves all fields of a child object stored in a given field into the parent object and passes the result
+// to a sub-operation. Reversal splits the object constructed by the sub-operation back into the parent and the child.
+//
+// The keys map contains names of all fields of the child object, mapped to field names in the merged object.
+// Mapped names must not collide with the fields of the parent, thus, for example, the type of the child should
+// be mapped to a new name. The child object must not have fields that are not listed in the map.
+func Merge(field string, keys map[string]string, op Op) Op {
+	return &opMerge{field: field, keys: keys, op: op}
+}
+
+type opMerge struct {
+	field string
+	keys  map[string]string // child field name -> merged field name
+	op    Op
+}
+
+func (*opMerge) Kinds() nodes.Kind {
+	return nodes.KindObject
+}
+
+func (op *opMerge) Check(st *State, n nodes.Node) (bool, error) {
+	obj, ok := n.(nodes.Object)
+	if !ok {
+		return filtered("%+v is not an object\n%+v", n, op)
+	}
+	child, ok := obj[op.field].(nodes.Object)
+	if !ok {
+		return filtered("field %q is not an object for %v", op.field, op)
+	}
+	merged := make(nodes.Object, len(obj)+len(child)-1)
+	for k, v := range obj {
+		if k != op.field {
+			merged[k] = v
+		}
+	}
+	for k, v := range child {
+		name, ok := op.keys[k]
+		if !ok {
+			return false, NewErrUnusedField(child, []string{k})
+		} else if _, ok = merged[name]; ok {
+			return false, ErrDuplicateField.New(name)
+		}
+		merged[name] = v
+	}
+	return op.op.Check(st, merged)
+}
+
+func (op *opMerge) Construct(st *State, n nodes.Node) (nodes.Node, error) {
+	if err := noNode(n); err != nil {
+		return nil, err
+	}
+	nn, err := op.op.Construct(st, nil)
+	if err != nil {
+		return nil, err
+	}
+	merged, ok := nn.(nodes.Object)
+	if !ok {
+		return nil, ErrExpectedObject.New(nn)
+	}
+	obj := merged.CloneObject()
+	if _, ok := obj[op.field]; ok {
+		return nil, ErrDuplicateField.New(op.field)
+	}
+	child := make(nodes.Object, len(op.keys))
+	for k, name := range op.keys {
+		if v, ok := obj[name]; ok {
+			child[k] = v
+			delete(obj, name)
+		}
+	}
+	obj[op.field] = child
+	return obj, nil
+}
@@ -1,6 +1,9 @@
 package transformer
 
 import (
+	"sort"
+	"strings"
+
 	"gopkg.in/bblfsh/sdk.v2/uast"
 	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
 	"gopkg.in/bblfsh/sdk.v2/uast/role"
@@ -55,6 +58,18 @@ type ObjectToNode struct {
 	ColumnKey string
 	// EndColumnKey is a key that indicates the column inside the line where the node ends.
 	EndColumnKey string
+	// TokenKeys is an ordered list of keys used in the native AST for the token of the node. The token is saved
+	// to uast.KeyToken. If the node has multiple token keys, the first one is used, unless TokenCombine is set.
+	TokenKeys []string
+	// TokenCombine is an optional function that builds a token of the node that has multiple token keys.
+	// It receives a map from the token key to its value.
+	TokenCombine func(tokens map[string]nodes.Value) (nodes.Value, error)
+	// Decoders is an optional map from the native key to a function that decodes its value. Decoders are applied
+	// before any other keys are processed, thus the value can be decoded to an object and referred by dotted paths
+	// in other keys. Keys of the map can be dotted paths as well.
+	//
+	// Decoders are not reversible: the decoded value is saved on reversal.
+	Decoders map[string]func(v nodes.Node) (nodes.Node, error)
 }
 
 // Mapping construct a transformation from ObjectToNode definition.
@@ -113,7 +128,246 @@ func (n ObjectToNode) Mapping() Mapping {
 	if len(normPos) != 0 {
 		norm[uast.KeyPos] = UASTType(uast.Positions{}, normPos)
 	}
-	return MapPart("other", MapObj(ast, norm))
+	var dst ObjectOp = norm
+	if len(n.TokenKeys) != 0 {
+		const (
+			vr  = "token"
+			vre = "token_exists"
+		)
+		tok := Field{Name: uast.KeyToken, Op: Var(vr), Optional: vre}
+		ast = append(ast, tok)
+		dst = append(norm.fields(), tok)
+	}
+	paths := n.paths()
+	if len(paths) == 0 && len(n.TokenKeys) == 0 && len(n.Decoders) == 0 {
+		return MapPart("other", MapObj(ast, dst))
+	}
+	var decoders []keyDecoder
+	for k, fnc := range n.Decoders {
+		decoders = append(decoders, keyDecoder{path: strings.Split(k, "."), fnc: fnc})
+	}
+	sort.Slice(decoders, func(i, j int) bool {
+		return strings.Join(decoders[i].path, ".") < strings.Join(decoders[j].path, ".")
+	})
+	return MapObj(
+		&opNativeObj{
+			op:    Part("other", ast),
+			paths: paths, decoders: decoders,
+			tokens: n.TokenKeys, combine: n.TokenCombine,
+		},
+		Part("other", dst),
+	)
+}
+
+// paths returns all keys that refer to nested fields.
+func (n ObjectToNode) paths() [][]string {
+	var out [][]string
+	keys := []string{
+		n.InternalTypeKey,
+		n.OffsetKey, n.EndOffsetKey,
+		n.LineKey, n.EndLineKey,
+		n.ColumnKey, n.EndColumnKey,
+	}
+	keys = append(keys, n.TokenKeys...)
+	for _, k := range keys {
+		if strings.Contains(k, ".") {
+			out = append(out, strings.Split(k, "."))
+		}
+	}
+	return out
+}
+
+// keyDecoder is a decoder for a value of a native field.
+type keyDecoder struct {
+	path []string
+	fnc  func(v nodes.Node) (nodes.Node, error)
+}
+
+// opNativeObj prepares a native object for the ObjectToNode mapping.
+//
+// Values of the fields with decoders are replaced with decoded values first.
+// Nested fields referred by dotted paths are moved to the root of the object, with the path used as a key.
+// Empty objects left after moving nested fields are removed. If token keys are set, a token is selected from
+// them and passed to the sub-operation as uast.KeyToken.
+//
+// Reversal saves the token to the first token key and moves nested fields back.
+type opNativeObj struct {
+	op       ObjectOp
+	paths    [][]string
+	decoders []keyDecoder
+	tokens   []string
+	combine  func(tokens map[string]nodes.Value) (nodes.Value, error)
+}
+
+func (op *opNativeObj) Kinds() nodes.Kind {
+	return nodes.KindObject
+}
+
+func (op *opNativeObj) Fields() (FieldDescs, bool) {
+	fields, _ := op.op.Fields()
+	fields = fields.Clone()
+	delete(fields, uast.KeyToken)
+	for _, k := range op.tokens {
+		fields[k] = FieldDesc{Optional: true}
+	}
+	for _, p := range op.paths {
+		delete(fields, strings.Join(p, "."))
+		fields[p[0]] = FieldDesc{Optional: true}
+	}
+	return fields, false
+}
+
+func (op *opNativeObj) Check(st *State, n nodes.Node) (bool, error) {
+	return checkObj(op, st, n)
+}
+
+func (op *opNativeObj) CheckObj(st *State, n nodes.Object) (bool, error) {
+	if len(op.paths) != 0 || len(op.decoders) != 0 {
+		n = n.CloneObject()
+		for _, d := range op.decoders {
+			v, ok := takePath(n, d.path)
+			if !ok {
+				continue
+			}
+			v, err := d.fnc(v)
+			if err != nil {
+				return false, errKey.Wrap(err, strings.Join(d.path, "."))
+			}
+			if err = putPath(n, d.path, v); err != nil {
+				return false, err
+			}
+		}
+		for _, p := range op.paths {
+			v, ok := takePath(n, p)
+			if ok {
+				n[strings.Join(p, ".")] = v
+			}
+		}
+	}
+	if len(op.tokens) == 0 {
+		return op.op.CheckObj(st, n)
+	}
+	var (
+		first  nodes.Node
+		tokens map[string]nodes.Value
+	)
+	for _, k := range op.tokens {
+		v, ok := n[k]
+		if !ok {
+			continue
+		}
+		if tokens == nil {
+			first = v
+			tokens = make(map[string]nodes.Value, len(op.tokens))
+		}
+		tv, ok := v.(nodes.Value)
+		if !ok {
+			return false, ErrExpectedValue.New(v)
+		}
+		tokens[k] = tv
+	}
+	if tokens == nil {
+		return op.op.CheckObj(st, n)
+	}
+	n = n.CloneObject()
+	for k := range tokens {
+		delete(n, k)
+	}
+	if _, ok := n[uast.KeyToken]; ok {
+		return false, ErrDuplicateField.New(uast.KeyToken)
+	}
+	if len(tokens) > 1 && op.combine != nil {
+		tok, err := op.combine(tokens)
+		if err != nil {
+			return false, err
+		}
+		first = tok
+	}
+	n[uast.KeyToken] = first
+	return op.op.CheckObj(st, n)
+}
+
+func (op *opNativeObj) Construct(st *State, n nodes.Node) (nodes.Node, error) {
+	return constructObj(op, st, n)
+}
+
+func (op *opNativeObj) ConstructObj(st *State, n nodes.Object) (nodes.Object, error) {
+	n, err := op.op.ConstructObj(st, n)
+	if err != nil {
+		return nil, err
+	}
+	if tok, ok := n[uast.KeyToken]; ok && len(op.tokens) != 0 {
+		delete(n, uast.KeyToken)
+		n[op.tokens[0]] = tok
+	}
+	for _, p := range op.paths {
+		k := strings.Join(p, ".")
+		v, ok := n[k]
+		if !ok {
+			continue
+		}
+		delete(n, k)
+		if err := putPath(n, p, v); err != nil {
+			return nil, err
+		}
+	}
+	return n, nil
+}
+
+// takePath removes a nested field from the object and returns its value. The path is a dotted key of ObjectToNode
+// (for example, "loc.start.line") split into fields. All objects on the path are cloned, and objects that become
+// empty are removed.
+func takePath(n nodes.Object, path []string) (nodes.Node, bool) {
+	v, ok := n[path[0]]
+	if !ok {
+		return nil, false
+	}
+	if len(path) == 1 {
+		delete(n, path[0])
+		return v, true
+	}
+	sub, ok := v.(nodes.Object)
+	if !ok {
+		return nil, false
+	}
+	sub = sub.CloneObject()
+	v, ok = takePath(sub, path[1:])
+	if !ok {
+		return nil, false
+	}
+	if len(sub) == 0 {
+		delete(n, path[0])
+	} else {
+		n[path[0]] = sub
+	}
+	return v, true
+}
+
+// putPath sets a nested field of the object, creating objects on the path if necessary. The path is the same as
+// for takePath. Existing objects on the path are cloned.
+func putPath(n nodes.Object, path []string, v nodes.Node) error {
+	if len(path) == 1 {
+		if _, ok := n[path[0]]; ok {
+			return ErrDuplicateField.New(path[0])
+		}
+		n[path[0]] = v
+		return nil
+	}
+	var sub nodes.Object
+	if cur, ok := n[path[0]]; ok {
+		obj, ok := cur.(nodes.Object)
+		if !ok {
+			return ErrExpectedObject.New(cur)
+		}
+		sub = obj.CloneObject()
+	} else {
+		sub = make(nodes.Object)
+	}
+	if err := putPath(sub, path[1:], v); err != nil {
+		return err
+	}
+	n[path[0]] = sub
+	return nil
 }
 
 // RolesDedup is an irreversible transformation that removes duplicate roles from AST nodes.
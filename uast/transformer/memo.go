@@ -0,0 +1,172 @@
+package transformer
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"sync"
+
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+// NewCache creates a cache of transformed subtrees that can store up to max objects.
+// If max is zero or negative, the size of the cache is not limited.
+func NewCache(max int) *Cache {
+	return &Cache{max: max, m: make(map[nodes.Hash]nodes.Node)}
+}
+
+// Cache stores transformed subtrees by the hash of the source subtree. See Memoize.
+//
+// It is safe to use the same cache from multiple goroutines.
+type Cache struct {
+	max int
+
+	mu   sync.RWMutex
+	m    map[nodes.Hash]nodes.Node
+	hits uint64
+}
+
+func (c *Cache) get(h nodes.Hash) (nodes.Node, bool) {
+	c.mu.RLock()
+	n, ok := c.m[h]
+	c.mu.RUnlock()
+	if ok {
+		c.mu.Lock()
+		c.hits++
+		c.mu.Unlock()
+	}
+	return n, ok
+}
+
+func (c *Cache) put(h nodes.Hash, n nodes.Node) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.max > 0 && len(c.m) >= c.max {
+		// simple eviction policy: drop everything
+		c.m = make(map[nodes.Hash]nodes.Node)
+	}
+	c.m[h] = n
+}
+
+// Len returns the number of subtrees in the cache.
+func (c *Cache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.m)
+}
+
+// Hits returns the number of cache hits.
+func (c *Cache) Hits() uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.hits
+}
+
+// Memoize caches the results of the transformation for each object subtree. If the same subtree is seen again,
+// in the same tree or in a different one, the cached result is used instead of running the transformation.
+//
+// The cache must not be shared between different transformers. Cached subtrees are shared between results,
+// thus they must not be modified in place.
+//
+// Memoization changes the semantic of rules that depend on the node context (Parent, PrevSibling, etc), since only
+// the subtree is used as a key. Such rules should not be memoized.
+//
+// Only transformers created with Mappings, TransformFunc or TransformObjFunc can be memoized. Other transformers
+// are returned as-is.
+func Memoize(t Transformer, cache *Cache) Transformer {
+	st, ok := t.(subtreeTransformer)
+	if !ok || cache == nil {
+		return t
+	}
+	return &memoized{t: st, c: cache}
+}
+
+type memoized struct {
+	t subtreeTransformer
+	c *Cache
+}
+
+// hashTree stores hashes for all subtrees of a specific node.
+type hashTree struct {
+	hash nodes.Hash
+	sub  []hashTree // by sorted object keys or array index
+}
+
+func hashSubtrees(n nodes.Node) hashTree {
+	switch n := n.(type) {
+	case nodes.Object:
+		keys := n.Keys()
+		t := hashTree{sub: make([]hashTree, len(keys))}
+		h := sha256.New()
+		var buf [4]byte
+		binary.LittleEndian.PutUint32(buf[:], uint32(nodes.KindObject))
+		h.Write(buf[:])
+		for i, k := range keys {
+			t.sub[i] = hashSubtrees(n[k])
+			binary.LittleEndian.PutUint32(buf[:], uint32(len(k)))
+			h.Write(buf[:])
+			h.Write([]byte(k))
+			h.Write(t.sub[i].hash[:])
+		}
+		h.Sum(t.hash[:0])
+		return t
+	case nodes.Array:
+		t := hashTree{sub: make([]hashTree, len(n))}
+		h := sha256.New()
+		var buf [4]byte
+		binary.LittleEndian.PutUint32(buf[:], uint32(nodes.KindArray))
+		h.Write(buf[:])
+		for i, e := range n {
+			t.sub[i] = hashSubtrees(e)
+			h.Write(t.sub[i].hash[:])
+		}
+		h.Sum(t.hash[:0])
+		return t
+	}
+	return hashTree{hash: nodes.HashOf(n)}
+}
+
+func (m *memoized) Do(root nodes.Node) (nodes.Node, error) {
+	return m.do(nil, root, hashSubtrees(root))
+}
+
+func (m *memoized) do(ctx *nodeContext, n nodes.Node, h hashTree) (nodes.Node, error) {
+	var errs []error
+	switch nd := n.(type) {
+	case nodes.Object:
+		if out, ok := m.c.get(h.hash); ok {
+			return out, nil
+		}
+		keys := nd.Keys()
+		sub := &nodeContext{up: ctx, parent: nd, index: -1}
+		nn := make(nodes.Object, len(nd))
+		for i, k := range keys {
+			v, err := m.do(sub, nd[k], h.sub[i])
+			if err != nil {
+				errs = append(errs, err)
+			}
+			nn[k] = v
+		}
+		n = nn
+	case nodes.Array:
+		nn := make(nodes.Array, len(nd))
+		for i, v := range nd {
+			sub := &nodeContext{up: ctx, parent: nd, index: i}
+			v, err := m.do(sub, v, h.sub[i])
+			if err != nil {
+				errs = append(errs, err)
+			}
+			nn[i] = v
+		}
+		n = nn
+	case nil:
+		return nil, nil
+	}
+	out, err := m.t.doNode(ctx, n)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	if _, ok := n.(nodes.Object); ok && len(errs) == 0 {
+		m.c.put(h.hash, out)
+	}
+	return out, NewMultiError(errs...)
+}
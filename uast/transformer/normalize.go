@@ -0,0 +1,185 @@
+package transformer
+
+import (
+	"gopkg.in/bblfsh/sdk.v2/uast"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+	"gopkg.in/bblfsh/sdk.v2/uast/role"
+)
+
+// This file contains reusable normalization passes for idioms common to many languages.
+// All passes in this file are lossy and cannot be reversed.
+
+var (
+	typeString     = nodes.String(uast.TypeOf(uast.String{}))
+	typeIdent      = nodes.String(uast.TypeOf(uast.Identifier{}))
+	typeQualIdent  = nodes.String(uast.TypeOf(uast.QualifiedIdentifier{}))
+	typeArgument   = nodes.String(uast.TypeOf(uast.Argument{}))
+	typeOfOperator = nodes.String(uast.TypeOperator)
+)
+
+// withPos sets positions of an object, if they are defined.
+func withPos(obj nodes.Object, pos nodes.Node) nodes.Object {
+	if pos != nil {
+		obj[uast.KeyPos] = pos
+	}
+	return obj
+}
+
+// FoldStringConcat returns a transformer that folds chains of binary concatenation expressions of string literals
+// into a single uast:String node. The expression is a native node of type typ with an operator stored in the opField
+// and operands stored in the left and right fields. The operator can either be a plain value, or a uast:Operator node.
+//
+// The literals are only merged if they have the same format.
+// It should be executed after string literals are converted to uast:String.
+func FoldStringConcat(typ, opField, op, left, right string) Transformer {
+	return TransformObjFunc(func(n nodes.Object) (nodes.Object, bool, error) {
+		if n[uast.KeyType] != nodes.String(typ) || !isOperator(n[opField], op) {
+			return n, false, nil
+		}
+		l, ok1 := n[left].(nodes.Object)
+		r, ok2 := n[right].(nodes.Object)
+		if !ok1 || !ok2 || l[uast.KeyType] != typeString || r[uast.KeyType] != typeString {
+			return n, false, nil
+		}
+		lv, ok1 := l["Value"].(nodes.String)
+		rv, ok2 := r["Value"].(nodes.String)
+		if !ok1 || !ok2 || !nodes.Equal(l["Format"], r["Format"]) {
+			return n, false, nil
+		}
+		out := nodes.Object{
+			uast.KeyType: typeString,
+			"Value":      lv + rv,
+			"Format":     l["Format"],
+		}
+		return withPos(out, n[uast.KeyPos]), true, nil
+	})
+}
+
+// isOperator checks if the node is a given operator token, or a uast:Operator node with the token.
+func isOperator(n nodes.Node, tok string) bool {
+	if obj, ok := n.(nodes.Object); ok && obj[uast.KeyType] == typeOfOperator {
+		n = obj[uast.KeyToken]
+	}
+	return n == nodes.String(tok)
+}
+
+// FoldMemberAccess returns a transformer that converts chains of member access expressions that consist only of
+// identifiers (for example "a.b.c") into a single uast:QualifiedIdentifier node. The expression is a native node
+// of type typ that stores an object in the left field and a member name in the right field.
+//
+// It should be executed after identifiers are converted to uast:Identifier.
+func FoldMemberAccess(typ, left, right string) Transformer {
+	return TransformObjFunc(func(n nodes.Object) (nodes.Object, bool, error) {
+		if n[uast.KeyType] != nodes.String(typ) {
+			return n, false, nil
+		}
+		l, ok1 := n[left].(nodes.Object)
+		r, ok2 := n[right].(nodes.Object)
+		if !ok1 || !ok2 || r[uast.KeyType] != typeIdent {
+			return n, false, nil
+		}
+		var names nodes.Array
+		switch l[uast.KeyType] {
+		case typeIdent:
+			names = nodes.Array{l, r}
+		case typeQualIdent:
+			arr, ok := l["Names"].(nodes.Array)
+			if !ok {
+				return n, false, nil
+			}
+			names = append(arr.CloneList(), r)
+		default:
+			return n, false, nil
+		}
+		out := nodes.Object{
+			uast.KeyType: typeQualIdent,
+			"Names":      names,
+		}
+		return withPos(out, n[uast.KeyPos]), true, nil
+	})
+}
+
+// ExpandArguments returns a transformer that splits native argument declarations that define multiple names with
+// a shared type (for example "a, b int") into separate uast:Argument nodes. The declaration is a native node of type
+// typ that stores an array of names in the names field and an optional argument type in the argType field.
+//
+// The transformer expands declarations in any array, thus it works for both arguments and returns of a function.
+// Position of the declaration is preserved only if it defines a single name.
+func ExpandArguments(typ, names, argType string) Transformer {
+	return TransformFunc(func(n nodes.Node) (nodes.Node, bool, error) {
+		arr, ok := n.(nodes.Array)
+		if !ok {
+			return n, false, nil
+		}
+		var out nodes.Array
+		for i, e := range arr {
+			obj, ok := e.(nodes.Object)
+			if !ok || obj[uast.KeyType] != nodes.String(typ) {
+				if out != nil {
+					out = append(out, e)
+				}
+				continue
+			}
+			list, ok := obj[names].(nodes.Array)
+			if !ok {
+				return n, false, ErrExpectedList.New(obj[names])
+			}
+			if out == nil {
+				out = append(nodes.Array{}, arr[:i]...)
+			}
+			for _, name := range list {
+				arg := newArgument(name, obj[argType])
+				if len(list) == 1 {
+					arg = withPos(arg, obj[uast.KeyPos])
+				}
+				out = append(out, arg)
+			}
+		}
+		if out == nil {
+			return n, false, nil
+		}
+		return out, true, nil
+	})
+}
+
+// newArgument creates a uast:Argument node with a given name and type.
+func newArgument(name, typ nodes.Node) nodes.Object {
+	if typ != nil {
+		typ = typ.Clone()
+	}
+	return nodes.Object{
+		uast.KeyType:  typeArgument,
+		"Name":        name,
+		"Type":        typ,
+		"Init":        nil,
+		"Variadic":    nodes.Bool(false),
+		"MapVariadic": nodes.Bool(false),
+		"Receiver":    nodes.Bool(false),
+	}
+}
+
+// CLikeOperators returns a map of operator tokens common for C-like languages to their roles.
+// Drivers may extend the map and use it with StringToRolesMap and Operator.
+func CLikeOperators() map[string][]role.Role {
+	return map[string][]role.Role{
+		"+":  {role.Arithmetic, role.Add},
+		"-":  {role.Arithmetic, role.Substract},
+		"*":  {role.Arithmetic, role.Multiply},
+		"/":  {role.Arithmetic, role.Divide},
+		"%":  {role.Arithmetic, role.Modulo},
+		"&":  {role.Bitwise, role.And},
+		"|":  {role.Bitwise, role.Or},
+		"^":  {role.Bitwise, role.Xor},
+		"<<": {role.Bitwise, role.LeftShift},
+		">>": {role.Bitwise, role.RightShift},
+		"&&": {role.Boolean, role.And},
+		"||": {role.Boolean, role.Or},
+		"!":  {role.Boolean, role.Not},
+		"==": {role.Relational, role.Equal},
+		"!=": {role.Relational, role.Equal, role.Not},
+		"<":  {role.Relational, role.LessThan},
+		"<=": {role.Relational, role.LessThanOrEqual},
+		">":  {role.Relational, role.GreaterThan},
+		">=": {role.Relational, role.GreaterThanOrEqual},
+	}
+}
@@ -0,0 +1,111 @@
+package transformer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"gopkg.in/bblfsh/sdk.v2/uast"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+	"gopkg.in/bblfsh/sdk.v2/uast/role"
+)
+
+// noPos removes empty positions added by uast.ToNode.
+func noPos(n nodes.Node) nodes.Node {
+	n = n.Clone()
+	nodes.WalkPreOrder(n, func(n nodes.Node) bool {
+		if obj, ok := n.(nodes.Object); ok {
+			delete(obj, uast.KeyPos)
+		}
+		return true
+	})
+	return n
+}
+
+func binExpr(op string, l, r nodes.Node) nodes.Object {
+	return nodes.Object{
+		uast.KeyType: nodes.String("BinExpr"),
+		"op":         nodes.String(op),
+		"left":       l,
+		"right":      r,
+	}
+}
+
+func TestFoldStringConcat(t *testing.T) {
+	str := func(s string) nodes.Node {
+		return noPos(toNode(uast.String{Value: s}))
+	}
+	ident := noPos(toNode(uast.Identifier{Name: "x"}))
+
+	tr := FoldStringConcat("BinExpr", "op", "+", "left", "right")
+	out, err := tr.Do(nodes.Array{
+		binExpr("+", binExpr("+", str("a"), str("b")), str("c")),
+		binExpr("+", ident, str("a")),
+		binExpr("-", str("a"), str("b")),
+	})
+	require.NoError(t, err)
+	require.Equal(t, nodes.Array{
+		str("abc"),
+		binExpr("+", ident, str("a")),
+		binExpr("-", str("a"), str("b")),
+	}, out)
+}
+
+func TestFoldMemberAccess(t *testing.T) {
+	ident := func(s string) uast.Identifier {
+		return uast.Identifier{Name: s}
+	}
+	sel := func(l, r nodes.Node) nodes.Node {
+		return nodes.Object{
+			uast.KeyType: nodes.String("Selector"),
+			"x":          l,
+			"sel":        r,
+		}
+	}
+	call := nodes.Object{uast.KeyType: nodes.String("Call")}
+
+	tr := FoldMemberAccess("Selector", "x", "sel")
+	out, err := tr.Do(nodes.Array{
+		sel(sel(noPos(toNode(ident("a"))), noPos(toNode(ident("b")))), noPos(toNode(ident("c")))),
+		sel(call, noPos(toNode(ident("c")))),
+	})
+	require.NoError(t, err)
+	require.Equal(t, nodes.Array{
+		noPos(toNode(uast.QualifiedIdentifier{Names: []uast.Identifier{ident("a"), ident("b"), ident("c")}})),
+		sel(call, noPos(toNode(ident("c")))),
+	}, out)
+}
+
+func TestExpandArguments(t *testing.T) {
+	ident := func(s string) *uast.Identifier {
+		return &uast.Identifier{Name: s}
+	}
+	typ := noPos(toNode(ident("int")))
+	decl := func(names ...string) nodes.Node {
+		arr := make(nodes.Array, 0, len(names))
+		for _, name := range names {
+			arr = append(arr, noPos(toNode(ident(name))))
+		}
+		return nodes.Object{
+			uast.KeyType: nodes.String("Field"),
+			"names":      arr,
+			"type":       typ,
+		}
+	}
+	tr := ExpandArguments("Field", "names", "type")
+	out, err := tr.Do(nodes.Array{decl("a", "b"), decl("c")})
+	require.NoError(t, err)
+	require.Equal(t, noPos(toNode([]uast.Argument{
+		{Name: ident("a"), Type: uast.Identifier{Name: "int"}},
+		{Name: ident("b"), Type: uast.Identifier{Name: "int"}},
+		{Name: ident("c"), Type: uast.Identifier{Name: "int"}},
+	})), out)
+}
+
+func TestCLikeOperators(t *testing.T) {
+	ops := CLikeOperators()
+	require.Equal(t, []role.Role{role.Arithmetic, role.Add}, ops["+"])
+	for tok, roles := range ops {
+		require.NotEmpty(t, roles, tok)
+	}
+}
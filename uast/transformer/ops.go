@@ -669,7 +669,7 @@ func (op opObjScope) Fields() (FieldDescs, bool) {
 }
 
 func (op opObjScope) Check(st *State, n nodes.Node) (bool, error) {
-	sub := NewState()
+	sub := st.newSub()
 	if ok, err := op.op.Check(sub, n); err != nil || !ok {
 		return false, err
 	}
@@ -680,7 +680,7 @@ func (op opObjScope) Check(st *State, n nodes.Node) (bool, error) {
 }
 
 func (op opObjScope) CheckObj(st *State, n nodes.Object) (bool, error) {
-	sub := NewState()
+	sub := st.newSub()
 	if ok, err := op.op.CheckObj(sub, n); err != nil || !ok {
 		return false, err
 	}
@@ -722,7 +722,7 @@ func (op opScope) Kinds() nodes.Kind {
 }
 
 func (op opScope) Check(st *State, n nodes.Node) (bool, error) {
-	sub := NewState()
+	sub := st.newSub()
 	if ok, err := op.op.Check(sub, n); err != nil || !ok {
 		return false, err
 	}
@@ -912,7 +912,23 @@ func TypedObj(typ string, ops map[string]Op) Op {
 // op and will assign it to the current node.
 // Since reversal transformation needs to build a reverse map,
 // the mapping should not be ambiguous in reverse direction (no duplicate values).
+//
+// A nil key can be used to set a default value for all values that are not in the map.
+// Reversal of the default value will create a nil node, thus the transformation is lossy.
 func Lookup(op Op, m map[nodes.Value]nodes.Value) Op {
+	return newLookup(op, m, false)
+}
+
+// LookupWarn is similar to Lookup, but values that are not in the map are passed to op unchanged and reported
+// as warnings instead of failing the transformation. If the map has a nil key, its value is used instead.
+// See State.Warn for details.
+//
+// It is useful for enumerations that may get new values in new versions of the language.
+func LookupWarn(op Op, m map[nodes.Value]nodes.Value) Op {
+	return newLookup(op, m, true)
+}
+
+func newLookup(op Op, m map[nodes.Value]nodes.Value, warn bool) Op {
 	rev := make(map[nodes.Value]nodes.Value, len(m))
 	for k, v := range m {
 		if _, ok := rev[v]; ok {
@@ -920,12 +936,13 @@ func Lookup(op Op, m map[nodes.Value]nodes.Value) Op {
 		}
 		rev[v] = k
 	}
-	return &opLookup{op: op, fwd: m, rev: rev}
+	return &opLookup{op: op, fwd: m, rev: rev, warn: warn}
 }
 
 type opLookup struct {
 	op       Op
 	fwd, rev map[nodes.Value]nodes.Value
+	warn     bool // pass unknown values unchanged and report them as warnings
 }
 
 func (*opLookup) Kinds() nodes.Kind {
@@ -939,7 +956,16 @@ func (op *opLookup) Check(st *State, n nodes.Node) (bool, error) {
 	}
 	vn, ok := op.fwd[v]
 	if !ok {
-		return false, ErrUnhandledValueIn.New(v, op.fwd)
+		def, hasDef := op.fwd[nil]
+		if !op.warn && !hasDef {
+			return false, ErrUnhandledValueIn.New(v, op.fwd)
+		} else if op.warn {
+			st.Warn(ErrUnhandledValueIn.New(v, op.fwd))
+		}
+		vn = v
+		if hasDef {
+			vn = def
+		}
 	}
 	return op.op.Check(st, vn)
 }
@@ -958,7 +984,11 @@ func (op *opLookup) Construct(st *State, n nodes.Node) (nodes.Node, error) {
 	}
 	vn, ok := op.rev[v]
 	if !ok {
-		return nil, ErrUnhandledValueIn.New(v, op.rev)
+		if !op.warn {
+			return nil, ErrUnhandledValueIn.New(v, op.rev)
+		}
+		st.Warn(ErrUnhandledValueIn.New(v, op.rev))
+		vn = v
 	}
 	return vn, nil
 }
@@ -1235,6 +1265,57 @@ func (op *opOptional) Construct(st *State, n nodes.Node) (nodes.Node, error) {
 	return op.op.Construct(st, n)
 }
 
+// Maybe is an optional operation that accepts a nil node or a node matching a sub-operation.
+// Unlike Opt, it does not use a variable to store the state. Instead, reversal will create a nil node
+// if none of the variables of the sub-operation are defined. Other errors of the sub-operation, including
+// undefined variables of nested operations, are returned as-is.
+//
+// Variables of some operations (Each, Lookup, etc) cannot be determined in advance. If the sub-operation contains
+// them, reversal will create a nil node if the sub-operation fails because of an undefined variable.
+func Maybe(op Op) Op {
+	v := collectVars(op)
+	vars := make([]string, 0, len(v.vars))
+	for name := range v.vars {
+		vars = append(vars, name)
+	}
+	return &opMaybe{op: op, vars: vars, opaque: v.opaque}
+}
+
+type opMaybe struct {
+	op     Op
+	vars   []string // variables of the sub-operation
+	opaque bool     // the sub-operation may use variables not listed in vars
+}
+
+func (op *opMaybe) Kinds() nodes.Kind {
+	return nodes.KindNil | op.op.Kinds()
+}
+
+func (op *opMaybe) Check(st *State, n nodes.Node) (bool, error) {
+	if n == nil {
+		return true, nil
+	}
+	return op.op.Check(st, n)
+}
+
+func (op *opMaybe) Construct(st *State, n nodes.Node) (nodes.Node, error) {
+	for _, name := range op.vars {
+		if _, ok := st.GetVar(name); ok {
+			return op.op.Construct(st, n)
+		}
+	}
+	if op.opaque {
+		nd, err := op.op.Construct(st, n)
+		if ErrVariableNotDefined.Is(err) {
+			return nil, nil
+		}
+		return nd, err
+	} else if len(op.vars) == 0 {
+		return op.op.Construct(st, n)
+	}
+	return nil, nil
+}
+
 // Check tests first check-only operation before applying the main op. It won't use the check-only argument for Construct.
 // The check-only operation will not be able to set any variables or change state by other means.
 func Check(s Sel, op Op) Op {
@@ -1,6 +1,7 @@
 package transformer
 
 import (
+	"math"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -100,6 +101,30 @@ var opsCases = []struct {
 		dst: Obj{"v2": Var("x")},
 		exp: arrObjStr("v2", "A"),
 	},
+	{
+		name: "lookup default",
+		inp:  arrObjInt("v", 2),
+		src: Obj{
+			"v": LookupVar("x", map[un.Value]un.Value{
+				un.Int(1): un.String("A"),
+				nil:       un.String("?"),
+			}),
+		},
+		dst:   Obj{"v2": Var("x")},
+		exp:   arrObjStr("v2", "?"),
+		noRev: true, // lossy
+	},
+	{
+		name: "lookup unknown",
+		inp:  arrObjInt("v", 2),
+		src: Obj{
+			"v": LookupWarn(Var("x"), map[un.Value]un.Value{
+				un.Int(1): un.String("A"),
+			}),
+		},
+		dst: Obj{"v2": Var("x")},
+		exp: arrObjInt("v2", 2),
+	},
 	{
 		name: "no var",
 		inp:  arrObjInt("v", 1),
@@ -815,6 +840,301 @@ var opsCases = []struct {
 			}
 		},
 	},
+	{
+		name: "add",
+		inp:  arrObjInt("line", 0),
+		src:  Obj{"line": Add(Var("line"), 1)},
+		dst:  Obj{"line": Var("line")},
+		exp:  arrObjInt("line", 1),
+	},
+	{
+		name: "sum",
+		inp:  arrObjVal2("start", "len", un.Int(3), un.Int(5)),
+		src: Fields{
+			{Name: "start", Op: Var("start")},
+			{Name: "len", Op: Var("len")},
+		},
+		dst: Fields{
+			{Name: "start", Op: Var("start")},
+			{Name: "end", Op: Sum("start", "len")},
+		},
+		exp: arrObjVal2("start", "end", un.Int(3), un.Int(8)),
+	},
+	{
+		name: "diff",
+		inp:  arrObjVal2("end", "len", un.Uint(8), un.Uint(5)),
+		src: Fields{
+			{Name: "end", Op: Var("end")},
+			{Name: "len", Op: Var("len")},
+		},
+		dst: Fields{
+			{Name: "end", Op: Var("end")},
+			{Name: "start", Op: Diff("end", "len")},
+		},
+		exp: arrObjVal2("end", "start", un.Uint(8), un.Uint(3)),
+	},
+	{
+		name: "sum no vars",
+		inp:  arrObjInt("end", 8),
+		src:  Obj{"end": Sum("start", "len")},
+		dst:  Obj{"end": Sum("start", "len")},
+		err:  ErrVariableNotDefined,
+	},
+	{
+		name: "trim",
+		inp:  arrObjStr("v", "'a'"),
+		src:  Obj{"v": Trim(Var("x"), "'", "'")},
+		dst:  Obj{"v": Var("x")},
+		exp:  arrObjStr("v", "a"),
+	},
+	{
+		name:  "trim no match",
+		inp:   arrObjStr("v", "a"),
+		src:   Obj{"v": Trim(Var("x"), "'", "'")},
+		dst:   Obj{"v": Var("x")},
+		exp:   arrObjStr("v", "a"),
+		noRev: true,
+	},
+	{
+		name: "trim space",
+		inp:  arrObjStr("v", " \ta \n"),
+		src:  Obj{"v": TrimSpace("l", "r", Var("x"))},
+		dst: Obj{
+			"v": Var("x"),
+			"l": Var("l"),
+			"r": Var("r"),
+		},
+		exp: func() un.Node {
+			return un.Array{
+				un.Object{"v": un.String("a"), "l": un.String(" \t"), "r": un.String(" \n")},
+			}
+		},
+	},
+	{
+		name: "lower",
+		inp:  arrObjStr("v", "BEGIN"),
+		src:  Obj{"v": Lower("orig", Var("x"))},
+		dst:  Obj{"v": Var("x"), "orig": Var("orig")},
+		exp: func() un.Node {
+			return un.Array{
+				un.Object{"v": un.String("begin"), "orig": un.String("BEGIN")},
+			}
+		},
+	},
+	{
+		name: "upper",
+		inp:  arrObjStr("v", "Begin"),
+		src:  Obj{"v": Upper("orig", Var("x"))},
+		dst:  Obj{"v": Var("x"), "orig": Var("orig")},
+		exp: func() un.Node {
+			return un.Array{
+				un.Object{"v": un.String("BEGIN"), "orig": un.String("Begin")},
+			}
+		},
+	},
+	{
+		name: "split",
+		inp:  arrObjStr("v", "a.b.c"),
+		src:  Obj{"v": Split(".", Var("x"))},
+		dst:  Obj{"v": Var("x")},
+		exp: func() un.Node {
+			return un.Array{
+				un.Object{"v": un.Array{un.String("a"), un.String("b"), un.String("c")}},
+			}
+		},
+	},
+	{
+		name: "join",
+		inp: func() un.Node {
+			return un.Array{
+				un.Object{"v": un.Array{un.String("a"), un.String("b")}},
+			}
+		},
+		src: Obj{"v": Join("::", Var("x"))},
+		dst: Obj{"v": Var("x")},
+		exp: arrObjStr("v", "a::b"),
+	},
+	{
+		name: "maybe",
+		inp: func() un.Node {
+			return un.Array{
+				un.Object{"v": un.Object{"x": un.Int(1)}},
+				un.Object{"v": nil},
+			}
+		},
+		src: Obj{"v": Maybe(Obj{"x": Var("x")})},
+		dst: Obj{"v2": Maybe(Var("x"))},
+		exp: func() un.Node {
+			return un.Array{
+				un.Object{"v2": un.Int(1)},
+				un.Object{"v2": nil},
+			}
+		},
+	},
+	{
+		name: "maybe partial",
+		inp: func() un.Node {
+			return un.Array{un.Object{"v": un.Object{"x": un.Int(1)}}}
+		},
+		src: Obj{"v": Maybe(Obj{"x": Var("x")})},
+		dst: Obj{"v2": Maybe(Obj{"x": Var("x"), "y": Var("y")})},
+		err: ErrVariableNotDefined,
+	},
+	{
+		name: "maybe each",
+		inp: func() un.Node {
+			return un.Array{
+				un.Object{"v": un.Array{un.Object{"x": un.Int(1)}}},
+				un.Object{"v": nil},
+			}
+		},
+		src: Obj{"v": Maybe(Each("arr", Obj{"x": Var("x")}))},
+		dst: Obj{"v2": Maybe(Each("arr", Var("x")))},
+		exp: func() un.Node {
+			return un.Array{
+				un.Object{"v2": un.Array{un.Int(1)}},
+				un.Object{"v2": nil},
+			}
+		},
+	},
+	{
+		name: "repeat",
+		inp: func() un.Node {
+			return un.Array{
+				un.Object{"v": un.Array{un.Object{"x": un.Int(1)}}},
+				un.Object{"v": un.Array{un.Object{"x": un.Int(1)}, un.Object{"x": un.Int(2)}}},
+				un.Object{"v": un.Array{}},
+				un.Object{"v": un.Array{un.Object{"x": un.Int(1)}, un.Object{"x": un.Int(2)}, un.Object{"x": un.Int(3)}}},
+			}
+		},
+		src: Obj{"v": Repeat("arr", 1, 2, Obj{"x": Var("x")})},
+		dst: Obj{"v2": Repeat("arr", 1, 2, Var("x"))},
+		exp: func() un.Node {
+			return un.Array{
+				un.Object{"v2": un.Array{un.Int(1)}},
+				un.Object{"v2": un.Array{un.Int(1), un.Int(2)}},
+				un.Object{"v": un.Array{}},
+				un.Object{"v": un.Array{un.Object{"x": un.Int(1)}, un.Object{"x": un.Int(2)}, un.Object{"x": un.Int(3)}}},
+			}
+		},
+	},
+	{
+		name: "unwrap",
+		inp: func() un.Node {
+			return un.Array{
+				un.Object{"v": un.Object{
+					u.KeyType: un.String("Stmt"),
+					"x": un.Object{
+						u.KeyType: un.String("Expr"),
+						"x":       un.Int(1),
+					},
+				}},
+				un.Object{"v": un.Object{
+					u.KeyType: un.String("Stmt"),
+					"x":       un.Int(2),
+					"y":       un.Int(3),
+				}},
+			}
+		},
+		src: Obj{"v": Unwrap("Stmt", "x", Unwrap("Expr", "x", Var("x")))},
+		dst: Obj{"v2": Var("x")},
+		exp: func() un.Node {
+			return un.Array{
+				un.Object{"v2": un.Int(1)},
+				un.Object{"v": un.Object{
+					u.KeyType: un.String("Stmt"),
+					"x":       un.Int(2),
+					"y":       un.Int(3),
+				}},
+			}
+		},
+	},
+	{
+		name: "merge",
+		inp: func() un.Node {
+			return un.Array{
+				un.Object{"v": un.Object{
+					u.KeyType: un.String("Stmt"),
+					"pos":     un.Int(1),
+					"expr": un.Object{
+						u.KeyType: un.String("Expr"),
+						"value":   un.Int(2),
+					},
+				}},
+			}
+		},
+		src: Obj{"v": Merge("expr", map[string]string{
+			u.KeyType: "expr_type",
+			"value":   "value",
+		}, Var("x"))},
+		dst: Obj{"v": Var("x")},
+		exp: func() un.Node {
+			return un.Array{
+				un.Object{"v": un.Object{
+					u.KeyType:   un.String("Stmt"),
+					"pos":       un.Int(1),
+					"expr_type": un.String("Expr"),
+					"value":     un.Int(2),
+				}},
+			}
+		},
+	},
+	{
+		name: "merge collision",
+		inp: func() un.Node {
+			return un.Array{
+				un.Object{"v": un.Object{
+					"value": un.Int(1),
+					"expr":  un.Object{"value": un.Int(2)},
+				}},
+			}
+		},
+		src: Obj{"v": Merge("expr", map[string]string{"value": "value"}, Var("x"))},
+		dst: Obj{"v": Var("x")},
+		err: ErrDuplicateField,
+	},
+	{
+		name: "span pos",
+		inp: func() un.Node {
+			return un.Array{
+				un.Object{"a": posIdent("a", 1, 2), "b": posIdent("b", 5, 8)},
+				un.Object{"a": un.Int(1), "b": un.Int(2)},
+			}
+		},
+		src: Obj{"a": Var("a"), "b": Var("b")},
+		dst: SpanPos(Obj{
+			u.KeyType: String("Group"),
+			"Nodes":   Arr(Var("a"), Var("b")),
+		}),
+		exp: func() un.Node {
+			return un.Array{
+				un.Object{
+					u.KeyType: un.String("Group"),
+					u.KeyPos: u.Positions{
+						u.KeyStart: {Offset: 1, Line: 1, Col: 2},
+						u.KeyEnd:   {Offset: 8, Line: 1, Col: 9},
+					}.ToObject(),
+					"Nodes": un.Array{posIdent("a", 1, 2), posIdent("b", 5, 8)},
+				},
+				un.Object{
+					u.KeyType: un.String("Group"),
+					"Nodes":   un.Array{un.Int(1), un.Int(2)},
+				},
+			}
+		},
+	},
+}
+
+// posIdent creates an identifier node with positions on the first line.
+func posIdent(name string, start, end uint32) un.Node {
+	return un.Object{
+		u.KeyType: un.String("Ident"),
+		u.KeyPos: u.Positions{
+			u.KeyStart: {Offset: start, Line: 1, Col: start + 1},
+			u.KeyEnd:   {Offset: end, Line: 1, Col: end + 1},
+		}.ToObject(),
+		"name": un.String(name),
+	}
 }
 
 func TestOps(t *testing.T) {
@@ -863,3 +1183,67 @@ func TestOps(t *testing.T) {
 		})
 	}
 }
+
+var numAddCases = []struct {
+	name string
+	a, b un.Value
+	exp  un.Value
+	err  *errors.Kind
+}{
+	{name: "int+float", a: un.Int(1), b: un.Float(0.5), exp: un.Float(1.5)},
+	{name: "float+int", a: un.Float(0.5), b: un.Int(1), exp: un.Float(1.5)},
+	{name: "uint+float", a: un.Uint(1), b: un.Float(0.5), exp: un.Float(1.5)},
+	{name: "int+uint", a: un.Int(-1), b: un.Uint(2), exp: un.Int(1)},
+	{name: "int+big uint", a: un.Int(0), b: un.Uint(math.MaxUint64), err: ErrUnexpectedValue},
+	{name: "uint+int", a: un.Uint(2), b: un.Int(-1), exp: un.Uint(1)},
+	{name: "big uint+int", a: un.Uint(math.MaxUint64), b: un.Int(-1), exp: un.Uint(math.MaxUint64 - 1)},
+	{name: "uint+min int", a: un.Uint(1 << 63), b: un.Int(math.MinInt64), exp: un.Uint(0)},
+	{name: "uint underflow", a: un.Uint(1), b: un.Int(-2), err: ErrUnexpectedValue},
+	{name: "uint overflow", a: un.Uint(math.MaxUint64), b: un.Uint(1), err: ErrUnexpectedValue},
+	{name: "uint+int overflow", a: un.Uint(math.MaxUint64), b: un.Int(1), err: ErrUnexpectedValue},
+	{name: "int+int", a: un.Int(math.MaxInt64), b: un.Int(math.MinInt64), exp: un.Int(-1)},
+	{name: "int overflow", a: un.Int(math.MaxInt64), b: un.Int(1), err: ErrUnexpectedValue},
+	{name: "int underflow", a: un.Int(math.MinInt64), b: un.Int(-1), err: ErrUnexpectedValue},
+	{name: "int+uint overflow", a: un.Int(1), b: un.Uint(math.MaxInt64), err: ErrUnexpectedValue},
+}
+
+func TestNumAdd(t *testing.T) {
+	for _, c := range numAddCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			v, err := numAdd(c.a, c.b)
+			if c.err != nil {
+				require.True(t, c.err.Is(err), "expected %v, got %v", c.err, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.exp, v)
+		})
+	}
+}
+
+func TestNumSub(t *testing.T) {
+	v, err := numSub(un.Uint(math.MaxUint64), un.Uint(1))
+	require.NoError(t, err)
+	require.Equal(t, un.Uint(math.MaxUint64-1), v)
+
+	v, err = numSub(un.Uint(1), un.Int(math.MinInt64))
+	require.NoError(t, err)
+	require.Equal(t, un.Uint(1<<63+1), v)
+
+	_, err = numSub(un.Uint(1), un.Uint(2))
+	require.True(t, ErrUnexpectedValue.Is(err), "%v", err)
+
+	v, err = numSub(un.Int(-1), un.Int(math.MinInt64))
+	require.NoError(t, err)
+	require.Equal(t, un.Int(math.MaxInt64), v)
+
+	_, err = numSub(un.Int(0), un.Int(math.MinInt64))
+	require.True(t, ErrUnexpectedValue.Is(err), "%v", err)
+
+	_, err = numSub(un.Int(math.MinInt64), un.Int(1))
+	require.True(t, ErrUnexpectedValue.Is(err), "%v", err)
+
+	_, err = numNeg(un.Int(math.MinInt64))
+	require.True(t, ErrUnexpectedValue.Is(err), "%v", err)
+}
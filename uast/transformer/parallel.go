@@ -0,0 +1,182 @@
+package transformer
+
+import (
+	"reflect"
+	"sync"
+
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+// subtreeTransformer is a transformer that can be applied to separate subtrees and nodes of the tree.
+//
+// It is implemented by Mappings, TransformFunc and TransformObjFunc.
+type subtreeTransformer interface {
+	Transformer
+	// doContext runs the transformation on a subtree located at a given position in the tree.
+	doContext(ctx *nodeContext, root nodes.Node) (nodes.Node, error)
+	// doNode runs the transformation on a single node without visiting its children.
+	doNode(ctx *nodeContext, n nodes.Node) (nodes.Node, error)
+}
+
+// Parallel runs the transformation concurrently on independent subtrees.
+//
+// The tree is split into subtrees at a given depth, counting both objects and arrays. For example, a file node that
+// contains a list of declarations should be split at depth 2. Each subtree is transformed by one of the workers,
+// and the results are merged back in the original order. Nodes above the split depth are transformed after all
+// subtrees are processed, as usual.
+//
+// Only transformers created with Mappings, TransformFunc or TransformObjFunc can be parallelized. Other transformers
+// are returned as-is. It is also required that the transformation of each node depends only on its subtree and its
+// position in the source tree. Parent, Ancestor and PrevSibling satisfy this requirement, since they read the source
+// tree that is not modified by the transformation.
+//
+// Operations that use the per-file context (SetContext, SaveContext and ContextIs) do not: a value set by one rule
+// is visible to rules applied later, and with multiple workers this order depends on goroutine scheduling. Mappings
+// bound to a context with WithContext that use any of these operations are returned as-is.
+func Parallel(t Transformer, workers, depth int) Transformer {
+	st, ok := t.(subtreeTransformer)
+	if !ok || workers <= 1 || depth <= 0 {
+		return t
+	}
+	if m, ok := t.(mappings); ok && m.file != nil && m.usesContext() {
+		return t
+	}
+	return &parallel{t: st, workers: workers, depth: depth}
+}
+
+// usesContext checks if any rule of the mappings uses the per-file context.
+func (m mappings) usesContext() bool {
+	seen := make(map[uintptr]struct{})
+	for _, mp := range m.all {
+		src, dst := mp.Mapping()
+		if hasContextOp(reflect.ValueOf(src), seen) || hasContextOp(reflect.ValueOf(dst), seen) {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	typeSetContext  = reflect.TypeOf(&opSetContext{})
+	typeSaveContext = reflect.TypeOf(&opSaveContext{})
+	typeContextIs   = reflect.TypeOf(&opContextIs{})
+)
+
+// hasContextOp walks the operation and all operations nested in it, and checks if any of them uses
+// the per-file context. Operations created by functions, such as the ones passed to AnyNode, cannot be inspected.
+func hasContextOp(v reflect.Value, seen map[uintptr]struct{}) bool {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return false
+		}
+		switch v.Type() {
+		case typeSetContext, typeSaveContext, typeContextIs:
+			return true
+		}
+		if _, ok := seen[v.Pointer()]; ok {
+			return false
+		}
+		seen[v.Pointer()] = struct{}{}
+		return hasContextOp(v.Elem(), seen)
+	case reflect.Interface:
+		return !v.IsNil() && hasContextOp(v.Elem(), seen)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if hasContextOp(v.Field(i), seen) {
+				return true
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if hasContextOp(v.Index(i), seen) {
+				return true
+			}
+		}
+	case reflect.Map:
+		for _, k := range v.MapKeys() {
+			if hasContextOp(v.MapIndex(k), seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type parallel struct {
+	t       subtreeTransformer
+	workers int
+	depth   int
+}
+
+func (p *parallel) Do(root nodes.Node) (nodes.Node, error) {
+	// the current goroutine is also used as a worker
+	sem := make(chan struct{}, p.workers-1)
+	return p.do(sem, nil, root, p.depth)
+}
+
+func (p *parallel) do(sem chan struct{}, ctx *nodeContext, n nodes.Node, depth int) (nodes.Node, error) {
+	if depth <= 0 {
+		return p.t.doContext(ctx, n)
+	}
+	switch n := n.(type) {
+	case nodes.Object:
+		keys := n.Keys()
+		sub := &nodeContext{up: ctx, parent: n, index: -1}
+		out := make([]nodes.Node, len(keys))
+		errs := make([]error, len(keys))
+		runEach(sem, len(keys), func(i int) {
+			out[i], errs[i] = p.do(sem, sub, n[keys[i]], depth-1)
+		})
+		nn := make(nodes.Object, len(n))
+		for i, k := range keys {
+			nn[k] = out[i]
+		}
+		return p.doNode(ctx, nn, errs)
+	case nodes.Array:
+		out := make(nodes.Array, len(n))
+		errs := make([]error, len(n))
+		runEach(sem, len(n), func(i int) {
+			sub := &nodeContext{up: ctx, parent: n, index: i}
+			out[i], errs[i] = p.do(sem, sub, n[i], depth-1)
+		})
+		return p.doNode(ctx, out, errs)
+	}
+	return p.t.doContext(ctx, n)
+}
+
+func (p *parallel) doNode(ctx *nodeContext, n nodes.Node, errs []error) (nodes.Node, error) {
+	nn, err := p.t.doNode(ctx, n)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	var nonNil []error
+	for _, err := range errs {
+		if err != nil {
+			nonNil = append(nonNil, err)
+		}
+	}
+	return nn, NewMultiError(nonNil...)
+}
+
+// runEach calls fnc for each index in [0, n). The function will run in a separate goroutine if there is a free
+// slot in the semaphore, or in the current goroutine otherwise.
+func runEach(sem chan struct{}, n int, fnc func(i int)) {
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		select {
+		case sem <- struct{}{}:
+			wg.Add(1)
+			go func(i int) {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+				fnc(i)
+			}(i)
+		default:
+			fnc(i)
+		}
+	}
+	wg.Wait()
+}
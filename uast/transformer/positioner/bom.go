@@ -0,0 +1,37 @@
+package positioner
+
+import (
+	"strings"
+
+	"gopkg.in/bblfsh/sdk.v2/uast"
+)
+
+// bomUTF8 is a byte order mark in UTF-8 encoding.
+const bomUTF8 = "\xEF\xBB\xBF"
+
+// HasBOM checks if the source starts with a UTF-8 byte order mark.
+func HasBOM(code string) bool {
+	return strings.HasPrefix(code, bomUTF8)
+}
+
+// WithStrippedBOM returns a copy of the positioner that expects the native parser to strip a UTF-8 byte order mark
+// from the source before parsing. If the source starts with the BOM, offsets and columns on the first line
+// reported by the native parser are shifted back to positions in the original source. Sources without the BOM
+// are not affected.
+//
+// If the parser also normalizes line endings, WithStrippedBOM should be set before WithLineEndings.
+func (t Positioner) WithStrippedBOM() Positioner {
+	return t.withAdjuster(func(idx *Index, u Units) func(pos *uast.Position) {
+		if !HasBOM(idx.data) {
+			return func(pos *uast.Position) {}
+		}
+		// the BOM is a single character, thus the shift depends on the units of the native positions
+		shift := uint32(u.width('\uFEFF', len(bomUTF8)))
+		return func(pos *uast.Position) {
+			pos.Offset += shift
+			if pos.Line == 1 && pos.Col != 0 {
+				pos.Col += shift
+			}
+		}
+	})
+}
@@ -0,0 +1,104 @@
+package positioner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"gopkg.in/bblfsh/sdk.v2/uast"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+func TestStrippedBOM(t *testing.T) {
+	const source = bomUTF8 + "ab\ncd"
+	var cases = []struct {
+		name string
+		p    Positioner
+		code string
+		inp  nodes.Object
+		exp  nodes.Object
+	}{
+		{
+			name: "offset", p: FromOffset().WithStrippedBOM(), code: source,
+			inp: offset(0), exp: fullPos(3, 1, 4),
+		},
+		{
+			name: "offset second line", p: FromOffset().WithStrippedBOM(), code: source,
+			inp: offset(3), exp: fullPos(6, 2, 1),
+		},
+		{
+			name: "line col", p: FromLineCol().WithStrippedBOM(), code: source,
+			inp: lineCol(1, 2), exp: fullPos(4, 1, 5),
+		},
+		{
+			name: "line col second line", p: FromLineCol().WithStrippedBOM(), code: source,
+			inp: lineCol(2, 2), exp: fullPos(7, 2, 2),
+		},
+		{
+			name: "runes", p: FromUnicodeOffset().WithStrippedBOM(), code: source,
+			inp: offset(1), exp: fullPos(4, 1, 5),
+		},
+		{
+			name: "no bom", p: FromOffset().WithStrippedBOM(), code: "ab\ncd",
+			inp: offset(1), exp: fullPos(1, 1, 2),
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			out, err := c.p.OnCode(c.code).Do(c.inp)
+			require.NoError(t, err)
+			require.Equal(t, c.exp, out)
+		})
+	}
+}
+
+func TestStrippedBOMRunes(t *testing.T) {
+	const source = bomUTF8 + "ab\ncd"
+	p := FromUnicodeOffset().WithStrippedBOM()
+	adjust := p.adjust[len(p.adjust)-1](NewIndex(source), p.units)
+
+	// offsets and columns on the first line are shifted by one rune
+	pos := &uast.Position{Offset: 1, Line: 1, Col: 2}
+	adjust(pos)
+	require.Equal(t, uast.Position{Offset: 2, Line: 1, Col: 3}, *pos)
+
+	pos = &uast.Position{Offset: 4, Line: 2, Col: 2}
+	adjust(pos)
+	require.Equal(t, uast.Position{Offset: 5, Line: 2, Col: 2}, *pos)
+}
+
+func TestStrippedBOMUnits(t *testing.T) {
+	// the native parser sees "ё😀b\ncd"
+	const source = bomUTF8 + "ё😀b\ncd"
+	var cases = []struct {
+		units Units
+		// native positions of "b" and "d"
+		offB, colB int
+		offD       int
+	}{
+		{units: Bytes, offB: 6, colB: 7, offD: 9},
+		{units: Runes, offB: 2, colB: 3, offD: 5},
+		{units: UTF16, offB: 3, colB: 4, offD: 6},
+	}
+	for _, c := range cases {
+		t.Run(c.units.String(), func(t *testing.T) {
+			tr := FromOffsetUnits(c.units).WithStrippedBOM().OnCode(source)
+			out, err := tr.Do(offset(c.offB))
+			require.NoError(t, err)
+			require.Equal(t, fullPos(9, 1, 10), out)
+
+			out, err = tr.Do(offset(c.offD))
+			require.NoError(t, err)
+			require.Equal(t, fullPos(12, 2, 2), out)
+
+			tr = FromLineColUnits(c.units).WithStrippedBOM().OnCode(source)
+			out, err = tr.Do(lineCol(1, c.colB))
+			require.NoError(t, err)
+			require.Equal(t, fullPos(9, 1, 10), out)
+
+			out, err = tr.Do(lineCol(2, 2))
+			require.NoError(t, err)
+			require.Equal(t, fullPos(12, 2, 2), out)
+		})
+	}
+}
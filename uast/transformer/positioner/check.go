@@ -0,0 +1,166 @@
+package positioner
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/bblfsh/sdk.v2/uast"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+	"gopkg.in/bblfsh/sdk.v2/uast/transformer"
+)
+
+var _ transformer.CodeTransformer = VerifyPositions{}
+
+// VerifyPositions is a code-assisted transformation that checks positional information of all nodes. It does not
+// change the tree, but returns a PositionError listing all problems.
+//
+// The following is checked for each node:
+//   - offsets, lines and columns are within the source
+//   - offsets correspond to lines and columns, if both are set
+//   - the start position is not after the end position
+//   - optionally, the node is located within the closest positioned parent node
+type VerifyPositions struct {
+	// Children enables checking that all nodes are located within their parent nodes.
+	Children bool
+}
+
+// PositionProblem describes a node with invalid positional information.
+type PositionProblem struct {
+	// Path is the path to the node from the root, for example "Body[2].Name".
+	Path string
+	// Type is the type of the node.
+	Type string
+	// Reason describes the problem.
+	Reason string
+	// Positions of the node.
+	Positions uast.Positions
+}
+
+func (p PositionProblem) String() string {
+	return fmt.Sprintf("%s (%s): %s", p.Path, p.Type, p.Reason)
+}
+
+// PositionError is returned by VerifyPositions if any of the nodes has invalid positions.
+type PositionError struct {
+	Problems []PositionProblem
+}
+
+func (e *PositionError) Error() string {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "%d node(s) have invalid positions:", len(e.Problems))
+	for _, p := range e.Problems {
+		buf.WriteString("\n\t")
+		buf.WriteString(p.String())
+	}
+	return buf.String()
+}
+
+// Verify checks positions of the tree. See VerifyPositions.
+func (t VerifyPositions) Verify(code string, root nodes.Node) error {
+	_, err := t.OnCode(code).Do(root)
+	return err
+}
+
+// OnCode implements transformer.CodeTransformer.
+func (t VerifyPositions) OnCode(code string) transformer.Transformer {
+	return &verifyPositions{conf: t, idx: NewIndex(code)}
+}
+
+type verifyPositions struct {
+	conf VerifyPositions
+	idx  *Index
+	errs []PositionProblem
+}
+
+// span is a range of offsets of a node.
+type span struct {
+	start, end int
+}
+
+// Do implements transformer.Transformer. See VerifyPositions.
+func (t *verifyPositions) Do(root nodes.Node) (nodes.Node, error) {
+	t.errs = nil
+	t.visit(nil, root, nil)
+	if len(t.errs) != 0 {
+		return root, &PositionError{Problems: t.errs}
+	}
+	return root, nil
+}
+
+func (t *verifyPositions) visit(path []string, n nodes.Node, parent *span) {
+	switch n := n.(type) {
+	case nodes.Object:
+		if sp := t.check(path, n, parent); sp != nil {
+			parent = sp
+		}
+		for _, k := range n.Keys() {
+			if k == uast.KeyPos {
+				continue
+			}
+			t.visit(append(path, "."+k), n[k], parent)
+		}
+	case nodes.Array:
+		for i, v := range n {
+			t.visit(append(path, "["+strconv.Itoa(i)+"]"), v, parent)
+		}
+	}
+}
+
+// check verifies positions of a single node and returns its span, if it's known.
+func (t *verifyPositions) check(path []string, obj nodes.Object, parent *span) *span {
+	ps := uast.PositionsOf(obj)
+	if len(ps) == 0 {
+		return nil
+	}
+	report := func(format string, args ...interface{}) {
+		t.errs = append(t.errs, PositionProblem{
+			Path:      pathString(path),
+			Type:      uast.TypeOf(obj),
+			Reason:    fmt.Sprintf(format, args...),
+			Positions: ps,
+		})
+	}
+	valid := true
+	for _, k := range ps.Keys() {
+		if err := t.checkPos(ps[k]); err != nil {
+			report("%s: %v", k, err)
+			valid = false
+		}
+	}
+	start, end := ps.Start(), ps.End()
+	if !valid || start == nil || !start.HasOffset() {
+		return nil
+	}
+	sp := &span{start: int(start.Offset), end: int(start.Offset)}
+	if end != nil && end.HasOffset() {
+		if end.Offset < start.Offset {
+			report("start offset is after the end: %d > %d", start.Offset, end.Offset)
+			return nil
+		}
+		sp.end = int(end.Offset)
+	}
+	if t.conf.Children && parent != nil && (sp.start < parent.start || sp.end > parent.end) {
+		report("node [%d, %d) is outside of the parent [%d, %d)", sp.start, sp.end, parent.start, parent.end)
+	}
+	return sp
+}
+
+// checkPos checks that the position is within the source and that all fields are consistent.
+func (t *verifyPositions) checkPos(p uast.Position) error {
+	if p.HasOffset() {
+		line, col, err := t.idx.LineCol(int(p.Offset))
+		if err != nil {
+			return err
+		}
+		if p.HasLineCol() && (uint32(line) != p.Line || uint32(col) != p.Col) {
+			return fmt.Errorf("offset %d is at %d:%d, not at %d:%d", p.Offset, line, col, p.Line, p.Col)
+		}
+		return nil
+	}
+	if p.HasLineCol() {
+		_, err := t.idx.Offset(int(p.Line), int(p.Col))
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package positioner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"gopkg.in/bblfsh/sdk.v2/uast"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+func TestVerifyPositions(t *testing.T) {
+	const source = " var a, b int"
+	node := func(pos nodes.Node, kids ...nodes.Node) nodes.Object {
+		obj := nodes.Object{
+			uast.KeyType: nodes.String("test:Node"),
+			uast.KeyPos:  pos,
+		}
+		if len(kids) != 0 {
+			obj["Kids"] = nodes.Array(kids)
+		}
+		return obj
+	}
+	ast := node(newPos(1, 9),
+		node(newPos(5, 6)),
+		// outside of the parent
+		node(newPos(8, 13)),
+		// out of bounds
+		node(newPos(8, 15)),
+		// start after end
+		node(newPos(6, 5)),
+		// inconsistent line-col
+		node(nodes.Object{uast.KeyStart: fullPos(2, 1, 2)}),
+	)
+
+	err := VerifyPositions{}.Verify(source, ast)
+	require.Error(t, err)
+	perr, ok := err.(*PositionError)
+	require.True(t, ok, "%T", err)
+	var paths []string
+	for _, p := range perr.Problems {
+		paths = append(paths, p.String())
+	}
+	require.Equal(t, []string{
+		"Kids[2] (test:Node): end: offset out of bounds: 15 [0, 13]",
+		"Kids[3] (test:Node): start offset is after the end: 6 > 5",
+		"Kids[4] (test:Node): start: offset 2 is at 1:3, not at 1:2",
+	}, paths)
+
+	err = VerifyPositions{Children: true}.Verify(source, ast)
+	require.Error(t, err)
+	perr = err.(*PositionError)
+	require.Len(t, perr.Problems, 4)
+	require.Equal(t, "Kids[1] (test:Node): node [8, 13) is outside of the parent [1, 9)", perr.Problems[0].String())
+
+	require.NoError(t, VerifyPositions{Children: true}.Verify(source, node(newPos(1, 9), node(newPos(5, 6)))))
+}
@@ -0,0 +1,106 @@
+package positioner
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/bblfsh/sdk.v2/uast"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+	"gopkg.in/bblfsh/sdk.v2/uast/transformer"
+)
+
+var _ transformer.CodeTransformer = LineDirectives{}
+
+// KeyPosFile is a name for a Position object field that stores a name of the original file. See LineDirectives.
+const KeyPosFile = "file"
+
+// DefaultLineDirective matches C-style line directives, both "#line 10 "file.c"" and "# 10 "file.c" 1" forms.
+var DefaultLineDirective = regexp.MustCompile(`^\s*#\s*(?:line\s+)?(?P<line>\d+)(?:\s+"(?P<file>[^"]*)")?`)
+
+// LineDirectives is a code-assisted transformation that maps positions in a preprocessed source back to original
+// files by using line directives. Each directive sets the line number (and optionally the file name) of the line
+// that follows it.
+//
+// Lines of all positions after a directive are replaced with lines in the original file, and the name of the file
+// is saved to KeyPosFile. Offsets and columns are not changed. Positions before the first directive are not changed
+// either. Positions must have lines, thus the transformation should run after FromOffset or a similar positioner.
+type LineDirectives struct {
+	// Pattern is a regular expression that matches a directive on a single line. It must have a "line" named
+	// group and may have a "file" named group. Uses DefaultLineDirective, if not set.
+	Pattern *regexp.Regexp
+}
+
+// lineDirective maps lines after a directive to lines of the original file.
+type lineDirective struct {
+	line     int // the line that follows the directive
+	origLine int
+	file     string
+}
+
+// OnCode implements transformer.CodeTransformer.
+func (t LineDirectives) OnCode(code string) transformer.Transformer {
+	re := t.Pattern
+	if re == nil {
+		re = DefaultLineDirective
+	}
+	lineGroup, fileGroup := -1, -1
+	for i, name := range re.SubexpNames() {
+		switch name {
+		case "line":
+			lineGroup = i
+		case "file":
+			fileGroup = i
+		}
+	}
+	dirs, err := parseLineDirectives(code, re, lineGroup, fileGroup)
+	return transformer.TransformObjFunc(func(o nodes.Object) (nodes.Object, bool, error) {
+		if err != nil {
+			return o, false, err
+		}
+		pos := uast.AsPosition(o)
+		if pos == nil || pos.Line == 0 {
+			return o, false, nil
+		}
+		line := int(pos.Line)
+		i := sort.Search(len(dirs), func(i int) bool {
+			return dirs[i].line > line
+		})
+		if i == 0 {
+			return o, false, nil
+		}
+		d := dirs[i-1]
+		o[uast.KeyPosLine] = nodes.Uint(d.origLine + line - d.line)
+		if d.file != "" {
+			o[KeyPosFile] = nodes.String(d.file)
+		}
+		return o, false, nil
+	})
+}
+
+func parseLineDirectives(code string, re *regexp.Regexp, lineGroup, fileGroup int) ([]lineDirective, error) {
+	if lineGroup < 0 {
+		return nil, fmt.Errorf("line directive pattern has no line group: %v", re)
+	}
+	var (
+		out  []lineDirective
+		file string
+	)
+	for i, text := range strings.Split(code, "\n") {
+		m := re.FindStringSubmatch(text)
+		if m == nil {
+			continue
+		}
+		n, err := strconv.Atoi(m[lineGroup])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid line directive: %v", i+1, err)
+		}
+		if fileGroup >= 0 && m[fileGroup] != "" {
+			file = m[fileGroup]
+		}
+		out = append(out, lineDirective{line: i + 2, origLine: n, file: file})
+	}
+	return out, nil
+}
@@ -0,0 +1,63 @@
+package positioner
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"gopkg.in/bblfsh/sdk.v2/uast"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+func TestLineDirectives(t *testing.T) {
+	withFile := func(pos nodes.Object, file string) nodes.Object {
+		pos[KeyPosFile] = nodes.String(file)
+		return pos
+	}
+	var cases = []struct {
+		name   string
+		source string
+		conf   LineDirectives
+		inp    nodes.Node
+		exp    nodes.Node
+	}{
+		{
+			name:   "c",
+			source: "int a;\n#line 10 \"a.h\"\nint b;\nint c;\n# 5 \"b.h\" 2\nint d;\n#line 20\nint e;",
+			inp: nodes.Array{
+				fullPos(0, 1, 1),
+				fullPos(22, 3, 1),
+				fullPos(29, 4, 1),
+				fullPos(48, 6, 1),
+				fullPos(64, 8, 1),
+			},
+			exp: nodes.Array{
+				fullPos(0, 1, 1),
+				withFile(fullPos(22, 10, 1), "a.h"),
+				withFile(fullPos(29, 11, 1), "a.h"),
+				withFile(fullPos(48, 5, 1), "b.h"),
+				withFile(fullPos(64, 20, 1), "b.h"),
+			},
+		},
+		{
+			name:   "custom",
+			source: "//line a.go:7\nx",
+			conf: LineDirectives{
+				Pattern: regexp.MustCompile(`^//line (?P<file>[^:]+):(?P<line>\d+)$`),
+			},
+			inp: nodes.Array{fullPos(14, 2, 1), uast.Position{}.ToObject()},
+			exp: nodes.Array{withFile(fullPos(14, 7, 1), "a.go"), uast.Position{}.ToObject()},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			out, err := c.conf.OnCode(c.source).Do(c.inp)
+			require.NoError(t, err)
+			require.Equal(t, c.exp, out)
+		})
+	}
+
+	_, err := LineDirectives{Pattern: regexp.MustCompile(`^#`)}.OnCode("#\n").Do(fullPos(0, 1, 1))
+	require.Error(t, err)
+}
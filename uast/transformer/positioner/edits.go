@@ -0,0 +1,202 @@
+package positioner
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/bblfsh/sdk.v2/uast"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+	"gopkg.in/bblfsh/sdk.v2/uast/transformer"
+)
+
+var _ transformer.CodeTransformer = RemapEdits{}
+
+// KeyStale is a name of the field that is set to true for nodes invalidated by edits. See RemapEdits.
+const KeyStale = "@stale"
+
+// Edit is a change of the source: bytes in the [Start, End) range are replaced with Text.
+type Edit struct {
+	Start, End int
+	Text       string
+}
+
+// ApplyEdits applies edits to the source code. Edits must be sorted by the offset and must not overlap.
+// All offsets are relative to the original source.
+func ApplyEdits(code string, edits []Edit) (string, error) {
+	if err := checkEdits(len(code), edits); err != nil {
+		return "", err
+	}
+	var buf strings.Builder
+	last := 0
+	for _, e := range edits {
+		buf.WriteString(code[last:e.Start])
+		buf.WriteString(e.Text)
+		last = e.End
+	}
+	buf.WriteString(code[last:])
+	return buf.String(), nil
+}
+
+func checkEdits(size int, edits []Edit) error {
+	last := 0
+	for _, e := range edits {
+		if e.Start < last || e.End < e.Start || e.End > size {
+			return fmt.Errorf("invalid edit: [%d, %d) (size: %d)", e.Start, e.End, size)
+		}
+		last = e.End
+	}
+	return nil
+}
+
+// RemapEdits is a code-assisted transformation that updates positions of an existing tree after the source was
+// edited, without parsing the new source. The transformation is initialized with the source before edits.
+//
+// Positions after the edits are shifted, and lines and columns are recomputed for the new source. Nodes that
+// overlap with an edited range are marked with KeyStale, and their positions that fall into the range are clamped
+// to it. Positions without offsets are not changed, and their nodes are marked as stale as well.
+type RemapEdits struct {
+	// Edits to the source. Must be sorted by the offset and must not overlap.
+	// All offsets are relative to the source before edits.
+	Edits []Edit
+}
+
+// OnCode implements transformer.CodeTransformer.
+func (t RemapEdits) OnCode(code string) transformer.Transformer {
+	return &remapEdits{edits: t.Edits, code: code}
+}
+
+type remapEdits struct {
+	edits []Edit
+	code  string
+	idx   *Index
+}
+
+// Do implements transformer.Transformer.
+func (t *remapEdits) Do(root nodes.Node) (nodes.Node, error) {
+	code, err := ApplyEdits(t.code, t.edits)
+	if err != nil {
+		return nil, err
+	}
+	t.idx = NewIndex(code)
+	return t.visit(root)
+}
+
+func (t *remapEdits) visit(n nodes.Node) (nodes.Node, error) {
+	switch n := n.(type) {
+	case nodes.Object:
+		out := make(nodes.Object, len(n))
+		for k, v := range n {
+			if k == uast.KeyPos {
+				continue
+			}
+			nv, err := t.visit(v)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = nv
+		}
+		ps, ok := n[uast.KeyPos].(nodes.Object)
+		if !ok {
+			if v, ok := n[uast.KeyPos]; ok {
+				out[uast.KeyPos] = v
+			}
+			return out, nil
+		}
+		nps, stale, err := t.remapPositions(ps)
+		if err != nil {
+			return nil, err
+		}
+		out[uast.KeyPos] = nps
+		if stale {
+			out[KeyStale] = nodes.Bool(true)
+		}
+		return out, nil
+	case nodes.Array:
+		out := make(nodes.Array, 0, len(n))
+		for _, v := range n {
+			nv, err := t.visit(v)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, nv)
+		}
+		return out, nil
+	}
+	return n, nil
+}
+
+// remapPositions updates a positions object of a node. It returns true, if the node is invalidated by edits.
+func (t *remapEdits) remapPositions(ps nodes.Object) (nodes.Object, bool, error) {
+	out := make(nodes.Object, len(ps))
+	stale := false
+	for k, v := range ps {
+		po, _ := v.(nodes.Object)
+		pos := uast.AsPosition(po)
+		if pos == nil {
+			out[k] = v
+			continue
+		}
+		if !pos.HasOffset() {
+			out[k] = v
+			stale = true
+			continue
+		}
+		off, inside := t.remap(int(pos.Offset))
+		if inside {
+			stale = true
+		}
+		line, col, err := t.idx.LineCol(off)
+		if err != nil {
+			return nil, false, err
+		}
+		out[k] = uast.Position{Offset: uint32(off), Line: uint32(line), Col: uint32(col)}.ToObject()
+	}
+	if !stale {
+		stale = t.overlaps(uast.PositionsOf(nodes.Object{uast.KeyPos: ps}))
+	}
+	return out, stale, nil
+}
+
+// remap converts an offset in the old source to the offset in the new one. It returns true if the offset is
+// inside an edited range.
+func (t *remapEdits) remap(off int) (int, bool) {
+	delta := 0
+	for _, e := range t.edits {
+		if off <= e.Start {
+			break
+		}
+		if off < e.End {
+			// clamp to the replacement
+			d := off - e.Start
+			if d > len(e.Text) {
+				d = len(e.Text)
+			}
+			return e.Start + delta + d, true
+		}
+		delta += len(e.Text) - (e.End - e.Start)
+	}
+	return off + delta, false
+}
+
+// overlaps checks if the range of the node in the old source intersects with any of the edits.
+func (t *remapEdits) overlaps(ps uast.Positions) bool {
+	start, end := ps.Start(), ps.End()
+	if start == nil || !start.HasOffset() {
+		return false
+	}
+	s, e := int(start.Offset), int(start.Offset)
+	if end != nil && end.HasOffset() {
+		e = int(end.Offset)
+	}
+	for _, ed := range t.edits {
+		if ed.Start == ed.End {
+			// insertion
+			if s < ed.Start && ed.Start < e {
+				return true
+			}
+		} else if ed.Start < e && s < ed.End {
+			return true
+		}
+	}
+	return false
+}
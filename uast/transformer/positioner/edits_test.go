@@ -0,0 +1,58 @@
+package positioner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"gopkg.in/bblfsh/sdk.v2/uast"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+func TestRemapEdits(t *testing.T) {
+	const source = "a = 1\nb = 2\nc = 3"
+	idx := NewIndex(source)
+	pos := func(off int) nodes.Object {
+		line, col, err := idx.LineCol(off)
+		require.NoError(t, err)
+		return fullPos(off, line, col)
+	}
+	span := func(start, end int) nodes.Object {
+		return nodes.Object{
+			uast.KeyStart: pos(start),
+			uast.KeyEnd:   pos(end),
+		}
+	}
+	node := func(start, end int) nodes.Object {
+		return nodes.Object{uast.KeyPos: span(start, end)}
+	}
+	ast := nodes.Array{node(0, 5), node(6, 11), node(12, 17)}
+
+	edits := []Edit{{Start: 10, End: 11, Text: "42\n"}}
+	code, err := ApplyEdits(source, edits)
+	require.NoError(t, err)
+	require.Equal(t, "a = 1\nb = 42\n\nc = 3", code)
+
+	out, err := RemapEdits{Edits: edits}.OnCode(source).Do(ast)
+	require.NoError(t, err)
+	require.Equal(t, nodes.Array{
+		nodes.Object{uast.KeyPos: nodes.Object{
+			uast.KeyStart: fullPos(0, 1, 1),
+			uast.KeyEnd:   fullPos(5, 1, 6),
+		}},
+		nodes.Object{
+			uast.KeyPos: nodes.Object{
+				uast.KeyStart: fullPos(6, 2, 1),
+				uast.KeyEnd:   fullPos(13, 3, 1),
+			},
+			KeyStale: nodes.Bool(true),
+		},
+		nodes.Object{uast.KeyPos: nodes.Object{
+			uast.KeyStart: fullPos(14, 4, 1),
+			uast.KeyEnd:   fullPos(19, 4, 6),
+		}},
+	}, out)
+
+	_, err = ApplyEdits(source, []Edit{{Start: 3, End: 5}, {Start: 4, End: 6}})
+	require.Error(t, err)
+}
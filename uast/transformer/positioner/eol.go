@@ -0,0 +1,60 @@
+package positioner
+
+import (
+	"sort"
+	"unicode/utf8"
+
+	"gopkg.in/bblfsh/sdk.v2/uast"
+)
+
+// LineEndings describes how the native parser changed line endings of the source before parsing it.
+type LineEndings int
+
+const (
+	// OriginalLineEndings means that the native parser reports offsets in the original source.
+	OriginalLineEndings = LineEndings(iota)
+	// NormalizedLF means that the native parser replaced all CRLF line endings with LF.
+	NormalizedLF
+	// NormalizedCRLF means that the native parser replaced all LF line endings with CRLF.
+	NormalizedCRLF
+)
+
+// WithLineEndings returns a copy of the positioner that converts offsets reported by the native parser on
+// a source with normalized line endings back to offsets in the original source. Lines and columns are not changed.
+func (t Positioner) WithLineEndings(le LineEndings) Positioner {
+	if le == OriginalLineEndings {
+		return t
+	}
+	return t.withAdjuster(func(idx *Index, u Units) func(pos *uast.Position) {
+		// offsets of changed line endings in the coordinates of the native parser
+		var at []int
+		data := idx.data
+		for i, r := 0, 0; i < len(data); {
+			c, n := utf8.DecodeRuneInString(data[i:])
+			switch {
+			case le == NormalizedLF && c == '\r' && i+1 < len(data) && data[i+1] == '\n':
+				// CR is removed, LF takes its place
+				at = append(at, r-len(at))
+			case le == NormalizedCRLF && c == '\n' && (i == 0 || data[i-1] != '\r'):
+				// CR is inserted before LF
+				at = append(at, r+len(at))
+			}
+			i += n
+			r += u.width(c, n)
+		}
+		return func(pos *uast.Position) {
+			if len(at) == 0 {
+				return
+			}
+			off := int(pos.Offset)
+			// number of changed line endings before the offset
+			k := sort.SearchInts(at, off)
+			if le == NormalizedLF {
+				off += k
+			} else {
+				off -= k
+			}
+			pos.Offset = uint32(off)
+		}
+	})
+}
@@ -0,0 +1,95 @@
+package positioner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLineEndings(t *testing.T) {
+	var cases = []struct {
+		name   string
+		source string
+		p      Positioner
+		// offsets reported by the native parser
+		native []int
+		exp    []int
+	}{
+		{
+			name:   "lf",
+			source: "a\r\nb\r\nc",
+			p:      FromOffset().WithLineEndings(NormalizedLF),
+			native: []int{0, 1, 2, 3, 4, 5},
+			exp:    []int{0, 1, 3, 4, 6, 7},
+		},
+		{
+			name:   "lf runes",
+			source: "ё\r\nb",
+			p:      FromUnicodeOffset().WithLineEndings(NormalizedLF),
+			native: []int{0, 1, 2, 3},
+			exp:    []int{0, 2, 4, 5},
+		},
+		{
+			name:   "crlf",
+			source: "a\nb\r\nc",
+			p:      FromOffset().WithLineEndings(NormalizedCRLF),
+			native: []int{0, 1, 2, 3, 4, 5, 6, 7},
+			exp:    []int{0, 1, 1, 2, 3, 4, 5, 6},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			tr := c.p.OnCode(c.source)
+			idx := NewIndex(c.source)
+			for i, off := range c.native {
+				line, col, err := idx.LineCol(c.exp[i])
+				require.NoError(t, err)
+
+				out, err := tr.Do(offset(off))
+				require.NoError(t, err)
+				require.Equal(t, fullPos(c.exp[i], line, col), out, "offset %d", off)
+			}
+		})
+	}
+}
+
+func TestLineEndingsUnits(t *testing.T) {
+	// the native parser sees "ё😀\nb\nc"
+	const source = "ё😀\r\nb\r\nc"
+	var cases = []struct {
+		units Units
+		// native offsets of all characters
+		native []int
+		// native column of "😀"
+		col int
+	}{
+		{units: Bytes, native: []int{0, 2, 6, 7, 8, 9}, col: 3},
+		{units: Runes, native: []int{0, 1, 2, 3, 4, 5}, col: 2},
+		{units: UTF16, native: []int{0, 1, 3, 4, 5, 6}, col: 2},
+	}
+	exp := []int{0, 2, 6, 8, 9, 11}
+	idx := NewIndex(source)
+	for _, c := range cases {
+		t.Run(c.units.String(), func(t *testing.T) {
+			tr := FromOffsetUnits(c.units).WithLineEndings(NormalizedLF).OnCode(source)
+			for i, off := range c.native {
+				line, col, err := idx.LineCol(exp[i])
+				require.NoError(t, err)
+
+				out, err := tr.Do(offset(off))
+				require.NoError(t, err)
+				require.Equal(t, fullPos(exp[i], line, col), out, "offset %d", off)
+			}
+
+			// lines and columns are the same in both sources
+			tr = FromLineColUnits(c.units).WithLineEndings(NormalizedLF).OnCode(source)
+			out, err := tr.Do(lineCol(1, c.col))
+			require.NoError(t, err)
+			require.Equal(t, fullPos(2, 1, 3), out)
+
+			out, err = tr.Do(lineCol(3, 1))
+			require.NoError(t, err)
+			require.Equal(t, fullPos(11, 3, 1), out)
+		})
+	}
+}
@@ -0,0 +1,317 @@
+package positioner
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"sync"
+	"unicode/utf8"
+
+	"gopkg.in/bblfsh/sdk.v2/uast"
+)
+
+// runeSpan represents a sequence of UTF8 characters of the same size in bytes.
+type runeSpan struct {
+	firstRuneInd int // index of the first rune
+	byteOff      int // bytes offset of the first rune
+	numRunes     int // number of runes
+	runeSize     int // in bytes
+}
+
+// Index is a precomputed index of the source code that allows to convert between different kinds of positions.
+// It is safe for concurrent use, thus the same index can be shared by multiple transformations of the same source.
+// See IndexCache.
+type Index struct {
+	data         string
+	offsetByLine []int
+	size         int
+
+	// spans are built on the first use
+	spansOnce sync.Once
+	spans     []runeSpan
+
+	// UTF-16 offsets of lines are built on the first use
+	utf16Once   sync.Once
+	utf16ByLine []int
+	utf16Size   int
+}
+
+// NewIndex builds an index for the source code.
+func NewIndex(code string) *Index {
+	idx := &Index{
+		data: code,
+		size: len(code),
+	}
+	idx.addLineOffset(0)
+	for i := 0; i < len(code); i++ {
+		if code[i] == '\n' {
+			idx.addLineOffset(i + 1)
+		}
+	}
+	return idx
+}
+
+// buildSpans collects spans of runes required for RuneOffset.
+func (idx *Index) buildSpans() {
+	data := idx.data
+	cur := runeSpan{
+		runeSize: 1,
+	}
+	runes := 0
+	// decode UTF8 runes and collect a slice of UTF8 character spans
+	// each span only contains characters with the same size in bytes
+	for i := 0; i < len(data); i++ {
+		_, n := utf8.DecodeRuneInString(data[i:])
+		if n == 0 {
+			break // EOF, should not happen
+		}
+		if n == cur.runeSize {
+			// continue this span
+			cur.numRunes++
+			runes++
+			i += n - 1
+			continue
+		}
+		if cur.numRunes != 0 {
+			idx.spans = append(idx.spans, cur)
+		}
+		// make a new span
+		cur = runeSpan{
+			byteOff:      i,
+			firstRuneInd: runes,
+			numRunes:     1,
+			runeSize:     n,
+		}
+		runes++
+		i += n - 1
+	}
+	if cur.numRunes != 0 {
+		idx.spans = append(idx.spans, cur)
+	}
+}
+
+func (idx *Index) addLineOffset(offset int) {
+	idx.offsetByLine = append(idx.offsetByLine, offset)
+}
+
+// LineCol returns a one-based line and col given a zero-based byte offset.
+// It returns an error if the given offset is out of bounds.
+func (idx *Index) LineCol(offset int) (int, int, error) {
+	var (
+		minOffset = 0
+		maxOffset = idx.size
+	)
+
+	if offset < minOffset || offset > maxOffset {
+		return 0, 0, fmt.Errorf("offset out of bounds: %d [%d, %d]", offset, minOffset, maxOffset)
+	}
+
+	line := sort.Search(len(idx.offsetByLine), func(i int) bool {
+		return offset < idx.offsetByLine[i]
+	})
+	if line <= 0 || line > len(idx.offsetByLine) {
+		return 0, 0, fmt.Errorf("offset not found in index: %d", offset)
+	}
+
+	lineOffset := idx.offsetByLine[line-1]
+	col := offset - lineOffset + 1
+	return line, col, nil
+}
+
+// Offset returns a zero-based byte offset given a one-based line and column.
+// It returns an error if the given line and column are out of bounds.
+func (idx *Index) Offset(line, col int) (int, error) {
+	var (
+		minLine = 1
+		maxLine = len(idx.offsetByLine)
+		minCol  = 1
+	)
+
+	maxOffset := idx.size - 1
+
+	if line < minLine || line > maxLine {
+		return -1, fmt.Errorf("line out of bounds: %d [%d, %d]", line, minLine, maxLine)
+	}
+
+	nextLine := line
+	line = line - 1
+	if nextLine < len(idx.offsetByLine) {
+		maxOffset = idx.offsetByLine[nextLine] - 1
+	}
+
+	maxCol := maxOffset - idx.offsetByLine[line] + 1
+
+	// For empty files with 1-indexed drivers, set maxCol to 1
+	if maxCol == 0 && col == 1 {
+		maxCol = 1
+	}
+
+	if col < minCol || (maxCol > 0 && col-1 > maxCol) {
+		return 0, fmt.Errorf("column out of bounds: %d [%d, %d]", col, minCol, maxCol)
+	}
+
+	offset := idx.offsetByLine[line] + col - 1
+	return offset, nil
+}
+
+// RuneOffset returns a zero-based byte offset given a zero-based Unicode character offset.
+func (idx *Index) RuneOffset(offset int) (int, error) {
+	idx.spansOnce.Do(idx.buildSpans)
+	var last int
+	if len(idx.spans) != 0 {
+		s := idx.spans[len(idx.spans)-1]
+		last = s.firstRuneInd + s.numRunes
+	}
+	if offset == last {
+		// special case — EOF position
+		return idx.size, nil
+	}
+	if offset < 0 || offset >= last {
+		return -1, fmt.Errorf("rune out of bounds: %d [%d, %d)", offset, 0, last)
+	}
+	i := sort.Search(len(idx.spans), func(i int) bool {
+		s := idx.spans[i]
+		return offset < s.firstRuneInd
+	})
+	s := idx.spans[i-1]
+	return s.byteOff + s.runeSize*(offset-s.firstRuneInd), nil
+}
+
+// sortedOrder returns indexes of elements sorted by a given key.
+func sortedOrder(n int, key func(i int) int) []int {
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return key(order[i]) < key(order[j])
+	})
+	return order
+}
+
+// FillLineCol fills the Line and Col fields of all positions by using their Offset.
+// It is equivalent to calling LineCol for each position, but converts all positions in a single sorted pass.
+func (idx *Index) FillLineCol(pos []uast.Position) error {
+	order := sortedOrder(len(pos), func(i int) int { return int(pos[i].Offset) })
+	line := 0
+	for _, i := range order {
+		off := int(pos[i].Offset)
+		if off > idx.size {
+			return fmt.Errorf("offset out of bounds: %d [%d, %d]", off, 0, idx.size)
+		}
+		for line+1 < len(idx.offsetByLine) && idx.offsetByLine[line+1] <= off {
+			line++
+		}
+		pos[i].Line = uint32(line + 1)
+		pos[i].Col = uint32(off - idx.offsetByLine[line] + 1)
+	}
+	return nil
+}
+
+// FillOffset fills the Offset field of all positions by using their Line and Col.
+// It is equivalent to calling Offset for each position.
+func (idx *Index) FillOffset(pos []uast.Position) error {
+	for i, p := range pos {
+		off, err := idx.Offset(int(p.Line), int(p.Col))
+		if err != nil {
+			return err
+		}
+		pos[i].Offset = uint32(off)
+	}
+	return nil
+}
+
+// RuneOffsets converts zero-based Unicode character offsets to zero-based byte offsets in place.
+// It is equivalent to calling RuneOffset for each offset, but converts all offsets in a single sorted pass.
+func (idx *Index) RuneOffsets(offsets []int) error {
+	idx.spansOnce.Do(idx.buildSpans)
+	var last int
+	if len(idx.spans) != 0 {
+		s := idx.spans[len(idx.spans)-1]
+		last = s.firstRuneInd + s.numRunes
+	}
+	order := sortedOrder(len(offsets), func(i int) int { return offsets[i] })
+	span := 0
+	for _, i := range order {
+		off := offsets[i]
+		if off == last {
+			// special case — EOF position
+			offsets[i] = idx.size
+			continue
+		}
+		if off < 0 || off > last {
+			return fmt.Errorf("rune out of bounds: %d [%d, %d)", off, 0, last)
+		}
+		for span+1 < len(idx.spans) && idx.spans[span+1].firstRuneInd <= off {
+			span++
+		}
+		s := idx.spans[span]
+		offsets[i] = s.byteOff + s.runeSize*(off-s.firstRuneInd)
+	}
+	return nil
+}
+
+// IndexCache is a cache of source code indexes keyed by a hash of the content. It allows to build an index once and
+// reuse it in all positioners that run on the same source, even across multiple requests.
+// The cache is safe for concurrent use.
+type IndexCache struct {
+	size int
+
+	mu    sync.Mutex
+	lru   *list.List
+	byKey map[[sha256.Size]byte]*list.Element
+}
+
+type cachedIndex struct {
+	key [sha256.Size]byte
+	idx *Index
+}
+
+// NewIndexCache creates a cache that holds at most a given number of indexes.
+func NewIndexCache(size int) *IndexCache {
+	if size <= 0 {
+		size = 1
+	}
+	return &IndexCache{
+		size:  size,
+		lru:   list.New(),
+		byKey: make(map[[sha256.Size]byte]*list.Element),
+	}
+}
+
+// Get returns an index for the source code. The index is built if it's not in the cache.
+func (c *IndexCache) Get(code string) *Index {
+	key := sha256.Sum256([]byte(code))
+	c.mu.Lock()
+	if e, ok := c.byKey[key]; ok {
+		c.lru.MoveToFront(e)
+		c.mu.Unlock()
+		return e.Value.(*cachedIndex).idx
+	}
+	c.mu.Unlock()
+
+	idx := NewIndex(code)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.byKey[key]; ok {
+		// built concurrently
+		c.lru.MoveToFront(e)
+		return e.Value.(*cachedIndex).idx
+	}
+	c.byKey[key] = c.lru.PushFront(&cachedIndex{key: key, idx: idx})
+	for c.lru.Len() > c.size {
+		e := c.lru.Back()
+		c.lru.Remove(e)
+		delete(c.byKey, e.Value.(*cachedIndex).key)
+	}
+	return idx
+}
+
+// Len returns the number of indexes in the cache.
+func (c *IndexCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
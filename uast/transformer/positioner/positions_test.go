@@ -128,7 +128,7 @@ a3`
 		{Offset: 12, Line: 3, Col: 3},
 	}
 
-	ind := newPositionIndex([]byte(source))
+	ind := NewIndex(source)
 	for _, c := range cases {
 		t.Run("", func(t *testing.T) {
 			line, col, err := ind.LineCol(int(c.Offset))
@@ -165,7 +165,7 @@ a3`
 		{runeOff: 11, byteOff: 12, line: 3, col: 3},
 	}
 
-	ind := newPositionIndexUnicode([]byte(source))
+	ind := NewIndex(source)
 	for _, c := range cases {
 		t.Run("", func(t *testing.T) {
 			off, err := ind.RuneOffset(c.runeOff)
@@ -180,3 +180,70 @@ a3`
 		})
 	}
 }
+
+func TestIndexCache(t *testing.T) {
+	c := NewIndexCache(2)
+	a := c.Get("a\nb")
+	require.True(t, a == c.Get("a\nb"))
+	b := c.Get("b")
+	require.Equal(t, 2, c.Len())
+
+	// evicts the least recently used index
+	c.Get("c")
+	require.Equal(t, 2, c.Len())
+	require.True(t, b == c.Get("b"))
+	require.False(t, a == c.Get("a\nb"))
+
+	p := FromOffset().WithIndexCache(c)
+	out, err := p.OnCode("a\nb").Do(offset(2))
+	require.NoError(t, err)
+	require.Equal(t, fullPos(2, 2, 1), out)
+}
+
+func TestIndexBatch(t *testing.T) {
+	const source = "line1\nё2\na3"
+	idx := NewIndex(source)
+
+	offsets := []int{12, 0, 8, 6, 4, 11, 10}
+	pos := make([]uast.Position, len(offsets))
+	for i, off := range offsets {
+		pos[i].Offset = uint32(off)
+	}
+	require.NoError(t, idx.FillLineCol(pos))
+	for i, off := range offsets {
+		line, col, err := idx.LineCol(off)
+		require.NoError(t, err)
+		require.Equal(t, uast.Position{Offset: uint32(off), Line: uint32(line), Col: uint32(col)}, pos[i])
+
+		pos[i].Offset = 0
+	}
+	require.NoError(t, idx.FillOffset(pos))
+	for i, off := range offsets {
+		require.Equal(t, uint32(off), pos[i].Offset)
+	}
+
+	runes := []int{11, 0, 7, 6, 10}
+	exp := make([]int, 0, len(runes))
+	for _, r := range runes {
+		off, err := idx.RuneOffset(r)
+		require.NoError(t, err)
+		exp = append(exp, off)
+	}
+	require.NoError(t, idx.RuneOffsets(runes))
+	require.Equal(t, exp, runes)
+
+	require.Error(t, idx.FillLineCol([]uast.Position{{Offset: 13}}))
+	require.Error(t, idx.RuneOffsets([]int{12}))
+}
+
+func TestPositionOverflow(t *testing.T) {
+	pos := offset(0)
+	pos[uast.KeyPosOff] = nodes.Uint(1 << 33)
+	_, err := FromOffset().OnCode("a").Do(pos)
+	require.True(t, ErrPositionOverflow.Is(err), "%v", err)
+
+	pos = lineCol(1, 1)
+	pos[uast.KeyPosCol] = nodes.Int(-1)
+	_, err = FromLineCol().OnCode("a").Do(pos)
+	require.True(t, ErrPositionOverflow.Is(err), "%v", err)
+}
@@ -0,0 +1,204 @@
+package positioner
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/bblfsh/sdk.v2/uast"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+	"gopkg.in/bblfsh/sdk.v2/uast/transformer"
+)
+
+var _ transformer.CodeTransformer = (*SourceMap)(nil)
+
+// SourceMap is a source map in the revision 3 format, as used by JavaScript tools.
+//
+// SourceMap is a code-assisted transformation that translates positions in the generated source to positions
+// in original sources. The name of the original file is saved to KeyPosFile. If the map includes the content of
+// the original source, all positional fields are set. Otherwise, the Offset is reset, and the column is set to
+// the UTF-16 column reported by the map. Positions that are not covered by the map are not changed.
+//
+// The transformation is initialized with the generated source and requires lines and columns to be set.
+type SourceMap struct {
+	Version        int       `json:"version"`
+	File           string    `json:"file,omitempty"`
+	SourceRoot     string    `json:"sourceRoot,omitempty"`
+	Sources        []string  `json:"sources"`
+	SourcesContent []*string `json:"sourcesContent,omitempty"`
+	Names          []string  `json:"names,omitempty"`
+	Mappings       string    `json:"mappings"`
+
+	// lines are decoded segments for each generated line
+	lines [][]mapSegment
+}
+
+// mapSegment maps a generated column to a position in the original source. All values are zero-based.
+type mapSegment struct {
+	genCol  int
+	source  int // -1 if the segment has no source
+	srcLine int
+	srcCol  int
+}
+
+// ParseSourceMap decodes a source map in the revision 3 format.
+func ParseSourceMap(data []byte) (*SourceMap, error) {
+	var m SourceMap
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	if m.Version != 3 {
+		return nil, fmt.Errorf("unsupported source map version: %d", m.Version)
+	}
+	if err := m.decode(); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// decode parses the mappings field.
+func (m *SourceMap) decode() error {
+	var (
+		source, srcLine, srcCol, name int
+	)
+	m.lines = nil
+	for i, line := range strings.Split(m.Mappings, ";") {
+		var segs []mapSegment
+		genCol := 0
+		for _, seg := range strings.Split(line, ",") {
+			if seg == "" {
+				continue
+			}
+			vals, err := decodeVLQ(seg)
+			if err != nil {
+				return fmt.Errorf("line %d: %v", i+1, err)
+			}
+			genCol += vals[0]
+			s := mapSegment{genCol: genCol, source: -1}
+			switch len(vals) {
+			case 1:
+			case 4, 5:
+				source += vals[1]
+				srcLine += vals[2]
+				srcCol += vals[3]
+				if len(vals) == 5 {
+					name += vals[4]
+				}
+				if source < 0 || source >= len(m.Sources) {
+					return fmt.Errorf("line %d: source index out of range: %d", i+1, source)
+				}
+				s.source, s.srcLine, s.srcCol = source, srcLine, srcCol
+			default:
+				return fmt.Errorf("line %d: invalid segment: %q", i+1, seg)
+			}
+			segs = append(segs, s)
+		}
+		sort.SliceStable(segs, func(i, j int) bool {
+			return segs[i].genCol < segs[j].genCol
+		})
+		m.lines = append(m.lines, segs)
+	}
+	return nil
+}
+
+const base64Chars = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+// decodeVLQ decodes a sequence of base64 VLQ values.
+func decodeVLQ(s string) ([]int, error) {
+	var (
+		out   []int
+		val   int
+		shift uint
+	)
+	for i := 0; i < len(s); i++ {
+		d := strings.IndexByte(base64Chars, s[i])
+		if d < 0 {
+			return nil, fmt.Errorf("invalid VLQ character: %q", s[i])
+		}
+		val += (d & 31) << shift
+		if d&32 != 0 {
+			shift += 5
+			continue
+		}
+		if val&1 != 0 {
+			out = append(out, -(val >> 1))
+		} else {
+			out = append(out, val>>1)
+		}
+		val, shift = 0, 0
+	}
+	if shift != 0 {
+		return nil, fmt.Errorf("unterminated VLQ value: %q", s)
+	}
+	return out, nil
+}
+
+// lookup finds a segment for a zero-based generated line and column.
+func (m *SourceMap) lookup(line, col int) (mapSegment, bool) {
+	if line < 0 || line >= len(m.lines) {
+		return mapSegment{}, false
+	}
+	segs := m.lines[line]
+	i := sort.Search(len(segs), func(i int) bool {
+		return segs[i].genCol > col
+	})
+	if i == 0 || segs[i-1].source < 0 {
+		return mapSegment{}, false
+	}
+	return segs[i-1], true
+}
+
+// sourceName returns a name of the original source with a given index.
+func (m *SourceMap) sourceName(i int) string {
+	name := m.Sources[i]
+	if m.SourceRoot != "" {
+		name = strings.TrimSuffix(m.SourceRoot, "/") + "/" + name
+	}
+	return name
+}
+
+// OnCode implements transformer.CodeTransformer.
+func (m *SourceMap) OnCode(code string) transformer.Transformer {
+	gen := NewIndex(code)
+	orig := make([]*Index, len(m.Sources))
+	for i := range orig {
+		if i < len(m.SourcesContent) && m.SourcesContent[i] != nil {
+			orig[i] = NewIndex(*m.SourcesContent[i])
+		}
+	}
+	return transformer.TransformObjFunc(func(o nodes.Object) (nodes.Object, bool, error) {
+		pos := uast.AsPosition(o)
+		if pos == nil || !pos.HasLineCol() {
+			return o, false, nil
+		}
+		off, err := gen.Offset(int(pos.Line), int(pos.Col))
+		if err != nil {
+			return o, false, err
+		}
+		_, col16, err := gen.UTF16LineCol(off)
+		if err != nil {
+			return o, false, err
+		}
+		seg, ok := m.lookup(int(pos.Line)-1, col16-1)
+		if !ok {
+			return o, false, nil
+		}
+		np := uast.Position{Line: uint32(seg.srcLine + 1), Col: uint32(seg.srcCol + 1)}
+		if idx := orig[seg.source]; idx != nil {
+			noff, err := idx.UTF16Offset(int(np.Line), int(np.Col))
+			if err != nil {
+				return o, false, err
+			}
+			np.Offset = uint32(noff)
+			if err = fromOffset(idx, &np, o); err != nil {
+				return o, false, err
+			}
+		}
+		for k, v := range np.ToObject() {
+			o[k] = v
+		}
+		o[KeyPosFile] = nodes.String(m.sourceName(seg.source))
+		return o, false, nil
+	})
+}
@@ -0,0 +1,64 @@
+package positioner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+func TestSourceMap(t *testing.T) {
+	// original source is "let a = 1\nlet b = 2"
+	const gen = "var a=1;\nvar b=2;"
+	inFile := func(pos nodes.Object, file string) nodes.Object {
+		pos[KeyPosFile] = nodes.String(file)
+		return pos
+	}
+	var cases = []struct {
+		name string
+		data string
+		inp  nodes.Node
+		exp  nodes.Node
+	}{
+		{
+			name: "no content",
+			data: `{"version":3,"sources":["a.ts"],"mappings":"AAAA,IAAI;AACJ,IAAI"}`,
+			inp:  nodes.Array{fullPos(0, 1, 1), fullPos(13, 2, 5), fullPos(6, 1, 7)},
+			exp: nodes.Array{
+				inFile(lineCol(1, 1), "a.ts"),
+				inFile(lineCol(2, 5), "a.ts"),
+				inFile(lineCol(1, 5), "a.ts"),
+			},
+		},
+		{
+			name: "content",
+			data: `{"version":3,"sourceRoot":"src/","sources":["a.ts"],"sourcesContent":["let a = 1\nlet b = 2"],"mappings":"AAAA,IAAI;AACJ,IAAI"}`,
+			inp:  nodes.Array{fullPos(0, 1, 1), fullPos(13, 2, 5)},
+			exp: nodes.Array{
+				inFile(fullPos(0, 1, 1), "src/a.ts"),
+				inFile(fullPos(14, 2, 5), "src/a.ts"),
+			},
+		},
+		{
+			name: "not mapped",
+			data: `{"version":3,"sources":["a.ts"],"mappings":";E"}`,
+			inp:  nodes.Array{fullPos(0, 1, 1), fullPos(13, 2, 5)},
+			exp:  nodes.Array{fullPos(0, 1, 1), fullPos(13, 2, 5)},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			m, err := ParseSourceMap([]byte(c.data))
+			require.NoError(t, err)
+			out, err := m.OnCode(gen).Do(c.inp)
+			require.NoError(t, err)
+			require.Equal(t, c.exp, out)
+		})
+	}
+
+	_, err := ParseSourceMap([]byte(`{"version":3,"sources":[],"mappings":"AAAA"}`))
+	require.Error(t, err)
+	_, err = ParseSourceMap([]byte(`{"version":2,"sources":[],"mappings":""}`))
+	require.Error(t, err)
+}
@@ -0,0 +1,95 @@
+package positioner
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"gopkg.in/bblfsh/sdk.v2/uast"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+// KeyPosVisualCol is a name for a Position object field that stores a visual column, with tabs expanded to the
+// next tab stop and each character counted as one column. It is a 1-based index.
+const KeyPosVisualCol = "vcol"
+
+// FromVisualLineCol fills the Offset field of all Position nodes by interpreting their Line and Col as a line and
+// a visual column, with tabs expanded to a given width. The Col field is replaced with a byte column and the
+// visual column is saved to KeyPosVisualCol.
+//
+// Some native parsers report columns this way, for example, a Python tokenizer.
+func FromVisualLineCol(tabWidth int) Positioner {
+	if tabWidth <= 0 {
+		panic("tab width must be positive")
+	}
+	return Positioner{tabWidth: tabWidth, method: func(idx *Index, pos *uast.Position, o nodes.Object) error {
+		offset, err := idx.VisualOffset(int(pos.Line), int(pos.Col), tabWidth)
+		if err != nil {
+			return err
+		}
+		pos.Offset = uint32(offset)
+		return fromOffset(idx, pos, o)
+	}}
+}
+
+// WithVisualColumns returns a copy of the positioner that additionally computes visual columns of all Position
+// nodes, with tabs expanded to a given width. See KeyPosVisualCol.
+func (t Positioner) WithVisualColumns(tabWidth int) Positioner {
+	if tabWidth <= 0 {
+		panic("tab width must be positive")
+	}
+	t.tabWidth = tabWidth
+	return t
+}
+
+// nextColumn returns a zero-based visual column after the rune.
+func nextColumn(col int, r rune, tabWidth int) int {
+	if r == '\t' {
+		return (col/tabWidth + 1) * tabWidth
+	}
+	return col + 1
+}
+
+// VisualLineCol returns a one-based line and a one-based visual column given a zero-based byte offset.
+func (idx *Index) VisualLineCol(offset, tabWidth int) (int, int, error) {
+	line, col, err := idx.LineCol(offset)
+	if err != nil {
+		return 0, 0, err
+	}
+	vcol := 0
+	for i := offset - (col - 1); i < offset; {
+		r, n := utf8.DecodeRuneInString(idx.data[i:offset])
+		if n == 0 {
+			break
+		}
+		vcol = nextColumn(vcol, r, tabWidth)
+		i += n
+	}
+	return line, vcol + 1, nil
+}
+
+// VisualOffset returns a zero-based byte offset given a one-based line and a one-based visual column.
+func (idx *Index) VisualOffset(line, col, tabWidth int) (int, error) {
+	if line < 1 || line > len(idx.offsetByLine) {
+		return -1, fmt.Errorf("line out of bounds: %d [%d, %d]", line, 1, len(idx.offsetByLine))
+	}
+	if col < 1 {
+		return -1, fmt.Errorf("column out of bounds: %d", col)
+	}
+	end := idx.size
+	if line < len(idx.offsetByLine) {
+		end = idx.offsetByLine[line]
+	}
+	off := idx.offsetByLine[line-1]
+	for vcol := 0; vcol < col-1; {
+		if off >= end {
+			return -1, fmt.Errorf("column out of bounds: %d", col)
+		}
+		r, n := utf8.DecodeRuneInString(idx.data[off:end])
+		vcol = nextColumn(vcol, r, tabWidth)
+		if vcol > col-1 {
+			return -1, fmt.Errorf("column points inside a tab: %d", col)
+		}
+		off += n
+	}
+	return off, nil
+}
@@ -0,0 +1,48 @@
+package positioner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"gopkg.in/bblfsh/sdk.v2/uast"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+func TestVisualColumns(t *testing.T) {
+	const source = "\tif a:\n\t\tb\n  \tё\tc"
+	var cases = []struct {
+		off, line, col, vcol int
+	}{
+		{off: 0, line: 1, col: 1, vcol: 1},
+		{off: 1, line: 1, col: 2, vcol: 9},
+		{off: 4, line: 1, col: 5, vcol: 12},
+
+		{off: 9, line: 2, col: 3, vcol: 17},
+
+		// spaces before a tab
+		{off: 11, line: 3, col: 1, vcol: 1},
+		{off: 13, line: 3, col: 3, vcol: 3},
+		{off: 14, line: 3, col: 4, vcol: 9},
+		// multi-byte rune is a single column
+		{off: 16, line: 3, col: 6, vcol: 10},
+		{off: 17, line: 3, col: 7, vcol: 17},
+	}
+	for _, c := range cases {
+		t.Run("", func(t *testing.T) {
+			exp := fullPos(c.off, c.line, c.col)
+			exp[KeyPosVisualCol] = nodes.Uint(c.vcol)
+
+			out, err := FromVisualLineCol(8).OnCode(source).Do(lineCol(c.line, c.vcol))
+			require.NoError(t, err)
+			require.Equal(t, exp, out)
+
+			out, err = FromOffset().WithVisualColumns(8).OnCode(source).Do(offset(c.off))
+			require.NoError(t, err)
+			require.Equal(t, exp, out)
+		})
+	}
+
+	_, err := FromVisualLineCol(8).OnCode(source).Do(uast.Position{Line: 1, Col: 3}.ToObject())
+	require.Error(t, err)
+}
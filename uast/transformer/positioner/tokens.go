@@ -2,6 +2,7 @@ package positioner
 
 import (
 	"fmt"
+	"unicode/utf8"
 
 	"gopkg.in/bblfsh/sdk.v2/uast"
 	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
@@ -123,6 +124,90 @@ func (t *tokenFromSource) Do(root nodes.Node) (nodes.Node, error) {
 	return root, last
 }
 
+var _ transformer.CodeTransformer = EndFromToken{}
+
+// EndFromToken sets the end position of nodes that have a start position and a token, but no end position.
+// The end offset is computed from the length of the token.
+type EndFromToken struct {
+	// Key is the name of the token field. Uses uast.KeyToken, if not set.
+	Key string
+	// Types is the list of node types that will be updated. Empty means all nodes.
+	Types []string
+	// Runes enables counting the length of the token in Unicode characters instead of bytes. The end position
+	// is found by skipping the same number of characters in the source.
+	Runes bool
+}
+
+// OnCode implements transformer.CodeTransformer.
+func (t EndFromToken) OnCode(code string) transformer.Transformer {
+	return &endFromToken{
+		tokenFilter: newTokenFilter(code, t.Key, t.Types),
+		idx:         NewIndex(code),
+		runes:       t.Runes,
+	}
+}
+
+type endFromToken struct {
+	tokenFilter
+	idx   *Index
+	runes bool
+}
+
+// Do implements transformer.Transformer. See EndFromToken.
+func (t *endFromToken) Do(root nodes.Node) (nodes.Node, error) {
+	var last error
+	nodes.WalkPreOrder(root, func(node nodes.Node) bool {
+		if last != nil {
+			return false
+		}
+		obj, ok := t.filterObj(node)
+		if !ok {
+			return true
+		}
+		token, ok := obj[t.tokenKey].(nodes.String)
+		if !ok {
+			return true
+		}
+		ps, ok := obj[uast.KeyPos].(nodes.Object)
+		if !ok {
+			return true
+		}
+		pos := uast.PositionsOf(obj)
+		start := pos.Start()
+		if start == nil || !start.HasOffset() || pos.End() != nil {
+			return true
+		}
+		end, err := t.endOffset(int(start.Offset), string(token))
+		if err != nil {
+			last = err
+			return false
+		}
+		line, col, err := t.idx.LineCol(end)
+		if err != nil {
+			last = err
+			return false
+		}
+		ps[uast.KeyEnd] = uast.Position{Offset: uint32(end), Line: uint32(line), Col: uint32(col)}.ToObject()
+		return true
+	})
+	return root, last
+}
+
+func (t *endFromToken) endOffset(start int, token string) (int, error) {
+	if !t.runes {
+		return start + len(token), nil
+	}
+	end := start
+	for n := utf8.RuneCountInString(token); n > 0; n-- {
+		if end >= len(t.source) {
+			return 0, fmt.Errorf("token is out of bounds: %q at %d", token, start)
+		}
+		_, sz := utf8.DecodeRuneInString(t.source[end:])
+		end += sz
+	}
+	return end, nil
+}
+
 // VerifyToken check that node's token matches its positional information.
 type VerifyToken struct {
 	// Key is the name of the token field to check. Uses uast.KeyToken, if not set.
@@ -161,3 +161,45 @@ func TestTokenFromSource(t *testing.T) {
 		})
 	}
 }
+
+func TestEndFromToken(t *testing.T) {
+	ident := func(tok string, start int, end nodes.Node) nodes.Object {
+		pos := nodes.Object{uast.KeyStart: fullPos(start, 1, 1+start)}
+		if end != nil {
+			pos[uast.KeyEnd] = end
+		}
+		return nodes.Object{
+			uast.KeyType:  nodes.String("test:Ident"),
+			uast.KeyToken: nodes.String(tok),
+			uast.KeyPos:   pos,
+		}
+	}
+	var cases = []struct {
+		name     string
+		source   string
+		conf     EndFromToken
+		ast, exp nodes.Node
+	}{
+		{
+			name:   "bytes",
+			source: "a ёb c",
+			ast:    nodes.Array{ident("a", 0, nil), ident("ёb", 2, nil), ident("c", 6, fullPos(6, 1, 7))},
+			exp:    nodes.Array{ident("a", 0, fullPos(1, 1, 2)), ident("ёb", 2, fullPos(5, 1, 6)), ident("c", 6, fullPos(6, 1, 7))},
+		},
+		{
+			name:   "runes",
+			source: "ёb",
+			conf:   EndFromToken{Runes: true},
+			// the parser unescaped the token
+			ast: nodes.Array{ident("xy", 0, nil)},
+			exp: nodes.Array{ident("xy", 0, fullPos(3, 1, 4))},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := c.conf.OnCode(c.source).Do(c.ast)
+			require.NoError(t, err)
+			require.Equal(t, c.exp, got)
+		})
+	}
+}
@@ -0,0 +1,225 @@
+package positioner
+
+import (
+	"sort"
+
+	"gopkg.in/bblfsh/sdk.v2/uast"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+	"gopkg.in/bblfsh/sdk.v2/uast/transformer"
+)
+
+var _ transformer.CodeTransformer = Trivia{}
+
+const (
+	// DefaultTriviaKey is a default field name for the trivia of a node.
+	DefaultTriviaKey = "Trivia"
+	// TriviaType is a type of nodes that hold source fragments not covered by any other node.
+	TriviaType = "Trivia"
+)
+
+// Trivia is a code-assisted transformation that preserves source fragments that are not covered by any node,
+// such as whitespaces, punctuation and formatting. It is useful for consumers that need to rewrite the source
+// without losing its formatting.
+//
+// Each positioned object receives a list of trivia nodes that fill the gaps between its children. Leaf nodes are
+// not changed, since they are covered by their tokens. The root node also receives fragments that precede or
+// follow it. Objects without positions are considered transparent: their children are treated as children of
+// the closest positioned ancestor. If the tokens of leaf nodes are verbatim slices of the source, the concatenation
+// of all trivia with these tokens reproduces the source exactly. Tokens normalized by the driver, such as unquoted
+// string literals, must be replaced with the source text at their positions instead.
+//
+// All positions must have offsets, thus the transformation should run after FromLineCol or a similar positioner.
+type Trivia struct {
+	// Key is the name of the field that will hold the trivia of a node. Uses DefaultTriviaKey, if not set.
+	Key string
+	// OffsetsOnly disables saving the text of the trivia. Only the positions of fragments will be saved.
+	OffsetsOnly bool
+}
+
+// OnCode implements transformer.CodeTransformer.
+func (t Trivia) OnCode(code string) transformer.Transformer {
+	if t.Key == "" {
+		t.Key = DefaultTriviaKey
+	}
+	return &trivia{conf: t, code: code, idx: NewIndex(code)}
+}
+
+type trivia struct {
+	conf Trivia
+	code string
+	idx  *Index
+}
+
+// offsets returns start and end offsets of a node. A missing end offset is set to start.
+func offsets(n nodes.Object) (int, int, bool) {
+	ps := uast.PositionsOf(n)
+	start := ps.Start()
+	if start == nil || !start.HasOffset() {
+		return 0, 0, false
+	}
+	end := ps.End()
+	if end == nil || !end.HasOffset() || end.Offset < start.Offset {
+		end = start
+	}
+	return int(start.Offset), int(end.Offset), true
+}
+
+// Do implements transformer.Transformer.
+func (t *trivia) Do(root nodes.Node) (nodes.Node, error) {
+	if root == nil {
+		return nil, nil
+	}
+	root = root.Clone()
+	if obj, ok := root.(nodes.Object); ok {
+		if _, _, ok := offsets(obj); ok {
+			// make sure fragments around the root are preserved as well
+			if err := t.fill(obj, 0, len(t.code), t.children(nodes.Array{obj})); err != nil {
+				return nil, err
+			}
+			return root, t.visit(obj)
+		}
+		if err := t.fill(obj, 0, len(t.code), t.children(obj)); err != nil {
+			return nil, err
+		}
+		return root, t.visitFields(obj)
+	}
+	return root, t.visitFields(root)
+}
+
+// visit fills the trivia for a positioned object and all its descendants.
+func (t *trivia) visit(obj nodes.Object) error {
+	kids := t.children(obj)
+	if len(kids) == 0 {
+		// leaf nodes are covered by their tokens
+		return nil
+	}
+	start, end, _ := offsets(obj)
+	if err := t.fill(obj, start, end, kids); err != nil {
+		return err
+	}
+	for _, k := range kids {
+		if err := t.visit(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// visitFields runs visit for all positioned descendants of the node.
+func (t *trivia) visitFields(n nodes.Node) error {
+	for _, k := range t.children(n) {
+		if err := t.visit(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// children returns all positioned objects that are direct descendants of the node, sorted by the start offset.
+func (t *trivia) children(n nodes.Node) []nodes.Object {
+	var out []nodes.Object
+	var visit func(n nodes.Node, root bool)
+	visit = func(n nodes.Node, root bool) {
+		switch n := n.(type) {
+		case nodes.Object:
+			if _, _, ok := offsets(n); ok && !root {
+				out = append(out, n)
+				return
+			}
+			for k, v := range n {
+				if k == uast.KeyPos || k == t.conf.Key {
+					continue
+				}
+				visit(v, false)
+			}
+		case nodes.Array:
+			for _, e := range n {
+				visit(e, false)
+			}
+		}
+	}
+	visit(n, true)
+	sort.SliceStable(out, func(i, j int) bool {
+		si, ei, _ := offsets(out[i])
+		sj, ej, _ := offsets(out[j])
+		if si == sj {
+			return ei < ej
+		}
+		return si < sj
+	})
+	return out
+}
+
+// fill saves all gaps between children in the [start, end) range as the trivia of the object.
+func (t *trivia) fill(obj nodes.Object, start, end int, kids []nodes.Object) error {
+	if end > len(t.code) {
+		end = len(t.code)
+	}
+	var arr nodes.Array
+	add := func(from, to int) error {
+		if from >= to {
+			return nil
+		}
+		n, err := t.newTrivia(from, to)
+		if err != nil {
+			return err
+		}
+		arr = append(arr, n)
+		return nil
+	}
+	cur := start
+	for _, k := range kids {
+		ks, ke, _ := offsets(k)
+		if err := add(cur, ks); err != nil {
+			return err
+		}
+		if ke > cur {
+			cur = ke
+		}
+	}
+	if err := add(cur, end); err != nil {
+		return err
+	}
+	if len(arr) == 0 {
+		return nil
+	}
+	prev, _ := obj[t.conf.Key].(nodes.Array)
+	arr = append(prev, arr...)
+	sort.SliceStable(arr, func(i, j int) bool {
+		si, _, _ := offsets(arr[i].(nodes.Object))
+		sj, _, _ := offsets(arr[j].(nodes.Object))
+		return si < sj
+	})
+	obj[t.conf.Key] = arr
+	return nil
+}
+
+// newTrivia creates a trivia node for the [start, end) range of the source.
+func (t *trivia) newTrivia(start, end int) (nodes.Object, error) {
+	pos := func(off int) (uast.Position, error) {
+		line, col, err := t.idx.LineCol(off)
+		if err != nil {
+			return uast.Position{}, err
+		}
+		return uast.Position{Offset: uint32(off), Line: uint32(line), Col: uint32(col)}, nil
+	}
+	ps, err := pos(start)
+	if err != nil {
+		return nil, err
+	}
+	pe, err := pos(end)
+	if err != nil {
+		return nil, err
+	}
+	obj := nodes.Object{
+		uast.KeyType: nodes.String(TriviaType),
+		uast.KeyPos: uast.Positions{
+			uast.KeyStart: ps,
+			uast.KeyEnd:   pe,
+		}.ToObject(),
+	}
+	if !t.conf.OffsetsOnly {
+		obj["Text"] = nodes.String(t.code[start:end])
+	}
+	return obj, nil
+}
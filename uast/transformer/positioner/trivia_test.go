@@ -0,0 +1,67 @@
+package positioner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"gopkg.in/bblfsh/sdk.v2/uast"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+func TestTrivia(t *testing.T) {
+	const source = "a = b + c;\n"
+	ident := func(off int) nodes.Object {
+		return nodes.Object{
+			uast.KeyType: nodes.String("test:Ident"),
+			uast.KeyPos:  newPos(off, off+1),
+		}
+	}
+	trivia := func(start, end int) nodes.Object {
+		n := nodes.Object{
+			uast.KeyType: nodes.String(TriviaType),
+			uast.KeyPos:  newPos(start, end),
+			"Text":       nodes.String(source[start:end]),
+		}
+		return n
+	}
+	ast := nodes.Object{
+		uast.KeyType: nodes.String("test:Assign"),
+		uast.KeyPos:  newPos(0, 10),
+		"Left":       ident(0),
+		"Right": nodes.Object{
+			// no positions, should be transparent
+			uast.KeyType: nodes.String("test:Wrap"),
+			"Expr": nodes.Object{
+				uast.KeyType: nodes.String("test:Add"),
+				uast.KeyPos:  newPos(4, 9),
+				"Args":       nodes.Array{ident(4), ident(8)},
+			},
+		},
+	}
+	eol := trivia(10, 11)
+	eol[uast.KeyPos] = uast.Positions{
+		uast.KeyStart: {Offset: 10, Line: 1, Col: 11},
+		uast.KeyEnd:   {Offset: 11, Line: 2, Col: 1},
+	}.ToObject()
+
+	exp := ast.Clone().(nodes.Object)
+	exp[DefaultTriviaKey] = nodes.Array{
+		trivia(1, 4), trivia(9, 10), eol,
+	}
+	add := exp["Right"].(nodes.Object)["Expr"].(nodes.Object)
+	add[DefaultTriviaKey] = nodes.Array{trivia(5, 8)}
+
+	out, err := Trivia{}.OnCode(source).Do(ast)
+	require.NoError(t, err)
+	require.Equal(t, exp, out)
+
+	out, err = Trivia{Key: "ws", OffsetsOnly: true}.OnCode(source).Do(ast)
+	require.NoError(t, err)
+	arr := out.(nodes.Object)["ws"].(nodes.Array)
+	require.Len(t, arr, 3)
+	require.Equal(t, nodes.Object{
+		uast.KeyType: nodes.String(TriviaType),
+		uast.KeyPos:  newPos(1, 4),
+	}, arr[0])
+}
@@ -0,0 +1,432 @@
+package positioner
+
+import (
+	"fmt"
+	"sort"
+	"unicode/utf8"
+
+	"gopkg.in/bblfsh/sdk.v2/uast"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+	"gopkg.in/bblfsh/sdk.v2/uast/transformer"
+)
+
+// Units describes units of offsets and columns reported by a native parser.
+type Units int
+
+const (
+	// Bytes means that offsets and columns are counted in bytes of UTF-8 source.
+	Bytes = Units(iota)
+	// Runes means that offsets and columns are counted in Unicode characters.
+	Runes
+	// UTF16 means that offsets and columns are counted in UTF-16 code units, as in JavaScript or LSP.
+	UTF16
+)
+
+func (u Units) String() string {
+	switch u {
+	case Bytes:
+		return "bytes"
+	case Runes:
+		return "runes"
+	case UTF16:
+		return "utf16"
+	}
+	return fmt.Sprintf("Units(%d)", int(u))
+}
+
+// FromOffsetUnits fills the Line, Col and Offset fields of all Position nodes by interpreting their Offset
+// as a 0-based index in given units. The resulting offsets and columns are in bytes.
+func FromOffsetUnits(u Units) Positioner {
+	switch u {
+	case Runes:
+		return FromUnicodeOffset()
+	case UTF16:
+		return Positioner{units: UTF16, method: fromUTF16Offset}
+	}
+	return FromOffset()
+}
+
+// FromLineColUnits fills the Offset and Col fields of all Position nodes by interpreting their Col as a 1-based
+// column in given units. The resulting offsets and columns are in bytes.
+func FromLineColUnits(u Units) Positioner {
+	switch u {
+	case Runes:
+		return Positioner{units: Runes, method: fromRuneLineCol}
+	case UTF16:
+		return Positioner{units: UTF16, method: fromUTF16LineCol}
+	}
+	return FromLineCol()
+}
+
+// width returns the number of units occupied by a character r that takes n bytes in UTF-8.
+func (u Units) width(r rune, n int) int {
+	switch u {
+	case Runes:
+		return 1
+	case UTF16:
+		return utf16Len(r)
+	}
+	return n
+}
+
+func fromUTF16Offset(idx *Index, pos *uast.Position, o nodes.Object) error {
+	off, err := idx.UTF16ToOffset(int(pos.Offset))
+	if err != nil {
+		return err
+	}
+	pos.Offset = uint32(off)
+	return fromOffset(idx, pos, o)
+}
+
+func fromRuneLineCol(idx *Index, pos *uast.Position, o nodes.Object) error {
+	off, err := idx.RuneColOffset(int(pos.Line), int(pos.Col))
+	if err != nil {
+		return err
+	}
+	pos.Offset = uint32(off)
+	return fromOffset(idx, pos, o)
+}
+
+func fromUTF16LineCol(idx *Index, pos *uast.Position, o nodes.Object) error {
+	off, err := idx.UTF16Offset(int(pos.Line), int(pos.Col))
+	if err != nil {
+		return err
+	}
+	pos.Offset = uint32(off)
+	return fromOffset(idx, pos, o)
+}
+
+func runeLen(r rune) int {
+	return 1
+}
+
+// RuneColOffset returns a zero-based byte offset given a one-based line and a one-based column in Unicode characters.
+func (idx *Index) RuneColOffset(line, col int) (int, error) {
+	return idx.unitOffset(line, col, runeLen)
+}
+
+// UTF16ToOffset returns a zero-based byte offset given a zero-based offset in UTF-16 code units.
+func (idx *Index) UTF16ToOffset(offset int) (int, error) {
+	idx.utf16Once.Do(idx.buildUTF16Lines)
+	if offset < 0 || offset > idx.utf16Size {
+		return -1, fmt.Errorf("offset out of bounds: %d [%d, %d]", offset, 0, idx.utf16Size)
+	}
+	line := sort.Search(len(idx.utf16ByLine), func(i int) bool {
+		return offset < idx.utf16ByLine[i]
+	})
+	return idx.unitOffset(line, offset-idx.utf16ByLine[line-1]+1, utf16Len)
+}
+
+// buildUTF16Lines counts UTF-16 code units before each line.
+func (idx *Index) buildUTF16Lines() {
+	idx.utf16ByLine = make([]int, 0, len(idx.offsetByLine))
+	units, line := 0, 0
+	for i := 0; i < len(idx.data); {
+		for line < len(idx.offsetByLine) && idx.offsetByLine[line] <= i {
+			idx.utf16ByLine = append(idx.utf16ByLine, units)
+			line++
+		}
+		r, n := utf8.DecodeRuneInString(idx.data[i:])
+		units += utf16Len(r)
+		i += n
+	}
+	for ; line < len(idx.offsetByLine); line++ {
+		idx.utf16ByLine = append(idx.utf16ByLine, units)
+	}
+	idx.utf16Size = units
+}
+
+// unitOffset returns a zero-based byte offset given a one-based line and a one-based column in units
+// determined by the width function.
+func (idx *Index) unitOffset(line, col int, width func(r rune) int) (int, error) {
+	if line < 1 || line > len(idx.offsetByLine) {
+		return -1, fmt.Errorf("line out of bounds: %d [%d, %d]", line, 1, len(idx.offsetByLine))
+	}
+	if col < 1 {
+		return -1, fmt.Errorf("column out of bounds: %d", col)
+	}
+	end := idx.size
+	if line < len(idx.offsetByLine) {
+		end = idx.offsetByLine[line]
+	}
+	off := idx.offsetByLine[line-1]
+	for units := col - 1; units > 0; {
+		if off >= end {
+			return -1, fmt.Errorf("column out of bounds: %d", col)
+		}
+		r, n := utf8.DecodeRuneInString(idx.data[off:end])
+		sz := width(r)
+		if sz > units {
+			return -1, fmt.Errorf("column points inside a character: %d", col)
+		}
+		units -= sz
+		off += n
+	}
+	return off, nil
+}
+
+// KeyPosUnits is a name of the root node field that describes units of all positions in the tree.
+// See PositionUnits.
+const KeyPosUnits = "@pos_units"
+
+// PositionUnits describes conventions used by positions of the tree.
+type PositionUnits struct {
+	// Offsets are the units of the Offset field.
+	Offsets Units
+	// Columns are the units of the Col field.
+	Columns Units
+	// ZeroBased is set if lines and columns are 0-based.
+	ZeroBased bool
+}
+
+// DefaultUnits is a convention used by UAST: byte offsets and 1-based byte columns.
+var DefaultUnits = PositionUnits{Offsets: Bytes, Columns: Bytes}
+
+// ToObject converts units to a node.
+func (u PositionUnits) ToObject() nodes.Object {
+	base := 1
+	if u.ZeroBased {
+		base = 0
+	}
+	return nodes.Object{
+		"offsets": nodes.String(u.Offsets.String()),
+		"columns": nodes.String(u.Columns.String()),
+		"base":    nodes.Uint(base),
+	}
+}
+
+func parseUnits(v nodes.Node) (Units, error) {
+	s, _ := v.(nodes.String)
+	for _, u := range []Units{Bytes, Runes, UTF16} {
+		if string(s) == u.String() {
+			return u, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown position units: %v", v)
+}
+
+// UnitsOf returns position units recorded in the root node. It returns false if units are not set.
+func UnitsOf(root nodes.Node) (PositionUnits, bool, error) {
+	obj, ok := root.(nodes.Object)
+	if !ok {
+		return PositionUnits{}, false, nil
+	}
+	m, ok := obj[KeyPosUnits].(nodes.Object)
+	if !ok {
+		return PositionUnits{}, false, nil
+	}
+	var (
+		u   PositionUnits
+		err error
+	)
+	if u.Offsets, err = parseUnits(m["offsets"]); err != nil {
+		return u, false, err
+	}
+	if u.Columns, err = parseUnits(m["columns"]); err != nil {
+		return u, false, err
+	}
+	switch m["base"] {
+	case nodes.Uint(0), nodes.Int(0):
+		u.ZeroBased = true
+	case nodes.Uint(1), nodes.Int(1):
+	default:
+		return u, false, fmt.Errorf("unknown position base: %v", m["base"])
+	}
+	return u, true, nil
+}
+
+// SetUnits records position units in the root node. Root nodes that are not objects are not changed.
+func SetUnits(root nodes.Node, u PositionUnits) nodes.Node {
+	obj, ok := root.(nodes.Object)
+	if !ok {
+		return root
+	}
+	obj = obj.CloneObject()
+	obj[KeyPosUnits] = u.ToObject()
+	return obj
+}
+
+// WithUnitsMetadata returns a copy of the positioner that records DefaultUnits in the root node of the tree.
+// See KeyPosUnits.
+//
+// The returned transformer always processes the whole tree, thus it cannot be used for incremental transformations.
+func (t Positioner) WithUnitsMetadata() transformer.CodeTransformer {
+	return unitsPositioner{t}
+}
+
+type unitsPositioner struct {
+	p Positioner
+}
+
+// OnCode implements transformer.CodeTransformer.
+func (t unitsPositioner) OnCode(code string) transformer.Transformer {
+	return unitsTransformer{t.p.OnCode(code)}
+}
+
+type unitsTransformer struct {
+	tr transformer.Transformer
+}
+
+// Do implements transformer.Transformer.
+func (t unitsTransformer) Do(root nodes.Node) (nodes.Node, error) {
+	out, err := t.tr.Do(root)
+	if err != nil {
+		return out, err
+	}
+	return SetUnits(out, DefaultUnits), nil
+}
+
+// ConvertUnits converts all positions of the tree to given units and records them in the root node.
+// Current units are taken from the root node; DefaultUnits are assumed if they are not set.
+// The tree is not modified.
+func ConvertUnits(code string, root nodes.Node, to PositionUnits) (nodes.Node, error) {
+	from, ok, err := UnitsOf(root)
+	if err != nil {
+		return nil, err
+	} else if !ok {
+		from = DefaultUnits
+	}
+	idx := NewIndex(code)
+	tr := transformer.TransformObjFunc(func(o nodes.Object) (nodes.Object, bool, error) {
+		pos := uast.AsPosition(o)
+		if pos == nil {
+			return o, false, nil
+		}
+		if err := idx.toDefaultUnits(pos, from); err != nil {
+			return o, false, err
+		}
+		if err := idx.fromDefaultUnits(pos, to); err != nil {
+			return o, false, err
+		}
+		o = o.CloneObject()
+		for k, v := range pos.ToObject() {
+			o[k] = v
+		}
+		return o, true, nil
+	})
+	out, err := tr.Do(root.Clone())
+	if err != nil {
+		return nil, err
+	}
+	return SetUnits(out, to), nil
+}
+
+// toDefaultUnits converts a position in given units to DefaultUnits.
+func (idx *Index) toDefaultUnits(pos *uast.Position, u PositionUnits) error {
+	if u.ZeroBased {
+		pos.Line++
+		pos.Col++
+	}
+	off := int(pos.Offset)
+	var err error
+	switch u.Offsets {
+	case Runes:
+		off, err = idx.RuneOffset(off)
+	case UTF16:
+		off, err = idx.UTF16ToOffset(off)
+	}
+	if err != nil {
+		return err
+	}
+	pos.Offset = uint32(off)
+	if pos.Line == 0 {
+		return nil
+	}
+	col := int(pos.Col)
+	switch u.Columns {
+	case Runes:
+		off, err = idx.RuneColOffset(int(pos.Line), col)
+	case UTF16:
+		off, err = idx.UTF16Offset(int(pos.Line), col)
+	default:
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	_, col, err = idx.LineCol(off)
+	pos.Col = uint32(col)
+	return err
+}
+
+// fromDefaultUnits converts a position in DefaultUnits to given units.
+func (idx *Index) fromDefaultUnits(pos *uast.Position, u PositionUnits) error {
+	off := int(pos.Offset)
+	if pos.Line != 0 {
+		boff, err := idx.Offset(int(pos.Line), int(pos.Col))
+		if err != nil {
+			return err
+		}
+		var col int
+		switch u.Columns {
+		case Runes:
+			_, col, err = idx.RuneLineCol(boff)
+		case UTF16:
+			_, col, err = idx.UTF16LineCol(boff)
+		default:
+			col = int(pos.Col)
+		}
+		if err != nil {
+			return err
+		}
+		pos.Col = uint32(col)
+	}
+	var err error
+	switch u.Offsets {
+	case Runes:
+		off, err = idx.ToRuneOffset(off)
+	case UTF16:
+		off, err = idx.ToUTF16Offset(off)
+	}
+	if err != nil {
+		return err
+	}
+	pos.Offset = uint32(off)
+	if u.ZeroBased && pos.Line != 0 {
+		pos.Line--
+		pos.Col--
+	}
+	return nil
+}
+
+// RuneLineCol returns a one-based line and a one-based column in Unicode characters given a zero-based byte offset.
+func (idx *Index) RuneLineCol(offset int) (int, int, error) {
+	line, col, err := idx.LineCol(offset)
+	if err != nil {
+		return 0, 0, err
+	}
+	return line, utf8.RuneCountInString(idx.data[offset-(col-1):offset]) + 1, nil
+}
+
+// ToRuneOffset returns a zero-based Unicode character offset given a zero-based byte offset.
+func (idx *Index) ToRuneOffset(offset int) (int, error) {
+	if offset < 0 || offset > idx.size {
+		return -1, fmt.Errorf("offset out of bounds: %d [%d, %d]", offset, 0, idx.size)
+	}
+	idx.spansOnce.Do(idx.buildSpans)
+	i := sort.Search(len(idx.spans), func(i int) bool {
+		return offset < idx.spans[i].byteOff
+	})
+	if i == 0 {
+		return 0, nil
+	}
+	s := idx.spans[i-1]
+	d := offset - s.byteOff
+	if d > s.numRunes*s.runeSize {
+		d = s.numRunes * s.runeSize
+	}
+	if d%s.runeSize != 0 {
+		return -1, fmt.Errorf("offset points inside a character: %d", offset)
+	}
+	return s.firstRuneInd + d/s.runeSize, nil
+}
+
+// ToUTF16Offset returns a zero-based offset in UTF-16 code units given a zero-based byte offset.
+func (idx *Index) ToUTF16Offset(offset int) (int, error) {
+	line, col, err := idx.UTF16LineCol(offset)
+	if err != nil {
+		return -1, err
+	}
+	idx.utf16Once.Do(idx.buildUTF16Lines)
+	return idx.utf16ByLine[line-1] + col - 1, nil
+}
@@ -0,0 +1,86 @@
+package positioner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+func TestUnits(t *testing.T) {
+	const source = "a😀b\nёc\n"
+	var cases = []struct {
+		units     Units
+		off       int
+		line, col int
+		// expected byte offset
+		exp int
+	}{
+		{units: Bytes, off: 5, line: 1, col: 6, exp: 5},
+		{units: Runes, off: 2, line: 1, col: 3, exp: 5},
+		{units: Runes, off: 5, line: 2, col: 2, exp: 9},
+		{units: UTF16, off: 3, line: 1, col: 4, exp: 5},
+		{units: UTF16, off: 6, line: 2, col: 2, exp: 9},
+		// special case — EOF position
+		{units: UTF16, off: 8, line: 3, col: 1, exp: 11},
+	}
+	idx := NewIndex(source)
+	for _, c := range cases {
+		t.Run(c.units.String(), func(t *testing.T) {
+			line, col, err := idx.LineCol(c.exp)
+			require.NoError(t, err)
+			exp := fullPos(c.exp, line, col)
+
+			out, err := FromOffsetUnits(c.units).OnCode(source).Do(offset(c.off))
+			require.NoError(t, err)
+			require.Equal(t, exp, out)
+
+			out, err = FromLineColUnits(c.units).OnCode(source).Do(lineCol(c.line, c.col))
+			require.NoError(t, err)
+			require.Equal(t, exp, out)
+		})
+	}
+}
+
+func TestConvertUnits(t *testing.T) {
+	const source = "a😀b\nёc\n"
+	root := nodes.Object{
+		"a": fullPos(5, 1, 6),
+		"b": fullPos(9, 2, 3),
+	}
+	lsp := PositionUnits{Offsets: UTF16, Columns: UTF16, ZeroBased: true}
+	out, err := ConvertUnits(source, root, lsp)
+	require.NoError(t, err)
+	require.Equal(t, nodes.Object{
+		KeyPosUnits: lsp.ToObject(),
+		"a":         fullPos(3, 0, 3),
+		"b":         fullPos(6, 1, 1),
+	}, out)
+
+	u, ok, err := UnitsOf(out)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, lsp, u)
+
+	runes := PositionUnits{Offsets: Runes, Columns: Runes}
+	out, err = ConvertUnits(source, out, runes)
+	require.NoError(t, err)
+	require.Equal(t, nodes.Object{
+		KeyPosUnits: runes.ToObject(),
+		"a":         fullPos(2, 1, 3),
+		"b":         fullPos(5, 2, 2),
+	}, out)
+
+	out, err = ConvertUnits(source, out, DefaultUnits)
+	require.NoError(t, err)
+	root[KeyPosUnits] = DefaultUnits.ToObject()
+	require.Equal(t, root, out)
+
+	out, err = FromOffset().WithUnitsMetadata().OnCode(source).Do(nodes.Object{"a": offset(5)})
+	require.NoError(t, err)
+	require.Equal(t, nodes.Object{
+		KeyPosUnits: DefaultUnits.ToObject(),
+		"a":         fullPos(5, 1, 6),
+	}, out)
+}
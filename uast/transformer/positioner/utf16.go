@@ -0,0 +1,84 @@
+package positioner
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"gopkg.in/bblfsh/sdk.v2/uast"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+// KeyPosUTF16Col is a name for a Position object field that stores a column in UTF-16 code units.
+// It is a 1-based index, thus the LSP "character" of the position is the value minus one.
+const KeyPosUTF16Col = "col16"
+
+// ToUTF16 fills the Line, Col and the UTF-16 column (see KeyPosUTF16Col) of all Position nodes by using their Offset.
+//
+// The result is compatible with the Language Server Protocol: characters outside of the Basic Multilingual Plane
+// are counted as two code units (a surrogate pair).
+func ToUTF16() Positioner {
+	return Positioner{method: toUTF16}
+}
+
+// FromUTF16 fills the Offset and Col fields of all Position nodes by using their Line and the UTF-16 column
+// (see KeyPosUTF16Col).
+func FromUTF16() Positioner {
+	return Positioner{method: fromUTF16}
+}
+
+func toUTF16(idx *Index, pos *uast.Position, o nodes.Object) error {
+	if err := fromOffset(idx, pos, o); err != nil {
+		return err
+	}
+	_, col, err := idx.UTF16LineCol(int(pos.Offset))
+	if err != nil {
+		return err
+	}
+	o[KeyPosUTF16Col] = nodes.Uint(col)
+	return nil
+}
+
+func fromUTF16(idx *Index, pos *uast.Position, o nodes.Object) error {
+	col, ok := o[KeyPosUTF16Col].(nodes.Uint)
+	if !ok {
+		return fmt.Errorf("position has no UTF-16 column: %v", o)
+	}
+	off, err := idx.UTF16Offset(int(pos.Line), int(col))
+	if err != nil {
+		return err
+	}
+	pos.Offset = uint32(off)
+	return fromOffset(idx, pos, o)
+}
+
+// utf16Len returns the number of UTF-16 code units in a rune. Invalid UTF-8 bytes are counted as a single unit.
+func utf16Len(r rune) int {
+	if r >= 0x10000 && r <= utf8.MaxRune {
+		return 2
+	}
+	return 1
+}
+
+// UTF16LineCol returns a one-based line and a one-based column in UTF-16 code units given a zero-based byte offset.
+func (idx *Index) UTF16LineCol(offset int) (int, int, error) {
+	line, col, err := idx.LineCol(offset)
+	if err != nil {
+		return 0, 0, err
+	}
+	start := offset - (col - 1)
+	units := 0
+	for i := start; i < offset; {
+		r, n := utf8.DecodeRuneInString(idx.data[i:offset])
+		if n == 0 {
+			break
+		}
+		units += utf16Len(r)
+		i += n
+	}
+	return line, units + 1, nil
+}
+
+// UTF16Offset returns a zero-based byte offset given a one-based line and a one-based column in UTF-16 code units.
+func (idx *Index) UTF16Offset(line, col int) (int, error) {
+	return idx.unitOffset(line, col, utf16Len)
+}
@@ -0,0 +1,54 @@
+package positioner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"gopkg.in/bblfsh/sdk.v2/uast"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+func TestUTF16(t *testing.T) {
+	const source = "a😀b\nё\n"
+	var cases = []struct {
+		off, line, col, col16 int
+	}{
+		{off: 0, line: 1, col: 1, col16: 1},
+		{off: 1, line: 1, col: 2, col16: 2},
+		// surrogate pair
+		{off: 5, line: 1, col: 6, col16: 4},
+		{off: 6, line: 1, col: 7, col16: 5},
+
+		{off: 7, line: 2, col: 1, col16: 1},
+		{off: 9, line: 2, col: 3, col16: 2},
+
+		// special case — EOF position
+		{off: 10, line: 3, col: 1, col16: 1},
+	}
+	for _, c := range cases {
+		t.Run("", func(t *testing.T) {
+			exp := fullPos(c.off, c.line, c.col)
+			exp[KeyPosUTF16Col] = nodes.Uint(c.col16)
+
+			out, err := FromUTF16().OnCode(source).Do(nodes.Object{
+				uast.KeyType:    nodes.String(uast.TypePosition),
+				uast.KeyPosLine: nodes.Uint(c.line),
+				KeyPosUTF16Col:  nodes.Uint(c.col16),
+			})
+			require.NoError(t, err)
+			require.Equal(t, exp, out)
+
+			out, err = ToUTF16().OnCode(source).Do(offset(c.off))
+			require.NoError(t, err)
+			require.Equal(t, exp, out)
+		})
+	}
+
+	_, err := FromUTF16().OnCode(source).Do(nodes.Object{
+		uast.KeyType:    nodes.String(uast.TypePosition),
+		uast.KeyPosLine: nodes.Uint(1),
+		KeyPosUTF16Col:  nodes.Uint(3),
+	})
+	require.Error(t, err)
+}
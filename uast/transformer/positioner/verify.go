@@ -0,0 +1,153 @@
+package positioner
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/bblfsh/sdk.v2/uast"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+	"gopkg.in/bblfsh/sdk.v2/uast/transformer"
+)
+
+var _ transformer.CodeTransformer = VerifySpans{}
+
+// VerifySpans is a code-assisted transformation that checks that the token of each node matches the source
+// fragment referred by its positions. It does not change the tree, but returns a SpanError listing all mismatches.
+//
+// It is useful in driver tests to catch errors in positional information.
+type VerifySpans struct {
+	// Key is the name of the token field to check. Uses uast.KeyToken, if not set.
+	Key string
+	// Types is the list of node types that will be checked. Empty means all nodes.
+	Types []string
+	// Normalize is an optional function that is applied both to the token and to the source fragment
+	// before comparing them. For example, strings.TrimSpace.
+	Normalize func(s string) string
+}
+
+// SpanMismatch describes a node with a token that doesn't match its positions.
+type SpanMismatch struct {
+	// Path is the path to the node from the root, for example "Body[2].Name".
+	Path string
+	// Type is the type of the node.
+	Type string
+	// Token is the token of the node.
+	Token string
+	// Source is the fragment of the source referred by positions of the node.
+	Source string
+	// Positions of the node.
+	Positions uast.Positions
+}
+
+func (m SpanMismatch) String() string {
+	return fmt.Sprintf("%s (%s): %q vs %q", m.Path, m.Type, m.Token, m.Source)
+}
+
+// SpanError is returned by VerifySpans if any of the nodes has a token that doesn't match its positions.
+type SpanError struct {
+	Mismatches []SpanMismatch
+}
+
+func (e *SpanError) Error() string {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "%d token(s) do not match the source:", len(e.Mismatches))
+	for _, m := range e.Mismatches {
+		buf.WriteString("\n\t")
+		buf.WriteString(m.String())
+	}
+	return buf.String()
+}
+
+// Verify checks that the tokens of the tree match the source code. See VerifySpans.
+func (t VerifySpans) Verify(code string, root nodes.Node) error {
+	_, err := t.OnCode(code).Do(root)
+	return err
+}
+
+// OnCode implements transformer.CodeTransformer.
+func (t VerifySpans) OnCode(code string) transformer.Transformer {
+	return &verifySpans{
+		tokenFilter: newTokenFilter(code, t.Key, t.Types),
+		norm:        t.Normalize,
+	}
+}
+
+type verifySpans struct {
+	tokenFilter
+	norm func(s string) string
+	errs []SpanMismatch
+}
+
+// Do implements transformer.Transformer. See VerifySpans.
+func (t *verifySpans) Do(root nodes.Node) (nodes.Node, error) {
+	t.errs = nil
+	if err := t.visit(nil, root); err != nil {
+		return root, err
+	}
+	if len(t.errs) != 0 {
+		return root, &SpanError{Mismatches: t.errs}
+	}
+	return root, nil
+}
+
+func (t *verifySpans) visit(path []string, n nodes.Node) error {
+	switch n := n.(type) {
+	case nodes.Object:
+		if err := t.check(path, n); err != nil {
+			return err
+		}
+		for _, k := range n.Keys() {
+			if k == uast.KeyPos {
+				continue
+			}
+			if err := t.visit(append(path, "."+k), n[k]); err != nil {
+				return err
+			}
+		}
+	case nodes.Array:
+		for i, v := range n {
+			if err := t.visit(append(path, "["+strconv.Itoa(i)+"]"), v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (t *verifySpans) check(path []string, obj nodes.Object) error {
+	if _, ok := t.filterObj(obj); !ok {
+		return nil
+	}
+	token, ok := obj[t.tokenKey].(nodes.String)
+	if !ok {
+		return nil
+	}
+	src, ok, err := t.tokenFromPos(obj)
+	if err != nil {
+		return fmt.Errorf("%s: %v", pathString(path), err)
+	} else if !ok {
+		return nil
+	}
+	exp, got := string(token), src
+	if t.norm != nil {
+		exp, got = t.norm(exp), t.norm(got)
+	}
+	if exp != got {
+		t.errs = append(t.errs, SpanMismatch{
+			Path:      pathString(path),
+			Type:      uast.TypeOf(obj),
+			Token:     string(token),
+			Source:    src,
+			Positions: uast.PositionsOf(obj),
+		})
+	}
+	return nil
+}
+
+func pathString(path []string) string {
+	if len(path) == 0 {
+		return "<root>"
+	}
+	return strings.TrimPrefix(strings.Join(path, ""), ".")
+}
@@ -0,0 +1,51 @@
+package positioner
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"gopkg.in/bblfsh/sdk.v2/uast"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+func TestVerifySpans(t *testing.T) {
+	const source = " var a, b int"
+	ident := func(tok string, start, end int) nodes.Object {
+		return nodes.Object{
+			uast.KeyType:  nodes.String("test:Ident"),
+			uast.KeyToken: nodes.String(tok),
+			uast.KeyPos:   newPos(start, end),
+		}
+	}
+	ast := nodes.Object{
+		uast.KeyType: nodes.String("test:Var"),
+		uast.KeyPos:  newPos(1, 13),
+		"Names": nodes.Array{
+			ident("a", 5, 6),
+			ident("b", 7, 9),
+		},
+		"Type": ident("int", 9, 13),
+	}
+
+	err := VerifySpans{}.Verify(source, ast)
+	require.Error(t, err)
+	serr, ok := err.(*SpanError)
+	require.True(t, ok, "%T", err)
+	require.Equal(t, []SpanMismatch{
+		{
+			Path: "Names[1]", Type: "test:Ident",
+			Token: "b", Source: " b",
+			Positions: uast.PositionsOf(ast["Names"].(nodes.Array)[1]),
+		},
+		{
+			Path: "Type", Type: "test:Ident",
+			Token: "int", Source: " int",
+			Positions: uast.PositionsOf(ast["Type"]),
+		},
+	}, serr.Mismatches)
+
+	err = VerifySpans{Normalize: strings.TrimSpace}.Verify(source, ast)
+	require.NoError(t, err)
+}
@@ -0,0 +1,117 @@
+package transformer
+
+import (
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+// WithProfile returns a copy of the transformer that measures the time spent in each mapping rule.
+// It works only for transformers created with Mappings, and returns nil profile for all other types.
+//
+// If allocs is set, the profile will also record memory allocations for each rule. This requires reading
+// runtime memory statistics on each rule execution and will slow down the transformation significantly.
+func WithProfile(t Transformer, allocs bool) (Transformer, *Profile) {
+	m, ok := t.(mappings)
+	if !ok {
+		return t, nil
+	}
+	m.prof = &Profile{
+		allocs: allocs,
+		rules:  make([]RuleProfile, len(m.all)),
+	}
+	for i := range m.prof.rules {
+		m.prof.rules[i].Index = i
+	}
+	return m, m.prof
+}
+
+// RuleProfile contains execution statistics for a single mapping rule.
+type RuleProfile struct {
+	Index   int           // index of the rule in Mappings
+	Calls   int           // number of times the rule was tried
+	Matched int           // number of times the rule matched a node
+	Time    time.Duration // total time spent in Check and Construct
+	Allocs  uint64        // number of heap allocations; only set if allocs were enabled
+	Bytes   uint64        // bytes allocated on the heap; only set if allocs were enabled
+}
+
+// Profile collects execution statistics for each mapping rule. See WithProfile.
+//
+// It is safe to use the same profile from multiple goroutines, but allocation statistics will not be accurate
+// in this case.
+type Profile struct {
+	allocs bool
+
+	mu    sync.Mutex
+	rules []RuleProfile
+}
+
+type profileSample struct {
+	start  time.Time
+	allocs uint64
+	bytes  uint64
+}
+
+func (p *Profile) start() *profileSample {
+	if p == nil {
+		return nil
+	}
+	s := &profileSample{}
+	if p.allocs {
+		var ms runtime.MemStats
+		runtime.ReadMemStats(&ms)
+		s.allocs, s.bytes = ms.Mallocs, ms.TotalAlloc
+	}
+	s.start = time.Now()
+	return s
+}
+
+func (p *Profile) stop(ind int, s *profileSample, matched bool) {
+	if p == nil || s == nil {
+		return
+	}
+	dt := time.Since(s.start)
+	var allocs, bytes uint64
+	if p.allocs {
+		var ms runtime.MemStats
+		runtime.ReadMemStats(&ms)
+		allocs, bytes = ms.Mallocs-s.allocs, ms.TotalAlloc-s.bytes
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if ind < 0 || ind >= len(p.rules) {
+		return
+	}
+	r := &p.rules[ind]
+	r.Calls++
+	if matched {
+		r.Matched++
+	}
+	r.Time += dt
+	r.Allocs += allocs
+	r.Bytes += bytes
+}
+
+// Rules returns statistics for all rules, sorted by the time spent in each rule, in descending order.
+func (p *Profile) Rules() []RuleProfile {
+	p.mu.Lock()
+	out := append([]RuleProfile{}, p.rules...)
+	p.mu.Unlock()
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Time > out[j].Time
+	})
+	return out
+}
+
+// Total returns the total time spent in all rules.
+func (p *Profile) Total() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var dt time.Duration
+	for _, r := range p.rules {
+		dt += r.Time
+	}
+	return dt
+}
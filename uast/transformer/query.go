@@ -0,0 +1,136 @@
+package transformer
+
+import (
+	"gopkg.in/bblfsh/sdk.v2/uast"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+	"gopkg.in/bblfsh/sdk.v2/uast/query"
+	"gopkg.in/bblfsh/sdk.v2/uast/role"
+)
+
+// QueryMapping returns a transformer that selects nodes with a query and applies the mapping to each of them.
+// Nodes that do not match the source operation of the mapping are left unchanged.
+//
+// The query is executed on the input tree, thus the mapping cannot select nodes created by the same transformer.
+func QueryMapping(q query.Query, m Mapping) Transformer {
+	src, dst := m.Mapping()
+	return &queryTransform{q: q, fnc: func(obj nodes.Object) (nodes.Object, error) {
+		st := NewState()
+		if ok, err := src.Check(st, obj); err != nil || !ok {
+			return nil, err
+		}
+		nn, err := dst.Construct(st, nil)
+		if err != nil {
+			return nil, err
+		}
+		out, ok := nn.(nodes.Object)
+		if !ok {
+			return nil, ErrExpectedObject.New(nn)
+		}
+		return out, nil
+	}}
+}
+
+// AnnotateQuery returns a transformer that selects nodes with a query and adds roles to each of them.
+// Roles that are already set on the node are not duplicated.
+//
+// It is useful for roles that depend on a deep context of the node, which is hard to express with mappings.
+func AnnotateQuery(q query.Query, roles ...role.Role) Transformer {
+	return &queryTransform{q: q, fnc: func(obj nodes.Object) (nodes.Object, error) {
+		var old role.Roles
+		if _, ok := obj[uast.KeyRoles]; ok {
+			old = uast.RolesOf(obj)
+		}
+		seen := make(map[role.Role]struct{}, len(old)+len(roles))
+		for _, r := range old {
+			seen[r] = struct{}{}
+		}
+		out := append(role.Roles{}, old...)
+		for _, r := range roles {
+			if _, ok := seen[r]; !ok {
+				seen[r] = struct{}{}
+				out = append(out, r)
+			}
+		}
+		if len(out) == len(old) {
+			return nil, nil
+		}
+		obj = obj.CloneObject()
+		obj[uast.KeyRoles] = uast.RoleList(out...)
+		return obj, nil
+	}}
+}
+
+type queryTransform struct {
+	q query.Query
+	// fnc is called for each selected object. It returns nil if the object was not changed.
+	fnc func(obj nodes.Object) (nodes.Object, error)
+}
+
+func (t *queryTransform) Do(root nodes.Node) (nodes.Node, error) {
+	it, err := t.q.Execute(root)
+	if err != nil {
+		return nil, err
+	}
+	sel := make(map[nodes.Comparable]struct{})
+	for it.Next() {
+		if obj, ok := it.Node().(nodes.Object); ok {
+			sel[nodes.UniqueKey(obj)] = struct{}{}
+		}
+	}
+	if len(sel) == 0 {
+		return root, nil
+	}
+	n, _, err := t.apply(root, sel)
+	return n, err
+}
+
+// apply runs the function on all selected nodes of the tree. Selected nodes are identified before the children
+// are changed, since changing the children produces new parent nodes.
+func (t *queryTransform) apply(n nodes.Node, sel map[nodes.Comparable]struct{}) (nodes.Node, bool, error) {
+	var changed bool
+	switch nd := n.(type) {
+	case nodes.Object:
+		_, match := sel[nodes.UniqueKey(nd)]
+		var nn nodes.Object
+		for k, v := range nd {
+			nv, ok, err := t.apply(v, sel)
+			if err != nil {
+				return nil, false, err
+			} else if ok {
+				if nn == nil {
+					nn = nd.CloneObject()
+				}
+				nn[k] = nv
+			}
+		}
+		if nn != nil {
+			nd, changed = nn, true
+		}
+		if match {
+			out, err := t.fnc(nd)
+			if err != nil {
+				return nil, false, err
+			} else if out != nil {
+				nd, changed = out, true
+			}
+		}
+		return nd, changed, nil
+	case nodes.Array:
+		var nn nodes.Array
+		for i, v := range nd {
+			nv, ok, err := t.apply(v, sel)
+			if err != nil {
+				return nil, false, err
+			} else if ok {
+				if nn == nil {
+					nn = nd.CloneList()
+				}
+				nn[i] = nv
+			}
+		}
+		if nn != nil {
+			return nn, true, nil
+		}
+	}
+	return n, false, nil
+}
@@ -0,0 +1,152 @@
+package transformer
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+// WithReverseCheck returns a copy of the transformer that verifies that each applied rule is reversible. For each
+// node matched by a rule, the reversed rule is applied to the result, and the reconstructed node is compared to the
+// original one. Only the first divergence is recorded for each rule.
+//
+// It works only for transformers created with Mappings, and returns a nil checker for all other types.
+func WithReverseCheck(t Transformer) (Transformer, *ReverseCheck) {
+	m, ok := t.(mappings)
+	if !ok {
+		return t, nil
+	}
+	m.rev = &ReverseCheck{
+		checked: make([]int, len(m.all)),
+		first:   make(map[int]Divergence),
+	}
+	return m, m.rev
+}
+
+// Divergence describes a node that cannot be reconstructed by reversing a mapping rule.
+type Divergence struct {
+	Rule     int        // index of the rule
+	Path     string     // path to the first node that differs; empty for the root
+	Input    nodes.Node // original node
+	Output   nodes.Node // node produced by the rule
+	Reversed nodes.Node // node produced by the reversed rule; nil if the reversal failed
+	Err      error      // reversal error, if any
+}
+
+func (d Divergence) Error() string {
+	if d.Err != nil {
+		return fmt.Sprintf("rule %d: cannot reverse: %v", d.Rule, d.Err)
+	}
+	path := d.Path
+	if path == "" {
+		path = "root"
+	}
+	return fmt.Sprintf("rule %d: reversed node differs at %s", d.Rule, path)
+}
+
+// ReverseCheck records the results of reversibility checks of mapping rules. See WithReverseCheck.
+//
+// It is safe to use the same checker from multiple goroutines.
+type ReverseCheck struct {
+	mu      sync.Mutex
+	checked []int              // by rule index
+	first   map[int]Divergence // first divergence, by rule index
+}
+
+func (c *ReverseCheck) check(ind int, ctx *nodeContext, file *Context, mp Mapping, in, out nodes.Node) {
+	if c == nil || ind < 0 {
+		return
+	}
+	d := Divergence{Rule: ind, Input: in, Output: out}
+	st := NewState()
+	if file != nil {
+		// reversed rule must not change the context used by the transformation
+		st.file = file.clone()
+	}
+	rev, matched, err := applyRule(st, ctx, Reverse(mp), out, nil)
+	if err == nil && !matched {
+		err = fmt.Errorf("reversed rule does not match the node")
+	}
+	diff := false
+	if err != nil {
+		d.Err = err
+		diff = true
+	} else {
+		d.Reversed = rev
+		d.Path, diff = firstDiff("", in, rev)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if ind < len(c.checked) {
+		c.checked[ind]++
+	}
+	if _, ok := c.first[ind]; diff && !ok {
+		c.first[ind] = d
+	}
+}
+
+// Checked returns the number of times each rule was checked, by the index of the rule.
+func (c *ReverseCheck) Checked() []int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]int{}, c.checked...)
+}
+
+// Divergences returns the first divergence for each rule that failed the check, sorted by the rule index.
+func (c *ReverseCheck) Divergences() []Divergence {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make([]Divergence, 0, len(c.first))
+	for _, d := range c.first {
+		out = append(out, d)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Rule < out[j].Rule
+	})
+	return out
+}
+
+// firstDiff returns a path to the first node that differs in two trees.
+func firstDiff(path string, a, b nodes.Node) (string, bool) {
+	if nodes.Equal(a, b) {
+		return "", false
+	}
+	switch a := a.(type) {
+	case nodes.Object:
+		b, ok := b.(nodes.Object)
+		if !ok {
+			break
+		}
+		keys := a.Keys()
+		for _, k := range b.Keys() {
+			if _, ok := a[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			va, ok1 := a[k]
+			vb, ok2 := b[k]
+			sub := path + "." + k
+			if ok1 != ok2 {
+				return sub, true
+			} else if p, ok := firstDiff(sub, va, vb); ok {
+				return p, true
+			}
+		}
+	case nodes.Array:
+		b, ok := b.(nodes.Array)
+		if !ok || len(a) != len(b) {
+			break
+		}
+		for i := range a {
+			if p, ok := firstDiff(path+"["+strconv.Itoa(i)+"]", a[i], b[i]); ok {
+				return p, true
+			}
+		}
+	}
+	return path, true
+}
@@ -0,0 +1,264 @@
+// Package rules loads declarative transformation rules from YAML files.
+//
+// The file defines annotations and mappings that are compiled into regular transformer operations:
+//
+//   annotations:
+//     - type: Identifier
+//       roles: [Identifier, Expression]
+//       fields:
+//         Name: {rename: "@token"}
+//         Body: {roles: [Body], arr: true}
+//   mappings:
+//     - src: {"@type": "Ident", "name": "$x"}
+//       dst: {"@type": "Identifier", "Name": "$x"}
+//
+// Mapping patterns are converted into operations as follows:
+//  * objects are converted to Obj; the "..." key can be used to store all unused fields into a variable (see Part)
+//  * arrays are converted to Arr
+//  * strings starting with "$" are converted to Var; "$$" can be used to escape the "$" character
+//  * all other values are converted to Is
+package rules
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/src-d/go-errors.v1"
+	"gopkg.in/yaml.v2"
+
+	"gopkg.in/bblfsh/sdk.v2/uast"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+	"gopkg.in/bblfsh/sdk.v2/uast/role"
+	"gopkg.in/bblfsh/sdk.v2/uast/transformer"
+)
+
+// PartKey is a special key in object patterns that stores all unused fields into a variable.
+const PartKey = "..."
+
+var (
+	// ErrInvalidRule is returned when a rule in the file cannot be compiled.
+	ErrInvalidRule = errors.NewKind("invalid rule %s")
+	// ErrUnknownRole is returned when a rule references a role that does not exist.
+	ErrUnknownRole = errors.NewKind("unknown role: %q")
+)
+
+// File is a set of rules loaded from a single file.
+type File struct {
+	Annotations []Annotation `yaml:"annotations"`
+	Mappings    []MapRule    `yaml:"mappings"`
+}
+
+// Annotation assigns roles to a node of a specific type and its fields. See transformer.AnnotateType.
+type Annotation struct {
+	Type   string           `yaml:"type"`
+	Roles  []string         `yaml:"roles"`
+	Fields map[string]Field `yaml:"fields"`
+}
+
+// Field describes roles assigned to a specific field. See transformer.FieldRole.
+type Field struct {
+	Rename string   `yaml:"rename"`
+	Skip   bool     `yaml:"skip"`
+	Add    bool     `yaml:"add"`
+	Opt    bool     `yaml:"opt"`
+	Arr    bool     `yaml:"arr"`
+	Roles  []string `yaml:"roles"`
+}
+
+// MapRule is a two-way mapping between two node patterns.
+type MapRule struct {
+	Src interface{} `yaml:"src"`
+	Dst interface{} `yaml:"dst"`
+}
+
+// Parse reads rules from YAML data.
+func Parse(data []byte) (*File, error) {
+	var f File
+	if err := yaml.UnmarshalStrict(data, &f); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// Load reads rules from r and compiles them into mappings.
+func Load(r io.Reader) ([]transformer.Mapping, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	f, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	return f.Compile()
+}
+
+// LoadFile reads rules from a file and compiles them into mappings.
+func LoadFile(path string) ([]transformer.Mapping, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Load(f)
+}
+
+// Compile converts all rules to mappings. Annotations are returned first, followed by other mappings.
+func (f *File) Compile() ([]transformer.Mapping, error) {
+	out := make([]transformer.Mapping, 0, len(f.Annotations)+len(f.Mappings))
+	for i, a := range f.Annotations {
+		m, err := a.Compile()
+		if err != nil {
+			return nil, ErrInvalidRule.Wrap(err, fmt.Sprintf("annotations[%d] (%s)", i, a.Type))
+		}
+		out = append(out, m)
+	}
+	for i, r := range f.Mappings {
+		m, err := r.Compile()
+		if err != nil {
+			return nil, ErrInvalidRule.Wrap(err, fmt.Sprintf("mappings[%d]", i))
+		}
+		out = append(out, m)
+	}
+	return out, nil
+}
+
+// Compile converts an annotation to a mapping.
+func (a Annotation) Compile() (_ transformer.Mapping, gerr error) {
+	if a.Type == "" {
+		return nil, fmt.Errorf("type is not set")
+	}
+	roles, err := toRoles(a.Roles)
+	if err != nil {
+		return nil, err
+	}
+	var fields transformer.ObjMapping
+	if len(a.Fields) != 0 {
+		fr := make(transformer.FieldRoles, len(a.Fields))
+		for name, f := range a.Fields {
+			froles, err := toRoles(f.Roles)
+			if err != nil {
+				return nil, fmt.Errorf("field %q: %v", name, err)
+			}
+			fr[name] = transformer.FieldRole{
+				Rename: f.Rename,
+				Skip:   f.Skip, Add: f.Add,
+				Opt: f.Opt, Arr: f.Arr,
+				Roles: froles,
+			}
+		}
+		fields = fr
+	}
+	// FieldRoles panics on invalid field definitions
+	defer func() {
+		if r := recover(); r != nil {
+			gerr = fmt.Errorf("%v", r)
+		}
+	}()
+	return transformer.AnnotateType(a.Type, fields, roles...), nil
+}
+
+// Compile converts a rule to a mapping.
+func (r MapRule) Compile() (transformer.Mapping, error) {
+	src, err := Pattern(r.Src)
+	if err != nil {
+		return nil, fmt.Errorf("src: %v", err)
+	}
+	dst, err := Pattern(r.Dst)
+	if err != nil {
+		return nil, fmt.Errorf("dst: %v", err)
+	}
+	return transformer.Map(src, dst), nil
+}
+
+func toRoles(names []string) ([]role.Role, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	out := make([]role.Role, 0, len(names))
+	for _, name := range names {
+		r := role.FromString(name)
+		if r == role.Invalid {
+			return nil, ErrUnknownRole.New(name)
+		}
+		out = append(out, r)
+	}
+	return out, nil
+}
+
+// Pattern converts a pattern decoded from YAML to a transformer operation.
+func Pattern(o interface{}) (transformer.Op, error) {
+	switch o := o.(type) {
+	case string:
+		if strings.HasPrefix(o, "$$") {
+			return transformer.String(o[1:]), nil
+		} else if strings.HasPrefix(o, "$") {
+			if len(o) == 1 {
+				return nil, fmt.Errorf("empty variable name")
+			}
+			return transformer.Var(o[1:]), nil
+		}
+		return transformer.String(o), nil
+	case []interface{}:
+		ops := make([]transformer.Op, 0, len(o))
+		for i, e := range o {
+			op, err := Pattern(e)
+			if err != nil {
+				return nil, fmt.Errorf("elem %d: %v", i, err)
+			}
+			ops = append(ops, op)
+		}
+		return transformer.Arr(ops...), nil
+	case map[interface{}]interface{}:
+		keys := make([]string, 0, len(o))
+		for k := range o {
+			ks, ok := k.(string)
+			if !ok {
+				return nil, fmt.Errorf("unexpected key type: %T", k)
+			}
+			keys = append(keys, ks)
+		}
+		sort.Strings(keys)
+		obj := make(transformer.Obj, len(o))
+		part := ""
+		for _, k := range keys {
+			v := o[k]
+			if k == PartKey {
+				s, ok := v.(string)
+				if !ok || !strings.HasPrefix(s, "$") || len(s) == 1 {
+					return nil, fmt.Errorf("%q should be set to a variable", PartKey)
+				}
+				part = s[1:]
+				continue
+			}
+			op, err := Pattern(v)
+			if err != nil {
+				return nil, fmt.Errorf("key %q: %v", k, err)
+			}
+			obj[k] = op
+		}
+		if part != "" {
+			return transformer.Part(part, obj), nil
+		}
+		return obj, nil
+	}
+	n, err := toNode(o)
+	if err != nil {
+		return nil, err
+	}
+	return transformer.Is(n), nil
+}
+
+func toNode(o interface{}) (nodes.Node, error) {
+	switch o := o.(type) {
+	case int:
+		return nodes.Int(o), nil
+	case uint64:
+		return nodes.Uint(o), nil
+	}
+	return uast.ToNode(o)
+}
@@ -0,0 +1,76 @@
+package rules
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"gopkg.in/bblfsh/sdk.v2/uast"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+	"gopkg.in/bblfsh/sdk.v2/uast/role"
+	"gopkg.in/bblfsh/sdk.v2/uast/transformer"
+)
+
+const testRules = `
+annotations:
+  - type: Ident
+    roles: [Identifier, Expression]
+    fields:
+      name: {rename: "@token"}
+mappings:
+  - src: {"@type": "Call", "fn": "$fn", "args": ["$arg"], "...": "$rest"}
+    dst: {"@type": "Call", "func": "$fn", "arg": "$arg", "...": "$rest"}
+`
+
+func TestLoad(t *testing.T) {
+	maps, err := Load(strings.NewReader(testRules))
+	require.NoError(t, err)
+	require.Len(t, maps, 2)
+
+	inp := nodes.Object{
+		uast.KeyType: nodes.String("Call"),
+		"fn": nodes.Object{
+			uast.KeyType: nodes.String("Ident"),
+			"name":       nodes.String("f"),
+		},
+		"args": nodes.Array{nodes.Int(1)},
+		"line": nodes.Int(3),
+	}
+	out, err := transformer.Mappings(maps...).Do(inp)
+	require.NoError(t, err)
+	require.Equal(t, nodes.Object{
+		uast.KeyType: nodes.String("Call"),
+		"func": nodes.Object{
+			uast.KeyType:  nodes.String("Ident"),
+			uast.KeyToken: nodes.String("f"),
+			uast.KeyRoles: uast.RoleList(role.Identifier, role.Expression),
+		},
+		"arg":  nodes.Int(1),
+		"line": nodes.Int(3),
+	}, out)
+}
+
+func TestLoadErrors(t *testing.T) {
+	_, err := Load(strings.NewReader(`
+annotations:
+  - type: Ident
+    roles: [NoSuchRole]
+`))
+	require.True(t, ErrInvalidRule.Is(err), "%v", err)
+
+	_, err = Load(strings.NewReader(`
+annotations:
+  - type: Ident
+    fields:
+      name: {opt: true}
+`))
+	require.True(t, ErrInvalidRule.Is(err), "%v", err)
+
+	_, err = Load(strings.NewReader(`
+mappings:
+  - src: {"...": "rest"}
+    dst: {}
+`))
+	require.True(t, ErrInvalidRule.Is(err), "%v", err)
+}
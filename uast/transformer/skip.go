@@ -0,0 +1,84 @@
+package transformer
+
+import (
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+// SkipSubtrees returns a transformer that leaves subtrees at a given depth unchanged if skip returns true for them.
+// The depth is counted the same way as in Parallel. Nodes above the depth are transformed as usual.
+//
+// It can be used to transform only the parts of the tree that changed since the last transformation.
+//
+// Only transformers created with Mappings, TransformFunc, TransformObjFunc, or wrapped with Tolerant can skip
+// subtrees. For other transformers the function returns false.
+func SkipSubtrees(t Transformer, depth int, skip func(n nodes.Node) bool) (Transformer, bool) {
+	st, ok := t.(subtreeTransformer)
+	if !ok {
+		return t, false
+	}
+	return &skipSubtrees{t: st, depth: depth, skip: skip}, true
+}
+
+var _ subtreeTransformer = (*skipSubtrees)(nil)
+
+type skipSubtrees struct {
+	t     subtreeTransformer
+	depth int
+	skip  func(n nodes.Node) bool
+}
+
+func (t *skipSubtrees) Do(root nodes.Node) (nodes.Node, error) {
+	return t.doContext(nil, root)
+}
+
+func (t *skipSubtrees) doContext(ctx *nodeContext, root nodes.Node) (nodes.Node, error) {
+	return t.do(ctx, root, t.depth)
+}
+
+func (t *skipSubtrees) doNode(ctx *nodeContext, n nodes.Node) (nodes.Node, error) {
+	return t.t.doNode(ctx, n)
+}
+
+func (t *skipSubtrees) do(ctx *nodeContext, n nodes.Node, depth int) (nodes.Node, error) {
+	if depth <= 0 {
+		if t.skip(n) {
+			return n, nil
+		}
+		return t.t.doContext(ctx, n)
+	}
+	var errs []error
+	switch n := n.(type) {
+	case nodes.Object:
+		sub := &nodeContext{up: ctx, parent: n, index: -1}
+		nn := make(nodes.Object, len(n))
+		for k, v := range n {
+			nv, err := t.do(sub, v, depth-1)
+			if err != nil {
+				errs = append(errs, err)
+			}
+			nn[k] = nv
+		}
+		return t.doParent(ctx, nn, errs)
+	case nodes.Array:
+		nn := make(nodes.Array, len(n))
+		for i, v := range n {
+			sub := &nodeContext{up: ctx, parent: n, index: i}
+			nv, err := t.do(sub, v, depth-1)
+			if err != nil {
+				errs = append(errs, err)
+			}
+			nn[i] = nv
+		}
+		return t.doParent(ctx, nn, errs)
+	}
+	return t.t.doContext(ctx, n)
+}
+
+// doParent transforms a node above the skip depth and merges errors of its children.
+func (t *skipSubtrees) doParent(ctx *nodeContext, n nodes.Node, errs []error) (nodes.Node, error) {
+	nn, err := t.t.doNode(ctx, n)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	return nn, NewMultiError(errs...)
+}
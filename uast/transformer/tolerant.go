@@ -0,0 +1,178 @@
+package transformer
+
+import (
+	"fmt"
+	"sync"
+
+	"gopkg.in/bblfsh/sdk.v2/uast"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+// Diagnostic describes a transformation failure or a warning on a specific node.
+type Diagnostic struct {
+	Type    string         // type of the node; empty if unknown
+	Pos     uast.Positions // positions of the node, if any
+	Err     error          // transformation error
+	Warning bool           // the node was transformed, but the transformation reported a warning
+}
+
+func (d Diagnostic) Error() string {
+	typ := d.Type
+	if typ == "" {
+		typ = "node"
+	}
+	if d.Warning {
+		typ = "warning: " + typ
+	}
+	if start := d.Pos.Start(); start != nil && start.Valid() {
+		return fmt.Sprintf("%s at %d:%d: %v", typ, start.Line, start.Col, d.Err)
+	}
+	return fmt.Sprintf("%s: %v", typ, d.Err)
+}
+
+// Diagnostics is a list of non-fatal transformation failures and warnings. See Tolerant and WithWarnings.
+//
+// It is safe to use the same list from multiple goroutines.
+type Diagnostics struct {
+	mu   sync.Mutex
+	list []Diagnostic
+}
+
+// Add appends a diagnostic to the list.
+func (d *Diagnostics) Add(diag Diagnostic) {
+	d.mu.Lock()
+	d.list = append(d.list, diag)
+	d.mu.Unlock()
+}
+
+// AddError records an error returned by a transformer for a given node.
+func (d *Diagnostics) AddError(n nodes.Node, err error) {
+	d.Add(newDiagnostic(n, err))
+}
+
+// List returns all recorded diagnostics.
+func (d *Diagnostics) List() []Diagnostic {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]Diagnostic{}, d.list...)
+}
+
+// Errors returns all recorded diagnostics as a list of errors.
+func (d *Diagnostics) Errors() []error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	errs := make([]error, 0, len(d.list))
+	for _, diag := range d.list {
+		errs = append(errs, diag)
+	}
+	return errs
+}
+
+// Tolerant runs the transformation and records all errors to diag instead of returning them. Nodes that
+// failed to transform are left unchanged in the tree.
+//
+// For transformers created with Mappings, TransformFunc or TransformObjFunc, diagnostics are recorded for each node
+// that failed, and the result can be passed to Parallel. For other transformers the whole tree is left unchanged
+// in case of an error, and a single diagnostic is recorded.
+func Tolerant(t Transformer, diag *Diagnostics) Transformer {
+	if st, ok := t.(subtreeTransformer); ok {
+		return &tolerantSubtree{t: st, diag: diag}
+	}
+	return &tolerant{t: t, diag: diag}
+}
+
+type tolerant struct {
+	t    Transformer
+	diag *Diagnostics
+}
+
+func (t *tolerant) Do(root nodes.Node) (nodes.Node, error) {
+	nn, err := t.t.Do(root)
+	if err != nil {
+		t.diag.AddError(root, err)
+		return root, nil
+	}
+	return nn, nil
+}
+
+// BestEffort runs the transformation and records an error to diag instead of returning it. Unlike Tolerant, it
+// continues with the tree returned by the transformer together with the error, or with the original tree if the
+// transformer returned no tree.
+func BestEffort(t Transformer, diag *Diagnostics) Transformer {
+	return &bestEffort{t: t, diag: diag}
+}
+
+type bestEffort struct {
+	t    Transformer
+	diag *Diagnostics
+}
+
+func (t *bestEffort) Do(root nodes.Node) (nodes.Node, error) {
+	nn, err := t.t.Do(root)
+	if err == nil {
+		return nn, nil
+	}
+	if m, ok := err.(*MultiError); ok {
+		for _, err := range m.Errs {
+			t.diag.AddError(nil, err)
+		}
+	} else {
+		t.diag.AddError(nil, err)
+	}
+	if nn == nil {
+		nn = root
+	}
+	return nn, nil
+}
+
+var _ subtreeTransformer = (*tolerantSubtree)(nil)
+
+type tolerantSubtree struct {
+	t    subtreeTransformer
+	diag *Diagnostics
+}
+
+func (t *tolerantSubtree) Do(root nodes.Node) (nodes.Node, error) {
+	return t.doContext(nil, root)
+}
+
+func (t *tolerantSubtree) doContext(ctx *nodeContext, root nodes.Node) (nodes.Node, error) {
+	nn, ok := applyContext(root, ctx, func(ctx *nodeContext, n nodes.Node) (nodes.Node, bool) {
+		nn, _ := t.doNode(ctx, n)
+		return nn, !nodes.Same(nn, n)
+	})
+	if !ok {
+		return root, nil
+	}
+	return nn, nil
+}
+
+func (t *tolerantSubtree) doNode(ctx *nodeContext, n nodes.Node) (nodes.Node, error) {
+	nn, err := t.t.doNode(ctx, n)
+	if err != nil {
+		t.diag.AddError(n, err)
+		return n, nil
+	}
+	return nn, nil
+}
+
+// WithWarnings returns a copy of the transformer that records warnings reported by its rules to diag. Warnings are
+// ignored by default. See State.Warn for details.
+// It works only for transformers created with Mappings, and returns all other transformers unchanged.
+func WithWarnings(t Transformer, diag *Diagnostics) Transformer {
+	m, ok := t.(mappings)
+	if !ok {
+		return t
+	}
+	m.diag = diag
+	return m
+}
+
+func newDiagnostic(n nodes.Node, err error) Diagnostic {
+	d := Diagnostic{Err: err}
+	if obj, ok := n.(nodes.Object); ok {
+		d.Type = uast.TypeOf(obj)
+		d.Pos = uast.PositionsOf(obj)
+	}
+	return d
+}
@@ -0,0 +1,132 @@
+package transformer
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"gopkg.in/bblfsh/sdk.v2/uast"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+// Trace is a log of rules tried on each node of the tree. See TraceMappings.
+//
+// It is safe to use the same trace from multiple goroutines. In this case the order of nodes is not defined.
+// Nodes should only be accessed directly when no transformation is running; methods of Trace can be used at any time.
+type Trace struct {
+	mu    sync.Mutex
+	Nodes []NodeTrace
+}
+
+func (t *Trace) addNode(n NodeTrace) {
+	t.mu.Lock()
+	t.Nodes = append(t.Nodes, n)
+	t.mu.Unlock()
+}
+
+// NodeTrace is a list of rules tried on a single node.
+type NodeTrace struct {
+	Input  nodes.Node  // node before the transformation
+	Output nodes.Node  // node after the transformation
+	Rules  []RuleTrace // all rules tried on this node, in the order of execution
+}
+
+// Applied returns indexes of all rules applied to this node.
+func (t *NodeTrace) Applied() []int {
+	var out []int
+	for _, r := range t.Rules {
+		if r.Applied {
+			out = append(out, r.Index)
+		}
+	}
+	return out
+}
+
+func (t *NodeTrace) add(r RuleTrace, err error) {
+	if t == nil {
+		return
+	}
+	r.Err = err
+	t.Rules = append(t.Rules, r)
+}
+
+// RuleTrace describes a single attempt to apply a rule to a node.
+type RuleTrace struct {
+	// Index of the rule in the list passed to TraceMappings.
+	Index int
+	// Applied is set if the rule matched and the node was successfully constructed.
+	Applied bool
+	// Err is set if the rule failed with an error.
+	Err error
+}
+
+// Reason returns a human-readable reason why the rule was or was not applied.
+func (r RuleTrace) Reason() string {
+	switch {
+	case r.Applied:
+		return "applied"
+	case r.Err != nil:
+		return "failed: " + r.Err.Error()
+	}
+	return "no match"
+}
+
+// Reset clears the trace.
+func (t *Trace) Reset() {
+	t.mu.Lock()
+	t.Nodes = nil
+	t.mu.Unlock()
+}
+
+// snapshot returns a copy of the list of traced nodes.
+func (t *Trace) snapshot() []NodeTrace {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]NodeTrace(nil), t.Nodes...)
+}
+
+// Unmatched returns all nodes that were not changed by any rule.
+func (t *Trace) Unmatched() []NodeTrace {
+	var out []NodeTrace
+	for _, n := range t.snapshot() {
+		if len(n.Applied()) == 0 {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// WriteTo writes a human-readable report to w.
+func (t *Trace) WriteTo(w io.Writer) (int64, error) {
+	var total int64
+	for i, n := range t.snapshot() {
+		c, err := fmt.Fprintf(w, "node %d: %s\n", i, traceNodeName(n.Input))
+		total += int64(c)
+		if err != nil {
+			return total, err
+		}
+		for _, r := range n.Rules {
+			c, err = fmt.Fprintf(w, "\trule %d: %s\n", r.Index, r.Reason())
+			total += int64(c)
+			if err != nil {
+				return total, err
+			}
+		}
+	}
+	return total, nil
+}
+
+func traceNodeName(n nodes.Node) string {
+	switch n := n.(type) {
+	case nodes.Object:
+		if typ := uast.TypeOf(n); typ != "" {
+			return typ
+		}
+		return fmt.Sprintf("object %v", n.Keys())
+	case nodes.Array:
+		return fmt.Sprintf("array [%d]", len(n))
+	case nil:
+		return "nil"
+	}
+	return fmt.Sprintf("%T(%v)", n, n)
+}
@@ -80,6 +80,18 @@ func (f TransformFunc) Do(n nodes.Node) (nodes.Node, error) {
 	return n, last
 }
 
+func (f TransformFunc) doContext(_ *nodeContext, root nodes.Node) (nodes.Node, error) {
+	return f.Do(root)
+}
+
+func (f TransformFunc) doNode(_ *nodeContext, n nodes.Node) (nodes.Node, error) {
+	nn, ok, err := f(n)
+	if err != nil || !ok {
+		return n, err
+	}
+	return nn, nil
+}
+
 var _ Transformer = (TransformObjFunc)(nil)
 
 // TransformObjFunc is like TransformFunc, but only matches Object nodes.
@@ -107,6 +119,14 @@ func (f TransformObjFunc) Do(n nodes.Node) (nodes.Node, error) {
 	return f.Func().Do(n)
 }
 
+func (f TransformObjFunc) doContext(ctx *nodeContext, root nodes.Node) (nodes.Node, error) {
+	return f.Func().doContext(ctx, root)
+}
+
+func (f TransformObjFunc) doNode(ctx *nodeContext, n nodes.Node) (nodes.Node, error) {
+	return f.Func().doNode(ctx, n)
+}
+
 // Map creates a two-way mapping between two transform operations.
 // The first operation will be used to check constraints for each node and store state, while the second one will use
 // the state to construct a new tree.
@@ -161,6 +181,12 @@ type objMapping struct {
 	src, dst ObjectOp
 }
 
+// ruleMapping is a pre-compiled mapping that remembers its index in the list of rules passed to Mappings.
+type ruleMapping struct {
+	ind int
+	mapping
+}
+
 func (m objMapping) Mapping() (src, dst Op) {
 	return m.src, m.dst
 }
@@ -181,7 +207,7 @@ func (m mapping) apply(root nodes.Node) (nodes.Node, error) {
 	_, objOp := src.(ObjectOp)
 	_, arrOp := src.(ArrayOp)
 	st := NewState()
-	nn, ok := nodes.Apply(root, func(n nodes.Node) (nodes.Node, bool) {
+	nn, ok := applyContext(root, nil, func(ctx *nodeContext, n nodes.Node) (nodes.Node, bool) {
 		if n != nil {
 			if objOp {
 				if _, ok := n.(nodes.Object); !ok {
@@ -194,6 +220,7 @@ func (m mapping) apply(root nodes.Node) (nodes.Node, error) {
 			}
 		}
 		st.Reset()
+		st.ctx = ctx
 		if ok, err := src.Check(st, n); err != nil {
 			errs = append(errs, errCheck.Wrap(err))
 			return n, false
@@ -229,9 +256,23 @@ func Mappings(maps ...Mapping) Transformer {
 	return mp
 }
 
+// TraceMappings is like Mappings, but records a log of all rules applied to each node into tr.
+func TraceMappings(tr *Trace, maps ...Mapping) Transformer {
+	mp := Mappings(maps...).(mappings)
+	mp.trace = tr
+	return mp
+}
+
 type mappings struct {
 	all []Mapping
 
+	trace *Trace        // optional; records applied rules
+	cov   *Coverage     // optional; counts applied rules
+	prof  *Profile      // optional; measures time spent in each rule
+	rev   *ReverseCheck // optional; checks if applied rules are reversible
+	file  *Context      // optional; transformation context shared by rules
+	diag  *Diagnostics  // optional; collects warnings of applied rules
+
 	// indexed mappings
 
 	byKind map[nodes.Kind][]Mapping // mappings applied to specific node kind
@@ -241,8 +282,9 @@ type mappings struct {
 }
 
 func (m *mappings) index() {
-	precompile := func(m Mapping) Mapping {
-		return Map(m.Mapping())
+	precompile := func(i int, m Mapping) Mapping {
+		src, dst := m.Mapping()
+		return ruleMapping{ind: i, mapping: mapping{src: src, dst: dst}}
 	}
 	type ordered struct {
 		ind int
@@ -252,7 +294,7 @@ func (m *mappings) index() {
 	typed := make(map[string][]ordered)
 	for i, mp := range m.all {
 		// pre-compile object operations (sort fields for unordered ops, etc)
-		mp = precompile(mp)
+		mp = precompile(i, mp)
 
 		oop, _ := mp.Mapping()
 		if chk, ok := oop.(*opCheck); ok {
@@ -300,58 +342,171 @@ func (m *mappings) index() {
 }
 
 func (m mappings) Do(root nodes.Node) (nodes.Node, error) {
-	var errs []error
+	return m.doContext(nil, root)
+}
+
+// doContext runs the transformation on a subtree located at a given position in the tree.
+func (m mappings) doContext(ctx *nodeContext, root nodes.Node) (nodes.Node, error) {
+	var (
+		errs  []error
+		warns *[]Diagnostic
+	)
+	if m.diag != nil {
+		warns = new([]Diagnostic)
+	}
 	st := NewState()
-	nn, ok := nodes.Apply(root, func(old nodes.Node) (nodes.Node, bool) {
-		var maps []Mapping
-		if !optimizeCheck {
-			maps = m.all
-		} else {
-			maps = m.byKind[nodes.KindOf(old)]
-			switch old := old.(type) {
-			case nodes.Object:
-				if typ, ok := old[uast.KeyType].(nodes.String); ok {
-					if mp, ok := m.typedObj[string(typ)]; ok {
-						maps = mp
-					}
+	st.file = m.file
+	nn, ok := applyContext(root, ctx, func(ctx *nodeContext, old nodes.Node) (nodes.Node, bool) {
+		return m.applyNode(st, &errs, warns, ctx, old)
+	})
+	err := NewMultiError(errs...)
+	if err == nil {
+		err = st.Validate()
+	}
+	if err == nil {
+		m.warn(warns)
+	}
+	if ok {
+		return nn, err
+	}
+	return root, err
+}
+
+// doNode runs the transformation on a single node without visiting its children.
+func (m mappings) doNode(ctx *nodeContext, n nodes.Node) (nodes.Node, error) {
+	var (
+		errs  []error
+		warns *[]Diagnostic
+	)
+	if m.diag != nil {
+		warns = new([]Diagnostic)
+	}
+	st := NewState()
+	st.file = m.file
+	nn, ok := m.applyNode(st, &errs, warns, ctx, n)
+	err := NewMultiError(errs...)
+	if err == nil {
+		err = st.Validate()
+	}
+	if err == nil {
+		m.warn(warns)
+	}
+	if ok {
+		return nn, err
+	}
+	return n, err
+}
+
+// warn records warnings of a successful transformation to the diagnostics, if set.
+func (m mappings) warn(warns *[]Diagnostic) {
+	if warns == nil {
+		return
+	}
+	for _, w := range *warns {
+		m.diag.Add(w)
+	}
+}
+
+// applyNode applies all matching rules to a node. Errors are appended to errs, and warnings are appended to warns
+// if it is set.
+func (m mappings) applyNode(st *State, errs *[]error, warns *[]Diagnostic, ctx *nodeContext, old nodes.Node) (nodes.Node, bool) {
+	var maps []Mapping
+	if !optimizeCheck {
+		maps = m.all
+	} else {
+		maps = m.byKind[nodes.KindOf(old)]
+		switch old := old.(type) {
+		case nodes.Object:
+			if typ, ok := old[uast.KeyType].(nodes.String); ok {
+				if mp, ok := m.typedObj[string(typ)]; ok {
+					maps = mp
 				}
 			}
 		}
+	}
 
-		n := old
-		applied := false
-		for _, mp := range maps {
-			src, dst := mp.Mapping()
-			st.Reset()
-			if ok, err := src.Check(st, n); err != nil {
-				errs = append(errs, errCheck.Wrap(err))
-				continue
-			} else if !ok {
-				continue
-			}
-			applied = true
+	var tr *NodeTrace
+	if m.trace != nil && len(maps) != 0 {
+		tr = &NodeTrace{Input: old}
+	}
 
-			nn, err := dst.Construct(st, nil)
-			if err != nil {
-				errs = append(errs, errConstruct.Wrap(err))
-				continue
+	n := old
+	applied := false
+	for i, mp := range maps {
+		rule := RuleTrace{Index: i}
+		if r, ok := mp.(ruleMapping); ok {
+			rule.Index = r.ind
+		}
+		sample := m.prof.start()
+		nn, matched, err := applyRule(st, ctx, mp, n, warns)
+		m.prof.stop(rule.Index, sample, matched)
+		if err != nil {
+			*errs = append(*errs, err)
+			tr.add(rule, err)
+			if matched {
+				applied = true
 			}
-			n = nn
+			continue
+		} else if !matched {
+			tr.add(rule, nil)
+			continue
 		}
+		applied = true
+		rule.Applied = true
+		tr.add(rule, nil)
+		m.cov.addRule(rule.Index)
+		m.rev.check(rule.Index, ctx, m.file, mp, n, nn)
+		n = nn
+	}
+	if m.cov != nil {
+		m.cov.addNode(uast.TypeOf(old), applied)
+	}
+	if tr != nil {
+		tr.Output = n
+		m.trace.addNode(*tr)
+	}
 
-		if !applied {
-			return old, false
-		}
-		return n, true
-	})
-	err := NewMultiError(errs...)
-	if err == nil {
-		err = st.Validate()
+	if !applied {
+		return old, false
 	}
-	if ok {
-		return nn, err
+	return n, true
+}
+
+// applyRule checks a node with the source operation of a mapping and constructs a new node with the destination
+// operation. It returns false if the node does not match the rule. Warnings of a successfully applied rule are
+// appended to warns, if it is set.
+func applyRule(st *State, ctx *nodeContext, mp Mapping, n nodes.Node, warns *[]Diagnostic) (nodes.Node, bool, error) {
+	src, dst := mp.Mapping()
+	st.Reset()
+	st.ctx = ctx
+	var (
+		local []error
+		vars  []contextVar
+	)
+	if warns != nil {
+		st.warns = &local
 	}
-	return root, err
+	if st.file != nil {
+		st.fileSet = &vars
+	}
+	if ok, err := src.Check(st, n); err != nil {
+		return nil, false, errCheck.Wrap(err)
+	} else if !ok {
+		return nil, false, nil
+	}
+	nn, err := dst.Construct(st, nil)
+	if err != nil {
+		return nil, true, errConstruct.Wrap(err)
+	}
+	for _, v := range vars {
+		st.file.Set(v.name, v.val)
+	}
+	for _, err := range local {
+		d := newDiagnostic(n, err)
+		d.Warning = true
+		*warns = append(*warns, d)
+	}
+	return nn, true, nil
 }
 
 // NewState creates a new state for Ops to work on.
@@ -369,13 +524,37 @@ type State struct {
 	vars   Vars
 	unused map[string]struct{}
 	states map[string][]*State
+	ctx    *nodeContext // position of the current node in the source tree
+	warns  *[]error     // optional; collects warnings of the current rule
+
+	file    *Context      // optional; transformation context shared by all rules, preserved by Reset
+	fileSet *[]contextVar // optional; context variables set by the current rule
 }
 
-// Reset clears the state and allows to reuse an object.
+// Reset clears the state and allows to reuse an object. The transformation context is preserved.
 func (st *State) Reset() {
 	st.vars = nil
 	st.unused = nil
 	st.states = nil
+	st.ctx = nil
+	st.warns = nil
+	st.fileSet = nil
+}
+
+// newSub creates an empty state for a sub-operation that shares the position of the current node, warnings and
+// the transformation context with the current state.
+func (st *State) newSub() *State {
+	return &State{ctx: st.ctx, warns: st.warns, file: st.file, fileSet: st.fileSet}
+}
+
+// Warn records a non-fatal error for the current node. Warnings are only reported if the rule is applied
+// successfully. Mappings record them only if diagnostics are set with WithWarnings.
+//
+// Warnings are ignored if the operation is not executed by Mappings.
+func (st *State) Warn(err error) {
+	if st.warns != nil {
+		*st.warns = append(*st.warns, err)
+	}
 }
 
 // Validate should be called after a successful transformation to check if there are any errors related to unused state.
@@ -395,6 +574,9 @@ func (st *State) Validate() error {
 // To merge a cloned state back use ApplyFrom on a parent state.
 func (st *State) Clone() *State {
 	st2 := NewState()
+	st2.ctx = st.ctx
+	st2.warns = st.warns
+	st2.file, st2.fileSet = st.file, st.fileSet
 	if len(st.vars) != 0 {
 		st2.vars = make(Vars)
 		st2.unused = make(map[string]struct{})
@@ -1,11 +1,23 @@
 package transformer
 
 import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"math"
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	"gopkg.in/src-d/go-errors.v1"
+
 	u "gopkg.in/bblfsh/sdk.v2/uast"
 	un "gopkg.in/bblfsh/sdk.v2/uast/nodes"
+	"gopkg.in/bblfsh/sdk.v2/uast/query/xpath"
 	"gopkg.in/bblfsh/sdk.v2/uast/role"
 )
 
@@ -201,6 +213,107 @@ var mappingCases = []struct {
 			}),
 		},
 	},
+	{
+		name: "token keys",
+		inp: un.Array{
+			un.Object{"raw": un.String("0x1"), "value": un.String("1")},
+			un.Object{"value": un.String("2")},
+			un.Object{"other": un.String("3")},
+		},
+		m: Mappings(
+			ObjectToNode{
+				TokenKeys: []string{"raw", "value"},
+			}.Mapping(),
+		),
+		exp: un.Array{
+			un.Object{u.KeyToken: un.String("0x1")},
+			un.Object{u.KeyToken: un.String("2")},
+			un.Object{"other": un.String("3")},
+		},
+	},
+	{
+		name: "token combine",
+		inp:  un.Object{"raw": un.String("0x1"), "value": un.String("1")},
+		m: Mappings(
+			ObjectToNode{
+				TokenKeys: []string{"raw", "value"},
+				TokenCombine: func(tokens map[string]un.Value) (un.Value, error) {
+					return tokens["raw"].(un.String) + "=" + tokens["value"].(un.String), nil
+				},
+			}.Mapping(),
+		),
+		exp: un.Object{u.KeyToken: un.String("0x1=1")},
+	},
+	{
+		name: "nested keys",
+		inp: un.Object{
+			"kind": un.String("Ident"),
+			"loc": un.Object{
+				"start":  un.Object{"line": un.Uint(5), "col": un.Uint(3)},
+				"source": un.String("a.go"),
+			},
+		},
+		m: Mappings(
+			ObjectToNode{
+				InternalTypeKey: "kind",
+				LineKey:         "loc.start.line", ColumnKey: "loc.start.col",
+			}.Mapping(),
+		),
+		exp: un.Object{
+			u.KeyType: un.String("Ident"),
+			u.KeyPos: toNode(u.Positions{
+				u.KeyStart: {
+					Line: 5, Col: 3,
+				},
+			}),
+			"loc": un.Object{"source": un.String("a.go")},
+		},
+	},
+	{
+		name: "key decoders",
+		inp: un.Object{
+			"pos": un.String("5:3"),
+		},
+		m: Mappings(
+			ObjectToNode{
+				LineKey: "pos.line", ColumnKey: "pos.col",
+				Decoders: map[string]func(v un.Node) (un.Node, error){
+					"pos": func(v un.Node) (un.Node, error) {
+						var line, col uint32
+						if _, err := fmt.Sscanf(string(v.(un.String)), "%d:%d", &line, &col); err != nil {
+							return nil, err
+						}
+						return un.Object{"line": un.Uint(line), "col": un.Uint(col)}, nil
+					},
+				},
+			}.Mapping(),
+		),
+		exp: un.Object{
+			u.KeyPos: toNode(u.Positions{
+				u.KeyStart: {
+					Line: 5, Col: 3,
+				},
+			}),
+		},
+	},
+	{
+		name: "key decoder error",
+		inp: un.Object{
+			"token": un.String("%"),
+		},
+		m: Mappings(
+			ObjectToNode{
+				TokenKeys: []string{"token"},
+				Decoders: map[string]func(v un.Node) (un.Node, error){
+					"token": func(v un.Node) (un.Node, error) {
+						b, err := base64.StdEncoding.DecodeString(string(v.(un.String)))
+						return un.String(b), err
+					},
+				},
+			}.Mapping(),
+		),
+		err: `check: key "token": illegal base64 data at input byte 0`,
+	},
 	{
 		name: "semantic comment",
 		inp: un.Object{
@@ -268,6 +381,89 @@ var mappingCases = []struct {
 		)),
 		err: `variables ["x"] unused in the second part of the transform`,
 	},
+	{
+		name: "parent and sibling",
+		inp: un.Object{
+			u.KeyType: un.String("Call"),
+			"args": un.Array{
+				un.Object{u.KeyType: un.String("Ident"), "name": un.String("a")},
+				un.Object{u.KeyType: un.String("Ident"), "name": un.String("b")},
+			},
+			"func": un.Object{u.KeyType: un.String("Ident"), "name": un.String("f")},
+		},
+		m: Mappings(
+			Map(
+				Check(Parent(HasType("Call")), Check(FirstElem(),
+					Part("_", Obj{u.KeyType: String("Ident")}),
+				)),
+				Part("_", Obj{u.KeyType: String("Ident"), "first": Bool(true)}),
+			),
+			Map(
+				Check(PrevSibling(Has{"name": String("a")}),
+					Part("_", Obj{u.KeyType: String("Ident")}),
+				),
+				Part("_", Obj{u.KeyType: String("Ident"), "after_a": Bool(true)}),
+			),
+		),
+		exp: un.Object{
+			u.KeyType: un.String("Call"),
+			"args": un.Array{
+				un.Object{u.KeyType: un.String("Ident"), "name": un.String("a"), "first": un.Bool(true)},
+				un.Object{u.KeyType: un.String("Ident"), "name": un.String("b"), "after_a": un.Bool(true)},
+			},
+			"func": un.Object{u.KeyType: un.String("Ident"), "name": un.String("f")},
+		},
+	},
+	{
+		name: "parent in scope",
+		inp: un.Object{
+			u.KeyType: un.String("Call"),
+			"args": un.Array{
+				un.Object{u.KeyType: un.String("Ident"), "name": un.String("a")},
+			},
+		},
+		m: Mappings(
+			Scope("s", Map(
+				Check(Parent(HasType("Call")),
+					Obj{u.KeyType: String("Ident"), "name": Var("name")},
+				),
+				Obj{u.KeyType: String("Arg"), "name": Var("name")},
+			)),
+		),
+		exp: un.Object{
+			u.KeyType: un.String("Call"),
+			"args": un.Array{
+				un.Object{u.KeyType: un.String("Arg"), "name": un.String("a")},
+			},
+		},
+	},
+	{
+		name: "ancestor",
+		inp: un.Object{
+			u.KeyType: un.String("Func"),
+			"body": un.Array{
+				un.Object{
+					u.KeyType: un.String("Block"),
+					"stmt":    un.Object{u.KeyType: un.String("Return")},
+				},
+			},
+		},
+		m: Mappings(
+			Map(
+				Check(Ancestor(HasType("Func")), Obj{u.KeyType: String("Return")}),
+				Obj{u.KeyType: String("Return"), "in_func": Bool(true)},
+			),
+		),
+		exp: un.Object{
+			u.KeyType: un.String("Func"),
+			"body": un.Array{
+				un.Object{
+					u.KeyType: un.String("Block"),
+					"stmt":    un.Object{u.KeyType: un.String("Return"), "in_func": un.Bool(true)},
+				},
+			},
+		},
+	},
 }
 
 func TestMappings(t *testing.T) {
@@ -290,3 +486,556 @@ func TestMappings(t *testing.T) {
 		})
 	}
 }
+
+func TestTraceMappings(t *testing.T) {
+	var tr Trace
+	m := TraceMappings(&tr,
+		Map(
+			Obj{u.KeyType: String("A"), "v": Var("x")},
+			Obj{u.KeyType: String("B"), "v": Var("x")},
+		),
+		Map(
+			Obj{u.KeyType: String("C")},
+			Obj{u.KeyType: String("D")},
+		),
+	)
+	inp := un.Array{
+		un.Object{u.KeyType: un.String("A"), "v": un.Int(1)},
+		un.Object{u.KeyType: un.String("C"), "v": un.Int(2)},
+	}
+	out, err := m.Do(inp)
+	require.Error(t, err)
+	require.Equal(t, un.Array{
+		un.Object{u.KeyType: un.String("B"), "v": un.Int(1)},
+		un.Object{u.KeyType: un.String("C"), "v": un.Int(2)},
+	}, out)
+
+	var applied, failed int
+	for _, n := range tr.Nodes {
+		if u.TypeOf(n.Input) == "A" {
+			require.Equal(t, []int{0}, n.Applied())
+			applied++
+		}
+		if u.TypeOf(n.Input) == "C" {
+			require.Len(t, n.Rules, 1)
+			require.Equal(t, 1, n.Rules[0].Index)
+			require.Error(t, n.Rules[0].Err)
+			failed++
+		}
+	}
+	require.Equal(t, 1, applied)
+	require.Equal(t, 1, failed)
+	require.Len(t, tr.Unmatched(), len(tr.Nodes)-1)
+}
+
+func TestCoverage(t *testing.T) {
+	m, cov := WithCoverage(Mappings(
+		Map(
+			Obj{u.KeyType: String("A")},
+			Obj{u.KeyType: String("B")},
+		),
+		Map(
+			Obj{u.KeyType: String("C")},
+			Obj{u.KeyType: String("D")},
+		),
+	))
+	require.NotNil(t, cov)
+
+	_, err := m.Do(un.Array{
+		un.Object{u.KeyType: un.String("A")},
+		un.Object{u.KeyType: un.String("A")},
+		un.Object{u.KeyType: un.String("E")},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []int{2, 0}, cov.Hits())
+	require.Equal(t, []int{1}, cov.Unused())
+	require.Equal(t, []string{"E"}, cov.NeverMatched())
+
+	_, cov = WithCoverage(RolesDedup())
+	require.Nil(t, cov)
+}
+
+func TestLookupWarn(t *testing.T) {
+	m := Mappings(
+		Map(
+			Obj{u.KeyType: String("A"), "op": LookupWarn(Var("op"), map[un.Value]un.Value{
+				un.String("+"): un.String("add"),
+			})},
+			Obj{u.KeyType: String("B"), "op": Var("op")},
+		),
+	)
+	inp := un.Array{
+		un.Object{u.KeyType: un.String("A"), "op": un.String("+")},
+		un.Object{u.KeyType: un.String("A"), "op": un.String("**")},
+	}
+	exp := un.Array{
+		un.Object{u.KeyType: un.String("B"), "op": un.String("add")},
+		un.Object{u.KeyType: un.String("B"), "op": un.String("**")},
+	}
+	// warnings are ignored by default
+	out, err := m.Do(inp)
+	require.NoError(t, err)
+	require.Equal(t, exp, out)
+
+	var diag Diagnostics
+	out, err = WithWarnings(m, &diag).Do(inp)
+	require.NoError(t, err)
+	require.Equal(t, exp, out)
+	warns := diag.List()
+	require.Len(t, warns, 1)
+	require.True(t, warns[0].Warning)
+	require.Equal(t, "A", warns[0].Type)
+	require.True(t, ErrUnhandledValueIn.Is(warns[0].Err))
+
+	// warnings are not recorded if the transformation fails
+	var diag2 Diagnostics
+	_, err = WithWarnings(m, &diag2).Do(un.Array{
+		un.Object{u.KeyType: un.String("A"), "op": un.String("**"), "extra": un.Int(1)},
+	})
+	require.Error(t, err)
+	require.Len(t, diag2.List(), 0)
+}
+
+func TestContext(t *testing.T) {
+	pragmas := Mappings(
+		Map(
+			Obj{u.KeyType: String("Pragma"), "name": SaveContext("dialect", Var("name"))},
+			Obj{u.KeyType: String("Pragma"), "name": Var("name")},
+		),
+	)
+	stmts := Mappings(
+		Map(
+			Check(ContextIs("dialect", String("py2")), Obj{u.KeyType: String("Print")}),
+			Obj{u.KeyType: String("PrintStmt")},
+		),
+	)
+	inp := un.Array{
+		un.Object{u.KeyType: un.String("Pragma"), "name": un.String("py2")},
+		un.Object{u.KeyType: un.String("Print")},
+	}
+
+	_, err := pragmas.Do(inp)
+	require.True(t, ErrNoContext.Is(err), "%v", err)
+
+	out, err := stmts.Do(inp)
+	require.NoError(t, err)
+	require.Equal(t, inp, out)
+
+	c := NewContext()
+	out, err = WithContext(pragmas, c).Do(inp)
+	require.NoError(t, err)
+	v, ok := c.Get("dialect")
+	require.True(t, ok)
+	require.Equal(t, un.String("py2"), v)
+
+	out, err = WithContext(stmts, c).Do(out)
+	require.NoError(t, err)
+	require.Equal(t, un.Array{
+		un.Object{u.KeyType: un.String("Pragma"), "name": un.String("py2")},
+		un.Object{u.KeyType: un.String("PrintStmt")},
+	}, out)
+}
+
+func TestReverseCheck(t *testing.T) {
+	m, rev := WithReverseCheck(Mappings(
+		Map(
+			Obj{u.KeyType: String("A"), "v": Var("x")},
+			Obj{u.KeyType: String("B"), "v": Var("x")},
+		),
+		Map(
+			Obj{u.KeyType: String("C"), "v": Quote(Var("x"))},
+			Obj{u.KeyType: String("D"), "v": Var("x")},
+		),
+	))
+	require.NotNil(t, rev)
+
+	// raw strings are quoted with double quotes on reversal
+	inp := un.Object{u.KeyType: un.String("C"), "v": un.String("`Foo`")}
+	_, err := m.Do(un.Array{
+		un.Object{u.KeyType: un.String("A"), "v": un.String("Foo")},
+		inp,
+		un.Object{u.KeyType: un.String("C"), "v": un.String(`"Bar"`)},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []int{1, 2}, rev.Checked())
+
+	divs := rev.Divergences()
+	require.Len(t, divs, 1)
+	require.Equal(t, 1, divs[0].Rule)
+	require.Equal(t, ".v", divs[0].Path)
+	require.Equal(t, inp, divs[0].Input)
+	require.NoError(t, divs[0].Err)
+
+	_, rev = WithReverseCheck(RolesDedup())
+	require.Nil(t, rev)
+}
+
+var validateCases = []struct {
+	name string
+	maps []Mapping
+	errs []*errors.Kind
+}{
+	{
+		name: "valid",
+		maps: []Mapping{
+			Map(
+				Part("_", Obj{u.KeyType: String("A"), "v": Var("x")}),
+				Part("_", Obj{u.KeyType: String("B"), "n": Var("x")}),
+			),
+			Map(
+				Obj{u.KeyType: String("C"), "v": Opt("exists", Var("x"))},
+				Obj{u.KeyType: String("D"), "v": Opt("exists", Var("x"))},
+			),
+		},
+	},
+	{
+		name: "undefined and unused",
+		maps: []Mapping{
+			Map(
+				Obj{u.KeyType: String("A"), "v": Var("x")},
+				Obj{u.KeyType: String("B"), "v": Var("y")},
+			),
+		},
+		errs: []*errors.Kind{ErrVariableNotDefined, ErrVariableUnused},
+	},
+	{
+		name: "opaque",
+		maps: []Mapping{
+			Map(
+				Obj{u.KeyType: String("A"), "v": Each("arr", Var("x"))},
+				Obj{u.KeyType: String("B"), "v": Var("arr")},
+			),
+		},
+	},
+	{
+		name: "kinds",
+		maps: []Mapping{
+			Map(
+				Obj{"v": Lower("orig", Var("x"))},
+				Obj{"v": VarKind("x", un.KindInt), "orig": Var("orig")},
+			),
+		},
+		errs: []*errors.Kind{ErrVariableKinds},
+	},
+	{
+		name: "duplicate field",
+		maps: []Mapping{
+			Map(
+				Fields{{Name: "a", Op: Var("x")}, {Name: "a", Op: Var("y")}},
+				Obj{"a": Var("x"), "b": Var("y")},
+			),
+		},
+		errs: []*errors.Kind{ErrDuplicateField},
+	},
+	{
+		name: "shadowed",
+		maps: []Mapping{
+			Map(
+				Obj{u.KeyType: String("A")},
+				Obj{u.KeyType: String("B")},
+			),
+			Map(
+				Obj{u.KeyType: String("A")},
+				Obj{u.KeyType: String("C")},
+			),
+		},
+		errs: []*errors.Kind{ErrRuleShadowed},
+	},
+}
+
+func TestValidateMappings(t *testing.T) {
+	for _, c := range validateCases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			err := ValidateMappings(c.maps...)
+			if len(c.errs) == 0 {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			errs := []error{err}
+			if e, ok := err.(*MultiError); ok {
+				errs = e.Errs
+			}
+			require.Len(t, errs, len(c.errs), "%v", err)
+			for i, kind := range c.errs {
+				require.True(t, errMapping.Is(errs[i]))
+				require.True(t, kind.Is(errs[i].(*errors.Error).Cause()), "%v", errs[i])
+			}
+		})
+	}
+}
+
+func TestProfile(t *testing.T) {
+	m, prof := WithProfile(Mappings(
+		Map(
+			Obj{u.KeyType: String("A")},
+			Obj{u.KeyType: String("B")},
+		),
+		Map(
+			Obj{u.KeyType: String("C")},
+			Obj{u.KeyType: String("D")},
+		),
+	), true)
+	require.NotNil(t, prof)
+
+	_, err := m.Do(un.Array{
+		un.Object{u.KeyType: un.String("A")},
+		un.Object{u.KeyType: un.String("C")},
+		un.Object{u.KeyType: un.String("E")},
+	})
+	require.NoError(t, err)
+
+	rules := prof.Rules()
+	require.Len(t, rules, 2)
+	for _, r := range rules {
+		require.Equal(t, 2, r.Calls) // typed node and the node with unknown type
+		require.Equal(t, 1, r.Matched)
+		require.True(t, r.Allocs > 0)
+	}
+	require.True(t, prof.Total() > 0)
+}
+
+func TestGenerateGo(t *testing.T) {
+	maps := []Mapping{
+		Map(
+			Part("rest", Obj{u.KeyType: String("A"), "v": Var("x")}),
+			Part("rest", Obj{u.KeyType: String("B"), "arr": Arr(Var("x"), Int(1))}),
+		),
+		Map(
+			Obj{u.KeyType: String("Inf"), "v": Is(un.Float(math.Inf(1)))},
+			Obj{u.KeyType: String("NaN"), "v": Is(un.Float(math.NaN()))},
+		),
+	}
+	buf := bytes.NewBuffer(nil)
+	err := GenerateGo(buf, "gen", "compiled", maps...)
+	require.NoError(t, err)
+
+	// make sure the generated code compiles
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "gen.go", buf.Bytes(), 0)
+	require.NoError(t, err)
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	_, err = conf.Check("gen", fset, []*ast.File{f}, nil)
+	require.NoError(t, err)
+
+	err = GenerateGo(buf, "gen", "compiled", Map(Obj{"v": Var("x")}, Obj{"v": Var("y")}))
+	require.True(t, ErrVariableNotDefined.Is(err), "%v", err)
+
+	err = GenerateGo(buf, "gen", "compiled", Map(Obj{"v": Quote(Var("x"))}, Obj{"v": Var("x")}))
+	require.True(t, ErrNotCompilable.Is(err), "%v", err)
+}
+
+func TestParallel(t *testing.T) {
+	m := Mappings(
+		Map(
+			Obj{u.KeyType: String("A"), "v": Var("x")},
+			Obj{u.KeyType: String("B"), "v": Var("x")},
+		),
+		Map(
+			Check(PrevSibling(HasType("A")), Obj{u.KeyType: String("C")}),
+			Obj{u.KeyType: String("D")},
+		),
+	)
+	inp := un.Object{
+		u.KeyType: un.String("File"),
+		"decls":   un.Array{},
+	}
+	var arr un.Array
+	for i := 0; i < 100; i++ {
+		arr = append(arr,
+			un.Object{u.KeyType: un.String("A"), "v": un.Int(i)},
+			un.Object{u.KeyType: un.String("C")},
+		)
+	}
+	inp["decls"] = arr
+
+	exp, err := m.Do(inp)
+	require.NoError(t, err)
+
+	out, err := Parallel(m, 4, 2).Do(inp)
+	require.NoError(t, err)
+	require.Equal(t, exp, out)
+	require.Equal(t, un.String("D"), out.(un.Object)["decls"].(un.Array)[1].(un.Object)[u.KeyType])
+}
+
+func TestParallelContext(t *testing.T) {
+	m := Mappings(
+		Map(
+			Seq(Obj{u.KeyType: String("A")}, SetContext("seen", un.Bool(true))),
+			Obj{u.KeyType: String("B")},
+		),
+		Map(
+			Check(ContextIs("seen", Is(un.Bool(true))), Obj{u.KeyType: String("C")}),
+			Obj{u.KeyType: String("D")},
+		),
+	)
+	_, ok := Parallel(m, 4, 2).(*parallel)
+	require.True(t, ok, "mappings without a context can be parallelized")
+
+	mc := WithContext(m, NewContext())
+	_, ok = Parallel(mc, 4, 2).(*parallel)
+	require.False(t, ok, "mappings that use the context must run sequentially")
+
+	mc = WithContext(Mappings(Map(
+		Obj{u.KeyType: String("A"), "v": Var("x")},
+		Obj{u.KeyType: String("B"), "v": Var("x")},
+	)), NewContext())
+	_, ok = Parallel(mc, 4, 2).(*parallel)
+	require.True(t, ok, "mappings that do not use the context can be parallelized")
+}
+
+func TestMemoize(t *testing.T) {
+	m := Mappings(
+		Map(
+			Obj{u.KeyType: String("A"), "v": Var("x")},
+			Obj{u.KeyType: String("B"), "v": Var("x")},
+		),
+	)
+	inp := un.Array{
+		un.Object{u.KeyType: un.String("A"), "v": un.Int(1)},
+		un.Object{u.KeyType: un.String("A"), "v": un.Int(1)},
+		un.Object{u.KeyType: un.String("A"), "v": un.Int(2)},
+	}
+	exp, err := m.Do(inp)
+	require.NoError(t, err)
+
+	c := NewCache(0)
+	mm := Memoize(m, c)
+	out, err := mm.Do(inp)
+	require.NoError(t, err)
+	require.Equal(t, exp, out)
+	require.Equal(t, 2, c.Len())
+	require.Equal(t, uint64(1), c.Hits())
+
+	out, err = mm.Do(inp)
+	require.NoError(t, err)
+	require.Equal(t, exp, out)
+	require.Equal(t, uint64(4), c.Hits())
+}
+
+func TestTolerant(t *testing.T) {
+	m := Mappings(
+		Map(
+			Obj{u.KeyType: String("A"), "v": Var("x")},
+			Obj{u.KeyType: String("B"), "v": Var("y")},
+		),
+		Map(
+			Obj{u.KeyType: String("C")},
+			Obj{u.KeyType: String("D")},
+		),
+	)
+	inp := un.Array{
+		un.Object{u.KeyType: un.String("A"), "v": un.Int(1)},
+		un.Object{u.KeyType: un.String("C")},
+	}
+	_, err := m.Do(inp)
+	require.Error(t, err)
+
+	var diag Diagnostics
+	out, err := Tolerant(m, &diag).Do(inp)
+	require.NoError(t, err)
+	require.Equal(t, un.Array{
+		un.Object{u.KeyType: un.String("A"), "v": un.Int(1)},
+		un.Object{u.KeyType: un.String("D")},
+	}, out)
+	list := diag.List()
+	require.Len(t, list, 1)
+	require.Equal(t, "A", list[0].Type)
+	require.True(t, ErrVariableNotDefined.Is(list[0].Err))
+
+	tr := TransformFunc(func(n un.Node) (un.Node, bool, error) {
+		return nil, false, ErrExpectedObject.New(n)
+	})
+	_, err = Tolerant(tr, &diag).Do(inp)
+	require.NoError(t, err)
+	require.Len(t, diag.List(), 1+6) // one for each node in the tree
+}
+
+func TestBestEffort(t *testing.T) {
+	errs := []error{ErrExpectedObject.New(nil), ErrExpectedList.New(nil)}
+	out := un.Object{u.KeyType: un.String("B")}
+	tr := transformFunc(func(n un.Node) (un.Node, error) {
+		return out, NewMultiError(errs...)
+	})
+	var diag Diagnostics
+	got, err := BestEffort(tr, &diag).Do(un.Object{u.KeyType: un.String("A")})
+	require.NoError(t, err)
+	require.Equal(t, out, got)
+	require.Len(t, diag.List(), 2)
+}
+
+func TestQuery(t *testing.T) {
+	ident := func(name string) un.Object {
+		return un.Object{u.KeyType: un.String("Ident"), u.KeyToken: un.String(name)}
+	}
+	inp := un.Array{
+		un.Object{u.KeyType: un.String("Call"), "fn": ident("print")},
+		ident("x"),
+	}
+
+	q, err := xpath.New().Prepare("//Call/fn/Ident")
+	require.NoError(t, err)
+	out, err := AnnotateQuery(q, role.Callee, role.Identifier).Do(inp)
+	require.NoError(t, err)
+	callee := ident("print")
+	callee[u.KeyRoles] = u.RoleList(role.Callee, role.Identifier)
+	require.Equal(t, un.Array{
+		un.Object{u.KeyType: un.String("Call"), "fn": callee},
+		ident("x"),
+	}, out)
+
+	// roles should not be duplicated
+	out2, err := AnnotateQuery(q, role.Identifier).Do(out)
+	require.NoError(t, err)
+	require.Equal(t, out, out2)
+
+	q, err = xpath.New().Prepare("//Ident[text() = 'x']")
+	require.NoError(t, err)
+	out, err = QueryMapping(q, Map(
+		Part("other", Obj{u.KeyType: String("Ident")}),
+		Part("other", Obj{u.KeyType: String("Var")}),
+	)).Do(inp)
+	require.NoError(t, err)
+	require.Equal(t, un.Array{
+		un.Object{u.KeyType: un.String("Call"), "fn": ident("print")},
+		un.Object{u.KeyType: un.String("Var"), u.KeyToken: un.String("x")},
+	}, out)
+}
+
+func TestSkipSubtrees(t *testing.T) {
+	m := Mappings(Map(
+		Obj{u.KeyType: String("A"), "v": Var("x")},
+		Obj{u.KeyType: String("B"), "v": Var("x")},
+	))
+	node := func(typ string, v int) un.Object {
+		return un.Object{u.KeyType: un.String(typ), "v": un.Int(v)}
+	}
+	inp := un.Object{
+		u.KeyType: un.String("A"),
+		"v":       un.Array{node("A", 1), node("A", 2)},
+	}
+	skip := func(n un.Node) bool {
+		obj, ok := n.(un.Object)
+		return ok && obj["v"] == un.Int(2)
+	}
+	tr, ok := SkipSubtrees(m, 2, skip)
+	require.True(t, ok)
+	out, err := tr.Do(inp)
+	require.NoError(t, err)
+	require.Equal(t, un.Object{
+		u.KeyType: un.String("B"),
+		"v":       un.Array{node("B", 1), node("A", 2)},
+	}, out)
+
+	_, ok = SkipSubtrees(transformFunc(func(n un.Node) (un.Node, error) {
+		return n, nil
+	}), 2, skip)
+	require.False(t, ok)
+}
+
+type transformFunc func(n un.Node) (un.Node, error)
+
+func (f transformFunc) Do(n un.Node) (un.Node, error) {
+	return f(n)
+}
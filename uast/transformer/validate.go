@@ -0,0 +1,231 @@
+package transformer
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+
+	"gopkg.in/src-d/go-errors.v1"
+
+	"gopkg.in/bblfsh/sdk.v2/uast"
+	"gopkg.in/bblfsh/sdk.v2/uast/nodes"
+)
+
+var (
+	// ErrVariableKinds is returned by Validate when a variable is used in positions that expect incompatible
+	// node kinds, thus the mapping can never succeed.
+	ErrVariableKinds = errors.NewKind("variable %q has incompatible kinds: %v vs %v")
+	// ErrRuleShadowed is returned by Validate when a rule can never be applied, because all nodes it matches are
+	// already changed by an earlier rule.
+	ErrRuleShadowed = errors.NewKind("rule is shadowed by rule %d")
+)
+
+// Validate checks mapping rules of a transformer for errors that can be detected without running the transformation.
+// It works only for transformers created with Mappings, and returns nil for all other types.
+// See ValidateMappings for details.
+func Validate(t Transformer) error {
+	m, ok := t.(mappings)
+	if !ok {
+		return nil
+	}
+	return ValidateMappings(m.all...)
+}
+
+// ValidateMappings checks mapping rules for errors that can be detected without running the transformation:
+//
+//  * variables that are used but never defined, or defined and never used;
+//  * variables that are bound in positions with incompatible node kinds;
+//  * duplicate fields in Fields operations;
+//  * rules that are shadowed by earlier rules with the same source operation.
+//
+// Only a subset of operations can be analyzed, and rules with other operations are checked partially. Thus, the
+// validation never reports an error for a valid rule, but may miss some errors.
+func ValidateMappings(maps ...Mapping) error {
+	var errs []error
+	for i, m := range maps {
+		src, dst := m.Mapping()
+		for _, err := range validateRule(src, dst) {
+			errs = append(errs, errMapping.Wrap(err, strconv.Itoa(i)))
+		}
+		for j := 0; j < i; j++ {
+			if shadows(maps[j], m) {
+				errs = append(errs, errMapping.Wrap(ErrRuleShadowed.New(j), strconv.Itoa(i)))
+				break
+			}
+		}
+	}
+	return NewMultiError(errs...)
+}
+
+// opVars collects variables used by an operation and kinds of nodes the variables are bound to.
+type opVars struct {
+	vars   map[string]nodes.Kind
+	errs   []error
+	opaque bool // the operation contains operations that cannot be analyzed
+}
+
+func collectVars(op Op) *opVars {
+	v := &opVars{vars: make(map[string]nodes.Kind)}
+	v.walk(op, nodes.KindsAny)
+	return v
+}
+
+func (v *opVars) add(name string, kinds nodes.Kind) {
+	if prev, ok := v.vars[name]; ok {
+		if prev&kinds == 0 {
+			v.errs = append(v.errs, ErrVariableKinds.New(name, prev, kinds))
+		}
+		kinds &= prev
+	}
+	v.vars[name] = kinds
+}
+
+func (v *opVars) walk(op Op, kinds nodes.Kind) {
+	switch op := op.(type) {
+	case nil, opIs:
+	case opVar:
+		v.add(op.name, kinds&op.kinds)
+	case *opCheck:
+		v.walk(op.op, kinds&op.sel.Kinds())
+	case Obj:
+		v.walkFields(op.fields())
+	case Fields:
+		seen := make(map[string]struct{}, len(op))
+		for _, f := range op {
+			if _, ok := seen[f.Name]; ok {
+				v.errs = append(v.errs, ErrDuplicateField.New(f.Name))
+			}
+			seen[f.Name] = struct{}{}
+		}
+		v.walkFields(op)
+	case *opPartialObj:
+		v.walk(op.op, nodes.KindObject)
+		v.add(op.vr, nodes.KindObject)
+	case opArr:
+		for _, sub := range op {
+			v.walk(sub, nodes.KindsAny)
+		}
+	case opSeq:
+		for _, sub := range op {
+			v.walk(sub, kinds)
+		}
+	case opAnyNode:
+		if sub, ok := op.create.(Op); ok {
+			v.walk(sub, kinds)
+		} else {
+			v.opaque = true
+		}
+	case *opValueConv:
+		v.walk(op.op, kinds&op.kinds)
+	case *opCase:
+		v.add(op.vr, nodes.KindString)
+		v.walk(op.op, nodes.KindString)
+	case *opNotEmpty:
+		v.walk(op.op, kinds&nodes.KindsNotNil)
+	case *opUnwrap:
+		v.walk(op.op, nodes.KindsAny)
+	case *opMerge:
+		v.walk(op.op, nodes.KindObject)
+	case *opSaveContext:
+		v.walk(op.op, kinds)
+	case *opSetContext:
+	case *opSpanPos:
+		v.walk(op.op, kinds)
+	case *opNativeObj:
+		v.walk(op.op, nodes.KindObject)
+	case *opOptional:
+		v.add(op.vr, nodes.KindBool)
+		v.walk(op.op, kinds)
+	case *opMaybe:
+		v.walk(op.op, kinds)
+	default:
+		v.opaque = true
+	}
+}
+
+func (v *opVars) walkFields(fields Fields) {
+	for _, f := range fields {
+		if f.Optional != "" {
+			v.add(f.Optional, nodes.KindBool)
+		}
+		v.walk(f.Op, nodes.KindsAny)
+	}
+}
+
+// validateRule checks variables defined by the source operation of a rule and used by the destination operation.
+func validateRule(src, dst Op) []error {
+	sv, dv := collectVars(src), collectVars(dst)
+	errs := append(sv.errs, dv.errs...)
+
+	names := make([]string, 0, len(sv.vars)+len(dv.vars))
+	for name := range sv.vars {
+		names = append(names, name)
+	}
+	for name := range dv.vars {
+		if _, ok := sv.vars[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var unused []string
+	for _, name := range names {
+		sk, inSrc := sv.vars[name]
+		dk, inDst := dv.vars[name]
+		switch {
+		case inSrc && inDst:
+			if sk&dk == 0 {
+				errs = append(errs, ErrVariableKinds.New(name, sk, dk))
+			}
+		case inDst && !sv.opaque:
+			errs = append(errs, ErrVariableNotDefined.New(name))
+		case inSrc && !dv.opaque:
+			unused = append(unused, name)
+		}
+	}
+	if len(unused) != 0 {
+		errs = append(errs, ErrVariableUnused.New(unused))
+	}
+	return errs
+}
+
+// shadows checks if an earlier rule prev always prevents the rule m from being applied. This happens when both rules
+// have the same source operation, and prev changes the type of the node.
+func shadows(prev, m Mapping) bool {
+	psrc, pdst := prev.Mapping()
+	src, _ := m.Mapping()
+	if !reflect.DeepEqual(psrc, src) {
+		return false
+	}
+	styp, ok1 := opType(src)
+	dtyp, ok2 := opType(pdst)
+	return ok1 && ok2 && styp != dtyp
+}
+
+// opType returns a constant type of an object operation, if any.
+func opType(op Op) (string, bool) {
+	var fields Fields
+	switch op := op.(type) {
+	case Obj:
+		fields = op.fields()
+	case Fields:
+		fields = op
+	case *opPartialObj:
+		return opType(op.op)
+	case *opCheck:
+		return opType(op.op)
+	default:
+		return "", false
+	}
+	for _, f := range fields {
+		if f.Name != uast.KeyType || f.Optional != "" {
+			continue
+		}
+		if is, ok := f.Op.(opIs); ok {
+			if typ, ok := is.n.(nodes.String); ok {
+				return string(typ), true
+			}
+		}
+	}
+	return "", false
+}
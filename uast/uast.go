@@ -45,6 +45,7 @@ func init() {
 		Argument{},
 		FunctionType{},
 		Function{},
+		Error{},
 	)
 }
 
@@ -608,3 +609,13 @@ type Bool struct {
 	GenNode
 	Value bool `json:"Value"`
 }
+
+// Error is a part of the source that the parser failed to parse. Drivers that support error recovery wrap
+// unparseable regions of the file into this node, so the rest of the tree can still be used.
+type Error struct {
+	GenNode
+	// Message is an optional description of the syntax error.
+	Message string `json:"Message"`
+	// Native is an optional native AST node that the parser returned for the region.
+	Native Any `json:"Native"`
+}